@@ -244,6 +244,38 @@ type VirtualHost struct {
 	// The policy for rate limiting on the virtual host.
 	// +optional
 	RateLimitPolicy *RateLimitPolicy `json:"rateLimitPolicy,omitempty"`
+	// The policy for replacing the responses that Envoy generates
+	// itself, such as "no healthy upstream", with custom error pages.
+	// +optional
+	LocalReplyPolicy *LocalReplyPolicy `json:"localReplyPolicy,omitempty"`
+}
+
+// LocalReplyPolicy defines custom error pages for the responses that
+// Envoy generates itself instead of proxying them from an upstream.
+type LocalReplyPolicy struct {
+	// ErrorPages are the custom error pages, at most one per status code.
+	// +kubebuilder:validation:MinItems=1
+	ErrorPages []ErrorPage `json:"errorPages"`
+}
+
+// ErrorPage replaces the body of local replies that have a given
+// status code with the contents of a ConfigMap key.
+type ErrorPage struct {
+	// StatusCode is the HTTP status code of the local replies to replace.
+	// +kubebuilder:validation:Minimum=400
+	// +kubebuilder:validation:Maximum=599
+	StatusCode int `json:"statusCode"`
+	// ConfigMap is the name of a ConfigMap in the HTTPProxy's
+	// namespace that holds the error page.
+	// +kubebuilder:validation:MinLength=1
+	ConfigMap string `json:"configMap"`
+	// Key is the key of the ConfigMap data that holds the error page.
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+	// ContentType is the content type of the error page.
+	// Defaults to "text/plain".
+	// +optional
+	ContentType string `json:"contentType,omitempty"`
 }
 
 // TLS describes tls properties. The SNI names that will be matched on
@@ -347,6 +379,9 @@ type Route struct {
 	// The retry policy for this route.
 	// +optional
 	RetryPolicy *RetryPolicy `json:"retryPolicy,omitempty"`
+	// The request hedging policy for this route.
+	// +optional
+	HedgePolicy *HedgePolicy `json:"hedgePolicy,omitempty"`
 	// The health check policy for this route.
 	// +optional
 	HealthCheckPolicy *HTTPHealthCheckPolicy `json:"healthCheckPolicy,omitempty"`
@@ -377,6 +412,27 @@ type Route struct {
 	// RequestRedirectPolicy defines an HTTP redirection.
 	// +optional
 	RequestRedirectPolicy *HTTPRequestRedirectPolicy `json:"requestRedirectPolicy,omitempty"`
+
+	// DirectResponsePolicy returns a fixed HTTP response directly,
+	// without proxying the request to any service. It can't be
+	// combined with a RequestRedirectPolicy or with Services.
+	// +optional
+	DirectResponsePolicy *HTTPDirectResponsePolicy `json:"directResponsePolicy,omitempty"`
+}
+
+// HTTPDirectResponsePolicy defines the response to send directly to the client.
+type HTTPDirectResponsePolicy struct {
+	// StatusCode is the HTTP status code of the response.
+	// +kubebuilder:validation:Minimum=200
+	// +kubebuilder:validation:Maximum=599
+	StatusCode int `json:"statusCode"`
+
+	// Body is the body of the response. If omitted, the
+	// response has no body. Envoy sends bodies as "text/plain"
+	// unless the route's ResponseHeadersPolicy sets Content-Type.
+	// +optional
+	// +kubebuilder:validation:MaxLength=4096
+	Body string `json:"body,omitempty"`
 }
 
 // HTTPRequestRedirectPolicy defines configuration for redirecting a request.
@@ -480,17 +536,20 @@ type RateLimitPolicy struct {
 // LocalRateLimitPolicy defines local rate limiting parameters.
 type LocalRateLimitPolicy struct {
 	// Requests defines how many requests per unit of time should
-	// be allowed before rate limiting occurs.
-	// +required
+	// be allowed before rate limiting occurs. It is required unless
+	// descriptors or buckets are defined, in which case requests
+	// that don't match a descriptor or bucket are not rate limited
+	// if it is not set.
+	// +optional
 	// +kubebuilder:validation:Minimum=1
-	Requests uint32 `json:"requests"`
+	Requests uint32 `json:"requests,omitempty"`
 
 	// Unit defines the period of time within which requests
 	// over the limit will be rate limited. Valid values are
 	// "second", "minute" and "hour".
 	// +kubebuilder:validation:Enum=second;minute;hour
-	// +required
-	Unit string `json:"unit"`
+	// +optional
+	Unit string `json:"unit,omitempty"`
 
 	// Burst defines the number of requests above the requests per
 	// unit that should be allowed within a short period of time.
@@ -510,6 +569,145 @@ type LocalRateLimitPolicy struct {
 	// set when a request is rate-limited.
 	// +optional
 	ResponseHeadersToAdd []HeaderValue `json:"responseHeadersToAdd,omitempty"`
+
+	// Descriptors defines separate limits for requests that generate
+	// a matching descriptor. Requests that match a descriptor are
+	// counted against the descriptor's limit instead of the limit
+	// defined by Requests and Unit.
+	// +optional
+	Descriptors []LocalRateLimitDescriptor `json:"descriptors,omitempty"`
+
+	// PerConnection gives each downstream connection its own token
+	// buckets, so that clients are limited separately instead of
+	// sharing the limits. A client that opens several connections
+	// has separate buckets for each of them. PerConnection can only
+	// be set on the virtual host.
+	// +optional
+	PerConnection bool `json:"perConnection,omitempty"`
+
+	// Buckets defines named limits that routes in the HTTPProxy tree
+	// can share by referencing them with Bucket. Buckets can only be
+	// defined on the virtual host.
+	// +optional
+	Buckets []LocalRateLimitBucket `json:"buckets,omitempty"`
+
+	// Bucket is the name of a bucket defined on the virtual host that
+	// requests to this route are counted against. Bucket can only be
+	// set on routes, and cannot be combined with any other field.
+	// +optional
+	Bucket string `json:"bucket,omitempty"`
+}
+
+// LocalRateLimitDescriptor defines a limit for requests that
+// generate a descriptor with the given entries. All matching
+// requests share the limit, unless the policy sets PerConnection.
+type LocalRateLimitDescriptor struct {
+	// Entries is the list of key-value pairs that a request must
+	// generate to match the descriptor.
+	// +required
+	// +kubebuilder:validation:MinItems=1
+	Entries []LocalRateLimitDescriptorEntry `json:"entries,omitempty"`
+
+	// Requests defines how many requests per unit of time should
+	// be allowed before rate limiting occurs.
+	// +required
+	// +kubebuilder:validation:Minimum=1
+	Requests uint32 `json:"requests"`
+
+	// Unit defines the period of time within which requests
+	// over the limit will be rate limited. Valid values are
+	// "second", "minute" and "hour". The unit must not be shorter
+	// than the unit of the policy.
+	// +kubebuilder:validation:Enum=second;minute;hour
+	// +required
+	Unit string `json:"unit"`
+
+	// Burst defines the number of requests above the requests per
+	// unit that should be allowed within a short period of time.
+	// +optional
+	Burst uint32 `json:"burst,omitempty"`
+}
+
+// LocalRateLimitDescriptorEntry is a key-value pair that a request
+// must generate. Exactly one field on this struct must be non-nil.
+type LocalRateLimitDescriptorEntry struct {
+	// GenericKey defines a descriptor entry with a static key and value.
+	// +optional
+	GenericKey *GenericKeyDescriptor `json:"genericKey,omitempty"`
+
+	// RequestHeader defines a descriptor entry that matches requests
+	// with the given header value.
+	// +optional
+	RequestHeader *LocalRequestHeaderDescriptor `json:"requestHeader,omitempty"`
+
+	// RemoteAddress defines a descriptor entry that matches requests
+	// from the given client address.
+	// +optional
+	RemoteAddress *LocalRemoteAddressDescriptor `json:"remoteAddress,omitempty"`
+
+	// MaskedRemoteAddress defines a descriptor entry that matches
+	// requests from client addresses within the given CIDR range.
+	// +optional
+	MaskedRemoteAddress *LocalMaskedRemoteAddressDescriptor `json:"maskedRemoteAddress,omitempty"`
+}
+
+// LocalRequestHeaderDescriptor matches requests with a header value.
+type LocalRequestHeaderDescriptor struct {
+	// HeaderName defines the name of the header to look for on the request.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	HeaderName string `json:"headerName,omitempty"`
+
+	// Value defines the header value that the request must have.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Value string `json:"value,omitempty"`
+}
+
+// LocalRemoteAddressDescriptor matches requests from a client address.
+type LocalRemoteAddressDescriptor struct {
+	// Address is the IPv4 or IPv6 client address.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Address string `json:"address,omitempty"`
+}
+
+// LocalMaskedRemoteAddressDescriptor matches requests from a range
+// of client addresses.
+type LocalMaskedRemoteAddressDescriptor struct {
+	// CIDR is the IPv4 or IPv6 range of client addresses,
+	// e.g. "192.0.2.0/24".
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	CIDR string `json:"cidr,omitempty"`
+}
+
+// LocalRateLimitBucket defines a named limit that is shared by
+// the routes that reference it.
+type LocalRateLimitBucket struct {
+	// Name is the name that routes use to reference the bucket.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+
+	// Requests defines how many requests per unit of time should
+	// be allowed before rate limiting occurs.
+	// +required
+	// +kubebuilder:validation:Minimum=1
+	Requests uint32 `json:"requests"`
+
+	// Unit defines the period of time within which requests
+	// over the limit will be rate limited. Valid values are
+	// "second", "minute" and "hour". The unit must not be shorter
+	// than the unit of the virtual host policy.
+	// +kubebuilder:validation:Enum=second;minute;hour
+	// +required
+	Unit string `json:"unit"`
+
+	// Burst defines the number of requests above the requests per
+	// unit that should be allowed within a short period of time.
+	// +optional
+	Burst uint32 `json:"burst,omitempty"`
 }
 
 // GlobalRateLimitPolicy defines global rate limiting parameters.
@@ -553,6 +751,30 @@ type RateLimitDescriptorEntry struct {
 	// and a value equal to the client's IP address (from x-forwarded-for).
 	// +optional
 	RemoteAddress *RemoteAddressDescriptor `json:"remoteAddress,omitempty"`
+
+	// QueryParameterValueMatch defines a descriptor entry that's populated
+	// if the request's query parameters match the given criteria. The
+	// descriptor key is "header_match", and the descriptor value is static.
+	// Envoy cannot generate a descriptor entry with the value of a query
+	// parameter, so requests can't be limited per parameter value, such
+	// as per API key, unless the value is also sent in a header.
+	// +optional
+	QueryParameterValueMatch *QueryParameterValueMatchDescriptor `json:"queryParameterValueMatch,omitempty"`
+
+	// Metadata defines a descriptor entry that's populated only if the
+	// given key is present in the request's dynamic metadata, for example
+	// a JWT claim or a value set by an external authorization server.
+	// The descriptor key is static, and the descriptor value is equal to
+	// the metadata value.
+	// +optional
+	Metadata *MetadataDescriptor `json:"metadata,omitempty"`
+
+	// MaskedRemoteAddress defines a descriptor entry with a key of
+	// "masked_remote_address" and a value equal to the client's IP address
+	// (from x-forwarded-for) masked to the given prefix length, so that
+	// a whole subnet shares a rate limit.
+	// +optional
+	MaskedRemoteAddress *MaskedRemoteAddressDescriptor `json:"maskedRemoteAddress,omitempty"`
 }
 
 // GenericKeyDescriptor defines a descriptor entry with a static key and
@@ -611,6 +833,81 @@ type RequestHeaderValueMatchDescriptor struct {
 // (from x-forwarded-for).
 type RemoteAddressDescriptor struct{}
 
+// QueryParameterValueMatchDescriptor defines a descriptor entry that's
+// populated if the request's query parameters match the given criteria.
+// The descriptor key is "header_match", and the descriptor value is
+// statically defined. Query parameters are compared as they appear on
+// the request, without URL decoding.
+type QueryParameterValueMatchDescriptor struct {
+	// Name defines the name of the query parameter to look for on the request.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name,omitempty"`
+
+	// Exact, if set, requires the query parameter to be equal to the
+	// given value. If not set, the query parameter only needs to be present.
+	// +optional
+	Exact string `json:"exact,omitempty"`
+
+	// ExpectMatch defines whether the request must positively match the match
+	// criteria in order to generate a descriptor entry (i.e. true), or not
+	// match the match criteria in order to generate a descriptor entry (i.e. false).
+	// The default is true.
+	// +kubebuilder:default=true
+	ExpectMatch bool `json:"expectMatch,omitempty"`
+
+	// Value defines the value of the descriptor entry.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Value string `json:"value,omitempty"`
+}
+
+// MetadataDescriptor defines a descriptor entry that's populated only
+// if the given key is present in the request's dynamic metadata.
+type MetadataDescriptor struct {
+	// DescriptorKey defines the key to use on the descriptor entry.
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	DescriptorKey string `json:"descriptorKey,omitempty"`
+
+	// FilterNamespace is the metadata namespace to look up the value in,
+	// for example "envoy.filters.http.jwt_authn" or "envoy.filters.http.ext_authz".
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	FilterNamespace string `json:"filterNamespace,omitempty"`
+
+	// Path is the list of keys to traverse in the metadata namespace
+	// to find the value, which must be a string.
+	// +required
+	// +kubebuilder:validation:MinItems=1
+	Path []string `json:"path,omitempty"`
+
+	// DefaultValue is used as the descriptor value if the metadata
+	// key is not present. If not set, no descriptor entry is generated
+	// when the key is not present.
+	// +optional
+	DefaultValue string `json:"defaultValue,omitempty"`
+}
+
+// MaskedRemoteAddressDescriptor defines a descriptor entry with a key of
+// "masked_remote_address" and a value equal to the client's IP address
+// masked to the given prefix lengths.
+type MaskedRemoteAddressDescriptor struct {
+	// V4PrefixLength is the prefix length applied to IPv4 client
+	// addresses. The default is 32, which uses the full address.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=32
+	V4PrefixLength *int32 `json:"v4PrefixLength,omitempty"`
+
+	// V6PrefixLength is the prefix length applied to IPv6 client
+	// addresses. The default is 128, which uses the full address.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=128
+	V6PrefixLength *int32 `json:"v6PrefixLength,omitempty"`
+}
+
 // TCPProxy contains the set of services to proxy TCP connections.
 type TCPProxy struct {
 	// The load balancing policy for the backend services. Note that the
@@ -648,15 +945,30 @@ type TCPProxyInclude struct {
 type Service struct {
 	// Name is the name of Kubernetes service to proxy traffic.
 	// Names defined here will be used to look up corresponding endpoints which contain the ips to route.
-	Name string `json:"name"`
+	// Name must be set unless ExtensionService is.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Namespace is the namespace of the Kubernetes service. Defaults to the
+	// namespace of the HTTPProxy. A service in another namespace can only be
+	// referenced if a ServiceReferenceGrant in that namespace permits it.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
 	// Port (defined as Integer) to proxy traffic to since a service can have multiple defined.
+	// Port must be set unless ExtensionService is.
 	//
-	// +required
+	// +optional
 	// +kubebuilder:validation:Minimum=1
 	// +kubebuilder:validation:Maximum=65536
 	// +kubebuilder:validation:ExclusiveMinimum=false
 	// +kubebuilder:validation:ExclusiveMaximum=true
-	Port int `json:"port"`
+	Port int `json:"port,omitempty"`
+	// ExtensionService routes traffic to an ExtensionService instead of
+	// a Kubernetes service. The ExtensionService's load balancing policy,
+	// protocol and upstream validation are used. An ExtensionService in
+	// another namespace can only be referenced if a ServiceReferenceGrant
+	// in that namespace permits it. Only supported on routes.
+	// +optional
+	ExtensionService *ExtensionServiceReference `json:"extensionService,omitempty"`
 	// Protocol may be used to specify (or override) the protocol used to reach this Service.
 	// Values may be tls, h2, h2c. If omitted, protocol-selection falls back on Service annotations.
 	// +kubebuilder:validation:Enum=h2;h2c;tls
@@ -682,6 +994,109 @@ type Service struct {
 	// The policies for rewriting Set-Cookie header attributes.
 	// +optional
 	CookieRewritePolicies []CookieRewritePolicy `json:"cookieRewritePolicies,omitempty"`
+	// ConnectionPolicy tunes how Envoy manages its connection pool to this Service.
+	// Fields that are not set fall back to the cluster defaults in the Sesame
+	// configuration, then to the Service annotations.
+	// +optional
+	ConnectionPolicy *ConnectionPolicy `json:"connectionPolicy,omitempty"`
+	// CircuitBreakers sets the circuit breaking limits Envoy applies to this Service.
+	// Limits set here take precedence over the connection policy and the
+	// projectsesame.io/max-* Service annotations.
+	// +optional
+	CircuitBreakers *CircuitBreakers `json:"circuitBreakers,omitempty"`
+}
+
+// CircuitBreakers defines the circuit breaking limits for an upstream service.
+// Limits that are not set fall back to the Service annotations.
+type CircuitBreakers struct {
+	// MaxConnections is the maximum number of connections
+	// that Envoy will make to the upstream cluster.
+	// +optional
+	MaxConnections uint32 `json:"maxConnections,omitempty"`
+	// MaxPendingRequests is the maximum number of pending
+	// requests that Envoy will allow to the upstream cluster.
+	// +optional
+	MaxPendingRequests uint32 `json:"maxPendingRequests,omitempty"`
+	// MaxRequests is the maximum number of parallel requests that
+	// Envoy will make to the upstream cluster.
+	// +optional
+	MaxRequests uint32 `json:"maxRequests,omitempty"`
+	// MaxRetries is the maximum number of parallel retries that
+	// Envoy will allow to the upstream cluster.
+	// Ignored when a retry budget is set.
+	// +optional
+	MaxRetries uint32 `json:"maxRetries,omitempty"`
+	// RetryBudget limits the number of parallel retries to a
+	// proportion of the active requests to the upstream cluster.
+	// +optional
+	RetryBudget *RetryBudget `json:"retryBudget,omitempty"`
+}
+
+// RetryBudget limits concurrent retries relative to the number of active requests.
+type RetryBudget struct {
+	// BudgetPercent is the percentage of active requests
+	// that may be retries at any one time.
+	// If not specified, Envoy's default of 20% applies.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	BudgetPercent *uint32 `json:"budgetPercent,omitempty"`
+	// MinRetryConcurrency is the number of parallel retries
+	// that are always allowed, regardless of the budget.
+	// If not specified, Envoy's default of 3 applies.
+	// +optional
+	MinRetryConcurrency *uint32 `json:"minRetryConcurrency,omitempty"`
+}
+
+// ConnectionPolicy defines how Envoy manages connections to an upstream service.
+type ConnectionPolicy struct {
+	// MaxConnections is the maximum number of connections
+	// that Envoy will make to the upstream cluster.
+	// Overrides the projectsesame.io/max-connections annotation.
+	// +optional
+	MaxConnections uint32 `json:"maxConnections,omitempty"`
+	// MaxPendingRequests is the maximum number of pending
+	// requests that Envoy will allow to the upstream cluster.
+	// Overrides the projectsesame.io/max-pending-requests annotation.
+	// +optional
+	MaxPendingRequests uint32 `json:"maxPendingRequests,omitempty"`
+	// MaxRequestsPerConnection is the maximum number of requests
+	// Envoy will send over a single upstream connection before
+	// closing it. Only applies to HTTP traffic.
+	// +optional
+	MaxRequestsPerConnection uint32 `json:"maxRequestsPerConnection,omitempty"`
+	// MaxConcurrentStreams is the maximum number of concurrent streams
+	// Envoy will open on a single HTTP/2 upstream connection.
+	// Only applies when the upstream protocol is h2 or h2c.
+	// +optional
+	// +kubebuilder:validation:Maximum=2147483647
+	MaxConcurrentStreams uint32 `json:"maxConcurrentStreams,omitempty"`
+	// IdleTimeout is how long an upstream connection may remain without
+	// active requests before Envoy closes it. Only applies to HTTP traffic.
+	// The value is expressed in the Go [Duration format](https://godoc.org/time#ParseDuration).
+	// The string "infinity" is also a valid input and disables the timeout.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+|infinity|infinite)$`
+	IdleTimeout string `json:"idleTimeout,omitempty"`
+	// TCPKeepalive enables TCP keepalive on upstream connections.
+	// +optional
+	TCPKeepalive *TCPKeepalive `json:"tcpKeepalive,omitempty"`
+}
+
+// TCPKeepalive defines the TCP keepalive settings for upstream connections.
+// Fields that are not set use the operating system defaults.
+type TCPKeepalive struct {
+	// Probes is the maximum number of keepalive probes to send
+	// without response before deciding the connection is dead.
+	// +optional
+	Probes uint32 `json:"probes,omitempty"`
+	// IdleTimeSeconds is the number of seconds a connection needs to be
+	// idle before keepalive probes start being sent.
+	// +optional
+	IdleTimeSeconds uint32 `json:"idleTimeSeconds,omitempty"`
+	// IntervalSeconds is the number of seconds between keepalive probes.
+	// +optional
+	IntervalSeconds uint32 `json:"intervalSeconds,omitempty"`
 }
 
 // HTTPHealthCheckPolicy defines health checks on the upstream service.
@@ -794,6 +1209,88 @@ type RetryPolicy struct {
 	// This field is only respected when you include `retriable-status-codes` in the `RetryOn` field.
 	// +optional
 	RetriableStatusCodes []uint32 `json:"retriableStatusCodes,omitempty"`
+	// RetryHostPredicates specifies which hosts should be rejected when
+	// selecting a host for a retry attempt.
+	//
+	// Supported predicates:
+	//
+	// - `previous-hosts`: reject hosts that have already been attempted.
+	// - `omit-canary-hosts`: reject hosts that are marked as canaries.
+	// +optional
+	RetryHostPredicates []RetryHostPredicate `json:"retryHostPredicates,omitempty"`
+	// HostSelectionRetryMaxAttempts is the maximum number of times host
+	// selection is reattempted when the retry host predicates reject a host.
+	// If not supplied, Envoy's default of 1 applies.
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	HostSelectionRetryMaxAttempts int64 `json:"hostSelectionRetryMaxAttempts,omitempty"`
+	// RetryPriorityPredicate makes retry attempts prefer priority levels
+	// that have not already been attempted.
+	// +optional
+	RetryPriorityPredicate *RetryPriorityPredicate `json:"retryPriorityPredicate,omitempty"`
+	// RetryBackOff specifies the exponential back-off between retry attempts.
+	// If not supplied, Envoy's default base interval of 25ms applies.
+	// +optional
+	RetryBackOff *RetryBackOff `json:"retryBackOff,omitempty"`
+	// PerTryIdleTimeout specifies the timeout for how long a retry attempt
+	// may go without receiving any response bytes from the upstream.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+|infinity|infinite)$`
+	PerTryIdleTimeout string `json:"perTryIdleTimeout,omitempty"`
+}
+
+// HedgePolicy defines how Envoy hedges requests to the upstream services of a route.
+//
+// Hedging sends the same request to more than one upstream host, so it is only
+// allowed on routes whose conditions restrict the request method to an
+// idempotent one (GET, HEAD, OPTIONS, PUT, DELETE or TRACE), unless Force is set.
+type HedgePolicy struct {
+	// HedgeOnPerTryTimeout sends another request when the retry policy's
+	// per-try timeout elapses, without cancelling the outstanding request.
+	// The first response to arrive is used. The route's retry policy
+	// must set a perTryTimeout. This is currently the only way to enable
+	// hedging, so hedge policies that do not set it are rejected.
+	// +optional
+	HedgeOnPerTryTimeout bool `json:"hedgeOnPerTryTimeout,omitempty"`
+	// Force allows hedging on routes that are not restricted
+	// to idempotent request methods.
+	// +optional
+	Force bool `json:"force,omitempty"`
+}
+
+// RetryHostPredicate is a string type alias with validation to ensure that the value is valid.
+// +kubebuilder:validation:Enum=previous-hosts;omit-canary-hosts
+type RetryHostPredicate string
+
+const (
+	// RetryHostPredicatePreviousHosts rejects hosts that have already been attempted.
+	RetryHostPredicatePreviousHosts RetryHostPredicate = "previous-hosts"
+	// RetryHostPredicateOmitCanaryHosts rejects hosts that are marked as canaries.
+	RetryHostPredicateOmitCanaryHosts RetryHostPredicate = "omit-canary-hosts"
+)
+
+// RetryPriorityPredicate defines how retry attempts are spread across priority levels.
+type RetryPriorityPredicate struct {
+	// UpdateFrequency is the number of retry attempts after which the
+	// priority levels that have already been attempted are excluded.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=2147483647
+	UpdateFrequency uint32 `json:"updateFrequency"`
+}
+
+// RetryBackOff defines the exponential back-off between retry attempts.
+//
+// Durations are expressed in the Go [Duration format](https://godoc.org/time#ParseDuration).
+type RetryBackOff struct {
+	// BaseInterval is the base interval between retry attempts.
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+)$`
+	BaseInterval string `json:"baseInterval"`
+	// MaxInterval is the maximum interval between retry attempts.
+	// It must not be less than BaseInterval.
+	// If not supplied, it defaults to 10 times BaseInterval.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^(((\d*(\.\d*)?h)|(\d*(\.\d*)?m)|(\d*(\.\d*)?s)|(\d*(\.\d*)?ms)|(\d*(\.\d*)?us)|(\d*(\.\d*)?µs)|(\d*(\.\d*)?ns))+)$`
+	MaxInterval string `json:"maxInterval,omitempty"`
 }
 
 // ReplacePrefix describes a path prefix replacement.
@@ -894,6 +1391,14 @@ type HeadersPolicy struct {
 	// If the header does not exist it will be added, otherwise it will be overwritten with the new value.
 	// +optional
 	Set []HeaderValue `json:"set,omitempty"`
+	// Add specifies a list of HTTP header values that will be appended to the HTTP header.
+	// If the header already exists, the new value is added alongside the existing values.
+	// +optional
+	Add []HeaderValue `json:"add,omitempty"`
+	// AddIfAbsent specifies a list of HTTP header values that will be added to the HTTP header
+	// only if the header is not already present. Requires Envoy 1.22 or later.
+	// +optional
+	AddIfAbsent []HeaderValue `json:"addIfAbsent,omitempty"`
 	// Remove specifies a list of HTTP header names to remove.
 	// +optional
 	Remove []string `json:"remove,omitempty"`
@@ -905,7 +1410,10 @@ type HeaderValue struct {
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:MinLength=1
 	Name string `json:"name"`
-	// Value represents the value of a header specified by a key
+	// Value represents the value of a header specified by a key.
+	// It may contain Envoy command operators such as `%DOWNSTREAM_REMOTE_ADDRESS%`,
+	// `%REQ(X-Request-Id)%` or `%DYNAMIC_METADATA(namespace:key)%`.
+	// Any other `%` is sent literally.
 	// +kubebuilder:validation:Required
 	// +kubebuilder:validation:MinLength=1
 	Value string `json:"value"`
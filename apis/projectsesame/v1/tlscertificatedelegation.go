@@ -26,15 +26,38 @@ type TLSCertificateDelegationSpec struct {
 // in the current namespace to a set of namespaces.
 type CertificateDelegation struct {
 
-	// required, the name of a secret in the current namespace.
-	SecretName string `json:"secretName"`
+	// the name of a secret in the current namespace.
+	// One of SecretName or SecretSelector must be set.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+
+	// SecretSelector selects the secrets in the current namespace
+	// whose authority is delegated, by their labels.
+	// +optional
+	SecretSelector *metav1.LabelSelector `json:"secretSelector,omitempty"`
 
-	// required, the namespaces the authority to reference the
+	// the namespaces the authority to reference the
 	// the secret will be delegated to.
-	// If TargetNamespaces is nil or empty, the CertificateDelegation'
-	// is ignored. If the TargetNamespace list contains the character, "*"
-	// the secret will be delegated to all namespaces.
-	TargetNamespaces []string `json:"targetNamespaces"`
+	// If TargetNamespaces and TargetNamespaceSelector are both empty,
+	// the CertificateDelegation is ignored. If the TargetNamespace list
+	// contains the character, "*" the secret will be delegated to all namespaces.
+	// +optional
+	TargetNamespaces []string `json:"targetNamespaces,omitempty"`
+
+	// TargetNamespaceSelector selects the namespaces the authority
+	// to reference the secret will be delegated to, by their labels.
+	// +optional
+	TargetNamespaceSelector *metav1.LabelSelector `json:"targetNamespaceSelector,omitempty"`
+}
+
+// CertificateDelegationStatus lists the namespaces that reference
+// a delegated secret.
+type CertificateDelegationStatus struct {
+	// SecretName is the name of the delegated secret.
+	SecretName string `json:"secretName"`
+
+	// Namespaces are the namespaces that reference the secret.
+	Namespaces []string `json:"namespaces"`
 }
 
 // TLSCertificateDelegationStatus allows for the status of the delegation
@@ -58,6 +81,11 @@ type TLSCertificateDelegationStatus struct {
 	// +listType=map
 	// +listMapKey=type
 	Conditions []DetailedCondition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+
+	// +optional
+	// Delegations lists the delegated secrets that are referenced
+	// from other namespaces, and the namespaces that reference them.
+	Delegations []CertificateDelegationStatus `json:"delegations,omitempty"`
 }
 
 // +genclient
@@ -21,6 +21,7 @@ limitations under the License.
 package v1
 
 import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -125,11 +126,21 @@ func (in *CORSPolicy) DeepCopy() *CORSPolicy {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CertificateDelegation) DeepCopyInto(out *CertificateDelegation) {
 	*out = *in
+	if in.SecretSelector != nil {
+		in, out := &in.SecretSelector, &out.SecretSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.TargetNamespaces != nil {
 		in, out := &in.TargetNamespaces, &out.TargetNamespaces
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.TargetNamespaceSelector != nil {
+		in, out := &in.TargetNamespaceSelector, &out.TargetNamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateDelegation.
@@ -142,6 +153,66 @@ func (in *CertificateDelegation) DeepCopy() *CertificateDelegation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateDelegationStatus) DeepCopyInto(out *CertificateDelegationStatus) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateDelegationStatus.
+func (in *CertificateDelegationStatus) DeepCopy() *CertificateDelegationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateDelegationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CircuitBreakers) DeepCopyInto(out *CircuitBreakers) {
+	*out = *in
+	if in.RetryBudget != nil {
+		in, out := &in.RetryBudget, &out.RetryBudget
+		*out = new(RetryBudget)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CircuitBreakers.
+func (in *CircuitBreakers) DeepCopy() *CircuitBreakers {
+	if in == nil {
+		return nil
+	}
+	out := new(CircuitBreakers)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionPolicy) DeepCopyInto(out *ConnectionPolicy) {
+	*out = *in
+	if in.TCPKeepalive != nil {
+		in, out := &in.TCPKeepalive, &out.TCPKeepalive
+		*out = new(TCPKeepalive)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionPolicy.
+func (in *ConnectionPolicy) DeepCopy() *ConnectionPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CookieDomainRewrite) DeepCopyInto(out *CookieDomainRewrite) {
 	*out = *in
@@ -248,6 +319,21 @@ func (in *DownstreamValidation) DeepCopy() *DownstreamValidation {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ErrorPage) DeepCopyInto(out *ErrorPage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ErrorPage.
+func (in *ErrorPage) DeepCopy() *ErrorPage {
+	if in == nil {
+		return nil
+	}
+	out := new(ErrorPage)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExtensionServiceReference) DeepCopyInto(out *ExtensionServiceReference) {
 	*out = *in
@@ -300,6 +386,21 @@ func (in *GlobalRateLimitPolicy) DeepCopy() *GlobalRateLimitPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPDirectResponsePolicy) DeepCopyInto(out *HTTPDirectResponsePolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPDirectResponsePolicy.
+func (in *HTTPDirectResponsePolicy) DeepCopy() *HTTPDirectResponsePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPDirectResponsePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HTTPHealthCheckPolicy) DeepCopyInto(out *HTTPHealthCheckPolicy) {
 	*out = *in
@@ -524,6 +625,16 @@ func (in *HeadersPolicy) DeepCopyInto(out *HeadersPolicy) {
 		*out = make([]HeaderValue, len(*in))
 		copy(*out, *in)
 	}
+	if in.Add != nil {
+		in, out := &in.Add, &out.Add
+		*out = make([]HeaderValue, len(*in))
+		copy(*out, *in)
+	}
+	if in.AddIfAbsent != nil {
+		in, out := &in.AddIfAbsent, &out.AddIfAbsent
+		*out = make([]HeaderValue, len(*in))
+		copy(*out, *in)
+	}
 	if in.Remove != nil {
 		in, out := &in.Remove, &out.Remove
 		*out = make([]string, len(*in))
@@ -541,6 +652,21 @@ func (in *HeadersPolicy) DeepCopy() *HeadersPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HedgePolicy) DeepCopyInto(out *HedgePolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HedgePolicy.
+func (in *HedgePolicy) DeepCopy() *HedgePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(HedgePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Include) DeepCopyInto(out *Include) {
 	*out = *in
@@ -585,6 +711,93 @@ func (in *LoadBalancerPolicy) DeepCopy() *LoadBalancerPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalMaskedRemoteAddressDescriptor) DeepCopyInto(out *LocalMaskedRemoteAddressDescriptor) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalMaskedRemoteAddressDescriptor.
+func (in *LocalMaskedRemoteAddressDescriptor) DeepCopy() *LocalMaskedRemoteAddressDescriptor {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalMaskedRemoteAddressDescriptor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalRateLimitBucket) DeepCopyInto(out *LocalRateLimitBucket) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalRateLimitBucket.
+func (in *LocalRateLimitBucket) DeepCopy() *LocalRateLimitBucket {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalRateLimitBucket)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalRateLimitDescriptor) DeepCopyInto(out *LocalRateLimitDescriptor) {
+	*out = *in
+	if in.Entries != nil {
+		in, out := &in.Entries, &out.Entries
+		*out = make([]LocalRateLimitDescriptorEntry, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalRateLimitDescriptor.
+func (in *LocalRateLimitDescriptor) DeepCopy() *LocalRateLimitDescriptor {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalRateLimitDescriptor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalRateLimitDescriptorEntry) DeepCopyInto(out *LocalRateLimitDescriptorEntry) {
+	*out = *in
+	if in.GenericKey != nil {
+		in, out := &in.GenericKey, &out.GenericKey
+		*out = new(GenericKeyDescriptor)
+		**out = **in
+	}
+	if in.RequestHeader != nil {
+		in, out := &in.RequestHeader, &out.RequestHeader
+		*out = new(LocalRequestHeaderDescriptor)
+		**out = **in
+	}
+	if in.RemoteAddress != nil {
+		in, out := &in.RemoteAddress, &out.RemoteAddress
+		*out = new(LocalRemoteAddressDescriptor)
+		**out = **in
+	}
+	if in.MaskedRemoteAddress != nil {
+		in, out := &in.MaskedRemoteAddress, &out.MaskedRemoteAddress
+		*out = new(LocalMaskedRemoteAddressDescriptor)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalRateLimitDescriptorEntry.
+func (in *LocalRateLimitDescriptorEntry) DeepCopy() *LocalRateLimitDescriptorEntry {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalRateLimitDescriptorEntry)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LocalRateLimitPolicy) DeepCopyInto(out *LocalRateLimitPolicy) {
 	*out = *in
@@ -593,6 +806,18 @@ func (in *LocalRateLimitPolicy) DeepCopyInto(out *LocalRateLimitPolicy) {
 		*out = make([]HeaderValue, len(*in))
 		copy(*out, *in)
 	}
+	if in.Descriptors != nil {
+		in, out := &in.Descriptors, &out.Descriptors
+		*out = make([]LocalRateLimitDescriptor, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Buckets != nil {
+		in, out := &in.Buckets, &out.Buckets
+		*out = make([]LocalRateLimitBucket, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalRateLimitPolicy.
@@ -605,6 +830,81 @@ func (in *LocalRateLimitPolicy) DeepCopy() *LocalRateLimitPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalRemoteAddressDescriptor) DeepCopyInto(out *LocalRemoteAddressDescriptor) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalRemoteAddressDescriptor.
+func (in *LocalRemoteAddressDescriptor) DeepCopy() *LocalRemoteAddressDescriptor {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalRemoteAddressDescriptor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalReplyPolicy) DeepCopyInto(out *LocalReplyPolicy) {
+	*out = *in
+	if in.ErrorPages != nil {
+		in, out := &in.ErrorPages, &out.ErrorPages
+		*out = make([]ErrorPage, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalReplyPolicy.
+func (in *LocalReplyPolicy) DeepCopy() *LocalReplyPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalReplyPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LocalRequestHeaderDescriptor) DeepCopyInto(out *LocalRequestHeaderDescriptor) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LocalRequestHeaderDescriptor.
+func (in *LocalRequestHeaderDescriptor) DeepCopy() *LocalRequestHeaderDescriptor {
+	if in == nil {
+		return nil
+	}
+	out := new(LocalRequestHeaderDescriptor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MaskedRemoteAddressDescriptor) DeepCopyInto(out *MaskedRemoteAddressDescriptor) {
+	*out = *in
+	if in.V4PrefixLength != nil {
+		in, out := &in.V4PrefixLength, &out.V4PrefixLength
+		*out = new(int32)
+		**out = **in
+	}
+	if in.V6PrefixLength != nil {
+		in, out := &in.V6PrefixLength, &out.V6PrefixLength
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MaskedRemoteAddressDescriptor.
+func (in *MaskedRemoteAddressDescriptor) DeepCopy() *MaskedRemoteAddressDescriptor {
+	if in == nil {
+		return nil
+	}
+	out := new(MaskedRemoteAddressDescriptor)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MatchCondition) DeepCopyInto(out *MatchCondition) {
 	*out = *in
@@ -625,6 +925,26 @@ func (in *MatchCondition) DeepCopy() *MatchCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetadataDescriptor) DeepCopyInto(out *MetadataDescriptor) {
+	*out = *in
+	if in.Path != nil {
+		in, out := &in.Path, &out.Path
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetadataDescriptor.
+func (in *MetadataDescriptor) DeepCopy() *MetadataDescriptor {
+	if in == nil {
+		return nil
+	}
+	out := new(MetadataDescriptor)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PathRewritePolicy) DeepCopyInto(out *PathRewritePolicy) {
 	*out = *in
@@ -645,6 +965,21 @@ func (in *PathRewritePolicy) DeepCopy() *PathRewritePolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QueryParameterValueMatchDescriptor) DeepCopyInto(out *QueryParameterValueMatchDescriptor) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QueryParameterValueMatchDescriptor.
+func (in *QueryParameterValueMatchDescriptor) DeepCopy() *QueryParameterValueMatchDescriptor {
+	if in == nil {
+		return nil
+	}
+	out := new(QueryParameterValueMatchDescriptor)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RateLimitDescriptor) DeepCopyInto(out *RateLimitDescriptor) {
 	*out = *in
@@ -690,6 +1025,21 @@ func (in *RateLimitDescriptorEntry) DeepCopyInto(out *RateLimitDescriptorEntry)
 		*out = new(RemoteAddressDescriptor)
 		**out = **in
 	}
+	if in.QueryParameterValueMatch != nil {
+		in, out := &in.QueryParameterValueMatch, &out.QueryParameterValueMatch
+		*out = new(QueryParameterValueMatchDescriptor)
+		**out = **in
+	}
+	if in.Metadata != nil {
+		in, out := &in.Metadata, &out.Metadata
+		*out = new(MetadataDescriptor)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MaskedRemoteAddress != nil {
+		in, out := &in.MaskedRemoteAddress, &out.MaskedRemoteAddress
+		*out = new(MaskedRemoteAddressDescriptor)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RateLimitDescriptorEntry.
@@ -812,6 +1162,46 @@ func (in *RequestHeaderValueMatchDescriptor) DeepCopy() *RequestHeaderValueMatch
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetryBackOff) DeepCopyInto(out *RetryBackOff) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetryBackOff.
+func (in *RetryBackOff) DeepCopy() *RetryBackOff {
+	if in == nil {
+		return nil
+	}
+	out := new(RetryBackOff)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetryBudget) DeepCopyInto(out *RetryBudget) {
+	*out = *in
+	if in.BudgetPercent != nil {
+		in, out := &in.BudgetPercent, &out.BudgetPercent
+		*out = new(uint32)
+		**out = **in
+	}
+	if in.MinRetryConcurrency != nil {
+		in, out := &in.MinRetryConcurrency, &out.MinRetryConcurrency
+		*out = new(uint32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetryBudget.
+func (in *RetryBudget) DeepCopy() *RetryBudget {
+	if in == nil {
+		return nil
+	}
+	out := new(RetryBudget)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RetryPolicy) DeepCopyInto(out *RetryPolicy) {
 	*out = *in
@@ -825,6 +1215,21 @@ func (in *RetryPolicy) DeepCopyInto(out *RetryPolicy) {
 		*out = make([]uint32, len(*in))
 		copy(*out, *in)
 	}
+	if in.RetryHostPredicates != nil {
+		in, out := &in.RetryHostPredicates, &out.RetryHostPredicates
+		*out = make([]RetryHostPredicate, len(*in))
+		copy(*out, *in)
+	}
+	if in.RetryPriorityPredicate != nil {
+		in, out := &in.RetryPriorityPredicate, &out.RetryPriorityPredicate
+		*out = new(RetryPriorityPredicate)
+		**out = **in
+	}
+	if in.RetryBackOff != nil {
+		in, out := &in.RetryBackOff, &out.RetryBackOff
+		*out = new(RetryBackOff)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetryPolicy.
@@ -837,6 +1242,21 @@ func (in *RetryPolicy) DeepCopy() *RetryPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RetryPriorityPredicate) DeepCopyInto(out *RetryPriorityPredicate) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RetryPriorityPredicate.
+func (in *RetryPriorityPredicate) DeepCopy() *RetryPriorityPredicate {
+	if in == nil {
+		return nil
+	}
+	out := new(RetryPriorityPredicate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Route) DeepCopyInto(out *Route) {
 	*out = *in
@@ -869,6 +1289,11 @@ func (in *Route) DeepCopyInto(out *Route) {
 		*out = new(RetryPolicy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.HedgePolicy != nil {
+		in, out := &in.HedgePolicy, &out.HedgePolicy
+		*out = new(HedgePolicy)
+		**out = **in
+	}
 	if in.HealthCheckPolicy != nil {
 		in, out := &in.HealthCheckPolicy, &out.HealthCheckPolicy
 		*out = new(HTTPHealthCheckPolicy)
@@ -911,6 +1336,11 @@ func (in *Route) DeepCopyInto(out *Route) {
 		*out = new(HTTPRequestRedirectPolicy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.DirectResponsePolicy != nil {
+		in, out := &in.DirectResponsePolicy, &out.DirectResponsePolicy
+		*out = new(HTTPDirectResponsePolicy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Route.
@@ -926,6 +1356,11 @@ func (in *Route) DeepCopy() *Route {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Service) DeepCopyInto(out *Service) {
 	*out = *in
+	if in.ExtensionService != nil {
+		in, out := &in.ExtensionService, &out.ExtensionService
+		*out = new(ExtensionServiceReference)
+		**out = **in
+	}
 	if in.Protocol != nil {
 		in, out := &in.Protocol, &out.Protocol
 		*out = new(string)
@@ -953,6 +1388,16 @@ func (in *Service) DeepCopyInto(out *Service) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ConnectionPolicy != nil {
+		in, out := &in.ConnectionPolicy, &out.ConnectionPolicy
+		*out = new(ConnectionPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.CircuitBreakers != nil {
+		in, out := &in.CircuitBreakers, &out.CircuitBreakers
+		*out = new(CircuitBreakers)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Service.
@@ -995,6 +1440,21 @@ func (in *TCPHealthCheckPolicy) DeepCopy() *TCPHealthCheckPolicy {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TCPKeepalive) DeepCopyInto(out *TCPKeepalive) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TCPKeepalive.
+func (in *TCPKeepalive) DeepCopy() *TCPKeepalive {
+	if in == nil {
+		return nil
+	}
+	out := new(TCPKeepalive)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TCPProxy) DeepCopyInto(out *TCPProxy) {
 	*out = *in
@@ -1163,6 +1623,13 @@ func (in *TLSCertificateDelegationStatus) DeepCopyInto(out *TLSCertificateDelega
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Delegations != nil {
+		in, out := &in.Delegations, &out.Delegations
+		*out = make([]CertificateDelegationStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSCertificateDelegationStatus.
@@ -1228,6 +1695,11 @@ func (in *VirtualHost) DeepCopyInto(out *VirtualHost) {
 		*out = new(RateLimitPolicy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.LocalReplyPolicy != nil {
+		in, out := &in.LocalReplyPolicy, &out.LocalReplyPolicy
+		*out = new(LocalReplyPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VirtualHost.
@@ -109,6 +109,7 @@ var envoySimpleOperators = map[string]struct{}{
 	"UPSTREAM_CLUSTER":                              {},
 	"UPSTREAM_HOST":                                 {},
 	"UPSTREAM_LOCAL_ADDRESS":                        {},
+	"UPSTREAM_REMOTE_ADDRESS":                       {},
 	"UPSTREAM_TRANSPORT_FAILURE_REASON":             {},
 }
 
@@ -120,6 +121,8 @@ var envoyComplexOperators = map[string]struct{}{
 	"START_TIME":        {},
 	"TRAILER":           {},
 	"REQ_WITHOUT_QUERY": {},
+	"DYNAMIC_METADATA":  {},
+	"UPSTREAM_METADATA": {},
 }
 
 // AccessLogType is the name of a supported access logging mechanism.
@@ -238,7 +241,7 @@ func parseAccessLogFormat(format string) error {
 			return fmt.Errorf("invalid Envoy format: %s, invalid Envoy operator: %s", f, op)
 		}
 
-		if (op == "REQ" || op == "RESP" || op == "TRAILER" || op == "REQ_WITHOUT_QUERY") && f[3] == "" {
+		if (op == "REQ" || op == "RESP" || op == "TRAILER" || op == "REQ_WITHOUT_QUERY" || op == "DYNAMIC_METADATA" || op == "UPSTREAM_METADATA") && f[3] == "" {
 			return fmt.Errorf("invalid Envoy format: %s, arguments required for operator: %s", f, op)
 		}
 
@@ -250,3 +253,18 @@ func parseAccessLogFormat(format string) error {
 
 	return nil
 }
+
+// IsEnvoyCommandOperator reports whether op is the name of a known
+// Envoy command operator, such as "REQ" or "DOWNSTREAM_REMOTE_ADDRESS".
+func IsEnvoyCommandOperator(op string) bool {
+	_, okSimple := envoySimpleOperators[op]
+	_, okComplex := envoyComplexOperators[op]
+	return okSimple || okComplex
+}
+
+// ValidateEnvoyFormat validates the command operators in a string
+// that Envoy formats, such as a dynamic header value, using the
+// same rules as access log formats.
+func ValidateEnvoyFormat(format string) error {
+	return parseAccessLogFormat(format)
+}
@@ -58,6 +58,35 @@ type ExtensionServiceTarget struct {
 	Weight uint32 `json:"weight,omitempty"`
 }
 
+// ExtensionServiceEndpoint defines a static network endpoint, such as
+// a virtual machine outside the cluster, to target with extension
+// service traffic.
+type ExtensionServiceEndpoint struct {
+	// Address is the IP address of the endpoint. Loopback,
+	// unspecified and link-local addresses are not allowed.
+	//
+	// +required
+	// +kubebuilder:validation:MinLength=1
+	Address string `json:"address"`
+
+	// Port (defined as Integer) to proxy traffic to on the endpoint.
+	//
+	// +required
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65536
+	// +kubebuilder:validation:ExclusiveMinimum=false
+	// +kubebuilder:validation:ExclusiveMaximum=true
+	Port int `json:"port"`
+
+	// Weight defines the proportion of traffic to balance to this
+	// endpoint, relative to the other static endpoints. Endpoints
+	// without a weight have a weight of 1.
+	//
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	Weight uint32 `json:"weight,omitempty"`
+}
+
 // ExtensionServiceSpec defines the desired state of an ExtensionService resource.
 type ExtensionServiceSpec struct {
 	// Services specifies the set of Kubernetes Service resources that
@@ -68,9 +97,18 @@ type ExtensionServiceSpec struct {
 	// Otherwise, traffic is balanced proportionally to the
 	// Weight field in each entry.
 	//
-	// +required
-	// +kubebuilder:validation:MinItems=1
-	Services []ExtensionServiceTarget `json:"services"`
+	// At least one of Services or Endpoints must be specified.
+	//
+	// +optional
+	Services []ExtensionServiceTarget `json:"services,omitempty"`
+
+	// Endpoints specifies a set of static network endpoints that
+	// receive requests in addition to the endpoints of Services.
+	// This can be used to target backends that are not part of
+	// the Kubernetes cluster.
+	//
+	// +optional
+	Endpoints []ExtensionServiceEndpoint `json:"endpoints,omitempty"`
 
 	// UpstreamValidation defines how to verify the backend service's certificate
 	// +optional
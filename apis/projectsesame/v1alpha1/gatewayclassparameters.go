@@ -0,0 +1,121 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// GatewayClassParametersSpec defines how Sesame and Envoy are
+// provisioned for the Gateways of a GatewayClass.
+type GatewayClassParametersSpec struct {
+	// Replicas is the desired number of Sesame replicas for each
+	// Gateway. If unset, defaults to 2.
+	//
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// Config is the config that the instances of Sesame provisioned
+	// for each Gateway are to utilize. The Gateway settings are
+	// always set to the Gateway the instance is provisioned for.
+	//
+	// +optional
+	Config SesameConfigurationSpec `json:"config"`
+
+	// Envoy defines how the Envoy fleet for each Gateway is deployed.
+	// If unset, Envoy is run as a DaemonSet behind a LoadBalancer Service.
+	//
+	// +optional
+	Envoy *EnvoySettings `json:"envoy,omitempty"`
+
+	// EnvoyConfig overrides the Envoy settings of the Sesame that
+	// serves the Gateways of the GatewayClass.
+	//
+	// +optional
+	EnvoyConfig *GatewayEnvoyConfig `json:"envoyConfig,omitempty"`
+}
+
+// GatewayEnvoyConfig defines the Envoy settings that override
+// Sesame's configuration for the Gateways of a GatewayClass.
+type GatewayEnvoyConfig struct {
+	// HTTPListener overrides the port and access log of
+	// Envoy's HTTP listener.
+	//
+	// +optional
+	HTTPListener *GatewayEnvoyListener `json:"http,omitempty"`
+
+	// HTTPSListener overrides the port and access log of
+	// Envoy's HTTPS listener.
+	//
+	// +optional
+	HTTPSListener *GatewayEnvoyListener `json:"https,omitempty"`
+
+	// AccessLogFormat overrides the access log format.
+	// Valid options are 'envoy' or 'json'
+	//
+	// +kubebuilder:validation:Enum="envoy";"json"
+	// +optional
+	AccessLogFormat AccessLogType `json:"accessLogFormat,omitempty"`
+
+	// Timeouts overrides the connection and stream timeouts
+	// of Envoy's listeners. Only the timeouts that are set
+	// are overridden.
+	//
+	// +optional
+	Timeouts *TimeoutParameters `json:"timeouts,omitempty"`
+}
+
+// GatewayEnvoyListener defines the settings of an Envoy listener
+// that a GatewayClass overrides.
+type GatewayEnvoyListener struct {
+	// Port overrides the port the listener binds to.
+	// It is only supported for provisioned Gateways, since the
+	// provisioner also updates the target ports of the Envoy
+	// Service. A GatewayClass of a static Sesame that sets it
+	// is not accepted.
+	//
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port int `json:"port,omitempty"`
+
+	// AccessLog overrides where Envoy logs are outputted for this listener.
+	//
+	// +optional
+	AccessLog string `json:"accessLog,omitempty"`
+}
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Namespaced,shortName=gwclassparams
+
+// GatewayClassParameters is the schema for the parameters a
+// GatewayClass refers to with its parametersRef.
+type GatewayClassParameters struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec GatewayClassParametersSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// GatewayClassParametersList contains a list of GatewayClassParameters resources.
+type GatewayClassParametersList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []GatewayClassParameters `json:"items"`
+}
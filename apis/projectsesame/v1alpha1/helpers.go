@@ -15,6 +15,9 @@ package v1alpha1
 
 import (
 	"fmt"
+	"net"
+	"strconv"
+	"time"
 
 	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
 )
@@ -31,12 +34,36 @@ func (status *ExtensionServiceStatus) GetConditionFor(condType string) *sesame_a
 	return nil
 }
 
+// GetConditionFor returns the a pointer to the condition for a given type,
+// or nil if there are none currently present.
+func (status *SesameDeploymentStatus) GetConditionFor(condType string) *sesame_api_v1.DetailedCondition {
+	for i, cond := range status.Conditions {
+		if cond.Type == condType {
+			return &status.Conditions[i]
+		}
+	}
+
+	return nil
+}
+
 // Validate configuration that is not already covered by CRD validation.
 func (c *SesameConfigurationSpec) Validate() error {
 	if err := endpointsInConfict(c.Health, c.Metrics); err != nil {
 		return fmt.Errorf("invalid sesame configuration: %v", err)
 	}
 
+	if err := c.Webhook.Validate(); err != nil {
+		return fmt.Errorf("invalid sesame configuration: %v", err)
+	}
+
+	if err := c.CertificateRotation.Validate(); err != nil {
+		return fmt.Errorf("invalid sesame configuration: %v", err)
+	}
+
+	if err := c.Gateway.Validate(); err != nil {
+		return fmt.Errorf("invalid sesame configuration: %v", err)
+	}
+
 	return c.Envoy.Validate()
 }
 
@@ -45,6 +72,217 @@ func (e *EnvoyConfig) Validate() error {
 	if err := endpointsInConfict(e.Health, e.Metrics); err != nil {
 		return fmt.Errorf("invalid envoy configuration: %v", err)
 	}
+	if err := e.OverloadManager.Validate(); err != nil {
+		return fmt.Errorf("invalid envoy configuration: %v", err)
+	}
+	if err := e.HTTP3.Validate(); err != nil {
+		return fmt.Errorf("invalid envoy configuration: %v", err)
+	}
+	if err := e.Listener.TLS.Validate(); err != nil {
+		return fmt.Errorf("invalid envoy configuration: %v", err)
+	}
+	return nil
+}
+
+// Validate ensures that the certificate expiry warning is a valid,
+// non-negative duration.
+func (t EnvoyTLS) Validate() error {
+	if t.CertificateExpiryWarning == "" {
+		return nil
+	}
+
+	window, err := time.ParseDuration(t.CertificateExpiryWarning)
+	if err != nil {
+		return fmt.Errorf("invalid TLS certificateExpiryWarning %q: %v", t.CertificateExpiryWarning, err)
+	}
+	if window < 0 {
+		return fmt.Errorf("TLS certificateExpiryWarning %q must not be negative", t.CertificateExpiryWarning)
+	}
+
+	return nil
+}
+
+// Override applies the settings of the GatewayEnvoyConfig that are set
+// to the provided EnvoyConfig.
+func (g *GatewayEnvoyConfig) Override(envoy *EnvoyConfig) {
+	if g == nil {
+		return
+	}
+
+	g.HTTPListener.override(&envoy.HTTPListener)
+	g.HTTPSListener.override(&envoy.HTTPSListener)
+
+	if len(g.AccessLogFormat) > 0 {
+		envoy.Logging.AccessLogFormat = g.AccessLogFormat
+	}
+
+	if t := g.Timeouts; t != nil {
+		if envoy.Timeouts == nil {
+			envoy.Timeouts = &TimeoutParameters{}
+		}
+		if t.RequestTimeout != nil {
+			envoy.Timeouts.RequestTimeout = t.RequestTimeout
+		}
+		if t.ConnectionIdleTimeout != nil {
+			envoy.Timeouts.ConnectionIdleTimeout = t.ConnectionIdleTimeout
+		}
+		if t.StreamIdleTimeout != nil {
+			envoy.Timeouts.StreamIdleTimeout = t.StreamIdleTimeout
+		}
+		if t.MaxConnectionDuration != nil {
+			envoy.Timeouts.MaxConnectionDuration = t.MaxConnectionDuration
+		}
+		if t.DelayedCloseTimeout != nil {
+			envoy.Timeouts.DelayedCloseTimeout = t.DelayedCloseTimeout
+		}
+		if t.ConnectionShutdownGracePeriod != nil {
+			envoy.Timeouts.ConnectionShutdownGracePeriod = t.ConnectionShutdownGracePeriod
+		}
+	}
+}
+
+func (l *GatewayEnvoyListener) override(listener *EnvoyListener) {
+	if l == nil {
+		return
+	}
+	if l.Port != 0 {
+		listener.Port = l.Port
+	}
+	if len(l.AccessLog) > 0 {
+		listener.AccessLog = l.AccessLog
+	}
+}
+
+// Validate ensures that the HTTP/3 ports and flow control windows are
+// within the ranges that Envoy accepts.
+func (h *HTTP3Parameters) Validate() error {
+	if h == nil {
+		return nil
+	}
+
+	if h.Port < 0 || h.Port > 65535 {
+		return fmt.Errorf("invalid http3 port %d", h.Port)
+	}
+	if h.AdvertisedPort < 0 || h.AdvertisedPort > 65535 {
+		return fmt.Errorf("invalid http3 advertisedPort %d", h.AdvertisedPort)
+	}
+	if h.InitialStreamWindowSize > 16777216 {
+		return fmt.Errorf("http3 initialStreamWindowSize %d must not exceed 16777216", h.InitialStreamWindowSize)
+	}
+	if h.InitialConnectionWindowSize > 25165824 {
+		return fmt.Errorf("http3 initialConnectionWindowSize %d must not exceed 25165824", h.InitialConnectionWindowSize)
+	}
+
+	return nil
+}
+
+// Validate ensures that the heap thresholds are percentages of a
+// configured maximum heap size.
+func (o *OverloadManagerParameters) Validate() error {
+	if o == nil {
+		return nil
+	}
+
+	thresholds := []struct {
+		name  string
+		value uint32
+	}{
+		{"shrinkHeapThresholdPercent", o.ShrinkHeapThresholdPercent},
+		{"disableKeepaliveThresholdPercent", o.DisableKeepaliveThresholdPercent},
+		{"stopAcceptingRequestsThresholdPercent", o.StopAcceptingRequestsThresholdPercent},
+		{"stopAcceptingConnectionsThresholdPercent", o.StopAcceptingConnectionsThresholdPercent},
+	}
+
+	for _, t := range thresholds {
+		if t.value == 0 {
+			continue
+		}
+		if t.value > 100 {
+			return fmt.Errorf("overload manager %s %d must not exceed 100", t.name, t.value)
+		}
+		if o.MaxHeapSizeBytes == 0 {
+			return fmt.Errorf("overload manager %s requires maxHeapSizeBytes", t.name)
+		}
+	}
+
+	return nil
+}
+
+// Validate ensures that each Gateway is mapped at most once and
+// that the Envoy listeners of the mapped Gateways don't share an
+// address and port.
+func (g *GatewayConfig) Validate() error {
+	if g == nil {
+		return nil
+	}
+
+	gateways := map[NamespacedName]bool{}
+	addresses := map[string]bool{}
+
+	for _, m := range g.Gateways {
+		if m.Gateway.Name == "" || m.Gateway.Namespace == "" {
+			return fmt.Errorf("gateway mapping requires a gateway name and namespace")
+		}
+		if gateways[m.Gateway] {
+			return fmt.Errorf("gateway %s/%s is mapped more than once", m.Gateway.Namespace, m.Gateway.Name)
+		}
+		gateways[m.Gateway] = true
+
+		if m.Service != nil && (m.Service.Name == "" || m.Service.Namespace == "") {
+			return fmt.Errorf("service of gateway %s/%s requires a name and namespace", m.Gateway.Namespace, m.Gateway.Name)
+		}
+
+		for _, l := range []*EnvoyListener{m.HTTPListener, m.HTTPSListener} {
+			if l == nil {
+				continue
+			}
+
+			address := net.JoinHostPort(l.Address, strconv.Itoa(l.Port))
+			if addresses[address] {
+				return fmt.Errorf("gateway %s/%s listener address %s is already in use", m.Gateway.Namespace, m.Gateway.Name, address)
+			}
+			addresses[address] = true
+		}
+	}
+
+	return nil
+}
+
+// Validate ensures that the webhook is served over TLS, since the
+// Kubernetes API server only calls webhooks over HTTPS.
+func (w *WebhookConfig) Validate() error {
+	if w == nil {
+		return nil
+	}
+
+	if w.CertFile == "" || w.KeyFile == "" {
+		return fmt.Errorf("webhook requires both a certificate and key file")
+	}
+
+	return nil
+}
+
+// Validate ensures that certificates are renewed before they expire.
+func (r *CertificateRotationConfig) Validate() error {
+	if r == nil {
+		return nil
+	}
+
+	if len(r.SecretNames) == 0 {
+		return fmt.Errorf("certificate rotation requires at least one secret name")
+	}
+
+	renewBefore, err := time.ParseDuration(r.RenewBefore)
+	if err != nil {
+		return fmt.Errorf("invalid certificate rotation renewBefore %q: %v", r.RenewBefore, err)
+	}
+	if renewBefore <= 0 {
+		return fmt.Errorf("certificate rotation renewBefore must be positive")
+	}
+	if lifetime := time.Duration(r.Lifetime) * 24 * time.Hour; renewBefore >= lifetime {
+		return fmt.Errorf("certificate rotation renewBefore %q must be shorter than the certificate lifetime of %d days", r.RenewBefore, r.Lifetime)
+	}
+
 	return nil
 }
 
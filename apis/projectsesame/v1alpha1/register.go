@@ -21,6 +21,9 @@ import (
 
 var ExtensionServiceGVR = GroupVersion.WithResource("extensionservices")
 var SesameConfigurationGVR = GroupVersion.WithResource("sesameconfigurations")
+var SesameDeploymentGVR = GroupVersion.WithResource("sesamedeployments")
+var GatewayClassParametersGVR = GroupVersion.WithResource("gatewayclassparameters")
+var ServiceReferenceGrantGVR = GroupVersion.WithResource("servicereferencegrants")
 
 var (
 	// GroupVersion is group version used to register these objects
@@ -40,6 +43,12 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&ExtensionServiceList{},
 		&SesameConfiguration{},
 		&SesameConfigurationList{},
+		&SesameDeployment{},
+		&SesameDeploymentList{},
+		&GatewayClassParameters{},
+		&GatewayClassParametersList{},
+		&ServiceReferenceGrant{},
+		&ServiceReferenceGrantList{},
 	)
 
 	metav1.AddToGroupVersion(scheme, GroupVersion)
@@ -0,0 +1,93 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// KindService is the kind of a Kubernetes Service.
+	KindService = "Service"
+
+	// KindExtensionService is the kind of an ExtensionService.
+	KindExtensionService = "ExtensionService"
+)
+
+// ServiceReferenceGrantSpec defines which namespaces may route
+// traffic to the Services and ExtensionServices of the namespace
+// that the ServiceReferenceGrant is in.
+type ServiceReferenceGrantSpec struct {
+	// From lists the namespaces whose HTTPProxies may
+	// refer to the backends this grant covers.
+	//
+	// +kubebuilder:validation:MinItems=1
+	From []ServiceReferenceGrantFrom `json:"from"`
+
+	// To lists the backends in this namespace that
+	// may be referred to.
+	//
+	// +kubebuilder:validation:MinItems=1
+	To []ServiceReferenceGrantTo `json:"to"`
+}
+
+// ServiceReferenceGrantFrom describes the namespace
+// of the HTTPProxies that a grant applies to.
+type ServiceReferenceGrantFrom struct {
+	// Namespace is the namespace of the referring HTTPProxies.
+	// The value "*" matches all namespaces.
+	//
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+}
+
+// ServiceReferenceGrantTo describes the backends
+// that a grant applies to.
+type ServiceReferenceGrantTo struct {
+	// Kind is the kind of the backends, either Service or
+	// ExtensionService.
+	//
+	// +kubebuilder:validation:Enum=Service;ExtensionService
+	Kind string `json:"kind"`
+
+	// Name is the name of the backend. If unset, all the
+	// backends of the kind in this namespace are covered.
+	//
+	// +optional
+	Name string `json:"name,omitempty"`
+}
+
+// +genclient
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Namespaced,shortName=servicegrant
+
+// ServiceReferenceGrant permits HTTPProxies in other namespaces
+// to route traffic to the Services and ExtensionServices of the
+// namespace it is in.
+type ServiceReferenceGrant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ServiceReferenceGrantSpec `json:"spec"`
+}
+
+// +kubebuilder:object:root=true
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ServiceReferenceGrantList contains a list of ServiceReferenceGrant resources.
+type ServiceReferenceGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ServiceReferenceGrant `json:"items"`
+}
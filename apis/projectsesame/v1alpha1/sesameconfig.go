@@ -77,6 +77,16 @@ type SesameConfigurationSpec struct {
 	// +optional
 	// +kubebuilder:default={address: "0.0.0.0", port: 8000}
 	Metrics MetricsConfig `json:"metrics"`
+
+	// Webhook optionally enables the validating admission webhook
+	// for HTTPProxy and Sesame resources.
+	// +optional
+	Webhook *WebhookConfig `json:"webhook,omitempty"`
+
+	// CertificateRotation optionally enables automatic rotation of
+	// the certificates used to secure the xDS connection.
+	// +optional
+	CertificateRotation *CertificateRotationConfig `json:"certificateRotation,omitempty"`
 }
 
 // XDSServerType is the type of xDS server implementation.
@@ -110,6 +120,46 @@ type GatewayConfig struct {
 	// If unset, the gatewayclass controller will not be started.
 	// +kubebuilder:default="projectsesame.io/projectsesame/sesame"
 	ControllerName string `json:"controllerName"`
+
+	// GatewayRef defines a specific Gateway that this Sesame
+	// instance corresponds to. If set, Sesame will reconcile
+	// only this gateway, and will not reconcile any gateway
+	// classes. Each port of the Gateway's listeners is served
+	// by an Envoy listener of its own.
+	// +optional
+	GatewayRef *NamespacedName `json:"gatewayRef,omitempty"`
+
+	// Gateways maps Gateways onto their own Envoy listeners and
+	// services. Gateways that are not listed share Envoy's HTTP
+	// and HTTPS listeners, and have the addresses of Envoy's
+	// service written to their status.
+	// +optional
+	Gateways []GatewayEnvoyMapping `json:"gateways,omitempty"`
+}
+
+// GatewayEnvoyMapping maps a Gateway onto the Envoy listeners that
+// serve its listeners and the Envoy service that exposes them.
+type GatewayEnvoyMapping struct {
+	// Gateway is the namespace and name of the Gateway.
+	Gateway NamespacedName `json:"gateway"`
+
+	// HTTPListener is the Envoy listener that serves the Gateway's
+	// HTTP listeners. If unset, Envoy's HTTP listener serves them.
+	// +optional
+	HTTPListener *EnvoyListener `json:"http,omitempty"`
+
+	// HTTPSListener is the Envoy listener that serves the Gateway's
+	// HTTPS and TLS listeners. If unset, Envoy's HTTPS listener
+	// serves them.
+	// +optional
+	HTTPSListener *EnvoyListener `json:"https,omitempty"`
+
+	// Service is the Envoy service that exposes the Gateway's
+	// listeners. Its load balancer addresses are written to the
+	// Gateway's status. If unset, the addresses of Envoy's service
+	// are written.
+	// +optional
+	Service *NamespacedName `json:"service,omitempty"`
 }
 
 // TLS holds TLS file config details.
@@ -182,6 +232,54 @@ type MetricsTLS struct {
 	KeyFile string `json:"keyFile,omitempty"`
 }
 
+// WebhookConfig defines the validating admission webhook endpoint.
+type WebhookConfig struct {
+	// Defines the webhook address interface.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:default="0.0.0.0"
+	Address string `json:"address"`
+
+	// Defines the webhook port.
+	// +kubebuilder:default=9443
+	Port int `json:"port"`
+
+	// Serving certificate filename.
+	// +kubebuilder:validation:MinLength=1
+	CertFile string `json:"certFile"`
+
+	// Serving key filename.
+	// +kubebuilder:validation:MinLength=1
+	KeyFile string `json:"keyFile"`
+}
+
+// CertificateRotationConfig defines how Sesame rotates the certificates
+// used to secure the xDS connection between Sesame and Envoy.
+type CertificateRotationConfig struct {
+	// Namespace of the CA and certificate Secrets.
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+
+	// Name of the Secret holding the CA certificate and key used
+	// to issue the rotated certificates.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:default="sesame-ca"
+	CASecretName string `json:"caSecretName"`
+
+	// Names of the Secrets holding the certificates to rotate.
+	// +kubebuilder:default={"sesamecert", "envoycert"}
+	SecretNames []string `json:"secretNames"`
+
+	// RenewBefore is how long before expiry a certificate is
+	// re-issued, as a duration string.
+	// +kubebuilder:default="720h"
+	RenewBefore string `json:"renewBefore"`
+
+	// Lifetime of re-issued certificates, in days.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=365
+	Lifetime uint `json:"lifetime"`
+}
+
 // HTTPVersionType is the name of a supported HTTP version.
 // +kubebuilder:validation:Enum="HTTP/1.1";"HTTP/2"
 type HTTPVersionType string
@@ -242,6 +340,96 @@ type EnvoyConfig struct {
 
 	// Network holds various configurable Envoy network values.
 	Network NetworkParameters `json:"network"`
+
+	// OverloadManager configures the Envoy overload manager and the
+	// global downstream connection limit, which protect Envoy from
+	// running out of memory under load. These settings are rendered
+	// into the bootstrap configuration by `sesame bootstrap`, which
+	// the provisioners pass them to with its --overload-* flags.
+	// +optional
+	OverloadManager *OverloadManagerParameters `json:"overloadManager,omitempty"`
+
+	// HTTP3 enables an HTTP/3 (QUIC) listener that serves the same TLS
+	// virtual hosts as the HTTPS listener. HTTPS responses advertise it
+	// to clients with an alt-svc header.
+	// +optional
+	HTTP3 *HTTP3Parameters `json:"http3,omitempty"`
+}
+
+// HTTP3Parameters holds the configuration of the HTTP/3 listener.
+type HTTP3Parameters struct {
+	// Address is the address that the UDP listener binds to.
+	// Defaults to the HTTPS listener address.
+	// +optional
+	Address string `json:"address,omitempty"`
+
+	// Port is the UDP port that the listener binds to.
+	// Defaults to the HTTPS listener port.
+	// +optional
+	Port int `json:"port,omitempty"`
+
+	// AdvertisedPort is the UDP port that clients connect to, which
+	// is advertised in the alt-svc header. This is usually the port of
+	// the Envoy Service rather than the port Envoy binds to.
+	// Defaults to 443.
+	// +optional
+	AdvertisedPort int `json:"advertisedPort,omitempty"`
+
+	// MaxConcurrentStreams is the maximum number of concurrent streams
+	// per QUIC connection.
+	// +optional
+	MaxConcurrentStreams uint32 `json:"maxConcurrentStreams,omitempty"`
+
+	// InitialStreamWindowSize is the initial stream level flow control
+	// window, in bytes.
+	// +kubebuilder:validation:Maximum=16777216
+	// +optional
+	InitialStreamWindowSize uint32 `json:"initialStreamWindowSize,omitempty"`
+
+	// InitialConnectionWindowSize is the initial connection level flow
+	// control window, in bytes.
+	// +kubebuilder:validation:Maximum=25165824
+	// +optional
+	InitialConnectionWindowSize uint32 `json:"initialConnectionWindowSize,omitempty"`
+}
+
+// OverloadManagerParameters holds the Envoy overload manager settings.
+// The thresholds are percentages of MaxHeapSizeBytes, and require it
+// to be set.
+type OverloadManagerParameters struct {
+	// MaxHeapSizeBytes is the maximum heap size of Envoy, in bytes.
+	// Setting it enables the overload manager.
+	// +optional
+	MaxHeapSizeBytes uint64 `json:"maxHeapSizeBytes,omitempty"`
+
+	// MaxActiveDownstreamConnections is the maximum number of active
+	// downstream connections across all of Envoy's listeners.
+	// +optional
+	MaxActiveDownstreamConnections uint64 `json:"maxActiveDownstreamConnections,omitempty"`
+
+	// ShrinkHeapThresholdPercent is the heap usage at which Envoy
+	// releases free memory back to the system.
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	ShrinkHeapThresholdPercent uint32 `json:"shrinkHeapThresholdPercent,omitempty"`
+
+	// DisableKeepaliveThresholdPercent is the heap usage at which Envoy
+	// disables HTTP keepalive, so that clients reconnect elsewhere.
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	DisableKeepaliveThresholdPercent uint32 `json:"disableKeepaliveThresholdPercent,omitempty"`
+
+	// StopAcceptingRequestsThresholdPercent is the heap usage at which
+	// Envoy stops accepting new requests.
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	StopAcceptingRequestsThresholdPercent uint32 `json:"stopAcceptingRequestsThresholdPercent,omitempty"`
+
+	// StopAcceptingConnectionsThresholdPercent is the heap usage at
+	// which Envoy stops accepting new connections.
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	StopAcceptingConnectionsThresholdPercent uint32 `json:"stopAcceptingConnectionsThresholdPercent,omitempty"`
 }
 
 // LogLevel is the logging levels available.
@@ -318,6 +506,13 @@ type EnvoyTLS struct {
 	//See: https://www.envoyproxy.io/docs/envoy/latest/api-v3/extensions/transport_sockets/tls/v3/common.proto#extensions-transport-sockets-tls-v3-tlsparameters
 	// Note: This list is a superset of what is valid for stock Envoy builds and those using BoringSSL FIPS.
 	CipherSuites []TLSCipherType `json:"cipherSuites"`
+
+	// CertificateExpiryWarning is how long before expiry a serving
+	// certificate referenced by an HTTPProxy or Gateway is reported
+	// as expiring in its status. Set to "0s" to disable the warning.
+	// Defaults to "720h".
+	// +optional
+	CertificateExpiryWarning string `json:"certificateExpiryWarning,omitempty"`
 }
 
 // EnvoyListener defines parameters for an Envoy Listener.
@@ -331,6 +526,14 @@ type EnvoyListener struct {
 
 	// AccessLog defines where Envoy logs are outputted for this listener.
 	AccessLog string `json:"accessLog"`
+
+	// MaxConnections is the maximum number of active connections on
+	// each filter chain of this listener. For the HTTPS listener, each
+	// virtual host has its own filter chain. Use the overload manager
+	// maxActiveDownstreamConnections field to limit the connections
+	// across all listeners. Zero means unlimited.
+	// +optional
+	MaxConnections uint32 `json:"maxConnections,omitempty"`
 }
 
 // EnvoyLogging defines how Envoy's logs can be configured.
@@ -438,6 +641,18 @@ type ClusterParameters struct {
 	// +kubebuilder:default="auto"
 	// +kubebuilder:validation:Enum="auto";"v4";"v6"
 	DNSLookupFamily ClusterDNSFamilyType `json:"dnsLookupFamily"`
+
+	// ConnectionPolicy defines the default connection pool settings for
+	// clusters generated from HTTPProxy services. Settings on an individual
+	// HTTPProxy service take precedence over these defaults.
+	// +optional
+	ConnectionPolicy *sesame_api_v1.ConnectionPolicy `json:"connectionPolicy,omitempty"`
+
+	// RetryBudget defines the default retry budget for clusters generated
+	// from HTTPProxy services. It is not applied to services that set
+	// their own retry budget or a maximum number of parallel retries.
+	// +optional
+	RetryBudget *sesame_api_v1.RetryBudget `json:"retryBudget,omitempty"`
 }
 
 // HTTPProxyConfig defines parameters on HTTPProxy.
@@ -514,6 +729,15 @@ type HeadersPolicy struct {
 	// +optional
 	Set map[string]string `json:"set,omitempty"`
 
+	// Add appends the given values to any existing values of the headers.
+	// +optional
+	Add map[string]string `json:"add,omitempty"`
+
+	// AddIfAbsent adds the given headers only if they are not already present.
+	// Requires Envoy 1.22 or later.
+	// +optional
+	AddIfAbsent map[string]string `json:"addIfAbsent,omitempty"`
+
 	// +optional
 	Remove []string `json:"remove,omitempty"`
 }
@@ -15,6 +15,7 @@ package v1alpha1
 
 import (
 	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -30,6 +31,47 @@ type SesameDeploymentSpec struct {
 
 	// Config is the config that the instances of Sesame are to utilize.
 	Config SesameConfigurationSpec `json:"config"`
+
+	// Envoy defines how the Envoy fleet for this Sesame is deployed.
+	// If unset, Envoy is run as a DaemonSet behind a LoadBalancer Service.
+	//
+	// +optional
+	Envoy *EnvoySettings `json:"envoy,omitempty"`
+}
+
+// WorkloadType is the type of Kubernetes workload used to run Envoy.
+type WorkloadType string
+
+const (
+	// WorkloadTypeDaemonSet runs Envoy as a DaemonSet.
+	WorkloadTypeDaemonSet WorkloadType = "DaemonSet"
+
+	// WorkloadTypeDeployment runs Envoy as a Deployment.
+	WorkloadTypeDeployment WorkloadType = "Deployment"
+)
+
+// EnvoySettings defines how the Envoy fleet is deployed.
+type EnvoySettings struct {
+	// WorkloadType is the type of workload used to run Envoy.
+	// If unset, defaults to DaemonSet.
+	//
+	// +kubebuilder:validation:Enum=DaemonSet;Deployment
+	// +optional
+	WorkloadType WorkloadType `json:"workloadType,omitempty"`
+
+	// Replicas is the desired number of Envoy replicas when
+	// WorkloadType is Deployment. If unset, defaults to 2.
+	//
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// ServiceType is the type of the Service that exposes Envoy.
+	// If unset, defaults to LoadBalancer.
+	//
+	// +kubebuilder:validation:Enum=LoadBalancer;NodePort;ClusterIP
+	// +optional
+	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
 }
 
 // SesameDeploymentStatus defines the observed state of a SesameDeployment resource.
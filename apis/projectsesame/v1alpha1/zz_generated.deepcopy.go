@@ -44,9 +44,39 @@ func (in AccessLogFields) DeepCopy() AccessLogFields {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateRotationConfig) DeepCopyInto(out *CertificateRotationConfig) {
+	*out = *in
+	if in.SecretNames != nil {
+		in, out := &in.SecretNames, &out.SecretNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateRotationConfig.
+func (in *CertificateRotationConfig) DeepCopy() *CertificateRotationConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateRotationConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterParameters) DeepCopyInto(out *ClusterParameters) {
 	*out = *in
+	if in.ConnectionPolicy != nil {
+		in, out := &in.ConnectionPolicy, &out.ConnectionPolicy
+		*out = new(v1.ConnectionPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RetryBudget != nil {
+		in, out := &in.RetryBudget, &out.RetryBudget
+		*out = new(v1.RetryBudget)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterParameters.
@@ -99,8 +129,18 @@ func (in *EnvoyConfig) DeepCopyInto(out *EnvoyConfig) {
 		*out = new(TimeoutParameters)
 		(*in).DeepCopyInto(*out)
 	}
-	out.Cluster = in.Cluster
+	in.Cluster.DeepCopyInto(&out.Cluster)
 	out.Network = in.Network
+	if in.OverloadManager != nil {
+		in, out := &in.OverloadManager, &out.OverloadManager
+		*out = new(OverloadManagerParameters)
+		**out = **in
+	}
+	if in.HTTP3 != nil {
+		in, out := &in.HTTP3, &out.HTTP3
+		*out = new(HTTP3Parameters)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoyConfig.
@@ -169,6 +209,21 @@ func (in *EnvoyLogging) DeepCopy() *EnvoyLogging {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EnvoySettings) DeepCopyInto(out *EnvoySettings) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EnvoySettings.
+func (in *EnvoySettings) DeepCopy() *EnvoySettings {
+	if in == nil {
+		return nil
+	}
+	out := new(EnvoySettings)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvoyTLS) DeepCopyInto(out *EnvoyTLS) {
 	*out = *in
@@ -216,6 +271,21 @@ func (in *ExtensionService) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExtensionServiceEndpoint) DeepCopyInto(out *ExtensionServiceEndpoint) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExtensionServiceEndpoint.
+func (in *ExtensionServiceEndpoint) DeepCopy() *ExtensionServiceEndpoint {
+	if in == nil {
+		return nil
+	}
+	out := new(ExtensionServiceEndpoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ExtensionServiceList) DeepCopyInto(out *ExtensionServiceList) {
 	*out = *in
@@ -256,6 +326,11 @@ func (in *ExtensionServiceSpec) DeepCopyInto(out *ExtensionServiceSpec) {
 		*out = make([]ExtensionServiceTarget, len(*in))
 		copy(*out, *in)
 	}
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]ExtensionServiceEndpoint, len(*in))
+		copy(*out, *in)
+	}
 	if in.UpstreamValidation != nil {
 		in, out := &in.UpstreamValidation, &out.UpstreamValidation
 		*out = new(v1.UpstreamValidation)
@@ -325,9 +400,105 @@ func (in *ExtensionServiceTarget) DeepCopy() *ExtensionServiceTarget {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayClassParameters) DeepCopyInto(out *GatewayClassParameters) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayClassParameters.
+func (in *GatewayClassParameters) DeepCopy() *GatewayClassParameters {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayClassParameters)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GatewayClassParameters) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayClassParametersList) DeepCopyInto(out *GatewayClassParametersList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]GatewayClassParameters, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayClassParametersList.
+func (in *GatewayClassParametersList) DeepCopy() *GatewayClassParametersList {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayClassParametersList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GatewayClassParametersList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayClassParametersSpec) DeepCopyInto(out *GatewayClassParametersSpec) {
+	*out = *in
+	in.Config.DeepCopyInto(&out.Config)
+	if in.Envoy != nil {
+		in, out := &in.Envoy, &out.Envoy
+		*out = new(EnvoySettings)
+		**out = **in
+	}
+	if in.EnvoyConfig != nil {
+		in, out := &in.EnvoyConfig, &out.EnvoyConfig
+		*out = new(GatewayEnvoyConfig)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayClassParametersSpec.
+func (in *GatewayClassParametersSpec) DeepCopy() *GatewayClassParametersSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayClassParametersSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GatewayConfig) DeepCopyInto(out *GatewayConfig) {
 	*out = *in
+	if in.GatewayRef != nil {
+		in, out := &in.GatewayRef, &out.GatewayRef
+		*out = new(NamespacedName)
+		**out = **in
+	}
+	if in.Gateways != nil {
+		in, out := &in.Gateways, &out.Gateways
+		*out = make([]GatewayEnvoyMapping, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayConfig.
@@ -340,6 +511,97 @@ func (in *GatewayConfig) DeepCopy() *GatewayConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayEnvoyConfig) DeepCopyInto(out *GatewayEnvoyConfig) {
+	*out = *in
+	if in.HTTPListener != nil {
+		in, out := &in.HTTPListener, &out.HTTPListener
+		*out = new(GatewayEnvoyListener)
+		**out = **in
+	}
+	if in.HTTPSListener != nil {
+		in, out := &in.HTTPSListener, &out.HTTPSListener
+		*out = new(GatewayEnvoyListener)
+		**out = **in
+	}
+	if in.Timeouts != nil {
+		in, out := &in.Timeouts, &out.Timeouts
+		*out = new(TimeoutParameters)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayEnvoyConfig.
+func (in *GatewayEnvoyConfig) DeepCopy() *GatewayEnvoyConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayEnvoyConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayEnvoyListener) DeepCopyInto(out *GatewayEnvoyListener) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayEnvoyListener.
+func (in *GatewayEnvoyListener) DeepCopy() *GatewayEnvoyListener {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayEnvoyListener)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GatewayEnvoyMapping) DeepCopyInto(out *GatewayEnvoyMapping) {
+	*out = *in
+	out.Gateway = in.Gateway
+	if in.HTTPListener != nil {
+		in, out := &in.HTTPListener, &out.HTTPListener
+		*out = new(EnvoyListener)
+		**out = **in
+	}
+	if in.HTTPSListener != nil {
+		in, out := &in.HTTPSListener, &out.HTTPSListener
+		*out = new(EnvoyListener)
+		**out = **in
+	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(NamespacedName)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GatewayEnvoyMapping.
+func (in *GatewayEnvoyMapping) DeepCopy() *GatewayEnvoyMapping {
+	if in == nil {
+		return nil
+	}
+	out := new(GatewayEnvoyMapping)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTP3Parameters) DeepCopyInto(out *HTTP3Parameters) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTP3Parameters.
+func (in *HTTP3Parameters) DeepCopy() *HTTP3Parameters {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTP3Parameters)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HTTPProxyConfig) DeepCopyInto(out *HTTPProxyConfig) {
 	*out = *in
@@ -375,6 +637,20 @@ func (in *HeadersPolicy) DeepCopyInto(out *HeadersPolicy) {
 			(*out)[key] = val
 		}
 	}
+	if in.Add != nil {
+		in, out := &in.Add, &out.Add
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.AddIfAbsent != nil {
+		in, out := &in.AddIfAbsent, &out.AddIfAbsent
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.Remove != nil {
 		in, out := &in.Remove, &out.Remove
 		*out = make([]string, len(*in))
@@ -497,6 +773,21 @@ func (in *NetworkParameters) DeepCopy() *NetworkParameters {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OverloadManagerParameters) DeepCopyInto(out *OverloadManagerParameters) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OverloadManagerParameters.
+func (in *OverloadManagerParameters) DeepCopy() *OverloadManagerParameters {
+	if in == nil {
+		return nil
+	}
+	out := new(OverloadManagerParameters)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PolicyConfig) DeepCopyInto(out *PolicyConfig) {
 	*out = *in
@@ -538,6 +829,119 @@ func (in *RateLimitServiceConfig) DeepCopy() *RateLimitServiceConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceReferenceGrant) DeepCopyInto(out *ServiceReferenceGrant) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceReferenceGrant.
+func (in *ServiceReferenceGrant) DeepCopy() *ServiceReferenceGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceReferenceGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServiceReferenceGrant) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceReferenceGrantFrom) DeepCopyInto(out *ServiceReferenceGrantFrom) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceReferenceGrantFrom.
+func (in *ServiceReferenceGrantFrom) DeepCopy() *ServiceReferenceGrantFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceReferenceGrantFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceReferenceGrantList) DeepCopyInto(out *ServiceReferenceGrantList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ServiceReferenceGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceReferenceGrantList.
+func (in *ServiceReferenceGrantList) DeepCopy() *ServiceReferenceGrantList {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceReferenceGrantList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ServiceReferenceGrantList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceReferenceGrantSpec) DeepCopyInto(out *ServiceReferenceGrantSpec) {
+	*out = *in
+	if in.From != nil {
+		in, out := &in.From, &out.From
+		*out = make([]ServiceReferenceGrantFrom, len(*in))
+		copy(*out, *in)
+	}
+	if in.To != nil {
+		in, out := &in.To, &out.To
+		*out = make([]ServiceReferenceGrantTo, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceReferenceGrantSpec.
+func (in *ServiceReferenceGrantSpec) DeepCopy() *ServiceReferenceGrantSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceReferenceGrantSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceReferenceGrantTo) DeepCopyInto(out *ServiceReferenceGrantTo) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceReferenceGrantTo.
+func (in *ServiceReferenceGrantTo) DeepCopy() *ServiceReferenceGrantTo {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceReferenceGrantTo)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SesameConfiguration) DeepCopyInto(out *SesameConfiguration) {
 	*out = *in
@@ -612,7 +1016,7 @@ func (in *SesameConfigurationSpec) DeepCopyInto(out *SesameConfigurationSpec) {
 	if in.Gateway != nil {
 		in, out := &in.Gateway, &out.Gateway
 		*out = new(GatewayConfig)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	in.HTTPProxy.DeepCopyInto(&out.HTTPProxy)
 	if in.RateLimitService != nil {
@@ -626,6 +1030,16 @@ func (in *SesameConfigurationSpec) DeepCopyInto(out *SesameConfigurationSpec) {
 		(*in).DeepCopyInto(*out)
 	}
 	in.Metrics.DeepCopyInto(&out.Metrics)
+	if in.Webhook != nil {
+		in, out := &in.Webhook, &out.Webhook
+		*out = new(WebhookConfig)
+		**out = **in
+	}
+	if in.CertificateRotation != nil {
+		in, out := &in.CertificateRotation, &out.CertificateRotation
+		*out = new(CertificateRotationConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SesameConfigurationSpec.
@@ -723,6 +1137,11 @@ func (in *SesameDeploymentList) DeepCopyObject() runtime.Object {
 func (in *SesameDeploymentSpec) DeepCopyInto(out *SesameDeploymentSpec) {
 	*out = *in
 	in.Config.DeepCopyInto(&out.Config)
+	if in.Envoy != nil {
+		in, out := &in.Envoy, &out.Envoy
+		*out = new(EnvoySettings)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SesameDeploymentSpec.
@@ -817,6 +1236,21 @@ func (in *TimeoutParameters) DeepCopy() *TimeoutParameters {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookConfig) DeepCopyInto(out *WebhookConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookConfig.
+func (in *WebhookConfig) DeepCopy() *WebhookConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *XDSServerConfig) DeepCopyInto(out *XDSServerConfig) {
 	*out = *in
@@ -14,27 +14,95 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/projectsesame/sesame/internal/envoy"
+	"github.com/projectsesame/sesame/pkg/config"
 	kingpin "gopkg.in/alecthomas/kingpin.v2"
 )
 
 // registerBootstrap registers the bootstrap subcommand and flags
 // with the Application provided.
 func registerBootstrap(app *kingpin.Application) (*kingpin.CmdClause, *envoy.BootstrapConfig) {
-	var config envoy.BootstrapConfig
+	var (
+		bootstrapConfig envoy.BootstrapConfig
+		configFile      string
+	)
+
+	// parseConfig reads the bootstrap section of the Sesame
+	// configuration file, if one is given.
+	parseConfig := func(_ *kingpin.ParseContext) error {
+		if configFile == "" {
+			return nil
+		}
+
+		f, err := os.Open(configFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		params, err := config.Parse(f)
+		if err != nil {
+			return err
+		}
+
+		if err := params.Validate(); err != nil {
+			return fmt.Errorf("invalid Sesame configuration: %w", err)
+		}
+
+		// The overload manager flags have already been parsed
+		// and take precedence over the configuration file.
+		overloadManager := bootstrapConfig.Parameters.OverloadManager
+		bootstrapConfig.Parameters = params.Bootstrap
+		overrideOverloadManager(&bootstrapConfig.Parameters.OverloadManager, overloadManager)
+		return nil
+	}
 
 	bootstrap := app.Command("bootstrap", "Generate bootstrap configuration.")
-	bootstrap.Arg("path", "Configuration file ('-' for standard output).").Required().StringVar(&config.Path)
-	bootstrap.Flag("resources-dir", "Directory where configuration files will be written to.").StringVar(&config.ResourcesDir)
-	bootstrap.Flag("admin-address", "Path to Envoy admin unix domain socket.").Default("/admin/admin.sock").StringVar(&config.AdminAddress)
-	bootstrap.Flag("admin-port", "DEPRECATED: Envoy admin interface port.").IntVar(&config.AdminPort)
-	bootstrap.Flag("xds-address", "xDS gRPC API address.").StringVar(&config.XDSAddress)
-	bootstrap.Flag("xds-port", "xDS gRPC API port.").IntVar(&config.XDSGRPCPort)
-	bootstrap.Flag("envoy-cafile", "CA Filename for Envoy secure xDS gRPC communication.").Envar("ENVOY_CAFILE").StringVar(&config.GrpcCABundle)
-	bootstrap.Flag("envoy-cert-file", "Client certificate filename for Envoy secure xDS gRPC communication.").Envar("ENVOY_CERT_FILE").StringVar(&config.GrpcClientCert)
-	bootstrap.Flag("envoy-key-file", "Client key filename for Envoy secure xDS gRPC communication.").Envar("ENVOY_KEY_FILE").StringVar(&config.GrpcClientKey)
-	bootstrap.Flag("namespace", "The namespace the Envoy container will run in.").Envar("Sesame_NAMESPACE").Default("projectsesame").StringVar(&config.Namespace)
-	bootstrap.Flag("xds-resource-version", "The versions of the xDS resources to request from Sesame.").Default("v3").StringVar((*string)(&config.XDSResourceVersion))
-	bootstrap.Flag("dns-lookup-family", "Defines what DNS Resolution Policy to use for Envoy -> Sesame cluster name lookup. Either v4, v6 or auto.").StringVar(&config.DNSLookupFamily)
-	return bootstrap, &config
+	bootstrap.Arg("path", "Configuration file ('-' for standard output).").Required().StringVar(&bootstrapConfig.Path)
+	bootstrap.Flag("config-path", "Path to the Sesame configuration file containing the bootstrap section.").Short('c').PlaceHolder("/path/to/file").Action(parseConfig).ExistingFileVar(&configFile)
+	bootstrap.Flag("resources-dir", "Directory where configuration files will be written to.").StringVar(&bootstrapConfig.ResourcesDir)
+	bootstrap.Flag("admin-address", "Path to Envoy admin unix domain socket.").Default("/admin/admin.sock").StringVar(&bootstrapConfig.AdminAddress)
+	bootstrap.Flag("admin-port", "DEPRECATED: Envoy admin interface port.").IntVar(&bootstrapConfig.AdminPort)
+	bootstrap.Flag("xds-address", "xDS gRPC API address.").StringVar(&bootstrapConfig.XDSAddress)
+	bootstrap.Flag("xds-port", "xDS gRPC API port.").IntVar(&bootstrapConfig.XDSGRPCPort)
+	bootstrap.Flag("envoy-cafile", "CA Filename for Envoy secure xDS gRPC communication.").Envar("ENVOY_CAFILE").StringVar(&bootstrapConfig.GrpcCABundle)
+	bootstrap.Flag("envoy-cert-file", "Client certificate filename for Envoy secure xDS gRPC communication.").Envar("ENVOY_CERT_FILE").StringVar(&bootstrapConfig.GrpcClientCert)
+	bootstrap.Flag("envoy-key-file", "Client key filename for Envoy secure xDS gRPC communication.").Envar("ENVOY_KEY_FILE").StringVar(&bootstrapConfig.GrpcClientKey)
+	bootstrap.Flag("namespace", "The namespace the Envoy container will run in.").Envar("Sesame_NAMESPACE").Default("projectsesame").StringVar(&bootstrapConfig.Namespace)
+	bootstrap.Flag("xds-resource-version", "The versions of the xDS resources to request from Sesame.").Default("v3").StringVar((*string)(&bootstrapConfig.XDSResourceVersion))
+	bootstrap.Flag("dns-lookup-family", "Defines what DNS Resolution Policy to use for Envoy -> Sesame cluster name lookup. Either v4, v6 or auto.").StringVar(&bootstrapConfig.DNSLookupFamily)
+
+	overloadManager := &bootstrapConfig.Parameters.OverloadManager
+	bootstrap.Flag("overload-max-heap-size-bytes", "Maximum heap size of Envoy, in bytes. Enables the overload manager.").Uint64Var(&overloadManager.MaxHeapSizeBytes)
+	bootstrap.Flag("overload-max-active-downstream-connections", "Maximum number of active downstream connections across all of Envoy's listeners.").Uint64Var(&overloadManager.MaxActiveDownstreamConnections)
+	bootstrap.Flag("overload-shrink-heap-threshold", "Fraction of the maximum heap size at which Envoy releases free memory.").Float64Var(&overloadManager.ShrinkHeapThreshold)
+	bootstrap.Flag("overload-disable-keepalive-threshold", "Fraction of the maximum heap size at which Envoy disables HTTP keepalive.").Float64Var(&overloadManager.DisableKeepaliveThreshold)
+	bootstrap.Flag("overload-stop-accepting-requests-threshold", "Fraction of the maximum heap size at which Envoy stops accepting requests.").Float64Var(&overloadManager.StopAcceptingRequestsThreshold)
+	bootstrap.Flag("overload-stop-accepting-connections-threshold", "Fraction of the maximum heap size at which Envoy stops accepting connections.").Float64Var(&overloadManager.StopAcceptingConnectionsThreshold)
+	return bootstrap, &bootstrapConfig
+}
+
+// overrideOverloadManager applies the settings of overrides that are set to dst.
+func overrideOverloadManager(dst *config.OverloadManagerParameters, overrides config.OverloadManagerParameters) {
+	if overrides.MaxHeapSizeBytes > 0 {
+		dst.MaxHeapSizeBytes = overrides.MaxHeapSizeBytes
+	}
+	if overrides.MaxActiveDownstreamConnections > 0 {
+		dst.MaxActiveDownstreamConnections = overrides.MaxActiveDownstreamConnections
+	}
+	if overrides.ShrinkHeapThreshold > 0 {
+		dst.ShrinkHeapThreshold = overrides.ShrinkHeapThreshold
+	}
+	if overrides.DisableKeepaliveThreshold > 0 {
+		dst.DisableKeepaliveThreshold = overrides.DisableKeepaliveThreshold
+	}
+	if overrides.StopAcceptingRequestsThreshold > 0 {
+		dst.StopAcceptingRequestsThreshold = overrides.StopAcceptingRequestsThreshold
+	}
+	if overrides.StopAcceptingConnectionsThreshold > 0 {
+		dst.StopAcceptingConnectionsThreshold = overrides.StopAcceptingConnectionsThreshold
+	}
 }
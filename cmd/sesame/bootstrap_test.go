@@ -0,0 +1,53 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/projectsesame/sesame/pkg/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+)
+
+func TestBootstrapOverloadManagerFlags(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "sesame.yaml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+bootstrap:
+  overload-manager:
+    max-heap-size-bytes: 1024
+    shrink-heap-threshold: 0.95
+`), 0600))
+
+	app := kingpin.New("sesame", "")
+	_, bootstrapConfig := registerBootstrap(app)
+
+	_, err := app.Parse([]string{
+		"bootstrap", "envoy.json",
+		"--overload-shrink-heap-threshold=0.8",
+		"--config-path=" + configFile,
+		"--overload-max-active-downstream-connections=10000",
+	})
+	require.NoError(t, err)
+
+	// The flags take precedence over the configuration file.
+	assert.Equal(t, config.OverloadManagerParameters{
+		MaxHeapSizeBytes:               1024,
+		ShrinkHeapThreshold:            0.8,
+		MaxActiveDownstreamConnections: 10000,
+	}, bootstrapConfig.Parameters.OverloadManager)
+}
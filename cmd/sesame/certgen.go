@@ -15,6 +15,7 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -42,8 +43,15 @@ func registerCertGen(app *kingpin.Application) (*kingpin.CmdClause, *certgenConf
 	certgenApp.Flag("namespace", "Kubernetes namespace, used for Kube objects.").Default(certs.DefaultNamespace).Envar("Sesame_NAMESPACE").StringVar(&certgenConfig.Namespace)
 	// NOTE: --certificate-lifetime can be used to accept Duration string once certificate rotation is supported.
 	certgenApp.Flag("certificate-lifetime", "Generated certificate lifetime (in days).").Default(strconv.Itoa(certs.DefaultCertificateLifetime)).UintVar(&certgenConfig.Lifetime)
+	certgenApp.Flag("ca-lifetime", "Generated CA certificate lifetime (in days).").Default(strconv.Itoa(certs.DefaultCALifetime)).UintVar(&certgenConfig.CALifetime)
 	certgenApp.Flag("overwrite", "Overwrite existing files or Secrets.").BoolVar(&certgenConfig.Overwrite)
 	certgenApp.Flag("secrets-format", "Specify how to format the generated Kubernetes Secrets.").Default("legacy").StringVar(&certgenConfig.Format)
+	certgenApp.Flag("webhook-service-name", "Also generate a certificate for the admission webhook Service with this name.").StringVar(&certgenConfig.WebhookServiceName)
+	certgenApp.Flag("key-type", "Type of the generated private keys.").Default(string(certs.DefaultKeyType)).EnumVar(&certgenConfig.KeyType, keyTypes()...)
+	certgenApp.Flag("ca-cert", "Issue the certificates from the existing CA certificate (or chain) in this PEM file, rather than a new self-signed CA.").PlaceHolder("/path/to/file").ExistingFileVar(&certgenConfig.CACertFile)
+	certgenApp.Flag("ca-key", "Private key in PEM form for the CA certificate given by --ca-cert.").PlaceHolder("/path/to/file").ExistingFileVar(&certgenConfig.CAKeyFile)
+	certgenApp.Flag("secrets-name-suffix", "Suffix appended to the names of the generated Secrets.").StringVar(&certgenConfig.NameSuffix)
+	certgenApp.Flag("ca-secret-name", "Also store the CA keypair in a Secret with this name, so that Sesame can rotate the certificates.").StringVar(&certgenConfig.CASecretName)
 
 	certgenApp.Arg("outputdir", "Directory to write output files into (default \"certs\").").Default("certs").StringVar(&certgenConfig.OutputDir)
 
@@ -77,11 +85,43 @@ type certgenConfig struct {
 	// Lifetime is the number of days for which certificates will be valid.
 	Lifetime uint
 
+	// CALifetime is the number of days for which a generated CA certificate will be valid.
+	CALifetime uint
+
 	// Overwrite allows certgen to overwrite any existing files or Kubernetes Secrets.
 	Overwrite bool
 
 	// Format specifies how to format the Kubernetes Secrets (must be "legacy" or "compat").
 	Format string
+
+	// WebhookServiceName is the name of the admission webhook Service. If set,
+	// a certificate for the webhook is generated alongside the gRPC certificates.
+	WebhookServiceName string
+
+	// CASecretName is the name of the Secret to store the CA keypair
+	// in. If empty, the CA private key is discarded.
+	CASecretName string
+
+	// NameSuffix is appended to the names of the generated Secrets,
+	// so that more than one Sesame can run in a namespace.
+	NameSuffix string
+
+	// KeyType is the type of the generated private keys.
+	KeyType string
+
+	// CACertFile and CAKeyFile are the paths to an existing CA keypair
+	// to issue the certificates from. If empty, a new CA is generated.
+	CACertFile string
+	CAKeyFile  string
+}
+
+// keyTypes returns the supported key types as strings.
+func keyTypes() []string {
+	var types []string
+	for _, t := range certs.KeyTypes {
+		types = append(types, string(t))
+	}
+	return types
 }
 
 // OutputCerts outputs the certs in certs as directed by config.
@@ -101,6 +141,14 @@ func OutputCerts(config *certgenConfig, kubeclient *kubernetes.Clientset, certs
 		default:
 			return fmt.Errorf("unsupported Secrets format %q", config.Format)
 		}
+
+		for _, s := range secrets {
+			s.Name += config.NameSuffix
+		}
+
+		if config.CASecretName != "" {
+			secrets = append(secrets, certgen.CASecret(config.Namespace, config.CASecretName, certs))
+		}
 	}
 
 	if config.OutputPEM {
@@ -127,10 +175,30 @@ func OutputCerts(config *certgenConfig, kubeclient *kubernetes.Clientset, certs
 }
 
 func doCertgen(config *certgenConfig, log logrus.FieldLogger) {
+	if (config.CACertFile == "") != (config.CAKeyFile == "") {
+		log.Fatal("--ca-cert and --ca-key must be provided together")
+	}
+
+	var caCert, caKey []byte
+	if config.CACertFile != "" {
+		var err error
+		if caCert, err = ioutil.ReadFile(config.CACertFile); err != nil {
+			log.WithError(err).Fatal("failed to read CA certificate")
+		}
+		if caKey, err = ioutil.ReadFile(config.CAKeyFile); err != nil {
+			log.WithError(err).Fatal("failed to read CA private key")
+		}
+	}
+
 	generatedCerts, err := certs.GenerateCerts(
 		&certs.Configuration{
-			Lifetime:  config.Lifetime,
-			Namespace: config.Namespace,
+			Lifetime:           config.Lifetime,
+			CALifetime:         config.CALifetime,
+			Namespace:          config.Namespace,
+			WebhookServiceName: config.WebhookServiceName,
+			KeyType:            certs.KeyType(config.KeyType),
+			CACertificate:      caCert,
+			CAPrivateKey:       caKey,
 		})
 	if err != nil {
 		log.WithError(err).Fatal("failed to generate certificates")
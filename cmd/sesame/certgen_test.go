@@ -41,20 +41,23 @@ func TestGeneratedSecretsValid(t *testing.T) {
 		OutputPEM:  false,
 		Lifetime:   0,
 		Overwrite:  false,
+
+		WebhookServiceName: "sesame-webhook",
 	}
 
 	certificates, err := certs.GenerateCerts(
 		&certs.Configuration{
-			Lifetime:  conf.Lifetime,
-			Namespace: conf.Namespace,
+			Lifetime:           conf.Lifetime,
+			Namespace:          conf.Namespace,
+			WebhookServiceName: conf.WebhookServiceName,
 		})
 	if err != nil {
 		t.Fatalf("failed to generate certificates: %s", err)
 	}
 
 	secrets := certgen.AsSecrets(conf.Namespace, certificates)
-	if len(secrets) != 2 {
-		t.Errorf("expected 2 secrets, got %d", len(secrets))
+	if len(secrets) != 3 {
+		t.Errorf("expected 3 secrets, got %d", len(secrets))
 	}
 
 	wantedNames := map[string][]string{
@@ -64,12 +67,18 @@ func TestGeneratedSecretsValid(t *testing.T) {
 			fmt.Sprintf("envoy.%s.svc", conf.Namespace),
 			fmt.Sprintf("envoy.%s.svc.cluster.local", conf.Namespace),
 		},
-		"Sesamecert": {
+		"sesamecert": {
 			"sesame",
 			fmt.Sprintf("sesame.%s", conf.Namespace),
 			fmt.Sprintf("sesame.%s.svc", conf.Namespace),
 			fmt.Sprintf("sesame.%s.svc.cluster.local", conf.Namespace),
 		},
+		certgen.WebhookSecretName: {
+			"sesame-webhook",
+			fmt.Sprintf("sesame-webhook.%s", conf.Namespace),
+			fmt.Sprintf("sesame-webhook.%s.svc", conf.Namespace),
+			fmt.Sprintf("sesame-webhook.%s.svc.cluster.local", conf.Namespace),
+		},
 	}
 
 	for _, s := range secrets {
@@ -136,7 +145,7 @@ func TestOutputFileMode(t *testing.T) {
 		},
 		{
 			name:         "yaml format with overwrite",
-			insecureFile: "Sesamecert.yaml",
+			insecureFile: "sesamecert.yaml",
 			cc: &certgenConfig{
 				OutputYAML: true,
 				Overwrite:  true,
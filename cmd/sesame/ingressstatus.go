@@ -23,6 +23,7 @@ import (
 	"github.com/sirupsen/logrus"
 	v1 "k8s.io/api/core/v1"
 	networking_v1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	gatewayapi_v1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
@@ -53,6 +54,12 @@ type loadBalancerStatusWriter struct {
 	statusUpdater         k8s.StatusUpdater
 	ingressClassName      string
 	gatewayControllerName string
+	gatewayRef            *types.NamespacedName
+	excludedGateways      []types.NamespacedName
+
+	// gatewayOnly restricts the writer to setting the address
+	// of Gateways, leaving Ingress and HTTPProxy untouched.
+	gatewayOnly bool
 }
 
 func (isw *loadBalancerStatusWriter) NeedLeaderElection() bool {
@@ -73,15 +80,17 @@ func (isw *loadBalancerStatusWriter) Start(ctx context.Context) error {
 		Cache:                 isw.cache,
 		IngressClassName:      isw.ingressClassName,
 		GatewayControllerName: isw.gatewayControllerName,
+		GatewayRef:            isw.gatewayRef,
+		ExcludedGateways:      isw.excludedGateways,
 		StatusUpdater:         isw.statusUpdater,
 	}
 
 	// Create informers for the types that need load balancer
 	// address status. The cache should have already started
 	// informers, so new informers will auto-start.
-	resources := []client.Object{
-		&Sesame_api_v1.HTTPProxy{},
-		&networking_v1.Ingress{},
+	var resources []client.Object
+	if !isw.gatewayOnly {
+		resources = append(resources, &Sesame_api_v1.HTTPProxy{}, &networking_v1.Ingress{})
 	}
 
 	// Only create Gateway informer if a controller name was provided,
@@ -114,22 +123,8 @@ func (isw *loadBalancerStatusWriter) Start(ctx context.Context) error {
 
 			u.Set(lbs)
 
-			var ingressList networking_v1.IngressList
-			if err := isw.cache.List(context.Background(), &ingressList); err != nil {
-				isw.log.WithError(err).WithField("kind", "Ingress").Error("failed to list objects")
-			} else {
-				for i := range ingressList.Items {
-					u.OnAdd(&ingressList.Items[i])
-				}
-			}
-
-			var proxyList Sesame_api_v1.HTTPProxyList
-			if err := isw.cache.List(context.Background(), &proxyList); err != nil {
-				isw.log.WithError(err).WithField("kind", "HTTPProxy").Error("failed to list objects")
-			} else {
-				for i := range proxyList.Items {
-					u.OnAdd(&proxyList.Items[i])
-				}
+			if !isw.gatewayOnly {
+				isw.updateIngressAndHTTPProxy(u)
 			}
 
 			// Only list Gateways if a controller name was configured,
@@ -148,6 +143,28 @@ func (isw *loadBalancerStatusWriter) Start(ctx context.Context) error {
 	}
 }
 
+// updateIngressAndHTTPProxy lists all Ingress and HTTPProxy objects
+// from the cache and passes them to u to have their address set.
+func (isw *loadBalancerStatusWriter) updateIngressAndHTTPProxy(u *k8s.StatusAddressUpdater) {
+	var ingressList networking_v1.IngressList
+	if err := isw.cache.List(context.Background(), &ingressList); err != nil {
+		isw.log.WithError(err).WithField("kind", "Ingress").Error("failed to list objects")
+	} else {
+		for i := range ingressList.Items {
+			u.OnAdd(&ingressList.Items[i])
+		}
+	}
+
+	var proxyList Sesame_api_v1.HTTPProxyList
+	if err := isw.cache.List(context.Background(), &proxyList); err != nil {
+		isw.log.WithError(err).WithField("kind", "HTTPProxy").Error("failed to list objects")
+	} else {
+		for i := range proxyList.Items {
+			u.OnAdd(&proxyList.Items[i])
+		}
+	}
+}
+
 func parseStatusFlag(status string) v1.LoadBalancerStatus {
 	// Support ','-separated lists.
 	var ingresses []v1.LoadBalancerIngress
@@ -0,0 +1,111 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/projectsesame/sesame/internal/controller"
+	"github.com/projectsesame/sesame/internal/k8s"
+	"github.com/projectsesame/sesame/internal/provisioner"
+	"github.com/sirupsen/logrus"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
+)
+
+// registerOperator registers the operator subcommand and flags
+// with the Application provided.
+func registerOperator(app *kingpin.Application) (*kingpin.CmdClause, *operatorContext) {
+	ctx := &operatorContext{}
+
+	op := app.Command("operator", "Run the operator that provisions Sesame and Envoy for SesameDeployment resources.")
+	op.Flag("incluster", "Use in cluster configuration.").BoolVar(&ctx.InCluster)
+	op.Flag("kubeconfig", "Path to kubeconfig (if not in running inside a cluster).").PlaceHolder("/path/to/file").StringVar(&ctx.Kubeconfig)
+	op.Flag("sesame-image", "Image for the provisioned Sesame, certgen and shutdown-manager containers.").Default(provisioner.DefaultSesameImage).StringVar(&ctx.Images.Sesame)
+	op.Flag("envoy-image", "Image for the provisioned Envoy containers.").Default(provisioner.DefaultEnvoyImage).StringVar(&ctx.Images.Envoy)
+	op.Flag("gateway-controller-name", "Provision Sesame and Envoy for each Gateway whose GatewayClass has this controller name.").PlaceHolder("<controller name>").StringVar(&ctx.GatewayControllerName)
+	op.Flag("metrics-address", "Address the operator's metrics endpoint binds to.").Default(":8000").StringVar(&ctx.MetricsAddress)
+	op.Flag("disable-leader-election", "Disable leader election mechanism.").BoolVar(&ctx.DisableLeaderElection)
+	op.Flag("leader-election-namespace", "The namespace of the lease leader election uses.").Default("sesame-operator").StringVar(&ctx.LeaderElectionNamespace)
+	op.Flag("debug", "Enable debug logging.").Short('d').BoolVar(&ctx.Debug)
+
+	return op, ctx
+}
+
+// operatorContext holds the configuration for the operator subcommand.
+type operatorContext struct {
+	// Kubeconfig and InCluster select how to connect to the API server.
+	Kubeconfig string
+	InCluster  bool
+
+	// Images are the container images of the provisioned instances.
+	Images provisioner.Images
+
+	// GatewayControllerName is the controller name of the GatewayClasses
+	// whose Gateways are provisioned. If unset, Gateways are not provisioned.
+	GatewayControllerName string
+
+	// MetricsAddress is the address the metrics endpoint binds to.
+	MetricsAddress string
+
+	// DisableLeaderElection runs the operator without leader election.
+	DisableLeaderElection bool
+
+	// LeaderElectionNamespace is the namespace of the leader election lease.
+	LeaderElectionNamespace string
+
+	// Debug enables debug logging.
+	Debug bool
+}
+
+func doOperator(ctx *operatorContext, log logrus.FieldLogger) error {
+	restConfig, err := k8s.NewRestConfig(ctx.Kubeconfig, ctx.InCluster)
+	if err != nil {
+		return fmt.Errorf("failed to create REST config for Kubernetes clients: %w", err)
+	}
+
+	scheme, err := k8s.NewSesameScheme()
+	if err != nil {
+		return fmt.Errorf("unable to create scheme: %w", err)
+	}
+
+	mgr, err := manager.New(restConfig, manager.Options{
+		Scheme:                        scheme,
+		MetricsBindAddress:            ctx.MetricsAddress,
+		LeaderElection:                !ctx.DisableLeaderElection,
+		LeaderElectionResourceLock:    "leases",
+		LeaderElectionNamespace:       ctx.LeaderElectionNamespace,
+		LeaderElectionID:              "sesame-operator",
+		LeaderElectionReleaseOnCancel: true,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to set up controller manager: %w", err)
+	}
+
+	if err := controller.RegisterSesameDeploymentController(log.WithField("context", "sesamedeployment-controller"), mgr, ctx.Images); err != nil {
+		return fmt.Errorf("unable to register sesamedeployment controller: %w", err)
+	}
+
+	if len(ctx.GatewayControllerName) > 0 {
+		if err := controller.RegisterGatewayProvisioner(log, mgr, ctx.GatewayControllerName, ctx.Images); err != nil {
+			return fmt.Errorf("unable to register gateway provisioner: %w", err)
+		}
+	}
+
+	log.Info("started sesame operator")
+	defer log.Info("stopped sesame operator")
+
+	return mgr.Start(signals.SetupSignalHandler())
+}
@@ -0,0 +1,86 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net"
+	"strconv"
+
+	"github.com/projectsesame/sesame/internal/ratelimit"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+	"sigs.k8s.io/controller-runtime/pkg/manager/signals"
+)
+
+// registerRateLimitServer registers the ratelimit-server subcommand
+// and flags with the Application provided.
+func registerRateLimitServer(app *kingpin.Application) (*kingpin.CmdClause, *rateLimitServerContext) {
+	ctx := &rateLimitServerContext{}
+
+	rls := app.Command("ratelimit-server", "Run a reference Envoy rate limit service with in-memory counters, for development and tests.")
+	rls.Flag("config-path", "Path to the rate limit configuration file.").Short('c').Required().PlaceHolder("/path/to/file").ExistingFileVar(&ctx.ConfigFile)
+	rls.Flag("address", "Address the gRPC server listens on.").Default("0.0.0.0").StringVar(&ctx.Address)
+	rls.Flag("port", "Port the gRPC server listens on.").Default("8081").IntVar(&ctx.Port)
+	rls.Flag("debug", "Enable debug logging.").Short('d').BoolVar(&ctx.Debug)
+
+	return rls, ctx
+}
+
+// rateLimitServerContext holds the configuration for the
+// ratelimit-server subcommand.
+type rateLimitServerContext struct {
+	// ConfigFile is the path to the rate limit configuration file.
+	ConfigFile string
+
+	// Address and Port are where the gRPC server listens.
+	Address string
+	Port    int
+
+	// Debug enables debug logging.
+	Debug bool
+}
+
+func doRateLimitServer(ctx *rateLimitServerContext, log logrus.FieldLogger) error {
+	cfg, err := ratelimit.ParseFile(ctx.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	svc, err := ratelimit.NewService(log, cfg, &ratelimit.MemoryCounter{})
+	if err != nil {
+		return err
+	}
+
+	grpcServer := grpc.NewServer()
+	svc.Register(grpcServer)
+
+	addr := net.JoinHostPort(ctx.Address, strconv.Itoa(ctx.Port))
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	stop := signals.SetupSignalHandler()
+	go func() {
+		<-stop.Done()
+		grpcServer.GracefulStop()
+	}()
+
+	log = log.WithField("address", addr).WithField("domain", cfg.Domain)
+	log.Info("started rate limit server")
+	defer log.Info("stopped rate limit server")
+
+	return grpcServer.Serve(l)
+}
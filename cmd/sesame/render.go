@@ -0,0 +1,568 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	// The HTTP filters that Sesame configures by type URL only
+	// must be registered to be marshaled to JSON.
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/compression/gzip/compressor/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/cors/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/grpc_web/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/router/v3"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
+	envoy_v3 "github.com/projectsesame/sesame/internal/envoy/v3"
+	"github.com/projectsesame/sesame/internal/k8s"
+	"github.com/projectsesame/sesame/internal/xdscache"
+	xdscache_v3 "github.com/projectsesame/sesame/internal/xdscache/v3"
+	"github.com/projectsesame/sesame/pkg/config"
+	"github.com/sirupsen/logrus"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+	corev1 "k8s.io/api/core/v1"
+	networking_v1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	apimachinery_util_yaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayapi_v1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+	"sigs.k8s.io/yaml"
+)
+
+// registerRender registers the render subcommand and flags
+// with the Application provided.
+func registerRender(app *kingpin.Application) (*kingpin.CmdClause, *renderContext) {
+	ctx := &renderContext{
+		serveContext: newServeContext(),
+	}
+
+	render := app.Command("render", "Render the Envoy configuration for a set of Kubernetes manifests without contacting an API server.")
+	render.Arg("paths", "Manifest files or directories to load ('-' for standard input).").Required().StringsVar(&ctx.Paths)
+	render.Flag("config-path", "Path to base configuration.").Short('c').PlaceHolder("/path/to/file").ExistingFileVar(&ctx.ConfigFile)
+	render.Flag("sesame-config-name", "Name of a SesameConfiguration in the manifests to use for configuration.").PlaceHolder("sesame").StringVar(&ctx.SesameConfigurationName)
+	render.Flag("namespace", "Namespace to assign to namespaced objects that do not specify one.").Default("default").StringVar(&ctx.Namespace)
+	render.Flag("root-namespaces", "Restrict sesame to searching these namespaces for root ingress routes.").PlaceHolder("<ns,ns>").StringVar(&ctx.serveContext.rootNamespaces)
+	render.Flag("ingress-class-name", "Sesame IngressClass name.").PlaceHolder("<name>").StringVar(&ctx.serveContext.ingressClassName)
+	render.Flag("output", "Output file ('-' for standard output).").Short('o').Default("-").StringVar(&ctx.Output)
+	render.Flag("format", "Output format.").Default("yaml").EnumVar(&ctx.Format, "yaml", "json")
+	render.Flag("debug", "Enable debug logging.").Short('d').BoolVar(&ctx.Debug)
+
+	return render, ctx
+}
+
+// renderContext holds the configuration for the render subcommand.
+type renderContext struct {
+	// Paths are the manifest files or directories to load.
+	Paths []string
+
+	// ConfigFile is the optional path to a Sesame configuration file.
+	ConfigFile string
+
+	// SesameConfigurationName is the optional name of a SesameConfiguration
+	// object in the loaded manifests to take configuration from.
+	SesameConfigurationName string
+
+	// Namespace is assigned to namespaced objects without a namespace.
+	Namespace string
+
+	// Output is the path to write the rendered configuration to.
+	Output string
+
+	// Format is either "yaml" or "json".
+	Format string
+
+	// Debug enables debug logging.
+	Debug bool
+
+	serveContext *serveContext
+}
+
+// renderOutput is the document written by the render subcommand.
+type renderOutput struct {
+	Listeners []json.RawMessage `json:"listeners"`
+	Routes    []json.RawMessage `json:"routes"`
+	Clusters  []json.RawMessage `json:"clusters"`
+	Endpoints []json.RawMessage `json:"endpoints"`
+	Statuses  []renderStatus    `json:"statuses"`
+}
+
+// renderStatus is the status an object would be given by Sesame.
+type renderStatus struct {
+	APIVersion string      `json:"apiVersion"`
+	Kind       string      `json:"kind"`
+	Namespace  string      `json:"namespace,omitempty"`
+	Name       string      `json:"name"`
+	Status     interface{} `json:"status"`
+}
+
+// doRender runs the render subcommand.
+func doRender(ctx *renderContext, log logrus.FieldLogger) error {
+	objs, err := ctx.loadObjects()
+	if err != nil {
+		return err
+	}
+
+	out, err := ctx.render(objs, log)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(out)
+	if err != nil {
+		return err
+	}
+
+	if ctx.Format == "yaml" {
+		if data, err = yaml.JSONToYAML(data); err != nil {
+			return err
+		}
+	} else {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, data, "", "  "); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		data = buf.Bytes()
+	}
+
+	if ctx.Output == "" || ctx.Output == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	return ioutil.WriteFile(ctx.Output, data, 0644) // nolint:gosec
+}
+
+// sesameConfiguration returns the SesameConfigurationSpec to render with.
+func (ctx *renderContext) sesameConfiguration(objs []client.Object) (sesame_api_v1alpha1.SesameConfigurationSpec, error) {
+	if ctx.SesameConfigurationName != "" {
+		if ctx.ConfigFile != "" {
+			return sesame_api_v1alpha1.SesameConfigurationSpec{}, fmt.Errorf("cannot specify both %s and %s", "--sesame-config-name", "-c/--config-path")
+		}
+
+		for _, obj := range objs {
+			if sc, ok := obj.(*sesame_api_v1alpha1.SesameConfiguration); ok && sc.Name == ctx.SesameConfigurationName {
+				return sc.Spec, nil
+			}
+		}
+
+		return sesame_api_v1alpha1.SesameConfigurationSpec{}, fmt.Errorf("sesame configuration %q not found", ctx.SesameConfigurationName)
+	}
+
+	if ctx.ConfigFile != "" {
+		f, err := os.Open(ctx.ConfigFile)
+		if err != nil {
+			return sesame_api_v1alpha1.SesameConfigurationSpec{}, err
+		}
+		defer f.Close()
+
+		params, err := config.Parse(f)
+		if err != nil {
+			return sesame_api_v1alpha1.SesameConfigurationSpec{}, err
+		}
+
+		if err := params.Validate(); err != nil {
+			return sesame_api_v1alpha1.SesameConfigurationSpec{}, fmt.Errorf("invalid Sesame configuration: %w", err)
+		}
+
+		ctx.serveContext.Config = *params
+	}
+
+	return ctx.serveContext.convertToSesameConfigurationSpec(), nil
+}
+
+// render builds the DAG for objs and returns the resulting
+// Envoy resources and object statuses.
+func (ctx *renderContext) render(objs []client.Object, log logrus.FieldLogger) (*renderOutput, error) {
+	sesameConfiguration, err := ctx.sesameConfiguration(objs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := sesameConfiguration.Validate(); err != nil {
+		return nil, err
+	}
+
+	listenerConfig, err := newListenerConfig(sesameConfiguration)
+	if err != nil {
+		return nil, err
+	}
+
+	if rls := sesameConfiguration.RateLimitService; rls != nil {
+		key := types.NamespacedName{Namespace: rls.ExtensionService.Namespace, Name: rls.ExtensionService.Name}
+		extensionSvc, ok := findObject(objs, &sesame_api_v1alpha1.ExtensionService{}, key).(*sesame_api_v1alpha1.ExtensionService)
+		if !ok {
+			return nil, fmt.Errorf("rate limit extension service %s not found", key)
+		}
+
+		if listenerConfig.RateLimitConfig, err = newRateLimitConfig(sesameConfiguration, extensionSvc); err != nil {
+			return nil, err
+		}
+	}
+
+	endpointHandler := xdscache_v3.NewEndpointsTranslator(log.WithField("context", "endpointstranslator"))
+	listenerCache := xdscache_v3.NewListenerCache(sesameConfiguration.Envoy, listenerConfig)
+	routeCache := &xdscache_v3.RouteCache{AltSvc: listenerConfig.AltSvc()}
+	clusterCache := &xdscache_v3.ClusterCache{}
+
+	resources := []xdscache.ResourceCache{
+		listenerCache,
+		xdscache_v3.NewSecretsCache(envoy_v3.StatsSecrets(sesameConfiguration.Envoy.Metrics.TLS)),
+		routeCache,
+		clusterCache,
+		endpointHandler,
+	}
+
+	s := &Server{log: log}
+	builder := s.getDAGBuilder(newDAGBuilderConfig(sesameConfiguration))
+
+	var endpoints []*corev1.Endpoints
+	for _, obj := range gatewayAPIFilter(objs, sesameConfiguration.Gateway) {
+		switch obj := obj.(type) {
+		case *corev1.Endpoints:
+			endpoints = append(endpoints, obj)
+		default:
+			builder.Source.Insert(obj)
+		}
+	}
+
+	root := builder.Build()
+	for _, r := range resources {
+		r.OnChange(root)
+	}
+
+	// The EndpointsTranslator only accepts Endpoints for the
+	// service clusters it learned about from the DAG.
+	for _, ep := range endpoints {
+		endpointHandler.OnAdd(ep)
+	}
+
+	out := &renderOutput{}
+	if out.Listeners, err = marshalResources(listenerCache.Contents()); err != nil {
+		return nil, err
+	}
+	if out.Routes, err = marshalResources(routeCache.Contents()); err != nil {
+		return nil, err
+	}
+	if out.Clusters, err = marshalResources(clusterCache.Contents()); err != nil {
+		return nil, err
+	}
+	if out.Endpoints, err = marshalResources(endpointHandler.Contents()); err != nil {
+		return nil, err
+	}
+	if out.Statuses, err = renderStatuses(objs, root.StatusCache.GetStatusUpdates()); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// gatewayAPIFilter removes the GatewayClasses and Gateways that
+// this Sesame does not manage, the same way the Gateway API
+// controllers do when running against an API server. If the
+// configuration has a GatewayRef, only that Gateway and its
+// GatewayClass are kept, whatever the class's controller is.
+// Otherwise, the GatewayClasses of the configured controller
+// and their Gateways are kept.
+func gatewayAPIFilter(objs []client.Object, gatewayConfig *sesame_api_v1alpha1.GatewayConfig) []client.Object {
+	var gatewayRef *types.NamespacedName
+	classes := map[string]bool{}
+	if gatewayConfig != nil {
+		gatewayRef = gatewayRefOf(gatewayConfig)
+		for _, obj := range objs {
+			switch obj := obj.(type) {
+			case *gatewayapi_v1alpha2.Gateway:
+				if gatewayRef != nil && k8s.NamespacedNameOf(obj) == *gatewayRef {
+					classes[string(obj.Spec.GatewayClassName)] = true
+				}
+			case *gatewayapi_v1alpha2.GatewayClass:
+				if gatewayRef == nil && gatewayConfig.ControllerName != "" && string(obj.Spec.ControllerName) == gatewayConfig.ControllerName {
+					classes[obj.Name] = true
+				}
+			}
+		}
+	}
+
+	var filtered []client.Object
+	for _, obj := range objs {
+		switch obj := obj.(type) {
+		case *gatewayapi_v1alpha2.GatewayClass:
+			if !classes[obj.Name] {
+				continue
+			}
+		case *gatewayapi_v1alpha2.Gateway:
+			if gatewayRef != nil && k8s.NamespacedNameOf(obj) != *gatewayRef {
+				continue
+			}
+			if !classes[string(obj.Spec.GatewayClassName)] {
+				continue
+			}
+		}
+		filtered = append(filtered, obj)
+	}
+
+	return filtered
+}
+
+// loadObjects decodes every Kubernetes object in ctx.Paths.
+func (ctx *renderContext) loadObjects() ([]client.Object, error) {
+	scheme, err := k8s.NewSesameScheme()
+	if err != nil {
+		return nil, fmt.Errorf("unable to create scheme: %w", err)
+	}
+
+	decoder := serializer.NewCodecFactory(scheme).UniversalDeserializer()
+
+	var objs []client.Object
+	for _, path := range ctx.Paths {
+		files, err := manifestFiles(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			decoded, err := decodeFile(file, decoder, ctx.Namespace)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode %s: %w", file, err)
+			}
+			objs = append(objs, decoded...)
+		}
+	}
+
+	return objs, nil
+}
+
+// manifestFiles returns path if it is a file, or the YAML
+// and JSON files beneath path if it is a directory.
+func manifestFiles(path string) ([]string, error) {
+	if path == "-" {
+		return []string{path}, nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".yaml", ".yml", ".json":
+			if !info.IsDir() {
+				files = append(files, p)
+			}
+		}
+		return nil
+	})
+
+	return files, err
+}
+
+// decodeFile decodes the objects in file, or standard input if file is "-".
+func decodeFile(file string, decoder runtime.Decoder, namespace string) ([]client.Object, error) {
+	if file == "-" {
+		return decodeObjects(os.Stdin, decoder, namespace)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return decodeObjects(f, decoder, namespace)
+}
+
+// decodeObjects decodes a stream of YAML or JSON documents into
+// Kubernetes objects. Lists are flattened and namespaced objects
+// without a namespace are assigned namespace.
+func decodeObjects(r io.Reader, decoder runtime.Decoder, namespace string) ([]client.Object, error) {
+	var objs []client.Object
+
+	reader := apimachinery_util_yaml.NewYAMLReader(bufio.NewReader(r))
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return objs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		obj, _, err := decoder.Decode(doc, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		decoded, err := flattenObject(obj, decoder)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, o := range decoded {
+			if o.GetNamespace() == "" && isNamespaced(o) {
+				o.SetNamespace(namespace)
+			}
+			objs = append(objs, o)
+		}
+	}
+}
+
+// flattenObject returns the items of a List, or obj itself.
+func flattenObject(obj runtime.Object, decoder runtime.Decoder) ([]client.Object, error) {
+	if list, ok := obj.(*corev1.List); ok {
+		var objs []client.Object
+		for _, item := range list.Items {
+			o, _, err := decoder.Decode(item.Raw, nil, nil)
+			if err != nil {
+				return nil, err
+			}
+
+			flattened, err := flattenObject(o, decoder)
+			if err != nil {
+				return nil, err
+			}
+			objs = append(objs, flattened...)
+		}
+		return objs, nil
+	}
+
+	o, ok := obj.(client.Object)
+	if !ok {
+		return nil, fmt.Errorf("unsupported object type %T", obj)
+	}
+
+	return []client.Object{o}, nil
+}
+
+// isNamespaced returns false for the cluster scoped kinds Sesame reads.
+func isNamespaced(obj client.Object) bool {
+	switch obj.(type) {
+	case *corev1.Namespace, *networking_v1.IngressClass, *gatewayapi_v1alpha2.GatewayClass:
+		return false
+	default:
+		return true
+	}
+}
+
+// findObject returns the object in objs with the same type as kind
+// and the given name, or nil.
+func findObject(objs []client.Object, kind client.Object, name types.NamespacedName) client.Object {
+	for _, obj := range objs {
+		if fmt.Sprintf("%T", obj) == fmt.Sprintf("%T", kind) && k8s.NamespacedNameOf(obj) == name {
+			return obj
+		}
+	}
+
+	return nil
+}
+
+// marshalResources converts xDS resources to JSON.
+func marshalResources(msgs []proto.Message) ([]json.RawMessage, error) {
+	m := jsonpb.Marshaler{OrigName: true}
+
+	out := []json.RawMessage{}
+	for _, msg := range msgs {
+		str, err := m.MarshalToString(msg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal %T: %w", msg, err)
+		}
+		out = append(out, json.RawMessage(str))
+	}
+
+	return out, nil
+}
+
+// renderStatuses applies each status update to a copy of the object
+// it targets and returns the resulting statuses. Condition transition
+// times are removed so that the output is stable across runs.
+func renderStatuses(objs []client.Object, updates []k8s.StatusUpdate) ([]renderStatus, error) {
+	statuses := []renderStatus{}
+
+	for _, upd := range updates {
+		obj := findObject(objs, upd.Resource, upd.NamespacedName)
+		if obj == nil {
+			continue
+		}
+
+		mutated := upd.Mutator.Mutate(obj.DeepCopyObject().(client.Object))
+
+		u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(mutated)
+		if err != nil {
+			return nil, err
+		}
+
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		statuses = append(statuses, renderStatus{
+			APIVersion: gvk.GroupVersion().String(),
+			Kind:       gvk.Kind,
+			Namespace:  obj.GetNamespace(),
+			Name:       obj.GetName(),
+			Status:     stripTransitionTimes(u["status"]),
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		a, b := statuses[i], statuses[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	return statuses, nil
+}
+
+// stripTransitionTimes recursively removes lastTransitionTime fields.
+func stripTransitionTimes(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		delete(v, "lastTransitionTime")
+		for k := range v {
+			v[k] = stripTransitionTimes(v[k])
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = stripTransitionTimes(v[i])
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,315 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
+	"github.com/projectsesame/sesame/internal/k8s"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	gatewayapi_v1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+const renderManifests = `
+apiVersion: v1
+kind: Service
+metadata:
+  name: kuard
+spec:
+  ports:
+  - port: 80
+    targetPort: 8080
+---
+apiVersion: v1
+kind: Endpoints
+metadata:
+  name: kuard
+subsets:
+- addresses:
+  - ip: 10.0.0.1
+  ports:
+  - port: 8080
+---
+apiVersion: projectsesame.io/v1
+kind: HTTPProxy
+metadata:
+  name: kuard
+spec:
+  virtualhost:
+    fqdn: kuard.example.com
+  routes:
+  - services:
+    - name: kuard
+      port: 80
+---
+apiVersion: projectsesame.io/v1
+kind: HTTPProxy
+metadata:
+  name: broken
+  namespace: other
+spec:
+  virtualhost:
+    fqdn: broken.example.com
+  routes:
+  - services:
+    - name: missing
+      port: 80
+`
+
+func TestRenderDecodeObjects(t *testing.T) {
+	scheme, err := k8s.NewSesameScheme()
+	require.NoError(t, err)
+
+	objs, err := decodeObjects(strings.NewReader(renderManifests), serializer.NewCodecFactory(scheme).UniversalDeserializer(), "default")
+	require.NoError(t, err)
+	require.Len(t, objs, 4)
+
+	assert.IsType(t, &corev1.Service{}, objs[0])
+	assert.IsType(t, &corev1.Endpoints{}, objs[1])
+	assert.IsType(t, &sesame_api_v1.HTTPProxy{}, objs[2])
+
+	assert.Equal(t, "default", objs[0].GetNamespace())
+	assert.Equal(t, "other", objs[3].GetNamespace())
+}
+
+func TestRender(t *testing.T) {
+	scheme, err := k8s.NewSesameScheme()
+	require.NoError(t, err)
+
+	objs, err := decodeObjects(strings.NewReader(renderManifests), serializer.NewCodecFactory(scheme).UniversalDeserializer(), "default")
+	require.NoError(t, err)
+
+	ctx := &renderContext{serveContext: newServeContext()}
+	out, err := ctx.render(objs, logrus.StandardLogger())
+	require.NoError(t, err)
+
+	require.Len(t, out.Clusters, 1)
+	assert.Contains(t, string(out.Clusters[0]), `"name":"default/kuard/80/da39a3ee5e"`)
+
+	require.Len(t, out.Endpoints, 1)
+	assert.Contains(t, string(out.Endpoints[0]), `"address":"10.0.0.1"`)
+
+	var routes strings.Builder
+	for _, r := range out.Routes {
+		routes.Write(r)
+	}
+	assert.Contains(t, routes.String(), "kuard.example.com")
+	assert.Contains(t, routes.String(), `"direct_response":{"status":503}`)
+
+	require.Len(t, out.Statuses, 2)
+	assert.Equal(t, "kuard", out.Statuses[0].Name)
+	assert.Equal(t, "valid", out.Statuses[0].Status.(map[string]interface{})["currentStatus"])
+	assert.Equal(t, "broken", out.Statuses[1].Name)
+	assert.Equal(t, "other", out.Statuses[1].Namespace)
+	assert.Equal(t, "invalid", out.Statuses[1].Status.(map[string]interface{})["currentStatus"])
+}
+
+const renderGatewayManifests = `
+apiVersion: projectsesame.io/v1alpha1
+kind: SesameConfiguration
+metadata:
+  name: sesame
+spec:
+  gateway:
+    controllerName: projectsesame.io/projectsesame/sesame
+    gateways:
+    - gateway:
+        namespace: default
+        name: gw
+      http:
+        address: 0.0.0.0
+        port: 9080
+---
+apiVersion: projectsesame.io/v1alpha1
+kind: SesameConfiguration
+metadata:
+  name: sesame-gateway-ref
+spec:
+  envoy:
+    http:
+      port: 8080
+    https:
+      port: 8443
+  gateway:
+    controllerName: projectsesame.io/projectsesame/sesame
+    gatewayRef:
+      namespace: default
+      name: gw
+---
+apiVersion: gateway.networking.k8s.io/v1alpha2
+kind: GatewayClass
+metadata:
+  name: provisioned
+spec:
+  controllerName: projectsesame.io/gateway-provisioner
+---
+apiVersion: gateway.networking.k8s.io/v1alpha2
+kind: Gateway
+metadata:
+  name: gw
+spec:
+  gatewayClassName: provisioned
+  listeners:
+  - name: http
+    protocol: HTTP
+    port: 80
+    allowedRoutes:
+      namespaces:
+        from: Same
+  - name: http-alt
+    protocol: HTTP
+    port: 8081
+    allowedRoutes:
+      namespaces:
+        from: Same
+---
+apiVersion: gateway.networking.k8s.io/v1alpha2
+kind: Gateway
+metadata:
+  name: other
+spec:
+  gatewayClassName: provisioned
+  listeners:
+  - name: http
+    protocol: HTTP
+    port: 80
+    allowedRoutes:
+      namespaces:
+        from: Same
+---
+apiVersion: gateway.networking.k8s.io/v1alpha2
+kind: HTTPRoute
+metadata:
+  name: kuard
+spec:
+  parentRefs:
+  - group: gateway.networking.k8s.io
+    kind: Gateway
+    name: gw
+  hostnames:
+  - route.example.com
+  rules:
+  - matches:
+    - path:
+        type: PathPrefix
+        value: /
+    backendRefs:
+    - group: ""
+      kind: Service
+      name: kuard
+      port: 80
+---
+` + renderManifests
+
+// routeConfigs returns the marshaled route configurations
+// of out, indexed by name.
+func routeConfigs(t *testing.T, out *renderOutput) map[string]string {
+	t.Helper()
+
+	routes := map[string]string{}
+	for _, r := range out.Routes {
+		var rc struct {
+			Name string `json:"name"`
+		}
+		require.NoError(t, json.Unmarshal(r, &rc))
+		routes[rc.Name] = string(r)
+	}
+	return routes
+}
+
+// listeners returns the marshaled listeners of out, indexed by name.
+func listeners(t *testing.T, out *renderOutput) map[string]string {
+	t.Helper()
+
+	listeners := map[string]string{}
+	for _, l := range out.Listeners {
+		var listener struct {
+			Name string `json:"name"`
+		}
+		require.NoError(t, json.Unmarshal(l, &listener))
+		listeners[listener.Name] = string(l)
+	}
+	return listeners
+}
+
+// gatewayStatuses returns the names of the Gateways that out has statuses for.
+func gatewayStatuses(out *renderOutput) []string {
+	var gateways []string
+	for _, s := range out.Statuses {
+		if s.Kind == "Gateway" {
+			gateways = append(gateways, s.Name)
+		}
+	}
+	return gateways
+}
+
+func TestRenderGatewayListeners(t *testing.T) {
+	scheme, err := k8s.NewSesameScheme()
+	require.NoError(t, err)
+
+	objs, err := decodeObjects(strings.NewReader(renderGatewayManifests), serializer.NewCodecFactory(scheme).UniversalDeserializer(), "default")
+	require.NoError(t, err)
+
+	// The GatewayClass of the Gateways has another controller.
+	ctx := &renderContext{serveContext: newServeContext(), SesameConfigurationName: "sesame"}
+	out, err := ctx.render(objs, logrus.StandardLogger())
+	require.NoError(t, err)
+	assert.Empty(t, gatewayStatuses(out))
+
+	for _, obj := range objs {
+		if gc, ok := obj.(*gatewayapi_v1alpha2.GatewayClass); ok {
+			gc.Spec.ControllerName = "projectsesame.io/projectsesame/sesame"
+		}
+	}
+
+	out, err = ctx.render(objs, logrus.StandardLogger())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"gw", "other"}, gatewayStatuses(out))
+
+	// Like serve, render maps the Gateway onto its own listener.
+	routes := routeConfigs(t, out)
+	require.Contains(t, routes, "ingress_http_default_gw")
+	assert.Contains(t, routes["ingress_http_default_gw"], "route.example.com")
+	assert.NotContains(t, routes["ingress_http"], "route.example.com")
+}
+
+func TestRenderGatewayRef(t *testing.T) {
+	scheme, err := k8s.NewSesameScheme()
+	require.NoError(t, err)
+
+	objs, err := decodeObjects(strings.NewReader(renderGatewayManifests), serializer.NewCodecFactory(scheme).UniversalDeserializer(), "default")
+	require.NoError(t, err)
+
+	// Only the referenced Gateway is processed, even though
+	// its GatewayClass has another controller.
+	ctx := &renderContext{serveContext: newServeContext(), SesameConfigurationName: "sesame-gateway-ref"}
+	out, err := ctx.render(objs, logrus.StandardLogger())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"gw"}, gatewayStatuses(out))
+
+	// Port 80 is served by Envoy's HTTP listener on port 8080, and
+	// port 8081 by a listener of its own.
+	routes := routeConfigs(t, out)
+	assert.Contains(t, routes["ingress_http"], "route.example.com")
+	require.Contains(t, routes, "ingress_http_8081")
+	assert.Contains(t, routes["ingress_http_8081"], "route.example.com")
+	assert.Contains(t, listeners(t, out)["ingress_http_8081"], `"port_value":8081`)
+}
@@ -28,6 +28,7 @@ import (
 	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
 	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
 	"github.com/projectsesame/sesame/internal/annotation"
+	"github.com/projectsesame/sesame/internal/certrotation"
 	"github.com/projectsesame/sesame/internal/controller"
 	"github.com/projectsesame/sesame/internal/dag"
 	"github.com/projectsesame/sesame/internal/debug"
@@ -40,6 +41,7 @@ import (
 	"github.com/projectsesame/sesame/internal/sesame"
 	"github.com/projectsesame/sesame/internal/sesameconfig"
 	"github.com/projectsesame/sesame/internal/timeout"
+	"github.com/projectsesame/sesame/internal/webhook"
 	"github.com/projectsesame/sesame/internal/xds"
 	sesame_xds_v3 "github.com/projectsesame/sesame/internal/xds/v3"
 	"github.com/projectsesame/sesame/internal/xdscache"
@@ -292,52 +294,11 @@ func (s *Server) doServe() error {
 		}
 	}
 
-	cipherSuites := []string{}
-	for _, cs := range sesameConfiguration.Envoy.Listener.TLS.CipherSuites {
-		cipherSuites = append(cipherSuites, string(cs))
-	}
-
-	timeouts, err := sesameconfig.ParseTimeoutPolicy(sesameConfiguration.Envoy.Timeouts)
+	listenerConfig, err := newListenerConfig(sesameConfiguration)
 	if err != nil {
 		return err
 	}
 
-	accessLogFormatString := ""
-	if sesameConfiguration.Envoy.Logging.AccessLogFormatString != nil {
-		accessLogFormatString = *sesameConfiguration.Envoy.Logging.AccessLogFormatString
-	}
-
-	listenerConfig := xdscache_v3.ListenerConfig{
-		UseProxyProto: sesameConfiguration.Envoy.Listener.UseProxyProto,
-		HTTPListeners: map[string]xdscache_v3.Listener{
-			xdscache_v3.ENVOY_HTTP_LISTENER: {
-				Name:    xdscache_v3.ENVOY_HTTP_LISTENER,
-				Address: sesameConfiguration.Envoy.HTTPListener.Address,
-				Port:    sesameConfiguration.Envoy.HTTPListener.Port,
-			},
-		},
-		HTTPAccessLog: sesameConfiguration.Envoy.HTTPListener.AccessLog,
-		HTTPSListeners: map[string]xdscache_v3.Listener{
-			xdscache_v3.ENVOY_HTTPS_LISTENER: {
-				Name:    xdscache_v3.ENVOY_HTTPS_LISTENER,
-				Address: sesameConfiguration.Envoy.HTTPSListener.Address,
-				Port:    sesameConfiguration.Envoy.HTTPSListener.Port,
-			},
-		},
-		HTTPSAccessLog:               sesameConfiguration.Envoy.HTTPSListener.AccessLog,
-		AccessLogType:                sesameConfiguration.Envoy.Logging.AccessLogFormat,
-		AccessLogFields:              sesameConfiguration.Envoy.Logging.AccessLogFields,
-		AccessLogFormatString:        accessLogFormatString,
-		AccessLogFormatterExtensions: AccessLogFormatterExtensions(sesameConfiguration.Envoy.Logging.AccessLogFormat, sesameConfiguration.Envoy.Logging.AccessLogFields, sesameConfiguration.Envoy.Logging.AccessLogFormatString),
-		MinimumTLSVersion:            annotation.MinTLSVersion(sesameConfiguration.Envoy.Listener.TLS.MinimumProtocolVersion, "1.2"),
-		CipherSuites:                 config.SanitizeCipherSuites(cipherSuites),
-		Timeouts:                     timeouts,
-		DefaultHTTPVersions:          parseDefaultHTTPVersions(sesameConfiguration.Envoy.DefaultHTTPVersions),
-		AllowChunkedLength:           !sesameConfiguration.Envoy.Listener.DisableAllowChunkedLength,
-		XffNumTrustedHops:            sesameConfiguration.Envoy.Network.XffNumTrustedHops,
-		ConnectionBalancer:           sesameConfiguration.Envoy.Listener.ConnectionBalancer,
-	}
-
 	if listenerConfig.RateLimitConfig, err = s.setupRateLimitService(sesameConfiguration); err != nil {
 		return err
 	}
@@ -351,7 +312,7 @@ func (s *Server) doServe() error {
 	resources := []xdscache.ResourceCache{
 		xdscache_v3.NewListenerCache(sesameConfiguration.Envoy, listenerConfig),
 		xdscache_v3.NewSecretsCache(envoy_v3.StatsSecrets(sesameConfiguration.Envoy.Metrics.TLS)),
-		&xdscache_v3.RouteCache{},
+		&xdscache_v3.RouteCache{AltSvc: listenerConfig.AltSvc()},
 		&xdscache_v3.ClusterCache{},
 		endpointHandler,
 	}
@@ -370,36 +331,13 @@ func (s *Server) doServe() error {
 		s.log.WithField("context", "envoy-client-certificate").Infof("enabled client certificate with secret: %q", sesameConfiguration.Envoy.ClientCertificate)
 	}
 
-	ingressClassName := ""
-	if sesameConfiguration.Ingress != nil && sesameConfiguration.Ingress.ClassName != nil {
-		ingressClassName = *sesameConfiguration.Ingress.ClassName
-	}
-
-	var clientCert *types.NamespacedName
-	var fallbackCert *types.NamespacedName
-	if sesameConfiguration.Envoy.ClientCertificate != nil {
-		clientCert = &types.NamespacedName{Name: sesameConfiguration.Envoy.ClientCertificate.Name, Namespace: sesameConfiguration.Envoy.ClientCertificate.Namespace}
-	}
-	if sesameConfiguration.HTTPProxy.FallbackCertificate != nil {
-		fallbackCert = &types.NamespacedName{Name: sesameConfiguration.HTTPProxy.FallbackCertificate.Name, Namespace: sesameConfiguration.HTTPProxy.FallbackCertificate.Namespace}
-	}
-
 	sh := k8s.NewStatusUpdateHandler(s.log.WithField("context", "StatusUpdateHandler"), s.mgr.GetClient())
 	if err := s.mgr.Add(sh); err != nil {
 		return err
 	}
 
-	builder := s.getDAGBuilder(dagBuilderConfig{
-		ingressClassName:          ingressClassName,
-		rootNamespaces:            sesameConfiguration.HTTPProxy.RootNamespaces,
-		gatewayAPIConfigured:      sesameConfiguration.Gateway != nil,
-		disablePermitInsecure:     sesameConfiguration.HTTPProxy.DisablePermitInsecure,
-		enableExternalNameService: sesameConfiguration.EnableExternalNameService,
-		dnsLookupFamily:           sesameConfiguration.Envoy.Cluster.DNSLookupFamily,
-		headersPolicy:             sesameConfiguration.Policy,
-		clientCert:                clientCert,
-		fallbackCert:              fallbackCert,
-	})
+	dbc := newDAGBuilderConfig(sesameConfiguration)
+	builder := s.getDAGBuilder(dbc)
 
 	// Build the core Kubernetes event handler.
 	observer := sesame.NewRebuildMetricsObserver(
@@ -422,19 +360,7 @@ func (s *Server) doServe() error {
 	}
 
 	// Inform on default resources.
-	for name, r := range map[string]client.Object{
-		"httpproxies":               &sesame_api_v1.HTTPProxy{},
-		"tlscertificatedelegations": &sesame_api_v1.TLSCertificateDelegation{},
-		"extensionservices":         &sesame_api_v1alpha1.ExtensionService{},
-		"sesameconfigurations":      &sesame_api_v1alpha1.SesameConfiguration{},
-		"services":                  &corev1.Service{},
-		"ingresses":                 &networking_v1.Ingress{},
-		"ingressclasses":            &networking_v1.IngressClass{},
-	} {
-		if err := informOnResource(r, eventHandler, s.mgr.GetCache()); err != nil {
-			s.log.WithError(err).WithField("resource", name).Fatal("failed to create informer")
-		}
-	}
+	s.informOnDefaultResources(eventHandler, s.mgr.GetCache())
 
 	// Inform on Gateway API resources.
 	needsNotification := s.setupGatewayAPI(sesameConfiguration, s.mgr, eventHandler, sh)
@@ -479,9 +405,32 @@ func (s *Server) doServe() error {
 		return err
 	}
 
+	// Create the validating admission webhook if configured.
+	if sesameConfiguration.Webhook != nil {
+		if err := s.setupWebhook(*sesameConfiguration.Webhook, dbc); err != nil {
+			return err
+		}
+	}
+
+	// Create the xDS certificate rotator if configured.
+	if sesameConfiguration.CertificateRotation != nil {
+		if err := s.setupCertificateRotation(*sesameConfiguration.CertificateRotation, SesameMetrics); err != nil {
+			return err
+		}
+	}
+
 	var gatewayControllerName string
+	var gatewayRef *types.NamespacedName
+	var gatewayServices map[types.NamespacedName]types.NamespacedName
 	if sesameConfiguration.Gateway != nil {
 		gatewayControllerName = sesameConfiguration.Gateway.ControllerName
+		gatewayRef = gatewayRefOf(sesameConfiguration.Gateway)
+		gatewayServices = gatewayServicesOf(sesameConfiguration.Gateway)
+	}
+
+	var excludedGateways []types.NamespacedName
+	for gateway := range gatewayServices {
+		excludedGateways = append(excludedGateways, gateway)
 	}
 
 	// Set up ingress load balancer status writer.
@@ -489,8 +438,10 @@ func (s *Server) doServe() error {
 		log:                   s.log.WithField("context", "loadBalancerStatusWriter"),
 		cache:                 s.mgr.GetCache(),
 		lbStatus:              make(chan corev1.LoadBalancerStatus, 1),
-		ingressClassName:      ingressClassName,
+		ingressClassName:      dbc.ingressClassName,
 		gatewayControllerName: gatewayControllerName,
+		gatewayRef:            gatewayRef,
+		excludedGateways:      excludedGateways,
 		statusUpdater:         sh.Writer(),
 	}
 	if err := s.mgr.Add(lbsw); err != nil {
@@ -522,6 +473,39 @@ func (s *Server) doServe() error {
 			Info("Watching Service for Ingress status")
 	}
 
+	// Set up a Gateway load balancer status writer for each Gateway
+	// that is served by its own Envoy service.
+	for gateway, service := range gatewayServices {
+		gateway, service := gateway, service
+
+		gwsw := &loadBalancerStatusWriter{
+			log:                   s.log.WithField("context", "loadBalancerStatusWriter").WithField("gateway", gateway),
+			cache:                 s.mgr.GetCache(),
+			lbStatus:              make(chan corev1.LoadBalancerStatus, 1),
+			gatewayControllerName: gatewayControllerName,
+			gatewayRef:            &gateway,
+			gatewayOnly:           true,
+			statusUpdater:         sh.Writer(),
+		}
+		if err := s.mgr.Add(gwsw); err != nil {
+			return err
+		}
+
+		handler := k8s.NewNamespaceFilter([]string{service.Namespace}, &k8s.ServiceStatusLoadBalancerWatcher{
+			ServiceName: service.Name,
+			LBStatus:    gwsw.lbStatus,
+			Log:         s.log.WithField("context", "serviceStatusLoadBalancerWatcher").WithField("gateway", gateway),
+		})
+		if err := informOnResource(&corev1.Service{}, handler, s.mgr.GetCache()); err != nil {
+			s.log.WithError(err).WithField("resource", "services").Fatal("failed to create informer")
+		}
+
+		s.log.WithField("envoy-service-name", service.Name).
+			WithField("envoy-service-namespace", service.Namespace).
+			WithField("gateway", gateway).
+			Info("Watching Service for Gateway status")
+	}
+
 	xdsServer := &xdsServer{
 		log:             s.log,
 		mgr:             s.mgr,
@@ -548,6 +532,114 @@ func (s *Server) doServe() error {
 	return s.mgr.Start(signals.SetupSignalHandler())
 }
 
+// newListenerConfig returns the xdscache_v3.ListenerConfig for the
+// supplied SesameConfigurationSpec. The rate limit configuration is
+// left unset since it requires looking up an ExtensionService.
+func newListenerConfig(sesameConfiguration sesame_api_v1alpha1.SesameConfigurationSpec) (xdscache_v3.ListenerConfig, error) {
+	cipherSuites := []string{}
+	for _, cs := range sesameConfiguration.Envoy.Listener.TLS.CipherSuites {
+		cipherSuites = append(cipherSuites, string(cs))
+	}
+
+	timeouts, err := sesameconfig.ParseTimeoutPolicy(sesameConfiguration.Envoy.Timeouts)
+	if err != nil {
+		return xdscache_v3.ListenerConfig{}, err
+	}
+
+	accessLogFormatString := ""
+	if sesameConfiguration.Envoy.Logging.AccessLogFormatString != nil {
+		accessLogFormatString = *sesameConfiguration.Envoy.Logging.AccessLogFormatString
+	}
+
+	var http3 *xdscache_v3.HTTP3Config
+	if h := sesameConfiguration.Envoy.HTTP3; h != nil {
+		http3 = &xdscache_v3.HTTP3Config{
+			Address:                     h.Address,
+			Port:                        h.Port,
+			AdvertisedPort:              h.AdvertisedPort,
+			MaxConcurrentStreams:        h.MaxConcurrentStreams,
+			InitialStreamWindowSize:     h.InitialStreamWindowSize,
+			InitialConnectionWindowSize: h.InitialConnectionWindowSize,
+		}
+		// Default to the HTTPS listener's address and port, which
+		// Envoy can bind for both TCP and UDP.
+		if http3.Address == "" {
+			http3.Address = sesameConfiguration.Envoy.HTTPSListener.Address
+		}
+		if http3.Port == 0 {
+			http3.Port = sesameConfiguration.Envoy.HTTPSListener.Port
+		}
+		if http3.AdvertisedPort == 0 {
+			http3.AdvertisedPort = 443
+		}
+	}
+
+	listenerConfig := xdscache_v3.ListenerConfig{
+		UseProxyProto: sesameConfiguration.Envoy.Listener.UseProxyProto,
+		HTTPListeners: map[string]xdscache_v3.Listener{
+			xdscache_v3.ENVOY_HTTP_LISTENER: {
+				Name:           xdscache_v3.ENVOY_HTTP_LISTENER,
+				Address:        sesameConfiguration.Envoy.HTTPListener.Address,
+				Port:           sesameConfiguration.Envoy.HTTPListener.Port,
+				MaxConnections: sesameConfiguration.Envoy.HTTPListener.MaxConnections,
+			},
+		},
+		HTTPAccessLog: sesameConfiguration.Envoy.HTTPListener.AccessLog,
+		HTTPSListeners: map[string]xdscache_v3.Listener{
+			xdscache_v3.ENVOY_HTTPS_LISTENER: {
+				Name:           xdscache_v3.ENVOY_HTTPS_LISTENER,
+				Address:        sesameConfiguration.Envoy.HTTPSListener.Address,
+				Port:           sesameConfiguration.Envoy.HTTPSListener.Port,
+				MaxConnections: sesameConfiguration.Envoy.HTTPSListener.MaxConnections,
+			},
+		},
+		HTTPSAccessLog:               sesameConfiguration.Envoy.HTTPSListener.AccessLog,
+		AccessLogType:                sesameConfiguration.Envoy.Logging.AccessLogFormat,
+		AccessLogFields:              sesameConfiguration.Envoy.Logging.AccessLogFields,
+		AccessLogFormatString:        accessLogFormatString,
+		AccessLogFormatterExtensions: AccessLogFormatterExtensions(sesameConfiguration.Envoy.Logging.AccessLogFormat, sesameConfiguration.Envoy.Logging.AccessLogFields, sesameConfiguration.Envoy.Logging.AccessLogFormatString),
+		MinimumTLSVersion:            annotation.MinTLSVersion(sesameConfiguration.Envoy.Listener.TLS.MinimumProtocolVersion, "1.2"),
+		CipherSuites:                 config.SanitizeCipherSuites(cipherSuites),
+		Timeouts:                     timeouts,
+		DefaultHTTPVersions:          parseDefaultHTTPVersions(sesameConfiguration.Envoy.DefaultHTTPVersions),
+		AllowChunkedLength:           !sesameConfiguration.Envoy.Listener.DisableAllowChunkedLength,
+		XffNumTrustedHops:            sesameConfiguration.Envoy.Network.XffNumTrustedHops,
+		ConnectionBalancer:           sesameConfiguration.Envoy.Listener.ConnectionBalancer,
+		HTTP3:                        http3,
+	}
+
+	// Add the Envoy listeners of Gateways that are mapped
+	// onto their own set of listeners.
+	if sesameConfiguration.Gateway != nil {
+		for _, mapping := range sesameConfiguration.Gateway.Gateways {
+			gateway := types.NamespacedName{Namespace: mapping.Gateway.Namespace, Name: mapping.Gateway.Name}
+
+			if l := mapping.HTTPListener; l != nil {
+				name := xdscache_v3.GatewayListenerName(xdscache_v3.ENVOY_HTTP_LISTENER, gateway)
+				listenerConfig.HTTPListeners[name] = xdscache_v3.Listener{
+					Name:           name,
+					Address:        l.Address,
+					Port:           l.Port,
+					AccessLog:      l.AccessLog,
+					MaxConnections: sesameConfiguration.Envoy.HTTPListener.MaxConnections,
+				}
+			}
+			if l := mapping.HTTPSListener; l != nil {
+				name := xdscache_v3.GatewayListenerName(xdscache_v3.ENVOY_HTTPS_LISTENER, gateway)
+				listenerConfig.HTTPSListeners[name] = xdscache_v3.Listener{
+					Name:           name,
+					Address:        l.Address,
+					Port:           l.Port,
+					AccessLog:      l.AccessLog,
+					MaxConnections: sesameConfiguration.Envoy.HTTPSListener.MaxConnections,
+				}
+			}
+		}
+	}
+
+	return listenerConfig, nil
+}
+
 func (s *Server) setupRateLimitService(SesameConfiguration sesame_api_v1alpha1.SesameConfigurationSpec) (*xdscache_v3.RateLimitConfig, error) {
 	if SesameConfiguration.RateLimitService == nil {
 		return nil, nil
@@ -566,6 +658,14 @@ func (s *Server) setupRateLimitService(SesameConfiguration sesame_api_v1alpha1.S
 		return nil, fmt.Errorf("error getting rate limit extension service %s: %v", key, err)
 	}
 
+	return newRateLimitConfig(SesameConfiguration, extensionSvc)
+}
+
+// newRateLimitConfig returns the xdscache_v3.RateLimitConfig for the
+// supplied SesameConfigurationSpec and its rate limit ExtensionService.
+func newRateLimitConfig(SesameConfiguration sesame_api_v1alpha1.SesameConfigurationSpec, extensionSvc *sesame_api_v1alpha1.ExtensionService) (*xdscache_v3.RateLimitConfig, error) {
+	key := k8s.NamespacedNameOf(extensionSvc)
+
 	// get the response timeout from the ExtensionService
 	var responseTimeout timeout.Setting
 	var err error
@@ -598,6 +698,45 @@ func (s *Server) setupDebugService(debugConfig sesame_api_v1alpha1.DebugConfig,
 	return s.mgr.Add(debugsvc)
 }
 
+func (s *Server) setupWebhook(webhookConfig sesame_api_v1alpha1.WebhookConfig, dbc dagBuilderConfig) error {
+	webhooksvc := &webhook.Service{
+		Service: httpsvc.Service{
+			Addr:        webhookConfig.Address,
+			Port:        webhookConfig.Port,
+			Cert:        webhookConfig.CertFile,
+			Key:         webhookConfig.KeyFile,
+			FieldLogger: s.log.WithField("context", "webhook"),
+		},
+		Scheme: s.mgr.GetScheme(),
+		Validator: &webhook.Validator{
+			Client: s.mgr.GetClient(),
+			NewBuilder: func() *dag.Builder {
+				return s.getDAGBuilder(dbc)
+			},
+			FieldLogger: s.log.WithField("context", "webhook-validator"),
+		},
+	}
+	return s.mgr.Add(webhooksvc)
+}
+
+func (s *Server) setupCertificateRotation(rotationConfig sesame_api_v1alpha1.CertificateRotationConfig, sesameMetrics *metrics.Metrics) error {
+	// Validated by SesameConfigurationSpec.Validate.
+	renewBefore, _ := time.ParseDuration(rotationConfig.RenewBefore)
+
+	rotator := &certrotation.Rotator{
+		Client:       s.mgr.GetClient(),
+		Namespace:    rotationConfig.Namespace,
+		CASecretName: rotationConfig.CASecretName,
+		SecretNames:  rotationConfig.SecretNames,
+		RenewBefore:  renewBefore,
+		Lifetime:     rotationConfig.Lifetime,
+		Recorder:     s.mgr.GetEventRecorderFor("sesame"),
+		Metrics:      sesameMetrics,
+		FieldLogger:  s.log.WithField("context", "certrotation"),
+	}
+	return s.mgr.Add(rotator)
+}
+
 type xdsServer struct {
 	log             logrus.FieldLogger
 	mgr             manager.Manager
@@ -719,27 +858,33 @@ func (s *Server) setupGatewayAPI(SesameConfiguration sesame_api_v1alpha1.SesameC
 
 	// Check if GatewayAPI is configured.
 	if SesameConfiguration.Gateway != nil {
-		// Create and register the gatewayclass controller with the manager.
 		gatewayClassControllerName := SesameConfiguration.Gateway.ControllerName
-		gwClass, err := controller.RegisterGatewayClassController(
-			s.log.WithField("context", "gatewayclass-controller"),
-			mgr,
-			eventHandler,
-			sh.Writer(),
-			gatewayClassControllerName,
-		)
-		if err != nil {
-			s.log.WithError(err).Fatal("failed to create gatewayclass-controller")
+		gatewayRef := gatewayRefOf(SesameConfiguration.Gateway)
+
+		// Create and register the gatewayclass controller with the manager,
+		// unless Sesame corresponds to a specific Gateway, in which case
+		// its GatewayClass is managed elsewhere.
+		if gatewayRef == nil {
+			gwClass, err := controller.RegisterGatewayClassController(
+				s.log.WithField("context", "gatewayclass-controller"),
+				mgr,
+				eventHandler,
+				sh.Writer(),
+				gatewayClassControllerName,
+			)
+			if err != nil {
+				s.log.WithError(err).Fatal("failed to create gatewayclass-controller")
+			}
+			needLeadershipNotification = append(needLeadershipNotification, gwClass)
 		}
-		needLeadershipNotification = append(needLeadershipNotification, gwClass)
 
 		// Create and register the NewGatewayController controller with the manager.
 		gw, err := controller.RegisterGatewayController(
 			s.log.WithField("context", "gateway-controller"),
 			mgr,
 			eventHandler,
-			sh.Writer(),
 			gatewayClassControllerName,
+			gatewayRef,
 		)
 		if err != nil {
 			s.log.WithError(err).Fatal("failed to create gateway-controller")
@@ -761,25 +906,171 @@ func (s *Server) setupGatewayAPI(SesameConfiguration sesame_api_v1alpha1.SesameC
 			s.log.WithError(err).WithField("resource", "referencepolicies").Fatal("failed to create informer")
 		}
 
-		// Inform on Namespaces.
-		if err := informOnResource(&corev1.Namespace{}, eventHandler, mgr.GetCache()); err != nil {
-			s.log.WithError(err).WithField("resource", "namespaces").Fatal("failed to create informer")
+		// Inform on GatewayClassParameters.
+		if err := informOnResource(&sesame_api_v1alpha1.GatewayClassParameters{}, eventHandler, mgr.GetCache()); err != nil {
+			s.log.WithError(err).WithField("resource", "gatewayclassparameters").Fatal("failed to create informer")
 		}
 	}
 	return needLeadershipNotification
 }
 
+// informOnDefaultResources informs on each of the defaultResources.
+func (s *Server) informOnDefaultResources(handler cache.ResourceEventHandler, cache ctrl_cache.Cache) {
+	for name, r := range defaultResources() {
+		if err := informOnResource(r, handler, cache); err != nil {
+			s.log.WithError(err).WithField("resource", name).Fatal("failed to create informer")
+		}
+	}
+}
+
+// defaultResources returns the resources that Sesame informs on whether
+// or not Gateway API is enabled, keyed by resource name. Namespaces are
+// included since TLSCertificateDelegations can select their target
+// namespaces by label for HTTPProxy and Ingress too.
+func defaultResources() map[string]client.Object {
+	return map[string]client.Object{
+		"httpproxies":               &sesame_api_v1.HTTPProxy{},
+		"tlscertificatedelegations": &sesame_api_v1.TLSCertificateDelegation{},
+		"extensionservices":         &sesame_api_v1alpha1.ExtensionService{},
+		"servicereferencegrants":    &sesame_api_v1alpha1.ServiceReferenceGrant{},
+		"sesameconfigurations":      &sesame_api_v1alpha1.SesameConfiguration{},
+		"configmaps":                &corev1.ConfigMap{},
+		"namespaces":                &corev1.Namespace{},
+		"services":                  &corev1.Service{},
+		"ingresses":                 &networking_v1.Ingress{},
+		"ingressclasses":            &networking_v1.IngressClass{},
+	}
+}
+
+// gatewayRefOf returns the Gateway this Sesame corresponds to,
+// or nil if Sesame reconciles the Gateways of its GatewayClass.
+func gatewayRefOf(gatewayConfig *sesame_api_v1alpha1.GatewayConfig) *types.NamespacedName {
+	if gatewayConfig.GatewayRef == nil {
+		return nil
+	}
+
+	return &types.NamespacedName{
+		Namespace: gatewayConfig.GatewayRef.Namespace,
+		Name:      gatewayConfig.GatewayRef.Name,
+	}
+}
+
+// gatewayServicesOf returns the Envoy services of the Gateways
+// that are mapped onto their own Envoy service.
+func gatewayServicesOf(gatewayConfig *sesame_api_v1alpha1.GatewayConfig) map[types.NamespacedName]types.NamespacedName {
+	gatewayServices := map[types.NamespacedName]types.NamespacedName{}
+	for _, mapping := range gatewayConfig.Gateways {
+		if mapping.Service == nil {
+			continue
+		}
+
+		gateway := types.NamespacedName{Namespace: mapping.Gateway.Namespace, Name: mapping.Gateway.Name}
+		gatewayServices[gateway] = types.NamespacedName{Namespace: mapping.Service.Namespace, Name: mapping.Service.Name}
+	}
+
+	return gatewayServices
+}
+
+// gatewayListenersOf returns the Envoy listener names of the Gateways
+// that are mapped onto their own set of Envoy listeners.
+func gatewayListenersOf(gatewayConfig *sesame_api_v1alpha1.GatewayConfig) map[types.NamespacedName]dag.GatewayEnvoyListeners {
+	if gatewayConfig == nil || len(gatewayConfig.Gateways) == 0 {
+		return nil
+	}
+
+	gatewayListeners := map[types.NamespacedName]dag.GatewayEnvoyListeners{}
+	for _, mapping := range gatewayConfig.Gateways {
+		gateway := types.NamespacedName{Namespace: mapping.Gateway.Namespace, Name: mapping.Gateway.Name}
+
+		var listeners dag.GatewayEnvoyListeners
+		if mapping.HTTPListener != nil {
+			listeners.HTTP = xdscache_v3.GatewayListenerName(xdscache_v3.ENVOY_HTTP_LISTENER, gateway)
+		}
+		if mapping.HTTPSListener != nil {
+			listeners.HTTPS = xdscache_v3.GatewayListenerName(xdscache_v3.ENVOY_HTTPS_LISTENER, gateway)
+		}
+		gatewayListeners[gateway] = listeners
+	}
+
+	return gatewayListeners
+}
+
+// newDAGBuilderConfig returns the DAG builder configuration for
+// sesameConfiguration. It is shared by serve and render so that
+// both build the same DAG from the same configuration.
+func newDAGBuilderConfig(sesameConfiguration sesame_api_v1alpha1.SesameConfigurationSpec) dagBuilderConfig {
+	ingressClassName := ""
+	if sesameConfiguration.Ingress != nil && sesameConfiguration.Ingress.ClassName != nil {
+		ingressClassName = *sesameConfiguration.Ingress.ClassName
+	}
+
+	var clientCert *types.NamespacedName
+	var fallbackCert *types.NamespacedName
+	if sesameConfiguration.Envoy.ClientCertificate != nil {
+		clientCert = &types.NamespacedName{Name: sesameConfiguration.Envoy.ClientCertificate.Name, Namespace: sesameConfiguration.Envoy.ClientCertificate.Namespace}
+	}
+	if sesameConfiguration.HTTPProxy.FallbackCertificate != nil {
+		fallbackCert = &types.NamespacedName{Name: sesameConfiguration.HTTPProxy.FallbackCertificate.Name, Namespace: sesameConfiguration.HTTPProxy.FallbackCertificate.Namespace}
+	}
+
+	// A Sesame that serves a single Gateway serves each of its listener
+	// ports from an Envoy listener of its own. The Gateway provisioner
+	// forwards each port of the Envoy service to the matching listener.
+	var gatewayPortListeners *dag.GatewayPortListeners
+	if sesameConfiguration.Gateway != nil && sesameConfiguration.Gateway.GatewayRef != nil {
+		gatewayPortListeners = &dag.GatewayPortListeners{
+			HTTPPort:  sesameConfiguration.Envoy.HTTPListener.Port,
+			HTTPSPort: sesameConfiguration.Envoy.HTTPSListener.Port,
+		}
+	}
+
+	return dagBuilderConfig{
+		ingressClassName:           ingressClassName,
+		rootNamespaces:             sesameConfiguration.HTTPProxy.RootNamespaces,
+		gatewayAPIConfigured:       sesameConfiguration.Gateway != nil,
+		gatewayListeners:           gatewayListenersOf(sesameConfiguration.Gateway),
+		gatewayPortListeners:       gatewayPortListeners,
+		disablePermitInsecure:      sesameConfiguration.HTTPProxy.DisablePermitInsecure,
+		enableExternalNameService:  sesameConfiguration.EnableExternalNameService,
+		dnsLookupFamily:            sesameConfiguration.Envoy.Cluster.DNSLookupFamily,
+		connectionPolicy:           sesameConfiguration.Envoy.Cluster.ConnectionPolicy,
+		retryBudget:                sesameConfiguration.Envoy.Cluster.RetryBudget,
+		headersPolicy:              sesameConfiguration.Policy,
+		applyHeaderPolicyToIngress: sesameConfiguration.Policy != nil && sesameConfiguration.Policy.ApplyToIngress,
+		clientCert:                 clientCert,
+		fallbackCert:               fallbackCert,
+		certificateExpiryWarning:   certificateExpiryWarningOf(sesameConfiguration.Envoy.Listener.TLS),
+	}
+}
+
 type dagBuilderConfig struct {
 	ingressClassName           string
 	rootNamespaces             []string
 	gatewayAPIConfigured       bool
+	gatewayListeners           map[types.NamespacedName]dag.GatewayEnvoyListeners
+	gatewayPortListeners       *dag.GatewayPortListeners
 	disablePermitInsecure      bool
 	enableExternalNameService  bool
 	dnsLookupFamily            sesame_api_v1alpha1.ClusterDNSFamilyType
+	connectionPolicy           *sesame_api_v1.ConnectionPolicy
+	retryBudget                *sesame_api_v1.RetryBudget
 	headersPolicy              *sesame_api_v1alpha1.PolicyConfig
 	applyHeaderPolicyToIngress bool
 	clientCert                 *types.NamespacedName
 	fallbackCert               *types.NamespacedName
+	certificateExpiryWarning   time.Duration
+}
+
+// certificateExpiryWarningOf returns how long before expiry serving
+// certificates are reported as expiring, defaulting to 30 days.
+func certificateExpiryWarningOf(tls sesame_api_v1alpha1.EnvoyTLS) time.Duration {
+	if tls.CertificateExpiryWarning == "" {
+		return 720 * time.Hour
+	}
+
+	// The value has already been validated.
+	window, _ := time.ParseDuration(tls.CertificateExpiryWarning)
+	return window
 }
 
 func (s *Server) getDAGBuilder(dbc dagBuilderConfig) *dag.Builder {
@@ -795,6 +1086,18 @@ func (s *Server) getDAGBuilder(dbc dagBuilderConfig) *dag.Builder {
 					requestHeadersPolicy.Set[k] = v
 				}
 			}
+			if dbc.headersPolicy.RequestHeadersPolicy.Add != nil {
+				requestHeadersPolicy.Add = make(map[string]string)
+				for k, v := range dbc.headersPolicy.RequestHeadersPolicy.Add {
+					requestHeadersPolicy.Add[k] = v
+				}
+			}
+			if dbc.headersPolicy.RequestHeadersPolicy.AddIfAbsent != nil {
+				requestHeadersPolicy.AddIfAbsent = make(map[string]string)
+				for k, v := range dbc.headersPolicy.RequestHeadersPolicy.AddIfAbsent {
+					requestHeadersPolicy.AddIfAbsent[k] = v
+				}
+			}
 			if dbc.headersPolicy.RequestHeadersPolicy.Remove != nil {
 				requestHeadersPolicy.Remove = make([]string, 0, len(dbc.headersPolicy.RequestHeadersPolicy.Remove))
 				requestHeadersPolicy.Remove = append(requestHeadersPolicy.Remove, dbc.headersPolicy.RequestHeadersPolicy.Remove...)
@@ -808,6 +1111,18 @@ func (s *Server) getDAGBuilder(dbc dagBuilderConfig) *dag.Builder {
 					responseHeadersPolicy.Set[k] = v
 				}
 			}
+			if dbc.headersPolicy.ResponseHeadersPolicy.Add != nil {
+				responseHeadersPolicy.Add = make(map[string]string)
+				for k, v := range dbc.headersPolicy.ResponseHeadersPolicy.Add {
+					responseHeadersPolicy.Add[k] = v
+				}
+			}
+			if dbc.headersPolicy.ResponseHeadersPolicy.AddIfAbsent != nil {
+				responseHeadersPolicy.AddIfAbsent = make(map[string]string)
+				for k, v := range dbc.headersPolicy.ResponseHeadersPolicy.AddIfAbsent {
+					responseHeadersPolicy.AddIfAbsent[k] = v
+				}
+			}
 			if dbc.headersPolicy.ResponseHeadersPolicy.Remove != nil {
 				responseHeadersPolicy.Remove = make([]string, 0, len(dbc.headersPolicy.ResponseHeadersPolicy.Remove))
 				responseHeadersPolicy.Remove = append(responseHeadersPolicy.Remove, dbc.headersPolicy.ResponseHeadersPolicy.Remove...)
@@ -832,6 +1147,7 @@ func (s *Server) getDAGBuilder(dbc dagBuilderConfig) *dag.Builder {
 			ClientCertificate:         dbc.clientCert,
 			RequestHeadersPolicy:      &requestHeadersPolicyIngress,
 			ResponseHeadersPolicy:     &responseHeadersPolicyIngress,
+			CertificateExpiryWarning:  dbc.certificateExpiryWarning,
 		},
 		&dag.ExtensionServiceProcessor{
 			// Note that ExtensionService does not support ExternalName, if it does get added,
@@ -844,9 +1160,12 @@ func (s *Server) getDAGBuilder(dbc dagBuilderConfig) *dag.Builder {
 			DisablePermitInsecure:     dbc.disablePermitInsecure,
 			FallbackCertificate:       dbc.fallbackCert,
 			DNSLookupFamily:           dbc.dnsLookupFamily,
+			ConnectionPolicy:          dbc.connectionPolicy,
+			RetryBudget:               dbc.retryBudget,
 			ClientCertificate:         dbc.clientCert,
 			RequestHeadersPolicy:      &requestHeadersPolicy,
 			ResponseHeadersPolicy:     &responseHeadersPolicy,
+			CertificateExpiryWarning:  dbc.certificateExpiryWarning,
 		},
 	}
 
@@ -854,9 +1173,16 @@ func (s *Server) getDAGBuilder(dbc dagBuilderConfig) *dag.Builder {
 		dagProcessors = append(dagProcessors, &dag.GatewayAPIProcessor{
 			EnableExternalNameService: dbc.enableExternalNameService,
 			FieldLogger:               s.log.WithField("context", "GatewayAPIProcessor"),
+			GatewayListeners:          dbc.gatewayListeners,
+			GatewayPortListeners:      dbc.gatewayPortListeners,
+			CertificateExpiryWarning:  dbc.certificateExpiryWarning,
 		})
 	}
 
+	// The TLSCertificateDelegation processor looks at the secret
+	// references made by the other processors.
+	dagProcessors = append(dagProcessors, &dag.TLSCertificateDelegationProcessor{})
+
 	// The listener processor has to go last since it looks at
 	// the output of the other processors.
 	dagProcessors = append(dagProcessors, &dag.ListenerProcessor{})
@@ -14,26 +14,74 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"reflect"
 	"testing"
 
+	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
 	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
 
 	"github.com/projectsesame/sesame/internal/dag"
+	"github.com/projectsesame/sesame/internal/sesame"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	ctrl_cache "sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// recordingCache records the objects informed on.
+type recordingCache struct {
+	ctrl_cache.Cache
+
+	informed []client.Object
+}
+
+func (c *recordingCache) GetInformer(_ context.Context, obj client.Object) (ctrl_cache.Informer, error) {
+	c.informed = append(c.informed, obj)
+	return nopInformer{}, nil
+}
+
+type nopInformer struct {
+	ctrl_cache.Informer
+}
+
+func (nopInformer) AddEventHandler(cache.ResourceEventHandler) {}
+
+func TestInformOnNamespacesWithoutGatewayAPI(t *testing.T) {
+	serve := &Server{
+		log: logrus.StandardLogger(),
+	}
+
+	// Nothing is informed on for Gateway API when it's not configured.
+	assert.Empty(t, serve.setupGatewayAPI(sesame_api_v1alpha1.SesameConfigurationSpec{}, nil, nil, nil))
+
+	c := &recordingCache{}
+	serve.informOnDefaultResources(&sesame.EventRecorder{}, c)
+
+	// TLSCertificateDelegations may select their target namespaces by
+	// label, so namespaces must still be informed on.
+	assert.Contains(t, c.informed, &corev1.Namespace{})
+	assert.Contains(t, c.informed, &sesame_api_v1.TLSCertificateDelegation{})
+	for _, obj := range c.informed {
+		assert.NotContains(t, fmt.Sprintf("%T", obj), "v1alpha2", "informed on Gateway API resource %T", obj)
+	}
+}
+
 func TestGetDAGBuilder(t *testing.T) {
 	commonAssertions := func(t *testing.T, builder *dag.Builder) {
 		t.Helper()
 
-		// note that these first two assertions will not hold when a gateway
+		// note that these assertions will not hold when a gateway
 		// is configured, but we don't currently have test cases that cover
 		// that so it's OK to keep them in the "common" assertions for now.
-		assert.Len(t, builder.Processors, 4)
+		assert.Len(t, builder.Processors, 5)
 		assert.IsType(t, &dag.ListenerProcessor{}, builder.Processors[len(builder.Processors)-1])
+		assert.IsType(t, &dag.TLSCertificateDelegationProcessor{}, builder.Processors[len(builder.Processors)-2])
 	}
 
 	t.Run("all default options", func(t *testing.T) {
@@ -155,6 +203,80 @@ func TestGetDAGBuilder(t *testing.T) {
 	// TODO(3453): test additional properties of the DAG builder (processor fields, cache fields, Gateway tests (requires a client fake))
 }
 
+// TestNewDAGBuilderConfig ensures that every field of the DAG builder
+// configuration is derived from the SesameConfiguration, since serve
+// and render both build their DAG from newDAGBuilderConfig.
+func TestNewDAGBuilderConfig(t *testing.T) {
+	className := "sesame"
+	spec := sesame_api_v1alpha1.SesameConfigurationSpec{
+		Ingress: &sesame_api_v1alpha1.IngressConfig{
+			ClassName: &className,
+		},
+		HTTPProxy: sesame_api_v1alpha1.HTTPProxyConfig{
+			RootNamespaces:        []string{"root"},
+			DisablePermitInsecure: true,
+			FallbackCertificate: &sesame_api_v1alpha1.NamespacedName{
+				Namespace: "projectsesame",
+				Name:      "fallback",
+			},
+		},
+		Gateway: &sesame_api_v1alpha1.GatewayConfig{
+			ControllerName: "projectsesame.io/projectsesame/sesame",
+			GatewayRef: &sesame_api_v1alpha1.NamespacedName{
+				Namespace: "default",
+				Name:      "gw",
+			},
+			Gateways: []sesame_api_v1alpha1.GatewayEnvoyMapping{{
+				Gateway: sesame_api_v1alpha1.NamespacedName{
+					Namespace: "default",
+					Name:      "gw",
+				},
+				HTTPListener: &sesame_api_v1alpha1.EnvoyListener{
+					Address: "0.0.0.0",
+					Port:    9080,
+				},
+			}},
+		},
+		EnableExternalNameService: true,
+		Envoy: sesame_api_v1alpha1.EnvoyConfig{
+			HTTPListener: sesame_api_v1alpha1.EnvoyListener{
+				Port: 8080,
+			},
+			HTTPSListener: sesame_api_v1alpha1.EnvoyListener{
+				Port: 8443,
+			},
+			ClientCertificate: &sesame_api_v1alpha1.NamespacedName{
+				Namespace: "projectsesame",
+				Name:      "client",
+			},
+			Cluster: sesame_api_v1alpha1.ClusterParameters{
+				DNSLookupFamily: sesame_api_v1alpha1.IPv4ClusterDNSFamily,
+				ConnectionPolicy: &sesame_api_v1.ConnectionPolicy{
+					MaxConnections: 100,
+				},
+				RetryBudget: &sesame_api_v1.RetryBudget{},
+			},
+		},
+		Policy: &sesame_api_v1alpha1.PolicyConfig{
+			ApplyToIngress: true,
+		},
+	}
+
+	dbc := reflect.ValueOf(newDAGBuilderConfig(spec))
+	for i := 0; i < dbc.NumField(); i++ {
+		assert.False(t, dbc.Field(i).IsZero(), "newDAGBuilderConfig does not set %s", dbc.Type().Field(i).Name)
+	}
+
+	assert.Equal(t, map[types.NamespacedName]dag.GatewayEnvoyListeners{
+		{Namespace: "default", Name: "gw"}: {HTTP: "ingress_http_default_gw"},
+	}, newDAGBuilderConfig(spec).gatewayListeners)
+
+	assert.Equal(t, &dag.GatewayPortListeners{
+		HTTPPort:  8080,
+		HTTPSPort: 8443,
+	}, newDAGBuilderConfig(spec).gatewayPortListeners)
+}
+
 func mustGetHTTPProxyProcessor(t *testing.T, builder *dag.Builder) *dag.HTTPProxyProcessor {
 	t.Helper()
 	for i := range builder.Processors {
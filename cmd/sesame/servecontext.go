@@ -16,19 +16,20 @@ package main
 import (
 	"crypto/rand"
 	"crypto/tls"
-	"crypto/x509"
 	"errors"
-	"fmt"
-	"io/ioutil"
+	"math"
 	"strings"
 	"time"
 
 	"github.com/projectsesame/sesame/internal/k8s"
 	"k8s.io/utils/pointer"
 
+	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
 	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
+	"github.com/projectsesame/sesame/internal/certwatch"
 	envoy_v3 "github.com/projectsesame/sesame/internal/envoy/v3"
 	xdscache_v3 "github.com/projectsesame/sesame/internal/xdscache/v3"
+	"github.com/projectsesame/sesame/pkg/certs"
 	"github.com/projectsesame/sesame/pkg/config"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc"
@@ -167,47 +168,19 @@ func tlsconfig(log logrus.FieldLogger, SesameXDSTLS *sesame_api_v1alpha1.TLS) *t
 		log.WithError(err).Fatal("failed to verify TLS flags")
 	}
 
-	// Define a closure that lazily loads certificates and key at TLS handshake
+	// The watcher reloads the certificates and key when the files change,
 	// to ensure that latest certificates are used in case they have been rotated.
-	loadConfig := func() (*tls.Config, error) {
-		if SesameXDSTLS == nil {
-			return nil, nil
-		}
-		cert, err := tls.LoadX509KeyPair(SesameXDSTLS.CertFile, SesameXDSTLS.KeyFile)
-		if err != nil {
-			return nil, err
-		}
-
-		ca, err := ioutil.ReadFile(SesameXDSTLS.CAFile)
-		if err != nil {
-			return nil, err
-		}
-
-		certPool := x509.NewCertPool()
-		if ok := certPool.AppendCertsFromPEM(ca); !ok {
-			return nil, fmt.Errorf("unable to append certificate in %s to CA pool", SesameXDSTLS.CAFile)
-		}
-
-		return &tls.Config{
-			Certificates: []tls.Certificate{cert},
-			ClientAuth:   tls.RequireAndVerifyClientCert,
-			ClientCAs:    certPool,
-			MinVersion:   tls.VersionTLS13,
-		}, nil
-	}
-
-	// Attempt to load certificates and key to catch configuration errors early.
-	if _, lerr := loadConfig(); lerr != nil {
-		log.WithError(lerr).Fatal("failed to load certificate and key")
+	// Loading them now catches configuration errors early.
+	watcher, err := certwatch.New(log.WithField("context", "xds-tls"), SesameXDSTLS.CertFile, SesameXDSTLS.KeyFile, SesameXDSTLS.CAFile)
+	if err != nil {
+		log.WithError(err).Fatal("failed to load certificate and key")
 	}
 
 	return &tls.Config{
-		MinVersion: tls.VersionTLS13,
-		ClientAuth: tls.RequireAndVerifyClientCert,
-		Rand:       rand.Reader,
-		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
-			return loadConfig()
-		},
+		MinVersion:         tls.VersionTLS13,
+		ClientAuth:         tls.RequireAndVerifyClientCert,
+		Rand:               rand.Reader,
+		GetConfigForClient: watcher.GetConfigForClient,
 	}
 }
 
@@ -281,6 +254,29 @@ func (ctx *serveContext) convertToSesameConfigurationSpec() sesame_api_v1alpha1.
 		gatewayConfig = &sesame_api_v1alpha1.GatewayConfig{
 			ControllerName: ctx.Config.GatewayConfig.ControllerName,
 		}
+		if ref := ctx.Config.GatewayConfig.GatewayRef; ref != nil {
+			gatewayConfig.GatewayRef = &sesame_api_v1alpha1.NamespacedName{
+				Name:      ref.Name,
+				Namespace: ref.Namespace,
+			}
+		}
+		for _, m := range ctx.Config.GatewayConfig.Gateways {
+			mapping := sesame_api_v1alpha1.GatewayEnvoyMapping{
+				Gateway: sesame_api_v1alpha1.NamespacedName{
+					Name:      m.Gateway.Name,
+					Namespace: m.Gateway.Namespace,
+				},
+				HTTPListener:  gatewayEnvoyListener(m.HTTPListener),
+				HTTPSListener: gatewayEnvoyListener(m.HTTPSListener),
+			}
+			if m.Service != nil {
+				mapping.Service = &sesame_api_v1alpha1.NamespacedName{
+					Name:      m.Service.Name,
+					Namespace: m.Service.Namespace,
+				}
+			}
+			gatewayConfig.Gateways = append(gatewayConfig.Gateways, mapping)
+		}
 	}
 
 	var cipherSuites []sesame_api_v1alpha1.TLSCipherType
@@ -356,12 +352,16 @@ func (ctx *serveContext) convertToSesameConfigurationSpec() sesame_api_v1alpha1.
 
 	policy := &sesame_api_v1alpha1.PolicyConfig{
 		RequestHeadersPolicy: &sesame_api_v1alpha1.HeadersPolicy{
-			Set:    ctx.Config.Policy.RequestHeadersPolicy.Set,
-			Remove: ctx.Config.Policy.RequestHeadersPolicy.Remove,
+			Set:         ctx.Config.Policy.RequestHeadersPolicy.Set,
+			Add:         ctx.Config.Policy.RequestHeadersPolicy.Add,
+			AddIfAbsent: ctx.Config.Policy.RequestHeadersPolicy.AddIfAbsent,
+			Remove:      ctx.Config.Policy.RequestHeadersPolicy.Remove,
 		},
 		ResponseHeadersPolicy: &sesame_api_v1alpha1.HeadersPolicy{
-			Set:    ctx.Config.Policy.ResponseHeadersPolicy.Set,
-			Remove: ctx.Config.Policy.ResponseHeadersPolicy.Remove,
+			Set:         ctx.Config.Policy.ResponseHeadersPolicy.Set,
+			Add:         ctx.Config.Policy.ResponseHeadersPolicy.Add,
+			AddIfAbsent: ctx.Config.Policy.ResponseHeadersPolicy.AddIfAbsent,
+			Remove:      ctx.Config.Policy.ResponseHeadersPolicy.Remove,
 		},
 		ApplyToIngress: ctx.Config.Policy.ApplyToIngress,
 	}
@@ -407,6 +407,107 @@ func (ctx *serveContext) convertToSesameConfigurationSpec() sesame_api_v1alpha1.
 	setMetricsFromConfig(ctx.Config.Metrics.Sesame, &SesameMetrics)
 	setMetricsFromConfig(ctx.Config.Metrics.Envoy, &envoyMetrics)
 
+	var webhook *sesame_api_v1alpha1.WebhookConfig
+	if ctx.Config.Webhook.Enabled() {
+		webhook = &sesame_api_v1alpha1.WebhookConfig{
+			Address:  "0.0.0.0",
+			Port:     9443,
+			CertFile: ctx.Config.Webhook.ServerCert,
+			KeyFile:  ctx.Config.Webhook.ServerKey,
+		}
+		if len(ctx.Config.Webhook.Address) > 0 {
+			webhook.Address = ctx.Config.Webhook.Address
+		}
+		if ctx.Config.Webhook.Port > 0 {
+			webhook.Port = ctx.Config.Webhook.Port
+		}
+	}
+
+	var certificateRotation *sesame_api_v1alpha1.CertificateRotationConfig
+	if ctx.Config.CertificateRotation.Enabled {
+		certificateRotation = &sesame_api_v1alpha1.CertificateRotationConfig{
+			Namespace:    config.GetenvOr("Sesame_NAMESPACE", "projectsesame"),
+			CASecretName: "sesame-ca",
+			SecretNames:  []string{"sesamecert", "envoycert"},
+			RenewBefore:  "720h",
+			Lifetime:     certs.DefaultCertificateLifetime,
+		}
+		if len(ctx.Config.CertificateRotation.Namespace) > 0 {
+			certificateRotation.Namespace = ctx.Config.CertificateRotation.Namespace
+		}
+		if len(ctx.Config.CertificateRotation.CASecretName) > 0 {
+			certificateRotation.CASecretName = ctx.Config.CertificateRotation.CASecretName
+		}
+		if len(ctx.Config.CertificateRotation.SecretNames) > 0 {
+			certificateRotation.SecretNames = ctx.Config.CertificateRotation.SecretNames
+		}
+		if len(ctx.Config.CertificateRotation.RenewBefore) > 0 {
+			certificateRotation.RenewBefore = ctx.Config.CertificateRotation.RenewBefore
+		}
+		if ctx.Config.CertificateRotation.Lifetime > 0 {
+			certificateRotation.Lifetime = ctx.Config.CertificateRotation.Lifetime
+		}
+	}
+
+	var overloadManager *sesame_api_v1alpha1.OverloadManagerParameters
+	if om := ctx.Config.Bootstrap.OverloadManager; om.Enabled() || om.MaxActiveDownstreamConnections > 0 {
+		overloadManager = &sesame_api_v1alpha1.OverloadManagerParameters{
+			MaxHeapSizeBytes:                         om.MaxHeapSizeBytes,
+			MaxActiveDownstreamConnections:           om.MaxActiveDownstreamConnections,
+			ShrinkHeapThresholdPercent:               percent(om.ShrinkHeapThreshold),
+			DisableKeepaliveThresholdPercent:         percent(om.DisableKeepaliveThreshold),
+			StopAcceptingRequestsThresholdPercent:    percent(om.StopAcceptingRequestsThreshold),
+			StopAcceptingConnectionsThresholdPercent: percent(om.StopAcceptingConnectionsThreshold),
+		}
+	}
+
+	var http3 *sesame_api_v1alpha1.HTTP3Parameters
+	if ctx.Config.HTTP3.Enabled {
+		http3 = &sesame_api_v1alpha1.HTTP3Parameters{
+			Address:                     ctx.httpsAddr,
+			Port:                        ctx.httpsPort,
+			AdvertisedPort:              443,
+			MaxConcurrentStreams:        ctx.Config.HTTP3.MaxConcurrentStreams,
+			InitialStreamWindowSize:     ctx.Config.HTTP3.InitialStreamWindowSize,
+			InitialConnectionWindowSize: ctx.Config.HTTP3.InitialConnectionWindowSize,
+		}
+		if len(ctx.Config.HTTP3.Address) > 0 {
+			http3.Address = ctx.Config.HTTP3.Address
+		}
+		if ctx.Config.HTTP3.Port > 0 {
+			http3.Port = ctx.Config.HTTP3.Port
+		}
+		if ctx.Config.HTTP3.AdvertisedPort > 0 {
+			http3.AdvertisedPort = ctx.Config.HTTP3.AdvertisedPort
+		}
+	}
+
+	var connectionPolicy *sesame_api_v1.ConnectionPolicy
+	if cp := ctx.Config.Cluster.ConnectionPolicy; cp != (config.ConnectionPolicyParameters{}) {
+		connectionPolicy = &sesame_api_v1.ConnectionPolicy{
+			MaxConnections:           cp.MaxConnections,
+			MaxPendingRequests:       cp.MaxPendingRequests,
+			MaxRequestsPerConnection: cp.MaxRequestsPerConnection,
+			MaxConcurrentStreams:     cp.MaxConcurrentStreams,
+			IdleTimeout:              cp.IdleTimeout,
+		}
+		if ka := cp.TCPKeepalive; ka != nil {
+			connectionPolicy.TCPKeepalive = &sesame_api_v1.TCPKeepalive{
+				Probes:          ka.Probes,
+				IdleTimeSeconds: ka.IdleTime,
+				IntervalSeconds: ka.Interval,
+			}
+		}
+	}
+
+	var retryBudget *sesame_api_v1.RetryBudget
+	if rb := ctx.Config.Cluster.RetryBudget; rb != nil {
+		retryBudget = &sesame_api_v1.RetryBudget{
+			BudgetPercent:       rb.BudgetPercent,
+			MinRetryConcurrency: rb.MinRetryConcurrency,
+		}
+	}
+
 	// Convert serveContext to a SesameConfiguration
 	SesameConfiguration := sesame_api_v1alpha1.SesameConfigurationSpec{
 		Ingress: ingress,
@@ -426,8 +527,9 @@ func (ctx *serveContext) convertToSesameConfigurationSpec() sesame_api_v1alpha1.
 				DisableAllowChunkedLength: ctx.Config.DisableAllowChunkedLength,
 				ConnectionBalancer:        ctx.Config.Listener.ConnectionBalancer,
 				TLS: sesame_api_v1alpha1.EnvoyTLS{
-					MinimumProtocolVersion: ctx.Config.TLS.MinimumProtocolVersion,
-					CipherSuites:           cipherSuites,
+					MinimumProtocolVersion:   ctx.Config.TLS.MinimumProtocolVersion,
+					CipherSuites:             cipherSuites,
+					CertificateExpiryWarning: ctx.Config.TLS.CertificateExpiryWarning,
 				},
 			},
 			Service: sesame_api_v1alpha1.NamespacedName{
@@ -435,14 +537,16 @@ func (ctx *serveContext) convertToSesameConfigurationSpec() sesame_api_v1alpha1.
 				Namespace: ctx.Config.EnvoyServiceNamespace,
 			},
 			HTTPListener: sesame_api_v1alpha1.EnvoyListener{
-				Address:   ctx.httpAddr,
-				Port:      ctx.httpPort,
-				AccessLog: ctx.httpAccessLog,
+				Address:        ctx.httpAddr,
+				Port:           ctx.httpPort,
+				AccessLog:      ctx.httpAccessLog,
+				MaxConnections: ctx.Config.Listener.HTTPMaxConnections,
 			},
 			HTTPSListener: sesame_api_v1alpha1.EnvoyListener{
-				Address:   ctx.httpsAddr,
-				Port:      ctx.httpsPort,
-				AccessLog: ctx.httpsAccessLog,
+				Address:        ctx.httpsAddr,
+				Port:           ctx.httpsPort,
+				AccessLog:      ctx.httpsAccessLog,
+				MaxConnections: ctx.Config.Listener.HTTPSMaxConnections,
 			},
 			Metrics: envoyMetrics,
 			Health: sesame_api_v1alpha1.HealthConfig{
@@ -458,12 +562,16 @@ func (ctx *serveContext) convertToSesameConfigurationSpec() sesame_api_v1alpha1.
 			DefaultHTTPVersions: defaultHTTPVersions,
 			Timeouts:            timeoutParams,
 			Cluster: sesame_api_v1alpha1.ClusterParameters{
-				DNSLookupFamily: dnsLookupFamily,
+				DNSLookupFamily:  dnsLookupFamily,
+				ConnectionPolicy: connectionPolicy,
+				RetryBudget:      retryBudget,
 			},
 			Network: sesame_api_v1alpha1.NetworkParameters{
 				XffNumTrustedHops: ctx.Config.Network.XffNumTrustedHops,
 				EnvoyAdminPort:    ctx.Config.Network.EnvoyAdminPort,
 			},
+			OverloadManager: overloadManager,
+			HTTP3:           http3,
 		},
 		Gateway: gatewayConfig,
 		HTTPProxy: sesame_api_v1alpha1.HTTPProxyConfig{
@@ -475,6 +583,8 @@ func (ctx *serveContext) convertToSesameConfigurationSpec() sesame_api_v1alpha1.
 		RateLimitService:          rateLimitService,
 		Policy:                    policy,
 		Metrics:                   SesameMetrics,
+		Webhook:                   webhook,
+		CertificateRotation:       certificateRotation,
 	}
 
 	xdsServerType := sesame_api_v1alpha1.SesameServerType
@@ -497,6 +607,30 @@ func (ctx *serveContext) convertToSesameConfigurationSpec() sesame_api_v1alpha1.
 	return SesameConfiguration
 }
 
+// gatewayEnvoyListener converts the configuration file parameters of
+// an Envoy listener that serves a Gateway, defaulting its address.
+func gatewayEnvoyListener(l *config.GatewayListenerParameters) *sesame_api_v1alpha1.EnvoyListener {
+	if l == nil {
+		return nil
+	}
+
+	address := l.Address
+	if len(address) == 0 {
+		address = "0.0.0.0"
+	}
+
+	return &sesame_api_v1alpha1.EnvoyListener{
+		Address:   address,
+		Port:      l.Port,
+		AccessLog: l.AccessLog,
+	}
+}
+
+// percent converts a fraction to a whole percentage.
+func percent(fraction float64) uint32 {
+	return uint32(math.Round(fraction * 100))
+}
+
 func setMetricsFromConfig(src config.MetricsServerParameters, dst *sesame_api_v1alpha1.MetricsConfig) {
 	if len(src.Address) > 0 {
 		dst.Address = src.Address
@@ -29,6 +29,7 @@ import (
 	"github.com/tsaarni/certyaml"
 	"k8s.io/utils/pointer"
 
+	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
 	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
 	envoy_v3 "github.com/projectsesame/sesame/internal/envoy/v3"
 	"github.com/projectsesame/sesame/internal/fixture"
@@ -389,6 +390,23 @@ func TestConvertServeContext(t *testing.T) {
 	gatewayContext := newServeContext()
 	gatewayContext.Config.GatewayConfig = &config.GatewayParameters{
 		ControllerName: "projectsesame.io/projectsesame/sesame",
+		GatewayRef: &config.NamespacedName{
+			Name:      "gateway",
+			Namespace: "projectsesame",
+		},
+		Gateways: []config.GatewayEnvoyMapping{{
+			Gateway: config.NamespacedName{
+				Name:      "internal",
+				Namespace: "projectsesame",
+			},
+			HTTPListener: &config.GatewayListenerParameters{
+				Port: 9080,
+			},
+			Service: &config.NamespacedName{
+				Name:      "envoy-internal",
+				Namespace: "projectsesame",
+			},
+		}},
 	}
 
 	ingressContext := newServeContext()
@@ -843,6 +861,24 @@ func TestConvertServeContext(t *testing.T) {
 				},
 				Gateway: &sesame_api_v1alpha1.GatewayConfig{
 					ControllerName: "projectsesame.io/projectsesame/sesame",
+					GatewayRef: &sesame_api_v1alpha1.NamespacedName{
+						Name:      "gateway",
+						Namespace: "projectsesame",
+					},
+					Gateways: []sesame_api_v1alpha1.GatewayEnvoyMapping{{
+						Gateway: sesame_api_v1alpha1.NamespacedName{
+							Name:      "internal",
+							Namespace: "projectsesame",
+						},
+						HTTPListener: &sesame_api_v1alpha1.EnvoyListener{
+							Address: "0.0.0.0",
+							Port:    9080,
+						},
+						Service: &sesame_api_v1alpha1.NamespacedName{
+							Name:      "envoy-internal",
+							Namespace: "projectsesame",
+						},
+					}},
 				},
 				HTTPProxy: sesame_api_v1alpha1.HTTPProxyConfig{
 					DisablePermitInsecure: false,
@@ -1391,3 +1427,161 @@ func TestConvertServeContext(t *testing.T) {
 		})
 	}
 }
+
+func TestConvertServeContextWebhook(t *testing.T) {
+	ctx := newServeContext()
+	assert.Nil(t, ctx.convertToSesameConfigurationSpec().Webhook)
+
+	ctx.Config.Webhook = config.WebhookParameters{
+		ServerCert: "/certs/tls.crt",
+		ServerKey:  "/certs/tls.key",
+	}
+	assert.Equal(t, &sesame_api_v1alpha1.WebhookConfig{
+		Address:  "0.0.0.0",
+		Port:     9443,
+		CertFile: "/certs/tls.crt",
+		KeyFile:  "/certs/tls.key",
+	}, ctx.convertToSesameConfigurationSpec().Webhook)
+
+	ctx.Config.Webhook.Address = "127.0.0.1"
+	ctx.Config.Webhook.Port = 8443
+	assert.Equal(t, &sesame_api_v1alpha1.WebhookConfig{
+		Address:  "127.0.0.1",
+		Port:     8443,
+		CertFile: "/certs/tls.crt",
+		KeyFile:  "/certs/tls.key",
+	}, ctx.convertToSesameConfigurationSpec().Webhook)
+}
+
+func TestConvertServeContextCertificateRotation(t *testing.T) {
+	ctx := newServeContext()
+	assert.Nil(t, ctx.convertToSesameConfigurationSpec().CertificateRotation)
+
+	ctx.Config.CertificateRotation = config.CertificateRotationParameters{
+		Enabled: true,
+	}
+	assert.Equal(t, &sesame_api_v1alpha1.CertificateRotationConfig{
+		Namespace:    "projectsesame",
+		CASecretName: "sesame-ca",
+		SecretNames:  []string{"sesamecert", "envoycert"},
+		RenewBefore:  "720h",
+		Lifetime:     365,
+	}, ctx.convertToSesameConfigurationSpec().CertificateRotation)
+
+	ctx.Config.CertificateRotation = config.CertificateRotationParameters{
+		Enabled:      true,
+		Namespace:    "sesame-system",
+		CASecretName: "ca",
+		SecretNames:  []string{"xds"},
+		RenewBefore:  "24h",
+		Lifetime:     30,
+	}
+	assert.Equal(t, &sesame_api_v1alpha1.CertificateRotationConfig{
+		Namespace:    "sesame-system",
+		CASecretName: "ca",
+		SecretNames:  []string{"xds"},
+		RenewBefore:  "24h",
+		Lifetime:     30,
+	}, ctx.convertToSesameConfigurationSpec().CertificateRotation)
+}
+
+func TestConvertServeContextOverloadManager(t *testing.T) {
+	ctx := newServeContext()
+	spec := ctx.convertToSesameConfigurationSpec()
+	assert.Nil(t, spec.Envoy.OverloadManager)
+	assert.Zero(t, spec.Envoy.HTTPListener.MaxConnections)
+
+	ctx.Config.Bootstrap.OverloadManager = config.OverloadManagerParameters{
+		MaxHeapSizeBytes:               1073741824,
+		MaxActiveDownstreamConnections: 50000,
+		ShrinkHeapThreshold:            0.95,
+		DisableKeepaliveThreshold:      0.9,
+	}
+	ctx.Config.Listener.HTTPMaxConnections = 1000
+	ctx.Config.Listener.HTTPSMaxConnections = 2000
+
+	spec = ctx.convertToSesameConfigurationSpec()
+	assert.Equal(t, &sesame_api_v1alpha1.OverloadManagerParameters{
+		MaxHeapSizeBytes:                 1073741824,
+		MaxActiveDownstreamConnections:   50000,
+		ShrinkHeapThresholdPercent:       95,
+		DisableKeepaliveThresholdPercent: 90,
+	}, spec.Envoy.OverloadManager)
+	assert.Equal(t, uint32(1000), spec.Envoy.HTTPListener.MaxConnections)
+	assert.Equal(t, uint32(2000), spec.Envoy.HTTPSListener.MaxConnections)
+}
+
+func TestConvertServeContextHTTP3(t *testing.T) {
+	ctx := newServeContext()
+	assert.Nil(t, ctx.convertToSesameConfigurationSpec().Envoy.HTTP3)
+
+	ctx.Config.HTTP3 = config.HTTP3Parameters{Enabled: true}
+	assert.Equal(t, &sesame_api_v1alpha1.HTTP3Parameters{
+		Address:        "0.0.0.0",
+		Port:           8443,
+		AdvertisedPort: 443,
+	}, ctx.convertToSesameConfigurationSpec().Envoy.HTTP3)
+
+	ctx.Config.HTTP3 = config.HTTP3Parameters{
+		Enabled:              true,
+		Address:              "::",
+		Port:                 9443,
+		AdvertisedPort:       8443,
+		MaxConcurrentStreams: 100,
+	}
+	assert.Equal(t, &sesame_api_v1alpha1.HTTP3Parameters{
+		Address:              "::",
+		Port:                 9443,
+		AdvertisedPort:       8443,
+		MaxConcurrentStreams: 100,
+	}, ctx.convertToSesameConfigurationSpec().Envoy.HTTP3)
+}
+
+func TestConvertServeContextCertificateExpiryWarning(t *testing.T) {
+	ctx := newServeContext()
+	assert.Empty(t, ctx.convertToSesameConfigurationSpec().Envoy.Listener.TLS.CertificateExpiryWarning)
+
+	ctx.Config.TLS.CertificateExpiryWarning = "168h"
+	assert.Equal(t, "168h", ctx.convertToSesameConfigurationSpec().Envoy.Listener.TLS.CertificateExpiryWarning)
+}
+
+func TestConvertServeContextConnectionPolicy(t *testing.T) {
+	ctx := newServeContext()
+	assert.Nil(t, ctx.convertToSesameConfigurationSpec().Envoy.Cluster.ConnectionPolicy)
+
+	ctx.Config.Cluster.ConnectionPolicy = config.ConnectionPolicyParameters{
+		MaxConnections:           1024,
+		MaxRequestsPerConnection: 100,
+		IdleTimeout:              "1h",
+		TCPKeepalive: &config.TCPKeepaliveParameters{
+			Probes:   3,
+			IdleTime: 30,
+			Interval: 5,
+		},
+	}
+	assert.Equal(t, &sesame_api_v1.ConnectionPolicy{
+		MaxConnections:           1024,
+		MaxRequestsPerConnection: 100,
+		IdleTimeout:              "1h",
+		TCPKeepalive: &sesame_api_v1.TCPKeepalive{
+			Probes:          3,
+			IdleTimeSeconds: 30,
+			IntervalSeconds: 5,
+		},
+	}, ctx.convertToSesameConfigurationSpec().Envoy.Cluster.ConnectionPolicy)
+}
+
+func TestConvertServeContextRetryBudget(t *testing.T) {
+	ctx := newServeContext()
+	assert.Nil(t, ctx.convertToSesameConfigurationSpec().Envoy.Cluster.RetryBudget)
+
+	percent, concurrency := uint32(25), uint32(5)
+	ctx.Config.Cluster.RetryBudget = &config.RetryBudgetParameters{
+		BudgetPercent:       &percent,
+		MinRetryConcurrency: &concurrency,
+	}
+	assert.Equal(t, &sesame_api_v1.RetryBudget{
+		BudgetPercent:       &percent,
+		MinRetryConcurrency: &concurrency,
+	}, ctx.convertToSesameConfigurationSpec().Envoy.Cluster.RetryBudget)
+}
@@ -42,6 +42,12 @@ func main() {
 
 	certgenApp, certgenConfig := registerCertGen(app)
 
+	render, renderCtx := registerRender(app)
+
+	rateLimitServer, rateLimitServerCtx := registerRateLimitServer(app)
+
+	operator, operatorCtx := registerOperator(app)
+
 	cli := app.Command("cli", "A CLI client for the Sesame Kubernetes ingress controller.")
 	var client Client
 	cli.Flag("sesame", "Sesame host:port.").Default("127.0.0.1:8001").StringVar(&client.SesameAddr)
@@ -77,11 +83,35 @@ func main() {
 		if err := envoy.ValidAdminAddress(bootstrapCtx.AdminAddress); err != nil {
 			log.WithField("flag", "--admin-address").WithError(err).Fatal("failed to parse bootstrap args")
 		}
+		if err := bootstrapCtx.Parameters.OverloadManager.Validate(); err != nil {
+			log.WithError(err).Fatal("failed to parse bootstrap args")
+		}
 		if err := envoy_v3.WriteBootstrap(bootstrapCtx); err != nil {
 			log.WithError(err).Fatal("failed to write bootstrap configuration")
 		}
 	case certgenApp.FullCommand():
 		doCertgen(certgenConfig, log)
+	case render.FullCommand():
+		if renderCtx.Debug {
+			log.SetLevel(logrus.DebugLevel)
+		}
+		if err := doRender(renderCtx, log); err != nil {
+			log.WithError(err).Fatal("failed to render configuration")
+		}
+	case rateLimitServer.FullCommand():
+		if rateLimitServerCtx.Debug {
+			log.SetLevel(logrus.DebugLevel)
+		}
+		if err := doRateLimitServer(rateLimitServerCtx, log); err != nil {
+			log.WithError(err).Fatal("failed to run rate limit server")
+		}
+	case operator.FullCommand():
+		if operatorCtx.Debug {
+			log.SetLevel(logrus.DebugLevel)
+		}
+		if err := doOperator(operatorCtx, log); err != nil {
+			log.WithError(err).Fatal("failed to run operator")
+		}
 	case cds.FullCommand():
 		stream := client.ClusterStream()
 		watchstream(stream, resource_v3.ClusterType, resources)
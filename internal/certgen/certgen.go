@@ -39,6 +39,14 @@ const (
 	EnvoyCertificateKey = "envoycert.pem"
 	// EnvoyPrivateKeyKey is the dictionary key for the Envoy private key.
 	EnvoyPrivateKeyKey = "envoykey.pem"
+	// WebhookCertificateKey is the dictionary key for the webhook certificate.
+	WebhookCertificateKey = "webhookcert.pem"
+	// WebhookPrivateKeyKey is the dictionary key for the webhook private key.
+	WebhookPrivateKeyKey = "webhookkey.pem"
+
+	// WebhookSecretName is the name of the Secret holding the
+	// admission webhook keypair.
+	WebhookSecretName = "sesame-webhook-cert"
 )
 
 // OverwritePolicy specifies whether an output should be overwritten.
@@ -103,7 +111,21 @@ func WriteCertsPEM(outputDir string, certdata *certs.Certificates, force Overwri
 		return err
 	}
 
-	return writePEM(outputDir, "envoykey.pem", certdata.EnvoyPrivateKey, force)
+	err = writePEM(outputDir, "envoykey.pem", certdata.EnvoyPrivateKey, force)
+	if err != nil {
+		return err
+	}
+
+	if len(certdata.WebhookCertificate) == 0 {
+		return nil
+	}
+
+	err = writePEM(outputDir, WebhookCertificateKey, certdata.WebhookCertificate, force)
+	if err != nil {
+		return err
+	}
+
+	return writePEM(outputDir, WebhookPrivateKeyKey, certdata.WebhookPrivateKey, force)
 }
 
 // WriteSecretsYAML writes all the keypairs out to Kubernetes Secrets in YAML form
@@ -148,9 +170,9 @@ func WriteSecretsKube(client *kubernetes.Clientset, secrets []*corev1.Secret, fo
 // Secrets in in compact Secret format, which is compatible with
 // both cert-manager and Sesame.
 func AsSecrets(namespace string, certdata *certs.Certificates) []*corev1.Secret {
-	return []*corev1.Secret{
+	secrets := []*corev1.Secret{
 		newSecret(corev1.SecretTypeTLS,
-			"Sesamecert", namespace,
+			"sesamecert", namespace,
 			map[string][]byte{
 				dag.CACertificateKey:    certdata.CACertificate,
 				corev1.TLSCertKey:       certdata.SesameCertificate,
@@ -164,6 +186,29 @@ func AsSecrets(namespace string, certdata *certs.Certificates) []*corev1.Secret
 				corev1.TLSPrivateKeyKey: certdata.EnvoyPrivateKey,
 			}),
 	}
+
+	if len(certdata.WebhookCertificate) > 0 {
+		secrets = append(secrets, newSecret(corev1.SecretTypeTLS,
+			WebhookSecretName, namespace,
+			map[string][]byte{
+				dag.CACertificateKey:    certdata.CACertificate,
+				corev1.TLSCertKey:       certdata.WebhookCertificate,
+				corev1.TLSPrivateKeyKey: certdata.WebhookPrivateKey,
+			}))
+	}
+
+	return secrets
+}
+
+// CASecret returns a Secret holding the CA keypair in the given
+// Certificates, which Sesame can use to rotate the certificates.
+func CASecret(namespace, name string, certdata *certs.Certificates) *corev1.Secret {
+	return newSecret(corev1.SecretTypeTLS,
+		name, namespace,
+		map[string][]byte{
+			corev1.TLSCertKey:       certdata.CACertificate,
+			corev1.TLSPrivateKeyKey: certdata.CAPrivateKey,
+		})
 }
 
 // AsLegacySecrets transforms the given Certificates struct into a slice of
@@ -171,9 +216,9 @@ func AsSecrets(namespace string, certdata *certs.Certificates) []*corev1.Secret
 // The difference is that the CA cert is in a separate secret, rather
 // than duplicated inline in each TLS secrets.
 func AsLegacySecrets(namespace string, certdata *certs.Certificates) []*corev1.Secret {
-	return []*corev1.Secret{
+	secrets := []*corev1.Secret{
 		newSecret(corev1.SecretTypeTLS,
-			"Sesamecert", namespace,
+			"sesamecert", namespace,
 			map[string][]byte{
 				corev1.TLSCertKey:       certdata.SesameCertificate,
 				corev1.TLSPrivateKeyKey: certdata.SesamePrivateKey,
@@ -190,4 +235,15 @@ func AsLegacySecrets(namespace string, certdata *certs.Certificates) []*corev1.S
 				"cacert.pem": certdata.CACertificate,
 			}),
 	}
+
+	if len(certdata.WebhookCertificate) > 0 {
+		secrets = append(secrets, newSecret(corev1.SecretTypeTLS,
+			WebhookSecretName, namespace,
+			map[string][]byte{
+				corev1.TLSCertKey:       certdata.WebhookCertificate,
+				corev1.TLSPrivateKeyKey: certdata.WebhookPrivateKey,
+			}))
+	}
+
+	return secrets
 }
@@ -0,0 +1,378 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package certrotation re-issues the certificates used to secure the
+// xDS connection between Sesame and Envoy before they expire.
+package certrotation
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	"github.com/projectsesame/sesame/internal/dag"
+	"github.com/projectsesame/sesame/internal/metrics"
+	"github.com/projectsesame/sesame/pkg/certs"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ReasonCertificateRotated is the event reason used when a
+	// certificate has been re-issued.
+	ReasonCertificateRotated = "CertificateRotated"
+
+	// ReasonCertificateRotationFailed is the event reason used when
+	// a certificate could not be re-issued.
+	ReasonCertificateRotationFailed = "CertificateRotationFailed"
+
+	// defaultInterval is how often certificates are checked.
+	defaultInterval = time.Hour
+
+	// defaultCARenewBefore is how long before expiry the CA is
+	// re-issued by default.
+	defaultCARenewBefore = 90 * 24 * time.Hour
+)
+
+// Rotator periodically checks the expiry of the certificates in a set
+// of Secrets and re-issues them from the CA keypair when they are due
+// for renewal. Sesame and Envoy both reload their certificates when the
+// mounted Secrets change, so no restart is needed.
+//
+// A self-signed CA is also re-issued before it expires. The previous CA
+// stays in the CA bundle (the ca.crt key of the CA Secret) until it
+// expires, and the bundle is copied to the certificate Secrets before
+// the certificates are re-issued from the new CA on the next check, so
+// that peers trust the new CA by the time it is used.
+type Rotator struct {
+	// Client is used to read and update the Secrets.
+	Client client.Client
+
+	// Namespace of the CA and certificate Secrets.
+	Namespace string
+
+	// CASecretName is the name of the Secret holding the CA
+	// certificate and key.
+	CASecretName string
+
+	// SecretNames are the names of the Secrets holding the
+	// certificates to rotate.
+	SecretNames []string
+
+	// RenewBefore is how long before expiry a certificate is re-issued.
+	RenewBefore time.Duration
+
+	// Lifetime is the lifetime of re-issued certificates, in days.
+	Lifetime uint
+
+	// CARenewBefore is how long before expiry the CA is re-issued.
+	// Defaults to 90 days, and is never shorter than RenewBefore.
+	CARenewBefore time.Duration
+
+	// CALifetime is the lifetime of a re-issued CA, in days.
+	// Defaults to certs.DefaultCALifetime.
+	CALifetime uint
+
+	// Interval is how often certificates are checked. Defaults to
+	// one hour.
+	Interval time.Duration
+
+	// Recorder records events for the rotated Secrets.
+	Recorder record.EventRecorder
+
+	// Metrics records the certificate expiry and rotation metrics.
+	Metrics *metrics.Metrics
+
+	logrus.FieldLogger
+
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable, so that
+// only a single Sesame replica rotates the certificates.
+func (r *Rotator) NeedLeaderElection() bool {
+	return true
+}
+
+// Start checks the certificates immediately, and then at each interval
+// until the context is cancelled.
+func (r *Rotator) Start(ctx context.Context) error {
+	interval := r.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	r.WithField("interval", interval).Info("started certificate rotation")
+	defer r.Info("stopped certificate rotation")
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.Rotate(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Rotate re-issues the CA and each certificate that is due for renewal.
+// Errors are logged and recorded as events, so that a failure to rotate
+// one certificate does not prevent the others from being rotated.
+func (r *Rotator) Rotate(ctx context.Context) {
+	ca := &corev1.Secret{}
+	if err := r.Client.Get(ctx, types.NamespacedName{Namespace: r.Namespace, Name: r.CASecretName}, ca); err != nil {
+		r.WithError(err).WithField("secret", r.CASecretName).Error("failed to get CA secret")
+		return
+	}
+
+	log := r.WithField("namespace", r.Namespace).WithField("name", r.CASecretName)
+	rotated, err := r.rotateCA(ctx, ca)
+	switch {
+	case err != nil:
+		// Keep rotating the certificates from the current CA.
+		log.WithError(err).Error("failed to rotate CA certificate")
+		r.Recorder.Eventf(ca, corev1.EventTypeWarning, ReasonCertificateRotationFailed, "Failed to rotate CA certificate: %v", err)
+	case rotated:
+		log.Info("rotated CA certificate")
+		r.Recorder.Event(ca, corev1.EventTypeNormal, ReasonCertificateRotated, "CA certificate was re-issued before expiry")
+	}
+
+	for _, name := range r.SecretNames {
+		log := r.WithField("namespace", r.Namespace).WithField("name", name)
+
+		secret := &corev1.Secret{}
+		if err := r.Client.Get(ctx, types.NamespacedName{Namespace: r.Namespace, Name: name}, secret); err != nil {
+			log.WithError(err).Error("failed to get certificate secret")
+			continue
+		}
+
+		rotated, err := r.rotate(ctx, ca, secret)
+		if err != nil {
+			log.WithError(err).Error("failed to rotate certificate")
+			r.Recorder.Eventf(secret, corev1.EventTypeWarning, ReasonCertificateRotationFailed, "Failed to rotate certificate: %v", err)
+			continue
+		}
+
+		if rotated {
+			log.Info("rotated certificate")
+			r.Recorder.Event(secret, corev1.EventTypeNormal, ReasonCertificateRotated, "Certificate was re-issued before expiry")
+		}
+	}
+}
+
+// rotateCA re-issues the CA in ca if it expires within the CA renewal
+// window, and returns whether it did so. Expired CAs are dropped from
+// the CA bundle.
+func (r *Rotator) rotateCA(ctx context.Context, ca *corev1.Secret) (bool, error) {
+	caCert := ca.Data[corev1.TLSCertKey]
+	notAfter, err := expiry(caCert)
+	if err != nil {
+		return false, err
+	}
+
+	r.setExpiry(ca.Name, notAfter)
+
+	bundle, err := unexpired(caBundle(ca), r.timeNow())
+	if err != nil {
+		return false, err
+	}
+
+	renewBefore := r.CARenewBefore
+	if renewBefore <= 0 {
+		renewBefore = defaultCARenewBefore
+	}
+	if renewBefore < r.RenewBefore {
+		renewBefore = r.RenewBefore
+	}
+
+	if r.timeNow().Add(renewBefore).Before(notAfter) {
+		if _, ok := ca.Data[dag.CACertificateKey]; !ok || bytes.Equal(bundle, ca.Data[dag.CACertificateKey]) {
+			return false, nil
+		}
+		ca.Data[dag.CACertificateKey] = bundle
+		return false, r.Client.Update(ctx, ca)
+	}
+
+	cert, key, err := certs.RenewCA(caCert, ca.Data[corev1.TLSPrivateKeyKey], r.CALifetime)
+	if err != nil {
+		return false, err
+	}
+
+	ca.Data[corev1.TLSCertKey] = cert
+	ca.Data[corev1.TLSPrivateKeyKey] = key
+	ca.Data[dag.CACertificateKey] = append(append([]byte{}, cert...), bundle...)
+
+	if err := r.Client.Update(ctx, ca); err != nil {
+		return false, err
+	}
+
+	// The new CA was just issued, so it will parse.
+	notAfter, _ = expiry(cert)
+	r.setExpiry(ca.Name, notAfter)
+
+	if r.Metrics != nil {
+		r.Metrics.CertificateRotated(ca.Namespace, ca.Name)
+	}
+
+	return true, nil
+}
+
+// rotate re-issues the certificate in secret if it expires within the
+// renewal window or was issued by a previous CA, and returns whether it
+// did so. If the CA bundle in secret is out of date, only the bundle is
+// updated, and the certificate is re-issued on the next check, once its
+// peers trust the new CA.
+func (r *Rotator) rotate(ctx context.Context, ca, secret *corev1.Secret) (bool, error) {
+	notAfter, err := expiry(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		return false, err
+	}
+
+	r.setExpiry(secret.Name, notAfter)
+
+	bundle := caBundle(ca)
+	if current, ok := secret.Data[dag.CACertificateKey]; ok && !bytes.Equal(current, bundle) {
+		secret.Data[dag.CACertificateKey] = bundle
+		return false, r.Client.Update(ctx, secret)
+	}
+
+	caCert := ca.Data[corev1.TLSCertKey]
+	issued, err := issuedBy(secret.Data[corev1.TLSCertKey], caCert)
+	if err != nil {
+		return false, err
+	}
+
+	if issued && r.timeNow().Add(r.RenewBefore).Before(notAfter) {
+		return false, nil
+	}
+
+	cert, key, err := certs.RenewCertificate(caCert, ca.Data[corev1.TLSPrivateKeyKey], secret.Data[corev1.TLSCertKey], r.Lifetime)
+	if err != nil {
+		return false, err
+	}
+
+	secret.Data[corev1.TLSCertKey] = cert
+	secret.Data[corev1.TLSPrivateKeyKey] = key
+
+	if err := r.Client.Update(ctx, secret); err != nil {
+		return false, err
+	}
+
+	// The new certificate was just issued, so it will parse.
+	notAfter, _ = expiry(cert)
+	r.setExpiry(secret.Name, notAfter)
+
+	if r.Metrics != nil {
+		r.Metrics.CertificateRotated(secret.Namespace, secret.Name)
+	}
+
+	return true, nil
+}
+
+func (r *Rotator) setExpiry(name string, notAfter time.Time) {
+	if r.Metrics != nil {
+		r.Metrics.SetCertificateExpiry(r.Namespace, name, notAfter)
+	}
+}
+
+func (r *Rotator) timeNow() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
+
+// caBundle returns the CA certificates that the certificates issued
+// from ca are verified with. Secrets written before the CA was first
+// rotated have no bundle, so the CA certificate is used.
+func caBundle(ca *corev1.Secret) []byte {
+	if bundle, ok := ca.Data[dag.CACertificateKey]; ok {
+		return bundle
+	}
+	return ca.Data[corev1.TLSCertKey]
+}
+
+// unexpired returns the certificates in bundle that have not expired
+// by now.
+func unexpired(bundle []byte, now time.Time) ([]byte, error) {
+	var valid []byte
+	for rest := bundle; ; {
+		var block *pem.Block
+		if block, rest = pem.Decode(rest); block == nil {
+			break
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CA bundle: %v", err)
+		}
+
+		if now.Before(cert.NotAfter) {
+			valid = append(valid, pem.EncodeToMemory(block)...)
+		}
+	}
+
+	return valid, nil
+}
+
+// issuedBy returns whether the first certificate in certPEM was issued
+// by the first certificate in caCertPEM.
+func issuedBy(certPEM, caCertPEM []byte) (bool, error) {
+	cert, err := parseCertificate(certPEM)
+	if err != nil {
+		return false, err
+	}
+
+	caCert, err := parseCertificate(caCertPEM)
+	if err != nil {
+		return false, err
+	}
+
+	return cert.CheckSignatureFrom(caCert) == nil, nil
+}
+
+// expiry returns the NotAfter time of the first certificate in certPEM.
+func expiry(certPEM []byte) (time.Time, error) {
+	cert, err := parseCertificate(certPEM)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return cert.NotAfter, nil
+}
+
+// parseCertificate parses the first certificate in certPEM.
+func parseCertificate(certPEM []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode certificate PEM")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %v", err)
+	}
+
+	return cert, nil
+}
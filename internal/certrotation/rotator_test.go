@@ -0,0 +1,224 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certrotation
+
+import (
+	"context"
+	"crypto/x509"
+	"testing"
+	"time"
+
+	"github.com/projectsesame/sesame/internal/certgen"
+	"github.com/projectsesame/sesame/internal/fixture"
+	"github.com/projectsesame/sesame/internal/metrics"
+	"github.com/projectsesame/sesame/pkg/certs"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRotate(t *testing.T) {
+	const namespace = "projectsesame"
+
+	generated, err := certs.GenerateCerts(&certs.Configuration{
+		Lifetime:  10,
+		Namespace: namespace,
+	})
+	require.NoError(t, err)
+
+	objects := []client.Object{certgen.CASecret(namespace, "sesame-ca", generated)}
+	for _, s := range certgen.AsSecrets(namespace, generated) {
+		objects = append(objects, s)
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	recorder := record.NewFakeRecorder(10)
+	rotator := &Rotator{
+		Client:       fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		Namespace:    namespace,
+		CASecretName: "sesame-ca",
+		SecretNames:  []string{"envoycert", "missing"},
+		RenewBefore:  5 * 24 * time.Hour,
+		Lifetime:     30,
+		Recorder:     recorder,
+		Metrics:      metrics.NewMetrics(prometheus.NewRegistry()),
+		FieldLogger:  fixture.NewTestLogger(t),
+	}
+
+	getSecret := func(name string) *corev1.Secret {
+		s := &corev1.Secret{}
+		require.NoError(t, rotator.Client.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: name}, s))
+		return s
+	}
+
+	// The certificate is not yet due for renewal.
+	rotator.Rotate(context.Background())
+	assert.Equal(t, generated.EnvoyCertificate, getSecret("envoycert").Data[corev1.TLSCertKey])
+	assert.Len(t, recorder.Events, 0)
+
+	// Within the renewal window, the certificate is re-issued.
+	rotator.now = func() time.Time { return time.Now().Add(6 * 24 * time.Hour) }
+	rotator.Rotate(context.Background())
+
+	secret := getSecret("envoycert")
+	assert.NotEqual(t, generated.EnvoyCertificate, secret.Data[corev1.TLSCertKey])
+	assert.NotEqual(t, generated.EnvoyPrivateKey, secret.Data[corev1.TLSPrivateKeyKey])
+	assert.Equal(t, generated.CACertificate, secret.Data["ca.crt"])
+	assert.Equal(t, "Normal CertificateRotated Certificate was re-issued before expiry", <-recorder.Events)
+
+	notAfter, err := expiry(secret.Data[corev1.TLSCertKey])
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(30*24*time.Hour), notAfter, time.Minute)
+
+	// Sesame's certificate was not in the list and is left alone.
+	assert.Equal(t, generated.SesameCertificate, getSecret("sesamecert").Data[corev1.TLSCertKey])
+
+	// The renewed certificate is not rotated again.
+	rotator.Rotate(context.Background())
+	assert.Len(t, recorder.Events, 0)
+}
+
+func TestRotateCA(t *testing.T) {
+	const namespace = "projectsesame"
+
+	// The CA expires before the certificates would.
+	generated, err := certs.GenerateCerts(&certs.Configuration{
+		Lifetime:   30,
+		CALifetime: 10,
+		Namespace:  namespace,
+	})
+	require.NoError(t, err)
+
+	objects := []client.Object{certgen.CASecret(namespace, "sesame-ca", generated)}
+	for _, s := range certgen.AsSecrets(namespace, generated) {
+		objects = append(objects, s)
+	}
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	recorder := record.NewFakeRecorder(10)
+	rotator := &Rotator{
+		Client:        fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+		Namespace:     namespace,
+		CASecretName:  "sesame-ca",
+		SecretNames:   []string{"sesamecert", "envoycert"},
+		RenewBefore:   2 * 24 * time.Hour,
+		Lifetime:      30,
+		CARenewBefore: 5 * 24 * time.Hour,
+		Recorder:      recorder,
+		Metrics:       metrics.NewMetrics(prometheus.NewRegistry()),
+		FieldLogger:   fixture.NewTestLogger(t),
+	}
+
+	getSecret := func(name string) *corev1.Secret {
+		s := &corev1.Secret{}
+		require.NoError(t, rotator.Client.Get(context.Background(), types.NamespacedName{Namespace: namespace, Name: name}, s))
+		return s
+	}
+
+	// The certificates were clamped to the expiry of the CA.
+	caNotAfter, err := expiry(generated.CACertificate)
+	require.NoError(t, err)
+	notAfter, err := expiry(generated.EnvoyCertificate)
+	require.NoError(t, err)
+	assert.True(t, notAfter.Equal(caNotAfter))
+
+	// Nothing is due for renewal yet.
+	rotator.Rotate(context.Background())
+	assert.Equal(t, generated.CACertificate, getSecret("sesame-ca").Data[corev1.TLSCertKey])
+	assert.Len(t, recorder.Events, 0)
+
+	// Within the CA renewal window, the CA is re-issued and both CAs
+	// are trusted, but the certificates are not re-issued yet.
+	rotator.now = func() time.Time { return time.Now().Add(6 * 24 * time.Hour) }
+	rotator.Rotate(context.Background())
+	assert.Equal(t, "Normal CertificateRotated CA certificate was re-issued before expiry", <-recorder.Events)
+	assert.Len(t, recorder.Events, 0)
+
+	ca := getSecret("sesame-ca")
+	assert.NotEqual(t, generated.CACertificate, ca.Data[corev1.TLSCertKey])
+	assert.NotEqual(t, generated.CAPrivateKey, ca.Data[corev1.TLSPrivateKeyKey])
+	bundle := append(append([]byte{}, ca.Data[corev1.TLSCertKey]...), generated.CACertificate...)
+	assert.Equal(t, bundle, ca.Data["ca.crt"])
+
+	for _, name := range rotator.SecretNames {
+		secret := getSecret(name)
+		assert.Equal(t, bundle, secret.Data["ca.crt"])
+		assert.Equal(t, generated.CACertificate, secret.Data["ca.crt"][len(ca.Data[corev1.TLSCertKey]):])
+	}
+	assert.Equal(t, generated.EnvoyCertificate, getSecret("envoycert").Data[corev1.TLSCertKey])
+
+	// On the next check, the certificates are re-issued from the new CA
+	// and remain valid after the previous CA expires.
+	rotator.Rotate(context.Background())
+	assert.Equal(t, "Normal CertificateRotated Certificate was re-issued before expiry", <-recorder.Events)
+	assert.Equal(t, "Normal CertificateRotated Certificate was re-issued before expiry", <-recorder.Events)
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(bundle))
+	for _, name := range rotator.SecretNames {
+		secret := getSecret(name)
+		cert, err := parseCertificate(secret.Data[corev1.TLSCertKey])
+		require.NoError(t, err)
+		_, err = cert.Verify(x509.VerifyOptions{Roots: roots, CurrentTime: time.Now().Add(20 * 24 * time.Hour)})
+		assert.NoError(t, err)
+	}
+
+	// Once the previous CA expires, it is dropped from the bundles.
+	rotator.now = func() time.Time { return time.Now().Add(11 * 24 * time.Hour) }
+	rotator.Rotate(context.Background())
+	assert.Len(t, recorder.Events, 0)
+
+	ca = getSecret("sesame-ca")
+	assert.Equal(t, ca.Data[corev1.TLSCertKey], ca.Data["ca.crt"])
+	for _, name := range rotator.SecretNames {
+		assert.Equal(t, ca.Data[corev1.TLSCertKey], getSecret(name).Data["ca.crt"])
+	}
+}
+
+func TestRotateInvalidCertificate(t *testing.T) {
+	const namespace = "projectsesame"
+
+	generated, err := certs.GenerateCerts(&certs.Configuration{Namespace: namespace})
+	require.NoError(t, err)
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, corev1.AddToScheme(scheme))
+
+	invalid := certgen.AsSecrets(namespace, generated)[0]
+	invalid.Data[corev1.TLSCertKey] = []byte("invalid")
+
+	recorder := record.NewFakeRecorder(10)
+	rotator := &Rotator{
+		Client:       fake.NewClientBuilder().WithScheme(scheme).WithObjects(certgen.CASecret(namespace, "sesame-ca", generated), invalid).Build(),
+		Namespace:    namespace,
+		CASecretName: "sesame-ca",
+		SecretNames:  []string{invalid.Name},
+		RenewBefore:  time.Hour,
+		Recorder:     recorder,
+		FieldLogger:  fixture.NewTestLogger(t),
+	}
+
+	rotator.Rotate(context.Background())
+	assert.Equal(t, "Warning CertificateRotationFailed Failed to rotate certificate: failed to decode certificate PEM", <-recorder.Events)
+}
@@ -0,0 +1,190 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package certwatch serves TLS keypairs and CA bundles from files, and
+// reloads them when the files change so that rotated certificates are
+// used without a restart.
+package certwatch
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Watcher holds the TLS configuration loaded from a keypair and an
+// optional CA bundle. Before each TLS handshake, the files are checked
+// for changes, and if any have changed, they are all reloaded together.
+// The new configuration replaces the old one atomically, and only once
+// every file has loaded successfully, so a partially written update
+// never interrupts serving.
+type Watcher struct {
+	log logrus.FieldLogger
+
+	certFile string
+	keyFile  string
+	caFile   string
+
+	// mu serializes reloads.
+	mu sync.Mutex
+
+	// current holds the *material in use.
+	current atomic.Value
+}
+
+// material is a loaded TLS configuration and the state of the files
+// it was loaded from.
+type material struct {
+	config *tls.Config
+	files  []fileState
+}
+
+// fileState identifies a version of a file. Kubernetes updates mounted
+// Secrets by swapping a symlink, which changes both, as does rewriting
+// the file in place.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// New returns a Watcher for the keypair in certFile and keyFile. If
+// caFile is not empty, clients must present a certificate signed by a
+// CA in that bundle. The files are loaded immediately so that errors
+// are reported at startup.
+func New(log logrus.FieldLogger, certFile, keyFile, caFile string) (*Watcher, error) {
+	w := &Watcher{
+		log:      log,
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+	}
+
+	files, err := w.stat()
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := w.load(files)
+	if err != nil {
+		return nil, err
+	}
+
+	w.current.Store(m)
+	return w, nil
+}
+
+// GetConfigForClient implements tls.Config.GetConfigForClient. It
+// returns the configuration loaded from the current files, reloading
+// them first if they have changed. If reloading fails, the previous
+// configuration continues to be used and the reload is retried on the
+// next handshake.
+func (w *Watcher) GetConfigForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	m := w.current.Load().(*material)
+
+	files, err := w.stat()
+	if err != nil {
+		// A file may be briefly missing while it is replaced.
+		w.log.WithError(err).Warn("failed to check TLS files for changes")
+		return m.config, nil
+	}
+	if equal(files, m.files) {
+		return m.config, nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Another handshake may have reloaded the files while we waited.
+	if m = w.current.Load().(*material); equal(files, m.files) {
+		return m.config, nil
+	}
+
+	reloaded, err := w.load(files)
+	if err != nil {
+		w.log.WithError(err).Warn("failed to reload TLS files, using previous certificates")
+		return m.config, nil
+	}
+
+	w.current.Store(reloaded)
+	w.log.WithField("cert-file", w.certFile).Info("reloaded TLS certificates")
+	return reloaded.config, nil
+}
+
+// stat returns the state of each file.
+func (w *Watcher) stat() ([]fileState, error) {
+	var files []fileState
+	for _, path := range []string{w.certFile, w.keyFile, w.caFile} {
+		if path == "" {
+			continue
+		}
+
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, fileState{modTime: fi.ModTime(), size: fi.Size()})
+	}
+	return files, nil
+}
+
+// load loads the TLS configuration from the files, recording the file
+// states that were observed before loading. If a file changes while it
+// is loaded, the next handshake will reload it again.
+func (w *Watcher) load(files []fileState) (*material, error) {
+	cert, err := tls.LoadX509KeyPair(w.certFile, w.keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.NoClientCert,
+		MinVersion:   tls.VersionTLS13,
+	}
+
+	if w.caFile != "" {
+		ca, err := ioutil.ReadFile(w.caFile)
+		if err != nil {
+			return nil, err
+		}
+
+		certPool := x509.NewCertPool()
+		if ok := certPool.AppendCertsFromPEM(ca); !ok {
+			return nil, fmt.Errorf("unable to append certificate in %s to CA pool", w.caFile)
+		}
+
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+		config.ClientCAs = certPool
+	}
+
+	return &material{config: config, files: files}, nil
+}
+
+func equal(a, b []fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].modTime.Equal(b[i].modTime) || a[i].size != b[i].size {
+			return false
+		}
+	}
+	return true
+}
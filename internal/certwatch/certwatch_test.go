@@ -0,0 +1,115 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package certwatch
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/projectsesame/sesame/internal/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tsaarni/certyaml"
+)
+
+func TestWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certwatch-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ca := certyaml.Certificate{Subject: "cn=ca"}
+	before := certyaml.Certificate{Subject: "cn=before", Issuer: &ca}
+	after := certyaml.Certificate{Subject: "cn=after", Issuer: &ca}
+	other := certyaml.Certificate{Subject: "cn=other", Issuer: &ca}
+
+	caFile := filepath.Join(dir, "ca.crt")
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+
+	require.NoError(t, ca.WritePEM(caFile, filepath.Join(dir, "ca.key")))
+	require.NoError(t, before.WritePEM(certFile, keyFile))
+
+	w, err := New(fixture.NewTestLogger(t), certFile, keyFile, caFile)
+	require.NoError(t, err)
+
+	servedCert := func() tls.Certificate {
+		config, err := w.GetConfigForClient(nil)
+		require.NoError(t, err)
+		require.Len(t, config.Certificates, 1)
+		assert.Equal(t, tls.RequireAndVerifyClientCert, config.ClientAuth)
+		assert.NotNil(t, config.ClientCAs)
+		assert.Equal(t, uint16(tls.VersionTLS13), config.MinVersion)
+		return config.Certificates[0]
+	}
+
+	wantCert := func(c *certyaml.Certificate) tls.Certificate {
+		cert, err := c.TLSCertificate()
+		require.NoError(t, err)
+		return cert
+	}
+
+	assert.Equal(t, wantCert(&before).Certificate, servedCert().Certificate)
+
+	// Unchanged files are not reloaded.
+	first, err := w.GetConfigForClient(nil)
+	require.NoError(t, err)
+	second, err := w.GetConfigForClient(nil)
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+
+	// Rewritten files are reloaded.
+	require.NoError(t, after.WritePEM(certFile, keyFile))
+	assert.Equal(t, wantCert(&after).Certificate, servedCert().Certificate)
+
+	// A certificate that does not match the key is not loaded, and
+	// the previous keypair is still served.
+	otherDir := filepath.Join(dir, "other")
+	require.NoError(t, os.Mkdir(otherDir, 0o755))
+	require.NoError(t, other.WritePEM(filepath.Join(otherDir, "tls.crt"), filepath.Join(otherDir, "tls.key")))
+	otherCert, err := ioutil.ReadFile(filepath.Join(otherDir, "tls.crt"))
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(certFile, otherCert, 0o600))
+	assert.Equal(t, wantCert(&after).Certificate, servedCert().Certificate)
+
+	// Once the key is updated too, the new keypair is served.
+	require.NoError(t, os.Rename(filepath.Join(otherDir, "tls.key"), keyFile))
+	assert.Equal(t, wantCert(&other).Certificate, servedCert().Certificate)
+
+	// Missing files keep the previous keypair.
+	require.NoError(t, os.Remove(keyFile))
+	assert.Equal(t, wantCert(&other).Certificate, servedCert().Certificate)
+}
+
+func TestWatcherWithoutCA(t *testing.T) {
+	dir, err := ioutil.TempDir("", "certwatch-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cert := certyaml.Certificate{Subject: "cn=server"}
+	require.NoError(t, cert.WritePEM(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")))
+
+	w, err := New(fixture.NewTestLogger(t), filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"), "")
+	require.NoError(t, err)
+
+	config, err := w.GetConfigForClient(nil)
+	require.NoError(t, err)
+	assert.Equal(t, tls.NoClientCert, config.ClientAuth)
+	assert.Nil(t, config.ClientCAs)
+
+	_, err = New(fixture.NewTestLogger(t), filepath.Join(dir, "missing.crt"), filepath.Join(dir, "tls.key"), "")
+	assert.Error(t, err)
+}
@@ -30,7 +30,7 @@ import (
 func TestRegisterControllers(t *testing.T) {
 	tests := map[string]func(*mocks.Manager) error{
 		"gateway controller": func(mockManager *mocks.Manager) error {
-			_, err := controller.RegisterGatewayController(fixture.NewTestLogger(t), mockManager, nil, nil, "some-controller")
+			_, err := controller.RegisterGatewayController(fixture.NewTestLogger(t), mockManager, nil, "some-controller", nil)
 			return err
 		},
 		"gatewayclass controller": func(mockManager *mocks.Manager) error {
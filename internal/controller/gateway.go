@@ -16,11 +16,11 @@ package controller
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/projectsesame/sesame/internal/k8s"
 	"github.com/projectsesame/sesame/internal/leadership"
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/cache"
@@ -36,30 +36,33 @@ import (
 )
 
 type gatewayReconciler struct {
-	client        client.Client
-	eventHandler  cache.ResourceEventHandler
-	statusUpdater k8s.StatusUpdater
-	log           logrus.FieldLogger
+	client       client.Client
+	eventHandler cache.ResourceEventHandler
+	log          logrus.FieldLogger
 	// gatewayClassControllerName is the configured controller of managed gatewayclasses.
 	gatewayClassControllerName gatewayapi_v1alpha2.GatewayController
-	eventSource                chan event.GenericEvent
+	// gatewayRef is the specific Gateway to reconcile, if set.
+	gatewayRef  *types.NamespacedName
+	eventSource chan event.GenericEvent
 }
 
 // RegisterGatewayController creates the gateway controller from mgr. The controller will be pre-configured
-// to watch for Gateway objects across all namespaces and reconcile those that match class.
+// to watch for Gateway objects across all namespaces and reconcile those that match class. Every Gateway
+// of the accepted class is reconciled. If gatewayRef is not nil, only that Gateway is reconciled,
+// regardless of its class.
 func RegisterGatewayController(
 	log logrus.FieldLogger,
 	mgr manager.Manager,
 	eventHandler cache.ResourceEventHandler,
-	statusUpdater k8s.StatusUpdater,
 	gatewayClassControllerName string,
+	gatewayRef *types.NamespacedName,
 ) (leadership.NeedLeaderElectionNotification, error) {
 	r := &gatewayReconciler{
 		log:                        log,
 		client:                     mgr.GetClient(),
 		eventHandler:               eventHandler,
-		statusUpdater:              statusUpdater,
 		gatewayClassControllerName: gatewayapi_v1alpha2.GatewayController(gatewayClassControllerName),
+		gatewayRef:                 gatewayRef,
 		// Set up a source.Channel that will trigger reconciles
 		// for all GatewayClasses when this Sesame process is
 		// elected leader, to ensure that their statuses are up
@@ -130,6 +133,10 @@ func (r *gatewayReconciler) mapGatewayClassToGateways(gatewayClass client.Object
 
 	var reconciles []reconcile.Request
 	for _, gw := range gateways.Items {
+		if r.gatewayRef != nil && k8s.NamespacedNameOf(&gw) != *r.gatewayRef {
+			continue
+		}
+
 		if string(gw.Spec.GatewayClassName) == gatewayClass.GetName() {
 			reconciles = append(reconciles, reconcile.Request{
 				NamespacedName: types.NamespacedName{
@@ -158,6 +165,14 @@ func (r *gatewayReconciler) hasMatchingController(obj client.Object) bool {
 		return false
 	}
 
+	if r.gatewayRef != nil {
+		if k8s.NamespacedNameOf(gw) != *r.gatewayRef {
+			log.Debugf("gateway is not %s; bypassing reconciliation", r.gatewayRef)
+			return false
+		}
+		return true
+	}
+
 	gc := &gatewayapi_v1alpha2.GatewayClass{}
 	if err := r.client.Get(context.Background(), types.NamespacedName{Name: string(gw.Spec.GatewayClassName)}, gc); err != nil {
 		log.WithError(err).Errorf("failed to get gatewayclass %s", gw.Spec.GatewayClassName)
@@ -181,14 +196,34 @@ func (r *gatewayReconciler) gatewayClassHasMatchingController(obj client.Object)
 	return gc.Spec.ControllerName == r.gatewayClassControllerName
 }
 
-// Reconcile finds all the Gateways for the GatewayClass with an "Accepted: true" condition.
-// It passes the oldest such Gateway to the DAG for processing, and sets a "Scheduled: false"
-// condition on all other Gateways for the accepted GatewayClass.
+// Reconcile passes the Gateway to the DAG for processing if its
+// GatewayClass is the GatewayClass for this controller with an
+// "Accepted: true" condition. Otherwise, the Gateway is removed
+// from the DAG.
 func (r *gatewayReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
-	r.log.WithField("namespace", request.Namespace).WithField("name", request.Name).Info("reconciling gateway")
+	log := r.log.WithField("namespace", request.Namespace).WithField("name", request.Name)
+	log.Info("reconciling gateway")
+
+	if r.gatewayRef != nil {
+		return r.reconcileGatewayRef(ctx)
+	}
+
+	gateway := &gatewayapi_v1alpha2.Gateway{}
+	if err := r.client.Get(ctx, request.NamespacedName, gateway); err != nil {
+		if errors.IsNotFound(err) {
+			log.Info("gateway not found")
+			r.eventHandler.OnDelete(&gatewayapi_v1alpha2.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: request.Namespace,
+					Name:      request.Name,
+				}})
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("error getting gateway %s: %w", request.NamespacedName, err)
+	}
 
 	var gatewayClasses gatewayapi_v1alpha2.GatewayClassList
-	if err := r.client.List(context.Background(), &gatewayClasses); err != nil {
+	if err := r.client.List(ctx, &gatewayClasses); err != nil {
 		return reconcile.Result{}, fmt.Errorf("error listing gateway classes")
 	}
 
@@ -209,90 +244,57 @@ func (r *gatewayReconciler) Reconcile(ctx context.Context, request reconcile.Req
 	}
 
 	if acceptedGatewayClass == nil {
-		r.log.Info("No accepted gateway class found")
-		r.eventHandler.OnDelete(&gatewayapi_v1alpha2.Gateway{
-			ObjectMeta: metav1.ObjectMeta{
-				Namespace: request.Namespace,
-				Name:      request.Name,
-			}})
+		log.Info("No accepted gateway class found")
+		r.eventHandler.OnDelete(gateway)
 		return reconcile.Result{}, nil
 	}
 
-	var allGateways gatewayapi_v1alpha2.GatewayList
-	if err := r.client.List(context.Background(), &allGateways); err != nil {
-		return reconcile.Result{}, fmt.Errorf("error listing gateways")
+	if string(gateway.Spec.GatewayClassName) != acceptedGatewayClass.Name {
+		log.Infof("gateway's class is not the accepted gateway class %s", acceptedGatewayClass.Name)
+		r.eventHandler.OnDelete(gateway)
+		return reconcile.Result{}, nil
 	}
 
-	// Get all the Gateways for the Accepted=true GatewayClass.
-	var gatewaysForClass []*gatewayapi_v1alpha2.Gateway
-	for i := range allGateways.Items {
-		if string(allGateways.Items[i].Spec.GatewayClassName) == acceptedGatewayClass.Name {
-			gatewaysForClass = append(gatewaysForClass, &allGateways.Items[i])
-		}
-	}
+	// TODO: Ensure the gateway by creating manage infrastructure, i.e. the Envoy service.
+	// xref: https://github.com/projectsesame/sesame/issues/3545
 
-	if len(gatewaysForClass) == 0 {
-		r.log.Info("No gateways found for accepted gateway class")
-		r.eventHandler.OnDelete(&gatewayapi_v1alpha2.Gateway{
-			ObjectMeta: metav1.ObjectMeta{
-				Namespace: request.Namespace,
-				Name:      request.Name,
-			}})
-		return reconcile.Result{}, nil
-	}
+	log.Info("assigning gateway to DAG")
+	r.eventHandler.OnAdd(gateway)
+	return reconcile.Result{}, nil
+}
 
-	// Find the oldest Gateway, using alphabetical order
-	// as a tiebreaker.
-	var oldest *gatewayapi_v1alpha2.Gateway
-	for _, gw := range gatewaysForClass {
-		switch {
-		case oldest == nil:
-			oldest = gw
-		case gw.CreationTimestamp.Before(&oldest.CreationTimestamp):
-			oldest = gw
-		case gw.CreationTimestamp.Equal(&oldest.CreationTimestamp):
-			if fmt.Sprintf("%s/%s", gw.Namespace, gw.Name) < fmt.Sprintf("%s/%s", oldest.Namespace, oldest.Name) {
-				oldest = gw
-			}
+// reconcileGatewayRef passes the specific Gateway this Sesame corresponds
+// to, along with its GatewayClass, to the DAG for processing. GatewayClass
+// acceptance is handled by whatever provisioned this Sesame, so the
+// Gateway is processed whether or not its class is accepted.
+func (r *gatewayReconciler) reconcileGatewayRef(ctx context.Context) (reconcile.Result, error) {
+	gateway := &gatewayapi_v1alpha2.Gateway{}
+	if err := r.client.Get(ctx, *r.gatewayRef, gateway); err != nil {
+		if errors.IsNotFound(err) {
+			r.log.WithField("namespace", r.gatewayRef.Namespace).WithField("name", r.gatewayRef.Name).Info("gateway not found")
+			r.eventHandler.OnDelete(&gatewayapi_v1alpha2.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: r.gatewayRef.Namespace,
+					Name:      r.gatewayRef.Name,
+				}})
+			return reconcile.Result{}, nil
 		}
+		return reconcile.Result{}, fmt.Errorf("error getting gateway %s: %w", r.gatewayRef, err)
 	}
 
-	// Set the "Scheduled" condition to false for all gateways
-	// except the oldest. The oldest will have its status set
-	// by the DAG processor, so don't set it here.
-	for _, gw := range gatewaysForClass {
-		if gw == oldest {
-			continue
-		}
-
-		if r.statusUpdater != nil {
-			r.statusUpdater.Send(k8s.StatusUpdate{
-				NamespacedName: k8s.NamespacedNameOf(gw),
-				Resource:       &gatewayapi_v1alpha2.Gateway{},
-				Mutator: k8s.StatusMutatorFunc(func(obj client.Object) client.Object {
-					gw, ok := obj.(*gatewayapi_v1alpha2.Gateway)
-					if !ok {
-						panic(fmt.Sprintf("unsupported object type %T", obj))
-					}
-
-					return setGatewayNotScheduled(gw.DeepCopy())
-				}),
-			})
-		} else {
-			// this branch makes testing easier by not going through the StatusUpdater.
-			copy := setGatewayNotScheduled(gw.DeepCopy())
-			if err := r.client.Status().Update(context.Background(), copy); err != nil {
-				r.log.WithError(err).Error("error updating gateway status")
-				return reconcile.Result{}, fmt.Errorf("error updating status of gateway %s/%s: %v", gw.Namespace, gw.Name, err)
-			}
+	gatewayClass := &gatewayapi_v1alpha2.GatewayClass{}
+	if err := r.client.Get(ctx, types.NamespacedName{Name: string(gateway.Spec.GatewayClassName)}, gatewayClass); err != nil {
+		if errors.IsNotFound(err) {
+			r.log.WithField("name", gateway.Spec.GatewayClassName).Info("gateway class not found")
+			r.eventHandler.OnDelete(gateway)
+			return reconcile.Result{}, nil
 		}
+		return reconcile.Result{}, fmt.Errorf("error getting gateway class %s: %w", gateway.Spec.GatewayClassName, err)
 	}
 
-	// TODO: Ensure the gateway by creating manage infrastructure, i.e. the Envoy service.
-	// xref: https://github.com/projectsesame/sesame/issues/3545
-
-	r.log.WithField("namespace", oldest.Namespace).WithField("name", oldest.Name).Info("assigning gateway to DAG")
-	r.eventHandler.OnAdd(oldest)
+	r.log.WithField("namespace", gateway.Namespace).WithField("name", gateway.Name).Info("assigning gateway to DAG")
+	r.eventHandler.OnAdd(gatewayClass)
+	r.eventHandler.OnAdd(gateway)
 	return reconcile.Result{}, nil
 }
 
@@ -305,36 +307,3 @@ func isAccepted(gatewayClass *gatewayapi_v1alpha2.GatewayClass) bool {
 
 	return false
 }
-
-func setGatewayNotScheduled(gateway *gatewayapi_v1alpha2.Gateway) *gatewayapi_v1alpha2.Gateway {
-	newCond := metav1.Condition{
-		Type:               "Scheduled",
-		Status:             metav1.ConditionFalse,
-		Reason:             "OlderGatewayExists",
-		Message:            "An older Gateway exists for the accepted GatewayClass",
-		LastTransitionTime: metav1.NewTime(time.Now()),
-		ObservedGeneration: gateway.Generation,
-	}
-
-	for i := range gateway.Status.Conditions {
-		cond := &gateway.Status.Conditions[i]
-
-		if cond.Type != "Scheduled" {
-			continue
-		}
-
-		// Update only if something has changed.
-		if cond.Status != newCond.Status || cond.Reason != newCond.Reason || cond.Message != newCond.Message {
-			cond.Status = newCond.Status
-			cond.Reason = newCond.Reason
-			cond.Message = newCond.Message
-			cond.LastTransitionTime = newCond.LastTransitionTime
-			cond.ObservedGeneration = newCond.ObservedGeneration
-		}
-
-		return gateway
-	}
-
-	gateway.Status.Conditions = append(gateway.Status.Conditions, newCond)
-	return gateway
-}
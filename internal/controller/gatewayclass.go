@@ -17,6 +17,7 @@ import (
 	"context"
 	"fmt"
 
+	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
 	"github.com/projectsesame/sesame/internal/k8s"
 	"github.com/projectsesame/sesame/internal/leadership"
 	"github.com/projectsesame/sesame/internal/status"
@@ -84,6 +85,15 @@ func RegisterGatewayClassController(
 		return nil, err
 	}
 
+	// Reconcile the GatewayClasses that refer to a
+	// GatewayClassParameters when it is changed.
+	if err := c.Watch(
+		&source.Kind{Type: &sesame_api_v1alpha1.GatewayClassParameters{}},
+		handler.EnqueueRequestsFromMapFunc(r.mapParametersToGatewayClasses),
+	); err != nil {
+		return nil, err
+	}
+
 	if err := c.Watch(
 		&source.Channel{Source: r.eventSource},
 		&handler.EnqueueRequestForObject{},
@@ -132,6 +142,23 @@ func (r *gatewayClassReconciler) hasMatchingController(obj client.Object) bool {
 	return false
 }
 
+// mapParametersToGatewayClasses returns requests for the GatewayClasses
+// with a matching controller that refer to a GatewayClassParameters.
+func (r *gatewayClassReconciler) mapParametersToGatewayClasses(params client.Object) []reconcile.Request {
+	gatewayClasses, err := gatewayClassesWithParameters(context.Background(), r.client, r.controller, params)
+	if err != nil {
+		r.log.WithError(err).Error("error mapping parameters to gatewayclasses")
+		return nil
+	}
+
+	var reconciles []reconcile.Request
+	for _, gc := range gatewayClasses {
+		reconciles = append(reconciles, reconcile.Request{NamespacedName: types.NamespacedName{Name: gc.Name}})
+	}
+
+	return reconciles
+}
+
 func (r *gatewayClassReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
 	r.log.WithField("name", request.Name).Info("reconciling gatewayclass")
 
@@ -191,9 +218,32 @@ func (r *gatewayClassReconciler) Reconcile(ctx context.Context, request reconcil
 		}
 	}
 
+	// The oldest class is accepted unless the
+	// parameters it refers to are invalid.
+	acceptedClass := controlledClasses.acceptedClass()
+	setStatus := func(gc *gatewayapi_v1alpha2.GatewayClass) *gatewayapi_v1alpha2.GatewayClass {
+		return status.SetGatewayClassAccepted(context.Background(), r.client, gc, true)
+	}
+
+	params, err := gatewayClassParameters(ctx, r.client, acceptedClass)
+	if err == nil {
+		err = validateListenerPorts(params)
+	}
+	switch err.(type) {
+	case nil:
+	case *invalidParametersError:
+		r.log.WithError(err).WithField("name", acceptedClass.Name).Info("gatewayclass has invalid parameters")
+		msg := err.Error()
+		setStatus = func(gc *gatewayapi_v1alpha2.GatewayClass) *gatewayapi_v1alpha2.GatewayClass {
+			return status.SetGatewayClassInvalidParameters(context.Background(), r.client, gc, msg)
+		}
+	default:
+		return reconcile.Result{}, err
+	}
+
 	if r.statusUpdater != nil {
 		r.statusUpdater.Send(k8s.StatusUpdate{
-			NamespacedName: types.NamespacedName{Name: controlledClasses.acceptedClass().Name},
+			NamespacedName: types.NamespacedName{Name: acceptedClass.Name},
 			Resource:       &gatewayapi_v1alpha2.GatewayClass{},
 			Mutator: k8s.StatusMutatorFunc(func(obj client.Object) client.Object {
 				gc, ok := obj.(*gatewayapi_v1alpha2.GatewayClass)
@@ -201,18 +251,25 @@ func (r *gatewayClassReconciler) Reconcile(ctx context.Context, request reconcil
 					panic(fmt.Sprintf("unsupported object type %T", obj))
 				}
 
-				return status.SetGatewayClassAccepted(context.Background(), r.client, gc.DeepCopy(), true)
+				return setStatus(gc.DeepCopy())
 			}),
 		})
 	} else {
 		// this branch makes testing easier by not going through the StatusUpdater.
-		copy := status.SetGatewayClassAccepted(context.Background(), r.client, controlledClasses.acceptedClass().DeepCopy(), true)
+		copy := setStatus(acceptedClass.DeepCopy())
 		if err := r.client.Status().Update(context.Background(), copy); err != nil {
 			return reconcile.Result{}, fmt.Errorf("error updating status of gateway class %s: %v", copy.Name, err)
 		}
 	}
 
-	r.eventHandler.OnAdd(controlledClasses.acceptedClass())
+	if err != nil {
+		// Stop processing the Gateways of the class
+		// until its parameters are fixed.
+		r.eventHandler.OnDelete(acceptedClass)
+		return reconcile.Result{}, nil
+	}
+
+	r.eventHandler.OnAdd(acceptedClass)
 
 	return reconcile.Result{}, nil
 }
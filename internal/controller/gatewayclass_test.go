@@ -0,0 +1,242 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
+	"github.com/projectsesame/sesame/internal/fixture"
+	"github.com/projectsesame/sesame/internal/gatewayapi"
+	"github.com/projectsesame/sesame/internal/k8s"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayapi_v1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func TestGatewayClassReconcileParametersRef(t *testing.T) {
+	params := &sesame_api_v1alpha1.GatewayClassParameters{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "projectsesame",
+			Name:      "params",
+		},
+		Spec: sesame_api_v1alpha1.GatewayClassParametersSpec{
+			EnvoyConfig: &sesame_api_v1alpha1.GatewayEnvoyConfig{
+				HTTPListener: &sesame_api_v1alpha1.GatewayEnvoyListener{
+					AccessLog: "/dev/null",
+				},
+			},
+		},
+	}
+
+	portParams := &sesame_api_v1alpha1.GatewayClassParameters{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "projectsesame",
+			Name:      "port",
+		},
+		Spec: sesame_api_v1alpha1.GatewayClassParametersSpec{
+			EnvoyConfig: &sesame_api_v1alpha1.GatewayEnvoyConfig{
+				HTTPSListener: &sesame_api_v1alpha1.GatewayEnvoyListener{
+					Port: 9443,
+				},
+			},
+		},
+	}
+
+	invalidTimeout := "invalid"
+	invalidParams := &sesame_api_v1alpha1.GatewayClassParameters{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "projectsesame",
+			Name:      "invalid",
+		},
+		Spec: sesame_api_v1alpha1.GatewayClassParametersSpec{
+			EnvoyConfig: &sesame_api_v1alpha1.GatewayEnvoyConfig{
+				Timeouts: &sesame_api_v1alpha1.TimeoutParameters{
+					RequestTimeout: &invalidTimeout,
+				},
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		parametersRef *gatewayapi_v1alpha2.ParametersReference
+		wantReason    string
+		wantMessage   string
+	}{
+		"no parametersRef": {
+			wantReason: "Valid",
+		},
+		"parametersRef to GatewayClassParameters": {
+			parametersRef: &gatewayapi_v1alpha2.ParametersReference{
+				Group:     "projectsesame.io",
+				Kind:      "GatewayClassParameters",
+				Name:      "params",
+				Namespace: gatewayapi.NamespacePtr("projectsesame"),
+			},
+			wantReason: "Valid",
+		},
+		"parametersRef to another kind": {
+			parametersRef: &gatewayapi_v1alpha2.ParametersReference{
+				Group: "",
+				Kind:  "ConfigMap",
+				Name:  "params",
+			},
+			wantReason:  "InvalidParameters",
+			wantMessage: "Invalid GatewayClass parametersRef: parametersRef must refer to a namespaced GatewayClassParameters.projectsesame.io",
+		},
+		"parametersRef without namespace": {
+			parametersRef: &gatewayapi_v1alpha2.ParametersReference{
+				Group: "projectsesame.io",
+				Kind:  "GatewayClassParameters",
+				Name:  "params",
+			},
+			wantReason:  "InvalidParameters",
+			wantMessage: "Invalid GatewayClass parametersRef: parametersRef must refer to a namespaced GatewayClassParameters.projectsesame.io",
+		},
+		"parametersRef to missing GatewayClassParameters": {
+			parametersRef: &gatewayapi_v1alpha2.ParametersReference{
+				Group:     "projectsesame.io",
+				Kind:      "GatewayClassParameters",
+				Name:      "missing",
+				Namespace: gatewayapi.NamespacePtr("projectsesame"),
+			},
+			wantReason:  "InvalidParameters",
+			wantMessage: "Invalid GatewayClass parametersRef: GatewayClassParameters projectsesame/missing not found",
+		},
+		"parametersRef to GatewayClassParameters with invalid timeouts": {
+			parametersRef: &gatewayapi_v1alpha2.ParametersReference{
+				Group:     "projectsesame.io",
+				Kind:      "GatewayClassParameters",
+				Name:      "invalid",
+				Namespace: gatewayapi.NamespacePtr("projectsesame"),
+			},
+			wantReason:  "InvalidParameters",
+			wantMessage: `Invalid GatewayClass parametersRef: GatewayClassParameters projectsesame/invalid has invalid envoyConfig: failed to parse request timeout: unable to parse timeout string "invalid": time: invalid duration "invalid"`,
+		},
+		"parametersRef to GatewayClassParameters overriding a listener port": {
+			parametersRef: &gatewayapi_v1alpha2.ParametersReference{
+				Group:     "projectsesame.io",
+				Kind:      "GatewayClassParameters",
+				Name:      "port",
+				Namespace: gatewayapi.NamespacePtr("projectsesame"),
+			},
+			wantReason:  "InvalidParameters",
+			wantMessage: "Invalid GatewayClass parametersRef: GatewayClassParameters projectsesame/port has invalid envoyConfig: httpsListener.port can only be overridden for provisioned gateways",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			scheme, err := k8s.NewSesameScheme()
+			require.NoError(t, err)
+
+			gc := &gatewayapi_v1alpha2.GatewayClass{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "sesame",
+				},
+				Spec: gatewayapi_v1alpha2.GatewayClassSpec{
+					ControllerName: "projectsesame.io/gateway-controller",
+					ParametersRef:  tc.parametersRef,
+				},
+			}
+
+			cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gc, params, portParams, invalidParams).Build()
+
+			var added, deleted []interface{}
+			r := &gatewayClassReconciler{
+				client: cli,
+				eventHandler: cache.ResourceEventHandlerFuncs{
+					AddFunc:    func(obj interface{}) { added = append(added, obj) },
+					DeleteFunc: func(obj interface{}) { deleted = append(deleted, obj) },
+				},
+				log:        fixture.NewTestLogger(t),
+				controller: gc.Spec.ControllerName,
+			}
+
+			_, err = r.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: gc.Name}})
+			require.NoError(t, err)
+
+			gc = &gatewayapi_v1alpha2.GatewayClass{}
+			require.NoError(t, cli.Get(context.Background(), types.NamespacedName{Name: "sesame"}, gc))
+			require.Len(t, gc.Status.Conditions, 1)
+			assert.Equal(t, tc.wantReason, gc.Status.Conditions[0].Reason)
+
+			if tc.wantReason == "Valid" {
+				assert.Equal(t, metav1.ConditionTrue, gc.Status.Conditions[0].Status)
+				assert.Len(t, added, 1)
+				assert.Empty(t, deleted)
+			} else {
+				assert.Equal(t, metav1.ConditionFalse, gc.Status.Conditions[0].Status)
+				assert.Equal(t, tc.wantMessage, gc.Status.Conditions[0].Message)
+				assert.Empty(t, added)
+				assert.Len(t, deleted, 1)
+			}
+		})
+	}
+}
+
+func TestMapParametersToGatewayClasses(t *testing.T) {
+	scheme, err := k8s.NewSesameScheme()
+	require.NoError(t, err)
+
+	ref := &gatewayapi_v1alpha2.ParametersReference{
+		Group:     "projectsesame.io",
+		Kind:      "GatewayClassParameters",
+		Name:      "params",
+		Namespace: gatewayapi.NamespacePtr("projectsesame"),
+	}
+
+	objs := []client.Object{
+		&gatewayapi_v1alpha2.GatewayClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "referring"},
+			Spec: gatewayapi_v1alpha2.GatewayClassSpec{
+				ControllerName: "projectsesame.io/gateway-controller",
+				ParametersRef:  ref,
+			},
+		},
+		&gatewayapi_v1alpha2.GatewayClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-controller"},
+			Spec: gatewayapi_v1alpha2.GatewayClassSpec{
+				ControllerName: "example.com/gateway-controller",
+				ParametersRef:  ref,
+			},
+		},
+		&gatewayapi_v1alpha2.GatewayClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "no-parameters"},
+			Spec: gatewayapi_v1alpha2.GatewayClassSpec{
+				ControllerName: "projectsesame.io/gateway-controller",
+			},
+		},
+	}
+
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+
+	r := &gatewayClassReconciler{
+		client:     cli,
+		log:        fixture.NewTestLogger(t),
+		controller: "projectsesame.io/gateway-controller",
+	}
+
+	params := &sesame_api_v1alpha1.GatewayClassParameters{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "projectsesame", Name: "params"},
+	}
+	assert.Equal(t, []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "referring"}}}, r.mapParametersToGatewayClasses(params))
+}
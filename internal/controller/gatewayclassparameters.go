@@ -0,0 +1,121 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
+	"github.com/projectsesame/sesame/internal/k8s"
+	"github.com/projectsesame/sesame/internal/sesameconfig"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayapi_v1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+// invalidParametersError is returned when the parametersRef of a
+// GatewayClass, or the parameters it refers to, are invalid.
+type invalidParametersError struct {
+	msg string
+}
+
+func (e *invalidParametersError) Error() string {
+	return e.msg
+}
+
+func invalidParameters(format string, args ...interface{}) error {
+	return &invalidParametersError{msg: fmt.Sprintf(format, args...)}
+}
+
+// gatewayClassParameters returns the GatewayClassParameters the provided
+// GatewayClass refers to with its parametersRef, or nil if it has no
+// parametersRef. An *invalidParametersError is returned if the
+// parametersRef doesn't refer to valid GatewayClassParameters.
+func gatewayClassParameters(ctx context.Context, cli client.Client, gc *gatewayapi_v1alpha2.GatewayClass) (*sesame_api_v1alpha1.GatewayClassParameters, error) {
+	if gc.Spec.ParametersRef == nil {
+		return nil, nil
+	}
+
+	ref, ok := k8s.GatewayClassParametersRef(gc)
+	if !ok {
+		return nil, invalidParameters("parametersRef must refer to a namespaced %s.%s",
+			k8s.KindGatewayClassParameters, sesame_api_v1alpha1.GroupVersion.Group)
+	}
+
+	params := &sesame_api_v1alpha1.GatewayClassParameters{}
+	if err := cli.Get(ctx, ref, params); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, invalidParameters("%s %s not found", k8s.KindGatewayClassParameters, ref)
+		}
+		return nil, fmt.Errorf("failed to get parameters %s of gatewayclass %s: %w", ref, gc.Name, err)
+	}
+
+	if envoyConfig := params.Spec.EnvoyConfig; envoyConfig != nil {
+		if _, err := sesameconfig.ParseTimeoutPolicy(envoyConfig.Timeouts); err != nil {
+			return nil, invalidParameters("%s %s has invalid envoyConfig: %s", k8s.KindGatewayClassParameters, ref, err)
+		}
+	}
+
+	return params, nil
+}
+
+// validateListenerPorts returns an *invalidParametersError if the provided
+// parameters override the port of a listener. The ports can only be
+// overridden for Gateways that are provisioned, since the provisioner also
+// updates the target ports of the Envoy Service to match.
+func validateListenerPorts(params *sesame_api_v1alpha1.GatewayClassParameters) error {
+	if params == nil || params.Spec.EnvoyConfig == nil {
+		return nil
+	}
+
+	envoyConfig := params.Spec.EnvoyConfig
+	for _, l := range []struct {
+		name     string
+		listener *sesame_api_v1alpha1.GatewayEnvoyListener
+	}{
+		{"httpListener", envoyConfig.HTTPListener},
+		{"httpsListener", envoyConfig.HTTPSListener},
+	} {
+		if l.listener != nil && l.listener.Port != 0 {
+			return invalidParameters("%s %s has invalid envoyConfig: %s.port can only be overridden for provisioned gateways",
+				k8s.KindGatewayClassParameters, k8s.NamespacedNameOf(params), l.name)
+		}
+	}
+
+	return nil
+}
+
+// gatewayClassesWithParameters returns the GatewayClasses with the
+// provided controller name that refer to a GatewayClassParameters.
+func gatewayClassesWithParameters(ctx context.Context, cli client.Client, controllerName gatewayapi_v1alpha2.GatewayController, params client.Object) ([]*gatewayapi_v1alpha2.GatewayClass, error) {
+	var gatewayClasses gatewayapi_v1alpha2.GatewayClassList
+	if err := cli.List(ctx, &gatewayClasses); err != nil {
+		return nil, fmt.Errorf("error listing gatewayclasses: %w", err)
+	}
+
+	var classes []*gatewayapi_v1alpha2.GatewayClass
+	for i := range gatewayClasses.Items {
+		gc := &gatewayClasses.Items[i]
+		if gc.Spec.ControllerName != controllerName {
+			continue
+		}
+
+		if ref, ok := k8s.GatewayClassParametersRef(gc); ok && ref == k8s.NamespacedNameOf(params) {
+			classes = append(classes, gc)
+		}
+	}
+
+	return classes, nil
+}
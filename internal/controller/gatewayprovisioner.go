@@ -0,0 +1,421 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
+	"github.com/projectsesame/sesame/internal/k8s"
+	"github.com/projectsesame/sesame/internal/provisioner"
+	"github.com/projectsesame/sesame/internal/status"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	gatewayapi_v1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+// GatewayProvisionerFinalizer is added to provisioned Gateways so that
+// the cluster-scoped objects provisioned for them are deleted with them.
+const GatewayProvisionerFinalizer = "projectsesame.io/gateway-provisioner"
+
+type gatewayProvisioner struct {
+	client         client.Client
+	log            logrus.FieldLogger
+	controllerName gatewayapi_v1alpha2.GatewayController
+	images         provisioner.Images
+}
+
+type gatewayClassProvisioner struct {
+	client         client.Client
+	log            logrus.FieldLogger
+	controllerName gatewayapi_v1alpha2.GatewayController
+}
+
+// RegisterGatewayProvisioner creates the gateway-provisioner and
+// gatewayclass-provisioner controllers. For each Gateway whose GatewayClass
+// has a controller field that matches controllerName, a dedicated Sesame
+// instance and Envoy fleet are provisioned that serve only that Gateway.
+// The GatewayClass parametersRef may refer to a GatewayClassParameters
+// resource that defines how the instances are provisioned.
+func RegisterGatewayProvisioner(log logrus.FieldLogger, mgr manager.Manager, controllerName string, images provisioner.Images) error {
+	gcp := &gatewayClassProvisioner{
+		client:         mgr.GetClient(),
+		log:            log.WithField("context", "gatewayclass-provisioner"),
+		controllerName: gatewayapi_v1alpha2.GatewayController(controllerName),
+	}
+
+	gcc, err := controller.New("gatewayclass-provisioner", mgr, controller.Options{Reconciler: gcp})
+	if err != nil {
+		return err
+	}
+
+	if err := gcc.Watch(
+		&source.Kind{Type: &gatewayapi_v1alpha2.GatewayClass{}},
+		&handler.EnqueueRequestForObject{},
+		predicate.NewPredicateFuncs(gcp.hasMatchingController),
+	); err != nil {
+		return err
+	}
+
+	if err := gcc.Watch(
+		&source.Kind{Type: &sesame_api_v1alpha1.GatewayClassParameters{}},
+		handler.EnqueueRequestsFromMapFunc(gcp.mapParametersToGatewayClasses),
+	); err != nil {
+		return err
+	}
+
+	gp := &gatewayProvisioner{
+		client:         mgr.GetClient(),
+		log:            log.WithField("context", "gateway-provisioner"),
+		controllerName: gatewayapi_v1alpha2.GatewayController(controllerName),
+		images:         images,
+	}
+
+	gc, err := controller.New("gateway-provisioner", mgr, controller.Options{Reconciler: gp})
+	if err != nil {
+		return err
+	}
+
+	// Gateways with the finalizer are reconciled even if their class
+	// is no longer controlled by the provisioner, so that their
+	// deletion is not blocked.
+	if err := gc.Watch(
+		&source.Kind{Type: &gatewayapi_v1alpha2.Gateway{}},
+		&handler.EnqueueRequestForObject{},
+		predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			return gp.hasMatchingController(obj) || controllerutil.ContainsFinalizer(obj, GatewayProvisionerFinalizer)
+		}),
+	); err != nil {
+		return err
+	}
+
+	// Reprovision the Gateways of a class when the class or
+	// the parameters it refers to are changed.
+	if err := gc.Watch(
+		&source.Kind{Type: &gatewayapi_v1alpha2.GatewayClass{}},
+		handler.EnqueueRequestsFromMapFunc(gp.mapGatewayClassToGateways),
+		predicate.NewPredicateFuncs(gcp.hasMatchingController),
+	); err != nil {
+		return err
+	}
+
+	if err := gc.Watch(
+		&source.Kind{Type: &sesame_api_v1alpha1.GatewayClassParameters{}},
+		handler.EnqueueRequestsFromMapFunc(gp.mapParametersToGateways),
+	); err != nil {
+		return err
+	}
+
+	// Reconcile the owning Gateway when one of its
+	// namespaced objects is changed or deleted.
+	for _, owned := range []client.Object{
+		&appsv1.Deployment{},
+		&appsv1.DaemonSet{},
+		&batchv1.Job{},
+		&corev1.Service{},
+		&corev1.ServiceAccount{},
+		&rbacv1.Role{},
+		&rbacv1.RoleBinding{},
+		&sesame_api_v1alpha1.SesameConfiguration{},
+	} {
+		if err := gc.Watch(&source.Kind{Type: owned}, &handler.EnqueueRequestForOwner{
+			OwnerType:    &gatewayapi_v1alpha2.Gateway{},
+			IsController: true,
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, owned := range []client.Object{
+		&rbacv1.ClusterRole{},
+		&rbacv1.ClusterRoleBinding{},
+	} {
+		if err := gc.Watch(&source.Kind{Type: owned}, handler.EnqueueRequestsFromMapFunc(ownerLabelsToRequests(provisioner.KindGateway))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// hasMatchingController returns true if the provided object is a
+// GatewayClass controlled by the provisioner.
+func (r *gatewayClassProvisioner) hasMatchingController(obj client.Object) bool {
+	gc, ok := obj.(*gatewayapi_v1alpha2.GatewayClass)
+	if !ok {
+		r.log.Debugf("unexpected object type %T, bypassing reconciliation.", obj)
+		return false
+	}
+
+	return gc.Spec.ControllerName == r.controllerName
+}
+
+// mapParametersToGatewayClasses returns requests for the GatewayClasses
+// controlled by the provisioner that refer to a GatewayClassParameters.
+func (r *gatewayClassProvisioner) mapParametersToGatewayClasses(params client.Object) []reconcile.Request {
+	gatewayClasses, err := gatewayClassesWithParameters(context.Background(), r.client, r.controllerName, params)
+	if err != nil {
+		r.log.WithError(err).Error("error mapping parameters to gatewayclasses")
+		return nil
+	}
+
+	var reconciles []reconcile.Request
+	for _, gc := range gatewayClasses {
+		reconciles = append(reconciles, reconcile.Request{NamespacedName: types.NamespacedName{Name: gc.Name}})
+	}
+
+	return reconciles
+}
+
+// Reconcile accepts a GatewayClass controlled by the provisioner,
+// unless the parameters it refers to are invalid.
+func (r *gatewayClassProvisioner) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	r.log.WithField("name", request.Name).Info("reconciling gatewayclass")
+
+	gc := &gatewayapi_v1alpha2.GatewayClass{}
+	if err := r.client.Get(ctx, request.NamespacedName, gc); err != nil {
+		if errors.IsNotFound(err) {
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("failed to get gatewayclass %s: %w", request.Name, err)
+	}
+
+	updated := status.SetGatewayClassAccepted(ctx, r.client, gc.DeepCopy(), true)
+
+	params, err := gatewayClassParameters(ctx, r.client, gc)
+	switch err.(type) {
+	case nil:
+		if params != nil {
+			if err := params.Spec.Config.Validate(); err != nil {
+				updated = status.SetGatewayClassInvalidParameters(ctx, r.client, gc.DeepCopy(), err.Error())
+			}
+		}
+	case *invalidParametersError:
+		updated = status.SetGatewayClassInvalidParameters(ctx, r.client, gc.DeepCopy(), err.Error())
+	default:
+		return reconcile.Result{}, err
+	}
+
+	if equality.Semantic.DeepEqual(gc.Status, updated.Status) {
+		return reconcile.Result{}, nil
+	}
+
+	if err := r.client.Status().Update(ctx, updated); err != nil {
+		return reconcile.Result{}, fmt.Errorf("error updating status of gateway class %s: %w", gc.Name, err)
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// hasMatchingController returns true if the provided object is a Gateway
+// whose GatewayClass is controlled by the provisioner.
+func (r *gatewayProvisioner) hasMatchingController(obj client.Object) bool {
+	gw, ok := obj.(*gatewayapi_v1alpha2.Gateway)
+	if !ok {
+		r.log.Debugf("unexpected object type %T, bypassing reconciliation.", obj)
+		return false
+	}
+
+	gc := &gatewayapi_v1alpha2.GatewayClass{}
+	if err := r.client.Get(context.Background(), types.NamespacedName{Name: string(gw.Spec.GatewayClassName)}, gc); err != nil {
+		return false
+	}
+
+	return gc.Spec.ControllerName == r.controllerName
+}
+
+// mapGatewayClassToGateways returns requests for the Gateways of a GatewayClass.
+func (r *gatewayProvisioner) mapGatewayClassToGateways(gatewayClass client.Object) []reconcile.Request {
+	var gateways gatewayapi_v1alpha2.GatewayList
+	if err := r.client.List(context.Background(), &gateways); err != nil {
+		r.log.WithError(err).Error("error listing gateways")
+		return nil
+	}
+
+	var reconciles []reconcile.Request
+	for i := range gateways.Items {
+		if string(gateways.Items[i].Spec.GatewayClassName) == gatewayClass.GetName() {
+			reconciles = append(reconciles, reconcile.Request{
+				NamespacedName: k8s.NamespacedNameOf(&gateways.Items[i]),
+			})
+		}
+	}
+
+	return reconciles
+}
+
+// mapParametersToGateways returns requests for the Gateways of the
+// GatewayClasses that refer to a GatewayClassParameters.
+func (r *gatewayProvisioner) mapParametersToGateways(params client.Object) []reconcile.Request {
+	gatewayClasses, err := gatewayClassesWithParameters(context.Background(), r.client, r.controllerName, params)
+	if err != nil {
+		r.log.WithError(err).Error("error mapping parameters to gateways")
+		return nil
+	}
+
+	var reconciles []reconcile.Request
+	for _, gc := range gatewayClasses {
+		reconciles = append(reconciles, r.mapGatewayClassToGateways(gc)...)
+	}
+
+	return reconciles
+}
+
+func (r *gatewayProvisioner) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log := r.log.WithField("namespace", request.Namespace).WithField("name", request.Name)
+	log.Info("reconciling gateway")
+
+	gw := &gatewayapi_v1alpha2.Gateway{}
+	if err := r.client.Get(ctx, request.NamespacedName, gw); err != nil {
+		if errors.IsNotFound(err) {
+			log.Debug("gateway not found, ignoring")
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("failed to get gateway %s: %w", request, err)
+	}
+
+	instance := &provisioner.Instance{
+		Kind:      provisioner.KindGateway,
+		Name:      gw.Name,
+		Namespace: gw.Namespace,
+		Images:    r.images,
+	}
+
+	if !gw.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, r.finalize(ctx, gw, instance)
+	}
+
+	gc := &gatewayapi_v1alpha2.GatewayClass{}
+	if err := r.client.Get(ctx, types.NamespacedName{Name: string(gw.Spec.GatewayClassName)}, gc); err != nil {
+		if !errors.IsNotFound(err) {
+			return reconcile.Result{}, fmt.Errorf("failed to get gatewayclass %s: %w", gw.Spec.GatewayClassName, err)
+		}
+		gc = nil
+	}
+
+	if gc == nil || gc.Spec.ControllerName != r.controllerName {
+		log.Debug("gateway's class is not controlled by the provisioner")
+		return reconcile.Result{}, r.finalize(ctx, gw, instance)
+	}
+
+	params, err := gatewayClassParameters(ctx, r.client, gc)
+	if err != nil {
+		if _, ok := err.(*invalidParametersError); ok {
+			// The GatewayClass status reports the invalid parameters.
+			log.WithError(err).WithField("gatewayclass", gc.Name).Error("gatewayclass parameters are invalid, not provisioning gateway")
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	if params != nil {
+		instance.Replicas = params.Spec.Replicas
+		instance.Config = *params.Spec.Config.DeepCopy()
+		if params.Spec.Envoy != nil {
+			instance.Envoy = *params.Spec.Envoy
+		}
+		params.Spec.EnvoyConfig.Override(&instance.Config.Envoy)
+	}
+
+	if err := instance.Config.Validate(); err != nil {
+		log.WithError(err).Error("gatewayclass parameters configuration is invalid, not provisioning gateway")
+		return reconcile.Result{}, nil
+	}
+
+	// The provisioned Sesame serves only this Gateway.
+	instance.Config.Gateway = &sesame_api_v1alpha1.GatewayConfig{
+		ControllerName: string(r.controllerName),
+		GatewayRef: &sesame_api_v1alpha1.NamespacedName{
+			Name:      gw.Name,
+			Namespace: gw.Namespace,
+		},
+	}
+
+	// Expose every listener port on the Envoy Service. The provisioned
+	// Sesame serves each port from an Envoy listener of its own, which
+	// serves the protocol of the port's first listener.
+	exposed := map[gatewayapi_v1alpha2.PortNumber]bool{}
+	for _, listener := range gw.Spec.Listeners {
+		if exposed[listener.Port] {
+			continue
+		}
+		switch listener.Protocol {
+		case gatewayapi_v1alpha2.HTTPProtocolType:
+			instance.HTTPPorts = append(instance.HTTPPorts, int32(listener.Port))
+		case gatewayapi_v1alpha2.HTTPSProtocolType, gatewayapi_v1alpha2.TLSProtocolType:
+			instance.HTTPSPorts = append(instance.HTTPSPorts, int32(listener.Port))
+		default:
+			continue
+		}
+		exposed[listener.Port] = true
+	}
+
+	if !controllerutil.ContainsFinalizer(gw, GatewayProvisionerFinalizer) {
+		controllerutil.AddFinalizer(gw, GatewayProvisionerFinalizer)
+		if err := r.client.Update(ctx, gw); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to add finalizer to gateway %s: %w", request, err)
+		}
+	}
+
+	if err := provision(ctx, r.client, gw, instance); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, nil
+}
+
+// finalize deprovisions the instance of a Gateway that is deleted
+// or no longer controlled by the provisioner, and removes the finalizer.
+// Owned objects of a Gateway that still exists are deleted explicitly,
+// since they are not garbage collected.
+func (r *gatewayProvisioner) finalize(ctx context.Context, gw *gatewayapi_v1alpha2.Gateway, instance *provisioner.Instance) error {
+	if !controllerutil.ContainsFinalizer(gw, GatewayProvisionerFinalizer) {
+		return nil
+	}
+
+	if err := deprovision(ctx, r.client, instance); err != nil {
+		return err
+	}
+
+	if gw.DeletionTimestamp.IsZero() {
+		objects := append(provisioner.Objects(instance), provisioner.StaleObjects(instance)...)
+		for _, obj := range objects {
+			if err := provisioner.Delete(ctx, r.client, obj); err != nil {
+				return fmt.Errorf("failed to delete %T %s: %w", obj, obj.GetName(), err)
+			}
+		}
+	}
+
+	controllerutil.RemoveFinalizer(gw, GatewayProvisionerFinalizer)
+	if err := r.client.Update(ctx, gw); err != nil {
+		return fmt.Errorf("failed to remove finalizer from gateway %s/%s: %w", gw.Namespace, gw.Name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,250 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
+	"github.com/projectsesame/sesame/internal/fixture"
+	"github.com/projectsesame/sesame/internal/k8s"
+	"github.com/projectsesame/sesame/internal/provisioner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayapi_v1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func TestGatewayProvisionerReconcile(t *testing.T) {
+	scheme, err := k8s.NewSesameScheme()
+	require.NoError(t, err)
+
+	namespace := gatewayapi_v1alpha2.Namespace("projectsesame")
+	gc := &gatewayapi_v1alpha2.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "provisioned",
+		},
+		Spec: gatewayapi_v1alpha2.GatewayClassSpec{
+			ControllerName: "projectsesame.io/gateway-provisioner",
+			ParametersRef: &gatewayapi_v1alpha2.ParametersReference{
+				Group:     "projectsesame.io",
+				Kind:      "GatewayClassParameters",
+				Name:      "params",
+				Namespace: &namespace,
+			},
+		},
+	}
+	params := &sesame_api_v1alpha1.GatewayClassParameters{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "projectsesame",
+			Name:      "params",
+		},
+		Spec: sesame_api_v1alpha1.GatewayClassParametersSpec{
+			Replicas: 3,
+			Envoy: &sesame_api_v1alpha1.EnvoySettings{
+				WorkloadType: sesame_api_v1alpha1.WorkloadTypeDeployment,
+			},
+			EnvoyConfig: &sesame_api_v1alpha1.GatewayEnvoyConfig{
+				HTTPListener: &sesame_api_v1alpha1.GatewayEnvoyListener{
+					Port: 9080,
+				},
+			},
+		},
+	}
+	key := types.NamespacedName{Namespace: "projectsesame", Name: "example"}
+	gw := &gatewayapi_v1alpha2.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: key.Namespace,
+			Name:      key.Name,
+		},
+		Spec: gatewayapi_v1alpha2.GatewaySpec{
+			GatewayClassName: "provisioned",
+			Listeners: []gatewayapi_v1alpha2.Listener{{
+				Name:     "http",
+				Protocol: gatewayapi_v1alpha2.HTTPProtocolType,
+				Port:     8080,
+			}, {
+				Name:     "http-alt",
+				Protocol: gatewayapi_v1alpha2.HTTPProtocolType,
+				Port:     8081,
+			}, {
+				Name:     "http-other-host",
+				Protocol: gatewayapi_v1alpha2.HTTPProtocolType,
+				Port:     8080,
+			}, {
+				Name:     "tls",
+				Protocol: gatewayapi_v1alpha2.TLSProtocolType,
+				Port:     8443,
+			}},
+		},
+	}
+
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gc, params, gw).Build()
+
+	// The GatewayClass is accepted.
+	gcp := &gatewayClassProvisioner{
+		client:         cli,
+		log:            fixture.NewTestLogger(t),
+		controllerName: gc.Spec.ControllerName,
+	}
+	_, err = gcp.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: gc.Name}})
+	require.NoError(t, err)
+	require.NoError(t, cli.Get(context.Background(), types.NamespacedName{Name: gc.Name}, gc))
+	assert.True(t, isAccepted(gc))
+
+	r := &gatewayProvisioner{
+		client:         cli,
+		log:            fixture.NewTestLogger(t),
+		controllerName: gc.Spec.ControllerName,
+	}
+	_, err = r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+	require.NoError(t, err)
+
+	require.NoError(t, cli.Get(context.Background(), key, gw))
+	assert.Contains(t, gw.Finalizers, GatewayProvisionerFinalizer)
+
+	// The instance is provisioned from the parameters and owned by the Gateway.
+	deploy := &appsv1.Deployment{}
+	require.NoError(t, cli.Get(context.Background(), types.NamespacedName{Namespace: key.Namespace, Name: "sesame-gateway-example"}, deploy))
+	assert.Equal(t, int32(3), *deploy.Spec.Replicas)
+	require.Len(t, deploy.OwnerReferences, 1)
+	assert.Equal(t, "Gateway", deploy.OwnerReferences[0].Kind)
+
+	require.NoError(t, cli.Get(context.Background(), types.NamespacedName{Namespace: key.Namespace, Name: "envoy-gateway-example"}, deploy))
+
+	// The Envoy Service exposes each of the Gateway's listener ports.
+	svc := &corev1.Service{}
+	require.NoError(t, cli.Get(context.Background(), types.NamespacedName{Namespace: key.Namespace, Name: "envoy-gateway-example"}, svc))
+	require.Len(t, svc.Spec.Ports, 3)
+	assert.Equal(t, "http", svc.Spec.Ports[0].Name)
+	assert.Equal(t, int32(8080), svc.Spec.Ports[0].Port)
+	assert.Equal(t, "http-8081", svc.Spec.Ports[1].Name)
+	assert.Equal(t, int32(8081), svc.Spec.Ports[1].Port)
+	assert.Equal(t, "https", svc.Spec.Ports[2].Name)
+	assert.Equal(t, int32(8443), svc.Spec.Ports[2].Port)
+
+	// Each listener port is forwarded to the Envoy listener that serves
+	// it. The EnvoyConfig of the parameters moves Envoy's HTTP listener
+	// to port 9080, so the HTTP ports are served by listeners of their
+	// own, and the TLS port by Envoy's HTTPS listener.
+	assert.Equal(t, 8080, svc.Spec.Ports[0].TargetPort.IntValue())
+	assert.Equal(t, 8081, svc.Spec.Ports[1].TargetPort.IntValue())
+	assert.Equal(t, 8443, svc.Spec.Ports[2].TargetPort.IntValue())
+
+	// The provisioned Sesame serves only this Gateway.
+	config := &sesame_api_v1alpha1.SesameConfiguration{}
+	require.NoError(t, cli.Get(context.Background(), types.NamespacedName{Namespace: key.Namespace, Name: "sesame-gateway-example"}, config))
+	assert.Equal(t, &sesame_api_v1alpha1.GatewayConfig{
+		ControllerName: "projectsesame.io/gateway-provisioner",
+		GatewayRef: &sesame_api_v1alpha1.NamespacedName{
+			Name:      "example",
+			Namespace: "projectsesame",
+		},
+	}, config.Spec.Gateway)
+	assert.Equal(t, 9080, config.Spec.Envoy.HTTPListener.Port)
+
+	clusterRole := &rbacv1.ClusterRole{}
+	require.NoError(t, cli.Get(context.Background(), types.NamespacedName{Name: "sesame-projectsesame-gateway-example"}, clusterRole))
+	assert.Equal(t, []reconcile.Request{{NamespacedName: key}}, ownerLabelsToRequests(provisioner.KindGateway)(clusterRole))
+
+	// Changes to the parameters map to the Gateways of the classes that refer to them.
+	assert.Equal(t, []reconcile.Request{{NamespacedName: key}}, r.mapParametersToGateways(params))
+
+	// Moving the Gateway to a class the provisioner doesn't
+	// control deprovisions it and removes the finalizer.
+	gw.Spec.GatewayClassName = "other"
+	require.NoError(t, cli.Update(context.Background(), gw))
+
+	_, err = r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+	require.NoError(t, err)
+
+	gw = &gatewayapi_v1alpha2.Gateway{}
+	require.NoError(t, cli.Get(context.Background(), key, gw))
+	assert.NotContains(t, gw.Finalizers, GatewayProvisionerFinalizer)
+
+	err = cli.Get(context.Background(), types.NamespacedName{Namespace: key.Namespace, Name: "sesame-gateway-example"}, deploy)
+	assert.True(t, errors.IsNotFound(err))
+	err = cli.Get(context.Background(), types.NamespacedName{Name: "sesame-projectsesame-gateway-example"}, clusterRole)
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestGatewayProvisionerReconcileInvalidParameters(t *testing.T) {
+	scheme, err := k8s.NewSesameScheme()
+	require.NoError(t, err)
+
+	namespace := gatewayapi_v1alpha2.Namespace("projectsesame")
+	gc := &gatewayapi_v1alpha2.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "provisioned",
+		},
+		Spec: gatewayapi_v1alpha2.GatewayClassSpec{
+			ControllerName: "projectsesame.io/gateway-provisioner",
+			ParametersRef: &gatewayapi_v1alpha2.ParametersReference{
+				Group:     "projectsesame.io",
+				Kind:      "GatewayClassParameters",
+				Name:      "missing",
+				Namespace: &namespace,
+			},
+		},
+	}
+	key := types.NamespacedName{Namespace: "projectsesame", Name: "example"}
+	gw := &gatewayapi_v1alpha2.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: key.Namespace,
+			Name:      key.Name,
+		},
+		Spec: gatewayapi_v1alpha2.GatewaySpec{
+			GatewayClassName: "provisioned",
+		},
+	}
+
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(gc, gw).Build()
+
+	// The GatewayClass is not accepted.
+	gcp := &gatewayClassProvisioner{
+		client:         cli,
+		log:            fixture.NewTestLogger(t),
+		controllerName: gc.Spec.ControllerName,
+	}
+	_, err = gcp.Reconcile(context.Background(), reconcile.Request{NamespacedName: types.NamespacedName{Name: gc.Name}})
+	require.NoError(t, err)
+	require.NoError(t, cli.Get(context.Background(), types.NamespacedName{Name: gc.Name}, gc))
+	assert.False(t, isAccepted(gc))
+	require.Len(t, gc.Status.Conditions, 1)
+	assert.Equal(t, string(gatewayapi_v1alpha2.GatewayClassReasonInvalidParameters), gc.Status.Conditions[0].Reason)
+
+	// The Gateway is not provisioned.
+	r := &gatewayProvisioner{
+		client:         cli,
+		log:            fixture.NewTestLogger(t),
+		controllerName: gc.Spec.ControllerName,
+	}
+	_, err = r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+	require.NoError(t, err)
+
+	gw = &gatewayapi_v1alpha2.Gateway{}
+	require.NoError(t, cli.Get(context.Background(), key, gw))
+	assert.NotContains(t, gw.Finalizers, GatewayProvisionerFinalizer)
+
+	err = cli.Get(context.Background(), types.NamespacedName{Namespace: key.Namespace, Name: "sesame-gateway-example"}, &appsv1.Deployment{})
+	assert.True(t, errors.IsNotFound(err))
+}
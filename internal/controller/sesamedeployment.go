@@ -0,0 +1,269 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
+	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
+	"github.com/projectsesame/sesame/internal/provisioner"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// SesameDeploymentFinalizer is added to SesameDeployments so that the
+// cluster-scoped objects provisioned for them are deleted with them.
+const SesameDeploymentFinalizer = "projectsesame.io/sesamedeployment"
+
+// sesameDeploymentKind is the Kind of SesameDeployment resources.
+const sesameDeploymentKind = "SesameDeployment"
+
+type sesameDeploymentReconciler struct {
+	client client.Client
+	log    logrus.FieldLogger
+	images provisioner.Images
+}
+
+// RegisterSesameDeploymentController creates the sesamedeployment controller.
+// The controller provisions a Sesame instance and its Envoy fleet for each
+// SesameDeployment, and keeps the provisioned objects in sync with it.
+func RegisterSesameDeploymentController(log logrus.FieldLogger, mgr manager.Manager, images provisioner.Images) error {
+	r := &sesameDeploymentReconciler{
+		client: mgr.GetClient(),
+		log:    log,
+		images: images,
+	}
+
+	c, err := controller.New("sesamedeployment-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	if err := c.Watch(&source.Kind{Type: &sesame_api_v1alpha1.SesameDeployment{}}, &handler.EnqueueRequestForObject{}); err != nil {
+		return err
+	}
+
+	// Reconcile the owning SesameDeployment when one of
+	// its namespaced objects is changed or deleted.
+	for _, owned := range []client.Object{
+		&appsv1.Deployment{},
+		&appsv1.DaemonSet{},
+		&batchv1.Job{},
+		&corev1.Service{},
+		&corev1.ServiceAccount{},
+		&rbacv1.Role{},
+		&rbacv1.RoleBinding{},
+		&sesame_api_v1alpha1.SesameConfiguration{},
+	} {
+		if err := c.Watch(&source.Kind{Type: owned}, &handler.EnqueueRequestForOwner{
+			OwnerType:    &sesame_api_v1alpha1.SesameDeployment{},
+			IsController: true,
+		}); err != nil {
+			return err
+		}
+	}
+
+	// Cluster-scoped objects can't have a namespaced owner,
+	// so they are mapped back to it using their labels.
+	for _, owned := range []client.Object{
+		&rbacv1.ClusterRole{},
+		&rbacv1.ClusterRoleBinding{},
+	} {
+		if err := c.Watch(&source.Kind{Type: owned}, handler.EnqueueRequestsFromMapFunc(ownerLabelsToRequests(sesameDeploymentKind))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ownerLabelsToRequests returns a function that maps a provisioned
+// object to a request for the owner recorded in its labels, if the
+// owner is of the given kind.
+func ownerLabelsToRequests(kind string) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		labels := obj.GetLabels()
+		if labels[provisioner.OwningKindLabel] != kind {
+			return nil
+		}
+
+		name, namespace := labels[provisioner.OwningNameLabel], labels[provisioner.OwningNamespaceLabel]
+		if name == "" || namespace == "" {
+			return nil
+		}
+
+		return []reconcile.Request{{
+			NamespacedName: types.NamespacedName{Namespace: namespace, Name: name},
+		}}
+	}
+}
+
+func (r *sesameDeploymentReconciler) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	log := r.log.WithField("namespace", request.Namespace).WithField("name", request.Name)
+	log.Info("reconciling sesamedeployment")
+
+	sd := &sesame_api_v1alpha1.SesameDeployment{}
+	if err := r.client.Get(ctx, request.NamespacedName, sd); err != nil {
+		if errors.IsNotFound(err) {
+			log.Debug("sesamedeployment not found, ignoring")
+			return reconcile.Result{}, nil
+		}
+		return reconcile.Result{}, fmt.Errorf("failed to get sesamedeployment %s: %w", request, err)
+	}
+
+	instance := &provisioner.Instance{
+		Kind:      sesameDeploymentKind,
+		Name:      sd.Name,
+		Namespace: sd.Namespace,
+		Replicas:  sd.Spec.Replicas,
+		Config:    sd.Spec.Config,
+		Images:    r.images,
+	}
+	if sd.Spec.Envoy != nil {
+		instance.Envoy = *sd.Spec.Envoy
+	}
+
+	if !sd.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, r.finalize(ctx, sd, instance)
+	}
+
+	if !controllerutil.ContainsFinalizer(sd, SesameDeploymentFinalizer) {
+		controllerutil.AddFinalizer(sd, SesameDeploymentFinalizer)
+		if err := r.client.Update(ctx, sd); err != nil {
+			return reconcile.Result{}, fmt.Errorf("failed to add finalizer to sesamedeployment %s: %w", request, err)
+		}
+	}
+
+	if err := sd.Spec.Config.Validate(); err != nil {
+		log.WithError(err).Info("sesamedeployment configuration is invalid")
+		return reconcile.Result{}, r.updateStatus(ctx, sd, sesame_api_v1.ConditionFalse, "ConfigNotValid", err.Error())
+	}
+
+	if err := provision(ctx, r.client, sd, instance); err != nil {
+		if statusErr := r.updateStatus(ctx, sd, sesame_api_v1.ConditionFalse, "ProvisionFailed", err.Error()); statusErr != nil {
+			log.WithError(statusErr).Error("failed to update sesamedeployment status")
+		}
+		return reconcile.Result{}, err
+	}
+
+	return reconcile.Result{}, r.updateStatus(ctx, sd, sesame_api_v1.ConditionTrue, "Provisioned", "Sesame and Envoy are provisioned")
+}
+
+// provision creates or updates the objects that make up the instance.
+// The namespaced objects are owned by owner, so that they are garbage
+// collected with it.
+func provision(ctx context.Context, cli client.Client, owner client.Object, instance *provisioner.Instance) error {
+	for _, obj := range provisioner.ClusterObjects(instance) {
+		if err := provisioner.Ensure(ctx, cli, obj); err != nil {
+			return fmt.Errorf("failed to ensure %T %s: %w", obj, obj.GetName(), err)
+		}
+	}
+
+	for _, obj := range provisioner.Objects(instance) {
+		if err := controllerutil.SetControllerReference(owner, obj, cli.Scheme()); err != nil {
+			return fmt.Errorf("failed to set owner of %T %s: %w", obj, obj.GetName(), err)
+		}
+		if err := provisioner.Ensure(ctx, cli, obj); err != nil {
+			return fmt.Errorf("failed to ensure %T %s: %w", obj, obj.GetName(), err)
+		}
+	}
+
+	for _, obj := range provisioner.StaleObjects(instance) {
+		if err := provisioner.Delete(ctx, cli, obj); err != nil {
+			return fmt.Errorf("failed to delete %T %s: %w", obj, obj.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+// deprovision deletes the cluster-scoped objects of the instance. The
+// namespaced objects are garbage collected through their owner references.
+func deprovision(ctx context.Context, cli client.Client, instance *provisioner.Instance) error {
+	for _, obj := range provisioner.ClusterObjects(instance) {
+		if err := provisioner.Delete(ctx, cli, obj); err != nil {
+			return fmt.Errorf("failed to delete %T %s: %w", obj, obj.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+// finalize deprovisions the instance and removes the finalizer.
+func (r *sesameDeploymentReconciler) finalize(ctx context.Context, sd *sesame_api_v1alpha1.SesameDeployment, instance *provisioner.Instance) error {
+	if !controllerutil.ContainsFinalizer(sd, SesameDeploymentFinalizer) {
+		return nil
+	}
+
+	if err := deprovision(ctx, r.client, instance); err != nil {
+		return err
+	}
+
+	controllerutil.RemoveFinalizer(sd, SesameDeploymentFinalizer)
+	if err := r.client.Update(ctx, sd); err != nil {
+		return fmt.Errorf("failed to remove finalizer from sesamedeployment %s/%s: %w", sd.Namespace, sd.Name, err)
+	}
+
+	return nil
+}
+
+// updateStatus sets the Valid condition of the SesameDeployment,
+// writing the status only if it changed.
+func (r *sesameDeploymentReconciler) updateStatus(ctx context.Context, sd *sesame_api_v1alpha1.SesameDeployment, status sesame_api_v1.ConditionStatus, reason, message string) error {
+	updated := sd.DeepCopy()
+
+	cond := updated.Status.GetConditionFor(sesame_api_v1.ValidConditionType)
+	if cond == nil {
+		updated.Status.Conditions = append(updated.Status.Conditions, sesame_api_v1.DetailedCondition{
+			Condition: metav1.Condition{
+				Type: sesame_api_v1.ValidConditionType,
+			},
+		})
+		cond = &updated.Status.Conditions[len(updated.Status.Conditions)-1]
+	}
+
+	if cond.Status != status {
+		cond.LastTransitionTime = metav1.Now()
+	}
+	cond.Status = status
+	cond.ObservedGeneration = sd.Generation
+	cond.Reason = reason
+	cond.Message = message
+
+	if equality.Semantic.DeepEqual(sd.Status, updated.Status) {
+		return nil
+	}
+
+	if err := r.client.Status().Update(ctx, updated); err != nil {
+		return fmt.Errorf("failed to update status of sesamedeployment %s/%s: %w", sd.Namespace, sd.Name, err)
+	}
+
+	return nil
+}
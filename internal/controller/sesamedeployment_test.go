@@ -0,0 +1,187 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
+	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
+	"github.com/projectsesame/sesame/internal/fixture"
+	"github.com/projectsesame/sesame/internal/k8s"
+	"github.com/projectsesame/sesame/internal/provisioner"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestSesameDeploymentReconcile(t *testing.T) {
+	scheme, err := k8s.NewSesameScheme()
+	require.NoError(t, err)
+
+	key := types.NamespacedName{Namespace: "projectsesame", Name: "example"}
+	sd := &sesame_api_v1alpha1.SesameDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:  key.Namespace,
+			Name:       key.Name,
+			Generation: 3,
+		},
+		Spec: sesame_api_v1alpha1.SesameDeploymentSpec{
+			Replicas: 3,
+		},
+	}
+
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sd).Build()
+	r := &sesameDeploymentReconciler{
+		client: cli,
+		log:    fixture.NewTestLogger(t),
+	}
+
+	_, err = r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+	require.NoError(t, err)
+
+	got := &sesame_api_v1alpha1.SesameDeployment{}
+	require.NoError(t, cli.Get(context.Background(), key, got))
+	assert.Contains(t, got.Finalizers, SesameDeploymentFinalizer)
+
+	cond := got.Status.GetConditionFor(sesame_api_v1.ValidConditionType)
+	require.NotNil(t, cond)
+	assert.Equal(t, sesame_api_v1.ConditionTrue, cond.Status)
+	assert.Equal(t, "Provisioned", cond.Reason)
+	assert.Equal(t, int64(3), cond.ObservedGeneration)
+
+	// The namespaced objects are owned by the SesameDeployment.
+	deploy := &appsv1.Deployment{}
+	require.NoError(t, cli.Get(context.Background(), types.NamespacedName{Namespace: key.Namespace, Name: "sesame-example"}, deploy))
+	assert.Equal(t, int32(3), *deploy.Spec.Replicas)
+	require.Len(t, deploy.OwnerReferences, 1)
+	assert.Equal(t, "SesameDeployment", deploy.OwnerReferences[0].Kind)
+	assert.Equal(t, "example", deploy.OwnerReferences[0].Name)
+
+	ds := &appsv1.DaemonSet{}
+	require.NoError(t, cli.Get(context.Background(), types.NamespacedName{Namespace: key.Namespace, Name: "envoy-example"}, ds))
+
+	config := &sesame_api_v1alpha1.SesameConfiguration{}
+	require.NoError(t, cli.Get(context.Background(), types.NamespacedName{Namespace: key.Namespace, Name: "sesame-example"}, config))
+
+	clusterRole := &rbacv1.ClusterRole{}
+	require.NoError(t, cli.Get(context.Background(), types.NamespacedName{Name: "sesame-projectsesame-example"}, clusterRole))
+	assert.Equal(t, []reconcile.Request{{NamespacedName: key}}, ownerLabelsToRequests(sesameDeploymentKind)(clusterRole))
+	assert.Empty(t, ownerLabelsToRequests(provisioner.KindGateway)(clusterRole))
+
+	// Switching Envoy to a Deployment replaces the DaemonSet.
+	got.Spec.Envoy = &sesame_api_v1alpha1.EnvoySettings{
+		WorkloadType: sesame_api_v1alpha1.WorkloadTypeDeployment,
+	}
+	require.NoError(t, cli.Update(context.Background(), got))
+
+	_, err = r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+	require.NoError(t, err)
+
+	err = cli.Get(context.Background(), types.NamespacedName{Namespace: key.Namespace, Name: "envoy-example"}, ds)
+	assert.True(t, errors.IsNotFound(err))
+	require.NoError(t, cli.Get(context.Background(), types.NamespacedName{Namespace: key.Namespace, Name: "envoy-example"}, deploy))
+}
+
+func TestSesameDeploymentReconcileInvalidConfig(t *testing.T) {
+	scheme, err := k8s.NewSesameScheme()
+	require.NoError(t, err)
+
+	key := types.NamespacedName{Namespace: "projectsesame", Name: "example"}
+	sd := &sesame_api_v1alpha1.SesameDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: key.Namespace,
+			Name:      key.Name,
+		},
+		Spec: sesame_api_v1alpha1.SesameDeploymentSpec{
+			Config: sesame_api_v1alpha1.SesameConfigurationSpec{
+				Health: sesame_api_v1alpha1.HealthConfig{
+					Address: "0.0.0.0",
+					Port:    8000,
+				},
+				Metrics: sesame_api_v1alpha1.MetricsConfig{
+					Address: "0.0.0.0",
+					Port:    8000,
+					TLS:     &sesame_api_v1alpha1.MetricsTLS{},
+				},
+			},
+		},
+	}
+
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(sd).Build()
+	r := &sesameDeploymentReconciler{
+		client: cli,
+		log:    fixture.NewTestLogger(t),
+	}
+
+	_, err = r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+	require.NoError(t, err)
+
+	got := &sesame_api_v1alpha1.SesameDeployment{}
+	require.NoError(t, cli.Get(context.Background(), key, got))
+
+	cond := got.Status.GetConditionFor(sesame_api_v1.ValidConditionType)
+	require.NotNil(t, cond)
+	assert.Equal(t, sesame_api_v1.ConditionFalse, cond.Status)
+	assert.Equal(t, "ConfigNotValid", cond.Reason)
+
+	// Nothing is provisioned for an invalid configuration.
+	err = cli.Get(context.Background(), types.NamespacedName{Namespace: key.Namespace, Name: "sesame-example"}, &appsv1.Deployment{})
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestSesameDeploymentFinalize(t *testing.T) {
+	scheme, err := k8s.NewSesameScheme()
+	require.NoError(t, err)
+
+	now := metav1.Now()
+	key := types.NamespacedName{Namespace: "projectsesame", Name: "example"}
+	sd := &sesame_api_v1alpha1.SesameDeployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         key.Namespace,
+			Name:              key.Name,
+			DeletionTimestamp: &now,
+			Finalizers:        []string{SesameDeploymentFinalizer},
+		},
+	}
+
+	objects := []client.Object{sd}
+	objects = append(objects, provisioner.ClusterObjects(&provisioner.Instance{Kind: sesameDeploymentKind, Name: key.Name, Namespace: key.Namespace})...)
+
+	cli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+	r := &sesameDeploymentReconciler{
+		client: cli,
+		log:    fixture.NewTestLogger(t),
+	}
+
+	_, err = r.Reconcile(context.Background(), reconcile.Request{NamespacedName: key})
+	require.NoError(t, err)
+
+	err = cli.Get(context.Background(), types.NamespacedName{Name: "sesame-projectsesame-example"}, &rbacv1.ClusterRole{})
+	assert.True(t, errors.IsNotFound(err))
+	err = cli.Get(context.Background(), types.NamespacedName{Name: "sesame-projectsesame-example"}, &rbacv1.ClusterRoleBinding{})
+	assert.True(t, errors.IsNotFound(err))
+
+	// Removing the finalizer lets the deletion complete.
+	err = cli.Get(context.Background(), key, &sesame_api_v1alpha1.SesameDeployment{})
+	assert.True(t, errors.IsNotFound(err))
+}
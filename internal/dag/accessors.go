@@ -18,6 +18,7 @@ import (
 	"strconv"
 
 	"github.com/projectsesame/sesame/internal/annotation"
+	"github.com/projectsesame/sesame/internal/status"
 	"github.com/projectsesame/sesame/internal/xds"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -109,6 +110,25 @@ func externalName(svc *v1.Service) string {
 	return svc.Spec.ExternalName
 }
 
+// DelegationPermitted returns true if the referenced secret has been
+// delegated to targetNamespace. The target namespace is recorded as a
+// consumer of the secret in the status of each TLSCertificateDelegation
+// that permits the reference.
+func (d *DAG) DelegationPermitted(secret types.NamespacedName, targetNamespace string, cache *KubernetesCache) bool {
+	if secret.Namespace == targetNamespace {
+		return true
+	}
+
+	delegations := cache.delegationsFor(secret, targetNamespace)
+	for _, delegation := range delegations {
+		entry, commit := status.DelegationAccessor(&d.StatusCache, delegation)
+		entry.AddConsumer(secret.Name, targetNamespace)
+		commit()
+	}
+
+	return len(delegations) > 0
+}
+
 // GetSecureVirtualHost returns the secure virtual host in the DAG that
 // matches the provided name, or nil if no matching secure virtual host
 // is found.
@@ -152,6 +172,61 @@ func (d *DAG) EnsureVirtualHost(hostname string) *VirtualHost {
 	return vhost
 }
 
+// EnsureListenerVirtualHost adds a virtual host with the provided name
+// to the named HTTP listener if it does not already exist, and returns it.
+// The virtual hosts of the default HTTP listener are those of the DAG.
+func (d *DAG) EnsureListenerVirtualHost(listener, hostname string) *VirtualHost {
+	if listener == HTTP_LISTENER_NAME {
+		return d.EnsureVirtualHost(hostname)
+	}
+
+	if d.ListenerVirtualHosts == nil {
+		d.ListenerVirtualHosts = map[string]map[string]*VirtualHost{}
+	}
+	if d.ListenerVirtualHosts[listener] == nil {
+		d.ListenerVirtualHosts[listener] = map[string]*VirtualHost{}
+	}
+
+	if vhost := d.ListenerVirtualHosts[listener][hostname]; vhost != nil {
+		return vhost
+	}
+
+	vhost := &VirtualHost{
+		Name: hostname,
+	}
+	d.ListenerVirtualHosts[listener][hostname] = vhost
+	return vhost
+}
+
+// EnsureListenerSecureVirtualHost adds a secure virtual host with the
+// provided name to the named HTTPS listener if it does not already exist,
+// and returns it. The secure virtual hosts of the default HTTPS listener
+// are those of the DAG.
+func (d *DAG) EnsureListenerSecureVirtualHost(listener, hostname string) *SecureVirtualHost {
+	if listener == HTTPS_LISTENER_NAME {
+		return d.EnsureSecureVirtualHost(hostname)
+	}
+
+	if d.ListenerSecureVirtualHosts == nil {
+		d.ListenerSecureVirtualHosts = map[string]map[string]*SecureVirtualHost{}
+	}
+	if d.ListenerSecureVirtualHosts[listener] == nil {
+		d.ListenerSecureVirtualHosts[listener] = map[string]*SecureVirtualHost{}
+	}
+
+	if svh := d.ListenerSecureVirtualHosts[listener][hostname]; svh != nil {
+		return svh
+	}
+
+	svh := &SecureVirtualHost{
+		VirtualHost: VirtualHost{
+			Name: hostname,
+		},
+	}
+	d.ListenerSecureVirtualHosts[listener][hostname] = svh
+	return svh
+}
+
 func (d *DAG) GetClusters() []*Cluster {
 	var res []*Cluster
 
@@ -188,6 +263,12 @@ func (d *DAG) GetServiceClusters() []*ServiceCluster {
 	var res []*ServiceCluster
 
 	for _, cluster := range d.GetClusters() {
+		// Clusters that forward to an ExtensionService
+		// are covered by the extension clusters below.
+		if cluster.ExtensionCluster != nil {
+			continue
+		}
+
 		// A Service has only one WeightedService entry. Fake up a
 		// ServiceCluster so that the visitor can pretend to not
 		// know this.
@@ -260,36 +341,36 @@ func (d *DAG) GetExtensionCluster(name string) *ExtensionCluster {
 	return nil
 }
 
-func (d *DAG) GetVirtualHostRoutes() map[*VirtualHost][]*Route {
+// GetVirtualHostRoutes returns the routes of the listener's
+// virtual hosts, keyed by virtual host.
+func (l *Listener) GetVirtualHostRoutes() map[*VirtualHost][]*Route {
 	res := map[*VirtualHost][]*Route{}
 
-	for _, l := range d.Listeners {
-		for _, vhost := range l.VirtualHosts {
-			var routes []*Route
-			for _, r := range vhost.Routes {
-				routes = append(routes, r)
-			}
-			if len(routes) > 0 {
-				res[vhost] = routes
-			}
+	for _, vhost := range l.VirtualHosts {
+		var routes []*Route
+		for _, r := range vhost.Routes {
+			routes = append(routes, r)
+		}
+		if len(routes) > 0 {
+			res[vhost] = routes
 		}
 	}
 
 	return res
 }
 
-func (d *DAG) GetSecureVirtualHostRoutes() map[*SecureVirtualHost][]*Route {
+// GetSecureVirtualHostRoutes returns the routes of the listener's
+// secure virtual hosts, keyed by secure virtual host.
+func (l *Listener) GetSecureVirtualHostRoutes() map[*SecureVirtualHost][]*Route {
 	res := map[*SecureVirtualHost][]*Route{}
 
-	for _, l := range d.Listeners {
-		for _, vhost := range l.SecureVirtualHosts {
-			var routes []*Route
-			for _, r := range vhost.Routes {
-				routes = append(routes, r)
-			}
-			if len(routes) > 0 {
-				res[vhost] = routes
-			}
+	for _, vhost := range l.SecureVirtualHosts {
+		var routes []*Route
+		for _, r := range vhost.Routes {
+			routes = append(routes, r)
+		}
+		if len(routes) > 0 {
+			res[vhost] = routes
 		}
 	}
 
@@ -14,9 +14,7 @@
 package dag
 
 import (
-	"github.com/projectsesame/sesame/internal/k8s"
 	"github.com/projectsesame/sesame/internal/status"
-	"k8s.io/apimachinery/pkg/types"
 	gatewayapi_v1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 )
 
@@ -50,10 +48,6 @@ type Builder struct {
 // configured DAG processors, in order.
 func (b *Builder) Build() *DAG {
 
-	gatewayNSName := types.NamespacedName{}
-	if b.Source.gateway != nil {
-		gatewayNSName = k8s.NamespacedNameOf(b.Source.gateway)
-	}
 	var gatewayController gatewayapi_v1alpha2.GatewayController
 	if b.Source.gatewayclass != nil {
 		gatewayController = b.Source.gatewayclass.Spec.ControllerName
@@ -62,7 +56,11 @@ func (b *Builder) Build() *DAG {
 	dag := &DAG{
 		VirtualHosts:       map[string]*VirtualHost{},
 		SecureVirtualHosts: map[string]*SecureVirtualHost{},
-		StatusCache:        status.NewCache(gatewayNSName, gatewayController),
+		StatusCache:        status.NewCache(gatewayController),
+
+		ListenerVirtualHosts:       map[string]map[string]*VirtualHost{},
+		ListenerSecureVirtualHosts: map[string]map[string]*SecureVirtualHost{},
+		ListenerPorts:              map[string]int{},
 	}
 
 	for _, p := range b.Processors {
@@ -2145,6 +2145,66 @@ func TestDAGInsertGatewayAPI(t *testing.T) {
 			},
 			want: listeners(),
 		},
+		"Gateway references TLS cert in different namespace, with TLSCertificateDelegation": {
+			gatewayclass: validClass,
+			gateway:      gatewayTLSTerminateCertInDifferentNamespace,
+			objs: []interface{}{
+				sec2,
+				&sesame_api_v1.TLSCertificateDelegation{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "delegation",
+						Namespace: sec2.Namespace,
+					},
+					Spec: sesame_api_v1.TLSCertificateDelegationSpec{
+						Delegations: []sesame_api_v1.CertificateDelegation{{
+							SecretName:       sec2.Name,
+							TargetNamespaces: []string{gatewayTLSTerminateCertInDifferentNamespace.Namespace},
+						}},
+					},
+				},
+				basicTLSRoute,
+				kuardService,
+			},
+			want: listeners(
+				&Listener{
+					Name: HTTPS_LISTENER_NAME,
+					Port: 443,
+					SecureVirtualHosts: securevirtualhosts(
+						&SecureVirtualHost{
+							VirtualHost: VirtualHost{
+								Name: "test.projectsesame.io",
+							},
+							Secret: secret(sec2),
+							TCPProxy: &TCPProxy{
+								Clusters: clustersWeight(service(kuardService)),
+							},
+						},
+					),
+				},
+			),
+		},
+		"Gateway references TLS cert in different namespace, with TLSCertificateDelegation to another namespace": {
+			gatewayclass: validClass,
+			gateway:      gatewayTLSTerminateCertInDifferentNamespace,
+			objs: []interface{}{
+				sec2,
+				&sesame_api_v1.TLSCertificateDelegation{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "delegation",
+						Namespace: sec2.Namespace,
+					},
+					Spec: sesame_api_v1.TLSCertificateDelegationSpec{
+						Delegations: []sesame_api_v1.CertificateDelegation{{
+							SecretName:       sec2.Name,
+							TargetNamespaces: []string{"other"},
+						}},
+					},
+				},
+				basicTLSRoute,
+				kuardService,
+			},
+			want: listeners(),
+		},
 		"Gateway references TLS cert in different namespace, with valid ReferencePolicy (secret-specific)": {
 			gatewayclass: validClass,
 			gateway:      gatewayTLSTerminateCertInDifferentNamespace,
@@ -3994,7 +4054,6 @@ func TestDAGInsertGatewayAPI(t *testing.T) {
 			builder := Builder{
 				Source: KubernetesCache{
 					gatewayclass: tc.gatewayclass,
-					gateway:      tc.gateway,
 					FieldLogger:  fixture.NewTestLogger(t),
 				},
 				Processors: []Processor{
@@ -4005,6 +4064,9 @@ func TestDAGInsertGatewayAPI(t *testing.T) {
 				},
 			}
 
+			if tc.gateway != nil {
+				builder.Source.Insert(tc.gateway)
+			}
 			for _, o := range tc.objs {
 				builder.Source.Insert(o)
 			}
@@ -4024,6 +4086,303 @@ func TestDAGInsertGatewayAPI(t *testing.T) {
 	}
 }
 
+func TestDAGInsertMultipleGateways(t *testing.T) {
+	kuard := func(name string) *v1.Service {
+		return &v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "projectsesame",
+			},
+			Spec: v1.ServiceSpec{
+				Ports: []v1.ServicePort{{
+					Name:       "http",
+					Protocol:   "TCP",
+					Port:       8080,
+					TargetPort: intstr.FromInt(8080),
+				}},
+			},
+		}
+	}
+
+	gateway := func(name string) *gatewayapi_v1alpha2.Gateway {
+		return &gatewayapi_v1alpha2.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "projectsesame",
+			},
+			Spec: gatewayapi_v1alpha2.GatewaySpec{
+				GatewayClassName: "test-validClass",
+				Listeners: []gatewayapi_v1alpha2.Listener{{
+					Name:     "http",
+					Port:     80,
+					Protocol: gatewayapi_v1alpha2.HTTPProtocolType,
+					AllowedRoutes: &gatewayapi_v1alpha2.AllowedRoutes{
+						Namespaces: &gatewayapi_v1alpha2.RouteNamespaces{
+							From: gatewayapi.FromNamespacesPtr(gatewayapi_v1alpha2.NamespacesFromAll),
+						},
+					},
+				}},
+			},
+		}
+	}
+
+	route := func(name, gateway, backend string) *gatewayapi_v1alpha2.HTTPRoute {
+		return &gatewayapi_v1alpha2.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "projectsesame",
+			},
+			Spec: gatewayapi_v1alpha2.HTTPRouteSpec{
+				CommonRouteSpec: gatewayapi_v1alpha2.CommonRouteSpec{
+					ParentRefs: []gatewayapi_v1alpha2.ParentRef{gatewayapi.GatewayParentRef("projectsesame", gateway)},
+				},
+				Hostnames: []gatewayapi_v1alpha2.Hostname{
+					"test.projectsesame.io",
+				},
+				Rules: []gatewayapi_v1alpha2.HTTPRouteRule{{
+					Matches:     gatewayapi.HTTPRouteMatch(gatewayapi_v1alpha2.PathMatchPathPrefix, "/"),
+					BackendRefs: gatewayapi.HTTPBackendRef(backend, 8080, 1),
+				}},
+			},
+		}
+	}
+
+	externalService := kuard("external")
+	internalService := kuard("internal")
+
+	tests := map[string]struct {
+		gatewayListeners map[types.NamespacedName]GatewayEnvoyListeners
+		want             []*Listener
+	}{
+		"gateways share the default listener": {
+			want: listeners(
+				&Listener{
+					Name: HTTP_LISTENER_NAME,
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("test.projectsesame.io", prefixrouteHTTPRoute("/", service(internalService))),
+					),
+				},
+			),
+		},
+		"gateway mapped onto its own listener": {
+			gatewayListeners: map[types.NamespacedName]GatewayEnvoyListeners{
+				{Namespace: "projectsesame", Name: "internal"}: {HTTP: "ingress_http_projectsesame_internal"},
+			},
+			want: listeners(
+				&Listener{
+					Name: HTTP_LISTENER_NAME,
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("test.projectsesame.io", prefixrouteHTTPRoute("/", service(externalService))),
+					),
+				},
+				&Listener{
+					Name: "ingress_http_projectsesame_internal",
+					VirtualHosts: virtualhosts(
+						virtualhost("test.projectsesame.io", prefixrouteHTTPRoute("/", service(internalService))),
+					),
+				},
+			),
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					&GatewayAPIProcessor{
+						FieldLogger:      fixture.NewTestLogger(t),
+						GatewayListeners: tc.gatewayListeners,
+					},
+					&ListenerProcessor{},
+				},
+			}
+
+			for _, o := range []interface{}{
+				&gatewayapi_v1alpha2.GatewayClass{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test-validClass",
+					},
+					Spec: gatewayapi_v1alpha2.GatewayClassSpec{
+						ControllerName: "projectsesame.io/sesame",
+					},
+				},
+				gateway("external"),
+				gateway("internal"),
+				externalService,
+				internalService,
+				route("external", "external", "external"),
+				route("internal", "internal", "internal"),
+			} {
+				builder.Source.Insert(o)
+			}
+
+			dag := builder.Build()
+			assert.Equal(t, tc.want, dag.Listeners)
+
+			// Each Gateway has its own status, and each route's
+			// status is for the Gateway it's attached to.
+			assert.Len(t, dag.StatusCache.GetGatewayUpdates(), 2)
+
+			routeGateways := map[string]types.NamespacedName{}
+			for _, u := range dag.StatusCache.GetRouteUpdates() {
+				routeGateways[u.FullName.Name] = u.GatewayRef
+			}
+			assert.Equal(t, map[string]types.NamespacedName{
+				"external": {Namespace: "projectsesame", Name: "external"},
+				"internal": {Namespace: "projectsesame", Name: "internal"},
+			}, routeGateways)
+		})
+	}
+}
+
+func TestDAGInsertGatewayListenerPorts(t *testing.T) {
+	kuard := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kuard",
+			Namespace: "projectsesame",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:       "http",
+				Protocol:   "TCP",
+				Port:       8080,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	listener := func(name string, port gatewayapi_v1alpha2.PortNumber, protocol gatewayapi_v1alpha2.ProtocolType) gatewayapi_v1alpha2.Listener {
+		l := gatewayapi_v1alpha2.Listener{
+			Name:     gatewayapi_v1alpha2.SectionName(name),
+			Port:     port,
+			Protocol: protocol,
+			AllowedRoutes: &gatewayapi_v1alpha2.AllowedRoutes{
+				Namespaces: &gatewayapi_v1alpha2.RouteNamespaces{
+					From: gatewayapi.FromNamespacesPtr(gatewayapi_v1alpha2.NamespacesFromAll),
+				},
+			},
+		}
+		if protocol == gatewayapi_v1alpha2.TLSProtocolType {
+			l.TLS = &gatewayapi_v1alpha2.GatewayTLSConfig{
+				Mode: gatewayapi.TLSModeTypePtr(gatewayapi_v1alpha2.TLSModePassthrough),
+			}
+		}
+		return l
+	}
+
+	route := func(name, sectionName string) *gatewayapi_v1alpha2.HTTPRoute {
+		return &gatewayapi_v1alpha2.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "projectsesame",
+			},
+			Spec: gatewayapi_v1alpha2.HTTPRouteSpec{
+				CommonRouteSpec: gatewayapi_v1alpha2.CommonRouteSpec{
+					ParentRefs: []gatewayapi_v1alpha2.ParentRef{gatewayapi.GatewayListenerParentRef("projectsesame", "gw", sectionName)},
+				},
+				Hostnames: []gatewayapi_v1alpha2.Hostname{
+					gatewayapi_v1alpha2.Hostname(name + ".projectsesame.io"),
+				},
+				Rules: []gatewayapi_v1alpha2.HTTPRouteRule{{
+					Matches:     gatewayapi.HTTPRouteMatch(gatewayapi_v1alpha2.PathMatchPathPrefix, "/"),
+					BackendRefs: gatewayapi.HTTPBackendRef("kuard", 8080, 1),
+				}},
+			},
+		}
+	}
+
+	builder := Builder{
+		Source: KubernetesCache{
+			FieldLogger: fixture.NewTestLogger(t),
+		},
+		Processors: []Processor{
+			&GatewayAPIProcessor{
+				FieldLogger: fixture.NewTestLogger(t),
+				GatewayPortListeners: &GatewayPortListeners{
+					HTTPPort:  8080,
+					HTTPSPort: 8443,
+				},
+			},
+			&ListenerProcessor{},
+		},
+	}
+
+	for _, o := range []interface{}{
+		&gatewayapi_v1alpha2.GatewayClass{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "test-validClass",
+			},
+			Spec: gatewayapi_v1alpha2.GatewayClassSpec{
+				ControllerName: "projectsesame.io/sesame",
+			},
+		},
+		&gatewayapi_v1alpha2.Gateway{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "gw",
+				Namespace: "projectsesame",
+			},
+			Spec: gatewayapi_v1alpha2.GatewaySpec{
+				GatewayClassName: "test-validClass",
+				Listeners: []gatewayapi_v1alpha2.Listener{
+					listener("http", 80, gatewayapi_v1alpha2.HTTPProtocolType),
+					listener("http-alt", 8081, gatewayapi_v1alpha2.HTTPProtocolType),
+					listener("tls-alt", 8081, gatewayapi_v1alpha2.TLSProtocolType),
+					listener("http-secure", 443, gatewayapi_v1alpha2.HTTPProtocolType),
+				},
+			},
+		},
+		kuard,
+		route("default", "http"),
+		route("alt", "http-alt"),
+	} {
+		builder.Source.Insert(o)
+	}
+
+	dag := builder.Build()
+
+	// Port 80 is served by the default HTTP listener, which binds to
+	// port 8080, and port 8081 by a listener of its own.
+	assert.Equal(t, listeners(
+		&Listener{
+			Name: HTTP_LISTENER_NAME,
+			Port: 80,
+			VirtualHosts: virtualhosts(
+				virtualhost("default.projectsesame.io", prefixrouteHTTPRoute("/", service(kuard))),
+			),
+		},
+		&Listener{
+			Name: "ingress_http_8081",
+			Port: 8081,
+			VirtualHosts: virtualhosts(
+				virtualhost("alt.projectsesame.io", prefixrouteHTTPRoute("/", service(kuard))),
+			),
+		},
+	), dag.Listeners)
+
+	// Listeners on a port that an Envoy listener for
+	// another protocol serves are rejected.
+	updates := dag.StatusCache.GetGatewayUpdates()
+	assert.Len(t, updates, 1)
+
+	reasons := map[string][]string{}
+	for name, listenerStatus := range updates[0].ListenerStatus {
+		for _, cond := range listenerStatus.Conditions {
+			reasons[name] = append(reasons[name], cond.Reason)
+		}
+	}
+	assert.Equal(t, map[string][]string{
+		"http":        {string(gatewayapi_v1alpha2.ListenerReasonReady)},
+		"http-alt":    {string(gatewayapi_v1alpha2.ListenerReasonReady)},
+		"tls-alt":     {string(gatewayapi_v1alpha2.ListenerReasonProtocolConflict), string(gatewayapi_v1alpha2.ListenerReasonInvalid)},
+		"http-secure": {string(gatewayapi_v1alpha2.ListenerReasonProtocolConflict), string(gatewayapi_v1alpha2.ListenerReasonInvalid)},
+	}, reasons)
+}
+
 func TestDAGInsert(t *testing.T) {
 	// The DAG is insensitive to ordering, adding an ingress, then a service,
 	// should have the same result as adding a service, then an ingress.
@@ -7222,6 +7581,56 @@ func TestDAGInsert(t *testing.T) {
 		},
 	}
 
+	connectionPolicyService := &sesame_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: sesame_api_v1.HTTPProxySpec{
+			VirtualHost: &sesame_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []sesame_api_v1.Route{{
+				Conditions: []sesame_api_v1.MatchCondition{{
+					Prefix: "/foo",
+				}},
+				Services: []sesame_api_v1.Service{{
+					Name: "nginx",
+					Port: 80,
+					ConnectionPolicy: &sesame_api_v1.ConnectionPolicy{
+						MaxConnections:           100,
+						MaxRequestsPerConnection: 1000,
+						IdleTimeout:              "30s",
+					},
+				}},
+			}},
+		},
+	}
+
+	connectionPolicyInvalid := &sesame_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "example-com",
+			Namespace: "default",
+		},
+		Spec: sesame_api_v1.HTTPProxySpec{
+			VirtualHost: &sesame_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []sesame_api_v1.Route{{
+				Conditions: []sesame_api_v1.MatchCondition{{
+					Prefix: "/foo",
+				}},
+				Services: []sesame_api_v1.Service{{
+					Name: "nginx",
+					Port: 80,
+					ConnectionPolicy: &sesame_api_v1.ConnectionPolicy{
+						IdleTimeout: "bogus",
+					},
+				}},
+			}},
+		},
+	}
+
 	cookieRewritePoliciesService := &sesame_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      "example-com",
@@ -10569,6 +10978,40 @@ func TestDAGInsert(t *testing.T) {
 				},
 			),
 		},
+		"insert proxy with connection policy on service": {
+			objs: []interface{}{
+				connectionPolicyService,
+				s9,
+			},
+			want: listeners(
+				&Listener{
+					Name: HTTP_LISTENER_NAME,
+					Port: 80,
+					VirtualHosts: virtualhosts(
+						virtualhost("example.com", &Route{
+							PathMatchCondition: prefixString("/foo"),
+							Clusters: []*Cluster{
+								{
+									Upstream: service(s9),
+									ConnectionPolicy: &ConnectionPolicy{
+										MaxConnections:           100,
+										MaxRequestsPerConnection: 1000,
+										IdleTimeout:              timeout.DurationSetting(30 * time.Second),
+									},
+								},
+							},
+						}),
+					),
+				},
+			),
+		},
+		"insert proxy with invalid connection policy on service": {
+			objs: []interface{}{
+				connectionPolicyInvalid,
+				s9,
+			},
+			want: listeners(),
+		},
 		"insert proxy with duplicate cookie rewrite policies on route": {
 			objs: []interface{}{
 				duplicateCookieRewritePoliciesRoute,
@@ -12393,7 +12836,8 @@ func clustermap(services ...*v1.Service) []*Cluster {
 
 func secret(s *v1.Secret) *Secret {
 	return &Secret{
-		Object: s,
+		Object:      s,
+		Certificate: certificateInfo(s),
 	}
 }
 
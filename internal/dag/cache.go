@@ -27,6 +27,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	networking_v1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/tools/cache"
@@ -53,15 +54,18 @@ type KubernetesCache struct {
 	ingressclass              *networking_v1.IngressClass
 	httpproxies               map[types.NamespacedName]*sesame_api_v1.HTTPProxy
 	secrets                   map[types.NamespacedName]*v1.Secret
+	configmaps                map[types.NamespacedName]*v1.ConfigMap
 	tlscertificatedelegations map[types.NamespacedName]*sesame_api_v1.TLSCertificateDelegation
 	services                  map[types.NamespacedName]*v1.Service
 	namespaces                map[string]*v1.Namespace
 	gatewayclass              *gatewayapi_v1alpha2.GatewayClass
-	gateway                   *gatewayapi_v1alpha2.Gateway
+	gateways                  map[types.NamespacedName]*gatewayapi_v1alpha2.Gateway
 	httproutes                map[types.NamespacedName]*gatewayapi_v1alpha2.HTTPRoute
 	tlsroutes                 map[types.NamespacedName]*gatewayapi_v1alpha2.TLSRoute
 	referencepolicies         map[types.NamespacedName]*gatewayapi_v1alpha2.ReferencePolicy
 	extensions                map[types.NamespacedName]*sesame_api_v1alpha1.ExtensionService
+	gatewayclassparameters    map[types.NamespacedName]*sesame_api_v1alpha1.GatewayClassParameters
+	servicereferencegrants    map[types.NamespacedName]*sesame_api_v1alpha1.ServiceReferenceGrant
 
 	initialize sync.Once
 
@@ -73,13 +77,17 @@ func (kc *KubernetesCache) init() {
 	kc.ingresses = make(map[types.NamespacedName]*networking_v1.Ingress)
 	kc.httpproxies = make(map[types.NamespacedName]*sesame_api_v1.HTTPProxy)
 	kc.secrets = make(map[types.NamespacedName]*v1.Secret)
+	kc.configmaps = make(map[types.NamespacedName]*v1.ConfigMap)
 	kc.tlscertificatedelegations = make(map[types.NamespacedName]*sesame_api_v1.TLSCertificateDelegation)
 	kc.services = make(map[types.NamespacedName]*v1.Service)
 	kc.namespaces = make(map[string]*v1.Namespace)
+	kc.gateways = make(map[types.NamespacedName]*gatewayapi_v1alpha2.Gateway)
 	kc.httproutes = make(map[types.NamespacedName]*gatewayapi_v1alpha2.HTTPRoute)
 	kc.referencepolicies = make(map[types.NamespacedName]*gatewayapi_v1alpha2.ReferencePolicy)
 	kc.tlsroutes = make(map[types.NamespacedName]*gatewayapi_v1alpha2.TLSRoute)
 	kc.extensions = make(map[types.NamespacedName]*sesame_api_v1alpha1.ExtensionService)
+	kc.gatewayclassparameters = make(map[types.NamespacedName]*sesame_api_v1alpha1.GatewayClassParameters)
+	kc.servicereferencegrants = make(map[types.NamespacedName]*sesame_api_v1alpha1.ServiceReferenceGrant)
 }
 
 // matchesIngressClass returns true if the given IngressClass
@@ -142,6 +150,9 @@ func (kc *KubernetesCache) Insert(obj interface{}) bool {
 
 		kc.secrets[k8s.NamespacedNameOf(obj)] = obj
 		return kc.secretTriggersRebuild(obj)
+	case *v1.ConfigMap:
+		kc.configmaps[k8s.NamespacedNameOf(obj)] = obj
+		return kc.configMapTriggersRebuild(obj)
 	case *v1.Service:
 		kc.services[k8s.NamespacedNameOf(obj)] = obj
 		return kc.serviceTriggersRebuild(obj)
@@ -190,7 +201,7 @@ func (kc *KubernetesCache) Insert(obj interface{}) bool {
 		kc.gatewayclass = obj
 		return true
 	case *gatewayapi_v1alpha2.Gateway:
-		kc.gateway = obj
+		kc.gateways[k8s.NamespacedNameOf(obj)] = obj
 		return true
 	case *gatewayapi_v1alpha2.HTTPRoute:
 		kc.httproutes[k8s.NamespacedNameOf(obj)] = obj
@@ -204,6 +215,12 @@ func (kc *KubernetesCache) Insert(obj interface{}) bool {
 	case *sesame_api_v1alpha1.ExtensionService:
 		kc.extensions[k8s.NamespacedNameOf(obj)] = obj
 		return true
+	case *sesame_api_v1alpha1.GatewayClassParameters:
+		kc.gatewayclassparameters[k8s.NamespacedNameOf(obj)] = obj
+		return true
+	case *sesame_api_v1alpha1.ServiceReferenceGrant:
+		kc.servicereferencegrants[k8s.NamespacedNameOf(obj)] = obj
+		return true
 	case *sesame_api_v1alpha1.SesameConfiguration:
 		return false
 	default:
@@ -235,6 +252,11 @@ func (kc *KubernetesCache) remove(obj interface{}) bool {
 		_, ok := kc.secrets[m]
 		delete(kc.secrets, m)
 		return ok
+	case *v1.ConfigMap:
+		m := k8s.NamespacedNameOf(obj)
+		_, ok := kc.configmaps[m]
+		delete(kc.configmaps, m)
+		return ok && kc.configMapTriggersRebuild(obj)
 	case *v1.Service:
 		m := k8s.NamespacedNameOf(obj)
 		_, ok := kc.services[m]
@@ -269,8 +291,10 @@ func (kc *KubernetesCache) remove(obj interface{}) bool {
 		kc.gatewayclass = nil
 		return true
 	case *gatewayapi_v1alpha2.Gateway:
-		kc.gateway = nil
-		return true
+		m := k8s.NamespacedNameOf(obj)
+		_, ok := kc.gateways[m]
+		delete(kc.gateways, m)
+		return ok
 	case *gatewayapi_v1alpha2.HTTPRoute:
 		m := k8s.NamespacedNameOf(obj)
 		_, ok := kc.httproutes[m]
@@ -291,6 +315,16 @@ func (kc *KubernetesCache) remove(obj interface{}) bool {
 		_, ok := kc.extensions[m]
 		delete(kc.extensions, m)
 		return ok
+	case *sesame_api_v1alpha1.GatewayClassParameters:
+		m := k8s.NamespacedNameOf(obj)
+		_, ok := kc.gatewayclassparameters[m]
+		delete(kc.gatewayclassparameters, m)
+		return ok
+	case *sesame_api_v1alpha1.ServiceReferenceGrant:
+		m := k8s.NamespacedNameOf(obj)
+		_, ok := kc.servicereferencegrants[m]
+		delete(kc.servicereferencegrants, m)
+		return ok
 	case *sesame_api_v1alpha1.SesameConfiguration:
 		return false
 	default:
@@ -327,19 +361,16 @@ func (kc *KubernetesCache) serviceTriggersRebuild(service *v1.Service) bool {
 	}
 
 	for _, proxy := range kc.httpproxies {
-		if proxy.Namespace != service.Namespace {
-			continue
-		}
 		for _, route := range proxy.Spec.Routes {
 			for _, s := range route.Services {
-				if s.Name == service.Name {
+				if isProxyRefToService(s, service, proxy.Namespace) {
 					return true
 				}
 			}
 		}
 		if tcpproxy := proxy.Spec.TCPProxy; tcpproxy != nil {
 			for _, s := range tcpproxy.Services {
-				if s.Name == service.Name {
+				if isProxyRefToService(s, service, proxy.Namespace) {
 					return true
 				}
 			}
@@ -359,6 +390,12 @@ func (kc *KubernetesCache) serviceTriggersRebuild(service *v1.Service) bool {
 	return false
 }
 
+func isProxyRefToService(ref sesame_api_v1.Service, service *v1.Service, proxyNamespace string) bool {
+	return ref.ExtensionService == nil &&
+		ref.Name == service.Name &&
+		stringOrDefault(ref.Namespace, proxyNamespace) == service.Namespace
+}
+
 func isRefToService(ref gatewayapi_v1alpha2.BackendObjectReference, service *v1.Service, routeNamespace string) bool {
 	return ref.Group != nil && *ref.Group == "" &&
 		ref.Kind != nil && *ref.Kind == "Service" &&
@@ -379,16 +416,7 @@ func (kc *KubernetesCache) secretTriggersRebuild(secret *v1.Secret) bool {
 		return true
 	}
 
-	delegations := make(map[string]bool) // targetnamespace/secretname to bool
-
-	// TODO(youngnick): Check if this is required.
-	for _, d := range kc.tlscertificatedelegations {
-		for _, cd := range d.Spec.Delegations {
-			for _, n := range cd.TargetNamespaces {
-				delegations[n+"/"+cd.SecretName] = true
-			}
-		}
-	}
+	secretName := k8s.NamespacedNameOf(secret)
 
 	for _, ingress := range kc.ingresses {
 		if ingress.Namespace == secret.Namespace {
@@ -398,15 +426,7 @@ func (kc *KubernetesCache) secretTriggersRebuild(secret *v1.Secret) bool {
 				}
 			}
 		}
-		if delegations[ingress.Namespace+"/"+secret.Name] {
-			for _, tls := range ingress.Spec.TLS {
-				if tls.SecretName == secret.Namespace+"/"+secret.Name {
-					return true
-				}
-			}
-		}
-
-		if delegations["*/"+secret.Name] {
+		if kc.DelegationPermitted(secretName, ingress.Namespace) {
 			for _, tls := range ingress.Spec.TLS {
 				if tls.SecretName == secret.Namespace+"/"+secret.Name {
 					return true
@@ -430,12 +450,7 @@ func (kc *KubernetesCache) secretTriggersRebuild(secret *v1.Secret) bool {
 		if proxy.Namespace == secret.Namespace && tls.SecretName == secret.Name {
 			return true
 		}
-		if delegations[proxy.Namespace+"/"+secret.Name] {
-			if tls.SecretName == secret.Namespace+"/"+secret.Name {
-				return true
-			}
-		}
-		if delegations["*/"+secret.Name] {
+		if kc.DelegationPermitted(secretName, proxy.Namespace) {
 			if tls.SecretName == secret.Namespace+"/"+secret.Name {
 				return true
 			}
@@ -449,14 +464,14 @@ func (kc *KubernetesCache) secretTriggersRebuild(secret *v1.Secret) bool {
 		}
 	}
 
-	if kc.gateway != nil {
-		for _, listener := range kc.gateway.Spec.Listeners {
+	for _, gateway := range kc.gateways {
+		for _, listener := range gateway.Spec.Listeners {
 			if listener.TLS == nil {
 				continue
 			}
 
 			for _, certificateRef := range listener.TLS.CertificateRefs {
-				if isRefToSecret(*certificateRef, secret, kc.gateway.Namespace) {
+				if isRefToSecret(*certificateRef, secret, gateway.Namespace) {
 					return true
 				}
 			}
@@ -466,6 +481,29 @@ func (kc *KubernetesCache) secretTriggersRebuild(secret *v1.Secret) bool {
 	return false
 }
 
+// configMapTriggersRebuild returns true if the ConfigMap holds an
+// error page of an HTTPProxy's local reply policy.
+func (kc *KubernetesCache) configMapTriggersRebuild(configMap *v1.ConfigMap) bool {
+	for _, proxy := range kc.httpproxies {
+		if proxy.Namespace != configMap.Namespace {
+			continue
+		}
+
+		vh := proxy.Spec.VirtualHost
+		if vh == nil || vh.LocalReplyPolicy == nil {
+			continue
+		}
+
+		for _, page := range vh.LocalReplyPolicy.ErrorPages {
+			if page.ConfigMap == configMap.Name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 func isRefToSecret(ref gatewayapi_v1alpha2.SecretObjectReference, secret *v1.Secret, gatewayNamespace string) bool {
 	return ref.Group != nil && *ref.Group == "" &&
 		ref.Kind != nil && *ref.Kind == "Secret" &&
@@ -473,6 +511,27 @@ func isRefToSecret(ref gatewayapi_v1alpha2.SecretObjectReference, secret *v1.Sec
 		string(ref.Name) == secret.Name
 }
 
+// LookupGatewayEnvoyConfig returns the Envoy settings of the
+// GatewayClassParameters the GatewayClass refers to, or nil if
+// it doesn't refer to any or they are not in the cache.
+func (kc *KubernetesCache) LookupGatewayEnvoyConfig() *sesame_api_v1alpha1.GatewayEnvoyConfig {
+	if kc.gatewayclass == nil {
+		return nil
+	}
+
+	ref, ok := k8s.GatewayClassParametersRef(kc.gatewayclass)
+	if !ok {
+		return nil
+	}
+
+	params, ok := kc.gatewayclassparameters[ref]
+	if !ok {
+		return nil
+	}
+
+	return params.Spec.EnvoyConfig
+}
+
 // LookupSecret returns a Secret if present or nil if the underlying kubernetes
 // secret fails validation or is missing.
 func (kc *KubernetesCache) LookupSecret(name types.NamespacedName, validate func(*v1.Secret) error) (*Secret, error) {
@@ -486,12 +545,29 @@ func (kc *KubernetesCache) LookupSecret(name types.NamespacedName, validate func
 	}
 
 	s := &Secret{
-		Object: sec,
+		Object:      sec,
+		Certificate: certificateInfo(sec),
 	}
 
 	return s, nil
 }
 
+// LookupConfigMapKey returns the value of the given key of the
+// named ConfigMap.
+func (kc *KubernetesCache) LookupConfigMapKey(name types.NamespacedName, key string) (string, error) {
+	cm, ok := kc.configmaps[name]
+	if !ok {
+		return "", fmt.Errorf("ConfigMap not found")
+	}
+
+	value, ok := cm.Data[key]
+	if !ok {
+		return "", fmt.Errorf("ConfigMap has no %q key", key)
+	}
+
+	return value, nil
+}
+
 func (kc *KubernetesCache) LookupUpstreamValidation(uv *sesame_api_v1.UpstreamValidation, caCertificate types.NamespacedName) (*PeerValidationContext, error) {
 	if uv == nil {
 		// no upstream validation requested, nothing to do
@@ -518,36 +594,120 @@ func (kc *KubernetesCache) LookupUpstreamValidation(uv *sesame_api_v1.UpstreamVa
 // DelegationPermitted returns true if the referenced secret has been delegated
 // to the namespace where the ingress object is located.
 func (kc *KubernetesCache) DelegationPermitted(secret types.NamespacedName, targetNamespace string) bool {
-	contains := func(haystack []string, needle string) bool {
-		if len(haystack) == 1 && haystack[0] == "*" {
+	if secret.Namespace == targetNamespace {
+		// secret is in the same namespace as target
+		return true
+	}
+
+	return len(kc.delegationsFor(secret, targetNamespace)) > 0
+}
+
+// ServiceReferencePermitted returns true if HTTPProxies in fromNamespace
+// may route traffic to the backend of the given kind, either because it
+// is in the same namespace or because a ServiceReferenceGrant in the
+// backend's namespace permits it.
+func (kc *KubernetesCache) ServiceReferencePermitted(kind string, backend types.NamespacedName, fromNamespace string) bool {
+	if backend.Namespace == fromNamespace {
+		return true
+	}
+
+	for _, grant := range kc.servicereferencegrants {
+		if grant.Namespace != backend.Namespace {
+			continue
+		}
+
+		if grantsFrom(grant.Spec.From, fromNamespace) && grantsTo(grant.Spec.To, kind, backend.Name) {
 			return true
 		}
-		for _, h := range haystack {
-			if h == needle {
-				return true
-			}
+	}
+
+	return false
+}
+
+func grantsFrom(from []sesame_api_v1alpha1.ServiceReferenceGrantFrom, namespace string) bool {
+	for _, f := range from {
+		if f.Namespace == "*" || f.Namespace == namespace {
+			return true
 		}
-		return false
 	}
+	return false
+}
 
-	if secret.Namespace == targetNamespace {
-		// secret is in the same namespace as target
-		return true
+func grantsTo(to []sesame_api_v1alpha1.ServiceReferenceGrantTo, kind, name string) bool {
+	for _, t := range to {
+		if t.Kind == kind && (t.Name == "" || t.Name == name) {
+			return true
+		}
 	}
+	return false
+}
+
+// delegationsFor returns the TLSCertificateDelegations that delegate
+// the referenced secret to targetNamespace.
+func (kc *KubernetesCache) delegationsFor(secret types.NamespacedName, targetNamespace string) []*sesame_api_v1.TLSCertificateDelegation {
+	var delegations []*sesame_api_v1.TLSCertificateDelegation
 
 	for _, d := range kc.tlscertificatedelegations {
 		if d.Namespace != secret.Namespace {
 			continue
 		}
-		for _, d := range d.Spec.Delegations {
-			if contains(d.TargetNamespaces, targetNamespace) {
-				if secret.Name == d.SecretName {
-					return true
-				}
+		for _, cd := range d.Spec.Delegations {
+			if kc.delegatesSecret(cd, secret) && kc.delegatesToNamespace(cd, targetNamespace) {
+				delegations = append(delegations, d)
+				break
 			}
 		}
 	}
-	return false
+
+	return delegations
+}
+
+// delegatesSecret returns true if the CertificateDelegation names the
+// referenced secret, or selects it by its labels.
+func (kc *KubernetesCache) delegatesSecret(cd sesame_api_v1.CertificateDelegation, secret types.NamespacedName) bool {
+	if cd.SecretName == secret.Name {
+		return true
+	}
+	if cd.SecretSelector == nil {
+		return false
+	}
+
+	s, ok := kc.secrets[secret]
+	if !ok {
+		return false
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(cd.SecretSelector)
+	if err != nil {
+		return false
+	}
+
+	return selector.Matches(labels.Set(s.Labels))
+}
+
+// delegatesToNamespace returns true if the CertificateDelegation lists
+// the target namespace, or selects it by its labels.
+func (kc *KubernetesCache) delegatesToNamespace(cd sesame_api_v1.CertificateDelegation, targetNamespace string) bool {
+	for _, n := range cd.TargetNamespaces {
+		if n == "*" || n == targetNamespace {
+			return true
+		}
+	}
+	if cd.TargetNamespaceSelector == nil {
+		return false
+	}
+
+	ns, ok := kc.namespaces[targetNamespace]
+	if !ok {
+		return false
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(cd.TargetNamespaceSelector)
+	if err != nil {
+		return false
+	}
+
+	return selector.Matches(labels.Set(ns.Labels))
 }
 
 func validCA(s *v1.Secret) error {
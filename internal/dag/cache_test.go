@@ -50,6 +50,58 @@ func TestKubernetesCacheInsert(t *testing.T) {
 			},
 			want: false,
 		},
+		"insert configmap": {
+			obj: &v1.ConfigMap{
+				ObjectMeta: fixture.ObjectMeta("default/error-pages"),
+			},
+			want: false,
+		},
+		"insert configmap referenced by httpproxy local reply policy": {
+			pre: []interface{}{
+				&sesame_api_v1.HTTPProxy{
+					ObjectMeta: fixture.ObjectMeta("default/proxy"),
+					Spec: sesame_api_v1.HTTPProxySpec{
+						VirtualHost: &sesame_api_v1.VirtualHost{
+							Fqdn: "example.com",
+							LocalReplyPolicy: &sesame_api_v1.LocalReplyPolicy{
+								ErrorPages: []sesame_api_v1.ErrorPage{{
+									StatusCode: 503,
+									ConfigMap:  "error-pages",
+									Key:        "503.html",
+								}},
+							},
+						},
+					},
+				},
+			},
+			obj: &v1.ConfigMap{
+				ObjectMeta: fixture.ObjectMeta("default/error-pages"),
+			},
+			want: true,
+		},
+		"insert configmap in another namespace than httpproxy local reply policy": {
+			pre: []interface{}{
+				&sesame_api_v1.HTTPProxy{
+					ObjectMeta: fixture.ObjectMeta("other/proxy"),
+					Spec: sesame_api_v1.HTTPProxySpec{
+						VirtualHost: &sesame_api_v1.VirtualHost{
+							Fqdn: "example.com",
+							LocalReplyPolicy: &sesame_api_v1.LocalReplyPolicy{
+								ErrorPages: []sesame_api_v1.ErrorPage{{
+									StatusCode: 503,
+									ConfigMap:  "error-pages",
+									Key:        "503.html",
+								}},
+							},
+						},
+					},
+				},
+			},
+			obj: &v1.ConfigMap{
+				ObjectMeta: fixture.ObjectMeta("default/error-pages"),
+			},
+			want: false,
+		},
 		"insert secret w/ blank ca.crt": {
 			obj: &v1.Secret{
 				ObjectMeta: metav1.ObjectMeta{
@@ -901,6 +953,18 @@ func TestKubernetesCacheInsert(t *testing.T) {
 			},
 			want: true,
 		},
+		"insert service reference grant": {
+			obj: &sesame_api_v1alpha1.ServiceReferenceGrant{
+				ObjectMeta: fixture.ObjectMeta("default/grant"),
+			},
+			want: true,
+		},
+		"insert gatewayclass parameters": {
+			obj: &sesame_api_v1alpha1.GatewayClassParameters{
+				ObjectMeta: fixture.ObjectMeta("projectsesame/params"),
+			},
+			want: true,
+		},
 		"insert secret that is referred by configuration file": {
 			obj: &v1.Secret{
 				ObjectMeta: metav1.ObjectMeta{
@@ -1138,6 +1202,21 @@ func TestKubernetesCacheRemove(t *testing.T) {
 			},
 			want: true,
 		},
+		"remove gateway-api Gateway not in cache": {
+			cache: cache(&gatewayapi_v1alpha2.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "external",
+					Namespace: "projectsesame",
+				},
+			}),
+			obj: &gatewayapi_v1alpha2.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "internal",
+					Namespace: "projectsesame",
+				},
+			},
+			want: false,
+		},
 		"remove gateway-api HTTPRoute": {
 			cache: cache(&gatewayapi_v1alpha2.HTTPRoute{
 				ObjectMeta: metav1.ObjectMeta{
@@ -1192,6 +1271,33 @@ func TestKubernetesCacheRemove(t *testing.T) {
 			},
 			want: true,
 		},
+		"remove unreferenced configmap": {
+			cache: cache(&v1.ConfigMap{
+				ObjectMeta: fixture.ObjectMeta("default/error-pages"),
+			}),
+			obj: &v1.ConfigMap{
+				ObjectMeta: fixture.ObjectMeta("default/error-pages"),
+			},
+			want: false,
+		},
+		"remove service reference grant": {
+			cache: cache(&sesame_api_v1alpha1.ServiceReferenceGrant{
+				ObjectMeta: fixture.ObjectMeta("default/grant"),
+			}),
+			obj: &sesame_api_v1alpha1.ServiceReferenceGrant{
+				ObjectMeta: fixture.ObjectMeta("default/grant"),
+			},
+			want: true,
+		},
+		"remove gatewayclass parameters": {
+			cache: cache(&sesame_api_v1alpha1.GatewayClassParameters{
+				ObjectMeta: fixture.ObjectMeta("projectsesame/params"),
+			}),
+			obj: &sesame_api_v1alpha1.GatewayClassParameters{
+				ObjectMeta: fixture.ObjectMeta("projectsesame/params"),
+			},
+			want: true,
+		},
 		"remove unknown": {
 			cache: cache("not an object"),
 			obj:   "not an object",
@@ -1207,6 +1313,77 @@ func TestKubernetesCacheRemove(t *testing.T) {
 	}
 }
 
+func TestLookupGatewayEnvoyConfig(t *testing.T) {
+	envoyConfig := &sesame_api_v1alpha1.GatewayEnvoyConfig{
+		AccessLogFormat: sesame_api_v1alpha1.JSONAccessLog,
+	}
+	params := &sesame_api_v1alpha1.GatewayClassParameters{
+		ObjectMeta: fixture.ObjectMeta("projectsesame/params"),
+		Spec: sesame_api_v1alpha1.GatewayClassParametersSpec{
+			EnvoyConfig: envoyConfig,
+		},
+	}
+	gatewayClass := func(ref *gatewayapi_v1alpha2.ParametersReference) *gatewayapi_v1alpha2.GatewayClass {
+		return &gatewayapi_v1alpha2.GatewayClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "sesame"},
+			Spec: gatewayapi_v1alpha2.GatewayClassSpec{
+				ControllerName: "projectsesame.io/gateway-controller",
+				ParametersRef:  ref,
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		objs []interface{}
+		want *sesame_api_v1alpha1.GatewayEnvoyConfig
+	}{
+		"no gatewayclass": {
+			objs: []interface{}{params},
+		},
+		"gatewayclass without parametersRef": {
+			objs: []interface{}{params, gatewayClass(nil)},
+		},
+		"gatewayclass refers to parameters": {
+			objs: []interface{}{params, gatewayClass(&gatewayapi_v1alpha2.ParametersReference{
+				Group:     "projectsesame.io",
+				Kind:      "GatewayClassParameters",
+				Name:      "params",
+				Namespace: gatewayapi.NamespacePtr("projectsesame"),
+			})},
+			want: envoyConfig,
+		},
+		"gatewayclass refers to missing parameters": {
+			objs: []interface{}{gatewayClass(&gatewayapi_v1alpha2.ParametersReference{
+				Group:     "projectsesame.io",
+				Kind:      "GatewayClassParameters",
+				Name:      "params",
+				Namespace: gatewayapi.NamespacePtr("projectsesame"),
+			})},
+		},
+		"gatewayclass refers to another kind": {
+			objs: []interface{}{params, gatewayClass(&gatewayapi_v1alpha2.ParametersReference{
+				Group:     "",
+				Kind:      "ConfigMap",
+				Name:      "params",
+				Namespace: gatewayapi.NamespacePtr("projectsesame"),
+			})},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cache := KubernetesCache{
+				FieldLogger: fixture.NewTestLogger(t),
+			}
+			for _, o := range tc.objs {
+				cache.Insert(o)
+			}
+
+			assert.Equal(t, tc.want, cache.LookupGatewayEnvoyConfig())
+		})
+	}
+}
+
 func TestLookupService(t *testing.T) {
 	cache := func(objs ...interface{}) *KubernetesCache {
 		cache := KubernetesCache{
@@ -1387,6 +1564,12 @@ func TestServiceTriggersRebuild(t *testing.T) {
 		}
 	}
 
+	httpProxyNamespaced := func(namespace, serviceNamespace, name string) *sesame_api_v1.HTTPProxy {
+		proxy := httpProxy(namespace, name)
+		proxy.Spec.Routes[0].Services[0].Namespace = serviceNamespace
+		return proxy
+	}
+
 	tcpProxy := func(namespace, name string) *sesame_api_v1.HTTPProxy {
 		return &sesame_api_v1.HTTPProxy{
 			ObjectMeta: metav1.ObjectMeta{
@@ -1477,6 +1660,22 @@ func TestServiceTriggersRebuild(t *testing.T) {
 			svc:  service("default", "service-1"),
 			want: false,
 		},
+		"httpproxy refers to service in another namespace": {
+			cache: cache(
+				service("default", "service-1"),
+				httpProxyNamespaced("user", "default", "service-1"),
+			),
+			svc:  service("default", "service-1"),
+			want: true,
+		},
+		"httpproxy refers to service in a different namespace": {
+			cache: cache(
+				service("default", "service-1"),
+				httpProxyNamespaced("user", "other", "service-1"),
+			),
+			svc:  service("default", "service-1"),
+			want: false,
+		},
 		"tcproxy exists in same namespace as service": {
 			cache: cache(
 				service("default", "service-1"),
@@ -1518,6 +1717,101 @@ func TestServiceTriggersRebuild(t *testing.T) {
 	}
 }
 
+func TestServiceReferencePermitted(t *testing.T) {
+	grant := func(namespace string, from []string, to ...sesame_api_v1alpha1.ServiceReferenceGrantTo) *sesame_api_v1alpha1.ServiceReferenceGrant {
+		g := &sesame_api_v1alpha1.ServiceReferenceGrant{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "grant",
+				Namespace: namespace,
+			},
+			Spec: sesame_api_v1alpha1.ServiceReferenceGrantSpec{
+				To: to,
+			},
+		}
+		for _, ns := range from {
+			g.Spec.From = append(g.Spec.From, sesame_api_v1alpha1.ServiceReferenceGrantFrom{Namespace: ns})
+		}
+		return g
+	}
+
+	services := sesame_api_v1alpha1.ServiceReferenceGrantTo{Kind: sesame_api_v1alpha1.KindService}
+	backend := types.NamespacedName{Namespace: "backends", Name: "kuard"}
+
+	tests := map[string]struct {
+		grants        []interface{}
+		kind          string
+		fromNamespace string
+		want          bool
+	}{
+		"same namespace needs no grant": {
+			kind:          sesame_api_v1alpha1.KindService,
+			fromNamespace: "backends",
+			want:          true,
+		},
+		"other namespace without a grant": {
+			kind:          sesame_api_v1alpha1.KindService,
+			fromNamespace: "frontends",
+			want:          false,
+		},
+		"grant from namespace to all services": {
+			grants:        []interface{}{grant("backends", []string{"frontends"}, services)},
+			kind:          sesame_api_v1alpha1.KindService,
+			fromNamespace: "frontends",
+			want:          true,
+		},
+		"grant from all namespaces": {
+			grants:        []interface{}{grant("backends", []string{"*"}, services)},
+			kind:          sesame_api_v1alpha1.KindService,
+			fromNamespace: "frontends",
+			want:          true,
+		},
+		"grant from a different namespace": {
+			grants:        []interface{}{grant("backends", []string{"marketing"}, services)},
+			kind:          sesame_api_v1alpha1.KindService,
+			fromNamespace: "frontends",
+			want:          false,
+		},
+		"grant in a different namespace": {
+			grants:        []interface{}{grant("frontends", []string{"frontends"}, services)},
+			kind:          sesame_api_v1alpha1.KindService,
+			fromNamespace: "frontends",
+			want:          false,
+		},
+		"grant to the named service": {
+			grants: []interface{}{grant("backends", []string{"frontends"},
+				sesame_api_v1alpha1.ServiceReferenceGrantTo{Kind: sesame_api_v1alpha1.KindService, Name: "kuard"})},
+			kind:          sesame_api_v1alpha1.KindService,
+			fromNamespace: "frontends",
+			want:          true,
+		},
+		"grant to a different service": {
+			grants: []interface{}{grant("backends", []string{"frontends"},
+				sesame_api_v1alpha1.ServiceReferenceGrantTo{Kind: sesame_api_v1alpha1.KindService, Name: "other"})},
+			kind:          sesame_api_v1alpha1.KindService,
+			fromNamespace: "frontends",
+			want:          false,
+		},
+		"grant to a different kind": {
+			grants:        []interface{}{grant("backends", []string{"frontends"}, services)},
+			kind:          sesame_api_v1alpha1.KindExtensionService,
+			fromNamespace: "frontends",
+			want:          false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			cache := KubernetesCache{
+				FieldLogger: fixture.NewTestLogger(t),
+			}
+			for _, g := range tc.grants {
+				cache.Insert(g)
+			}
+			assert.Equal(t, tc.want, cache.ServiceReferencePermitted(tc.kind, backend, tc.fromNamespace))
+		})
+	}
+}
+
 func TestSecretTriggersRebuild(t *testing.T) {
 
 	secret := func(namespace, name string) *v1.Secret {
@@ -1635,6 +1929,52 @@ func TestSecretTriggersRebuild(t *testing.T) {
 			secret: secret("default", "tlscert"),
 			want:   true,
 		},
+		"ingress with secret delegated by label selector triggers rebuild": {
+			cache: cache(
+				&v1.Namespace{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:   "user",
+						Labels: map[string]string{"team": "user"},
+					},
+				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "tlscert",
+						Namespace: "default",
+						Labels:    map[string]string{"shared": "true"},
+					},
+					Type: v1.SecretTypeTLS,
+					Data: secretdata(fixture.CERTIFICATE, fixture.RSA_PRIVATE_KEY),
+				},
+				&sesame_api_v1.TLSCertificateDelegation{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "delegation",
+						Namespace: "default",
+					},
+					Spec: sesame_api_v1.TLSCertificateDelegationSpec{
+						Delegations: []sesame_api_v1.CertificateDelegation{{
+							SecretSelector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{"shared": "true"},
+							},
+							TargetNamespaceSelector: &metav1.LabelSelector{
+								MatchLabels: map[string]string{"team": "user"},
+							},
+						}},
+					},
+				},
+				ingress("user", "ingress", "default/tlscert"),
+			),
+			secret: secret("default", "tlscert"),
+			want:   true,
+		},
+		"ingress with delegated secret (other namespace) does not trigger rebuild": {
+			cache: cache(
+				tlsCertificateDelegation("default", "tlscert", "other"),
+				ingress("user", "ingress", "default/tlscert"),
+			),
+			secret: secret("default", "tlscert"),
+			want:   false,
+		},
 		"httpproxy empty vhost does not trigger rebuild": {
 			cache: cache(
 				&sesame_api_v1.HTTPProxy{
@@ -1702,7 +2042,6 @@ func TestSecretTriggersRebuild(t *testing.T) {
 		"no defined gateway does not trigger rebuild": {
 			cache: &KubernetesCache{
 				FieldLogger: fixture.NewTestLogger(t),
-				gateway:     nil,
 			},
 			secret: secret("default", "tlscert"),
 			want:   false,
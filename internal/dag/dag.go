@@ -23,6 +23,7 @@ import (
 	"strings"
 	"time"
 
+	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
 	"github.com/projectsesame/sesame/internal/status"
 	"github.com/projectsesame/sesame/internal/timeout"
 	v1 "k8s.io/api/core/v1"
@@ -63,6 +64,40 @@ type DAG struct {
 	VirtualHosts       map[string]*VirtualHost
 	SecureVirtualHosts map[string]*SecureVirtualHost
 	ExtensionClusters  []*ExtensionCluster
+
+	// ListenerVirtualHosts and ListenerSecureVirtualHosts hold the
+	// virtual hosts of the Envoy listeners that Gateways are mapped
+	// onto instead of the default HTTP and HTTPS listeners, keyed by
+	// listener name and then by hostname.
+	ListenerVirtualHosts       map[string]map[string]*VirtualHost
+	ListenerSecureVirtualHosts map[string]map[string]*SecureVirtualHost
+
+	// ListenerPorts holds the ports that the Envoy listeners of
+	// Gateway listener ports bind to, keyed by listener name. The
+	// Envoy listeners of Gateway mappings have no entry, since
+	// their ports are part of Sesame's configuration.
+	ListenerPorts map[string]int
+
+	// EnvoyConfig holds the Envoy settings of the GatewayClass
+	// that override Sesame's configuration, if any.
+	EnvoyConfig *sesame_api_v1alpha1.GatewayEnvoyConfig
+
+	// CertificateRecheck is the earliest time at which the expiry
+	// status of a serving certificate changes, or the zero time if
+	// none will. The DAG must be rebuilt then to refresh the status.
+	CertificateRecheck time.Time
+}
+
+// recheckCertificate records when the expiry status of
+// the certificate next changes in d.CertificateRecheck.
+func (d *DAG) recheckCertificate(cert *CertificateInfo, now time.Time, window time.Duration) {
+	t := cert.NextTransition(now, window)
+	if t.IsZero() {
+		return
+	}
+	if d.CertificateRecheck.IsZero() || t.Before(d.CertificateRecheck) {
+		d.CertificateRecheck = t
+	}
 }
 
 type MatchCondition interface {
@@ -158,6 +193,9 @@ func (hc *HeaderMatchCondition) String() string {
 // an envoy cluster.
 type DirectResponse struct {
 	StatusCode uint32
+
+	// Body is the optional body of the response.
+	Body string
 }
 
 // Redirect allows for a 301/302 redirect to be the response
@@ -214,6 +252,9 @@ type Route struct {
 	// RetryPolicy defines the retry / number / timeout options for a route
 	RetryPolicy *RetryPolicy
 
+	// HedgePolicy defines the request hedging options for a route
+	HedgePolicy *HedgePolicy
+
 	// Indicates that during forwarding, the matched prefix (or path) should be swapped with this value
 	PrefixRewrite string
 
@@ -285,6 +326,37 @@ type RetryPolicy struct {
 	// PerTryTimeout specifies the timeout per retry attempt.
 	// Ignored if RetryOn is blank.
 	PerTryTimeout timeout.Setting
+
+	// RetryHostPredicates specifies which hosts should be rejected
+	// when selecting a host for a retry attempt.
+	RetryHostPredicates []string
+
+	// HostSelectionRetryMaxAttempts is the maximum number of times
+	// host selection is reattempted when a predicate rejects a host.
+	HostSelectionRetryMaxAttempts int64
+
+	// PriorityUpdateFrequency enables the previous priorities retry
+	// predicate with the given update frequency. Zero disables it.
+	PriorityUpdateFrequency uint32
+
+	// PerTryIdleTimeout specifies how long a retry attempt may go
+	// without receiving any response bytes from the upstream.
+	PerTryIdleTimeout timeout.Setting
+
+	// BackOffBaseInterval is the base interval between retry attempts.
+	// Zero means the Envoy default.
+	BackOffBaseInterval time.Duration
+
+	// BackOffMaxInterval is the maximum interval between retry attempts.
+	// Zero means the Envoy default.
+	BackOffMaxInterval time.Duration
+}
+
+// HedgePolicy defines how requests are hedged across upstream hosts.
+type HedgePolicy struct {
+	// HedgeOnPerTryTimeout sends another request when the per-try
+	// timeout elapses, without cancelling the outstanding request.
+	HedgeOnPerTryTimeout bool
 }
 
 // MirrorPolicy defines the mirroring policy for a route.
@@ -297,9 +369,10 @@ type HeadersPolicy struct {
 	// HostRewrite defines if a host should be rewritten on upstream requests
 	HostRewrite string
 
-	Add    map[string]string
-	Set    map[string]string
-	Remove []string
+	Add         map[string]string
+	AddIfAbsent map[string]string
+	Set         map[string]string
+	Remove      []string
 }
 
 // CookieRewritePolicy defines how attributes of an HTTP Set-Cookie header
@@ -319,15 +392,55 @@ type CookieRewritePolicy struct {
 type RateLimitPolicy struct {
 	Local  *LocalRateLimitPolicy
 	Global *GlobalRateLimitPolicy
+
+	// LocalBucket is the name of a local rate limit bucket on
+	// the virtual host that requests to the route count against.
+	LocalBucket string
 }
 
 // LocalRateLimitPolicy holds local rate limiting parameters.
 type LocalRateLimitPolicy struct {
+	// MaxTokens is zero if requests that don't match a
+	// descriptor or bucket are not rate limited.
 	MaxTokens            uint32
 	TokensPerFill        uint32
 	FillInterval         time.Duration
 	ResponseStatusCode   uint32
 	ResponseHeadersToAdd map[string]string
+
+	// PerConnection is true if each downstream connection
+	// has its own token buckets.
+	PerConnection bool
+
+	Descriptors []*LocalRateLimitDescriptor
+	Buckets     []*LocalRateLimitBucket
+}
+
+// LocalRateLimitDescriptor holds the token bucket for requests
+// that generate a descriptor with matching entries.
+type LocalRateLimitDescriptor struct {
+	Entries       []LocalRateLimitDescriptorEntry
+	MaxTokens     uint32
+	TokensPerFill uint32
+	FillInterval  time.Duration
+}
+
+// LocalRateLimitDescriptorEntry is an entry in a local rate limit
+// descriptor. The request matches if Action generates an entry
+// with the given Key and Value.
+type LocalRateLimitDescriptorEntry struct {
+	Action RateLimitDescriptorEntry
+	Key    string
+	Value  string
+}
+
+// LocalRateLimitBucket holds a named token bucket that is shared
+// by the routes that reference it.
+type LocalRateLimitBucket struct {
+	Name          string
+	MaxTokens     uint32
+	TokensPerFill uint32
+	FillInterval  time.Duration
 }
 
 // HeaderHashOptions contains options for hashing a HTTP header.
@@ -378,10 +491,13 @@ type RateLimitDescriptor struct {
 // RateLimitDescriptorEntry is an entry in a rate limit descriptor.
 // Exactly one field should be non-nil.
 type RateLimitDescriptorEntry struct {
-	GenericKey       *GenericKeyDescriptorEntry
-	HeaderMatch      *HeaderMatchDescriptorEntry
-	HeaderValueMatch *HeaderValueMatchDescriptorEntry
-	RemoteAddress    *RemoteAddressDescriptorEntry
+	GenericKey          *GenericKeyDescriptorEntry
+	HeaderMatch         *HeaderMatchDescriptorEntry
+	HeaderValueMatch    *HeaderValueMatchDescriptorEntry
+	RemoteAddress       *RemoteAddressDescriptorEntry
+	QueryParameterMatch *QueryParameterMatchDescriptorEntry
+	Metadata            *MetadataDescriptorEntry
+	MaskedRemoteAddress *MaskedRemoteAddressDescriptorEntry
 }
 
 // GenericKeyDescriptorEntry  configures a descriptor entry
@@ -409,6 +525,33 @@ type HeaderValueMatchDescriptorEntry struct {
 // that contains the remote address (i.e. client IP).
 type RemoteAddressDescriptorEntry struct{}
 
+// QueryParameterMatchDescriptorEntry configures a descriptor entry
+// that's populated if the request's query parameter Name is present,
+// or equal to Exact if it is non-empty.
+type QueryParameterMatchDescriptorEntry struct {
+	Name        string
+	Exact       string
+	ExpectMatch bool
+	Value       string
+}
+
+// MetadataDescriptorEntry configures a descriptor entry that
+// contains a value from the request's dynamic metadata.
+type MetadataDescriptorEntry struct {
+	Key             string
+	FilterNamespace string
+	Path            []string
+	DefaultValue    string
+}
+
+// MaskedRemoteAddressDescriptorEntry configures a descriptor entry
+// that contains the remote address masked to the given prefix
+// lengths.
+type MaskedRemoteAddressDescriptorEntry struct {
+	V4PrefixLength uint32
+	V6PrefixLength uint32
+}
+
 // CORSPolicy allows setting the CORS policy
 type CORSPolicy struct {
 	// Specifies whether the resource allows credentials.
@@ -476,9 +619,26 @@ type VirtualHost struct {
 	// are rate limited.
 	RateLimitPolicy *RateLimitPolicy
 
+	// LocalReplies are the custom error pages that replace the
+	// bodies of responses that Envoy generates itself.
+	LocalReplies []LocalReply
+
 	Routes map[string]*Route
 }
 
+// LocalReply replaces the body of the local replies that
+// have the given status code.
+type LocalReply struct {
+	// StatusCode is the status code of the local replies to replace.
+	StatusCode uint32
+
+	// Body is the replacement body.
+	Body string
+
+	// ContentType is the content type of the replacement body.
+	ContentType string
+}
+
 func (v *VirtualHost) addRoute(route *Route) {
 	if v.Routes == nil {
 		v.Routes = make(map[string]*Route)
@@ -676,6 +836,94 @@ type Cluster struct {
 	// ClientCertificate is the optional identifier of the TLS secret containing client certificate and
 	// private key to be used when establishing TLS connection to upstream cluster.
 	ClientCertificate *Secret
+
+	// ConnectionPolicy tunes the connection pool to the upstream cluster.
+	// Circuit breaking limits set here take precedence over the ones
+	// derived from the Service annotations.
+	ConnectionPolicy *ConnectionPolicy
+
+	// CircuitBreakers holds the circuit breaking limits for the upstream
+	// cluster. Limits set here take precedence over the connection policy
+	// and the ones derived from the Service annotations.
+	CircuitBreakers *CircuitBreakers
+
+	// ExtensionCluster is the ExtensionService cluster that traffic
+	// arriving at this Cluster is forwarded to, in place of Upstream.
+	// The Envoy cluster is generated from the ExtensionCluster, so the
+	// upstream connection settings of this Cluster are not used.
+	ExtensionCluster *ExtensionCluster
+}
+
+// CircuitBreakers defines the circuit breaking limits for an upstream cluster.
+type CircuitBreakers struct {
+	// MaxConnections is the maximum number of connections
+	// that Envoy will make to the upstream cluster.
+	MaxConnections uint32
+
+	// MaxPendingRequests is the maximum number of pending
+	// requests that Envoy will allow to the upstream cluster.
+	MaxPendingRequests uint32
+
+	// MaxRequests is the maximum number of parallel requests that
+	// Envoy will make to the upstream cluster.
+	MaxRequests uint32
+
+	// MaxRetries is the maximum number of parallel retries that
+	// Envoy will allow to the upstream cluster.
+	MaxRetries uint32
+
+	// RetryBudget limits parallel retries relative to the active requests.
+	RetryBudget *RetryBudget
+}
+
+// RetryBudget limits concurrent retries relative to the number of active requests.
+type RetryBudget struct {
+	// BudgetPercent is the percentage of active requests that
+	// may be retries. Nil means the Envoy default.
+	BudgetPercent *uint32
+
+	// MinRetryConcurrency is the number of parallel retries that are
+	// always allowed. Nil means the Envoy default.
+	MinRetryConcurrency *uint32
+}
+
+// ConnectionPolicy defines how Envoy manages connections to an upstream cluster.
+type ConnectionPolicy struct {
+	// MaxConnections is the maximum number of connections
+	// that Envoy will make to the upstream cluster.
+	MaxConnections uint32
+
+	// MaxPendingRequests is the maximum number of pending
+	// requests that Envoy will allow to the upstream cluster.
+	MaxPendingRequests uint32
+
+	// MaxRequestsPerConnection is the maximum number of requests
+	// Envoy will send over a single upstream connection.
+	MaxRequestsPerConnection uint32
+
+	// MaxConcurrentStreams is the maximum number of concurrent
+	// streams on a single HTTP/2 upstream connection.
+	MaxConcurrentStreams uint32
+
+	// IdleTimeout is how long an upstream connection may remain
+	// without active requests before Envoy closes it.
+	IdleTimeout timeout.Setting
+
+	// TCPKeepalive enables TCP keepalive on upstream connections.
+	TCPKeepalive *TCPKeepalive
+}
+
+// TCPKeepalive defines the TCP keepalive settings for upstream connections.
+type TCPKeepalive struct {
+	// Probes is the maximum number of unanswered keepalive probes.
+	Probes uint32
+
+	// IdleTime is the time a connection must be idle
+	// before keepalive probes are sent.
+	IdleTime time.Duration
+
+	// Interval is the time between keepalive probes.
+	Interval time.Duration
 }
 
 // WeightedService represents the load balancing weight of a
@@ -691,6 +939,19 @@ type WeightedService struct {
 	ServicePort v1.ServicePort
 }
 
+// StaticEndpoint is a network endpoint that is not backed by
+// a Kubernetes Service.
+type StaticEndpoint struct {
+	// Address is the IP address of the endpoint.
+	Address string
+	// Port is the port to which we forward traffic.
+	Port uint32
+	// Weight is the load balancing weight of the endpoint
+	// relative to the other static endpoints. Zero means
+	// the default weight.
+	Weight uint32
+}
+
 // ServiceCluster capture the set of Kubernetes Services that will
 // compose the endpoints for a Envoy cluster. Traffic is balanced
 // across the Service slice based on the weight of the elements.
@@ -699,8 +960,12 @@ type ServiceCluster struct {
 	// It is eventually used as the Envoy ClusterLoadAssignment
 	// name, and must not be empty.
 	ClusterName string
-	// Services are the load balancing targets. This slice must not be empty.
+	// Services are the load balancing targets. This slice must not
+	// be empty unless there are StaticEndpoints.
 	Services []WeightedService
+	// StaticEndpoints are load balancing targets that are not
+	// backed by a Kubernetes Service.
+	StaticEndpoints []StaticEndpoint
 }
 
 // DeepCopy performs a deep copy of ServiceClusters
@@ -716,6 +981,10 @@ func (s *ServiceCluster) DeepCopy() *ServiceCluster {
 		w.ServicePort.DeepCopyInto(&s2.Services[i].ServicePort)
 	}
 
+	if s.StaticEndpoints != nil {
+		s2.StaticEndpoints = append([]StaticEndpoint{}, s.StaticEndpoints...)
+	}
+
 	return &s2
 }
 
@@ -725,8 +994,8 @@ func (s *ServiceCluster) Validate() error {
 		return errors.New("missing .ClusterName field")
 	}
 
-	if len(s.Services) == 0 {
-		return errors.New("empty .Services field")
+	if len(s.Services) == 0 && len(s.StaticEndpoints) == 0 {
+		return errors.New("empty .Services and .StaticEndpoints fields")
 	}
 
 	for i, w := range s.Services {
@@ -739,6 +1008,16 @@ func (s *ServiceCluster) Validate() error {
 		}
 	}
 
+	for i, e := range s.StaticEndpoints {
+		if e.Address == "" {
+			return fmt.Errorf("empty .StaticEndpoints[%d].Address field", i)
+		}
+
+		if e.Port == 0 {
+			return fmt.Errorf("empty .StaticEndpoints[%d].Port field", i)
+		}
+	}
+
 	return nil
 }
 
@@ -781,6 +1060,43 @@ func (s *ServiceCluster) Rebalance() {
 // a leaf in the DAG.
 type Secret struct {
 	Object *v1.Secret
+
+	// Certificate holds the metadata of the first certificate in
+	// the Secret's TLS certificate bundle, or nil if it has none.
+	Certificate *CertificateInfo
+}
+
+// CertificateInfo holds the metadata of a parsed X.509 certificate.
+type CertificateInfo struct {
+	CommonName string
+	DNSNames   []string
+	NotBefore  time.Time
+	NotAfter   time.Time
+}
+
+// Expired returns true if the certificate has expired at the given time.
+func (c *CertificateInfo) Expired(now time.Time) bool {
+	return now.After(c.NotAfter)
+}
+
+// ExpiresWithin returns true if the certificate expires within the
+// given window of the given time.
+func (c *CertificateInfo) ExpiresWithin(now time.Time, window time.Duration) bool {
+	return window > 0 && now.Add(window).After(c.NotAfter)
+}
+
+// NextTransition returns the first time after now at which the
+// certificate starts expiring within the given window, or expires,
+// or the zero time if it has already expired.
+func (c *CertificateInfo) NextTransition(now time.Time, window time.Duration) time.Time {
+	switch {
+	case c.Expired(now):
+		return time.Time{}
+	case window > 0 && !c.ExpiresWithin(now, window):
+		return c.NotAfter.Add(-window)
+	default:
+		return c.NotAfter
+	}
 }
 
 func (s *Secret) Name() string      { return s.Object.Name }
@@ -114,11 +114,19 @@ func TestServiceClusterValid(t *testing.T) {
 		{ClusterName: "foo", Services: []WeightedService{{}}},
 		{ClusterName: "foo", Services: []WeightedService{{ServiceName: "foo"}}},
 		{ClusterName: "foo", Services: []WeightedService{{ServiceNamespace: "foo"}}},
+		{ClusterName: "foo", StaticEndpoints: []StaticEndpoint{{Port: 80}}},
+		{ClusterName: "foo", StaticEndpoints: []StaticEndpoint{{Address: "192.0.2.1"}}},
 	}
 
 	for _, c := range invalid {
 		assert.Errorf(t, c.Validate(), "invalid cluster %#v", c)
 	}
+
+	valid := ServiceCluster{
+		ClusterName:     "foo",
+		StaticEndpoints: []StaticEndpoint{{Address: "192.0.2.1", Port: 80}},
+	}
+	assert.NoError(t, valid.Validate())
 }
 
 func TestServiceClusterAdd(t *testing.T) {
@@ -0,0 +1,60 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dag
+
+import (
+	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
+	"github.com/projectsesame/sesame/internal/status"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TLSCertificateDelegationProcessor validates TLSCertificateDelegations
+// and sets their status. It must run after the processors that reference
+// secrets, so that the namespaces consuming each delegated secret are known.
+type TLSCertificateDelegationProcessor struct{}
+
+// Run sets the Valid condition of every TLSCertificateDelegation.
+func (p *TLSCertificateDelegationProcessor) Run(dag *DAG, source *KubernetesCache) {
+	for _, d := range source.tlscertificatedelegations {
+		delegationStatus, commit := status.DelegationAccessor(&dag.StatusCache, d)
+		validCondition := delegationStatus.ConditionFor(status.ValidCondition)
+
+		for _, cd := range d.Spec.Delegations {
+			if cd.SecretName == "" && cd.SecretSelector == nil {
+				validCondition.AddError(sesame_api_v1.ConditionTypeSpecError, "SecretNotSpecified",
+					"delegation must specify a secretName or a secretSelector")
+			}
+			if cd.SecretSelector != nil {
+				if _, err := metav1.LabelSelectorAsSelector(cd.SecretSelector); err != nil {
+					validCondition.AddErrorf(sesame_api_v1.ConditionTypeSpecError, "SecretSelectorNotValid",
+						"secretSelector is invalid: %s", err)
+				}
+			}
+			if cd.TargetNamespaceSelector != nil {
+				if _, err := metav1.LabelSelectorAsSelector(cd.TargetNamespaceSelector); err != nil {
+					validCondition.AddErrorf(sesame_api_v1.ConditionTypeSpecError, "TargetNamespaceSelectorNotValid",
+						"targetNamespaceSelector is invalid: %s", err)
+				}
+			}
+		}
+
+		if len(validCondition.Errors) == 0 {
+			validCondition.Status = sesame_api_v1.ConditionTrue
+			validCondition.Reason = "Valid"
+			validCondition.Message = "Valid TLSCertificateDelegation"
+		}
+
+		commit()
+	}
+}
@@ -14,6 +14,7 @@
 package dag
 
 import (
+	"net"
 	"path"
 	"strings"
 
@@ -44,7 +45,7 @@ func (p *ExtensionServiceProcessor) Run(dag *DAG, cache *KubernetesCache) {
 		extStatus, commit := status.ExtensionAccessor(&dag.StatusCache, e)
 		validCondition := extStatus.ConditionFor(status.ValidCondition)
 
-		if ext := p.buildExtensionService(cache, e, validCondition); ext != nil {
+		if ext := p.buildExtensionService(dag, cache, e, validCondition); ext != nil {
 			if len(validCondition.Errors) == 0 {
 				dag.ExtensionClusters = append(dag.ExtensionClusters, ext)
 			}
@@ -76,6 +77,7 @@ func ExtensionClusterName(meta types.NamespacedName) string {
 // buildExtensionService builds one ExtensionCluster record based
 // on the corresponding CRD.
 func (p *ExtensionServiceProcessor) buildExtensionService(
+	dag *DAG,
 	cache *KubernetesCache,
 	ext *sesame_api_v1alpha1.ExtensionService,
 	validCondition *sesame_api_v1.DetailedCondition,
@@ -142,7 +144,7 @@ func (p *ExtensionServiceProcessor) buildExtensionService(
 		// delegated to the ExtensionService's namespace.
 		// By default, a non-namespaced CACertificate is expected to reside in the ExtensionService's namespace.
 		caCertNamespacedName := k8s.NamespacedNameFrom(v.CACertificate, k8s.DefaultNamespace(ext.Namespace))
-		if !cache.DelegationPermitted(caCertNamespacedName, ext.Namespace) {
+		if !dag.DelegationPermitted(caCertNamespacedName, ext.Namespace, cache) {
 			validCondition.AddErrorf(sesame_api_v1.ConditionTypeTLSError, "CACertificateNotDelegated",
 				"service.UpstreamValidation.CACertificate Secret %q is not configured for certificate delegation", caCertNamespacedName)
 			return nil
@@ -198,5 +200,35 @@ func (p *ExtensionServiceProcessor) buildExtensionService(
 		extension.Upstream.AddWeightedService(target.Weight, svcName, port)
 	}
 
+	for i, e := range ext.Spec.Endpoints {
+		// Envoy can't resolve hostnames of EDS endpoints,
+		// so static endpoints must be IP addresses.
+		ip := net.ParseIP(e.Address)
+		if ip == nil {
+			validCondition.AddErrorf(sesame_api_v1.ConditionTypeSpecError, "EndpointAddressInvalid",
+				"spec.endpoints[%d].address %q is not an IP address", i, e.Address)
+			continue
+		}
+
+		// Like ExternalName services, static endpoints must not
+		// point Envoy at itself or at its node.
+		if ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+			validCondition.AddErrorf(sesame_api_v1.ConditionTypeSpecError, "EndpointAddressInvalid",
+				"spec.endpoints[%d].address %q is a loopback, unspecified or link-local address, this is not allowed", i, e.Address)
+			continue
+		}
+
+		extension.Upstream.StaticEndpoints = append(extension.Upstream.StaticEndpoints, StaticEndpoint{
+			Address: e.Address,
+			Port:    uint32(e.Port),
+			Weight:  e.Weight,
+		})
+	}
+
+	if len(ext.Spec.Services) == 0 && len(ext.Spec.Endpoints) == 0 {
+		validCondition.AddError(sesame_api_v1.ConditionTypeSpecError, "NoUpstreams",
+			"at least one of spec.services or spec.endpoints must be specified")
+	}
+
 	return &extension
 }
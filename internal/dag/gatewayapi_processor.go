@@ -17,9 +17,12 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/projectsesame/sesame/internal/errors"
+	"github.com/projectsesame/sesame/internal/gatewayapi"
 	"github.com/projectsesame/sesame/internal/k8s"
 	"github.com/projectsesame/sesame/internal/status"
 
@@ -48,10 +51,48 @@ type GatewayAPIProcessor struct {
 	dag    *DAG
 	source *KubernetesCache
 
+	// gateway is the Gateway being processed, and listeners
+	// are the Envoy listeners that serve it.
+	gateway   *gatewayapi_v1alpha2.Gateway
+	listeners GatewayEnvoyListeners
+
 	// EnableExternalNameService allows processing of ExternalNameServices
 	// This is normally disabled for security reasons.
 	// See https://github.com/projectsesame/sesame/security/advisories/GHSA-5ph6-qq5x-7jwc for details.
 	EnableExternalNameService bool
+
+	// GatewayListeners maps Gateways onto the Envoy listeners that
+	// serve them. Gateways that are not mapped are served by the
+	// default HTTP and HTTPS listeners.
+	GatewayListeners map[types.NamespacedName]GatewayEnvoyListeners
+
+	// GatewayPortListeners, if not nil, serves the listeners of the
+	// Gateways that are not in GatewayListeners from an Envoy listener
+	// per port, so that each port only serves the routes of its own
+	// listeners.
+	GatewayPortListeners *GatewayPortListeners
+
+	// CertificateExpiryWarning is how long before expiry a listener
+	// certificate is reported as expiring. Zero disables the warning.
+	CertificateExpiryWarning time.Duration
+}
+
+// GatewayEnvoyListeners holds the names of the Envoy listeners that
+// serve a Gateway's HTTP listeners, and its HTTPS and TLS listeners.
+// An empty name selects the default HTTP or HTTPS listener.
+type GatewayEnvoyListeners struct {
+	HTTP  string
+	HTTPS string
+}
+
+// GatewayPortListeners holds the ports that Envoy's default HTTP and
+// HTTPS listeners bind to. The Envoy listener of a Gateway listener
+// port binds to the port returned by gatewayapi.EnvoyListenerPort,
+// unless that is the port of the default listener for the Gateway
+// listener's protocol, which then serves it instead.
+type GatewayPortListeners struct {
+	HTTPPort  int
+	HTTPSPort int
 }
 
 // matchConditions holds match rules.
@@ -63,9 +104,6 @@ type matchConditions struct {
 // Run translates Service APIs into DAG objects and
 // adds them to the DAG.
 func (p *GatewayAPIProcessor) Run(dag *DAG, source *KubernetesCache) {
-	var gatewayErrors field.ErrorList
-	path := field.NewPath("spec")
-
 	p.dag = dag
 	p.source = source
 
@@ -73,10 +111,11 @@ func (p *GatewayAPIProcessor) Run(dag *DAG, source *KubernetesCache) {
 	defer func() {
 		p.dag = nil
 		p.source = nil
+		p.gateway = nil
 	}()
 
 	// Gateway and GatewayClass must be defined for resources to be processed.
-	if p.source.gateway == nil {
+	if len(p.source.gateways) == 0 {
 		p.Info("Gateway not found in cache.")
 		return
 	}
@@ -85,24 +124,109 @@ func (p *GatewayAPIProcessor) Run(dag *DAG, source *KubernetesCache) {
 		return
 	}
 
+	p.dag.EnvoyConfig = p.source.LookupGatewayEnvoyConfig()
+
+	// Process the Gateways in a stable order, since Gateways
+	// that share Envoy listeners also share virtual hosts.
+	var gateways []types.NamespacedName
+	for name := range p.source.gateways {
+		gateways = append(gateways, name)
+	}
+	sort.Slice(gateways, func(i, j int) bool {
+		return gateways[i].String() < gateways[j].String()
+	})
+
+	for _, name := range gateways {
+		p.computeGateway(p.source.gateways[name])
+	}
+}
+
+// computeGateway adds the routes attached to the listeners of
+// the Gateway to the Envoy listeners that serve the Gateway, and
+// sets the Gateway's status.
+func (p *GatewayAPIProcessor) computeGateway(gateway *gatewayapi_v1alpha2.Gateway) {
+	var gatewayErrors field.ErrorList
+	path := field.NewPath("spec")
+
+	p.gateway = gateway
+
 	gwAccessor, commit := p.dag.StatusCache.GatewayStatusAccessor(
-		k8s.NamespacedNameOf(p.source.gateway),
-		p.source.gateway.Generation,
-		&p.source.gateway.Status,
+		k8s.NamespacedNameOf(p.gateway),
+		p.gateway.Generation,
+		&p.gateway.Status,
 	)
 	defer commit()
 
-	if len(p.source.gateway.Spec.Addresses) > 0 {
-		gatewayErrors = append(gatewayErrors, &field.Error{Type: field.ErrorTypeNotSupported, Field: path.String(), BadValue: p.source.gateway.Spec.Addresses, Detail: "Spec.Addresses is not supported"})
+	if len(p.gateway.Spec.Addresses) > 0 {
+		gatewayErrors = append(gatewayErrors, &field.Error{Type: field.ErrorTypeNotSupported, Field: path.String(), BadValue: p.gateway.Spec.Addresses, Detail: "Spec.Addresses is not supported"})
 	}
 
-	for _, listener := range p.source.gateway.Spec.Listeners {
+	for _, listener := range p.gateway.Spec.Listeners {
 		p.computeListener(listener, gwAccessor, len(gatewayErrors) == 0)
 	}
 
 	p.computeGatewayConditions(gwAccessor, gatewayErrors)
 }
 
+// envoyListenersFor returns the names of the Envoy listeners that
+// serve the given listener of the current Gateway. It returns false
+// and sets a listener condition if the listener's port cannot be
+// served.
+func (p *GatewayAPIProcessor) envoyListenersFor(listener gatewayapi_v1alpha2.Listener, gwAccessor *status.GatewayStatusUpdate) (GatewayEnvoyListeners, bool) {
+	listeners, mapped := p.GatewayListeners[k8s.NamespacedNameOf(p.gateway)]
+
+	if !mapped && p.GatewayPortListeners != nil {
+		port := gatewayapi.EnvoyListenerPort(listener.Port)
+
+		name := fmt.Sprintf("%s_%d", HTTP_LISTENER_NAME, listener.Port)
+		other := fmt.Sprintf("%s_%d", HTTPS_LISTENER_NAME, listener.Port)
+		defaultPort, otherDefaultPort := p.GatewayPortListeners.HTTPPort, p.GatewayPortListeners.HTTPSPort
+		secure := listener.Protocol != gatewayapi_v1alpha2.HTTPProtocolType
+		if secure {
+			name, other = other, name
+			defaultPort, otherDefaultPort = otherDefaultPort, defaultPort
+		}
+
+		// An Envoy listener serves either HTTP, or HTTPS and TLS,
+		// so the first listener on a port determines which.
+		if _, conflict := p.dag.ListenerPorts[other]; conflict || port == otherDefaultPort {
+			gwAccessor.AddListenerCondition(
+				string(listener.Name),
+				gatewayapi_v1alpha2.ListenerConditionConflicted,
+				metav1.ConditionTrue,
+				gatewayapi_v1alpha2.ListenerReasonProtocolConflict,
+				fmt.Sprintf("Listener.Port %d is served by an Envoy listener that does not serve protocol %q.", listener.Port, listener.Protocol),
+			)
+			return listeners, false
+		}
+
+		// The default listener serves the port if it binds to it.
+		if port == defaultPort {
+			name = ""
+		} else {
+			if p.dag.ListenerPorts == nil {
+				p.dag.ListenerPorts = map[string]int{}
+			}
+			p.dag.ListenerPorts[name] = port
+		}
+
+		if secure {
+			listeners.HTTPS = name
+		} else {
+			listeners.HTTP = name
+		}
+	}
+
+	if listeners.HTTP == "" {
+		listeners.HTTP = HTTP_LISTENER_NAME
+	}
+	if listeners.HTTPS == "" {
+		listeners.HTTPS = HTTPS_LISTENER_NAME
+	}
+
+	return listeners, true
+}
+
 func (p *GatewayAPIProcessor) computeListener(listener gatewayapi_v1alpha2.Listener, gwAccessor *status.GatewayStatusUpdate, isGatewayValid bool) {
 	// set the listener's "Ready" condition based on whether we've
 	// added any other conditions for the listener. The assumption
@@ -215,6 +339,13 @@ func (p *GatewayAPIProcessor) computeListener(listener gatewayapi_v1alpha2.Liste
 		return
 	}
 
+	// Select the Envoy listeners that serve the listener's routes.
+	listeners, ok := p.envoyListenersFor(listener, gwAccessor)
+	if !ok {
+		return
+	}
+	p.listeners = listeners
+
 	// Get a list of the route kinds that the listener accepts.
 	listenerRouteKinds := p.getListenerRouteKinds(listener, gwAccessor)
 	gwAccessor.SetListenerSupportedKinds(string(listener.Name), listenerRouteKinds)
@@ -235,7 +366,7 @@ func (p *GatewayAPIProcessor) computeListener(listener gatewayapi_v1alpha2.Liste
 
 				// If the Gateway selects the HTTPRoute, check to see if the HTTPRoute selects
 				// the Gateway/listener.
-				if !routeSelectsGatewayListener(p.source.gateway, listener, route.Spec.ParentRefs, route.Namespace) {
+				if !routeSelectsGatewayListener(p.gateway, listener, route.Spec.ParentRefs, route.Namespace) {
 					continue
 				}
 
@@ -256,7 +387,7 @@ func (p *GatewayAPIProcessor) computeListener(listener gatewayapi_v1alpha2.Liste
 
 				// If the Gateway selects the TLSRoute, check to see if the TLSRoute selects
 				// the Gateway/listener.
-				if !routeSelectsGatewayListener(p.source.gateway, listener, route.Spec.ParentRefs, route.Namespace) {
+				if !routeSelectsGatewayListener(p.gateway, listener, route.Spec.ParentRefs, route.Namespace) {
 					continue
 				}
 
@@ -353,20 +484,27 @@ func (p *GatewayAPIProcessor) validGatewayTLS(listenerTLS gatewayapi_v1alpha2.Ga
 		return nil
 	}
 
+	var meta types.NamespacedName
+	if certificateRef.Namespace != nil {
+		meta = types.NamespacedName{Name: string(certificateRef.Name), Namespace: string(*certificateRef.Namespace)}
+	} else {
+		meta = types.NamespacedName{Name: string(certificateRef.Name), Namespace: p.gateway.Namespace}
+	}
+
 	// If the secret is in a different namespace than the gateway, then we need to
-	// check for a ReferencePolicy that allows the reference.
-	if certificateRef.Namespace != nil && string(*certificateRef.Namespace) != p.source.gateway.Namespace {
+	// check for a TLSCertificateDelegation or a ReferencePolicy that allows the reference.
+	if meta.Namespace != p.gateway.Namespace && !p.dag.DelegationPermitted(meta, p.gateway.Namespace, p.source) {
 		if !p.validCrossNamespaceRef(
 			crossNamespaceFrom{
 				group:     gatewayapi_v1alpha2.GroupName,
 				kind:      KindGateway,
-				namespace: p.source.gateway.Namespace,
+				namespace: p.gateway.Namespace,
 			},
 			crossNamespaceTo{
 				group:     "",
 				kind:      "Secret",
-				namespace: string(*certificateRef.Namespace),
-				name:      string(certificateRef.Name),
+				namespace: meta.Namespace,
+				name:      meta.Name,
 			},
 		) {
 			gwAccessor.AddListenerCondition(
@@ -374,19 +512,12 @@ func (p *GatewayAPIProcessor) validGatewayTLS(listenerTLS gatewayapi_v1alpha2.Ga
 				gatewayapi_v1alpha2.ListenerConditionResolvedRefs,
 				metav1.ConditionFalse,
 				gatewayapi_v1alpha2.ListenerReasonInvalidCertificateRef,
-				fmt.Sprintf("Spec.VirtualHost.TLS.CertificateRefs %q namespace must match the Gateway's namespace or be covered by a ReferencePolicy", certificateRef.Name),
+				fmt.Sprintf("Spec.VirtualHost.TLS.CertificateRefs %q namespace must match the Gateway's namespace or be covered by a TLSCertificateDelegation or ReferencePolicy", certificateRef.Name),
 			)
 			return nil
 		}
 	}
 
-	var meta types.NamespacedName
-	if certificateRef.Namespace != nil {
-		meta = types.NamespacedName{Name: string(certificateRef.Name), Namespace: string(*certificateRef.Namespace)}
-	} else {
-		meta = types.NamespacedName{Name: string(certificateRef.Name), Namespace: p.source.gateway.Namespace}
-	}
-
 	listenerSecret, err := p.source.LookupSecret(meta, validSecret)
 	if err != nil {
 		gwAccessor.AddListenerCondition(
@@ -398,6 +529,30 @@ func (p *GatewayAPIProcessor) validGatewayTLS(listenerTLS gatewayapi_v1alpha2.Ga
 		)
 		return nil
 	}
+
+	// An expired certificate is reported, but still served,
+	// since removing the listener's TLS would be an outage.
+	if cert := listenerSecret.Certificate; cert != nil {
+		now := time.Now()
+		p.dag.recheckCertificate(cert, now, p.CertificateExpiryWarning)
+		switch {
+		case cert.Expired(now):
+			gwAccessor.AddCondition(
+				status.ConditionCertificateExpired,
+				metav1.ConditionTrue,
+				status.ReasonCertificateExpired,
+				fmt.Sprintf("Listener %q certificate %q expired at %s", listenerName, certificateRef.Name, cert.NotAfter.UTC().Format(time.RFC3339)),
+			)
+		case cert.ExpiresWithin(now, p.CertificateExpiryWarning):
+			gwAccessor.AddCondition(
+				status.ConditionCertificateExpiring,
+				metav1.ConditionTrue,
+				status.ReasonCertificateExpiring,
+				fmt.Sprintf("Listener %q certificate %q expires at %s", listenerName, certificateRef.Name, cert.NotAfter.UTC().Format(time.RFC3339)),
+			)
+		}
+	}
+
 	return listenerSecret
 }
 
@@ -576,7 +731,7 @@ func (p *GatewayAPIProcessor) namespaceMatches(namespaces *gatewayapi_v1alpha2.R
 	case gatewayapi_v1alpha2.NamespacesFromAll:
 		return true, nil
 	case gatewayapi_v1alpha2.NamespacesFromSame:
-		return p.source.gateway.Namespace == routeNamespace, nil
+		return p.gateway.Namespace == routeNamespace, nil
 	case gatewayapi_v1alpha2.NamespacesFromSelector:
 		if namespaces.Selector == nil ||
 			(len(namespaces.Selector.MatchLabels) == 0 && len(namespaces.Selector.MatchExpressions) == 0) {
@@ -669,7 +824,7 @@ func (p *GatewayAPIProcessor) computeGatewayConditions(gwAccessor *status.Gatewa
 
 func (p *GatewayAPIProcessor) computeTLSRoute(route *gatewayapi_v1alpha2.TLSRoute, listenerSecret *Secret, listenerHostname *gatewayapi_v1alpha2.Hostname, validGateway bool) bool {
 
-	routeAccessor, commit := p.dag.StatusCache.RouteConditionsAccessor(k8s.NamespacedNameOf(route), route.Generation, &gatewayapi_v1alpha2.TLSRoute{}, route.Status.Parents)
+	routeAccessor, commit := p.dag.StatusCache.RouteConditionsAccessor(k8s.NamespacedNameOf(route), route.Generation, &gatewayapi_v1alpha2.TLSRoute{}, k8s.NamespacedNameOf(p.gateway), route.Status.Parents)
 	defer commit()
 
 	// If the Gateway is invalid, set status on the route.
@@ -740,7 +895,7 @@ func (p *GatewayAPIProcessor) computeTLSRoute(route *gatewayapi_v1alpha2.TLSRout
 		}
 
 		for host := range hosts {
-			secure := p.dag.EnsureSecureVirtualHost(host)
+			secure := p.dag.EnsureListenerSecureVirtualHost(p.listeners.HTTPS, host)
 
 			if listenerSecret != nil {
 				secure.Secret = listenerSecret
@@ -765,7 +920,7 @@ func (p *GatewayAPIProcessor) computeTLSRoute(route *gatewayapi_v1alpha2.TLSRout
 }
 
 func (p *GatewayAPIProcessor) computeHTTPRoute(route *gatewayapi_v1alpha2.HTTPRoute, listenerSecret *Secret, listenerHostname *gatewayapi_v1alpha2.Hostname, validGateway bool) bool {
-	routeAccessor, commit := p.dag.StatusCache.RouteConditionsAccessor(k8s.NamespacedNameOf(route), route.Generation, &gatewayapi_v1alpha2.HTTPRoute{}, route.Status.Parents)
+	routeAccessor, commit := p.dag.StatusCache.RouteConditionsAccessor(k8s.NamespacedNameOf(route), route.Generation, &gatewayapi_v1alpha2.HTTPRoute{}, k8s.NamespacedNameOf(p.gateway), route.Status.Parents)
 	defer commit()
 
 	// If the Gateway is invalid, set status on the route.
@@ -878,11 +1033,11 @@ func (p *GatewayAPIProcessor) computeHTTPRoute(route *gatewayapi_v1alpha2.HTTPRo
 
 				switch {
 				case listenerSecret != nil:
-					svhost := p.dag.EnsureSecureVirtualHost(host)
+					svhost := p.dag.EnsureListenerSecureVirtualHost(p.listeners.HTTPS, host)
 					svhost.Secret = listenerSecret
 					svhost.addRoute(route)
 				default:
-					vhost := p.dag.EnsureVirtualHost(host)
+					vhost := p.dag.EnsureListenerVirtualHost(p.listeners.HTTP, host)
 					vhost.addRoute(route)
 				}
 
@@ -525,13 +525,13 @@ func TestNamespaceMatches(t *testing.T) {
 
 			processor := &GatewayAPIProcessor{
 				FieldLogger: fixture.NewTestLogger(t),
-				source: &KubernetesCache{
-					gateway: &gatewayapi_v1alpha2.Gateway{
-						ObjectMeta: metav1.ObjectMeta{
-							Name:      "sesame",
-							Namespace: "projectsesame",
-						},
+				gateway: &gatewayapi_v1alpha2.Gateway{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "sesame",
+						Namespace: "projectsesame",
 					},
+				},
+				source: &KubernetesCache{
 					namespaces: map[string]*v1.Namespace{
 						"projectsesame": {
 							ObjectMeta: metav1.ObjectMeta{
@@ -631,17 +631,16 @@ func TestRouteSelectsGatewayListener(t *testing.T) {
 
 			processor := &GatewayAPIProcessor{
 				FieldLogger: fixture.NewTestLogger(t),
-				source: &KubernetesCache{
-					gateway: &gatewayapi_v1alpha2.Gateway{
-						ObjectMeta: metav1.ObjectMeta{
-							Name:      "sesame",
-							Namespace: "projectsesame",
-						},
+				gateway: &gatewayapi_v1alpha2.Gateway{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "sesame",
+						Namespace: "projectsesame",
 					},
 				},
+				source: &KubernetesCache{},
 			}
 
-			got := routeSelectsGatewayListener(processor.source.gateway, tc.listener, tc.routeParentRefs, tc.routeNamespace)
+			got := routeSelectsGatewayListener(processor.gateway, tc.listener, tc.routeParentRefs, tc.routeNamespace)
 			assert.Equal(t, tc.want, got)
 		})
 	}
@@ -19,6 +19,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
 	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
@@ -75,6 +76,14 @@ type HTTPProxyProcessor struct {
 	// Note: This only applies to externalName clusters.
 	DNSLookupFamily sesame_api_v1alpha1.ClusterDNSFamilyType
 
+	// ConnectionPolicy holds the default connection pool settings
+	// for clusters. Settings on an HTTPProxy service take precedence.
+	ConnectionPolicy *sesame_api_v1.ConnectionPolicy
+
+	// RetryBudget is the default retry budget for HTTP clusters that
+	// do not limit retries themselves.
+	RetryBudget *sesame_api_v1.RetryBudget
+
 	// ClientCertificate is the optional identifier of the TLS secret containing client certificate and
 	// private key to be used when establishing TLS connection to upstream cluster.
 	ClientCertificate *types.NamespacedName
@@ -84,6 +93,10 @@ type HTTPProxyProcessor struct {
 
 	// Response headers that will be set on all routes (optional).
 	ResponseHeadersPolicy *HeadersPolicy
+
+	// CertificateExpiryWarning is how long before expiry a serving
+	// certificate is reported as expiring. Zero disables the warning.
+	CertificateExpiryWarning time.Duration
 }
 
 // Run translates HTTPProxies into DAG objects and
@@ -187,12 +200,27 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *sesame_api_v1.HTTPProxy) {
 				return
 			}
 
-			if !p.source.DelegationPermitted(secretName, proxy.Namespace) {
+			if !p.dag.DelegationPermitted(secretName, proxy.Namespace, p.source) {
 				validCond.AddErrorf(sesame_api_v1.ConditionTypeTLSError, "DelegationNotPermitted",
 					"Spec.VirtualHost.TLS Secret %q certificate delegation not permitted", tls.SecretName)
 				return
 			}
 
+			// An expired certificate is reported, but still served,
+			// since removing the virtual host would be an outage.
+			if cert := sec.Certificate; cert != nil {
+				now := time.Now()
+				p.dag.recheckCertificate(cert, now, p.CertificateExpiryWarning)
+				switch {
+				case cert.Expired(now):
+					validCond.AddErrorf(sesame_api_v1.ConditionTypeTLSError, "CertificateExpired",
+						"Spec.VirtualHost.TLS Secret %q certificate expired at %s", tls.SecretName, cert.NotAfter.UTC().Format(time.RFC3339))
+				case cert.ExpiresWithin(now, p.CertificateExpiryWarning):
+					validCond.AddWarningf(sesame_api_v1.ConditionTypeTLSError, "CertificateExpiring",
+						"Spec.VirtualHost.TLS Secret %q certificate expires at %s", tls.SecretName, cert.NotAfter.UTC().Format(time.RFC3339))
+				}
+			}
+
 			svhost := p.dag.EnsureSecureVirtualHost(host)
 			svhost.Secret = sec
 			// default to a minimum TLS version of 1.2 if it's not specified
@@ -231,7 +259,7 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *sesame_api_v1.HTTPProxy) {
 					return
 				}
 
-				if !p.source.DelegationPermitted(*p.FallbackCertificate, proxy.Namespace) {
+				if !p.dag.DelegationPermitted(*p.FallbackCertificate, proxy.Namespace, p.source) {
 					validCond.AddErrorf(sesame_api_v1.ConditionTypeTLSError, "FallbackNotDelegated",
 						"Spec.VirtualHost.TLS fallback Secret %q is not configured for certificate delegation", p.FallbackCertificate)
 					return
@@ -337,12 +365,25 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *sesame_api_v1.HTTPProxy) {
 	}
 	insecure.CORSPolicy = cp
 
+	lr, err := p.localReplies(proxy)
+	if err != nil {
+		validCond.AddErrorf(sesame_api_v1.ConditionTypeVirtualHostError, "LocalReplyPolicyNotValid",
+			"Spec.VirtualHost.LocalReplyPolicy is invalid: %s", err)
+		return
+	}
+	insecure.LocalReplies = lr
+
 	rlp, err := rateLimitPolicy(proxy.Spec.VirtualHost.RateLimitPolicy)
 	if err != nil {
 		validCond.AddErrorf(sesame_api_v1.ConditionTypeRouteError, "RateLimitPolicyNotValid",
 			"Spec.VirtualHost.RateLimitPolicy is invalid: %s", err)
 		return
 	}
+	if rlp != nil && rlp.LocalBucket != "" {
+		validCond.AddError(sesame_api_v1.ConditionTypeRouteError, "RateLimitPolicyNotValid",
+			"Spec.VirtualHost.RateLimitPolicy is invalid: local rate limit buckets can only be referenced by routes")
+		return
+	}
 	insecure.RateLimitPolicy = rlp
 
 	addRoutes(insecure, routes)
@@ -352,6 +393,7 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *sesame_api_v1.HTTPProxy) {
 	if tlsEnabled && proxy.Spec.TCPProxy == nil {
 		secure := p.dag.EnsureSecureVirtualHost(host)
 		secure.CORSPolicy = cp
+		secure.LocalReplies = lr
 
 		rlp, err := rateLimitPolicy(proxy.Spec.VirtualHost.RateLimitPolicy)
 		if err != nil {
@@ -365,6 +407,77 @@ func (p *HTTPProxyProcessor) computeHTTPProxy(proxy *sesame_api_v1.HTTPProxy) {
 	}
 }
 
+// localReplies returns the custom error pages of the proxy's local
+// reply policy, reading their bodies from ConfigMaps in the proxy's
+// namespace.
+func (p *HTTPProxyProcessor) localReplies(proxy *sesame_api_v1.HTTPProxy) ([]LocalReply, error) {
+	policy := proxy.Spec.VirtualHost.LocalReplyPolicy
+	if policy == nil {
+		return nil, nil
+	}
+
+	var replies []LocalReply
+	seen := map[int]bool{}
+
+	for _, page := range policy.ErrorPages {
+		if page.StatusCode < 400 || page.StatusCode > 599 {
+			return nil, fmt.Errorf("status code %d is not in the range 400-599", page.StatusCode)
+		}
+		if seen[page.StatusCode] {
+			return nil, fmt.Errorf("duplicate error page for status code %d", page.StatusCode)
+		}
+		seen[page.StatusCode] = true
+
+		name := types.NamespacedName{Namespace: proxy.Namespace, Name: page.ConfigMap}
+		body, err := p.source.LookupConfigMapKey(name, page.Key)
+		if err != nil {
+			return nil, fmt.Errorf("error page for status code %d: ConfigMap %q: %s", page.StatusCode, name, err)
+		}
+
+		replies = append(replies, LocalReply{
+			StatusCode:  uint32(page.StatusCode),
+			Body:        body,
+			ContentType: stringOrDefault(page.ContentType, "text/plain"),
+		})
+	}
+
+	return replies, nil
+}
+
+// routeLocalRateLimitValid returns an error if the route rate limit
+// policy defines local rate limit buckets or per-connection limits,
+// or references a bucket that is not defined on the root proxy's
+// virtual host. Envoy keeps a single per-connection rate limiter for
+// each connection, so per-connection limits of different routes would
+// share it.
+func routeLocalRateLimitValid(rlp *RateLimitPolicy, rootProxy *sesame_api_v1.HTTPProxy) error {
+	if rlp == nil {
+		return nil
+	}
+
+	if rlp.Local != nil && len(rlp.Local.Buckets) > 0 {
+		return fmt.Errorf("local rate limit buckets can only be defined on the virtual host")
+	}
+
+	if rlp.Local != nil && rlp.Local.PerConnection {
+		return fmt.Errorf("per-connection local rate limits can only be defined on the virtual host")
+	}
+
+	if rlp.LocalBucket == "" {
+		return nil
+	}
+
+	if vhrlp := rootProxy.Spec.VirtualHost.RateLimitPolicy; vhrlp != nil && vhrlp.Local != nil {
+		for _, b := range vhrlp.Local.Buckets {
+			if b.Name == rlp.LocalBucket {
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("local rate limit bucket %q is not defined on the virtual host", rlp.LocalBucket)
+}
+
 type vhost interface {
 	addRoute(*Route)
 }
@@ -501,8 +614,21 @@ func (p *HTTPProxyProcessor) computeRoutes(
 			return nil
 		}
 
-		// A route may have no services defined only if a RequestRedirectPolicy is defined.
-		if len(route.Services) < 1 && route.RequestRedirectPolicy == nil {
+		if route.RequestRedirectPolicy != nil && route.DirectResponsePolicy != nil {
+			validCond.AddError(sesame_api_v1.ConditionTypeRouteError, "PolicyConflict",
+				"route cannot specify both requestRedirectPolicy and directResponsePolicy")
+			return nil
+		}
+
+		if route.DirectResponsePolicy != nil && len(route.Services) > 0 {
+			validCond.AddError(sesame_api_v1.ConditionTypeRouteError, "PolicyConflict",
+				"route cannot specify both services and directResponsePolicy")
+			return nil
+		}
+
+		// A route may have no services defined only if a RequestRedirectPolicy
+		// or a DirectResponsePolicy is defined.
+		if len(route.Services) < 1 && route.RequestRedirectPolicy == nil && route.DirectResponsePolicy == nil {
 			validCond.AddError(sesame_api_v1.ConditionTypeRouteError, "NoServicesPresent",
 				"route.services must have at least one entry")
 			return nil
@@ -521,22 +647,45 @@ func (p *HTTPProxyProcessor) computeRoutes(
 				"route.rateLimitPolicy is invalid: %s", err)
 			return nil
 		}
+		if err := routeLocalRateLimitValid(rlp, rootProxy); err != nil {
+			validCond.AddErrorf(sesame_api_v1.ConditionTypeRouteError, "RateLimitPolicyNotValid",
+				"route.rateLimitPolicy is invalid: %s", err)
+			return nil
+		}
+
+		rp, err := retryPolicy(route.RetryPolicy)
+		if err != nil {
+			validCond.AddErrorf(sesame_api_v1.ConditionTypeRouteError, "RetryPolicyNotValid",
+				"route.retryPolicy is invalid: %s", err)
+			return nil
+		}
+
+		headerMatchConditions := mergeHeaderMatchConditions(routeConditions)
+
+		hp, err := hedgePolicy(route.HedgePolicy, rp, headerMatchConditions)
+		if err != nil {
+			validCond.AddErrorf(sesame_api_v1.ConditionTypeRouteError, "HedgePolicyNotValid",
+				"route.hedgePolicy is invalid: %s", err)
+			return nil
+		}
 
 		requestHashPolicies, lbPolicy := loadBalancerRequestHashPolicies(route.LoadBalancerPolicy, validCond)
 
 		r := &Route{
 			PathMatchCondition:    mergePathMatchConditions(routeConditions),
-			HeaderMatchConditions: mergeHeaderMatchConditions(routeConditions),
+			HeaderMatchConditions: headerMatchConditions,
 			Websocket:             route.EnableWebsockets,
 			HTTPSUpgrade:          routeEnforceTLS(enforceTLS, route.PermitInsecure && !p.DisablePermitInsecure),
 			TimeoutPolicy:         tp,
-			RetryPolicy:           retryPolicy(route.RetryPolicy),
+			RetryPolicy:           rp,
+			HedgePolicy:           hp,
 			RequestHeadersPolicy:  reqHP,
 			ResponseHeadersPolicy: respHP,
 			CookieRewritePolicies: cookieRP,
 			RateLimitPolicy:       rlp,
 			RequestHashPolicies:   requestHashPolicies,
 			Redirect:              redirectRoutePolicy(route.RequestRedirectPolicy),
+			DirectResponse:        directResponsePolicy(route.DirectResponsePolicy),
 		}
 
 		// If the enclosing root proxy enabled authorization,
@@ -600,12 +749,37 @@ func (p *HTTPProxyProcessor) computeRoutes(
 		}
 
 		for _, service := range route.Services {
+			if service.ExtensionService != nil {
+				c := p.extensionServiceCluster(proxy, service, dynamicHeaders, validCond)
+				if c == nil {
+					return nil
+				}
+				if service.Mirror && r.MirrorPolicy != nil {
+					validCond.AddError(sesame_api_v1.ConditionTypeServiceError, "OnlyOneMirror",
+						"only one service per route may be nominated as mirror")
+					return nil
+				}
+				if service.Mirror {
+					r.MirrorPolicy = &MirrorPolicy{
+						Cluster: c,
+					}
+				} else {
+					r.Clusters = append(r.Clusters, c)
+				}
+				continue
+			}
+
 			if service.Port < 1 || service.Port > 65535 {
 				validCond.AddErrorf(sesame_api_v1.ConditionTypeServiceError, "ServicePortInvalid",
 					"service %q: port must be in the range 1-65535", service.Name)
 				return nil
 			}
-			m := types.NamespacedName{Name: service.Name, Namespace: proxy.Namespace}
+			m := types.NamespacedName{Name: service.Name, Namespace: stringOrDefault(service.Namespace, proxy.Namespace)}
+			if !p.source.ServiceReferencePermitted(sesame_api_v1alpha1.KindService, m, proxy.Namespace) {
+				validCond.AddErrorf(sesame_api_v1.ConditionTypeServiceError, "ServiceReferenceNotPermitted",
+					"Spec.Routes service %q is not permitted by a ServiceReferenceGrant in namespace %q", m.Name, m.Namespace)
+				continue
+			}
 			s, err := p.dag.EnsureService(m, intstr.FromInt(service.Port), p.source, p.EnableExternalNameService)
 			if err != nil {
 				validCond.AddErrorf(sesame_api_v1.ConditionTypeServiceError, "ServiceUnresolvedReference",
@@ -627,7 +801,7 @@ func (p *HTTPProxyProcessor) computeRoutes(
 				// delegated to the proxy's namespace.
 				// By default, a non-namespaced CACertificate is expected to reside in the proxy's namespace.
 				caCertNamespacedName := k8s.NamespacedNameFrom(service.UpstreamValidation.CACertificate, k8s.DefaultNamespace(proxy.Namespace))
-				if !p.source.DelegationPermitted(caCertNamespacedName, proxy.Namespace) {
+				if !p.dag.DelegationPermitted(caCertNamespacedName, proxy.Namespace, p.source) {
 					validCond.AddErrorf(sesame_api_v1.ConditionTypeTLSError, "CACertificateNotDelegated",
 						"service.UpstreamValidation.CACertificate Secret %q is not configured for certificate delegation", caCertNamespacedName)
 					return nil
@@ -664,6 +838,20 @@ func (p *HTTPProxyProcessor) computeRoutes(
 				return nil
 			}
 
+			connPolicy, err := connectionPolicy(p.ConnectionPolicy, service.ConnectionPolicy)
+			if err != nil {
+				validCond.AddErrorf(sesame_api_v1.ConditionTypeServiceError, "ConnectionPolicyInvalid",
+					"%s on service connection policy", err)
+				return nil
+			}
+
+			breakers := circuitBreakers(service.CircuitBreakers)
+			for _, conflict := range circuitBreakerConflicts(breakers, service.ConnectionPolicy) {
+				validCond.AddWarningf(sesame_api_v1.ConditionTypeServiceError, "CircuitBreakersConflict",
+					"service %q: %s", service.Name, conflict)
+			}
+			breakers = withDefaultRetryBudget(breakers, p.RetryBudget, s)
+
 			var clientCertSecret *Secret
 			if p.ClientCertificate != nil {
 				clientCertSecret, err = p.source.LookupSecret(*p.ClientCertificate, validSecret)
@@ -687,6 +875,8 @@ func (p *HTTPProxyProcessor) computeRoutes(
 				SNI:                   determineSNI(r.RequestHeadersPolicy, reqHP, s),
 				DNSLookupFamily:       string(p.DNSLookupFamily),
 				ClientCertificate:     clientCertSecret,
+				ConnectionPolicy:      connPolicy,
+				CircuitBreakers:       breakers,
 			}
 			if service.Mirror && r.MirrorPolicy != nil {
 				validCond.AddError(sesame_api_v1.ConditionTypeServiceError, "OnlyOneMirror",
@@ -701,7 +891,7 @@ func (p *HTTPProxyProcessor) computeRoutes(
 				r.Clusters = append(r.Clusters, c)
 			}
 		}
-		if len(r.Clusters) == 0 && route.RequestRedirectPolicy == nil {
+		if len(r.Clusters) == 0 && r.Redirect == nil && r.DirectResponse == nil {
 			r.DirectResponse = directResponse(http.StatusServiceUnavailable)
 		}
 
@@ -721,6 +911,80 @@ func (p *HTTPProxyProcessor) computeRoutes(
 	return routes
 }
 
+// extensionServiceCluster returns a Cluster that routes traffic to the
+// ExtensionService that the route service refers to, or nil if the
+// reference is not valid.
+func (p *HTTPProxyProcessor) extensionServiceCluster(proxy *sesame_api_v1.HTTPProxy, service sesame_api_v1.Service, dynamicHeaders map[string]string, validCond *sesame_api_v1.DetailedCondition) *Cluster {
+	if service.Name != "" || service.Port != 0 {
+		validCond.AddErrorf(sesame_api_v1.ConditionTypeServiceError, "ServiceNotValid",
+			"service %q: name and port cannot be combined with extensionService", service.Name)
+		return nil
+	}
+
+	ref := defaultExtensionRef(*service.ExtensionService)
+	if ref.APIVersion != sesame_api_v1alpha1.GroupVersion.String() {
+		validCond.AddErrorf(sesame_api_v1.ConditionTypeServiceError, "ExtensionServiceBadResourceVersion",
+			"Spec.Routes extensionService specifies an unsupported resource version %q", ref.APIVersion)
+		return nil
+	}
+
+	extensionName := types.NamespacedName{
+		Name:      ref.Name,
+		Namespace: stringOrDefault(ref.Namespace, proxy.Namespace),
+	}
+
+	if !p.source.ServiceReferencePermitted(sesame_api_v1alpha1.KindExtensionService, extensionName, proxy.Namespace) {
+		validCond.AddErrorf(sesame_api_v1.ConditionTypeServiceError, "ServiceReferenceNotPermitted",
+			"Spec.Routes extension service %q is not permitted by a ServiceReferenceGrant in namespace %q", extensionName.Name, extensionName.Namespace)
+		return nil
+	}
+
+	ext := p.dag.GetExtensionCluster(ExtensionClusterName(extensionName))
+	if ext == nil {
+		validCond.AddErrorf(sesame_api_v1.ConditionTypeServiceError, "ExtensionServiceNotFound",
+			"Spec.Routes extension service %q not found", extensionName)
+		return nil
+	}
+
+	// The ExtensionService defines how Envoy connects to its
+	// endpoints, so the route service can't override that.
+	if service.Protocol != nil || service.UpstreamValidation != nil || service.ConnectionPolicy != nil || service.CircuitBreakers != nil {
+		validCond.AddWarningf(sesame_api_v1.ConditionTypeServiceError, "IgnoredField",
+			"extension service %q: protocol, validation, connectionPolicy and circuitBreakers are ignored", extensionName)
+	}
+
+	dynamicHeaders["Sesame_SERVICE_NAME"] = ref.Name
+	delete(dynamicHeaders, "Sesame_SERVICE_PORT")
+
+	reqHP, err := headersPolicyService(p.RequestHeadersPolicy, service.RequestHeadersPolicy, dynamicHeaders)
+	if err != nil {
+		validCond.AddErrorf(sesame_api_v1.ConditionTypeServiceError, "RequestHeadersPolicyInvalid",
+			"%s on request headers", err)
+		return nil
+	}
+	respHP, err := headersPolicyService(p.ResponseHeadersPolicy, service.ResponseHeadersPolicy, dynamicHeaders)
+	if err != nil {
+		validCond.AddErrorf(sesame_api_v1.ConditionTypeServiceError, "ResponseHeadersPolicyInvalid",
+			"%s on response headers", err)
+		return nil
+	}
+
+	cookieRP, err := cookieRewritePolicies(service.CookieRewritePolicies)
+	if err != nil {
+		validCond.AddErrorf(sesame_api_v1.ConditionTypeRouteError, "CookieRewritePoliciesInvalid",
+			"%s on service cookie rewrite rules", err)
+		return nil
+	}
+
+	return &Cluster{
+		ExtensionCluster:      ext,
+		Weight:                uint32(service.Weight),
+		RequestHeadersPolicy:  reqHP,
+		ResponseHeadersPolicy: respHP,
+		CookieRewritePolicies: cookieRP,
+	}
+}
+
 // processHTTPProxyTCPProxy processes the spec.tcpproxy stanza in a HTTPProxy document
 // following the chain of spec.tcpproxy.include references. It returns true if processing
 // was successful, otherwise false if an error was encountered. The details of the error
@@ -760,7 +1024,18 @@ func (p *HTTPProxyProcessor) processHTTPProxyTCPProxy(validCond *sesame_api_v1.D
 	if len(tcpproxy.Services) > 0 {
 		var proxy TCPProxy
 		for _, service := range httpproxy.Spec.TCPProxy.Services {
-			m := types.NamespacedName{Name: service.Name, Namespace: httpproxy.Namespace}
+			if service.ExtensionService != nil {
+				validCond.AddError(sesame_api_v1.ConditionTypeTCPProxyError, "ExtensionServiceNotSupported",
+					"Spec.TCPProxy services cannot refer to an extensionService")
+				return false
+			}
+
+			m := types.NamespacedName{Name: service.Name, Namespace: stringOrDefault(service.Namespace, httpproxy.Namespace)}
+			if !p.source.ServiceReferencePermitted(sesame_api_v1alpha1.KindService, m, httpproxy.Namespace) {
+				validCond.AddErrorf(sesame_api_v1.ConditionTypeTCPProxyError, "ServiceReferenceNotPermitted",
+					"Spec.TCPProxy service %q is not permitted by a ServiceReferenceGrant in namespace %q", m.Name, m.Namespace)
+				return false
+			}
 			s, err := p.dag.EnsureService(m, intstr.FromInt(service.Port), p.source, p.EnableExternalNameService)
 			if err != nil {
 				validCond.AddErrorf(sesame_api_v1.ConditionTypeTCPProxyError, "ServiceUnresolvedReference",
@@ -775,6 +1050,25 @@ func (p *HTTPProxyProcessor) processHTTPProxyTCPProxy(validCond *sesame_api_v1.D
 				return false
 			}
 
+			connPolicy, err := connectionPolicy(p.ConnectionPolicy, service.ConnectionPolicy)
+			if err != nil {
+				validCond.AddErrorf(sesame_api_v1.ConditionTypeTCPProxyError, "ConnectionPolicyInvalid",
+					"%s on service connection policy", err)
+				return false
+			}
+			if connPolicy != nil {
+				// The HTTP connection pool settings do not apply to TCP proxying.
+				connPolicy.MaxRequestsPerConnection = 0
+				connPolicy.MaxConcurrentStreams = 0
+				connPolicy.IdleTimeout = timeout.DefaultSetting()
+			}
+
+			breakers := circuitBreakers(service.CircuitBreakers)
+			for _, conflict := range circuitBreakerConflicts(breakers, service.ConnectionPolicy) {
+				validCond.AddWarningf(sesame_api_v1.ConditionTypeTCPProxyError, "CircuitBreakersConflict",
+					"service %q: %s", service.Name, conflict)
+			}
+
 			proxy.Clusters = append(proxy.Clusters, &Cluster{
 				Upstream:             s,
 				Weight:               uint32(service.Weight),
@@ -782,6 +1076,8 @@ func (p *HTTPProxyProcessor) processHTTPProxyTCPProxy(validCond *sesame_api_v1.D
 				LoadBalancerPolicy:   lbPolicy,
 				TCPHealthCheckPolicy: tcpHealthCheckPolicy(tcpproxy.HealthCheckPolicy),
 				SNI:                  s.ExternalName,
+				ConnectionPolicy:     connPolicy,
+				CircuitBreakers:      breakers,
 			})
 		}
 		secure := p.dag.EnsureSecureVirtualHost(host)
@@ -1088,6 +1384,18 @@ func directResponse(statusCode uint32) *DirectResponse {
 	}
 }
 
+// directResponsePolicy builds a *dag.DirectResponse for the supplied direct response policy.
+func directResponsePolicy(policy *sesame_api_v1.HTTPDirectResponsePolicy) *DirectResponse {
+	if policy == nil {
+		return nil
+	}
+
+	return &DirectResponse{
+		StatusCode: uint32(policy.StatusCode),
+		Body:       policy.Body,
+	}
+}
+
 // redirectRoutePolicy builds a *dag.Redirect for the supplied redirect policy.
 func redirectRoutePolicy(redirect *sesame_api_v1.HTTPRequestRedirectPolicy) *Redirect {
 	if redirect == nil {
@@ -17,6 +17,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"k8s.io/apimachinery/pkg/util/intstr"
 
@@ -49,6 +50,10 @@ type IngressProcessor struct {
 
 	// Response headers that will be set on all routes (optional).
 	ResponseHeadersPolicy *HeadersPolicy
+
+	// CertificateExpiryWarning is how long before expiry a serving
+	// certificate is logged as expiring. Zero disables the warning.
+	CertificateExpiryWarning time.Duration
 }
 
 // Run translates Ingresses into DAG objects and
@@ -86,7 +91,7 @@ func (p *IngressProcessor) computeSecureVirtualhosts() {
 				continue
 			}
 
-			if !p.source.DelegationPermitted(secretName, ing.GetNamespace()) {
+			if !p.dag.DelegationPermitted(secretName, ing.GetNamespace(), p.source) {
 				p.WithError(err).
 					WithField("name", ing.GetName()).
 					WithField("namespace", ing.GetNamespace()).
@@ -95,6 +100,27 @@ func (p *IngressProcessor) computeSecureVirtualhosts() {
 				continue
 			}
 
+			// Ingress has no status to report certificate
+			// expiry in, so just log it.
+			if cert := sec.Certificate; cert != nil {
+				now := time.Now()
+				p.dag.recheckCertificate(cert, now, p.CertificateExpiryWarning)
+				switch {
+				case cert.Expired(now):
+					p.WithField("name", ing.GetName()).
+						WithField("namespace", ing.GetNamespace()).
+						WithField("secret", secretName).
+						WithField("notAfter", cert.NotAfter).
+						Error("certificate has expired")
+				case cert.ExpiresWithin(now, p.CertificateExpiryWarning):
+					p.WithField("name", ing.GetName()).
+						WithField("namespace", ing.GetNamespace()).
+						WithField("secret", secretName).
+						WithField("notAfter", cert.NotAfter).
+						Warn("certificate is about to expire")
+				}
+			}
+
 			// We have validated the TLS secrets, so we can go
 			// ahead and create the SecureVirtualHost for this
 			// Ingress.
@@ -28,17 +28,27 @@ type ListenerProcessor struct{}
 
 // Run adds HTTP and HTTPS listeners to the DAG if there are
 // virtual hosts and secure virtual hosts already defined as
-// roots in the DAG.
+// roots in the DAG. Listeners are also added for the Envoy
+// listeners that Gateways are mapped onto.
 func (p *ListenerProcessor) Run(dag *DAG, _ *KubernetesCache) {
-	p.buildHTTPListener(dag)
-	p.buildHTTPSListener(dag)
+	p.buildHTTPListener(dag, HTTP_LISTENER_NAME, 80, dag.VirtualHosts)
+	p.buildHTTPSListener(dag, HTTPS_LISTENER_NAME, 443, dag.SecureVirtualHosts)
+
+	for _, name := range sortedKeys(dag.ListenerVirtualHosts) {
+		p.buildHTTPListener(dag, name, dag.ListenerPorts[name], dag.ListenerVirtualHosts[name])
+	}
+
+	for _, name := range sortedSecureKeys(dag.ListenerSecureVirtualHosts) {
+		p.buildHTTPSListener(dag, name, dag.ListenerPorts[name], dag.ListenerSecureVirtualHosts[name])
+	}
 }
 
-// buildHTTPListener builds a *dag.Listener for the vhosts bound to port 80.
-// The list of virtual hosts attached to the listener will be sorted by hostname.
-func (p *ListenerProcessor) buildHTTPListener(dag *DAG) {
+// buildHTTPListener builds a *dag.Listener with the given name for the
+// given vhosts. The list of virtual hosts attached to the listener will
+// be sorted by hostname.
+func (p *ListenerProcessor) buildHTTPListener(dag *DAG, name string, port int, virtualHosts map[string]*VirtualHost) {
 	var vhosts []*VirtualHost
-	for _, vh := range dag.VirtualHosts {
+	for _, vh := range virtualHosts {
 		if vh.Valid() {
 			vhosts = append(vhosts, vh)
 		}
@@ -53,19 +63,20 @@ func (p *ListenerProcessor) buildHTTPListener(dag *DAG) {
 	})
 
 	http := &Listener{
-		Name:         HTTP_LISTENER_NAME,
-		Port:         80,
+		Name:         name,
+		Port:         port,
 		VirtualHosts: vhosts,
 	}
 
 	dag.Listeners = append(dag.Listeners, http)
 }
 
-// buildHTTPSListener builds a *dag.Listener for the vhosts bound to port 443.
-// The list of virtual hosts attached to the listener will be sorted by hostname.
-func (p *ListenerProcessor) buildHTTPSListener(dag *DAG) {
+// buildHTTPSListener builds a *dag.Listener with the given name for the
+// given secure vhosts. The list of virtual hosts attached to the listener
+// will be sorted by hostname.
+func (p *ListenerProcessor) buildHTTPSListener(dag *DAG, name string, port int, secureVirtualHosts map[string]*SecureVirtualHost) {
 	var vhosts []*SecureVirtualHost
-	for _, svh := range dag.SecureVirtualHosts {
+	for _, svh := range secureVirtualHosts {
 		if svh.Valid() {
 			vhosts = append(vhosts, svh)
 		}
@@ -80,10 +91,28 @@ func (p *ListenerProcessor) buildHTTPSListener(dag *DAG) {
 	})
 
 	https := &Listener{
-		Name:               HTTPS_LISTENER_NAME,
-		Port:               443,
+		Name:               name,
+		Port:               port,
 		SecureVirtualHosts: vhosts,
 	}
 
 	dag.Listeners = append(dag.Listeners, https)
 }
+
+func sortedKeys(m map[string]map[string]*VirtualHost) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedSecureKeys(m map[string]map[string]*SecureVirtualHost) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
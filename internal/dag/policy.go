@@ -16,7 +16,9 @@ package dag
 import (
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"reflect"
 	"regexp"
 	"strings"
 	"time"
@@ -26,6 +28,7 @@ import (
 	gatewayapi_v1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
 	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
+	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
 	"github.com/projectsesame/sesame/internal/annotation"
 	"github.com/projectsesame/sesame/internal/timeout"
 	"github.com/sirupsen/logrus"
@@ -70,9 +73,9 @@ func retryOn(ro []sesame_api_v1.RetryOn) string {
 	return strings.Join(ss, ",")
 }
 
-func retryPolicy(rp *sesame_api_v1.RetryPolicy) *RetryPolicy {
+func retryPolicy(rp *sesame_api_v1.RetryPolicy) (*RetryPolicy, error) {
 	if rp == nil {
-		return nil
+		return nil, nil
 	}
 
 	// If PerTryTimeout is not a valid duration string, use the Envoy default
@@ -96,12 +99,51 @@ func retryPolicy(rp *sesame_api_v1.RetryPolicy) *RetryPolicy {
 		numRetries = 1
 	}
 
-	return &RetryPolicy{
-		RetryOn:              retryOn(rp.RetryOn),
-		RetriableStatusCodes: rp.RetriableStatusCodes,
-		NumRetries:           uint32(numRetries),
-		PerTryTimeout:        perTryTimeout,
+	policy := &RetryPolicy{
+		RetryOn:                       retryOn(rp.RetryOn),
+		RetriableStatusCodes:          rp.RetriableStatusCodes,
+		NumRetries:                    uint32(numRetries),
+		PerTryTimeout:                 perTryTimeout,
+		HostSelectionRetryMaxAttempts: rp.HostSelectionRetryMaxAttempts,
+	}
+
+	for _, predicate := range rp.RetryHostPredicates {
+		policy.RetryHostPredicates = append(policy.RetryHostPredicates, string(predicate))
+	}
+
+	if rp.RetryPriorityPredicate != nil {
+		policy.PriorityUpdateFrequency = rp.RetryPriorityPredicate.UpdateFrequency
+	}
+
+	perTryIdleTimeout, err := timeout.Parse(rp.PerTryIdleTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing per-try idle timeout: %w", err)
+	}
+	policy.PerTryIdleTimeout = perTryIdleTimeout
+
+	if bo := rp.RetryBackOff; bo != nil {
+		base, err := time.ParseDuration(bo.BaseInterval)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing back-off base interval: %w", err)
+		}
+		if base <= 0 {
+			return nil, fmt.Errorf("back-off base interval %q must be positive", bo.BaseInterval)
+		}
+		policy.BackOffBaseInterval = base
+
+		if len(bo.MaxInterval) > 0 {
+			maxInterval, err := time.ParseDuration(bo.MaxInterval)
+			if err != nil {
+				return nil, fmt.Errorf("error parsing back-off max interval: %w", err)
+			}
+			if maxInterval < base {
+				return nil, fmt.Errorf("back-off max interval %q must not be less than base interval %q", bo.MaxInterval, bo.BaseInterval)
+			}
+			policy.BackOffMaxInterval = maxInterval
+		}
 	}
+
+	return policy, nil
 }
 
 func headersPolicyService(defaultPolicy *HeadersPolicy, policy *sesame_api_v1.HeadersPolicy, dynamicHeaders map[string]string) (*HeadersPolicy, error) {
@@ -116,22 +158,25 @@ func headersPolicyService(defaultPolicy *HeadersPolicy, policy *sesame_api_v1.He
 		userPolicy = &HeadersPolicy{}
 	}
 
+	// A header that the user policy on the object manages in any way
+	// is not touched by the defaults.
+	userHeaders := sets.StringKeySet(userPolicy.Set).
+		Union(sets.StringKeySet(userPolicy.Add)).
+		Union(sets.StringKeySet(userPolicy.AddIfAbsent))
+
 	if userPolicy.Set == nil {
 		userPolicy.Set = make(map[string]string, len(defaultPolicy.Set))
 	}
-	for k, v := range defaultPolicy.Set {
-		key := http.CanonicalHeaderKey(k)
-		if key == "Host" {
-			return nil, fmt.Errorf("rewriting %q header is not supported", key)
-		}
-		if msgs := validation.IsHTTPHeaderName(key); len(msgs) != 0 {
-			return nil, fmt.Errorf("invalid set header %q: %v", key, msgs)
-		}
-		// if the user policy set on the object does not contain this header then use the default
-		if _, exists := userPolicy.Set[key]; !exists {
-			userPolicy.Set[key] = escapeHeaderValue(v, dynamicHeaders)
-		}
+	if userPolicy.Set, err = defaultHeaderValues("set", userPolicy.Set, defaultPolicy.Set, userHeaders, dynamicHeaders); err != nil {
+		return nil, err
+	}
+	if userPolicy.Add, err = defaultHeaderValues("add", userPolicy.Add, defaultPolicy.Add, userHeaders, dynamicHeaders); err != nil {
+		return nil, err
+	}
+	if userPolicy.AddIfAbsent, err = defaultHeaderValues("addIfAbsent", userPolicy.AddIfAbsent, defaultPolicy.AddIfAbsent, userHeaders, dynamicHeaders); err != nil {
+		return nil, err
 	}
+
 	// add any default remove header policy if not already set
 	remove := sets.NewString()
 	for _, entry := range userPolicy.Remove {
@@ -150,6 +195,33 @@ func headersPolicyService(defaultPolicy *HeadersPolicy, policy *sesame_api_v1.He
 	return userPolicy, nil
 }
 
+// defaultHeaderValues adds the default header values to headers,
+// except for the headers that the user policy manages.
+func defaultHeaderValues(kind string, headers, defaults map[string]string, userHeaders sets.String, dynamicHeaders map[string]string) (map[string]string, error) {
+	for k, v := range defaults {
+		key := http.CanonicalHeaderKey(k)
+		if key == "Host" {
+			return nil, fmt.Errorf("rewriting %q header is not supported", key)
+		}
+		if msgs := validation.IsHTTPHeaderName(key); len(msgs) != 0 {
+			return nil, fmt.Errorf("invalid %s header %q: %v", kind, key, msgs)
+		}
+		if userHeaders.Has(key) {
+			continue
+		}
+		value, err := escapeHeaderValue(v, dynamicHeaders)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s header %q: %v", kind, key, err)
+		}
+		if headers == nil {
+			headers = make(map[string]string, len(defaults))
+		}
+		headers[key] = value
+	}
+
+	return headers, nil
+}
+
 func headersPolicyRoute(policy *sesame_api_v1.HeadersPolicy, allowHostRewrite bool, dynamicHeaders map[string]string) (*HeadersPolicy, error) {
 	if policy == nil {
 		return nil, nil
@@ -172,7 +244,21 @@ func headersPolicyRoute(policy *sesame_api_v1.HeadersPolicy, allowHostRewrite bo
 		if msgs := validation.IsHTTPHeaderName(key); len(msgs) != 0 {
 			return nil, fmt.Errorf("invalid set header %q: %v", key, msgs)
 		}
-		set[key] = escapeHeaderValue(entry.Value, dynamicHeaders)
+		value, err := escapeHeaderValue(entry.Value, dynamicHeaders)
+		if err != nil {
+			return nil, fmt.Errorf("invalid set header %q: %v", key, err)
+		}
+		set[key] = value
+	}
+
+	seen := sets.StringKeySet(set)
+	add, err := headerValues("add", policy.Add, seen, dynamicHeaders)
+	if err != nil {
+		return nil, err
+	}
+	addIfAbsent, err := headerValues("addIfAbsent", policy.AddIfAbsent, seen, dynamicHeaders)
+	if err != nil {
+		return nil, err
 	}
 
 	remove := sets.NewString()
@@ -197,11 +283,44 @@ func headersPolicyRoute(policy *sesame_api_v1.HeadersPolicy, allowHostRewrite bo
 
 	return &HeadersPolicy{
 		Set:         set,
+		Add:         add,
+		AddIfAbsent: addIfAbsent,
 		HostRewrite: hostRewrite,
 		Remove:      rl,
 	}, nil
 }
 
+// headerValues returns the given header values keyed by their canonical
+// header name, or nil if there are none. Each header may only be managed
+// once in a policy, so seen records the headers that have been used.
+func headerValues(kind string, entries []sesame_api_v1.HeaderValue, seen sets.String, dynamicHeaders map[string]string) (map[string]string, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	headers := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		key := http.CanonicalHeaderKey(entry.Name)
+		if seen.Has(key) {
+			return nil, fmt.Errorf("duplicate header addition: %q", key)
+		}
+		if key == "Host" {
+			return nil, fmt.Errorf("rewriting %q header is not supported", key)
+		}
+		if msgs := validation.IsHTTPHeaderName(key); len(msgs) != 0 {
+			return nil, fmt.Errorf("invalid %s header %q: %v", kind, key, msgs)
+		}
+		value, err := escapeHeaderValue(entry.Value, dynamicHeaders)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s header %q: %v", kind, key, err)
+		}
+		seen.Insert(key)
+		headers[key] = value
+	}
+
+	return headers, nil
+}
+
 // headersPolicyGatewayAPI builds a *HeaderPolicy for the supplied HTTPRequestHeaderFilter.
 // TODO: Take care about the order of operators once https://github.com/kubernetes-sigs/gateway-api/issues/480 was solved.
 func headersPolicyGatewayAPI(hf *gatewayapi_v1alpha2.HTTPRequestHeaderFilter) (*HeadersPolicy, error) {
@@ -227,7 +346,12 @@ func headersPolicyGatewayAPI(hf *gatewayapi_v1alpha2.HTTPRequestHeaderFilter) (*
 			errlist = append(errlist, fmt.Errorf("invalid set header %q: %v", key, msgs))
 			continue
 		}
-		set[key] = escapeHeaderValue(setHeader.Value, nil)
+		value, err := escapeHeaderValue(setHeader.Value, nil)
+		if err != nil {
+			errlist = append(errlist, fmt.Errorf("invalid set header %q: %v", key, err))
+			continue
+		}
+		set[key] = value
 	}
 	for _, addHeader := range hf.Add {
 		key := http.CanonicalHeaderKey(string(addHeader.Name))
@@ -243,7 +367,12 @@ func headersPolicyGatewayAPI(hf *gatewayapi_v1alpha2.HTTPRequestHeaderFilter) (*
 			errlist = append(errlist, fmt.Errorf("invalid add header %q: %v", key, msgs))
 			continue
 		}
-		add[key] = escapeHeaderValue(addHeader.Value, nil)
+		value, err := escapeHeaderValue(addHeader.Value, nil)
+		if err != nil {
+			errlist = append(errlist, fmt.Errorf("invalid add header %q: %v", key, err))
+			continue
+		}
+		add[key] = value
 	}
 
 	for _, k := range hf.Remove {
@@ -275,49 +404,53 @@ func headersPolicyGatewayAPI(hf *gatewayapi_v1alpha2.HTTPRequestHeaderFilter) (*
 	}, utilerrors.NewAggregate(errlist)
 }
 
-func escapeHeaderValue(value string, dynamicHeaders map[string]string) string {
+// headerOperatorRegexp matches a command operator, such as
+// "%REQ(X-Request-Id)%", in a header value whose '%'s have been escaped.
+var headerOperatorRegexp = regexp.MustCompile(`%%([A-Z_]+)(\([^)]*\))?(:[0-9]+)?%%`)
+
+// headerNamesArgRegexp matches the arguments of the REQ, RESP and TRAILER
+// command operators: a header name, optionally followed by "?" and an
+// alternative header name.
+var headerNamesArgRegexp = regexp.MustCompile(`^\(:?[\w-]+(\?:?[\w-]+)?\)$`)
+
+func escapeHeaderValue(value string, dynamicHeaders map[string]string) (string, error) {
 	// Envoy supports %-encoded variables, so literal %'s in the header's value must be escaped.  See:
 	// https://www.envoyproxy.io/docs/envoy/latest/configuration/http/http_conn_man/headers#custom-request-response-headers
-	// Only allow a specific set of known good Envoy dynamic headers to pass through unescaped
+	// Command operators that Envoy supports are passed through unescaped once they
+	// have been validated with the access log format parser; anything else is literal.
 	if !strings.Contains(value, "%") {
-		return value
+		return value, nil
 	}
 	escapedValue := strings.ReplaceAll(value, "%", "%%")
 	for dynamicVar, dynamicVal := range dynamicHeaders {
 		escapedValue = strings.ReplaceAll(escapedValue, "%%"+dynamicVar+"%%", dynamicVal)
 	}
-	for _, envoyVar := range []string{
-		"DOWNSTREAM_REMOTE_ADDRESS",
-		"DOWNSTREAM_REMOTE_ADDRESS_WITHOUT_PORT",
-		"DOWNSTREAM_LOCAL_ADDRESS",
-		"DOWNSTREAM_LOCAL_ADDRESS_WITHOUT_PORT",
-		"DOWNSTREAM_LOCAL_PORT",
-		"DOWNSTREAM_LOCAL_URI_SAN",
-		"DOWNSTREAM_PEER_URI_SAN",
-		"DOWNSTREAM_LOCAL_SUBJECT",
-		"DOWNSTREAM_PEER_SUBJECT",
-		"DOWNSTREAM_PEER_ISSUER",
-		"DOWNSTREAM_TLS_SESSION_ID",
-		"DOWNSTREAM_TLS_CIPHER",
-		"DOWNSTREAM_TLS_VERSION",
-		"DOWNSTREAM_PEER_FINGERPRINT_256",
-		"DOWNSTREAM_PEER_FINGERPRINT_1",
-		"DOWNSTREAM_PEER_SERIAL",
-		"DOWNSTREAM_PEER_CERT",
-		"DOWNSTREAM_PEER_CERT_V_START",
-		"DOWNSTREAM_PEER_CERT_V_END",
-		"HOSTNAME",
-		"PROTOCOL",
-		"UPSTREAM_REMOTE_ADDRESS",
-		"RESPONSE_FLAGS",
-		"RESPONSE_CODE_DETAILS",
-	} {
-		escapedValue = strings.ReplaceAll(escapedValue, "%%"+envoyVar+"%%", "%"+envoyVar+"%")
-	}
-	// REQ(header-name)
-	var validReqEnvoyVar = regexp.MustCompile(`%(%REQ\([\w-]+\)%)%`)
-	escapedValue = validReqEnvoyVar.ReplaceAllString(escapedValue, "$1")
-	return escapedValue
+
+	var err error
+	escapedValue = headerOperatorRegexp.ReplaceAllStringFunc(escapedValue, func(match string) string {
+		parts := headerOperatorRegexp.FindStringSubmatch(match)
+		op, args := parts[1], parts[2]
+		if !sesame_api_v1alpha1.IsEnvoyCommandOperator(op) {
+			return match
+		}
+		switch op {
+		case "REQ", "RESP", "TRAILER", "REQ_WITHOUT_QUERY":
+			if args != "" && !headerNamesArgRegexp.MatchString(args) {
+				return match
+			}
+		}
+
+		operator := strings.ReplaceAll(match[1:len(match)-1], "%%", "%")
+		if verr := sesame_api_v1alpha1.ValidateEnvoyFormat(operator); verr != nil && err == nil {
+			err = verr
+		}
+		return operator
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return escapedValue, nil
 }
 
 func cookieRewritePolicies(policies []sesame_api_v1.CookieRewritePolicy) ([]CookieRewritePolicy, error) {
@@ -473,6 +606,186 @@ func tcpHealthCheckPolicy(hc *sesame_api_v1.TCPHealthCheckPolicy) *TCPHealthChec
 	}
 }
 
+// connectionPolicy merges the connection policy of a service over the
+// configured defaults. Each field set on the service replaces the
+// corresponding default. It returns nil if neither policy sets anything.
+func connectionPolicy(defaults, cp *sesame_api_v1.ConnectionPolicy) (*ConnectionPolicy, error) {
+	var merged sesame_api_v1.ConnectionPolicy
+	for _, p := range []*sesame_api_v1.ConnectionPolicy{defaults, cp} {
+		if p == nil {
+			continue
+		}
+		if p.MaxConnections > 0 {
+			merged.MaxConnections = p.MaxConnections
+		}
+		if p.MaxPendingRequests > 0 {
+			merged.MaxPendingRequests = p.MaxPendingRequests
+		}
+		if p.MaxRequestsPerConnection > 0 {
+			merged.MaxRequestsPerConnection = p.MaxRequestsPerConnection
+		}
+		if p.MaxConcurrentStreams > 0 {
+			merged.MaxConcurrentStreams = p.MaxConcurrentStreams
+		}
+		if len(p.IdleTimeout) > 0 {
+			merged.IdleTimeout = p.IdleTimeout
+		}
+		if p.TCPKeepalive != nil {
+			merged.TCPKeepalive = p.TCPKeepalive
+		}
+	}
+
+	if merged == (sesame_api_v1.ConnectionPolicy{}) {
+		return nil, nil
+	}
+
+	idleTimeout, err := timeout.Parse(merged.IdleTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing idle timeout: %w", err)
+	}
+
+	policy := &ConnectionPolicy{
+		MaxConnections:           merged.MaxConnections,
+		MaxPendingRequests:       merged.MaxPendingRequests,
+		MaxRequestsPerConnection: merged.MaxRequestsPerConnection,
+		MaxConcurrentStreams:     merged.MaxConcurrentStreams,
+		IdleTimeout:              idleTimeout,
+	}
+	if ka := merged.TCPKeepalive; ka != nil {
+		policy.TCPKeepalive = &TCPKeepalive{
+			Probes:   ka.Probes,
+			IdleTime: time.Duration(ka.IdleTimeSeconds) * time.Second,
+			Interval: time.Duration(ka.IntervalSeconds) * time.Second,
+		}
+	}
+
+	return policy, nil
+}
+
+// circuitBreakers converts the circuit breaking limits of a service
+// into a CircuitBreakers, or nil if no limits are set.
+func circuitBreakers(cb *sesame_api_v1.CircuitBreakers) *CircuitBreakers {
+	if cb == nil {
+		return nil
+	}
+
+	breakers := &CircuitBreakers{
+		MaxConnections:     cb.MaxConnections,
+		MaxPendingRequests: cb.MaxPendingRequests,
+		MaxRequests:        cb.MaxRequests,
+		MaxRetries:         cb.MaxRetries,
+	}
+	if rb := cb.RetryBudget; rb != nil {
+		breakers.RetryBudget = &RetryBudget{
+			BudgetPercent:       rb.BudgetPercent,
+			MinRetryConcurrency: rb.MinRetryConcurrency,
+		}
+	}
+
+	if *breakers == (CircuitBreakers{}) {
+		return nil
+	}
+
+	return breakers
+}
+
+// idempotentMethods are the HTTP request methods that may be hedged.
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+	http.MethodTrace:   true,
+}
+
+// hedgePolicy converts a route hedge policy into a HedgePolicy. Unless the
+// policy is forced, the header conditions must restrict the request method
+// to an idempotent one. Hedging on per-try timeout requires the retry
+// policy to set a per-try timeout.
+func hedgePolicy(hp *sesame_api_v1.HedgePolicy, rp *RetryPolicy, conditions []HeaderMatchCondition) (*HedgePolicy, error) {
+	if hp == nil {
+		return nil, nil
+	}
+	if !hp.HedgeOnPerTryTimeout {
+		return nil, errors.New("hedgeOnPerTryTimeout must be set, no hedging is enabled otherwise")
+	}
+
+	if !hp.Force && !idempotentMethodConditions(conditions) {
+		return nil, errors.New("hedging requires the route to match only idempotent methods (GET, HEAD, OPTIONS, PUT, DELETE or TRACE) unless force is set")
+	}
+
+	if hp.HedgeOnPerTryTimeout && (rp == nil || rp.PerTryTimeout.UseDefault() || rp.PerTryTimeout.IsDisabled()) {
+		return nil, errors.New("hedgeOnPerTryTimeout requires retryPolicy.perTryTimeout to be set")
+	}
+
+	return &HedgePolicy{
+		HedgeOnPerTryTimeout: hp.HedgeOnPerTryTimeout,
+	}, nil
+}
+
+// idempotentMethodConditions returns true if the header conditions
+// only match requests with an idempotent method.
+func idempotentMethodConditions(conditions []HeaderMatchCondition) bool {
+	for _, cond := range conditions {
+		if strings.EqualFold(cond.Name, ":method") && cond.MatchType == HeaderMatchTypeExact && !cond.Invert &&
+			idempotentMethods[strings.ToUpper(cond.Value)] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withDefaultRetryBudget applies the default retry budget to the circuit
+// breakers, unless they set their own retry budget or maxRetries is set
+// by them or by the Service annotation.
+func withDefaultRetryBudget(cb *CircuitBreakers, rb *sesame_api_v1.RetryBudget, svc *Service) *CircuitBreakers {
+	if rb == nil || svc.MaxRetries > 0 {
+		return cb
+	}
+	if cb != nil && (cb.RetryBudget != nil || cb.MaxRetries > 0) {
+		return cb
+	}
+
+	breakers := &CircuitBreakers{}
+	if cb != nil {
+		*breakers = *cb
+	}
+	breakers.RetryBudget = &RetryBudget{
+		BudgetPercent:       rb.BudgetPercent,
+		MinRetryConcurrency: rb.MinRetryConcurrency,
+	}
+
+	return breakers
+}
+
+// circuitBreakerConflicts returns a description of each setting in the
+// circuit breakers that conflicts with, and so overrides, another setting
+// on the same service.
+func circuitBreakerConflicts(cb *CircuitBreakers, cp *sesame_api_v1.ConnectionPolicy) []string {
+	if cb == nil {
+		return nil
+	}
+
+	var conflicts []string
+	if cp != nil {
+		if cb.MaxConnections > 0 && cp.MaxConnections > 0 && cb.MaxConnections != cp.MaxConnections {
+			conflicts = append(conflicts, fmt.Sprintf("circuitBreakers.maxConnections %d overrides connectionPolicy.maxConnections %d",
+				cb.MaxConnections, cp.MaxConnections))
+		}
+		if cb.MaxPendingRequests > 0 && cp.MaxPendingRequests > 0 && cb.MaxPendingRequests != cp.MaxPendingRequests {
+			conflicts = append(conflicts, fmt.Sprintf("circuitBreakers.maxPendingRequests %d overrides connectionPolicy.maxPendingRequests %d",
+				cb.MaxPendingRequests, cp.MaxPendingRequests))
+		}
+	}
+	if cb.RetryBudget != nil && cb.MaxRetries > 0 {
+		conflicts = append(conflicts, "circuitBreakers.maxRetries is ignored when a retry budget is set")
+	}
+
+	return conflicts
+}
+
 // loadBalancerPolicy returns the load balancer strategy or
 // blank if no valid strategy is supplied.
 func loadBalancerPolicy(lbp *sesame_api_v1.LoadBalancerPolicy) string {
@@ -513,11 +826,18 @@ func rateLimitPolicy(in *sesame_api_v1.RateLimitPolicy) (*RateLimitPolicy, error
 
 	rp := &RateLimitPolicy{}
 
-	local, err := localRateLimitPolicy(in.Local)
-	if err != nil {
-		return nil, err
+	if in.Local != nil && in.Local.Bucket != "" {
+		if !reflect.DeepEqual(*in.Local, sesame_api_v1.LocalRateLimitPolicy{Bucket: in.Local.Bucket}) {
+			return nil, fmt.Errorf("local rate limit bucket %q cannot be combined with other local rate limit parameters", in.Local.Bucket)
+		}
+		rp.LocalBucket = in.Local.Bucket
+	} else {
+		local, err := localRateLimitPolicy(in.Local)
+		if err != nil {
+			return nil, err
+		}
+		rp.Local = local
 	}
-	rp.Local = local
 
 	global, err := globalRateLimitPolicy(in.Global)
 	if err != nil {
@@ -533,27 +853,26 @@ func localRateLimitPolicy(in *sesame_api_v1.LocalRateLimitPolicy) (*LocalRateLim
 		return nil, nil
 	}
 
-	if in.Requests <= 0 {
-		return nil, fmt.Errorf("invalid requests value %d in local rate limit policy", in.Requests)
+	res := &LocalRateLimitPolicy{
+		ResponseStatusCode: in.ResponseStatusCode,
+		PerConnection:      in.PerConnection,
 	}
 
-	var fillInterval time.Duration
-	switch in.Unit {
-	case "second":
-		fillInterval = time.Second
-	case "minute":
-		fillInterval = time.Minute
-	case "hour":
-		fillInterval = time.Hour
-	default:
-		return nil, fmt.Errorf("invalid unit %q in local rate limit policy", in.Unit)
-	}
+	// Requests that don't match a descriptor or bucket are not
+	// rate limited if no default limit is set.
+	if in.Requests > 0 || (len(in.Descriptors) == 0 && len(in.Buckets) == 0) {
+		if in.Requests <= 0 {
+			return nil, fmt.Errorf("invalid requests value %d in local rate limit policy", in.Requests)
+		}
 
-	res := &LocalRateLimitPolicy{
-		MaxTokens:          in.Requests + in.Burst,
-		TokensPerFill:      in.Requests,
-		FillInterval:       fillInterval,
-		ResponseStatusCode: in.ResponseStatusCode,
+		fillInterval, err := localRateLimitUnit(in.Unit)
+		if err != nil {
+			return nil, fmt.Errorf("%s in local rate limit policy", err)
+		}
+
+		res.MaxTokens = in.Requests + in.Burst
+		res.TokensPerFill = in.Requests
+		res.FillInterval = fillInterval
 	}
 
 	for _, header := range in.ResponseHeadersToAdd {
@@ -569,12 +888,204 @@ func localRateLimitPolicy(in *sesame_api_v1.LocalRateLimitPolicy) (*LocalRateLim
 		if msgs := validation.IsHTTPHeaderName(key); len(msgs) != 0 {
 			return nil, fmt.Errorf("invalid header name %q: %v", key, msgs)
 		}
-		res.ResponseHeadersToAdd[key] = escapeHeaderValue(header.Value, map[string]string{})
+		value, err := escapeHeaderValue(header.Value, map[string]string{})
+		if err != nil {
+			return nil, fmt.Errorf("invalid header %q: %v", key, err)
+		}
+		res.ResponseHeadersToAdd[key] = value
+	}
+
+	for i, d := range in.Descriptors {
+		fillInterval, err := localRateLimitFillInterval(d.Requests, d.Unit, res.FillInterval)
+		if err != nil {
+			return nil, fmt.Errorf("%s in local rate limit descriptor %d", err, i)
+		}
+
+		if len(d.Entries) == 0 {
+			return nil, fmt.Errorf("local rate limit descriptor %d has no entries", i)
+		}
+
+		descriptor := &LocalRateLimitDescriptor{
+			MaxTokens:     d.Requests + d.Burst,
+			TokensPerFill: d.Requests,
+			FillInterval:  fillInterval,
+		}
+
+		for _, entry := range d.Entries {
+			e, err := localRateLimitDescriptorEntry(entry)
+			if err != nil {
+				return nil, fmt.Errorf("%s in local rate limit descriptor %d", err, i)
+			}
+			descriptor.Entries = append(descriptor.Entries, *e)
+		}
+
+		res.Descriptors = append(res.Descriptors, descriptor)
+	}
+
+	names := sets.NewString()
+	for _, b := range in.Buckets {
+		if names.Has(b.Name) {
+			return nil, fmt.Errorf("duplicate local rate limit bucket %q", b.Name)
+		}
+		names.Insert(b.Name)
+
+		fillInterval, err := localRateLimitFillInterval(b.Requests, b.Unit, res.FillInterval)
+		if err != nil {
+			return nil, fmt.Errorf("%s in local rate limit bucket %q", err, b.Name)
+		}
+
+		res.Buckets = append(res.Buckets, &LocalRateLimitBucket{
+			Name:          b.Name,
+			MaxTokens:     b.Requests + b.Burst,
+			TokensPerFill: b.Requests,
+			FillInterval:  fillInterval,
+		})
 	}
 
 	return res, nil
 }
 
+// localRateLimitUnit returns the fill interval for a local rate limit unit.
+func localRateLimitUnit(unit string) (time.Duration, error) {
+	switch unit {
+	case "second":
+		return time.Second, nil
+	case "minute":
+		return time.Minute, nil
+	case "hour":
+		return time.Hour, nil
+	default:
+		return 0, fmt.Errorf("invalid unit %q", unit)
+	}
+}
+
+// localRateLimitFillInterval returns the fill interval for a local rate
+// limit descriptor or bucket. Envoy requires it to be a multiple of the
+// fill interval of the policy, which is true as long as it is not shorter.
+func localRateLimitFillInterval(requests uint32, unit string, policyInterval time.Duration) (time.Duration, error) {
+	if requests <= 0 {
+		return 0, fmt.Errorf("invalid requests value %d", requests)
+	}
+
+	fillInterval, err := localRateLimitUnit(unit)
+	if err != nil {
+		return 0, err
+	}
+
+	if fillInterval < policyInterval {
+		return 0, fmt.Errorf("unit %q is shorter than the policy unit", unit)
+	}
+
+	return fillInterval, nil
+}
+
+// localRateLimitDescriptorEntry returns the action and the key and
+// value that a request must generate to match the entry.
+func localRateLimitDescriptorEntry(in sesame_api_v1.LocalRateLimitDescriptorEntry) (*LocalRateLimitDescriptorEntry, error) {
+	var (
+		entry *LocalRateLimitDescriptorEntry
+		set   int
+	)
+
+	if in.GenericKey != nil {
+		set++
+
+		if in.GenericKey.Value == "" {
+			return nil, fmt.Errorf("generic key entry must have a value")
+		}
+
+		// Envoy uses "generic_key" if the key is not set.
+		key := in.GenericKey.Key
+		if key == "" {
+			key = "generic_key"
+		}
+
+		entry = &LocalRateLimitDescriptorEntry{
+			Action: RateLimitDescriptorEntry{
+				GenericKey: &GenericKeyDescriptorEntry{
+					Key:   in.GenericKey.Key,
+					Value: in.GenericKey.Value,
+				},
+			},
+			Key:   key,
+			Value: in.GenericKey.Value,
+		}
+	}
+
+	if in.RequestHeader != nil {
+		set++
+
+		name := http.CanonicalHeaderKey(in.RequestHeader.HeaderName)
+		if msgs := validation.IsHTTPHeaderName(name); len(msgs) != 0 {
+			return nil, fmt.Errorf("invalid header name %q: %v", name, msgs)
+		}
+
+		entry = &LocalRateLimitDescriptorEntry{
+			Action: RateLimitDescriptorEntry{
+				HeaderMatch: &HeaderMatchDescriptorEntry{
+					HeaderName: name,
+					Key:        name,
+				},
+			},
+			Key:   name,
+			Value: in.RequestHeader.Value,
+		}
+	}
+
+	if in.RemoteAddress != nil {
+		set++
+
+		ip := net.ParseIP(in.RemoteAddress.Address)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid remote address %q", in.RemoteAddress.Address)
+		}
+
+		entry = &LocalRateLimitDescriptorEntry{
+			Action: RateLimitDescriptorEntry{
+				RemoteAddress: &RemoteAddressDescriptorEntry{},
+			},
+			Key:   "remote_address",
+			Value: ip.String(),
+		}
+	}
+
+	if in.MaskedRemoteAddress != nil {
+		set++
+
+		_, ipnet, err := net.ParseCIDR(in.MaskedRemoteAddress.CIDR)
+		if err != nil {
+			return nil, fmt.Errorf("invalid masked remote address %q", in.MaskedRemoteAddress.CIDR)
+		}
+
+		// Only the prefix length for the family of the CIDR
+		// matters, since the value can't match the other one.
+		ones, _ := ipnet.Mask.Size()
+		masked := &MaskedRemoteAddressDescriptorEntry{
+			V4PrefixLength: 32,
+			V6PrefixLength: 128,
+		}
+		if ipnet.IP.To4() != nil {
+			masked.V4PrefixLength = uint32(ones)
+		} else {
+			masked.V6PrefixLength = uint32(ones)
+		}
+
+		entry = &LocalRateLimitDescriptorEntry{
+			Action: RateLimitDescriptorEntry{
+				MaskedRemoteAddress: masked,
+			},
+			Key:   "masked_remote_address",
+			Value: ipnet.String(),
+		}
+	}
+
+	if set != 1 {
+		return nil, errors.New("local rate limit descriptor entry must have exactly one field set")
+	}
+
+	return entry, nil
+}
+
 func globalRateLimitPolicy(in *sesame_api_v1.GlobalRateLimitPolicy) (*GlobalRateLimitPolicy, error) {
 	if in == nil {
 		return nil, nil
@@ -631,6 +1142,45 @@ func globalRateLimitPolicy(in *sesame_api_v1.GlobalRateLimitPolicy) (*GlobalRate
 				})
 			}
 
+			if entry.QueryParameterValueMatch != nil {
+				set++
+
+				rld.Entries = append(rld.Entries, RateLimitDescriptorEntry{
+					QueryParameterMatch: &QueryParameterMatchDescriptorEntry{
+						Name:        entry.QueryParameterValueMatch.Name,
+						Exact:       entry.QueryParameterValueMatch.Exact,
+						ExpectMatch: entry.QueryParameterValueMatch.ExpectMatch,
+						Value:       entry.QueryParameterValueMatch.Value,
+					},
+				})
+			}
+
+			if entry.Metadata != nil {
+				set++
+
+				rld.Entries = append(rld.Entries, RateLimitDescriptorEntry{
+					Metadata: &MetadataDescriptorEntry{
+						Key:             entry.Metadata.DescriptorKey,
+						FilterNamespace: entry.Metadata.FilterNamespace,
+						Path:            entry.Metadata.Path,
+						DefaultValue:    entry.Metadata.DefaultValue,
+					},
+				})
+			}
+
+			if entry.MaskedRemoteAddress != nil {
+				set++
+
+				masked, err := maskedRemoteAddressDescriptorEntry(entry.MaskedRemoteAddress)
+				if err != nil {
+					return nil, err
+				}
+
+				rld.Entries = append(rld.Entries, RateLimitDescriptorEntry{
+					MaskedRemoteAddress: masked,
+				})
+			}
+
 			if set != 1 {
 				return nil, errors.New("rate limit descriptor entry must have exactly one field set")
 			}
@@ -642,6 +1192,29 @@ func globalRateLimitPolicy(in *sesame_api_v1.GlobalRateLimitPolicy) (*GlobalRate
 	return res, nil
 }
 
+func maskedRemoteAddressDescriptorEntry(in *sesame_api_v1.MaskedRemoteAddressDescriptor) (*MaskedRemoteAddressDescriptorEntry, error) {
+	res := &MaskedRemoteAddressDescriptorEntry{
+		V4PrefixLength: 32,
+		V6PrefixLength: 128,
+	}
+
+	if in.V4PrefixLength != nil {
+		if *in.V4PrefixLength < 0 || *in.V4PrefixLength > 32 {
+			return nil, fmt.Errorf("invalid IPv4 prefix length %d in masked remote address descriptor", *in.V4PrefixLength)
+		}
+		res.V4PrefixLength = uint32(*in.V4PrefixLength)
+	}
+
+	if in.V6PrefixLength != nil {
+		if *in.V6PrefixLength < 0 || *in.V6PrefixLength > 128 {
+			return nil, fmt.Errorf("invalid IPv6 prefix length %d in masked remote address descriptor", *in.V6PrefixLength)
+		}
+		res.V6PrefixLength = uint32(*in.V6PrefixLength)
+	}
+
+	return res, nil
+}
+
 // Validates and returns list of hash policies along with lb actual strategy to
 // be used. Will return default strategy and empty list of hash policies if
 // validation fails.
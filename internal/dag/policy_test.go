@@ -25,6 +25,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	networking_v1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
 )
 
 func TestRetryPolicyIngress(t *testing.T) {
@@ -119,8 +120,9 @@ func TestRetryPolicyIngress(t *testing.T) {
 
 func TestRetryPolicy(t *testing.T) {
 	tests := map[string]struct {
-		rp   *sesame_api_v1.RetryPolicy
-		want *RetryPolicy
+		rp      *sesame_api_v1.RetryPolicy
+		want    *RetryPolicy
+		wantErr bool
 	}{
 		"nil retry policy": {
 			rp:   nil,
@@ -181,16 +183,224 @@ func TestRetryPolicy(t *testing.T) {
 				NumRetries:           1,
 			},
 		},
+		"retry host and priority predicates": {
+			rp: &sesame_api_v1.RetryPolicy{
+				RetryHostPredicates: []sesame_api_v1.RetryHostPredicate{
+					sesame_api_v1.RetryHostPredicatePreviousHosts,
+					sesame_api_v1.RetryHostPredicateOmitCanaryHosts,
+				},
+				HostSelectionRetryMaxAttempts: 3,
+				RetryPriorityPredicate: &sesame_api_v1.RetryPriorityPredicate{
+					UpdateFrequency: 2,
+				},
+			},
+			want: &RetryPolicy{
+				RetryOn:                       "5xx",
+				NumRetries:                    1,
+				RetryHostPredicates:           []string{"previous-hosts", "omit-canary-hosts"},
+				HostSelectionRetryMaxAttempts: 3,
+				PriorityUpdateFrequency:       2,
+			},
+		},
+		"retry back-off": {
+			rp: &sesame_api_v1.RetryPolicy{
+				RetryBackOff: &sesame_api_v1.RetryBackOff{
+					BaseInterval: "50ms",
+					MaxInterval:  "1s",
+				},
+			},
+			want: &RetryPolicy{
+				RetryOn:             "5xx",
+				NumRetries:          1,
+				BackOffBaseInterval: 50 * time.Millisecond,
+				BackOffMaxInterval:  time.Second,
+			},
+		},
+		"retry back-off without max interval": {
+			rp: &sesame_api_v1.RetryPolicy{
+				RetryBackOff: &sesame_api_v1.RetryBackOff{
+					BaseInterval: "50ms",
+				},
+			},
+			want: &RetryPolicy{
+				RetryOn:             "5xx",
+				NumRetries:          1,
+				BackOffBaseInterval: 50 * time.Millisecond,
+			},
+		},
+		"retry back-off with invalid base interval": {
+			rp: &sesame_api_v1.RetryPolicy{
+				RetryBackOff: &sesame_api_v1.RetryBackOff{
+					BaseInterval: "0s",
+				},
+			},
+			wantErr: true,
+		},
+		"retry back-off with max interval less than base interval": {
+			rp: &sesame_api_v1.RetryPolicy{
+				RetryBackOff: &sesame_api_v1.RetryBackOff{
+					BaseInterval: "1s",
+					MaxInterval:  "50ms",
+				},
+			},
+			wantErr: true,
+		},
+		"per-try idle timeout": {
+			rp: &sesame_api_v1.RetryPolicy{
+				PerTryIdleTimeout: "5s",
+			},
+			want: &RetryPolicy{
+				RetryOn:           "5xx",
+				NumRetries:        1,
+				PerTryIdleTimeout: timeout.DurationSetting(5 * time.Second),
+			},
+		},
+		"invalid per-try idle timeout": {
+			rp: &sesame_api_v1.RetryPolicy{
+				PerTryIdleTimeout: "please",
+			},
+			wantErr: true,
+		},
 	}
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			got := retryPolicy(tc.rp)
-			assert.Equal(t, tc.want, got)
+			got, gotErr := retryPolicy(tc.rp)
+			if tc.wantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.Equal(t, tc.want, got)
+				assert.NoError(t, gotErr)
+			}
+		})
+	}
+}
+
+func TestHedgePolicy(t *testing.T) {
+	methodMatch := func(method string) []HeaderMatchCondition {
+		return []HeaderMatchCondition{{
+			Name:      ":method",
+			Value:     method,
+			MatchType: HeaderMatchTypeExact,
+		}}
+	}
+
+	perTryTimeout := &RetryPolicy{PerTryTimeout: timeout.DurationSetting(150 * time.Millisecond)}
+
+	tests := map[string]struct {
+		hp         *sesame_api_v1.HedgePolicy
+		rp         *RetryPolicy
+		conditions []HeaderMatchCondition
+		want       *HedgePolicy
+		wantErr    bool
+	}{
+		"nil hedge policy": {
+			hp:   nil,
+			want: nil,
+		},
+		"no hedging enabled": {
+			hp: &sesame_api_v1.HedgePolicy{
+				Force: true,
+			},
+			rp:      perTryTimeout,
+			wantErr: true,
+		},
+		"idempotent method": {
+			hp: &sesame_api_v1.HedgePolicy{
+				HedgeOnPerTryTimeout: true,
+			},
+			rp:         perTryTimeout,
+			conditions: methodMatch("GET"),
+			want: &HedgePolicy{
+				HedgeOnPerTryTimeout: true,
+			},
+		},
+		"no method condition": {
+			hp: &sesame_api_v1.HedgePolicy{
+				HedgeOnPerTryTimeout: true,
+			},
+			rp:      perTryTimeout,
+			wantErr: true,
+		},
+		"non-idempotent method": {
+			hp: &sesame_api_v1.HedgePolicy{
+				HedgeOnPerTryTimeout: true,
+			},
+			rp:         perTryTimeout,
+			conditions: methodMatch("POST"),
+			wantErr:    true,
+		},
+		"inverted method condition": {
+			hp: &sesame_api_v1.HedgePolicy{
+				HedgeOnPerTryTimeout: true,
+			},
+			rp: perTryTimeout,
+			conditions: []HeaderMatchCondition{{
+				Name:      ":method",
+				Value:     "GET",
+				MatchType: HeaderMatchTypeExact,
+				Invert:    true,
+			}},
+			wantErr: true,
+		},
+		"forced": {
+			hp: &sesame_api_v1.HedgePolicy{
+				HedgeOnPerTryTimeout: true,
+				Force:                true,
+			},
+			rp: perTryTimeout,
+			want: &HedgePolicy{
+				HedgeOnPerTryTimeout: true,
+			},
+		},
+		"hedge on per-try timeout without retry policy": {
+			hp: &sesame_api_v1.HedgePolicy{
+				HedgeOnPerTryTimeout: true,
+			},
+			conditions: methodMatch("GET"),
+			wantErr:    true,
+		},
+		"hedge on per-try timeout without per-try timeout": {
+			hp: &sesame_api_v1.HedgePolicy{
+				HedgeOnPerTryTimeout: true,
+			},
+			rp:         &RetryPolicy{PerTryTimeout: timeout.DefaultSetting()},
+			conditions: methodMatch("GET"),
+			wantErr:    true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, gotErr := hedgePolicy(tc.hp, tc.rp, tc.conditions)
+			if tc.wantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.Equal(t, tc.want, got)
+				assert.NoError(t, gotErr)
+			}
 		})
 	}
 }
 
+func TestWithDefaultRetryBudget(t *testing.T) {
+	budgetPercent := uint32(25)
+	defaultBudget := &sesame_api_v1.RetryBudget{BudgetPercent: &budgetPercent}
+
+	assert.Nil(t, withDefaultRetryBudget(nil, nil, &Service{}))
+	assert.Equal(t, &CircuitBreakers{
+		RetryBudget: &RetryBudget{BudgetPercent: &budgetPercent},
+	}, withDefaultRetryBudget(nil, defaultBudget, &Service{}))
+	assert.Equal(t, &CircuitBreakers{
+		MaxConnections: 10,
+		RetryBudget:    &RetryBudget{BudgetPercent: &budgetPercent},
+	}, withDefaultRetryBudget(&CircuitBreakers{MaxConnections: 10}, defaultBudget, &Service{}))
+
+	// Services that limit retries themselves keep their own limits.
+	assert.Equal(t, &CircuitBreakers{MaxRetries: 3}, withDefaultRetryBudget(&CircuitBreakers{MaxRetries: 3}, defaultBudget, &Service{}))
+	assert.Nil(t, withDefaultRetryBudget(nil, defaultBudget, &Service{MaxRetries: 3}))
+}
+
 func TestTimeoutPolicy(t *testing.T) {
 	tests := map[string]struct {
 		tp      *sesame_api_v1.TimeoutPolicy
@@ -251,6 +461,152 @@ func TestTimeoutPolicy(t *testing.T) {
 	}
 }
 
+func TestConnectionPolicy(t *testing.T) {
+	tests := map[string]struct {
+		defaults *sesame_api_v1.ConnectionPolicy
+		cp       *sesame_api_v1.ConnectionPolicy
+		want     *ConnectionPolicy
+		wantErr  bool
+	}{
+		"nil connection policies": {
+			want: nil,
+		},
+		"empty connection policy": {
+			cp:   &sesame_api_v1.ConnectionPolicy{},
+			want: nil,
+		},
+		"defaults only": {
+			defaults: &sesame_api_v1.ConnectionPolicy{
+				MaxConnections:       1024,
+				MaxConcurrentStreams: 100,
+			},
+			want: &ConnectionPolicy{
+				MaxConnections:       1024,
+				MaxConcurrentStreams: 100,
+			},
+		},
+		"service overrides defaults": {
+			defaults: &sesame_api_v1.ConnectionPolicy{
+				MaxConnections: 1024,
+				IdleTimeout:    "1h",
+				TCPKeepalive: &sesame_api_v1.TCPKeepalive{
+					Probes: 9,
+				},
+			},
+			cp: &sesame_api_v1.ConnectionPolicy{
+				MaxConnections:           16,
+				MaxRequestsPerConnection: 1000,
+				TCPKeepalive: &sesame_api_v1.TCPKeepalive{
+					IdleTimeSeconds: 30,
+					IntervalSeconds: 5,
+				},
+			},
+			want: &ConnectionPolicy{
+				MaxConnections:           16,
+				MaxRequestsPerConnection: 1000,
+				IdleTimeout:              timeout.DurationSetting(time.Hour),
+				TCPKeepalive: &TCPKeepalive{
+					IdleTime: 30 * time.Second,
+					Interval: 5 * time.Second,
+				},
+			},
+		},
+		"infinite idle timeout": {
+			cp: &sesame_api_v1.ConnectionPolicy{
+				IdleTimeout: "infinity",
+			},
+			want: &ConnectionPolicy{
+				IdleTimeout: timeout.DisabledSetting(),
+			},
+		},
+		"invalid idle timeout": {
+			cp: &sesame_api_v1.ConnectionPolicy{
+				IdleTimeout: "90", // 90 what?
+			},
+			wantErr: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, gotErr := connectionPolicy(tc.defaults, tc.cp)
+			if tc.wantErr {
+				assert.Error(t, gotErr)
+			} else {
+				assert.Equal(t, tc.want, got)
+				assert.NoError(t, gotErr)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakers(t *testing.T) {
+	budgetPercent := uint32(25)
+
+	tests := map[string]struct {
+		cb            *sesame_api_v1.CircuitBreakers
+		cp            *sesame_api_v1.ConnectionPolicy
+		want          *CircuitBreakers
+		wantConflicts []string
+	}{
+		"nil circuit breakers": {
+			want: nil,
+		},
+		"empty circuit breakers": {
+			cb:   &sesame_api_v1.CircuitBreakers{},
+			want: nil,
+		},
+		"limits": {
+			cb: &sesame_api_v1.CircuitBreakers{
+				MaxConnections: 10,
+				MaxRequests:    20,
+			},
+			cp: &sesame_api_v1.ConnectionPolicy{
+				MaxConnections: 10,
+			},
+			want: &CircuitBreakers{
+				MaxConnections: 10,
+				MaxRequests:    20,
+			},
+		},
+		"conflicting limits": {
+			cb: &sesame_api_v1.CircuitBreakers{
+				MaxConnections:     10,
+				MaxPendingRequests: 5,
+				MaxRetries:         3,
+				RetryBudget: &sesame_api_v1.RetryBudget{
+					BudgetPercent: &budgetPercent,
+				},
+			},
+			cp: &sesame_api_v1.ConnectionPolicy{
+				MaxConnections:     100,
+				MaxPendingRequests: 50,
+			},
+			want: &CircuitBreakers{
+				MaxConnections:     10,
+				MaxPendingRequests: 5,
+				MaxRetries:         3,
+				RetryBudget: &RetryBudget{
+					BudgetPercent: &budgetPercent,
+				},
+			},
+			wantConflicts: []string{
+				"circuitBreakers.maxConnections 10 overrides connectionPolicy.maxConnections 100",
+				"circuitBreakers.maxPendingRequests 5 overrides connectionPolicy.maxPendingRequests 50",
+				"circuitBreakers.maxRetries is ignored when a retry budget is set",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := circuitBreakers(tc.cb)
+			assert.Equal(t, tc.want, got)
+			assert.Equal(t, tc.wantConflicts, circuitBreakerConflicts(got, tc.cp))
+		})
+	}
+}
+
 func TestLoadBalancerPolicy(t *testing.T) {
 	tests := map[string]struct {
 		lbp  *sesame_api_v1.LoadBalancerPolicy
@@ -409,6 +765,128 @@ func TestHeadersPolicy(t *testing.T) {
 				},
 			},
 		},
+		"Envoy dynamic metadata unescaped": {
+			hp: &sesame_api_v1.HeadersPolicy{
+				Set: []sesame_api_v1.HeaderValue{{
+					Name:  "X-Tenant",
+					Value: "%DYNAMIC_METADATA(envoy.filters.http.ext_authz:tenant)%",
+				}},
+			},
+			dhp: HeadersPolicy{},
+			want: HeadersPolicy{
+				Set: map[string]string{
+					"X-Tenant": "%DYNAMIC_METADATA(envoy.filters.http.ext_authz:tenant)%",
+				},
+			},
+		},
+		"Envoy REQ header with alternative unescaped": {
+			hp: &sesame_api_v1.HeadersPolicy{
+				Set: []sesame_api_v1.HeaderValue{{
+					Name:  "X-Original-Path",
+					Value: "%REQ(X-Envoy-Original-Path?:Path)%",
+				}},
+			},
+			dhp: HeadersPolicy{},
+			want: HeadersPolicy{
+				Set: map[string]string{
+					"X-Original-Path": "%REQ(X-Envoy-Original-Path?:Path)%",
+				},
+			},
+		},
+		"Envoy REQ without header is invalid": {
+			hp: &sesame_api_v1.HeadersPolicy{
+				Set: []sesame_api_v1.HeaderValue{{
+					Name:  "X-Request-Host",
+					Value: "%REQ%",
+				}},
+			},
+			dhp:     HeadersPolicy{},
+			wantErr: true,
+		},
+		"Envoy START_TIME with truncation is invalid": {
+			hp: &sesame_api_v1.HeadersPolicy{
+				Add: []sesame_api_v1.HeaderValue{{
+					Name:  "X-Request-Start",
+					Value: "%START_TIME(%s):3%",
+				}},
+			},
+			dhp:     HeadersPolicy{},
+			wantErr: true,
+		},
+		"add and addIfAbsent headers": {
+			hp: &sesame_api_v1.HeadersPolicy{
+				Add: []sesame_api_v1.HeaderValue{{
+					Name:  "x-forwarded-client",
+					Value: "%DOWNSTREAM_REMOTE_ADDRESS%",
+				}},
+				AddIfAbsent: []sesame_api_v1.HeaderValue{{
+					Name:  "X-Request-Start",
+					Value: "t=%START_TIME(%s)%",
+				}},
+			},
+			dhp: HeadersPolicy{},
+			want: HeadersPolicy{
+				Set: map[string]string{},
+				Add: map[string]string{
+					"X-Forwarded-Client": "%DOWNSTREAM_REMOTE_ADDRESS%",
+				},
+				AddIfAbsent: map[string]string{
+					"X-Request-Start": "t=%START_TIME(%s)%",
+				},
+			},
+		},
+		"same header set and added is invalid": {
+			hp: &sesame_api_v1.HeadersPolicy{
+				Set: []sesame_api_v1.HeaderValue{{
+					Name:  "X-App-Weight",
+					Value: "100",
+				}},
+				Add: []sesame_api_v1.HeaderValue{{
+					Name:  "x-app-weight",
+					Value: "10",
+				}},
+			},
+			dhp:     HeadersPolicy{},
+			wantErr: true,
+		},
+		"adding host header is invalid": {
+			hp: &sesame_api_v1.HeadersPolicy{
+				AddIfAbsent: []sesame_api_v1.HeaderValue{{
+					Name:  "Host",
+					Value: "example.com",
+				}},
+			},
+			dhp:     HeadersPolicy{},
+			wantErr: true,
+		},
+		"default add header not applied when object manages header": {
+			hp: &sesame_api_v1.HeadersPolicy{
+				Set: []sesame_api_v1.HeaderValue{{
+					Name:  "X-Request-Id",
+					Value: "fixed",
+				}},
+			},
+			dhp: HeadersPolicy{
+				Add: map[string]string{
+					"X-Request-Id": "%REQ(X-Request-Id)%",
+					"Via":          "sesame",
+				},
+				AddIfAbsent: map[string]string{
+					"X-Client": "%DOWNSTREAM_REMOTE_ADDRESS_WITHOUT_PORT%",
+				},
+			},
+			want: HeadersPolicy{
+				Set: map[string]string{
+					"X-Request-Id": "fixed",
+				},
+				Add: map[string]string{
+					"Via": "sesame",
+				},
+				AddIfAbsent: map[string]string{
+					"X-Client": "%DOWNSTREAM_REMOTE_ADDRESS_WITHOUT_PORT%",
+				},
+			},
+		},
 		"dynamic service headers": {
 			hp: &sesame_api_v1.HeadersPolicy{
 				Set: []sesame_api_v1.HeaderValue{{
@@ -546,6 +1024,23 @@ func TestRateLimitPolicy(t *testing.T) {
 				},
 			},
 		},
+		"local - per connection": {
+			in: &sesame_api_v1.RateLimitPolicy{
+				Local: &sesame_api_v1.LocalRateLimitPolicy{
+					Requests:      3,
+					Unit:          "second",
+					PerConnection: true,
+				},
+			},
+			want: &RateLimitPolicy{
+				Local: &LocalRateLimitPolicy{
+					MaxTokens:     3,
+					TokensPerFill: 3,
+					FillInterval:  time.Second,
+					PerConnection: true,
+				},
+			},
+		},
 		"local - custom response status code": {
 			in: &sesame_api_v1.RateLimitPolicy{
 				Local: &sesame_api_v1.LocalRateLimitPolicy{
@@ -644,6 +1139,207 @@ func TestRateLimitPolicy(t *testing.T) {
 			},
 			wantErr: "invalid requests value 0 in local rate limit policy",
 		},
+		"local - descriptors": {
+			in: &sesame_api_v1.RateLimitPolicy{
+				Local: &sesame_api_v1.LocalRateLimitPolicy{
+					Requests: 100,
+					Unit:     "second",
+					Descriptors: []sesame_api_v1.LocalRateLimitDescriptor{
+						{
+							Entries: []sesame_api_v1.LocalRateLimitDescriptorEntry{
+								{
+									RequestHeader: &sesame_api_v1.LocalRequestHeaderDescriptor{
+										HeaderName: "x-tenant",
+										Value:      "a",
+									},
+								},
+								{
+									RemoteAddress: &sesame_api_v1.LocalRemoteAddressDescriptor{
+										Address: "10.0.0.1",
+									},
+								},
+							},
+							Requests: 10,
+							Unit:     "minute",
+							Burst:    5,
+						},
+						{
+							Entries: []sesame_api_v1.LocalRateLimitDescriptorEntry{
+								{
+									MaskedRemoteAddress: &sesame_api_v1.LocalMaskedRemoteAddressDescriptor{
+										CIDR: "192.0.2.10/24",
+									},
+								},
+								{
+									GenericKey: &sesame_api_v1.GenericKeyDescriptor{
+										Value: "foo",
+									},
+								},
+							},
+							Requests: 1,
+							Unit:     "second",
+						},
+					},
+				},
+			},
+			want: &RateLimitPolicy{
+				Local: &LocalRateLimitPolicy{
+					MaxTokens:     100,
+					TokensPerFill: 100,
+					FillInterval:  time.Second,
+					Descriptors: []*LocalRateLimitDescriptor{
+						{
+							Entries: []LocalRateLimitDescriptorEntry{
+								{
+									Action: RateLimitDescriptorEntry{
+										HeaderMatch: &HeaderMatchDescriptorEntry{
+											HeaderName: "X-Tenant",
+											Key:        "X-Tenant",
+										},
+									},
+									Key:   "X-Tenant",
+									Value: "a",
+								},
+								{
+									Action: RateLimitDescriptorEntry{
+										RemoteAddress: &RemoteAddressDescriptorEntry{},
+									},
+									Key:   "remote_address",
+									Value: "10.0.0.1",
+								},
+							},
+							MaxTokens:     15,
+							TokensPerFill: 10,
+							FillInterval:  time.Minute,
+						},
+						{
+							Entries: []LocalRateLimitDescriptorEntry{
+								{
+									Action: RateLimitDescriptorEntry{
+										MaskedRemoteAddress: &MaskedRemoteAddressDescriptorEntry{
+											V4PrefixLength: 24,
+											V6PrefixLength: 128,
+										},
+									},
+									Key:   "masked_remote_address",
+									Value: "192.0.2.0/24",
+								},
+								{
+									Action: RateLimitDescriptorEntry{
+										GenericKey: &GenericKeyDescriptorEntry{
+											Value: "foo",
+										},
+									},
+									Key:   "generic_key",
+									Value: "foo",
+								},
+							},
+							MaxTokens:     1,
+							TokensPerFill: 1,
+							FillInterval:  time.Second,
+						},
+					},
+				},
+			},
+		},
+		"local - descriptor unit shorter than policy unit": {
+			in: &sesame_api_v1.RateLimitPolicy{
+				Local: &sesame_api_v1.LocalRateLimitPolicy{
+					Requests: 100,
+					Unit:     "minute",
+					Descriptors: []sesame_api_v1.LocalRateLimitDescriptor{{
+						Entries: []sesame_api_v1.LocalRateLimitDescriptorEntry{{
+							RemoteAddress: &sesame_api_v1.LocalRemoteAddressDescriptor{Address: "10.0.0.1"},
+						}},
+						Requests: 10,
+						Unit:     "second",
+					}},
+				},
+			},
+			wantErr: `unit "second" is shorter than the policy unit in local rate limit descriptor 0`,
+		},
+		"local - invalid remote address": {
+			in: &sesame_api_v1.RateLimitPolicy{
+				Local: &sesame_api_v1.LocalRateLimitPolicy{
+					Descriptors: []sesame_api_v1.LocalRateLimitDescriptor{{
+						Entries: []sesame_api_v1.LocalRateLimitDescriptorEntry{{
+							RemoteAddress: &sesame_api_v1.LocalRemoteAddressDescriptor{Address: "not-an-ip"},
+						}},
+						Requests: 10,
+						Unit:     "second",
+					}},
+				},
+			},
+			wantErr: `invalid remote address "not-an-ip" in local rate limit descriptor 0`,
+		},
+		"local - descriptor entry with multiple fields": {
+			in: &sesame_api_v1.RateLimitPolicy{
+				Local: &sesame_api_v1.LocalRateLimitPolicy{
+					Descriptors: []sesame_api_v1.LocalRateLimitDescriptor{{
+						Entries: []sesame_api_v1.LocalRateLimitDescriptorEntry{{
+							RemoteAddress: &sesame_api_v1.LocalRemoteAddressDescriptor{Address: "10.0.0.1"},
+							GenericKey:    &sesame_api_v1.GenericKeyDescriptor{Value: "foo"},
+						}},
+						Requests: 10,
+						Unit:     "second",
+					}},
+				},
+			},
+			wantErr: "local rate limit descriptor entry must have exactly one field set in local rate limit descriptor 0",
+		},
+		"local - buckets": {
+			in: &sesame_api_v1.RateLimitPolicy{
+				Local: &sesame_api_v1.LocalRateLimitPolicy{
+					Buckets: []sesame_api_v1.LocalRateLimitBucket{{
+						Name:     "shared",
+						Requests: 100,
+						Unit:     "second",
+						Burst:    10,
+					}},
+				},
+			},
+			want: &RateLimitPolicy{
+				Local: &LocalRateLimitPolicy{
+					Buckets: []*LocalRateLimitBucket{{
+						Name:          "shared",
+						MaxTokens:     110,
+						TokensPerFill: 100,
+						FillInterval:  time.Second,
+					}},
+				},
+			},
+		},
+		"local - duplicate buckets": {
+			in: &sesame_api_v1.RateLimitPolicy{
+				Local: &sesame_api_v1.LocalRateLimitPolicy{
+					Buckets: []sesame_api_v1.LocalRateLimitBucket{
+						{Name: "shared", Requests: 100, Unit: "second"},
+						{Name: "shared", Requests: 10, Unit: "second"},
+					},
+				},
+			},
+			wantErr: `duplicate local rate limit bucket "shared"`,
+		},
+		"local - bucket reference": {
+			in: &sesame_api_v1.RateLimitPolicy{
+				Local: &sesame_api_v1.LocalRateLimitPolicy{
+					Bucket: "shared",
+				},
+			},
+			want: &RateLimitPolicy{
+				LocalBucket: "shared",
+			},
+		},
+		"local - bucket reference with other parameters": {
+			in: &sesame_api_v1.RateLimitPolicy{
+				Local: &sesame_api_v1.LocalRateLimitPolicy{
+					Bucket:   "shared",
+					Requests: 10,
+					Unit:     "second",
+				},
+			},
+			wantErr: `local rate limit bucket "shared" cannot be combined with other local rate limit parameters`,
+		},
 		"global - multiple descriptors": {
 			in: &sesame_api_v1.RateLimitPolicy{
 				Global: &sesame_api_v1.GlobalRateLimitPolicy{
@@ -722,6 +1418,87 @@ func TestRateLimitPolicy(t *testing.T) {
 				},
 			},
 		},
+		"global - query parameter, metadata and masked remote address": {
+			in: &sesame_api_v1.RateLimitPolicy{
+				Global: &sesame_api_v1.GlobalRateLimitPolicy{
+					Descriptors: []sesame_api_v1.RateLimitDescriptor{
+						{
+							Entries: []sesame_api_v1.RateLimitDescriptorEntry{
+								{
+									QueryParameterValueMatch: &sesame_api_v1.QueryParameterValueMatchDescriptor{
+										Name:        "apikey",
+										Exact:       "abc",
+										ExpectMatch: true,
+										Value:       "apikey-abc",
+									},
+								},
+								{
+									Metadata: &sesame_api_v1.MetadataDescriptor{
+										DescriptorKey:   "sub",
+										FilterNamespace: "envoy.filters.http.jwt_authn",
+										Path:            []string{"payload", "sub"},
+									},
+								},
+								{
+									MaskedRemoteAddress: &sesame_api_v1.MaskedRemoteAddressDescriptor{
+										V4PrefixLength: pointer.Int32(24),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			want: &RateLimitPolicy{
+				Global: &GlobalRateLimitPolicy{
+					Descriptors: []*RateLimitDescriptor{
+						{
+							Entries: []RateLimitDescriptorEntry{
+								{
+									QueryParameterMatch: &QueryParameterMatchDescriptorEntry{
+										Name:        "apikey",
+										Exact:       "abc",
+										ExpectMatch: true,
+										Value:       "apikey-abc",
+									},
+								},
+								{
+									Metadata: &MetadataDescriptorEntry{
+										Key:             "sub",
+										FilterNamespace: "envoy.filters.http.jwt_authn",
+										Path:            []string{"payload", "sub"},
+									},
+								},
+								{
+									MaskedRemoteAddress: &MaskedRemoteAddressDescriptorEntry{
+										V4PrefixLength: 24,
+										V6PrefixLength: 128,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		"global - invalid masked remote address prefix length": {
+			in: &sesame_api_v1.RateLimitPolicy{
+				Global: &sesame_api_v1.GlobalRateLimitPolicy{
+					Descriptors: []sesame_api_v1.RateLimitDescriptor{
+						{
+							Entries: []sesame_api_v1.RateLimitDescriptorEntry{
+								{
+									MaskedRemoteAddress: &sesame_api_v1.MaskedRemoteAddressDescriptor{
+										V6PrefixLength: pointer.Int32(129),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			wantErr: "invalid IPv6 prefix length 129 in masked remote address descriptor",
+		},
 		"global - multiple descriptor entries set": {
 			in: &sesame_api_v1.RateLimitPolicy{
 				Global: &sesame_api_v1.GlobalRateLimitPolicy{
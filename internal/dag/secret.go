@@ -89,6 +89,32 @@ func isValidSecret(secret *v1.Secret) (bool, error) {
 	return true, nil
 }
 
+// certificateInfo returns the metadata of the first certificate in
+// the Secret's TLS certificate bundle, or nil if it can't be parsed.
+func certificateInfo(secret *v1.Secret) *CertificateInfo {
+	data := secret.Data[v1.TLSCertKey]
+	if !containsPEMHeader(data) {
+		return nil
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil
+	}
+
+	return &CertificateInfo{
+		CommonName: cert.Subject.CommonName,
+		DNSNames:   cert.DNSNames,
+		NotBefore:  cert.NotBefore,
+		NotAfter:   cert.NotAfter,
+	}
+}
+
 // containsPEMHeader returns true if the given slice contains a string
 // that looks like a PEM header block. The problem is that pem.Decode
 // does not give us a way to distinguish between a missing PEM block
@@ -17,6 +17,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/projectsesame/sesame/internal/fixture"
 	"github.com/stretchr/testify/assert"
@@ -270,3 +271,62 @@ func pemBundle(cert ...string) string {
 
 	return data
 }
+
+func TestCertificateInfo(t *testing.T) {
+	tests := map[string]struct {
+		secret *v1.Secret
+		want   *CertificateInfo
+	}{
+		"TLS Secret": {
+			secret: &v1.Secret{
+				Type: v1.SecretTypeTLS,
+				Data: secretdata(fixture.CERTIFICATE, fixture.RSA_PRIVATE_KEY),
+			},
+			want: &CertificateInfo{
+				CommonName: "boring-wozniak.example.com",
+				NotBefore:  time.Date(2019, 12, 5, 1, 34, 33, 0, time.UTC),
+				NotAfter:   time.Date(2029, 12, 2, 1, 34, 33, 0, time.UTC),
+			},
+		},
+		"CA Secret": {
+			secret: &v1.Secret{
+				Type: v1.SecretTypeOpaque,
+				Data: map[string][]byte{
+					CACertificateKey: []byte(fixture.CA_CERT),
+				},
+			},
+			want: nil,
+		},
+		"TLS Secret with invalid certificate": {
+			secret: &v1.Secret{
+				Type: v1.SecretTypeTLS,
+				Data: secretdata("-----BEGIN CERTIFICATE-----\nc2VzYW1l\n-----END CERTIFICATE-----", fixture.RSA_PRIVATE_KEY),
+			},
+			want: nil,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, certificateInfo(tc.secret))
+		})
+	}
+}
+
+func TestCertificateInfoExpiry(t *testing.T) {
+	now := time.Date(2029, 11, 1, 0, 0, 0, 0, time.UTC)
+	cert := &CertificateInfo{
+		NotAfter: time.Date(2029, 12, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	assert.False(t, cert.Expired(now))
+	assert.False(t, cert.ExpiresWithin(now, 7*24*time.Hour))
+	assert.True(t, cert.ExpiresWithin(now, 720*time.Hour+time.Second))
+	assert.False(t, cert.ExpiresWithin(now, 0))
+	assert.True(t, cert.Expired(cert.NotAfter.Add(time.Second)))
+
+	assert.Equal(t, cert.NotAfter.Add(-7*24*time.Hour), cert.NextTransition(now, 7*24*time.Hour))
+	assert.Equal(t, cert.NotAfter, cert.NextTransition(now, 60*24*time.Hour))
+	assert.Equal(t, cert.NotAfter, cert.NextTransition(now, 0))
+	assert.True(t, cert.NextTransition(cert.NotAfter.Add(time.Second), 0).IsZero())
+}
@@ -14,15 +14,27 @@
 package dag
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
+	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
 	"github.com/projectsesame/sesame/internal/fixture"
 	"github.com/projectsesame/sesame/internal/gatewayapi"
+	"github.com/projectsesame/sesame/internal/k8s"
 	"github.com/projectsesame/sesame/internal/status"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	v1 "k8s.io/api/core/v1"
 	networking_v1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -2552,6 +2564,223 @@ func TestDAGStatus(t *testing.T) {
 		},
 	})
 
+	localRateLimitBuckets := func(bucket string) *sesame_api_v1.HTTPProxy {
+		return &sesame_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: fixture.ServiceRootsKuard.Namespace,
+				Name:      "local-rate-limit-buckets",
+			},
+			Spec: sesame_api_v1.HTTPProxySpec{
+				VirtualHost: &sesame_api_v1.VirtualHost{
+					Fqdn: "example.com",
+					RateLimitPolicy: &sesame_api_v1.RateLimitPolicy{
+						Local: &sesame_api_v1.LocalRateLimitPolicy{
+							Buckets: []sesame_api_v1.LocalRateLimitBucket{{
+								Name:     "shared",
+								Requests: 100,
+								Unit:     "second",
+							}},
+						},
+					},
+				},
+				Routes: []sesame_api_v1.Route{
+					{
+						Services: []sesame_api_v1.Service{
+							{
+								Name: fixture.ServiceRootsKuard.Name,
+								Port: 8080,
+							},
+						},
+						RateLimitPolicy: &sesame_api_v1.RateLimitPolicy{
+							Local: &sesame_api_v1.LocalRateLimitPolicy{
+								Bucket: bucket,
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	run(t, "proxy with route referencing local rate limit bucket is valid", testcase{
+		objs: []interface{}{localRateLimitBuckets("shared"), fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]sesame_api_v1.DetailedCondition{
+			{
+				Name:      "local-rate-limit-buckets",
+				Namespace: fixture.ServiceRootsKuard.Namespace,
+			}: fixture.NewValidCondition().Valid(),
+		},
+	})
+
+	run(t, "proxy with route referencing missing local rate limit bucket is invalid", testcase{
+		objs: []interface{}{localRateLimitBuckets("missing"), fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]sesame_api_v1.DetailedCondition{
+			{
+				Name:      "local-rate-limit-buckets",
+				Namespace: fixture.ServiceRootsKuard.Namespace,
+			}: fixture.NewValidCondition().WithError(sesame_api_v1.ConditionTypeRouteError, "RateLimitPolicyNotValid",
+				`route.rateLimitPolicy is invalid: local rate limit bucket "missing" is not defined on the virtual host`),
+		},
+	})
+
+	routePerConnection := localRateLimitBuckets("")
+	routePerConnection.Spec.Routes[0].RateLimitPolicy.Local = &sesame_api_v1.LocalRateLimitPolicy{
+		Requests:      10,
+		Unit:          "second",
+		PerConnection: true,
+	}
+
+	run(t, "proxy with per-connection route local rate limit is invalid", testcase{
+		objs: []interface{}{routePerConnection, fixture.ServiceRootsKuard},
+		want: map[types.NamespacedName]sesame_api_v1.DetailedCondition{
+			{
+				Name:      "local-rate-limit-buckets",
+				Namespace: fixture.ServiceRootsKuard.Namespace,
+			}: fixture.NewValidCondition().WithError(sesame_api_v1.ConditionTypeRouteError, "RateLimitPolicyNotValid",
+				`route.rateLimitPolicy is invalid: per-connection local rate limits can only be defined on the virtual host`),
+		},
+	})
+
+	circuitBreakersConflict := &sesame_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: sesame_api_v1.HTTPProxySpec{
+			VirtualHost: &sesame_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []sesame_api_v1.Route{{
+				Conditions: []sesame_api_v1.MatchCondition{{
+					Prefix: "/foo",
+				}},
+				Services: []sesame_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+					ConnectionPolicy: &sesame_api_v1.ConnectionPolicy{
+						MaxConnections: 100,
+					},
+					CircuitBreakers: &sesame_api_v1.CircuitBreakers{
+						MaxConnections: 10,
+					},
+				}},
+			}},
+		},
+	}
+
+	circuitBreakersConflictCondition := fixture.NewValidCondition().Valid()
+	circuitBreakersConflictCondition.AddWarning(sesame_api_v1.ConditionTypeServiceError, "CircuitBreakersConflict",
+		`service "home": circuitBreakers.maxConnections 10 overrides connectionPolicy.maxConnections 100`)
+
+	run(t, "conflicting circuit breaker settings are reported as warnings", testcase{
+		objs: []interface{}{circuitBreakersConflict, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]sesame_api_v1.DetailedCondition{
+			{Name: circuitBreakersConflict.Name, Namespace: circuitBreakersConflict.Namespace}: circuitBreakersConflictCondition,
+		},
+	})
+
+	hedgePolicyPost := &sesame_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: sesame_api_v1.HTTPProxySpec{
+			VirtualHost: &sesame_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []sesame_api_v1.Route{{
+				Conditions: []sesame_api_v1.MatchCondition{{
+					Header: &sesame_api_v1.HeaderMatchCondition{
+						Name:  ":method",
+						Exact: "POST",
+					},
+				}},
+				HedgePolicy: &sesame_api_v1.HedgePolicy{
+					HedgeOnPerTryTimeout: true,
+				},
+				Services: []sesame_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "hedge policy on non-idempotent route is invalid", testcase{
+		objs: []interface{}{hedgePolicyPost, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]sesame_api_v1.DetailedCondition{
+			{Name: hedgePolicyPost.Name, Namespace: hedgePolicyPost.Namespace}: fixture.NewValidCondition().WithError(
+				sesame_api_v1.ConditionTypeRouteError, "HedgePolicyNotValid",
+				"route.hedgePolicy is invalid: hedging requires the route to match only idempotent methods (GET, HEAD, OPTIONS, PUT, DELETE or TRACE) unless force is set"),
+		},
+	})
+
+	hedgePolicyNoPerTryTimeout := &sesame_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: sesame_api_v1.HTTPProxySpec{
+			VirtualHost: &sesame_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []sesame_api_v1.Route{{
+				Conditions: []sesame_api_v1.MatchCondition{{
+					Header: &sesame_api_v1.HeaderMatchCondition{
+						Name:  ":method",
+						Exact: "GET",
+					},
+				}},
+				HedgePolicy: &sesame_api_v1.HedgePolicy{
+					HedgeOnPerTryTimeout: true,
+				},
+				Services: []sesame_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "hedge policy without a per-try timeout is invalid", testcase{
+		objs: []interface{}{hedgePolicyNoPerTryTimeout, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]sesame_api_v1.DetailedCondition{
+			{Name: hedgePolicyNoPerTryTimeout.Name, Namespace: hedgePolicyNoPerTryTimeout.Namespace}: fixture.NewValidCondition().WithError(
+				sesame_api_v1.ConditionTypeRouteError, "HedgePolicyNotValid",
+				"route.hedgePolicy is invalid: hedgeOnPerTryTimeout requires retryPolicy.perTryTimeout to be set"),
+		},
+	})
+
+	hedgePolicyForceOnly := &sesame_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: sesame_api_v1.HTTPProxySpec{
+			VirtualHost: &sesame_api_v1.VirtualHost{
+				Fqdn: "example.com",
+			},
+			Routes: []sesame_api_v1.Route{{
+				HedgePolicy: &sesame_api_v1.HedgePolicy{
+					Force: true,
+				},
+				Services: []sesame_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	run(t, "hedge policy that enables no hedging is invalid", testcase{
+		objs: []interface{}{hedgePolicyForceOnly, fixture.ServiceRootsHome},
+		want: map[types.NamespacedName]sesame_api_v1.DetailedCondition{
+			{Name: hedgePolicyForceOnly.Name, Namespace: hedgePolicyForceOnly.Namespace}: fixture.NewValidCondition().WithError(
+				sesame_api_v1.ConditionTypeRouteError, "HedgePolicyNotValid",
+				"route.hedgePolicy is invalid: hedgeOnPerTryTimeout must be set, no hedging is enabled otherwise"),
+		},
+	})
+
 	// issue 3197: Fallback and passthrough HTTPProxy directive should emit a config error
 	tlsPassthroughAndFallback := &sesame_api_v1.HTTPProxy{
 		ObjectMeta: metav1.ObjectMeta{
@@ -2694,7 +2923,6 @@ func TestGatewayAPIHTTPRouteDAGStatus(t *testing.T) {
 							},
 						},
 					},
-					gateway: tc.gateway,
 				},
 				Processors: []Processor{
 					&IngressProcessor{
@@ -2710,7 +2938,7 @@ func TestGatewayAPIHTTPRouteDAGStatus(t *testing.T) {
 
 			// Set a default gateway if not defined by a test
 			if tc.gateway == nil {
-				builder.Source.gateway = &gatewayapi_v1alpha2.Gateway{
+				tc.gateway = &gatewayapi_v1alpha2.Gateway{
 					ObjectMeta: metav1.ObjectMeta{
 						Name:      "sesame",
 						Namespace: "projectsesame",
@@ -2729,6 +2957,7 @@ func TestGatewayAPIHTTPRouteDAGStatus(t *testing.T) {
 					},
 				}
 			}
+			builder.Source.Insert(tc.gateway)
 
 			for _, o := range tc.objs {
 				builder.Source.Insert(o)
@@ -3668,7 +3897,7 @@ func TestGatewayAPIHTTPRouteDAGStatus(t *testing.T) {
 							Type:    string(gatewayapi_v1alpha2.ListenerConditionResolvedRefs),
 							Status:  metav1.ConditionFalse,
 							Reason:  string(gatewayapi_v1alpha2.ListenerReasonInvalidCertificateRef),
-							Message: "Spec.VirtualHost.TLS.CertificateRefs \"secret\" namespace must match the Gateway's namespace or be covered by a ReferencePolicy",
+							Message: "Spec.VirtualHost.TLS.CertificateRefs \"secret\" namespace must match the Gateway's namespace or be covered by a TLSCertificateDelegation or ReferencePolicy",
 						},
 					},
 				},
@@ -3809,7 +4038,7 @@ func TestGatewayAPIHTTPRouteDAGStatus(t *testing.T) {
 							Type:    string(gatewayapi_v1alpha2.ListenerConditionResolvedRefs),
 							Status:  metav1.ConditionFalse,
 							Reason:  string(gatewayapi_v1alpha2.ListenerReasonInvalidCertificateRef),
-							Message: "Spec.VirtualHost.TLS.CertificateRefs \"secret\" namespace must match the Gateway's namespace or be covered by a ReferencePolicy",
+							Message: "Spec.VirtualHost.TLS.CertificateRefs \"secret\" namespace must match the Gateway's namespace or be covered by a TLSCertificateDelegation or ReferencePolicy",
 						},
 					},
 				},
@@ -3894,7 +4123,7 @@ func TestGatewayAPIHTTPRouteDAGStatus(t *testing.T) {
 							Type:    string(gatewayapi_v1alpha2.ListenerConditionResolvedRefs),
 							Status:  metav1.ConditionFalse,
 							Reason:  string(gatewayapi_v1alpha2.ListenerReasonInvalidCertificateRef),
-							Message: "Spec.VirtualHost.TLS.CertificateRefs \"secret\" namespace must match the Gateway's namespace or be covered by a ReferencePolicy",
+							Message: "Spec.VirtualHost.TLS.CertificateRefs \"secret\" namespace must match the Gateway's namespace or be covered by a TLSCertificateDelegation or ReferencePolicy",
 						},
 					},
 				},
@@ -3979,7 +4208,7 @@ func TestGatewayAPIHTTPRouteDAGStatus(t *testing.T) {
 							Type:    string(gatewayapi_v1alpha2.ListenerConditionResolvedRefs),
 							Status:  metav1.ConditionFalse,
 							Reason:  string(gatewayapi_v1alpha2.ListenerReasonInvalidCertificateRef),
-							Message: "Spec.VirtualHost.TLS.CertificateRefs \"secret\" namespace must match the Gateway's namespace or be covered by a ReferencePolicy",
+							Message: "Spec.VirtualHost.TLS.CertificateRefs \"secret\" namespace must match the Gateway's namespace or be covered by a TLSCertificateDelegation or ReferencePolicy",
 						},
 					},
 				},
@@ -4064,7 +4293,7 @@ func TestGatewayAPIHTTPRouteDAGStatus(t *testing.T) {
 							Type:    string(gatewayapi_v1alpha2.ListenerConditionResolvedRefs),
 							Status:  metav1.ConditionFalse,
 							Reason:  string(gatewayapi_v1alpha2.ListenerReasonInvalidCertificateRef),
-							Message: "Spec.VirtualHost.TLS.CertificateRefs \"secret\" namespace must match the Gateway's namespace or be covered by a ReferencePolicy",
+							Message: "Spec.VirtualHost.TLS.CertificateRefs \"secret\" namespace must match the Gateway's namespace or be covered by a TLSCertificateDelegation or ReferencePolicy",
 						},
 					},
 				},
@@ -4150,7 +4379,7 @@ func TestGatewayAPIHTTPRouteDAGStatus(t *testing.T) {
 							Type:    string(gatewayapi_v1alpha2.ListenerConditionResolvedRefs),
 							Status:  metav1.ConditionFalse,
 							Reason:  string(gatewayapi_v1alpha2.ListenerReasonInvalidCertificateRef),
-							Message: "Spec.VirtualHost.TLS.CertificateRefs \"secret\" namespace must match the Gateway's namespace or be covered by a ReferencePolicy",
+							Message: "Spec.VirtualHost.TLS.CertificateRefs \"secret\" namespace must match the Gateway's namespace or be covered by a TLSCertificateDelegation or ReferencePolicy",
 						},
 					},
 				},
@@ -5187,7 +5416,6 @@ func TestGatewayAPITLSRouteDAGStatus(t *testing.T) {
 				Source: KubernetesCache{
 					RootNamespaces: []string{"roots", "marketing"},
 					FieldLogger:    fixture.NewTestLogger(t),
-					gateway:        tc.gateway,
 					gatewayclass: &gatewayapi_v1alpha2.GatewayClass{
 						TypeMeta: metav1.TypeMeta{},
 						ObjectMeta: metav1.ObjectMeta{
@@ -5221,6 +5449,9 @@ func TestGatewayAPITLSRouteDAGStatus(t *testing.T) {
 			// Add a default cert to be used in tests with TLS.
 			builder.Source.Insert(fixture.SecretProjectSesameCert)
 
+			if tc.gateway != nil {
+				builder.Source.Insert(tc.gateway)
+			}
 			for _, o := range tc.objs {
 				builder.Source.Insert(o)
 			}
@@ -5713,3 +5944,886 @@ func TestGatewayAPITLSRouteDAGStatus(t *testing.T) {
 		})
 	}
 }
+
+func TestTLSCertificateDelegationDAGStatus(t *testing.T) {
+	type testcase struct {
+		objs           []interface{}
+		wantStatus     sesame_api_v1.ConditionStatus
+		wantErrors     []sesame_api_v1.SubCondition
+		wantDelegation []sesame_api_v1.CertificateDelegationStatus
+	}
+
+	run := func(t *testing.T, desc string, tc testcase) {
+		t.Helper()
+		t.Run(desc, func(t *testing.T) {
+			t.Helper()
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					&HTTPProxyProcessor{},
+					&TLSCertificateDelegationProcessor{},
+					&ListenerProcessor{},
+				},
+			}
+			var delegation *sesame_api_v1.TLSCertificateDelegation
+			for _, o := range tc.objs {
+				if d, ok := o.(*sesame_api_v1.TLSCertificateDelegation); ok {
+					delegation = d
+				}
+				builder.Source.Insert(o)
+			}
+			dag := builder.Build()
+
+			var got *sesame_api_v1.TLSCertificateDelegation
+			for _, u := range dag.StatusCache.GetStatusUpdates() {
+				if _, ok := u.Resource.(*sesame_api_v1.TLSCertificateDelegation); ok {
+					got = u.Mutator.Mutate(delegation).(*sesame_api_v1.TLSCertificateDelegation)
+				}
+			}
+			if !assert.NotNil(t, got) {
+				return
+			}
+
+			cond := got.Status.GetConditionFor(sesame_api_v1.ValidConditionType)
+			if !assert.NotNil(t, cond) {
+				return
+			}
+			assert.Equal(t, tc.wantStatus, cond.Status)
+			assert.Equal(t, tc.wantErrors, cond.Errors)
+			assert.Equal(t, tc.wantDelegation, got.Status.Delegations)
+		})
+	}
+
+	namespace := func(name, env string) *v1.Namespace {
+		return &v1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"env": env},
+			},
+		}
+	}
+
+	proxy := func(namespace, fqdn string) *sesame_api_v1.HTTPProxy {
+		return &sesame_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: namespace,
+				Name:      "proxy",
+			},
+			Spec: sesame_api_v1.HTTPProxySpec{
+				VirtualHost: &sesame_api_v1.VirtualHost{
+					Fqdn: fqdn,
+					TLS: &sesame_api_v1.TLS{
+						SecretName: "certs/wildcard",
+					},
+				},
+				Routes: []sesame_api_v1.Route{{
+					Services: []sesame_api_v1.Service{{
+						Name: "home",
+						Port: 8080,
+					}},
+				}},
+			},
+		}
+	}
+
+	wildcard := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "certs",
+			Name:      "wildcard",
+			Labels:    map[string]string{"tls": "shared"},
+		},
+		Type: v1.SecretTypeTLS,
+		Data: secretdata(fixture.CERTIFICATE, fixture.RSA_PRIVATE_KEY),
+	}
+
+	run(t, "secret and namespace selectors list the consuming namespaces", testcase{
+		objs: []interface{}{
+			namespace("team-a", "prod"),
+			namespace("team-b", "prod"),
+			namespace("team-c", "dev"),
+			wildcard,
+			&sesame_api_v1.TLSCertificateDelegation{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "certs",
+					Name:      "delegation",
+				},
+				Spec: sesame_api_v1.TLSCertificateDelegationSpec{
+					Delegations: []sesame_api_v1.CertificateDelegation{{
+						SecretSelector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{"tls": "shared"},
+						},
+						TargetNamespaceSelector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{"env": "prod"},
+						},
+					}},
+				},
+			},
+			proxy("team-b", "b.example.com"),
+			proxy("team-a", "a.example.com"),
+			proxy("team-c", "c.example.com"),
+		},
+		wantStatus: sesame_api_v1.ConditionTrue,
+		wantDelegation: []sesame_api_v1.CertificateDelegationStatus{{
+			SecretName: "wildcard",
+			Namespaces: []string{"team-a", "team-b"},
+		}},
+	})
+
+	run(t, "delegation without a secret name or selector is invalid", testcase{
+		objs: []interface{}{
+			wildcard,
+			&sesame_api_v1.TLSCertificateDelegation{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "certs",
+					Name:      "delegation",
+				},
+				Spec: sesame_api_v1.TLSCertificateDelegationSpec{
+					Delegations: []sesame_api_v1.CertificateDelegation{{
+						TargetNamespaces: []string{"*"},
+					}},
+				},
+			},
+			proxy("team-a", "a.example.com"),
+		},
+		wantStatus: sesame_api_v1.ConditionFalse,
+		wantErrors: []sesame_api_v1.SubCondition{{
+			Type:    "SpecError",
+			Reason:  "SecretNotSpecified",
+			Message: "delegation must specify a secretName or a secretSelector",
+			Status:  sesame_api_v1.ConditionTrue,
+		}},
+	})
+}
+
+// selfSignedSecret returns a TLS Secret holding a self-signed
+// certificate for the given hostname that expires at notAfter.
+func selfSignedSecret(t *testing.T, namespace, name, hostname string, notAfter time.Time) *v1.Secret {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: hostname},
+		DNSNames:     []string{hostname},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: namespace,
+			Name:      name,
+		},
+		Type: v1.SecretTypeTLS,
+		Data: secretdata(
+			string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+			string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+		),
+	}
+}
+
+func TestCertificateExpiryDAGStatus(t *testing.T) {
+	expiring := time.Now().Add(7 * 24 * time.Hour).Truncate(time.Second)
+	expired := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+
+	build := func(t *testing.T, objs ...interface{}) *DAG {
+		t.Helper()
+		builder := Builder{
+			Source: KubernetesCache{
+				RootNamespaces: []string{"roots"},
+				FieldLogger:    fixture.NewTestLogger(t),
+				gatewayclass: &gatewayapi_v1alpha2.GatewayClass{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "test-gc",
+					},
+					Spec: gatewayapi_v1alpha2.GatewayClassSpec{
+						ControllerName: "projectsesame.io/sesame",
+					},
+					Status: gatewayapi_v1alpha2.GatewayClassStatus{
+						Conditions: []metav1.Condition{{
+							Type:   string(gatewayapi_v1alpha2.GatewayClassConditionStatusAccepted),
+							Status: metav1.ConditionTrue,
+						}},
+					},
+				},
+			},
+			Processors: []Processor{
+				&HTTPProxyProcessor{
+					CertificateExpiryWarning: 30 * 24 * time.Hour,
+				},
+				&GatewayAPIProcessor{
+					FieldLogger:              fixture.NewTestLogger(t),
+					CertificateExpiryWarning: 30 * 24 * time.Hour,
+				},
+				&ListenerProcessor{},
+			},
+		}
+		for _, o := range objs {
+			builder.Source.Insert(o)
+		}
+		return builder.Build()
+	}
+
+	proxy := &sesame_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: sesame_api_v1.HTTPProxySpec{
+			VirtualHost: &sesame_api_v1.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &sesame_api_v1.TLS{
+					SecretName: "example-cert",
+				},
+			},
+			Routes: []sesame_api_v1.Route{{
+				Services: []sesame_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	}
+
+	gateway := &gatewayapi_v1alpha2.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "projectsesame",
+			Name:      "sesame",
+		},
+		Spec: gatewayapi_v1alpha2.GatewaySpec{
+			Listeners: []gatewayapi_v1alpha2.Listener{{
+				Name:     "https",
+				Port:     443,
+				Protocol: gatewayapi_v1alpha2.HTTPSProtocolType,
+				TLS: &gatewayapi_v1alpha2.GatewayTLSConfig{
+					CertificateRefs: []*gatewayapi_v1alpha2.SecretObjectReference{
+						gatewayapi.CertificateRef("example-cert", ""),
+					},
+				},
+				AllowedRoutes: &gatewayapi_v1alpha2.AllowedRoutes{
+					Namespaces: &gatewayapi_v1alpha2.RouteNamespaces{
+						From: gatewayapi.FromNamespacesPtr(gatewayapi_v1alpha2.NamespacesFromAll),
+					},
+				},
+			}},
+		},
+	}
+
+	proxyCondition := func(d *DAG) sesame_api_v1.DetailedCondition {
+		for _, pu := range d.StatusCache.GetProxyUpdates() {
+			if pu.Fullname == k8s.NamespacedNameOf(proxy) {
+				return *pu.Conditions[status.ValidCondition]
+			}
+		}
+		t.Fatalf("no status update for HTTPProxy %s", k8s.NamespacedNameOf(proxy))
+		return sesame_api_v1.DetailedCondition{}
+	}
+
+	gatewayUpdate := func(d *DAG) *status.GatewayStatusUpdate {
+		updates := d.StatusCache.GetGatewayUpdates()
+		require.Len(t, updates, 1)
+		return updates[0]
+	}
+
+	t.Run("HTTPProxy certificate is valid", func(t *testing.T) {
+		notAfter := time.Now().Add(365 * 24 * time.Hour).Truncate(time.Second)
+		d := build(t, fixture.ServiceRootsHome, proxy,
+			selfSignedSecret(t, "roots", "example-cert", "example.com", notAfter))
+
+		assert.Equal(t, fixture.NewValidCondition().Valid(), proxyCondition(d))
+		assert.True(t, notAfter.Add(-30*24*time.Hour).Equal(d.CertificateRecheck))
+	})
+
+	t.Run("HTTPProxy certificate is expiring", func(t *testing.T) {
+		d := build(t, fixture.ServiceRootsHome, proxy,
+			selfSignedSecret(t, "roots", "example-cert", "example.com", expiring))
+
+		want := fixture.NewValidCondition().Valid()
+		want.AddWarningf(sesame_api_v1.ConditionTypeTLSError, "CertificateExpiring",
+			"Spec.VirtualHost.TLS Secret %q certificate expires at %s", "example-cert", expiring.UTC().Format(time.RFC3339))
+		assert.Equal(t, want, proxyCondition(d))
+		assert.NotNil(t, d.GetSecureVirtualHost("example.com"))
+		assert.True(t, expiring.Equal(d.CertificateRecheck))
+	})
+
+	t.Run("HTTPProxy certificate has expired", func(t *testing.T) {
+		d := build(t, fixture.ServiceRootsHome, proxy,
+			selfSignedSecret(t, "roots", "example-cert", "example.com", expired))
+
+		assert.Equal(t, fixture.NewValidCondition().
+			WithError(sesame_api_v1.ConditionTypeTLSError, "CertificateExpired",
+				fmt.Sprintf("Spec.VirtualHost.TLS Secret %q certificate expired at %s", "example-cert", expired.UTC().Format(time.RFC3339))),
+			proxyCondition(d))
+
+		// The expired certificate is still served.
+		svh := d.GetSecureVirtualHost("example.com")
+		require.NotNil(t, svh)
+		assert.Equal(t, "example-cert", svh.Secret.Name())
+		assert.True(t, d.CertificateRecheck.IsZero())
+	})
+
+	t.Run("Gateway listener certificate is expiring", func(t *testing.T) {
+		d := build(t, gateway,
+			selfSignedSecret(t, "projectsesame", "example-cert", "example.com", expiring))
+
+		u := gatewayUpdate(d)
+		cond, ok := u.Conditions[status.ConditionCertificateExpiring]
+		require.True(t, ok)
+		assert.Equal(t, metav1.ConditionTrue, cond.Status)
+		assert.Equal(t, string(status.ReasonCertificateExpiring), cond.Reason)
+		assert.Equal(t, fmt.Sprintf("Listener %q certificate %q expires at %s", "https", "example-cert", expiring.UTC().Format(time.RFC3339)), cond.Message)
+		assert.Equal(t, metav1.ConditionTrue, u.Conditions[gatewayapi_v1alpha2.GatewayConditionReady].Status)
+		assert.True(t, expiring.Equal(d.CertificateRecheck))
+	})
+
+	t.Run("Gateway listener certificate has expired", func(t *testing.T) {
+		d := build(t, gateway,
+			selfSignedSecret(t, "projectsesame", "example-cert", "example.com", expired))
+
+		u := gatewayUpdate(d)
+		assert.NotContains(t, u.Conditions, status.ConditionCertificateExpiring)
+		cond, ok := u.Conditions[status.ConditionCertificateExpired]
+		require.True(t, ok)
+		assert.Equal(t, metav1.ConditionTrue, cond.Status)
+		assert.Equal(t, string(status.ReasonCertificateExpired), cond.Reason)
+		assert.Equal(t, fmt.Sprintf("Listener %q certificate %q expired at %s", "https", "example-cert", expired.UTC().Format(time.RFC3339)), cond.Message)
+
+		// The listener still serves the expired certificate,
+		// so its references are resolved.
+		assert.Equal(t, metav1.ConditionTrue, u.Conditions[gatewayapi_v1alpha2.GatewayConditionReady].Status)
+		for _, cond := range u.ListenerStatus["https"].Conditions {
+			assert.NotEqual(t, string(gatewayapi_v1alpha2.ListenerConditionResolvedRefs), cond.Type)
+		}
+	})
+}
+
+func TestServiceReferenceDAGStatus(t *testing.T) {
+	build := func(t *testing.T, objs ...interface{}) *DAG {
+		t.Helper()
+		builder := Builder{
+			Source: KubernetesCache{
+				RootNamespaces: []string{"roots"},
+				FieldLogger:    fixture.NewTestLogger(t),
+			},
+			Processors: []Processor{
+				&ExtensionServiceProcessor{
+					FieldLogger: fixture.NewTestLogger(t),
+				},
+				&HTTPProxyProcessor{},
+				&ListenerProcessor{},
+			},
+		}
+		for _, o := range objs {
+			builder.Source.Insert(o)
+		}
+		return builder.Build()
+	}
+
+	proxy := func(services ...sesame_api_v1.Service) *sesame_api_v1.HTTPProxy {
+		return &sesame_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "roots",
+				Name:      "example",
+			},
+			Spec: sesame_api_v1.HTTPProxySpec{
+				VirtualHost: &sesame_api_v1.VirtualHost{
+					Fqdn: "example.com",
+				},
+				Routes: []sesame_api_v1.Route{{
+					Services: services,
+				}},
+			},
+		}
+	}
+
+	proxyCondition := func(t *testing.T, d *DAG) sesame_api_v1.DetailedCondition {
+		t.Helper()
+		for _, pu := range d.StatusCache.GetProxyUpdates() {
+			if pu.Fullname == (types.NamespacedName{Namespace: "roots", Name: "example"}) {
+				return *pu.Conditions[status.ValidCondition]
+			}
+		}
+		t.Fatal("no status update for HTTPProxy roots/example")
+		return sesame_api_v1.DetailedCondition{}
+	}
+
+	routeClusters := func(t *testing.T, d *DAG) []*Cluster {
+		t.Helper()
+		vhost := d.GetVirtualHost("example.com")
+		require.NotNil(t, vhost)
+		require.Len(t, vhost.Routes, 1)
+		for _, r := range vhost.Routes {
+			return r.Clusters
+		}
+		return nil
+	}
+
+	backend := &v1.Service{
+		ObjectMeta: fixture.ObjectMeta("backends/kuard"),
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "http",
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	}
+
+	grant := &sesame_api_v1alpha1.ServiceReferenceGrant{
+		ObjectMeta: fixture.ObjectMeta("backends/roots"),
+		Spec: sesame_api_v1alpha1.ServiceReferenceGrantSpec{
+			From: []sesame_api_v1alpha1.ServiceReferenceGrantFrom{{
+				Namespace: "roots",
+			}},
+			To: []sesame_api_v1alpha1.ServiceReferenceGrantTo{{
+				Kind: sesame_api_v1alpha1.KindService,
+			}},
+		},
+	}
+
+	extension := &sesame_api_v1alpha1.ExtensionService{
+		ObjectMeta: fixture.ObjectMeta("roots/grpc"),
+		Spec: sesame_api_v1alpha1.ExtensionServiceSpec{
+			Services: []sesame_api_v1alpha1.ExtensionServiceTarget{{
+				Name: "home",
+				Port: 8080,
+			}},
+		},
+	}
+
+	t.Run("service in another namespace without a grant", func(t *testing.T) {
+		d := build(t, backend, proxy(sesame_api_v1.Service{
+			Name:      "kuard",
+			Namespace: "backends",
+			Port:      8080,
+		}))
+
+		assert.Equal(t, fixture.NewValidCondition().
+			WithError(sesame_api_v1.ConditionTypeServiceError, "ServiceReferenceNotPermitted",
+				`Spec.Routes service "kuard" is not permitted by a ServiceReferenceGrant in namespace "backends"`),
+			proxyCondition(t, d))
+		assert.Empty(t, routeClusters(t, d))
+	})
+
+	t.Run("service in another namespace with a grant", func(t *testing.T) {
+		d := build(t, backend, grant, proxy(sesame_api_v1.Service{
+			Name:      "kuard",
+			Namespace: "backends",
+			Port:      8080,
+		}))
+
+		assert.Equal(t, fixture.NewValidCondition().Valid(), proxyCondition(t, d))
+		clusters := routeClusters(t, d)
+		require.Len(t, clusters, 1)
+		assert.Equal(t, "backends", clusters[0].Upstream.Weighted.ServiceNamespace)
+		assert.Equal(t, "kuard", clusters[0].Upstream.Weighted.ServiceName)
+	})
+
+	t.Run("route to an extension service", func(t *testing.T) {
+		d := build(t, fixture.ServiceRootsHome, extension, proxy(sesame_api_v1.Service{
+			ExtensionService: &sesame_api_v1.ExtensionServiceReference{
+				Name: "grpc",
+			},
+		}))
+
+		assert.Equal(t, fixture.NewValidCondition().Valid(), proxyCondition(t, d))
+		clusters := routeClusters(t, d)
+		require.Len(t, clusters, 1)
+		require.NotNil(t, clusters[0].ExtensionCluster)
+		assert.Equal(t, "extension/roots/grpc", clusters[0].ExtensionCluster.Name)
+	})
+
+	t.Run("route to a missing extension service", func(t *testing.T) {
+		d := build(t, proxy(sesame_api_v1.Service{
+			ExtensionService: &sesame_api_v1.ExtensionServiceReference{
+				Name: "grpc",
+			},
+		}))
+
+		assert.Equal(t, fixture.NewValidCondition().
+			WithError(sesame_api_v1.ConditionTypeServiceError, "ExtensionServiceNotFound",
+				`Spec.Routes extension service "roots/grpc" not found`),
+			proxyCondition(t, d))
+	})
+
+	t.Run("route to an extension service in another namespace without a grant", func(t *testing.T) {
+		d := build(t, proxy(sesame_api_v1.Service{
+			ExtensionService: &sesame_api_v1.ExtensionServiceReference{
+				Namespace: "backends",
+				Name:      "grpc",
+			},
+		}))
+
+		assert.Equal(t, fixture.NewValidCondition().
+			WithError(sesame_api_v1.ConditionTypeServiceError, "ServiceReferenceNotPermitted",
+				`Spec.Routes extension service "grpc" is not permitted by a ServiceReferenceGrant in namespace "backends"`),
+			proxyCondition(t, d))
+	})
+
+	t.Run("extension service combined with a service name", func(t *testing.T) {
+		d := build(t, fixture.ServiceRootsHome, extension, proxy(sesame_api_v1.Service{
+			Name: "home",
+			ExtensionService: &sesame_api_v1.ExtensionServiceReference{
+				Name: "grpc",
+			},
+		}))
+
+		assert.Equal(t, fixture.NewValidCondition().
+			WithError(sesame_api_v1.ConditionTypeServiceError, "ServiceNotValid",
+				`service "home": name and port cannot be combined with extensionService`),
+			proxyCondition(t, d))
+	})
+
+	t.Run("tcpproxy to an extension service", func(t *testing.T) {
+		p := proxy()
+		p.Spec.Routes = nil
+		p.Spec.VirtualHost.TLS = &sesame_api_v1.TLS{Passthrough: true}
+		p.Spec.TCPProxy = &sesame_api_v1.TCPProxy{
+			Services: []sesame_api_v1.Service{{
+				ExtensionService: &sesame_api_v1.ExtensionServiceReference{
+					Name: "grpc",
+				},
+			}},
+		}
+		d := build(t, fixture.ServiceRootsHome, extension, p)
+
+		assert.Equal(t, fixture.NewValidCondition().
+			WithError(sesame_api_v1.ConditionTypeTCPProxyError, "ExtensionServiceNotSupported",
+				"Spec.TCPProxy services cannot refer to an extensionService"),
+			proxyCondition(t, d))
+	})
+}
+
+func TestExtensionServiceStaticEndpointsDAGStatus(t *testing.T) {
+	type testcase struct {
+		ext        *sesame_api_v1alpha1.ExtensionService
+		wantStatus sesame_api_v1.ConditionStatus
+		wantErrors []sesame_api_v1.SubCondition
+		wantUp     *ServiceCluster
+	}
+
+	run := func(t *testing.T, desc string, tc testcase) {
+		t.Helper()
+		t.Run(desc, func(t *testing.T) {
+			t.Helper()
+			builder := Builder{
+				Source: KubernetesCache{
+					FieldLogger: fixture.NewTestLogger(t),
+				},
+				Processors: []Processor{
+					&ExtensionServiceProcessor{
+						FieldLogger: fixture.NewTestLogger(t),
+					},
+				},
+			}
+			builder.Source.Insert(fixture.ServiceRootsHome)
+			builder.Source.Insert(tc.ext)
+			dag := builder.Build()
+
+			var got *sesame_api_v1alpha1.ExtensionService
+			for _, u := range dag.StatusCache.GetStatusUpdates() {
+				if _, ok := u.Resource.(*sesame_api_v1alpha1.ExtensionService); ok {
+					got = u.Mutator.Mutate(tc.ext).(*sesame_api_v1alpha1.ExtensionService)
+				}
+			}
+			if !assert.NotNil(t, got) {
+				return
+			}
+
+			cond := got.Status.GetConditionFor(sesame_api_v1.ValidConditionType)
+			if !assert.NotNil(t, cond) {
+				return
+			}
+			assert.Equal(t, tc.wantStatus, cond.Status)
+			assert.Equal(t, tc.wantErrors, cond.Errors)
+
+			if tc.wantUp == nil {
+				assert.Empty(t, dag.GetExtensionClusters())
+				return
+			}
+			ext := dag.GetExtensionCluster(ExtensionClusterName(k8s.NamespacedNameOf(tc.ext)))
+			if assert.NotNil(t, ext) {
+				assert.Equal(t, *tc.wantUp, ext.Upstream)
+			}
+		})
+	}
+
+	run(t, "static endpoints only", testcase{
+		ext: &sesame_api_v1alpha1.ExtensionService{
+			ObjectMeta: fixture.ObjectMeta("roots/vms"),
+			Spec: sesame_api_v1alpha1.ExtensionServiceSpec{
+				Endpoints: []sesame_api_v1alpha1.ExtensionServiceEndpoint{
+					{Address: "192.0.2.10", Port: 9000},
+					{Address: "2001:db8::10", Port: 9000, Weight: 3},
+				},
+			},
+		},
+		wantStatus: sesame_api_v1.ConditionTrue,
+		wantUp: &ServiceCluster{
+			ClusterName: "extension/roots/vms",
+			StaticEndpoints: []StaticEndpoint{
+				{Address: "192.0.2.10", Port: 9000},
+				{Address: "2001:db8::10", Port: 9000, Weight: 3},
+			},
+		},
+	})
+
+	run(t, "static endpoints and services", testcase{
+		ext: &sesame_api_v1alpha1.ExtensionService{
+			ObjectMeta: fixture.ObjectMeta("roots/mixed"),
+			Spec: sesame_api_v1alpha1.ExtensionServiceSpec{
+				Services: []sesame_api_v1alpha1.ExtensionServiceTarget{{
+					Name: "home",
+					Port: 8080,
+				}},
+				Endpoints: []sesame_api_v1alpha1.ExtensionServiceEndpoint{
+					{Address: "192.0.2.10", Port: 9000},
+				},
+			},
+		},
+		wantStatus: sesame_api_v1.ConditionTrue,
+		wantUp: &ServiceCluster{
+			ClusterName: "extension/roots/mixed",
+			Services: []WeightedService{{
+				ServiceName:      "home",
+				ServiceNamespace: "roots",
+				ServicePort:      fixture.ServiceRootsHome.Spec.Ports[0],
+			}},
+			StaticEndpoints: []StaticEndpoint{
+				{Address: "192.0.2.10", Port: 9000},
+			},
+		},
+	})
+
+	run(t, "static endpoint with a hostname", testcase{
+		ext: &sesame_api_v1alpha1.ExtensionService{
+			ObjectMeta: fixture.ObjectMeta("roots/vms"),
+			Spec: sesame_api_v1alpha1.ExtensionServiceSpec{
+				Endpoints: []sesame_api_v1alpha1.ExtensionServiceEndpoint{
+					{Address: "vm.example.com", Port: 9000},
+				},
+			},
+		},
+		wantStatus: sesame_api_v1.ConditionFalse,
+		wantErrors: []sesame_api_v1.SubCondition{{
+			Type:    sesame_api_v1.ConditionTypeSpecError,
+			Status:  sesame_api_v1.ConditionTrue,
+			Reason:  "EndpointAddressInvalid",
+			Message: `spec.endpoints[0].address "vm.example.com" is not an IP address`,
+		}},
+	})
+
+	for _, addr := range []string{"127.0.0.1", "::1", "0.0.0.0", "::", "169.254.169.254", "fe80::1"} {
+		run(t, "static endpoint with a non-routable address "+addr, testcase{
+			ext: &sesame_api_v1alpha1.ExtensionService{
+				ObjectMeta: fixture.ObjectMeta("roots/vms"),
+				Spec: sesame_api_v1alpha1.ExtensionServiceSpec{
+					Endpoints: []sesame_api_v1alpha1.ExtensionServiceEndpoint{
+						{Address: addr, Port: 9000},
+					},
+				},
+			},
+			wantStatus: sesame_api_v1.ConditionFalse,
+			wantErrors: []sesame_api_v1.SubCondition{{
+				Type:    sesame_api_v1.ConditionTypeSpecError,
+				Status:  sesame_api_v1.ConditionTrue,
+				Reason:  "EndpointAddressInvalid",
+				Message: fmt.Sprintf("spec.endpoints[0].address %q is a loopback, unspecified or link-local address, this is not allowed", addr),
+			}},
+		})
+	}
+
+	run(t, "no services or static endpoints", testcase{
+		ext: &sesame_api_v1alpha1.ExtensionService{
+			ObjectMeta: fixture.ObjectMeta("roots/empty"),
+		},
+		wantStatus: sesame_api_v1.ConditionFalse,
+		wantErrors: []sesame_api_v1.SubCondition{{
+			Type:    sesame_api_v1.ConditionTypeSpecError,
+			Status:  sesame_api_v1.ConditionTrue,
+			Reason:  "NoUpstreams",
+			Message: "at least one of spec.services or spec.endpoints must be specified",
+		}},
+	})
+}
+
+func TestDirectResponseAndLocalReplyDAGStatus(t *testing.T) {
+	build := func(t *testing.T, objs ...interface{}) *DAG {
+		t.Helper()
+		builder := Builder{
+			Source: KubernetesCache{
+				RootNamespaces: []string{"roots"},
+				FieldLogger:    fixture.NewTestLogger(t),
+			},
+			Processors: []Processor{
+				&HTTPProxyProcessor{},
+				&ListenerProcessor{},
+			},
+		}
+		for _, o := range objs {
+			builder.Source.Insert(o)
+		}
+		return builder.Build()
+	}
+
+	proxy := func(vh sesame_api_v1.VirtualHost, routes ...sesame_api_v1.Route) *sesame_api_v1.HTTPProxy {
+		vh.Fqdn = "example.com"
+		return &sesame_api_v1.HTTPProxy{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "roots",
+				Name:      "example",
+			},
+			Spec: sesame_api_v1.HTTPProxySpec{
+				VirtualHost: &vh,
+				Routes:      routes,
+			},
+		}
+	}
+
+	proxyCondition := func(t *testing.T, d *DAG) sesame_api_v1.DetailedCondition {
+		t.Helper()
+		for _, pu := range d.StatusCache.GetProxyUpdates() {
+			if pu.Fullname == (types.NamespacedName{Namespace: "roots", Name: "example"}) {
+				return *pu.Conditions[status.ValidCondition]
+			}
+		}
+		t.Fatal("no status update for HTTPProxy roots/example")
+		return sesame_api_v1.DetailedCondition{}
+	}
+
+	home := sesame_api_v1.Route{
+		Services: []sesame_api_v1.Service{{
+			Name: "home",
+			Port: 8080,
+		}},
+	}
+
+	robots := sesame_api_v1.Route{
+		Conditions: []sesame_api_v1.MatchCondition{{
+			Prefix: "/robots.txt",
+		}},
+		DirectResponsePolicy: &sesame_api_v1.HTTPDirectResponsePolicy{
+			StatusCode: 200,
+			Body:       "User-agent: *\nDisallow: /\n",
+		},
+	}
+
+	errorPages := &v1.ConfigMap{
+		ObjectMeta: fixture.ObjectMeta("roots/error-pages"),
+		Data: map[string]string{
+			"maintenance.html": "<h1>Down for maintenance</h1>",
+		},
+	}
+
+	localReplyPolicy := func(pages ...sesame_api_v1.ErrorPage) *sesame_api_v1.LocalReplyPolicy {
+		return &sesame_api_v1.LocalReplyPolicy{ErrorPages: pages}
+	}
+
+	maintenance := sesame_api_v1.ErrorPage{
+		StatusCode:  503,
+		ConfigMap:   "error-pages",
+		Key:         "maintenance.html",
+		ContentType: "text/html",
+	}
+
+	t.Run("route with a direct response and no services", func(t *testing.T) {
+		d := build(t, fixture.ServiceRootsHome, proxy(sesame_api_v1.VirtualHost{}, home, robots))
+
+		assert.Equal(t, fixture.NewValidCondition().Valid(), proxyCondition(t, d))
+		vhost := d.GetVirtualHost("example.com")
+		require.NotNil(t, vhost)
+		r := vhost.Routes[conditionsToString(&Route{PathMatchCondition: &PrefixMatchCondition{Prefix: "/robots.txt"}})]
+		require.NotNil(t, r)
+		assert.Equal(t, &DirectResponse{StatusCode: 200, Body: "User-agent: *\nDisallow: /\n"}, r.DirectResponse)
+		assert.Empty(t, r.Clusters)
+	})
+
+	t.Run("route with both a direct response and a redirect", func(t *testing.T) {
+		conflict := robots
+		conflict.RequestRedirectPolicy = &sesame_api_v1.HTTPRequestRedirectPolicy{
+			Hostname: pointer.StringPtr("www.example.com"),
+		}
+		d := build(t, proxy(sesame_api_v1.VirtualHost{}, conflict))
+
+		assert.Equal(t, fixture.NewValidCondition().
+			WithError(sesame_api_v1.ConditionTypeRouteError, "PolicyConflict",
+				"route cannot specify both requestRedirectPolicy and directResponsePolicy"),
+			proxyCondition(t, d))
+	})
+
+	t.Run("route with both a direct response and services", func(t *testing.T) {
+		conflict := robots
+		conflict.Services = home.Services
+		d := build(t, fixture.ServiceRootsHome, proxy(sesame_api_v1.VirtualHost{}, conflict))
+
+		assert.Equal(t, fixture.NewValidCondition().
+			WithError(sesame_api_v1.ConditionTypeRouteError, "PolicyConflict",
+				"route cannot specify both services and directResponsePolicy"),
+			proxyCondition(t, d))
+	})
+
+	t.Run("local reply policy reads error pages from a ConfigMap", func(t *testing.T) {
+		d := build(t, fixture.ServiceRootsHome, errorPages, proxy(sesame_api_v1.VirtualHost{
+			LocalReplyPolicy: localReplyPolicy(maintenance, sesame_api_v1.ErrorPage{
+				StatusCode: 404,
+				ConfigMap:  "error-pages",
+				Key:        "maintenance.html",
+			}),
+		}, home))
+
+		assert.Equal(t, fixture.NewValidCondition().Valid(), proxyCondition(t, d))
+		vhost := d.GetVirtualHost("example.com")
+		require.NotNil(t, vhost)
+		assert.Equal(t, []LocalReply{
+			{StatusCode: 503, Body: "<h1>Down for maintenance</h1>", ContentType: "text/html"},
+			{StatusCode: 404, Body: "<h1>Down for maintenance</h1>", ContentType: "text/plain"},
+		}, vhost.LocalReplies)
+	})
+
+	t.Run("local reply policy refers to a missing ConfigMap", func(t *testing.T) {
+		d := build(t, fixture.ServiceRootsHome, proxy(sesame_api_v1.VirtualHost{
+			LocalReplyPolicy: localReplyPolicy(maintenance),
+		}, home))
+
+		assert.Equal(t, fixture.NewValidCondition().
+			WithError(sesame_api_v1.ConditionTypeVirtualHostError, "LocalReplyPolicyNotValid",
+				`Spec.VirtualHost.LocalReplyPolicy is invalid: error page for status code 503: ConfigMap "roots/error-pages": ConfigMap not found`),
+			proxyCondition(t, d))
+		if vhost := d.GetVirtualHost("example.com"); vhost != nil {
+			assert.Empty(t, vhost.Routes)
+		}
+	})
+
+	t.Run("local reply policy refers to a missing ConfigMap key", func(t *testing.T) {
+		missing := maintenance
+		missing.Key = "missing.html"
+		d := build(t, fixture.ServiceRootsHome, errorPages, proxy(sesame_api_v1.VirtualHost{
+			LocalReplyPolicy: localReplyPolicy(missing),
+		}, home))
+
+		assert.Equal(t, fixture.NewValidCondition().
+			WithError(sesame_api_v1.ConditionTypeVirtualHostError, "LocalReplyPolicyNotValid",
+				`Spec.VirtualHost.LocalReplyPolicy is invalid: error page for status code 503: ConfigMap "roots/error-pages": ConfigMap has no "missing.html" key`),
+			proxyCondition(t, d))
+	})
+
+	t.Run("local reply policy has duplicate status codes", func(t *testing.T) {
+		d := build(t, fixture.ServiceRootsHome, errorPages, proxy(sesame_api_v1.VirtualHost{
+			LocalReplyPolicy: localReplyPolicy(maintenance, maintenance),
+		}, home))
+
+		assert.Equal(t, fixture.NewValidCondition().
+			WithError(sesame_api_v1.ConditionTypeVirtualHostError, "LocalReplyPolicyNotValid",
+				"Spec.VirtualHost.LocalReplyPolicy is invalid: duplicate error page for status code 503"),
+			proxyCondition(t, d))
+	})
+}
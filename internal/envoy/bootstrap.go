@@ -90,6 +90,11 @@ type BootstrapConfig struct {
 	// DNSLookupFamily specifies DNS Resolution Policy to use for Envoy -> Sesame cluster name lookup.
 	// Either v4, v6 or auto.
 	DNSLookupFamily string
+
+	// Parameters holds the optional overload manager, stats, node and
+	// runtime configuration from the bootstrap section of the Sesame
+	// configuration file.
+	Parameters config.BootstrapParameters
 }
 
 // GetXdsAddress returns the address configured or defaults to "127.0.0.1"
@@ -25,6 +25,12 @@ import (
 
 // Clustername returns the name of the CDS cluster for this service.
 func Clustername(cluster *dag.Cluster) string {
+	// Clusters that forward to an ExtensionService
+	// use the Envoy cluster of the ExtensionService.
+	if ext := cluster.ExtensionCluster; ext != nil {
+		return ext.Name
+	}
+
 	service := cluster.Upstream
 	buf := cluster.LoadBalancerPolicy
 	if hc := cluster.HTTPHealthCheckPolicy; hc != nil {
@@ -46,6 +52,27 @@ func Clustername(cluster *dag.Cluster) string {
 		buf += uv.CACertificate.Object.ObjectMeta.Name
 		buf += uv.SubjectName
 	}
+	if cp := cluster.ConnectionPolicy; cp != nil {
+		buf += fmt.Sprintf("%d/%d/%d/%d", cp.MaxConnections, cp.MaxPendingRequests, cp.MaxRequestsPerConnection, cp.MaxConcurrentStreams)
+		if !cp.IdleTimeout.UseDefault() {
+			buf += cp.IdleTimeout.Duration().String()
+		}
+		if ka := cp.TCPKeepalive; ka != nil {
+			buf += fmt.Sprintf("%d%s%s", ka.Probes, ka.IdleTime, ka.Interval)
+		}
+	}
+	if cb := cluster.CircuitBreakers; cb != nil {
+		buf += fmt.Sprintf("%d/%d/%d/%d", cb.MaxConnections, cb.MaxPendingRequests, cb.MaxRequests, cb.MaxRetries)
+		if rb := cb.RetryBudget; rb != nil {
+			buf += "budget"
+			if rb.BudgetPercent != nil {
+				buf += fmt.Sprintf("%d%%", *rb.BudgetPercent)
+			}
+			if rb.MinRetryConcurrency != nil {
+				buf += fmt.Sprintf("min%d", *rb.MinRetryConcurrency)
+			}
+		}
+	}
 
 	// This isn't a crypto hash, we just want a unique name.
 	hash := sha1.Sum([]byte(buf)) // nolint:gosec
@@ -69,12 +69,15 @@ func SingleSimpleCluster(route *dag.Route) bool {
 		// no request headers policy
 	} else if len(cluster.RequestHeadersPolicy.Set) != 0 ||
 		len(cluster.RequestHeadersPolicy.Add) != 0 ||
+		len(cluster.RequestHeadersPolicy.AddIfAbsent) != 0 ||
 		len(cluster.RequestHeadersPolicy.Remove) != 0 {
 		return false
 	}
 	if cluster.ResponseHeadersPolicy == nil {
 		// no response headers policy
 	} else if len(cluster.ResponseHeadersPolicy.Set) != 0 ||
+		len(cluster.ResponseHeadersPolicy.Add) != 0 ||
+		len(cluster.ResponseHeadersPolicy.AddIfAbsent) != 0 ||
 		len(cluster.ResponseHeadersPolicy.Remove) != 0 {
 		return false
 	}
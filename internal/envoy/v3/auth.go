@@ -119,14 +119,49 @@ func DownstreamTLSContext(serverSecret *dag.Secret, tlsMinProtoVersion envoy_v3_
 }
 
 func http2ProtocolOptions() map[string]*any.Any {
+	return httpProtocolOptions("h2", nil)
+}
+
+// httpProtocolOptions returns the upstream HTTP protocol options for a
+// cluster speaking the given protocol, tuned by the connection policy.
+// It returns nil for HTTP/1.1 clusters when there is nothing to tune.
+func httpProtocolOptions(protocol string, cp *dag.ConnectionPolicy) map[string]*any.Any {
+	options := &envoy_extensions_upstream_http_v3.HttpProtocolOptions{}
+	http2 := &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_Http2ProtocolOptions{}
+
+	if cp != nil {
+		if cp.MaxRequestsPerConnection > 0 || !cp.IdleTimeout.UseDefault() {
+			options.CommonHttpProtocolOptions = &envoy_api_v3_core.HttpProtocolOptions{
+				IdleTimeout:              envoy.Timeout(cp.IdleTimeout),
+				MaxRequestsPerConnection: protobuf.UInt32OrNil(cp.MaxRequestsPerConnection),
+			}
+		}
+		if cp.MaxConcurrentStreams > 0 {
+			http2.Http2ProtocolOptions = &envoy_api_v3_core.Http2ProtocolOptions{
+				MaxConcurrentStreams: protobuf.UInt32(cp.MaxConcurrentStreams),
+			}
+		}
+	}
+
+	switch protocol {
+	case "h2", "h2c":
+		options.UpstreamProtocolOptions = &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_{
+			ExplicitHttpConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig{
+				ProtocolConfig: http2,
+			},
+		}
+	default:
+		if options.CommonHttpProtocolOptions == nil {
+			return nil
+		}
+		options.UpstreamProtocolOptions = &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_{
+			ExplicitHttpConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig{
+				ProtocolConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_HttpProtocolOptions{},
+			},
+		}
+	}
+
 	return map[string]*any.Any{
-		"envoy.extensions.upstreams.http.v3.HttpProtocolOptions": protobuf.MustMarshalAny(
-			&envoy_extensions_upstream_http_v3.HttpProtocolOptions{
-				UpstreamProtocolOptions: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_{
-					ExplicitHttpConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig{
-						ProtocolConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_Http2ProtocolOptions{},
-					},
-				},
-			}),
+		"envoy.extensions.upstreams.http.v3.HttpProtocolOptions": protobuf.MustMarshalAny(options),
 	}
 }
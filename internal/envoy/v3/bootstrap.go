@@ -29,7 +29,10 @@ import (
 	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_endpoint_v3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	envoy_metrics_v3 "github.com/envoyproxy/go-control-plane/envoy/config/metrics/v3"
+	envoy_overload_v3 "github.com/envoyproxy/go-control-plane/envoy/config/overload/v3"
 	envoy_file_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/file/v3"
+	envoy_fixed_heap_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/resource_monitors/fixed_heap/v3"
 	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	envoy_service_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	envoy_matcher_v3 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
@@ -37,6 +40,8 @@ import (
 	"github.com/golang/protobuf/ptypes/any"
 	"github.com/projectsesame/sesame/internal/envoy"
 	"github.com/projectsesame/sesame/internal/protobuf"
+	"github.com/projectsesame/sesame/pkg/config"
+	"google.golang.org/protobuf/types/known/structpb"
 )
 
 // WriteBootstrap writes bootstrap configuration to files.
@@ -154,7 +159,7 @@ func bootstrap(c *envoy.BootstrapConfig) ([]bootstrapf, error) {
 }
 
 func bootstrapConfig(c *envoy.BootstrapConfig) *envoy_bootstrap_v3.Bootstrap {
-	return &envoy_bootstrap_v3.Bootstrap{
+	b := &envoy_bootstrap_v3.Bootstrap{
 		DynamicResources: &envoy_bootstrap_v3.Bootstrap_DynamicResources{
 			LdsConfig: ConfigSource("sesame"),
 			CdsConfig: ConfigSource("sesame"),
@@ -215,6 +220,194 @@ func bootstrapConfig(c *envoy.BootstrapConfig) *envoy_bootstrap_v3.Bootstrap {
 			Address:   UnixSocketAddress(c.GetAdminAddress(), c.GetAdminPort()),
 		},
 	}
+
+	applyBootstrapParameters(b, &c.Parameters)
+	return b
+}
+
+// applyBootstrapParameters adds the optional overload manager, stats,
+// node and runtime configuration to the bootstrap.
+func applyBootstrapParameters(b *envoy_bootstrap_v3.Bootstrap, p *config.BootstrapParameters) {
+	if p.OverloadManager.Enabled() {
+		b.OverloadManager = overloadManager(&p.OverloadManager)
+	}
+
+	for i, sink := range p.StatsSinks {
+		// Metrics service sinks need a cluster for the gRPC service.
+		clusterName := fmt.Sprintf("stats-sink-%d", i)
+		if sink.Type == config.MetricsServiceSink {
+			b.StaticResources.Clusters = append(b.StaticResources.Clusters, statsSinkCluster(clusterName, sink))
+		}
+		b.StatsSinks = append(b.StatsSinks, statsSink(clusterName, sink))
+	}
+
+	if len(p.StatsTags) > 0 {
+		b.StatsConfig = &envoy_metrics_v3.StatsConfig{}
+		for _, tag := range p.StatsTags {
+			specifier := &envoy_metrics_v3.TagSpecifier{TagName: tag.Name}
+			if tag.Regex != "" {
+				specifier.TagValue = &envoy_metrics_v3.TagSpecifier_Regex{Regex: tag.Regex}
+			} else {
+				specifier.TagValue = &envoy_metrics_v3.TagSpecifier_FixedValue{FixedValue: tag.FixedValue}
+			}
+			b.StatsConfig.StatsTags = append(b.StatsConfig.StatsTags, specifier)
+		}
+	}
+
+	if p.Locality != (config.LocalityParameters{}) || len(p.NodeMetadata) > 0 {
+		b.Node = &envoy_core_v3.Node{}
+		if p.Locality != (config.LocalityParameters{}) {
+			b.Node.Locality = &envoy_core_v3.Locality{
+				Region:  p.Locality.Region,
+				Zone:    p.Locality.Zone,
+				SubZone: p.Locality.SubZone,
+			}
+		}
+		if len(p.NodeMetadata) > 0 {
+			b.Node.Metadata = stringStruct(p.NodeMetadata)
+		}
+	}
+
+	runtime := map[string]string{}
+	for k, v := range p.Runtime {
+		runtime[k] = v
+	}
+	if max := p.OverloadManager.MaxActiveDownstreamConnections; max > 0 {
+		runtime["overload.global_downstream_max_connections"] = strconv.FormatUint(max, 10)
+	}
+
+	if len(runtime) > 0 {
+		// Keep an admin layer so that runtime values can still be
+		// changed through the admin interface.
+		b.LayeredRuntime = &envoy_bootstrap_v3.LayeredRuntime{
+			Layers: []*envoy_bootstrap_v3.RuntimeLayer{{
+				Name: "static_layer",
+				LayerSpecifier: &envoy_bootstrap_v3.RuntimeLayer_StaticLayer{
+					StaticLayer: stringStruct(runtime),
+				},
+			}, {
+				Name: "admin_layer",
+				LayerSpecifier: &envoy_bootstrap_v3.RuntimeLayer_AdminLayer_{
+					AdminLayer: &envoy_bootstrap_v3.RuntimeLayer_AdminLayer{},
+				},
+			}},
+		}
+	}
+}
+
+func overloadManager(p *config.OverloadManagerParameters) *envoy_overload_v3.OverloadManager {
+	const heapMonitor = "envoy.resource_monitors.fixed_heap"
+
+	om := &envoy_overload_v3.OverloadManager{
+		RefreshInterval: protobuf.Duration(250 * time.Millisecond),
+		ResourceMonitors: []*envoy_overload_v3.ResourceMonitor{{
+			Name: heapMonitor,
+			ConfigType: &envoy_overload_v3.ResourceMonitor_TypedConfig{
+				TypedConfig: protobuf.MustMarshalAny(&envoy_fixed_heap_v3.FixedHeapConfig{
+					MaxHeapSizeBytes: p.MaxHeapSizeBytes,
+				}),
+			},
+		}},
+	}
+
+	actions := []struct {
+		name      string
+		threshold float64
+	}{
+		{"envoy.overload_actions.shrink_heap", p.ShrinkHeapThreshold},
+		{"envoy.overload_actions.stop_accepting_requests", p.StopAcceptingRequestsThreshold},
+		{"envoy.overload_actions.stop_accepting_connections", p.StopAcceptingConnectionsThreshold},
+		{"envoy.overload_actions.disable_http_keepalive", p.DisableKeepaliveThreshold},
+	}
+
+	for _, action := range actions {
+		if action.threshold == 0 {
+			continue
+		}
+		om.Actions = append(om.Actions, &envoy_overload_v3.OverloadAction{
+			Name: action.name,
+			Triggers: []*envoy_overload_v3.Trigger{{
+				Name: heapMonitor,
+				TriggerOneof: &envoy_overload_v3.Trigger_Threshold{
+					Threshold: &envoy_overload_v3.ThresholdTrigger{
+						Value: action.threshold,
+					},
+				},
+			}},
+		})
+	}
+
+	return om
+}
+
+func statsSink(clusterName string, sink config.StatsSinkParameters) *envoy_metrics_v3.StatsSink {
+	var (
+		name  string
+		typed proto.Message
+	)
+
+	switch sink.Type {
+	case config.StatsdSink:
+		name = "envoy.stat_sinks.statsd"
+		typed = &envoy_metrics_v3.StatsdSink{
+			StatsdSpecifier: &envoy_metrics_v3.StatsdSink_Address{
+				Address: UDPSocketAddress(sink.Address, sink.Port),
+			},
+			Prefix: sink.Prefix,
+		}
+	case config.DogStatsdSink:
+		name = "envoy.stat_sinks.dog_statsd"
+		typed = &envoy_metrics_v3.DogStatsdSink{
+			DogStatsdSpecifier: &envoy_metrics_v3.DogStatsdSink_Address{
+				Address: UDPSocketAddress(sink.Address, sink.Port),
+			},
+			Prefix: sink.Prefix,
+		}
+	default:
+		name = "envoy.stat_sinks.metrics_service"
+		typed = &envoy_metrics_v3.MetricsServiceConfig{
+			GrpcService: &envoy_core_v3.GrpcService{
+				TargetSpecifier: &envoy_core_v3.GrpcService_EnvoyGrpc_{
+					EnvoyGrpc: &envoy_core_v3.GrpcService_EnvoyGrpc{
+						ClusterName: clusterName,
+					},
+				},
+			},
+			TransportApiVersion: envoy_core_v3.ApiVersion_V3,
+		}
+	}
+
+	return &envoy_metrics_v3.StatsSink{
+		Name: name,
+		ConfigType: &envoy_metrics_v3.StatsSink_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(typed),
+		},
+	}
+}
+
+func statsSinkCluster(name string, sink config.StatsSinkParameters) *envoy_cluster_v3.Cluster {
+	return &envoy_cluster_v3.Cluster{
+		Name:                 name,
+		ConnectTimeout:       protobuf.Duration(5 * time.Second),
+		ClusterDiscoveryType: ClusterDiscoveryTypeForAddress(sink.Address, envoy_cluster_v3.Cluster_STRICT_DNS),
+		LbPolicy:             envoy_cluster_v3.Cluster_ROUND_ROBIN,
+		LoadAssignment: &envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: name,
+			Endpoints: Endpoints(
+				SocketAddress(sink.Address, sink.Port),
+			),
+		},
+		TypedExtensionProtocolOptions: http2ProtocolOptions(),
+	}
+}
+
+// stringStruct converts a map of strings to a Struct.
+func stringStruct(values map[string]string) *structpb.Struct {
+	s := &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	for k, v := range values {
+		s.Fields[k] = structpb.NewStringValue(v)
+	}
+	return s
 }
 
 func adminAccessLog(logPath string) []*envoy_config_accesslog_v3.AccessLog {
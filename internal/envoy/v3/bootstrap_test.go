@@ -18,11 +18,13 @@ import (
 	"testing"
 
 	envoy_bootstrap_v3 "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
+	envoy_cluster_v3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	envoy_service_discovery_v3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
 	"github.com/projectsesame/sesame/internal/envoy"
 	"github.com/projectsesame/sesame/internal/protobuf"
+	"github.com/projectsesame/sesame/pkg/config"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -1468,6 +1470,168 @@ func TestBootstrap(t *testing.T) {
 	}
 }
 
+func TestBootstrapParameters(t *testing.T) {
+	got := bootstrapConfig(&envoy.BootstrapConfig{
+		Path:        "envoy.json",
+		Namespace:   "testing-ns",
+		XDSAddress:  "127.0.0.1",
+		XDSGRPCPort: 8001,
+		Parameters: config.BootstrapParameters{
+			OverloadManager: config.OverloadManagerParameters{
+				MaxHeapSizeBytes:               1073741824,
+				ShrinkHeapThreshold:            0.95,
+				StopAcceptingRequestsThreshold: 0.98,
+				DisableKeepaliveThreshold:      0.9,
+				MaxActiveDownstreamConnections: 50000,
+			},
+			StatsSinks: []config.StatsSinkParameters{{
+				Type:    config.StatsdSink,
+				Address: "127.0.0.1",
+				Port:    8125,
+				Prefix:  "envoy",
+			}, {
+				Type:    config.MetricsServiceSink,
+				Address: "metrics.example.com",
+				Port:    9000,
+			}},
+			StatsTags: []config.StatsTagParameters{{
+				Name:       "cluster",
+				FixedValue: "east",
+			}},
+			Locality: config.LocalityParameters{
+				Region: "us-east-1",
+				Zone:   "us-east-1a",
+			},
+			NodeMetadata: map[string]string{"team": "platform"},
+			Runtime:      map[string]string{"re2.max_program_size.error_level": "200"},
+		},
+	})
+
+	want := new(envoy_bootstrap_v3.Bootstrap)
+	unmarshal(t, `{
+  "overload_manager": {
+    "refresh_interval": "0.250s",
+    "resource_monitors": [
+      {
+        "name": "envoy.resource_monitors.fixed_heap",
+        "typed_config": {
+          "@type": "type.googleapis.com/envoy.extensions.resource_monitors.fixed_heap.v3.FixedHeapConfig",
+          "max_heap_size_bytes": "1073741824"
+        }
+      }
+    ],
+    "actions": [
+      {
+        "name": "envoy.overload_actions.shrink_heap",
+        "triggers": [
+          {
+            "name": "envoy.resource_monitors.fixed_heap",
+            "threshold": {
+              "value": 0.95
+            }
+          }
+        ]
+      },
+      {
+        "name": "envoy.overload_actions.stop_accepting_requests",
+        "triggers": [
+          {
+            "name": "envoy.resource_monitors.fixed_heap",
+            "threshold": {
+              "value": 0.98
+            }
+          }
+        ]
+      },
+      {
+        "name": "envoy.overload_actions.disable_http_keepalive",
+        "triggers": [
+          {
+            "name": "envoy.resource_monitors.fixed_heap",
+            "threshold": {
+              "value": 0.9
+            }
+          }
+        ]
+      }
+    ]
+  },
+  "stats_sinks": [
+    {
+      "name": "envoy.stat_sinks.statsd",
+      "typed_config": {
+        "@type": "type.googleapis.com/envoy.config.metrics.v3.StatsdSink",
+        "address": {
+          "socket_address": {
+            "protocol": "UDP",
+            "address": "127.0.0.1",
+            "port_value": 8125
+          }
+        },
+        "prefix": "envoy"
+      }
+    },
+    {
+      "name": "envoy.stat_sinks.metrics_service",
+      "typed_config": {
+        "@type": "type.googleapis.com/envoy.config.metrics.v3.MetricsServiceConfig",
+        "grpc_service": {
+          "envoy_grpc": {
+            "cluster_name": "stats-sink-1"
+          }
+        },
+        "transport_api_version": "V3"
+      }
+    }
+  ],
+  "stats_config": {
+    "stats_tags": [
+      {
+        "tag_name": "cluster",
+        "fixed_value": "east"
+      }
+    ]
+  },
+  "node": {
+    "locality": {
+      "region": "us-east-1",
+      "zone": "us-east-1a"
+    },
+    "metadata": {
+      "team": "platform"
+    }
+  },
+  "layered_runtime": {
+    "layers": [
+      {
+        "name": "static_layer",
+        "static_layer": {
+          "overload.global_downstream_max_connections": "50000",
+          "re2.max_program_size.error_level": "200"
+        }
+      },
+      {
+        "name": "admin_layer",
+        "admin_layer": {}
+      }
+    ]
+  }
+}`, want)
+
+	protobuf.ExpectEqual(t, want.OverloadManager, got.OverloadManager)
+	protobuf.ExpectEqual(t, want.StatsSinks, got.StatsSinks)
+	protobuf.ExpectEqual(t, want.StatsConfig, got.StatsConfig)
+	protobuf.ExpectEqual(t, want.Node, got.Node)
+	protobuf.ExpectEqual(t, want.LayeredRuntime, got.LayeredRuntime)
+
+	// The metrics service cluster follows the built in clusters.
+	clusters := got.StaticResources.Clusters
+	assert.Len(t, clusters, 3)
+	assert.Equal(t, "sesame", clusters[0].Name)
+	assert.Equal(t, "stats-sink-1", clusters[2].Name)
+	assert.Equal(t, envoy_cluster_v3.Cluster_STRICT_DNS, clusters[2].GetType())
+}
+
 func unmarshal(t *testing.T, data string, pb proto.Message) {
 	err := jsonpb.UnmarshalString(data, pb)
 	checkErr(t, err)
@@ -64,17 +64,57 @@ func Cluster(c *dag.Cluster) *envoy_cluster_v3.Cluster {
 		cluster.IgnoreHealthOnHostRemoval = true
 	}
 
-	if envoy.AnyPositive(service.MaxConnections, service.MaxPendingRequests, service.MaxRequests, service.MaxRetries) {
+	// Limits from the circuit breakers take precedence over the ones
+	// from the connection policy, which take precedence over the ones
+	// from the Service annotations.
+	maxConnections, maxPendingRequests := service.MaxConnections, service.MaxPendingRequests
+	maxRequests, maxRetries := service.MaxRequests, service.MaxRetries
+	if cp := c.ConnectionPolicy; cp != nil {
+		maxConnections = uint32OrDefault(cp.MaxConnections, maxConnections)
+		maxPendingRequests = uint32OrDefault(cp.MaxPendingRequests, maxPendingRequests)
+		if ka := cp.TCPKeepalive; ka != nil {
+			cluster.UpstreamConnectionOptions = &envoy_cluster_v3.UpstreamConnectionOptions{
+				TcpKeepalive: &envoy_core_v3.TcpKeepalive{
+					KeepaliveProbes:   protobuf.UInt32OrNil(ka.Probes),
+					KeepaliveTime:     protobuf.UInt32OrNil(uint32(ka.IdleTime.Seconds())),
+					KeepaliveInterval: protobuf.UInt32OrNil(uint32(ka.Interval.Seconds())),
+				},
+			}
+		}
+	}
+
+	var retryBudget *envoy_cluster_v3.CircuitBreakers_Thresholds_RetryBudget
+	if cb := c.CircuitBreakers; cb != nil {
+		maxConnections = uint32OrDefault(cb.MaxConnections, maxConnections)
+		maxPendingRequests = uint32OrDefault(cb.MaxPendingRequests, maxPendingRequests)
+		maxRequests = uint32OrDefault(cb.MaxRequests, maxRequests)
+		maxRetries = uint32OrDefault(cb.MaxRetries, maxRetries)
+
+		if rb := cb.RetryBudget; rb != nil {
+			retryBudget = &envoy_cluster_v3.CircuitBreakers_Thresholds_RetryBudget{}
+			if rb.BudgetPercent != nil {
+				retryBudget.BudgetPercent = &envoy_type.Percent{Value: float64(*rb.BudgetPercent)}
+			}
+			if rb.MinRetryConcurrency != nil {
+				retryBudget.MinRetryConcurrency = protobuf.UInt32(*rb.MinRetryConcurrency)
+			}
+		}
+	}
+
+	if envoy.AnyPositive(maxConnections, maxPendingRequests, maxRequests, maxRetries) || retryBudget != nil {
 		cluster.CircuitBreakers = &envoy_cluster_v3.CircuitBreakers{
 			Thresholds: []*envoy_cluster_v3.CircuitBreakers_Thresholds{{
-				MaxConnections:     protobuf.UInt32OrNil(service.MaxConnections),
-				MaxPendingRequests: protobuf.UInt32OrNil(service.MaxPendingRequests),
-				MaxRequests:        protobuf.UInt32OrNil(service.MaxRequests),
-				MaxRetries:         protobuf.UInt32OrNil(service.MaxRetries),
+				MaxConnections:     protobuf.UInt32OrNil(maxConnections),
+				MaxPendingRequests: protobuf.UInt32OrNil(maxPendingRequests),
+				MaxRequests:        protobuf.UInt32OrNil(maxRequests),
+				MaxRetries:         protobuf.UInt32OrNil(maxRetries),
+				RetryBudget:        retryBudget,
 			}},
 		}
 	}
 
+	cluster.TypedExtensionProtocolOptions = httpProtocolOptions(c.Protocol, c.ConnectionPolicy)
+
 	switch c.Protocol {
 	case "tls":
 		cluster.TransportSocket = UpstreamTLSTransportSocket(
@@ -85,7 +125,6 @@ func Cluster(c *dag.Cluster) *envoy_cluster_v3.Cluster {
 			),
 		)
 	case "h2":
-		cluster.TypedExtensionProtocolOptions = http2ProtocolOptions()
 		cluster.TransportSocket = UpstreamTLSTransportSocket(
 			UpstreamTLSContext(
 				c.UpstreamValidation,
@@ -94,8 +133,6 @@ func Cluster(c *dag.Cluster) *envoy_cluster_v3.Cluster {
 				"h2",
 			),
 		)
-	case "h2c":
-		cluster.TypedExtensionProtocolOptions = http2ProtocolOptions()
 	}
 
 	return cluster
@@ -146,6 +183,14 @@ func ExtensionCluster(ext *dag.ExtensionCluster) *envoy_cluster_v3.Cluster {
 	return cluster
 }
 
+// uint32OrDefault returns val if it is set, otherwise def.
+func uint32OrDefault(val, def uint32) uint32 {
+	if val > 0 {
+		return val
+	}
+	return def
+}
+
 // StaticClusterLoadAssignment creates a *envoy_endpoint_v3.ClusterLoadAssignment pointing to the external DNS address of the service
 func StaticClusterLoadAssignment(service *dag.Service) *envoy_endpoint_v3.ClusterLoadAssignment {
 	addr := SocketAddress(service.ExternalName, int(service.Weighted.ServicePort.Port))
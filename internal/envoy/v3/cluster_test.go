@@ -26,6 +26,7 @@ import (
 	"github.com/projectsesame/sesame/internal/dag"
 	"github.com/projectsesame/sesame/internal/envoy"
 	"github.com/projectsesame/sesame/internal/protobuf"
+	"github.com/projectsesame/sesame/internal/timeout"
 	"github.com/projectsesame/sesame/internal/xds"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
@@ -108,6 +109,8 @@ func TestCluster(t *testing.T) {
 		},
 	}
 
+	budgetPercent, minRetryConcurrency := uint32(25), uint32(5)
+
 	tests := map[string]struct {
 		cluster *dag.Cluster
 		want    *envoy_cluster_v3.Cluster
@@ -412,6 +415,144 @@ func TestCluster(t *testing.T) {
 				},
 			},
 		},
+		"connection policy overrides annotations": {
+			cluster: &dag.Cluster{
+				Upstream: &dag.Service{
+					MaxConnections: 9000,
+					MaxRetries:     7,
+					Weighted: dag.WeightedService{
+						Weight:           1,
+						ServiceName:      s1.Name,
+						ServiceNamespace: s1.Namespace,
+						ServicePort:      s1.Spec.Ports[0],
+					},
+				},
+				ConnectionPolicy: &dag.ConnectionPolicy{
+					MaxConnections:           100,
+					MaxRequestsPerConnection: 1000,
+					IdleTimeout:              timeout.DurationSetting(90 * time.Second),
+					TCPKeepalive: &dag.TCPKeepalive{
+						Probes:   3,
+						IdleTime: 30 * time.Second,
+					},
+				},
+			},
+			want: &envoy_cluster_v3.Cluster{
+				Name:                 "default/kuard/443/9196022cbe",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(envoy_cluster_v3.Cluster_EDS),
+				EdsClusterConfig: &envoy_cluster_v3.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("sesame"),
+					ServiceName: "default/kuard/http",
+				},
+				CircuitBreakers: &envoy_cluster_v3.CircuitBreakers{
+					Thresholds: []*envoy_cluster_v3.CircuitBreakers_Thresholds{{
+						MaxConnections: protobuf.UInt32(100),
+						MaxRetries:     protobuf.UInt32(7),
+					}},
+				},
+				UpstreamConnectionOptions: &envoy_cluster_v3.UpstreamConnectionOptions{
+					TcpKeepalive: &envoy_core_v3.TcpKeepalive{
+						KeepaliveProbes: protobuf.UInt32(3),
+						KeepaliveTime:   protobuf.UInt32(30),
+					},
+				},
+				TypedExtensionProtocolOptions: map[string]*any.Any{
+					"envoy.extensions.upstreams.http.v3.HttpProtocolOptions": protobuf.MustMarshalAny(
+						&envoy_extensions_upstream_http_v3.HttpProtocolOptions{
+							CommonHttpProtocolOptions: &envoy_core_v3.HttpProtocolOptions{
+								IdleTimeout:              protobuf.Duration(90 * time.Second),
+								MaxRequestsPerConnection: protobuf.UInt32(1000),
+							},
+							UpstreamProtocolOptions: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_{
+								ExplicitHttpConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig{
+									ProtocolConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_HttpProtocolOptions{},
+								},
+							},
+						}),
+				},
+			},
+		},
+		"circuit breakers override connection policy and annotations": {
+			cluster: &dag.Cluster{
+				Upstream: &dag.Service{
+					MaxConnections: 9000,
+					MaxRequests:    404,
+					MaxRetries:     7,
+					Weighted: dag.WeightedService{
+						Weight:           1,
+						ServiceName:      s1.Name,
+						ServiceNamespace: s1.Namespace,
+						ServicePort:      s1.Spec.Ports[0],
+					},
+				},
+				ConnectionPolicy: &dag.ConnectionPolicy{
+					MaxConnections:     100,
+					MaxPendingRequests: 50,
+				},
+				CircuitBreakers: &dag.CircuitBreakers{
+					MaxConnections: 10,
+					MaxRequests:    20,
+					RetryBudget: &dag.RetryBudget{
+						BudgetPercent:       &budgetPercent,
+						MinRetryConcurrency: &minRetryConcurrency,
+					},
+				},
+			},
+			want: &envoy_cluster_v3.Cluster{
+				Name:                 "default/kuard/443/ee2fb51a56",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(envoy_cluster_v3.Cluster_EDS),
+				EdsClusterConfig: &envoy_cluster_v3.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("sesame"),
+					ServiceName: "default/kuard/http",
+				},
+				CircuitBreakers: &envoy_cluster_v3.CircuitBreakers{
+					Thresholds: []*envoy_cluster_v3.CircuitBreakers_Thresholds{{
+						MaxConnections:     protobuf.UInt32(10),
+						MaxPendingRequests: protobuf.UInt32(50),
+						MaxRequests:        protobuf.UInt32(20),
+						MaxRetries:         protobuf.UInt32(7),
+						RetryBudget: &envoy_cluster_v3.CircuitBreakers_Thresholds_RetryBudget{
+							BudgetPercent:       &envoy_type.Percent{Value: 25},
+							MinRetryConcurrency: protobuf.UInt32(5),
+						},
+					}},
+				},
+			},
+		},
+		"h2c upstream with connection policy": {
+			cluster: &dag.Cluster{
+				Upstream: service(s1, "h2c"),
+				Protocol: "h2c",
+				ConnectionPolicy: &dag.ConnectionPolicy{
+					MaxConcurrentStreams: 50,
+				},
+			},
+			want: &envoy_cluster_v3.Cluster{
+				Name:                 "default/kuard/443/18f27cad40",
+				AltStatName:          "default_kuard_443",
+				ClusterDiscoveryType: ClusterDiscoveryType(envoy_cluster_v3.Cluster_EDS),
+				EdsClusterConfig: &envoy_cluster_v3.Cluster_EdsClusterConfig{
+					EdsConfig:   ConfigSource("sesame"),
+					ServiceName: "default/kuard/http",
+				},
+				TypedExtensionProtocolOptions: map[string]*any.Any{
+					"envoy.extensions.upstreams.http.v3.HttpProtocolOptions": protobuf.MustMarshalAny(
+						&envoy_extensions_upstream_http_v3.HttpProtocolOptions{
+							UpstreamProtocolOptions: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_{
+								ExplicitHttpConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig{
+									ProtocolConfig: &envoy_extensions_upstream_http_v3.HttpProtocolOptions_ExplicitHttpConfig_Http2ProtocolOptions{
+										Http2ProtocolOptions: &envoy_core_v3.Http2ProtocolOptions{
+											MaxConcurrentStreams: protobuf.UInt32(50),
+										},
+									},
+								},
+							},
+						}),
+				},
+			},
+		},
 		"cluster with random load balancer policy": {
 			cluster: &dag.Cluster{
 				Upstream:           service(s1),
@@ -569,6 +710,27 @@ func TestClustername(t *testing.T) {
 			},
 			want: "it-is-a--dea8b0/must-be--dea8b0/9999/da39a3ee5e",
 		},
+		"circuit breakers": {
+			cluster: &dag.Cluster{
+				Upstream: &dag.Service{
+					Weighted: dag.WeightedService{
+						Weight:           1,
+						ServiceName:      "backend",
+						ServiceNamespace: "default",
+						ServicePort: v1.ServicePort{
+							Name:       "http",
+							Protocol:   "TCP",
+							Port:       80,
+							TargetPort: intstr.FromInt(6502),
+						},
+					},
+				},
+				CircuitBreakers: &dag.CircuitBreakers{
+					MaxConnections: 10,
+				},
+			},
+			want: "default/backend/80/a25580ac54",
+		},
 		"various healthcheck params": {
 			cluster: &dag.Cluster{
 				Upstream: &dag.Service{
@@ -28,6 +28,7 @@ import (
 	envoy_config_filter_http_local_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/local_ratelimit/v3"
 	lua "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/lua/v3"
 	envoy_extensions_filters_http_router_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/router/v3"
+	envoy_connection_limit_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/connection_limit/v3"
 	http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	tcp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
 	envoy_extensions_http_original_ip_detection_xff_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/http/original_ip_detection/xff/v3"
@@ -40,6 +41,7 @@ import (
 	"github.com/projectsesame/sesame/internal/protobuf"
 	"github.com/projectsesame/sesame/internal/sorter"
 	"github.com/projectsesame/sesame/internal/timeout"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 type HTTPVersionType = http.HttpConnectionManager_CodecType
@@ -138,6 +140,23 @@ func Listener(name, address string, port int, lf []*envoy_listener_v3.ListenerFi
 	return l
 }
 
+// QUICListener returns a new envoy_listener_v3.Listener that accepts
+// QUIC connections on the supplied UDP address and port.
+func QUICListener(name, address string, port int, options *envoy_core_v3.QuicProtocolOptions) *envoy_listener_v3.Listener {
+	return &envoy_listener_v3.Listener{
+		Name:    name,
+		Address: UDPSocketAddress(address, port),
+		UdpListenerConfig: &envoy_listener_v3.UdpListenerConfig{
+			QuicOptions: &envoy_listener_v3.QuicProtocolOptions{
+				QuicProtocolOptions: options,
+			},
+			DownstreamSocketConfig: &envoy_core_v3.UdpSocketConfig{
+				PreferGro: protobuf.Bool(true),
+			},
+		},
+	}
+}
+
 type httpConnectionManagerBuilder struct {
 	routeConfigName               string
 	metricsPrefix                 string
@@ -151,6 +170,7 @@ type httpConnectionManagerBuilder struct {
 	filters                       []*http.HttpFilter
 	codec                         HTTPVersionType // Note the zero value is AUTO, which is the default we want.
 	allowChunkedLength            bool
+	localReplyConfig              *http.LocalReplyConfig
 }
 
 // RouteConfigName sets the name of the RDS element that contains
@@ -223,6 +243,13 @@ func (b *httpConnectionManagerBuilder) AllowChunkedLength(enabled bool) *httpCon
 	return b
 }
 
+// LocalReplyConfig sets the configuration for customizing the
+// responses that Envoy generates itself. It may be nil.
+func (b *httpConnectionManagerBuilder) LocalReplyConfig(config *http.LocalReplyConfig) *httpConnectionManagerBuilder {
+	b.localReplyConfig = config
+	return b
+}
+
 func (b *httpConnectionManagerBuilder) DefaultFilters() *httpConnectionManagerBuilder {
 
 	// Add a default set of ordered http filters.
@@ -415,10 +442,18 @@ func (b *httpConnectionManagerBuilder) Get() *envoy_listener_v3.Filter {
 		cm.CommonHttpProtocolOptions.MaxConnectionDuration = protobuf.Duration(b.maxConnectionDuration.Duration())
 	}
 
+	if b.codec == HTTPVersion3 {
+		cm.Http3ProtocolOptions = &envoy_core_v3.Http3ProtocolOptions{}
+	}
+
 	if len(b.accessLoggers) > 0 {
 		cm.AccessLog = b.accessLoggers
 	}
 
+	if b.localReplyConfig != nil {
+		cm.LocalReplyConfig = b.localReplyConfig
+	}
+
 	// If there's no explicit metrics prefix, default it to the
 	// route config name.
 	if b.metricsPrefix != "" {
@@ -528,6 +563,21 @@ func TCPProxy(statPrefix string, proxy *dag.TCPProxy, accesslogger []*accesslog.
 	}
 }
 
+// ConnectionLimit creates a new connection limit filter that closes new
+// connections once max connections are active on the filter chain it
+// is added to.
+func ConnectionLimit(statPrefix string, max uint32) *envoy_listener_v3.Filter {
+	return &envoy_listener_v3.Filter{
+		Name: "envoy.filters.network.connection_limit",
+		ConfigType: &envoy_listener_v3.Filter_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(&envoy_connection_limit_v3.ConnectionLimit{
+				StatPrefix:     statPrefix,
+				MaxConnections: wrapperspb.UInt64(uint64(max)),
+			}),
+		},
+	}
+}
+
 // UnixSocketAddress creates a new Unix Socket envoy_core_v3.Address.
 func UnixSocketAddress(address string, port int) *envoy_core_v3.Address {
 	return &envoy_core_v3.Address{
@@ -542,11 +592,20 @@ func UnixSocketAddress(address string, port int) *envoy_core_v3.Address {
 
 // SocketAddress creates a new TCP envoy_core_v3.Address.
 func SocketAddress(address string, port int) *envoy_core_v3.Address {
+	return socketAddress(envoy_core_v3.SocketAddress_TCP, address, port)
+}
+
+// UDPSocketAddress creates a new UDP envoy_core_v3.Address.
+func UDPSocketAddress(address string, port int) *envoy_core_v3.Address {
+	return socketAddress(envoy_core_v3.SocketAddress_UDP, address, port)
+}
+
+func socketAddress(protocol envoy_core_v3.SocketAddress_Protocol, address string, port int) *envoy_core_v3.Address {
 	if address == "::" {
 		return &envoy_core_v3.Address{
 			Address: &envoy_core_v3.Address_SocketAddress{
 				SocketAddress: &envoy_core_v3.SocketAddress{
-					Protocol:   envoy_core_v3.SocketAddress_TCP,
+					Protocol:   protocol,
 					Address:    address,
 					Ipv4Compat: true,
 					PortSpecifier: &envoy_core_v3.SocketAddress_PortValue{
@@ -559,7 +618,7 @@ func SocketAddress(address string, port int) *envoy_core_v3.Address {
 	return &envoy_core_v3.Address{
 		Address: &envoy_core_v3.Address_SocketAddress{
 			SocketAddress: &envoy_core_v3.SocketAddress{
-				Protocol: envoy_core_v3.SocketAddress_TCP,
+				Protocol: protocol,
 				Address:  address,
 				PortSpecifier: &envoy_core_v3.SocketAddress_PortValue{
 					PortValue: uint32(port),
@@ -725,6 +784,28 @@ func FilterChainTLS(domain string, downstream *envoy_tls_v3.DownstreamTlsContext
 	return fc
 }
 
+// FilterChainQUIC returns a QUIC envoy_listener_v3.FilterChain.
+func FilterChainQUIC(domain string, downstream *envoy_tls_v3.DownstreamTlsContext, filters []*envoy_listener_v3.Filter) *envoy_listener_v3.FilterChain {
+	fc := &envoy_listener_v3.FilterChain{
+		Filters:         filters,
+		TransportSocket: DownstreamQUICTransportSocket(downstream),
+	}
+
+	// As for TLS, a wildcard domain can't be matched on SNI, so
+	// match any QUIC connection to this listener instead.
+	if domain == "*" {
+		fc.FilterChainMatch = &envoy_listener_v3.FilterChainMatch{
+			TransportProtocol: "quic",
+		}
+	} else {
+		fc.FilterChainMatch = &envoy_listener_v3.FilterChainMatch{
+			ServerNames: []string{domain},
+		}
+	}
+
+	return fc
+}
+
 // FilterChainTLSFallback returns a TLS enabled envoy_listener_v3.FilterChain conifgured for FallbackCertificate.
 func FilterChainTLSFallback(downstream *envoy_tls_v3.DownstreamTlsContext, filters []*envoy_listener_v3.Filter) *envoy_listener_v3.FilterChain {
 	fc := &envoy_listener_v3.FilterChain{
@@ -0,0 +1,129 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"regexp"
+	"strings"
+
+	envoy_accesslog_v3 "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"github.com/projectsesame/sesame/internal/dag"
+)
+
+// LocalReplyConfig returns the local reply configuration that
+// replaces the bodies of Envoy's local replies with the custom
+// error pages of the given virtual hosts, or nil if none of
+// them have any.
+//
+// Envoy applies local reply configuration to a whole HTTP
+// connection manager, so each error page is scoped to its
+// virtual host by matching the :authority header. Envoy uses
+// the first mapper that matches, so the error pages of exact
+// virtual hosts come before those of wildcard virtual hosts
+// that also match their names.
+func LocalReplyConfig(vhosts ...*dag.VirtualHost) *http.LocalReplyConfig {
+	var mappers, wildcardMappers []*http.ResponseMapper
+
+	for _, vh := range vhosts {
+		for _, reply := range vh.LocalReplies {
+			mapper := &http.ResponseMapper{
+				Filter: &envoy_accesslog_v3.AccessLogFilter{
+					FilterSpecifier: &envoy_accesslog_v3.AccessLogFilter_AndFilter{
+						AndFilter: &envoy_accesslog_v3.AndFilter{
+							Filters: []*envoy_accesslog_v3.AccessLogFilter{
+								statusCodeFilter(reply.StatusCode),
+								authorityFilter(vh.Name),
+							},
+						},
+					},
+				},
+				Body: &envoy_core_v3.DataSource{
+					Specifier: &envoy_core_v3.DataSource_InlineString{
+						InlineString: reply.Body,
+					},
+				},
+				// Use the body verbatim rather than as a
+				// format string, so that '%' in error pages
+				// isn't taken as a command operator.
+				BodyFormatOverride: &envoy_core_v3.SubstitutionFormatString{
+					Format: &envoy_core_v3.SubstitutionFormatString_TextFormatSource{
+						TextFormatSource: &envoy_core_v3.DataSource{
+							Specifier: &envoy_core_v3.DataSource_InlineString{
+								InlineString: "%LOCAL_REPLY_BODY%",
+							},
+						},
+					},
+					ContentType: reply.ContentType,
+				},
+			}
+
+			if strings.HasPrefix(vh.Name, "*.") {
+				wildcardMappers = append(wildcardMappers, mapper)
+			} else {
+				mappers = append(mappers, mapper)
+			}
+		}
+	}
+
+	mappers = append(mappers, wildcardMappers...)
+	if len(mappers) == 0 {
+		return nil
+	}
+
+	return &http.LocalReplyConfig{
+		Mappers: mappers,
+	}
+}
+
+func statusCodeFilter(code uint32) *envoy_accesslog_v3.AccessLogFilter {
+	return &envoy_accesslog_v3.AccessLogFilter{
+		FilterSpecifier: &envoy_accesslog_v3.AccessLogFilter_StatusCodeFilter{
+			StatusCodeFilter: &envoy_accesslog_v3.StatusCodeFilter{
+				Comparison: &envoy_accesslog_v3.ComparisonFilter{
+					Op: envoy_accesslog_v3.ComparisonFilter_EQ,
+					Value: &envoy_core_v3.RuntimeUInt32{
+						DefaultValue: code,
+						RuntimeKey:   "sesame.local_reply.status_code",
+					},
+				},
+			},
+		},
+	}
+}
+
+// authorityFilter matches requests for the given virtual host name,
+// which may be a wildcard, with or without a port. Host names are
+// case insensitive, so the match is too.
+func authorityFilter(name string) *envoy_accesslog_v3.AccessLogFilter {
+	host := regexp.QuoteMeta(name)
+	if strings.HasPrefix(name, "*.") {
+		host = "[a-z0-9]([-a-z0-9]*[a-z0-9])?" + regexp.QuoteMeta(name[1:])
+	}
+
+	return &envoy_accesslog_v3.AccessLogFilter{
+		FilterSpecifier: &envoy_accesslog_v3.AccessLogFilter_HeaderFilter{
+			HeaderFilter: &envoy_accesslog_v3.HeaderFilter{
+				Header: &envoy_route_v3.HeaderMatcher{
+					Name: ":authority",
+					HeaderMatchSpecifier: &envoy_route_v3.HeaderMatcher_SafeRegexMatch{
+						SafeRegexMatch: SafeRegexMatch("(?i)" + host + "(:[0-9]+)?"),
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"regexp"
+	"testing"
+
+	envoy_accesslog_v3 "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"github.com/projectsesame/sesame/internal/dag"
+	"github.com/projectsesame/sesame/internal/protobuf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalReplyConfig(t *testing.T) {
+	mapper := func(code uint32, authority, body, contentType string) *http.ResponseMapper {
+		return &http.ResponseMapper{
+			Filter: &envoy_accesslog_v3.AccessLogFilter{
+				FilterSpecifier: &envoy_accesslog_v3.AccessLogFilter_AndFilter{
+					AndFilter: &envoy_accesslog_v3.AndFilter{
+						Filters: []*envoy_accesslog_v3.AccessLogFilter{{
+							FilterSpecifier: &envoy_accesslog_v3.AccessLogFilter_StatusCodeFilter{
+								StatusCodeFilter: &envoy_accesslog_v3.StatusCodeFilter{
+									Comparison: &envoy_accesslog_v3.ComparisonFilter{
+										Op: envoy_accesslog_v3.ComparisonFilter_EQ,
+										Value: &envoy_core_v3.RuntimeUInt32{
+											DefaultValue: code,
+											RuntimeKey:   "sesame.local_reply.status_code",
+										},
+									},
+								},
+							},
+						}, {
+							FilterSpecifier: &envoy_accesslog_v3.AccessLogFilter_HeaderFilter{
+								HeaderFilter: &envoy_accesslog_v3.HeaderFilter{
+									Header: &envoy_route_v3.HeaderMatcher{
+										Name: ":authority",
+										HeaderMatchSpecifier: &envoy_route_v3.HeaderMatcher_SafeRegexMatch{
+											SafeRegexMatch: SafeRegexMatch(authority),
+										},
+									},
+								},
+							},
+						}},
+					},
+				},
+			},
+			Body: &envoy_core_v3.DataSource{
+				Specifier: &envoy_core_v3.DataSource_InlineString{
+					InlineString: body,
+				},
+			},
+			BodyFormatOverride: &envoy_core_v3.SubstitutionFormatString{
+				Format: &envoy_core_v3.SubstitutionFormatString_TextFormatSource{
+					TextFormatSource: &envoy_core_v3.DataSource{
+						Specifier: &envoy_core_v3.DataSource_InlineString{
+							InlineString: "%LOCAL_REPLY_BODY%",
+						},
+					},
+				},
+				ContentType: contentType,
+			},
+		}
+	}
+
+	tests := map[string]struct {
+		vhosts []*dag.VirtualHost
+		want   *http.LocalReplyConfig
+	}{
+		"no virtual hosts": {
+			want: nil,
+		},
+		"no local replies": {
+			vhosts: []*dag.VirtualHost{{Name: "www.example.com"}},
+			want:   nil,
+		},
+		"local replies of several virtual hosts": {
+			vhosts: []*dag.VirtualHost{{
+				Name: "www.example.com",
+				LocalReplies: []dag.LocalReply{
+					{StatusCode: 503, Body: "<h1>100% down</h1>", ContentType: "text/html"},
+					{StatusCode: 404, Body: "not here", ContentType: "text/plain"},
+				},
+			}, {
+				Name: "other.example.com",
+			}, {
+				Name: "*.wild.example.com",
+				LocalReplies: []dag.LocalReply{
+					{StatusCode: 503, Body: "{}", ContentType: "application/json"},
+				},
+			}},
+			want: &http.LocalReplyConfig{
+				Mappers: []*http.ResponseMapper{
+					mapper(503, `(?i)www\.example\.com(:[0-9]+)?`, "<h1>100% down</h1>", "text/html"),
+					mapper(404, `(?i)www\.example\.com(:[0-9]+)?`, "not here", "text/plain"),
+					mapper(503, `(?i)[a-z0-9]([-a-z0-9]*[a-z0-9])?\.wild\.example\.com(:[0-9]+)?`, "{}", "application/json"),
+				},
+			},
+		},
+		"exact virtual hosts before wildcards": {
+			vhosts: []*dag.VirtualHost{{
+				Name: "*.example.com",
+				LocalReplies: []dag.LocalReply{
+					{StatusCode: 503, Body: "wildcard", ContentType: "text/plain"},
+				},
+			}, {
+				Name: "foo.example.com",
+				LocalReplies: []dag.LocalReply{
+					{StatusCode: 503, Body: "foo", ContentType: "text/plain"},
+				},
+			}},
+			want: &http.LocalReplyConfig{
+				Mappers: []*http.ResponseMapper{
+					mapper(503, `(?i)foo\.example\.com(:[0-9]+)?`, "foo", "text/plain"),
+					mapper(503, `(?i)[a-z0-9]([-a-z0-9]*[a-z0-9])?\.example\.com(:[0-9]+)?`, "wildcard", "text/plain"),
+				},
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := LocalReplyConfig(tc.vhosts...)
+			if tc.want == nil {
+				assert.Nil(t, got)
+				return
+			}
+			protobuf.ExpectEqual(t, tc.want, got)
+			assert.NoError(t, got.Validate())
+		})
+	}
+}
+
+func TestAuthorityFilter(t *testing.T) {
+	tests := map[string]struct {
+		name      string
+		authority string
+		want      bool
+	}{
+		"exact":                 {name: "foo.example.com", authority: "foo.example.com", want: true},
+		"exact with port":       {name: "foo.example.com", authority: "foo.example.com:8443", want: true},
+		"exact mixed case":      {name: "foo.example.com", authority: "Foo.Example.COM", want: true},
+		"exact other host":      {name: "foo.example.com", authority: "bar.example.com", want: false},
+		"wildcard":              {name: "*.example.com", authority: "foo.example.com", want: true},
+		"wildcard mixed case":   {name: "*.example.com", authority: "FOO.example.com:80", want: true},
+		"wildcard bare domain":  {name: "*.example.com", authority: "example.com", want: false},
+		"wildcard nested label": {name: "*.example.com", authority: "a.b.example.com", want: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			regex := authorityFilter(tc.name).GetHeaderFilter().GetHeader().GetSafeRegexMatch().GetRegex()
+			// Envoy requires the regex to match the whole value.
+			assert.Equal(t, tc.want, regexp.MustCompile("^(?:"+regex+")$").MatchString(tc.authority))
+		})
+	}
+}
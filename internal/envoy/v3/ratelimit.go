@@ -14,12 +14,18 @@
 package v3
 
 import (
+	"math"
+	"regexp"
+	"time"
+
 	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	ratelimit_config_v3 "github.com/envoyproxy/go-control-plane/envoy/config/ratelimit/v3"
 	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
 	envoy_config_filter_http_local_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/local_ratelimit/v3"
 	ratelimit_filter_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ratelimit/v3"
 	http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_metadata_v3 "github.com/envoyproxy/go-control-plane/envoy/type/metadata/v3"
 	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"github.com/golang/protobuf/ptypes/any"
@@ -31,6 +37,17 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 )
 
+const (
+	// LocalRateLimitStage is the rate limit stage of the descriptors
+	// generated for the local rate limit filter. Global rate limit
+	// descriptors use the default stage 0.
+	LocalRateLimitStage = 1
+
+	// LocalRateLimitBucketKey is the descriptor key used for
+	// local rate limit buckets.
+	LocalRateLimitBucketKey = "local_rate_limit_bucket"
+)
+
 // LocalRateLimitConfig returns a config for the HTTP local rate
 // limit filter.
 func LocalRateLimitConfig(config *dag.LocalRateLimitPolicy, statPrefix string) *any.Any {
@@ -38,13 +55,25 @@ func LocalRateLimitConfig(config *dag.LocalRateLimitPolicy, statPrefix string) *
 		return nil
 	}
 
+	// Envoy requires a token bucket, so if requests are only
+	// limited by descriptors or buckets, use one that never
+	// runs out.
+	tokenBucket := &envoy_type_v3.TokenBucket{
+		MaxTokens:     config.MaxTokens,
+		TokensPerFill: protobuf.UInt32(config.TokensPerFill),
+		FillInterval:  protobuf.Duration(config.FillInterval),
+	}
+	if config.MaxTokens == 0 {
+		tokenBucket = &envoy_type_v3.TokenBucket{
+			MaxTokens:     math.MaxUint32,
+			TokensPerFill: protobuf.UInt32(math.MaxUint32),
+			FillInterval:  protobuf.Duration(time.Second),
+		}
+	}
+
 	c := &envoy_config_filter_http_local_ratelimit_v3.LocalRateLimit{
-		StatPrefix: statPrefix,
-		TokenBucket: &envoy_type_v3.TokenBucket{
-			MaxTokens:     config.MaxTokens,
-			TokensPerFill: protobuf.UInt32(config.TokensPerFill),
-			FillInterval:  protobuf.Duration(config.FillInterval),
-		},
+		StatPrefix:           statPrefix,
+		TokenBucket:          tokenBucket,
 		ResponseHeadersToAdd: headerValueList(config.ResponseHeadersToAdd, false),
 		FilterEnabled: &envoy_core_v3.RuntimeFractionalPercent{
 			DefaultValue: &envoy_type_v3.FractionalPercent{
@@ -60,11 +89,49 @@ func LocalRateLimitConfig(config *dag.LocalRateLimitPolicy, statPrefix string) *
 		},
 	}
 
+	// Each downstream connection gets its own copy of the token
+	// buckets, including those of the descriptors.
+	c.LocalRateLimitPerDownstreamConnection = config.PerConnection
+
 	// Envoy defaults to 429 (Too Many Requests) if this is not specified.
 	if config.ResponseStatusCode > 0 {
 		c.Status = &envoy_type_v3.HttpStatus{Code: envoy_type_v3.StatusCode(config.ResponseStatusCode)}
 	}
 
+	for _, d := range config.Descriptors {
+		var entries []*envoy_ratelimit_v3.RateLimitDescriptor_Entry
+		for _, e := range d.Entries {
+			entries = append(entries, &envoy_ratelimit_v3.RateLimitDescriptor_Entry{Key: e.Key, Value: e.Value})
+		}
+
+		c.Descriptors = append(c.Descriptors, &envoy_ratelimit_v3.LocalRateLimitDescriptor{
+			Entries: entries,
+			TokenBucket: &envoy_type_v3.TokenBucket{
+				MaxTokens:     d.MaxTokens,
+				TokensPerFill: protobuf.UInt32(d.TokensPerFill),
+				FillInterval:  protobuf.Duration(d.FillInterval),
+			},
+		})
+	}
+
+	for _, b := range config.Buckets {
+		c.Descriptors = append(c.Descriptors, &envoy_ratelimit_v3.LocalRateLimitDescriptor{
+			Entries: []*envoy_ratelimit_v3.RateLimitDescriptor_Entry{{
+				Key:   LocalRateLimitBucketKey,
+				Value: b.Name,
+			}},
+			TokenBucket: &envoy_type_v3.TokenBucket{
+				MaxTokens:     b.MaxTokens,
+				TokensPerFill: protobuf.UInt32(b.TokensPerFill),
+				FillInterval:  protobuf.Duration(b.FillInterval),
+			},
+		})
+	}
+
+	if len(c.Descriptors) > 0 {
+		c.Stage = LocalRateLimitStage
+	}
+
 	return protobuf.MustMarshalAny(c)
 }
 
@@ -72,51 +139,157 @@ func LocalRateLimitConfig(config *dag.LocalRateLimitPolicy, statPrefix string) *
 func GlobalRateLimits(descriptors []*dag.RateLimitDescriptor) []*envoy_route_v3.RateLimit {
 	var rateLimits []*envoy_route_v3.RateLimit
 	for _, descriptor := range descriptors {
-		var rl envoy_route_v3.RateLimit
-
-		for _, entry := range descriptor.Entries {
-			switch {
-			case entry.GenericKey != nil:
-				rl.Actions = append(rl.Actions, &envoy_route_v3.RateLimit_Action{
-					ActionSpecifier: &envoy_route_v3.RateLimit_Action_GenericKey_{
-						GenericKey: &envoy_route_v3.RateLimit_Action_GenericKey{
-							DescriptorKey:   entry.GenericKey.Key,
-							DescriptorValue: entry.GenericKey.Value,
-						},
+		rateLimits = append(rateLimits, &envoy_route_v3.RateLimit{
+			Actions: rateLimitActions(descriptor.Entries),
+		})
+	}
+
+	return rateLimits
+}
+
+// LocalRateLimits returns the Envoy RateLimits that generate the
+// descriptors for the local rate limit filter. These use their own
+// stage so that they are not sent to the global rate limit service.
+func LocalRateLimits(policy *dag.RateLimitPolicy) []*envoy_route_v3.RateLimit {
+	if policy == nil {
+		return nil
+	}
+
+	var rateLimits []*envoy_route_v3.RateLimit
+
+	if policy.Local != nil {
+		for _, descriptor := range policy.Local.Descriptors {
+			var entries []dag.RateLimitDescriptorEntry
+			for _, entry := range descriptor.Entries {
+				entries = append(entries, entry.Action)
+			}
+
+			rateLimits = append(rateLimits, &envoy_route_v3.RateLimit{
+				Stage:   protobuf.UInt32(LocalRateLimitStage),
+				Actions: rateLimitActions(entries),
+			})
+		}
+	}
+
+	if policy.LocalBucket != "" {
+		rateLimits = append(rateLimits, &envoy_route_v3.RateLimit{
+			Stage: protobuf.UInt32(LocalRateLimitStage),
+			Actions: rateLimitActions([]dag.RateLimitDescriptorEntry{{
+				GenericKey: &dag.GenericKeyDescriptorEntry{
+					Key:   LocalRateLimitBucketKey,
+					Value: policy.LocalBucket,
+				},
+			}}),
+		})
+	}
+
+	return rateLimits
+}
+
+// rateLimitActions converts DAG RateLimitDescriptorEntries to the
+// Envoy RateLimit actions that generate them.
+func rateLimitActions(entries []dag.RateLimitDescriptorEntry) []*envoy_route_v3.RateLimit_Action {
+	var actions []*envoy_route_v3.RateLimit_Action
+
+	for _, entry := range entries {
+		switch {
+		case entry.GenericKey != nil:
+			actions = append(actions, &envoy_route_v3.RateLimit_Action{
+				ActionSpecifier: &envoy_route_v3.RateLimit_Action_GenericKey_{
+					GenericKey: &envoy_route_v3.RateLimit_Action_GenericKey{
+						DescriptorKey:   entry.GenericKey.Key,
+						DescriptorValue: entry.GenericKey.Value,
 					},
-				})
-			case entry.HeaderMatch != nil:
-				rl.Actions = append(rl.Actions, &envoy_route_v3.RateLimit_Action{
-					ActionSpecifier: &envoy_route_v3.RateLimit_Action_RequestHeaders_{
-						RequestHeaders: &envoy_route_v3.RateLimit_Action_RequestHeaders{
-							HeaderName:    entry.HeaderMatch.HeaderName,
-							DescriptorKey: entry.HeaderMatch.Key,
-						},
+				},
+			})
+		case entry.HeaderMatch != nil:
+			actions = append(actions, &envoy_route_v3.RateLimit_Action{
+				ActionSpecifier: &envoy_route_v3.RateLimit_Action_RequestHeaders_{
+					RequestHeaders: &envoy_route_v3.RateLimit_Action_RequestHeaders{
+						HeaderName:    entry.HeaderMatch.HeaderName,
+						DescriptorKey: entry.HeaderMatch.Key,
 					},
-				})
-			case entry.HeaderValueMatch != nil:
-				rl.Actions = append(rl.Actions, &envoy_route_v3.RateLimit_Action{
-					ActionSpecifier: &envoy_route_v3.RateLimit_Action_HeaderValueMatch_{
-						HeaderValueMatch: &envoy_route_v3.RateLimit_Action_HeaderValueMatch{
-							DescriptorValue: entry.HeaderValueMatch.Value,
-							ExpectMatch:     wrapperspb.Bool(entry.HeaderValueMatch.ExpectMatch),
-							Headers:         headerMatcher(entry.HeaderValueMatch.Headers),
-						},
+				},
+			})
+		case entry.HeaderValueMatch != nil:
+			actions = append(actions, &envoy_route_v3.RateLimit_Action{
+				ActionSpecifier: &envoy_route_v3.RateLimit_Action_HeaderValueMatch_{
+					HeaderValueMatch: &envoy_route_v3.RateLimit_Action_HeaderValueMatch{
+						DescriptorValue: entry.HeaderValueMatch.Value,
+						ExpectMatch:     wrapperspb.Bool(entry.HeaderValueMatch.ExpectMatch),
+						Headers:         headerMatcher(entry.HeaderValueMatch.Headers),
 					},
-				})
-			case entry.RemoteAddress != nil:
-				rl.Actions = append(rl.Actions, &envoy_route_v3.RateLimit_Action{
-					ActionSpecifier: &envoy_route_v3.RateLimit_Action_RemoteAddress_{
-						RemoteAddress: &envoy_route_v3.RateLimit_Action_RemoteAddress{},
+				},
+			})
+		case entry.RemoteAddress != nil:
+			actions = append(actions, &envoy_route_v3.RateLimit_Action{
+				ActionSpecifier: &envoy_route_v3.RateLimit_Action_RemoteAddress_{
+					RemoteAddress: &envoy_route_v3.RateLimit_Action_RemoteAddress{},
+				},
+			})
+		case entry.QueryParameterMatch != nil:
+			// Envoy has no query parameter action, so match the
+			// parameter against the request path instead.
+			actions = append(actions, &envoy_route_v3.RateLimit_Action{
+				ActionSpecifier: &envoy_route_v3.RateLimit_Action_HeaderValueMatch_{
+					HeaderValueMatch: &envoy_route_v3.RateLimit_Action_HeaderValueMatch{
+						DescriptorValue: entry.QueryParameterMatch.Value,
+						ExpectMatch:     wrapperspb.Bool(entry.QueryParameterMatch.ExpectMatch),
+						Headers: []*envoy_route_v3.HeaderMatcher{{
+							Name: ":path",
+							HeaderMatchSpecifier: &envoy_route_v3.HeaderMatcher_SafeRegexMatch{
+								SafeRegexMatch: SafeRegexMatch(queryParameterRegex(entry.QueryParameterMatch)),
+							},
+						}},
 					},
+				},
+			})
+		case entry.Metadata != nil:
+			var path []*envoy_metadata_v3.MetadataKey_PathSegment
+			for _, key := range entry.Metadata.Path {
+				path = append(path, &envoy_metadata_v3.MetadataKey_PathSegment{
+					Segment: &envoy_metadata_v3.MetadataKey_PathSegment_Key{Key: key},
 				})
 			}
+
+			actions = append(actions, &envoy_route_v3.RateLimit_Action{
+				ActionSpecifier: &envoy_route_v3.RateLimit_Action_Metadata{
+					Metadata: &envoy_route_v3.RateLimit_Action_MetaData{
+						DescriptorKey: entry.Metadata.Key,
+						MetadataKey: &envoy_metadata_v3.MetadataKey{
+							Key:  entry.Metadata.FilterNamespace,
+							Path: path,
+						},
+						DefaultValue: entry.Metadata.DefaultValue,
+						Source:       envoy_route_v3.RateLimit_Action_MetaData_DYNAMIC,
+					},
+				},
+			})
+		case entry.MaskedRemoteAddress != nil:
+			actions = append(actions, &envoy_route_v3.RateLimit_Action{
+				ActionSpecifier: &envoy_route_v3.RateLimit_Action_MaskedRemoteAddress_{
+					MaskedRemoteAddress: &envoy_route_v3.RateLimit_Action_MaskedRemoteAddress{
+						V4PrefixMaskLen: protobuf.UInt32(entry.MaskedRemoteAddress.V4PrefixLength),
+						V6PrefixMaskLen: protobuf.UInt32(entry.MaskedRemoteAddress.V6PrefixLength),
+					},
+				},
+			})
 		}
+	}
+
+	return actions
+}
 
-		rateLimits = append(rateLimits, &rl)
+// queryParameterRegex returns a regex that matches a request path
+// containing the given query parameter, with the exact value if one
+// is set.
+func queryParameterRegex(match *dag.QueryParameterMatchDescriptorEntry) string {
+	value := "(=[^&]*)?"
+	if match.Exact != "" {
+		value = "=" + regexp.QuoteMeta(match.Exact)
 	}
 
-	return rateLimits
+	return `^[^?]*\?(.*&)?` + regexp.QuoteMeta(match.Name) + value + `(&.*)?$`
 }
 
 // GlobalRateLimitConfig stores configuration for
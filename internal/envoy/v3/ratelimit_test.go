@@ -20,9 +20,11 @@ import (
 	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	ratelimit_config_v3 "github.com/envoyproxy/go-control-plane/envoy/config/ratelimit/v3"
 	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
 	envoy_config_filter_http_local_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/local_ratelimit/v3"
 	ratelimit_filter_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ratelimit/v3"
 	http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_metadata_v3 "github.com/envoyproxy/go-control-plane/envoy/type/metadata/v3"
 	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"github.com/projectsesame/sesame/internal/dag"
@@ -83,6 +85,98 @@ func TestLocalRateLimitConfig(t *testing.T) {
 					},
 				}),
 		},
+		"per-connection config": {
+			policy: &dag.LocalRateLimitPolicy{
+				MaxTokens:     10,
+				TokensPerFill: 10,
+				FillInterval:  time.Second,
+				PerConnection: true,
+			},
+			statPrefix: "stat-prefix",
+			want: protobuf.MustMarshalAny(
+				&envoy_config_filter_http_local_ratelimit_v3.LocalRateLimit{
+					StatPrefix: "stat-prefix",
+					TokenBucket: &envoy_type_v3.TokenBucket{
+						MaxTokens:     10,
+						TokensPerFill: protobuf.UInt32(10),
+						FillInterval:  protobuf.Duration(time.Second),
+					},
+					FilterEnabled: &envoy_core_v3.RuntimeFractionalPercent{
+						DefaultValue: &envoy_type_v3.FractionalPercent{
+							Numerator:   100,
+							Denominator: envoy_type_v3.FractionalPercent_HUNDRED,
+						},
+					},
+					FilterEnforced: &envoy_core_v3.RuntimeFractionalPercent{
+						DefaultValue: &envoy_type_v3.FractionalPercent{
+							Numerator:   100,
+							Denominator: envoy_type_v3.FractionalPercent_HUNDRED,
+						},
+					},
+					LocalRateLimitPerDownstreamConnection: true,
+				}),
+		},
+		"descriptors and buckets without a default limit": {
+			policy: &dag.LocalRateLimitPolicy{
+				Descriptors: []*dag.LocalRateLimitDescriptor{{
+					Entries: []dag.LocalRateLimitDescriptorEntry{{
+						Action: dag.RateLimitDescriptorEntry{RemoteAddress: &dag.RemoteAddressDescriptorEntry{}},
+						Key:    "remote_address",
+						Value:  "10.0.0.1",
+					}},
+					MaxTokens:     10,
+					TokensPerFill: 5,
+					FillInterval:  time.Minute,
+				}},
+				Buckets: []*dag.LocalRateLimitBucket{{
+					Name:          "shared",
+					MaxTokens:     100,
+					TokensPerFill: 100,
+					FillInterval:  time.Hour,
+				}},
+			},
+			statPrefix: "stat-prefix",
+			want: protobuf.MustMarshalAny(
+				&envoy_config_filter_http_local_ratelimit_v3.LocalRateLimit{
+					StatPrefix: "stat-prefix",
+					TokenBucket: &envoy_type_v3.TokenBucket{
+						MaxTokens:     4294967295,
+						TokensPerFill: protobuf.UInt32(4294967295),
+						FillInterval:  protobuf.Duration(time.Second),
+					},
+					FilterEnabled: &envoy_core_v3.RuntimeFractionalPercent{
+						DefaultValue: &envoy_type_v3.FractionalPercent{
+							Numerator:   100,
+							Denominator: envoy_type_v3.FractionalPercent_HUNDRED,
+						},
+					},
+					FilterEnforced: &envoy_core_v3.RuntimeFractionalPercent{
+						DefaultValue: &envoy_type_v3.FractionalPercent{
+							Numerator:   100,
+							Denominator: envoy_type_v3.FractionalPercent_HUNDRED,
+						},
+					},
+					Descriptors: []*envoy_ratelimit_v3.LocalRateLimitDescriptor{
+						{
+							Entries: []*envoy_ratelimit_v3.RateLimitDescriptor_Entry{{Key: "remote_address", Value: "10.0.0.1"}},
+							TokenBucket: &envoy_type_v3.TokenBucket{
+								MaxTokens:     10,
+								TokensPerFill: protobuf.UInt32(5),
+								FillInterval:  protobuf.Duration(time.Minute),
+							},
+						},
+						{
+							Entries: []*envoy_ratelimit_v3.RateLimitDescriptor_Entry{{Key: "local_rate_limit_bucket", Value: "shared"}},
+							TokenBucket: &envoy_type_v3.TokenBucket{
+								MaxTokens:     100,
+								TokensPerFill: protobuf.UInt32(100),
+								FillInterval:  protobuf.Duration(time.Hour),
+							},
+						},
+					},
+					Stage: 1,
+				}),
+		},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -92,6 +186,84 @@ func TestLocalRateLimitConfig(t *testing.T) {
 	}
 }
 
+func TestLocalRateLimits(t *testing.T) {
+	tests := map[string]struct {
+		policy *dag.RateLimitPolicy
+		want   []*envoy_route_v3.RateLimit
+	}{
+		"nil policy": {
+			policy: nil,
+			want:   nil,
+		},
+		"no descriptors": {
+			policy: &dag.RateLimitPolicy{
+				Local: &dag.LocalRateLimitPolicy{MaxTokens: 10, TokensPerFill: 10, FillInterval: time.Second},
+			},
+			want: nil,
+		},
+		"descriptor": {
+			policy: &dag.RateLimitPolicy{
+				Local: &dag.LocalRateLimitPolicy{
+					Descriptors: []*dag.LocalRateLimitDescriptor{{
+						Entries: []dag.LocalRateLimitDescriptorEntry{
+							{
+								Action: dag.RateLimitDescriptorEntry{
+									HeaderMatch: &dag.HeaderMatchDescriptorEntry{HeaderName: "X-Tenant", Key: "X-Tenant"},
+								},
+								Key:   "X-Tenant",
+								Value: "a",
+							},
+							{
+								Action: dag.RateLimitDescriptorEntry{RemoteAddress: &dag.RemoteAddressDescriptorEntry{}},
+								Key:    "remote_address",
+								Value:  "10.0.0.1",
+							},
+						},
+					}},
+				},
+			},
+			want: []*envoy_route_v3.RateLimit{{
+				Stage: protobuf.UInt32(1),
+				Actions: []*envoy_route_v3.RateLimit_Action{
+					{
+						ActionSpecifier: &envoy_route_v3.RateLimit_Action_RequestHeaders_{
+							RequestHeaders: &envoy_route_v3.RateLimit_Action_RequestHeaders{
+								HeaderName:    "X-Tenant",
+								DescriptorKey: "X-Tenant",
+							},
+						},
+					},
+					{
+						ActionSpecifier: &envoy_route_v3.RateLimit_Action_RemoteAddress_{
+							RemoteAddress: &envoy_route_v3.RateLimit_Action_RemoteAddress{},
+						},
+					},
+				},
+			}},
+		},
+		"bucket": {
+			policy: &dag.RateLimitPolicy{LocalBucket: "shared"},
+			want: []*envoy_route_v3.RateLimit{{
+				Stage: protobuf.UInt32(1),
+				Actions: []*envoy_route_v3.RateLimit_Action{{
+					ActionSpecifier: &envoy_route_v3.RateLimit_Action_GenericKey_{
+						GenericKey: &envoy_route_v3.RateLimit_Action_GenericKey{
+							DescriptorKey:   "local_rate_limit_bucket",
+							DescriptorValue: "shared",
+						},
+					},
+				}},
+			}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, LocalRateLimits(tc.policy))
+		})
+	}
+}
+
 func TestGlobalRateLimits(t *testing.T) {
 	tests := map[string]struct {
 		descriptors []*dag.RateLimitDescriptor
@@ -228,6 +400,105 @@ func TestGlobalRateLimits(t *testing.T) {
 				},
 			},
 		},
+		"query parameter, metadata and masked remote address descriptors": {
+			descriptors: []*dag.RateLimitDescriptor{
+				{
+					Entries: []dag.RateLimitDescriptorEntry{
+						{
+							QueryParameterMatch: &dag.QueryParameterMatchDescriptorEntry{
+								Name:        "api.key",
+								ExpectMatch: true,
+								Value:       "has-api-key",
+							},
+						},
+						{
+							QueryParameterMatch: &dag.QueryParameterMatchDescriptorEntry{
+								Name:        "tier",
+								Exact:       "free",
+								ExpectMatch: false,
+								Value:       "not-free",
+							},
+						},
+						{
+							Metadata: &dag.MetadataDescriptorEntry{
+								Key:             "sub",
+								FilterNamespace: "envoy.filters.http.jwt_authn",
+								Path:            []string{"payload", "sub"},
+								DefaultValue:    "anonymous",
+							},
+						},
+						{
+							MaskedRemoteAddress: &dag.MaskedRemoteAddressDescriptorEntry{
+								V4PrefixLength: 24,
+								V6PrefixLength: 64,
+							},
+						},
+					},
+				},
+			},
+			want: []*envoy_route_v3.RateLimit{
+				{
+					Actions: []*envoy_route_v3.RateLimit_Action{
+						{
+							ActionSpecifier: &envoy_route_v3.RateLimit_Action_HeaderValueMatch_{
+								HeaderValueMatch: &envoy_route_v3.RateLimit_Action_HeaderValueMatch{
+									Headers: []*envoy_route_v3.HeaderMatcher{
+										{
+											Name: ":path",
+											HeaderMatchSpecifier: &envoy_route_v3.HeaderMatcher_SafeRegexMatch{
+												SafeRegexMatch: SafeRegexMatch(`^[^?]*\?(.*&)?api\.key(=[^&]*)?(&.*)?$`),
+											},
+										},
+									},
+									ExpectMatch:     wrapperspb.Bool(true),
+									DescriptorValue: "has-api-key",
+								},
+							},
+						},
+						{
+							ActionSpecifier: &envoy_route_v3.RateLimit_Action_HeaderValueMatch_{
+								HeaderValueMatch: &envoy_route_v3.RateLimit_Action_HeaderValueMatch{
+									Headers: []*envoy_route_v3.HeaderMatcher{
+										{
+											Name: ":path",
+											HeaderMatchSpecifier: &envoy_route_v3.HeaderMatcher_SafeRegexMatch{
+												SafeRegexMatch: SafeRegexMatch(`^[^?]*\?(.*&)?tier=free(&.*)?$`),
+											},
+										},
+									},
+									ExpectMatch:     wrapperspb.Bool(false),
+									DescriptorValue: "not-free",
+								},
+							},
+						},
+						{
+							ActionSpecifier: &envoy_route_v3.RateLimit_Action_Metadata{
+								Metadata: &envoy_route_v3.RateLimit_Action_MetaData{
+									DescriptorKey: "sub",
+									MetadataKey: &envoy_metadata_v3.MetadataKey{
+										Key: "envoy.filters.http.jwt_authn",
+										Path: []*envoy_metadata_v3.MetadataKey_PathSegment{
+											{Segment: &envoy_metadata_v3.MetadataKey_PathSegment_Key{Key: "payload"}},
+											{Segment: &envoy_metadata_v3.MetadataKey_PathSegment_Key{Key: "sub"}},
+										},
+									},
+									DefaultValue: "anonymous",
+									Source:       envoy_route_v3.RateLimit_Action_MetaData_DYNAMIC,
+								},
+							},
+						},
+						{
+							ActionSpecifier: &envoy_route_v3.RateLimit_Action_MaskedRemoteAddress_{
+								MaskedRemoteAddress: &envoy_route_v3.RateLimit_Action_MaskedRemoteAddress{
+									V4PrefixMaskLen: wrapperspb.UInt32(24),
+									V6PrefixMaskLen: wrapperspb.UInt32(64),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
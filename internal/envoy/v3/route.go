@@ -25,6 +25,9 @@ import (
 	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	envoy_config_filter_http_ext_authz_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_authz/v3"
 	lua "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/lua/v3"
+	envoy_retry_omit_canary_hosts_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/host/omit_canary_hosts/v3"
+	envoy_retry_previous_hosts_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/host/previous_hosts/v3"
+	envoy_retry_previous_priorities_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/priority/previous_priorities/v3"
 	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	"github.com/golang/protobuf/ptypes/any"
 	wrappers "github.com/golang/protobuf/ptypes/wrappers"
@@ -56,6 +59,7 @@ func VirtualHostAndRoutes(vh *dag.VirtualHost, dagRoutes []*dag.Route, secure bo
 	if vh.RateLimitPolicy != nil && vh.RateLimitPolicy.Global != nil {
 		evh.RateLimits = GlobalRateLimits(vh.RateLimitPolicy.Global.Descriptors)
 	}
+	evh.RateLimits = append(evh.RateLimits, LocalRateLimits(vh.RateLimitPolicy)...)
 
 	return evh
 }
@@ -73,10 +77,19 @@ func buildRoute(dagRoute *dag.Route, vhostName string, secure bool, authService
 			Action: UpgradeHTTPS(),
 		}
 	case dagRoute.DirectResponse != nil:
-		return &envoy_route_v3.Route{
+		rt := &envoy_route_v3.Route{
 			Match:  RouteMatch(dagRoute),
 			Action: routeDirectResponse(dagRoute.DirectResponse),
 		}
+
+		// Response headers let direct responses set
+		// a Content-Type other than "text/plain".
+		if dagRoute.ResponseHeadersPolicy != nil {
+			rt.ResponseHeadersToAdd = headersToAdd(dagRoute.ResponseHeadersPolicy)
+			rt.ResponseHeadersToRemove = dagRoute.ResponseHeadersPolicy.Remove
+		}
+
+		return rt
 	case dagRoute.Redirect != nil:
 		// TODO request/response headers?
 		return &envoy_route_v3.Route{
@@ -90,11 +103,11 @@ func buildRoute(dagRoute *dag.Route, vhostName string, secure bool, authService
 		}
 
 		if dagRoute.RequestHeadersPolicy != nil {
-			rt.RequestHeadersToAdd = append(headerValueList(dagRoute.RequestHeadersPolicy.Set, false), headerValueList(dagRoute.RequestHeadersPolicy.Add, true)...)
+			rt.RequestHeadersToAdd = headersToAdd(dagRoute.RequestHeadersPolicy)
 			rt.RequestHeadersToRemove = dagRoute.RequestHeadersPolicy.Remove
 		}
 		if dagRoute.ResponseHeadersPolicy != nil {
-			rt.ResponseHeadersToAdd = headerValueList(dagRoute.ResponseHeadersPolicy.Set, false)
+			rt.ResponseHeadersToAdd = headersToAdd(dagRoute.ResponseHeadersPolicy)
 			rt.ResponseHeadersToRemove = dagRoute.ResponseHeadersPolicy.Remove
 		}
 		if dagRoute.RateLimitPolicy != nil && dagRoute.RateLimitPolicy.Local != nil {
@@ -204,11 +217,21 @@ func RouteMatch(route *dag.Route) *envoy_route_v3.RouteMatch {
 // http status code supplied. This allows a direct response to a route request
 // with an HTTP status code without needing to route to a specific cluster.
 func routeDirectResponse(response *dag.DirectResponse) *envoy_route_v3.Route_DirectResponse {
-	return &envoy_route_v3.Route_DirectResponse{
+	r := &envoy_route_v3.Route_DirectResponse{
 		DirectResponse: &envoy_route_v3.DirectResponseAction{
 			Status: response.StatusCode,
 		},
 	}
+
+	if response.Body != "" {
+		r.DirectResponse.Body = &envoy_core_v3.DataSource{
+			Specifier: &envoy_core_v3.DataSource_InlineString{
+				InlineString: response.Body,
+			},
+		}
+	}
+
+	return r
 }
 
 // routeRedirect creates a *envoy_route_v3.Route_Redirect for the
@@ -257,9 +280,14 @@ func routeRoute(r *dag.Route) *envoy_route_v3.Route_Route {
 		RequestMirrorPolicies: mirrorPolicy(r),
 	}
 
+	if r.HedgePolicy != nil {
+		ra.HedgePolicy = hedgePolicy(r.HedgePolicy)
+	}
+
 	if r.RateLimitPolicy != nil && r.RateLimitPolicy.Global != nil {
 		ra.RateLimits = GlobalRateLimits(r.RateLimitPolicy.Global.Descriptors)
 	}
+	ra.RateLimits = append(ra.RateLimits, LocalRateLimits(r.RateLimitPolicy)...)
 
 	// Check for host header policy and set if found
 	if val := envoy.HostReplaceHeader(r.RequestHeadersPolicy); val != "" {
@@ -340,6 +368,13 @@ func mirrorPolicy(r *dag.Route) []*envoy_route_v3.RouteAction_RequestMirrorPolic
 	}}
 }
 
+// hedgePolicy returns an Envoy hedge policy for the supplied HedgePolicy.
+func hedgePolicy(hp *dag.HedgePolicy) *envoy_route_v3.HedgePolicy {
+	return &envoy_route_v3.HedgePolicy{
+		HedgeOnPerTryTimeout: hp.HedgeOnPerTryTimeout,
+	}
+}
+
 func retryPolicy(r *dag.Route) *envoy_route_v3.RetryPolicy {
 	if r.RetryPolicy == nil {
 		return nil
@@ -356,6 +391,47 @@ func retryPolicy(r *dag.Route) *envoy_route_v3.RetryPolicy {
 		rp.NumRetries = protobuf.UInt32(r.RetryPolicy.NumRetries)
 	}
 	rp.PerTryTimeout = envoy.Timeout(r.RetryPolicy.PerTryTimeout)
+	rp.PerTryIdleTimeout = envoy.Timeout(r.RetryPolicy.PerTryIdleTimeout)
+
+	for _, predicate := range r.RetryPolicy.RetryHostPredicates {
+		switch predicate {
+		case "previous-hosts":
+			rp.RetryHostPredicate = append(rp.RetryHostPredicate, &envoy_route_v3.RetryPolicy_RetryHostPredicate{
+				Name: "envoy.retry_host_predicates.previous_hosts",
+				ConfigType: &envoy_route_v3.RetryPolicy_RetryHostPredicate_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&envoy_retry_previous_hosts_v3.PreviousHostsPredicate{}),
+				},
+			})
+		case "omit-canary-hosts":
+			rp.RetryHostPredicate = append(rp.RetryHostPredicate, &envoy_route_v3.RetryPolicy_RetryHostPredicate{
+				Name: "envoy.retry_host_predicates.omit_canary_hosts",
+				ConfigType: &envoy_route_v3.RetryPolicy_RetryHostPredicate_TypedConfig{
+					TypedConfig: protobuf.MustMarshalAny(&envoy_retry_omit_canary_hosts_v3.OmitCanaryHostsPredicate{}),
+				},
+			})
+		}
+	}
+	rp.HostSelectionRetryMaxAttempts = r.RetryPolicy.HostSelectionRetryMaxAttempts
+
+	if r.RetryPolicy.PriorityUpdateFrequency > 0 {
+		rp.RetryPriority = &envoy_route_v3.RetryPolicy_RetryPriority{
+			Name: "envoy.retry_priorities.previous_priorities",
+			ConfigType: &envoy_route_v3.RetryPolicy_RetryPriority_TypedConfig{
+				TypedConfig: protobuf.MustMarshalAny(&envoy_retry_previous_priorities_v3.PreviousPrioritiesConfig{
+					UpdateFrequency: int32(r.RetryPolicy.PriorityUpdateFrequency),
+				}),
+			},
+		}
+	}
+
+	if r.RetryPolicy.BackOffBaseInterval > 0 {
+		rp.RetryBackOff = &envoy_route_v3.RetryPolicy_RetryBackOff{
+			BaseInterval: protobuf.Duration(r.RetryPolicy.BackOffBaseInterval),
+		}
+		if r.RetryPolicy.BackOffMaxInterval > 0 {
+			rp.RetryBackOff.MaxInterval = protobuf.Duration(r.RetryPolicy.BackOffMaxInterval)
+		}
+	}
 
 	return rp
 }
@@ -394,6 +470,21 @@ func headerValueList(hvm map[string]string, app bool) []*envoy_core_v3.HeaderVal
 	return hvs
 }
 
+// headersToAdd creates a list of Envoy HeaderValueOptions for the headers
+// that the provided policy sets, appends to, and adds if absent.
+func headersToAdd(hp *dag.HeadersPolicy) []*envoy_core_v3.HeaderValueOption {
+	hvs := append(headerValueList(hp.Set, false), headerValueList(hp.Add, true)...)
+
+	for _, hv := range headerValueList(hp.AddIfAbsent, false) {
+		// Envoy ignores the append action if append is set.
+		hv.Append = nil
+		hv.AppendAction = envoy_core_v3.HeaderValueOption_ADD_IF_ABSENT
+		hvs = append(hvs, hv)
+	}
+
+	return hvs
+}
+
 // weightedClusters returns a route.WeightedCluster for multiple services.
 func weightedClusters(route *dag.Route) *envoy_route_v3.WeightedCluster {
 	var wc envoy_route_v3.WeightedCluster
@@ -406,11 +497,11 @@ func weightedClusters(route *dag.Route) *envoy_route_v3.WeightedCluster {
 			Weight: protobuf.UInt32(cluster.Weight),
 		}
 		if cluster.RequestHeadersPolicy != nil {
-			c.RequestHeadersToAdd = append(headerValueList(cluster.RequestHeadersPolicy.Set, false), headerValueList(cluster.RequestHeadersPolicy.Add, true)...)
+			c.RequestHeadersToAdd = headersToAdd(cluster.RequestHeadersPolicy)
 			c.RequestHeadersToRemove = cluster.RequestHeadersPolicy.Remove
 		}
 		if cluster.ResponseHeadersPolicy != nil {
-			c.ResponseHeadersToAdd = headerValueList(cluster.ResponseHeadersPolicy.Set, false)
+			c.ResponseHeadersToAdd = headersToAdd(cluster.ResponseHeadersPolicy)
 			c.ResponseHeadersToRemove = cluster.ResponseHeadersPolicy.Remove
 		}
 		if len(route.CookieRewritePolicies) > 0 || len(cluster.CookieRewritePolicies) > 0 {
@@ -461,6 +552,12 @@ func RouteConfiguration(name string, virtualhosts ...*envoy_route_v3.VirtualHost
 	}
 }
 
+// AltSvcHeaders returns the header options that set the alt-svc
+// response header to value, advertising alternative protocols.
+func AltSvcHeaders(value string) []*envoy_core_v3.HeaderValueOption {
+	return headerValueList(map[string]string{"alt-svc": value}, false)
+}
+
 // corsPolicy returns a *envoy_route_v3.CorsPolicy
 func corsPolicy(cp *dag.CORSPolicy) *envoy_route_v3.CorsPolicy {
 	if cp == nil {
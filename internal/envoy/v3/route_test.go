@@ -19,6 +19,9 @@ import (
 
 	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_route_v3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_retry_omit_canary_hosts_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/host/omit_canary_hosts/v3"
+	envoy_retry_previous_hosts_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/host/previous_hosts/v3"
+	envoy_retry_previous_priorities_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/retry/priority/previous_priorities/v3"
 	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	wrappers "github.com/golang/protobuf/ptypes/wrappers"
 	"github.com/projectsesame/sesame/internal/dag"
@@ -258,6 +261,69 @@ func TestRouteRoute(t *testing.T) {
 				},
 			},
 		},
+		"single with appended header manipulations": {
+			route: &dag.Route{
+				Clusters: []*dag.Cluster{{
+					Upstream: &dag.Service{
+						Weighted: dag.WeightedService{
+							Weight:           1,
+							ServiceName:      s1.Name,
+							ServiceNamespace: s1.Namespace,
+							ServicePort:      s1.Spec.Ports[0],
+						},
+					},
+
+					RequestHeadersPolicy: &dag.HeadersPolicy{
+						Add: map[string]string{
+							"X-Forwarded-Client": "%DOWNSTREAM_REMOTE_ADDRESS%",
+						},
+						AddIfAbsent: map[string]string{
+							"X-Request-Start": "%START_TIME(%s)%",
+						},
+					},
+					ResponseHeadersPolicy: &dag.HeadersPolicy{
+						AddIfAbsent: map[string]string{
+							"Cache-Control": "no-store",
+						},
+					},
+				}},
+			},
+			want: &envoy_route_v3.Route_Route{
+				Route: &envoy_route_v3.RouteAction{
+					ClusterSpecifier: &envoy_route_v3.RouteAction_WeightedClusters{
+						WeightedClusters: &envoy_route_v3.WeightedCluster{
+							Clusters: []*envoy_route_v3.WeightedCluster_ClusterWeight{{
+								Name:   "default/kuard/8080/da39a3ee5e",
+								Weight: protobuf.UInt32(1),
+								RequestHeadersToAdd: []*envoy_core_v3.HeaderValueOption{{
+									Header: &envoy_core_v3.HeaderValue{
+										Key:   "X-Forwarded-Client",
+										Value: "%DOWNSTREAM_REMOTE_ADDRESS%",
+									},
+									Append: &wrappers.BoolValue{
+										Value: true,
+									},
+								}, {
+									Header: &envoy_core_v3.HeaderValue{
+										Key:   "X-Request-Start",
+										Value: "%START_TIME(%s)%",
+									},
+									AppendAction: envoy_core_v3.HeaderValueOption_ADD_IF_ABSENT,
+								}},
+								ResponseHeadersToAdd: []*envoy_core_v3.HeaderValueOption{{
+									Header: &envoy_core_v3.HeaderValue{
+										Key:   "Cache-Control",
+										Value: "no-store",
+									},
+									AppendAction: envoy_core_v3.HeaderValueOption_ADD_IF_ABSENT,
+								}},
+							}},
+							TotalWeight: protobuf.UInt32(1),
+						},
+					},
+				},
+			},
+		},
 		"single service without retry-on": {
 			route: &dag.Route{
 				RetryPolicy: &dag.RetryPolicy{
@@ -320,6 +386,95 @@ func TestRouteRoute(t *testing.T) {
 				},
 			},
 		},
+		"retry host predicates, priority and back-off": {
+			route: &dag.Route{
+				RetryPolicy: &dag.RetryPolicy{
+					RetryOn:                       "5xx",
+					NumRetries:                    3,
+					RetryHostPredicates:           []string{"previous-hosts", "omit-canary-hosts"},
+					HostSelectionRetryMaxAttempts: 5,
+					PriorityUpdateFrequency:       2,
+					BackOffBaseInterval:           50 * time.Millisecond,
+					BackOffMaxInterval:            time.Second,
+				},
+				Clusters: []*dag.Cluster{c1},
+			},
+			want: &envoy_route_v3.Route_Route{
+				Route: &envoy_route_v3.RouteAction{
+					ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{
+						Cluster: "default/kuard/8080/da39a3ee5e",
+					},
+					RetryPolicy: &envoy_route_v3.RetryPolicy{
+						RetryOn:    "5xx",
+						NumRetries: protobuf.UInt32(3),
+						RetryHostPredicate: []*envoy_route_v3.RetryPolicy_RetryHostPredicate{{
+							Name: "envoy.retry_host_predicates.previous_hosts",
+							ConfigType: &envoy_route_v3.RetryPolicy_RetryHostPredicate_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(&envoy_retry_previous_hosts_v3.PreviousHostsPredicate{}),
+							},
+						}, {
+							Name: "envoy.retry_host_predicates.omit_canary_hosts",
+							ConfigType: &envoy_route_v3.RetryPolicy_RetryHostPredicate_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(&envoy_retry_omit_canary_hosts_v3.OmitCanaryHostsPredicate{}),
+							},
+						}},
+						HostSelectionRetryMaxAttempts: 5,
+						RetryPriority: &envoy_route_v3.RetryPolicy_RetryPriority{
+							Name: "envoy.retry_priorities.previous_priorities",
+							ConfigType: &envoy_route_v3.RetryPolicy_RetryPriority_TypedConfig{
+								TypedConfig: protobuf.MustMarshalAny(&envoy_retry_previous_priorities_v3.PreviousPrioritiesConfig{
+									UpdateFrequency: 2,
+								}),
+							},
+						},
+						RetryBackOff: &envoy_route_v3.RetryPolicy_RetryBackOff{
+							BaseInterval: protobuf.Duration(50 * time.Millisecond),
+							MaxInterval:  protobuf.Duration(time.Second),
+						},
+					},
+				},
+			},
+		},
+		"per-try idle timeout": {
+			route: &dag.Route{
+				RetryPolicy: &dag.RetryPolicy{
+					RetryOn:           "5xx",
+					NumRetries:        1,
+					PerTryIdleTimeout: timeout.DurationSetting(5 * time.Second),
+				},
+				Clusters: []*dag.Cluster{c1},
+			},
+			want: &envoy_route_v3.Route_Route{
+				Route: &envoy_route_v3.RouteAction{
+					ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{
+						Cluster: "default/kuard/8080/da39a3ee5e",
+					},
+					RetryPolicy: &envoy_route_v3.RetryPolicy{
+						RetryOn:           "5xx",
+						NumRetries:        protobuf.UInt32(1),
+						PerTryIdleTimeout: protobuf.Duration(5 * time.Second),
+					},
+				},
+			},
+		},
+		"hedge policy": {
+			route: &dag.Route{
+				HedgePolicy: &dag.HedgePolicy{
+					HedgeOnPerTryTimeout: true,
+				},
+				Clusters: []*dag.Cluster{c1},
+			},
+			want: &envoy_route_v3.Route_Route{
+				Route: &envoy_route_v3.RouteAction{
+					ClusterSpecifier: &envoy_route_v3.RouteAction_Cluster{
+						Cluster: "default/kuard/8080/da39a3ee5e",
+					},
+					HedgePolicy: &envoy_route_v3.HedgePolicy{
+						HedgeOnPerTryTimeout: true,
+					},
+				},
+			},
+		},
 		"timeout 90s": {
 			route: &dag.Route{
 				TimeoutPolicy: dag.TimeoutPolicy{
@@ -613,6 +768,19 @@ func TestRouteDirectResponse(t *testing.T) {
 				},
 			},
 		},
+		"200 with body": {
+			directResponse: &dag.DirectResponse{StatusCode: 200, Body: "User-agent: *\nDisallow: /\n"},
+			want: &envoy_route_v3.Route_DirectResponse{
+				DirectResponse: &envoy_route_v3.DirectResponseAction{
+					Status: 200,
+					Body: &envoy_core_v3.DataSource{
+						Specifier: &envoy_core_v3.DataSource_InlineString{
+							InlineString: "User-agent: *\nDisallow: /\n",
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for name, tc := range tests {
@@ -15,6 +15,7 @@ package v3
 
 import (
 	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_quic_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/quic/v3"
 	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	"github.com/projectsesame/sesame/internal/protobuf"
 )
@@ -38,3 +39,15 @@ func DownstreamTLSTransportSocket(tls *envoy_tls_v3.DownstreamTlsContext) *envoy
 		},
 	}
 }
+
+// DownstreamQUICTransportSocket returns a QUIC transport socket using the DownstreamTlsContext provided.
+func DownstreamQUICTransportSocket(tls *envoy_tls_v3.DownstreamTlsContext) *envoy_core_v3.TransportSocket {
+	return &envoy_core_v3.TransportSocket{
+		Name: "envoy.transport_sockets.quic",
+		ConfigType: &envoy_core_v3.TransportSocket_TypedConfig{
+			TypedConfig: protobuf.MustMarshalAny(&envoy_quic_v3.QuicDownstreamTransport{
+				DownstreamTlsContext: tls,
+			}),
+		},
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v3
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	envoy_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	envoy_service_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	"github.com/projectsesame/sesame/internal/fixture"
+	"github.com/projectsesame/sesame/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+const rateLimitServerConfig = `
+domain: sesame
+limits:
+- descriptor:
+    entries:
+    - genericKey:
+        value: foo
+  requests: 2
+  unit: minute
+- descriptor:
+    entries:
+    - requestHeader:
+        headerName: X-Tenant
+        descriptorKey: tenant
+  requests: 1
+  unit: minute
+- descriptor:
+    entries:
+    - requestHeader:
+        headerName: X-Tenant
+        descriptorKey: tenant
+    - requestHeader:
+        headerName: X-User
+        descriptorKey: user
+  requests: 1
+  unit: minute
+`
+
+// setupRateLimitServer serves the reference rate limit service over
+// gRPC and returns a client for it.
+func setupRateLimitServer(t *testing.T) (envoy_service_ratelimit_v3.RateLimitServiceClient, func()) {
+	cfg, err := ratelimit.Parse(strings.NewReader(rateLimitServerConfig))
+	require.NoError(t, err)
+
+	svc, err := ratelimit.NewService(fixture.NewTestLogger(t), cfg, nil)
+	require.NoError(t, err)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	srv := grpc.NewServer()
+	svc.Register(srv)
+
+	done := make(chan error)
+	go func() {
+		done <- srv.Serve(l) // srv now owns l and will close l before returning
+	}()
+
+	cc, err := grpc.Dial(l.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+
+	return envoy_service_ratelimit_v3.NewRateLimitServiceClient(cc), func() {
+		cc.Close()
+		srv.GracefulStop()
+		<-done
+	}
+}
+
+func TestRateLimitServer(t *testing.T) {
+	client, done := setupRateLimitServer(t)
+	defer done()
+
+	descriptor := func(kv ...string) *envoy_ratelimit_v3.RateLimitDescriptor {
+		d := &envoy_ratelimit_v3.RateLimitDescriptor{}
+		for i := 0; i < len(kv); i += 2 {
+			d.Entries = append(d.Entries, &envoy_ratelimit_v3.RateLimitDescriptor_Entry{Key: kv[i], Value: kv[i+1]})
+		}
+		return d
+	}
+
+	shouldRateLimit := func(descriptors ...*envoy_ratelimit_v3.RateLimitDescriptor) envoy_service_ratelimit_v3.RateLimitResponse_Code {
+		resp, err := client.ShouldRateLimit(context.Background(), &envoy_service_ratelimit_v3.RateLimitRequest{
+			Domain:      "sesame",
+			Descriptors: descriptors,
+		})
+		require.NoError(t, err)
+		return resp.OverallCode
+	}
+
+	// Requests over the limit are rate limited.
+	foo := descriptor("generic_key", "foo")
+	assert.Equal(t, envoy_service_ratelimit_v3.RateLimitResponse_OK, shouldRateLimit(foo))
+	assert.Equal(t, envoy_service_ratelimit_v3.RateLimitResponse_OK, shouldRateLimit(foo))
+	assert.Equal(t, envoy_service_ratelimit_v3.RateLimitResponse_OVER_LIMIT, shouldRateLimit(foo))
+
+	// Header values are counted separately, even when
+	// they contain the characters of other descriptors.
+	assert.Equal(t, envoy_service_ratelimit_v3.RateLimitResponse_OK, shouldRateLimit(descriptor("tenant", "a|user=b")))
+	assert.Equal(t, envoy_service_ratelimit_v3.RateLimitResponse_OK, shouldRateLimit(descriptor("tenant", "a", "user", "b")))
+	assert.Equal(t, envoy_service_ratelimit_v3.RateLimitResponse_OVER_LIMIT, shouldRateLimit(descriptor("tenant", "a", "user", "b")))
+	assert.Equal(t, envoy_service_ratelimit_v3.RateLimitResponse_OVER_LIMIT, shouldRateLimit(descriptor("tenant", "a|user=b")))
+}
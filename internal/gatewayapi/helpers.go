@@ -186,3 +186,14 @@ func TLSRouteBackendRef(serviceName string, port int, weight *int32) []gatewayap
 		},
 	}
 }
+
+// EnvoyListenerPort returns the port that Envoy binds to for the
+// Gateway listeners on the given port when they are served by an
+// Envoy listener of their own. Envoy does not run as root, so 8000
+// is added to privileged ports.
+func EnvoyListenerPort(port gatewayapi_v1alpha2.PortNumber) int {
+	if port < 1024 {
+		return int(port) + 8000
+	}
+	return int(port)
+}
@@ -18,14 +18,12 @@ package httpsvc
 import (
 	"context"
 	"crypto/tls"
-	"crypto/x509"
-	"fmt"
-	"io/ioutil"
 	"net"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/projectsesame/sesame/internal/certwatch"
 	"github.com/sirupsen/logrus"
 )
 
@@ -104,46 +102,16 @@ func (svc *Service) Start(ctx context.Context) (err error) {
 }
 
 func (svc *Service) tlsConfig() (*tls.Config, error) {
-	// Define a closure that lazily loads certificates and key at TLS handshake
+	// The watcher reloads the certificates and key when the files change,
 	// to ensure that latest certificates are used in case they have been rotated.
-	loadConfig := func() (*tls.Config, error) {
-		cert, err := tls.LoadX509KeyPair(svc.Cert, svc.Key)
-		if err != nil {
-			return nil, err
-		}
-
-		clientAuth := tls.NoClientCert
-		var certPool *x509.CertPool
-		if svc.CABundle != "" {
-			clientAuth = tls.RequireAndVerifyClientCert
-			ca, err := ioutil.ReadFile(svc.CABundle)
-			if err != nil {
-				return nil, err
-			}
-
-			certPool = x509.NewCertPool()
-			if ok := certPool.AppendCertsFromPEM(ca); !ok {
-				return nil, fmt.Errorf("unable to append certificate in %s to CA pool", svc.CABundle)
-			}
-		}
-
-		return &tls.Config{
-			Certificates: []tls.Certificate{cert},
-			ClientAuth:   clientAuth,
-			ClientCAs:    certPool,
-			MinVersion:   tls.VersionTLS13,
-		}, nil
-	}
-
-	// Attempt to load certificates and key to catch configuration errors early.
-	if _, err := loadConfig(); err != nil {
+	// Loading them now catches configuration errors early.
+	watcher, err := certwatch.New(svc.FieldLogger, svc.Cert, svc.Key, svc.CABundle)
+	if err != nil {
 		return nil, err
 	}
 
 	return &tls.Config{
-		MinVersion: tls.VersionTLS12,
-		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
-			return loadConfig()
-		},
+		MinVersion:         tls.VersionTLS12,
+		GetConfigForClient: watcher.GetConfigForClient,
 	}, nil
 }
@@ -0,0 +1,39 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+	gatewayapi_v1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+// KindGatewayClassParameters is the kind of the parameters
+// a GatewayClass can refer to with its parametersRef.
+const KindGatewayClassParameters = "GatewayClassParameters"
+
+// GatewayClassParametersRef returns the GatewayClassParameters a
+// GatewayClass refers to with its parametersRef, and false if the
+// parametersRef is unset or doesn't refer to a GatewayClassParameters.
+func GatewayClassParametersRef(gc *gatewayapi_v1alpha2.GatewayClass) (types.NamespacedName, bool) {
+	ref := gc.Spec.ParametersRef
+	if ref == nil ||
+		string(ref.Group) != sesame_api_v1alpha1.GroupVersion.Group ||
+		string(ref.Kind) != KindGatewayClassParameters ||
+		ref.Namespace == nil {
+		return types.NamespacedName{}, false
+	}
+
+	return types.NamespacedName{Namespace: string(*ref.Namespace), Name: ref.Name}, true
+}
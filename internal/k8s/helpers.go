@@ -26,7 +26,7 @@ import (
 //
 // Currently supports:
 // networking.k8s.io/ingress/v1
-// projectsesame.io/v1 (HTTPProxy only)
+// projectsesame.io/v1 (HTTPProxy and TLSCertificateDelegation only)
 // networking.x-k8s.io/v1alpha1 (GatewayClass and Gateway only)
 func isStatusEqual(objA, objB interface{}) bool {
 	switch a := objA.(type) {
@@ -47,6 +47,16 @@ func isStatusEqual(objA, objB interface{}) bool {
 				return true
 			}
 		}
+	case *sesame_api_v1.TLSCertificateDelegation:
+		if b, ok := objB.(*sesame_api_v1.TLSCertificateDelegation); ok {
+			// As for HTTPProxy, the LastTransitionTime is set on each
+			// DAG rebuild, so comparing it would update the status of
+			// every delegation each time.
+			if cmp.Equal(a.Status, b.Status,
+				cmpopts.IgnoreFields(sesame_api_v1.Condition{}, "LastTransitionTime")) {
+				return true
+			}
+		}
 	case *gatewayapi_v1alpha2.GatewayClass:
 		if b, ok := objB.(*gatewayapi_v1alpha2.GatewayClass); ok {
 			if cmp.Equal(a.Status, b.Status,
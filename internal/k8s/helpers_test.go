@@ -0,0 +1,51 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIsStatusEqualDelegation(t *testing.T) {
+	delegation := func(transitionTime time.Time, namespaces ...string) *sesame_api_v1.TLSCertificateDelegation {
+		return &sesame_api_v1.TLSCertificateDelegation{
+			Status: sesame_api_v1.TLSCertificateDelegationStatus{
+				Conditions: []sesame_api_v1.DetailedCondition{{
+					Condition: sesame_api_v1.Condition{
+						Type:               "Valid",
+						Status:             sesame_api_v1.ConditionTrue,
+						LastTransitionTime: metav1.NewTime(transitionTime),
+						Reason:             "Valid",
+					},
+				}},
+				Delegations: []sesame_api_v1.CertificateDelegationStatus{{
+					SecretName: "wildcard",
+					Namespaces: namespaces,
+				}},
+			},
+		}
+	}
+
+	now := time.Now()
+
+	// Each DAG rebuild sets a new transition time.
+	assert.True(t, isStatusEqual(delegation(now, "default"), delegation(now.Add(time.Minute), "default")))
+
+	assert.False(t, isStatusEqual(delegation(now, "default"), delegation(now, "default", "other")))
+}
@@ -17,13 +17,13 @@ package k8s
 // +kubebuilder:rbac:groups="networking.k8s.io",resources=ingressclasses,verbs=get;list;watch
 // +kubebuilder:rbac:groups="networking.k8s.io",resources=ingresses/status,verbs=create;get;update
 
-// +kubebuilder:rbac:groups="projectsesame.io",resources=httpproxies;tlscertificatedelegations;extensionservices;Sesameconfigurations,verbs=get;list;watch
-// +kubebuilder:rbac:groups="projectsesame.io",resources=httpproxies/status;extensionservices/status;Sesameconfigurations/status,verbs=create;get;update
+// +kubebuilder:rbac:groups="projectsesame.io",resources=httpproxies;tlscertificatedelegations;extensionservices;sesameconfigurations;gatewayclassparameters;servicereferencegrants,verbs=get;list;watch
+// +kubebuilder:rbac:groups="projectsesame.io",resources=httpproxies/status;tlscertificatedelegations/status;extensionservices/status;sesameconfigurations/status,verbs=create;get;update
 
 // +kubebuilder:rbac:groups="gateway.networking.k8s.io",resources=gatewayclasses;gateways;httproutes;tlsroutes;referencepolicies,verbs=get;list;watch
 // +kubebuilder:rbac:groups="gateway.networking.k8s.io",resources=gatewayclasses/status;gateways/status;httproutes/status;tlsroutes/status,verbs=update
 
-// +kubebuilder:rbac:groups="",resources=secrets;endpoints;services;namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=secrets;endpoints;services;namespaces;configmaps,verbs=get;list;watch
 
 // Add RBAC policy to support leader election.
 // +kubebuilder:rbac:groups="",resources=configmaps;events,verbs=create;get;update
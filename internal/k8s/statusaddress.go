@@ -26,6 +26,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	networking_v1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -43,8 +44,13 @@ type StatusAddressUpdater struct {
 	LBStatus              v1.LoadBalancerStatus
 	IngressClassName      string
 	GatewayControllerName string
+	GatewayRef            *types.NamespacedName
 	StatusUpdater         StatusUpdater
 
+	// ExcludedGateways are Gateways whose address is set from
+	// another Envoy service, so they are skipped by this updater.
+	ExcludedGateways []types.NamespacedName
+
 	// mu guards the LBStatus field, which can be updated dynamically.
 	mu sync.Mutex
 }
@@ -131,6 +137,27 @@ func (s *StatusAddressUpdater) OnAdd(obj interface{}) {
 		))
 
 	case *gatewayapi_v1alpha2.Gateway:
+		// If Sesame corresponds to a specific Gateway, only set that
+		// Gateway's address, since other Gateways of the same class
+		// are served by other Envoys.
+		if s.GatewayRef != nil && NamespacedNameOf(o) != *s.GatewayRef {
+			s.Logger.
+				WithField("name", o.Name).
+				WithField("namespace", o.Namespace).
+				Debug("Gateway is not the Gateway for this Sesame, not setting address")
+			return
+		}
+
+		for _, excluded := range s.ExcludedGateways {
+			if NamespacedNameOf(o) == excluded {
+				s.Logger.
+					WithField("name", o.Name).
+					WithField("namespace", o.Namespace).
+					Debug("Gateway is served by its own Envoy service, not setting address")
+				return
+			}
+		}
+
 		// Check if the Gateway's class is controlled by this Sesame
 		gc := &gatewayapi_v1alpha2.GatewayClass{}
 		if err := s.Cache.Get(context.Background(), client.ObjectKey{Name: string(o.Spec.GatewayClassName)}, gc); err != nil {
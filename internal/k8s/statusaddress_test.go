@@ -27,6 +27,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	networking_v1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/pointer"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	gatewayapi_v1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
@@ -369,6 +370,8 @@ func TestStatusAddressUpdater_Gateway(t *testing.T) {
 	testCases := map[string]struct {
 		status                     v1.LoadBalancerStatus
 		gatewayClassControllerName string
+		gatewayRef                 *types.NamespacedName
+		excludedGateways           []types.NamespacedName
 		preop                      *gatewayapi_v1alpha2.Gateway
 		postop                     *gatewayapi_v1alpha2.Gateway
 	}{
@@ -536,6 +539,84 @@ func TestStatusAddressUpdater_Gateway(t *testing.T) {
 				},
 			},
 		},
+		"Gateway is not the referenced Gateway": {
+			status:                     ipLBStatus,
+			gatewayClassControllerName: "projectsesame.io/sesame",
+			gatewayRef:                 &types.NamespacedName{Namespace: "projectsesame", Name: "other-gateway"},
+			preop: &gatewayapi_v1alpha2.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "projectsesame",
+					Name:      "sesame-gateway",
+				},
+				Spec: gatewayapi_v1alpha2.GatewaySpec{
+					GatewayClassName: gatewayapi_v1alpha2.ObjectName("sesame-gatewayclass"),
+				},
+				Status: gatewayapi_v1alpha2.GatewayStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:   string(gatewayapi_v1alpha2.GatewayConditionReady),
+							Status: metav1.ConditionTrue,
+						},
+					},
+				},
+			},
+			postop: &gatewayapi_v1alpha2.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "projectsesame",
+					Name:      "sesame-gateway",
+				},
+				Spec: gatewayapi_v1alpha2.GatewaySpec{
+					GatewayClassName: gatewayapi_v1alpha2.ObjectName("sesame-gatewayclass"),
+				},
+				Status: gatewayapi_v1alpha2.GatewayStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:   string(gatewayapi_v1alpha2.GatewayConditionReady),
+							Status: metav1.ConditionTrue,
+						},
+					},
+				},
+			},
+		},
+		"Gateway is served by its own Envoy service": {
+			status:                     ipLBStatus,
+			gatewayClassControllerName: "projectsesame.io/sesame",
+			excludedGateways:           []types.NamespacedName{{Namespace: "projectsesame", Name: "sesame-gateway"}},
+			preop: &gatewayapi_v1alpha2.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "projectsesame",
+					Name:      "sesame-gateway",
+				},
+				Spec: gatewayapi_v1alpha2.GatewaySpec{
+					GatewayClassName: gatewayapi_v1alpha2.ObjectName("sesame-gatewayclass"),
+				},
+				Status: gatewayapi_v1alpha2.GatewayStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:   string(gatewayapi_v1alpha2.GatewayConditionReady),
+							Status: metav1.ConditionTrue,
+						},
+					},
+				},
+			},
+			postop: &gatewayapi_v1alpha2.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "projectsesame",
+					Name:      "sesame-gateway",
+				},
+				Spec: gatewayapi_v1alpha2.GatewaySpec{
+					GatewayClassName: gatewayapi_v1alpha2.ObjectName("sesame-gatewayclass"),
+				},
+				Status: gatewayapi_v1alpha2.GatewayStatus{
+					Conditions: []metav1.Condition{
+						{
+							Type:   string(gatewayapi_v1alpha2.GatewayConditionReady),
+							Status: metav1.ConditionTrue,
+						},
+					},
+				},
+			},
+		},
 	}
 
 	for name, tc := range testCases {
@@ -558,6 +639,8 @@ func TestStatusAddressUpdater_Gateway(t *testing.T) {
 			isu := StatusAddressUpdater{
 				Logger:                log,
 				GatewayControllerName: "projectsesame.io/sesame",
+				GatewayRef:            tc.gatewayRef,
+				ExcludedGateways:      tc.excludedGateways,
 				Cache:                 mockCache,
 				LBStatus:              tc.status,
 				StatusUpdater:         &suc,
@@ -588,6 +671,8 @@ func TestStatusAddressUpdater_Gateway(t *testing.T) {
 			isu := StatusAddressUpdater{
 				Logger:                log,
 				GatewayControllerName: "projectsesame.io/sesame",
+				GatewayRef:            tc.gatewayRef,
+				ExcludedGateways:      tc.excludedGateways,
 				Cache:                 mockCache,
 				LBStatus:              tc.status,
 				StatusUpdater:         &suc,
@@ -38,8 +38,14 @@ type Metrics struct {
 	CacheHandlerOnUpdateSummary prometheus.Summary
 	EventHandlerOperations      *prometheus.CounterVec
 
+	certificateExpiryGauge   *prometheus.GaugeVec
+	certificateRotationTotal *prometheus.CounterVec
+
+	tlsCertificateExpiryGauge *prometheus.GaugeVec
+
 	// Keep a local cache of metrics for comparison on updates
-	proxyMetricCache *RouteMetric
+	proxyMetricCache          *RouteMetric
+	tlsCertificateMetricCache map[TLSCertificateMeta]time.Time
 }
 
 // RouteMetric stores various metrics for HTTPProxy objects
@@ -56,6 +62,12 @@ type Meta struct {
 	VHost, Namespace string
 }
 
+// TLSCertificateMeta holds the namespace and name of a TLS
+// certificate Secret, and the vhost it serves.
+type TLSCertificateMeta struct {
+	Namespace, Name, VHost string
+}
+
 const (
 	BuildInfoGauge = "Sesame_build_info"
 
@@ -69,6 +81,11 @@ const (
 	DAGRebuildTotal             = "Sesame_dagrebuild_total"
 	cacheHandlerOnUpdateSummary = "Sesame_cachehandler_onupdate_duration_seconds"
 	eventHandlerOperations      = "Sesame_eventhandler_operation_total"
+
+	CertificateExpiryGauge   = "Sesame_xds_certificate_expiry_timestamp_seconds"
+	CertificateRotationTotal = "Sesame_xds_certificate_rotation_total"
+
+	TLSCertificateExpiryGauge = "Sesame_tls_certificate_expiry_timestamp_seconds"
 )
 
 // NewMetrics creates a new set of metrics and registers them with
@@ -147,6 +164,28 @@ func NewMetrics(registry *prometheus.Registry) *Metrics {
 			},
 			[]string{"op", "kind"},
 		),
+		certificateExpiryGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: CertificateExpiryGauge,
+				Help: "Expiry time of the xDS certificates managed by Sesame, in seconds since the Unix epoch.",
+			},
+			[]string{"namespace", "name"},
+		),
+		certificateRotationTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: CertificateRotationTotal,
+				Help: "Total number of times Sesame has rotated an xDS certificate.",
+			},
+			[]string{"namespace", "name"},
+		),
+		tlsCertificateMetricCache: map[TLSCertificateMeta]time.Time{},
+		tlsCertificateExpiryGauge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: TLSCertificateExpiryGauge,
+				Help: "Expiry time of the serving certificates of TLS virtual hosts, in seconds since the Unix epoch.",
+			},
+			[]string{"namespace", "name", "vhost"},
+		),
 	}
 	m.buildInfoGauge.WithLabelValues(build.Branch, build.Sha, build.Version).Set(1)
 	m.register(registry)
@@ -166,6 +205,9 @@ func (m *Metrics) register(registry *prometheus.Registry) {
 		m.dagRebuildTotal,
 		m.CacheHandlerOnUpdateSummary,
 		m.EventHandlerOperations,
+		m.certificateExpiryGauge,
+		m.certificateRotationTotal,
+		m.tlsCertificateExpiryGauge,
 	)
 }
 
@@ -189,6 +231,9 @@ func (m *Metrics) Zero() {
 	m.SetDAGLastRebuilt(time.Now())
 	m.SetHTTPProxyMetric(zeroes)
 	m.EventHandlerOperations.WithLabelValues("add", "Secret").Inc()
+	m.SetCertificateExpiry("", "", time.Now())
+	m.certificateRotationTotal.WithLabelValues("", "").Add(0)
+	m.SetTLSCertificateExpiry(map[TLSCertificateMeta]time.Time{{}: time.Now()})
 
 	prometheus.NewTimer(m.CacheHandlerOnUpdateSummary).ObserveDuration()
 }
@@ -203,6 +248,34 @@ func (m *Metrics) SetDAGRebuiltTotal() {
 	m.dagRebuildTotal.Inc()
 }
 
+// SetCertificateExpiry records the expiry time of the certificate
+// stored in the named Secret.
+func (m *Metrics) SetCertificateExpiry(namespace, name string, notAfter time.Time) {
+	m.certificateExpiryGauge.WithLabelValues(namespace, name).Set(float64(notAfter.Unix()))
+}
+
+// CertificateRotated records that the certificate stored in the
+// named Secret was rotated.
+func (m *Metrics) CertificateRotated(namespace, name string) {
+	m.certificateRotationTotal.WithLabelValues(namespace, name).Inc()
+}
+
+// SetTLSCertificateExpiry records the expiry time of the serving
+// certificate of each TLS virtual host, removing the metrics of
+// certificates that are no longer served.
+func (m *Metrics) SetTLSCertificateExpiry(certificates map[TLSCertificateMeta]time.Time) {
+	for meta, notAfter := range certificates {
+		m.tlsCertificateExpiryGauge.WithLabelValues(meta.Namespace, meta.Name, meta.VHost).Set(float64(notAfter.Unix()))
+		delete(m.tlsCertificateMetricCache, meta)
+	}
+
+	for meta := range m.tlsCertificateMetricCache {
+		m.tlsCertificateExpiryGauge.DeleteLabelValues(meta.Namespace, meta.Name, meta.VHost)
+	}
+
+	m.tlsCertificateMetricCache = certificates
+}
+
 // SetHTTPProxyMetric sets metric values for a set of HTTPProxies
 func (m *Metrics) SetHTTPProxyMetric(metrics RouteMetric) {
 	// Process metrics
@@ -689,3 +689,58 @@ func TestRemoveProxyMetric(t *testing.T) {
 		})
 	}
 }
+
+func TestSetTLSCertificateExpiry(t *testing.T) {
+	expiries := func(r *prometheus.Registry) map[TLSCertificateMeta]float64 {
+		gathering, err := r.Gather()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got := map[TLSCertificateMeta]float64{}
+		for _, mf := range gathering {
+			if mf.GetName() != TLSCertificateExpiryGauge {
+				continue
+			}
+			for _, metric := range mf.Metric {
+				var meta TLSCertificateMeta
+				for _, label := range metric.Label {
+					switch label.GetName() {
+					case "namespace":
+						meta.Namespace = label.GetValue()
+					case "name":
+						meta.Name = label.GetValue()
+					case "vhost":
+						meta.VHost = label.GetValue()
+					}
+				}
+				got[meta] = metric.GetGauge().GetValue()
+			}
+		}
+		return got
+	}
+
+	r := prometheus.NewRegistry()
+	m := NewMetrics(r)
+
+	notAfter := time.Date(2029, 12, 2, 0, 0, 0, 0, time.UTC)
+	foo := TLSCertificateMeta{Namespace: "default", Name: "foo", VHost: "foo.com"}
+	bar := TLSCertificateMeta{Namespace: "default", Name: "bar", VHost: "bar.com"}
+
+	m.SetTLSCertificateExpiry(map[TLSCertificateMeta]time.Time{
+		foo: notAfter,
+		bar: notAfter,
+	})
+	assert.Equal(t, map[TLSCertificateMeta]float64{
+		foo: float64(notAfter.Unix()),
+		bar: float64(notAfter.Unix()),
+	}, expiries(r))
+
+	// Certificates that are no longer served are removed.
+	m.SetTLSCertificateExpiry(map[TLSCertificateMeta]time.Time{
+		foo: notAfter.Add(time.Hour),
+	})
+	assert.Equal(t, map[TLSCertificateMeta]float64{
+		foo: float64(notAfter.Add(time.Hour).Unix()),
+	}, expiries(r))
+}
@@ -0,0 +1,99 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// certgenObjects returns the certgen Job and the RBAC objects it runs with.
+func certgenObjects(i *Instance) []client.Object {
+	name := i.CertgenName()
+
+	return []client.Object{
+		serviceAccount(i, name),
+		role(i, name, certgenRules),
+		roleBinding(i, name),
+		certgenJob(i),
+	}
+}
+
+// certgenJob returns a Job that generates the Sesame and Envoy
+// gRPC certificates. Existing Secrets are not overwritten, so
+// the certificates survive the Job being recreated.
+func certgenJob(i *Instance) *batchv1.Job {
+	labels := i.labels(map[string]string{
+		"app.kubernetes.io/name":     "sesame-certgen",
+		"app.kubernetes.io/instance": i.baseName(),
+	})
+
+	command := []string{
+		"sesame",
+		"certgen",
+		"--kube",
+		"--incluster",
+		"--secrets-format=compact",
+		"--secrets-name-suffix=" + i.SecretNameSuffix(),
+		"--namespace=" + i.Namespace,
+	}
+
+	// Certificate rotation issues new certificates from the CA,
+	// so the CA keypair must be kept.
+	if rotation := i.Config.CertificateRotation; rotation != nil && len(rotation.CASecretName) > 0 {
+		command = append(command, "--ca-secret-name="+rotation.CASecretName)
+	}
+
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      i.CertgenName(),
+			Namespace: i.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			Parallelism:  pointer.Int32(1),
+			Completions:  pointer.Int32(1),
+			BackoffLimit: pointer.Int32(1),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:            "sesame",
+						Image:           i.sesameImage(),
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Command:         command,
+					}},
+					RestartPolicy:      corev1.RestartPolicyNever,
+					ServiceAccountName: i.CertgenName(),
+					SecurityContext:    podSecurityContext(),
+				},
+			},
+		},
+	}
+}
+
+// podSecurityContext returns the security context
+// shared by the provisioned pods.
+func podSecurityContext() *corev1.PodSecurityContext {
+	return &corev1.PodSecurityContext{
+		RunAsNonRoot: pointer.Bool(true),
+		RunAsUser:    pointer.Int64(65534),
+		RunAsGroup:   pointer.Int64(65534),
+	}
+}
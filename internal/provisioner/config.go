@@ -0,0 +1,127 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// certsDir is where the gRPC certificates are mounted
+	// in the Sesame and Envoy containers.
+	certsDir = "/certs"
+
+	sesameSecretName = "sesamecert"
+	envoySecretName  = "envoycert"
+)
+
+// sesameConfiguration returns the SesameConfiguration the Sesame
+// Deployment runs with. The settings that tie Sesame to the other
+// provisioned objects override the user supplied configuration.
+func sesameConfiguration(i *Instance) *sesame_api_v1alpha1.SesameConfiguration {
+	spec := i.Config.DeepCopy()
+	setDefaults(spec)
+
+	spec.XDSServer.Address = "0.0.0.0"
+	spec.XDSServer.Port = xdsPort
+	spec.XDSServer.TLS = &sesame_api_v1alpha1.TLS{
+		CAFile:   certsDir + "/ca.crt",
+		CertFile: certsDir + "/tls.crt",
+		KeyFile:  certsDir + "/tls.key",
+	}
+
+	spec.Envoy.Service = sesame_api_v1alpha1.NamespacedName{
+		Name:      i.EnvoyName(),
+		Namespace: i.Namespace,
+	}
+
+	if rotation := spec.CertificateRotation; rotation != nil {
+		rotation.Namespace = i.Namespace
+		rotation.SecretNames = []string{
+			sesameSecretName + i.SecretNameSuffix(),
+			envoySecretName + i.SecretNameSuffix(),
+		}
+	}
+
+	return &sesame_api_v1alpha1.SesameConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      i.SesameName(),
+			Namespace: i.Namespace,
+			Labels:    i.OwnerLabels(),
+		},
+		Spec: *spec,
+	}
+}
+
+// setDefaults fills in the required fields that the configuration
+// leaves unset with the defaults the SesameConfiguration CRD applies,
+// since the API server does not default fields that are sent as zero
+// values.
+func setDefaults(spec *sesame_api_v1alpha1.SesameConfigurationSpec) {
+	if len(spec.XDSServer.Type) == 0 {
+		spec.XDSServer.Type = sesame_api_v1alpha1.SesameServerType
+	}
+	if len(spec.Debug.DebugLogLevel) == 0 {
+		spec.Debug.DebugLogLevel = sesame_api_v1alpha1.InfoLog
+	}
+	if len(spec.Health.Address) == 0 && spec.Health.Port == 0 {
+		spec.Health = sesame_api_v1alpha1.HealthConfig{Address: "0.0.0.0", Port: metricsPort}
+	}
+	if len(spec.Metrics.Address) == 0 && spec.Metrics.Port == 0 {
+		spec.Metrics.Address = "0.0.0.0"
+		spec.Metrics.Port = metricsPort
+	}
+
+	envoy := &spec.Envoy
+	setListenerDefaults(&envoy.HTTPListener, 8080)
+	setListenerDefaults(&envoy.HTTPSListener, 8443)
+	if len(envoy.Health.Address) == 0 && envoy.Health.Port == 0 {
+		envoy.Health = sesame_api_v1alpha1.HealthConfig{Address: "0.0.0.0", Port: envoyAdminPort}
+	}
+	if len(envoy.Metrics.Address) == 0 && envoy.Metrics.Port == 0 {
+		envoy.Metrics.Address = "0.0.0.0"
+		envoy.Metrics.Port = envoyAdminPort
+	}
+	if len(envoy.Listener.TLS.MinimumProtocolVersion) == 0 {
+		envoy.Listener.TLS.MinimumProtocolVersion = "1.2"
+	}
+	if len(envoy.Logging.AccessLogFormat) == 0 {
+		envoy.Logging.AccessLogFormat = sesame_api_v1alpha1.EnvoyAccessLog
+	}
+	if len(envoy.DefaultHTTPVersions) == 0 {
+		envoy.DefaultHTTPVersions = []sesame_api_v1alpha1.HTTPVersionType{
+			sesame_api_v1alpha1.HTTPVersion1,
+			sesame_api_v1alpha1.HTTPVersion2,
+		}
+	}
+	if len(envoy.Cluster.DNSLookupFamily) == 0 {
+		envoy.Cluster.DNSLookupFamily = sesame_api_v1alpha1.AutoClusterDNSFamily
+	}
+	if envoy.Network.EnvoyAdminPort == 0 {
+		envoy.Network.EnvoyAdminPort = 9001
+	}
+}
+
+func setListenerDefaults(listener *sesame_api_v1alpha1.EnvoyListener, port int) {
+	if len(listener.Address) == 0 {
+		listener.Address = "0.0.0.0"
+	}
+	if listener.Port == 0 {
+		listener.Port = port
+	}
+	if len(listener.AccessLog) == 0 {
+		listener.AccessLog = "/dev/stdout"
+	}
+}
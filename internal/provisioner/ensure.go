@@ -0,0 +1,136 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+
+	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Ensure creates the desired object, or updates the existing
+// object so that the fields the provisioner manages match it.
+func Ensure(ctx context.Context, cli client.Client, desired client.Object) error {
+	current, ok := desired.DeepCopyObject().(client.Object)
+	if !ok {
+		return fmt.Errorf("unsupported object type %T", desired)
+	}
+
+	err := cli.Get(ctx, client.ObjectKeyFromObject(desired), current)
+	switch {
+	case errors.IsNotFound(err):
+		return cli.Create(ctx, desired)
+	case err != nil:
+		return err
+	}
+
+	updated, ok := current.DeepCopyObject().(client.Object)
+	if !ok {
+		return fmt.Errorf("unsupported object type %T", current)
+	}
+	if err := merge(updated, desired); err != nil {
+		return err
+	}
+
+	if equality.Semantic.DeepEqual(current, updated) {
+		return nil
+	}
+
+	return cli.Update(ctx, updated)
+}
+
+// Delete deletes the object if it exists.
+func Delete(ctx context.Context, cli client.Client, obj client.Object) error {
+	if err := cli.Delete(ctx, obj, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// merge copies the managed fields of desired onto current.
+// Fields that are immutable or filled in by the API server
+// are left alone.
+func merge(current, desired client.Object) error {
+	labels := current.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for k, v := range desired.GetLabels() {
+		labels[k] = v
+	}
+	current.SetLabels(labels)
+	current.SetOwnerReferences(desired.GetOwnerReferences())
+
+	switch cur := current.(type) {
+	case *corev1.ServiceAccount:
+		// Only the metadata is managed.
+	case *rbacv1.Role:
+		cur.Rules = desired.(*rbacv1.Role).Rules
+	case *rbacv1.RoleBinding:
+		cur.Subjects = desired.(*rbacv1.RoleBinding).Subjects
+	case *rbacv1.ClusterRole:
+		cur.Rules = desired.(*rbacv1.ClusterRole).Rules
+	case *rbacv1.ClusterRoleBinding:
+		cur.Subjects = desired.(*rbacv1.ClusterRoleBinding).Subjects
+	case *batchv1.Job:
+		// A Job's pod template is immutable, and the
+		// Job only has to run once.
+	case *sesame_api_v1alpha1.SesameConfiguration:
+		cur.Spec = desired.(*sesame_api_v1alpha1.SesameConfiguration).Spec
+	case *corev1.Service:
+		mergeService(cur, desired.(*corev1.Service))
+	case *appsv1.Deployment:
+		want := desired.(*appsv1.Deployment)
+		cur.Spec.Replicas = want.Spec.Replicas
+		cur.Spec.Strategy = want.Spec.Strategy
+		cur.Spec.Template = want.Spec.Template
+	case *appsv1.DaemonSet:
+		want := desired.(*appsv1.DaemonSet)
+		cur.Spec.UpdateStrategy = want.Spec.UpdateStrategy
+		cur.Spec.Template = want.Spec.Template
+	default:
+		return fmt.Errorf("unsupported object type %T", current)
+	}
+
+	return nil
+}
+
+// mergeService copies the managed fields of desired onto current,
+// keeping the cluster IP and any node ports already allocated.
+func mergeService(current, desired *corev1.Service) {
+	nodePorts := map[string]int32{}
+	if desired.Spec.Type != corev1.ServiceTypeClusterIP {
+		for _, port := range current.Spec.Ports {
+			nodePorts[port.Name] = port.NodePort
+		}
+	}
+
+	current.Spec.Type = desired.Spec.Type
+	current.Spec.Selector = desired.Spec.Selector
+	current.Spec.ExternalTrafficPolicy = desired.Spec.ExternalTrafficPolicy
+	current.Spec.Ports = nil
+	for _, port := range desired.Spec.Ports {
+		port.NodePort = nodePorts[port.Name]
+		current.Spec.Ports = append(current.Spec.Ports, port)
+	}
+}
@@ -0,0 +1,261 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package provisioner builds the Kubernetes objects that run an
+// instance of Sesame and its Envoy fleet, and applies them to a cluster.
+package provisioner
+
+import (
+	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DefaultSesameImage is the image used for the Sesame,
+	// certgen and shutdown-manager containers.
+	DefaultSesameImage = "ghcr.io/projectsesame/sesame:main"
+
+	// DefaultEnvoyImage is the image used for the Envoy container.
+	DefaultEnvoyImage = "docker.io/envoyproxy/envoy:v1.22.2"
+
+	// OwningKindLabel, OwningNameLabel and OwningNamespaceLabel are set
+	// on every provisioned object to record the resource that owns it.
+	// Cluster-scoped objects cannot carry an owner reference to a
+	// namespaced resource, so these labels are used to find them.
+	OwningKindLabel      = "projectsesame.io/owning-kind"
+	OwningNameLabel      = "projectsesame.io/owning-name"
+	OwningNamespaceLabel = "projectsesame.io/owning-namespace"
+
+	// KindGateway is the Kind of instances provisioned for Gateways.
+	KindGateway = "Gateway"
+
+	// xdsPort is the port Sesame serves xDS on.
+	xdsPort = 8001
+
+	// metricsPort is the port Sesame serves metrics and health on.
+	metricsPort = 8000
+
+	// envoyAdminPort is the port Envoy serves readiness and stats on.
+	envoyAdminPort = 8002
+
+	// shutdownManagerPort is the port the shutdown manager listens on.
+	shutdownManagerPort = 8090
+
+	// defaultReplicas is the number of Sesame or Envoy
+	// replicas when a replica count is not given.
+	defaultReplicas = 2
+)
+
+// Images holds the container images used by provisioned instances.
+type Images struct {
+	// Sesame is the image for the Sesame, certgen and
+	// shutdown-manager containers.
+	Sesame string
+
+	// Envoy is the image for the Envoy container.
+	Envoy string
+}
+
+// Instance describes a Sesame instance to provision.
+type Instance struct {
+	// Kind, Name and Namespace identify the resource the instance
+	// is provisioned for. Generated object names are derived from
+	// Kind and Name, and every object is placed in Namespace.
+	Kind      string
+	Name      string
+	Namespace string
+
+	// Replicas is the number of Sesame replicas.
+	Replicas int32
+
+	// Config is the configuration for the Sesame instance.
+	Config sesame_api_v1alpha1.SesameConfigurationSpec
+
+	// Envoy defines how the Envoy fleet is deployed.
+	Envoy sesame_api_v1alpha1.EnvoySettings
+
+	// HTTPPorts and HTTPSPorts are the ports of the Gateway's HTTP,
+	// and HTTPS and TLS listeners, which the Envoy Service exposes.
+	// Each port is forwarded to the Envoy listener that serves it.
+	// If unset, the Service exposes ports 80 and 443, which are
+	// forwarded to Envoy's HTTP and HTTPS listeners.
+	HTTPPorts  []int32
+	HTTPSPorts []int32
+
+	// Images are the container images to run.
+	Images Images
+}
+
+// baseName returns the name generated object names are derived from.
+// Gateways and SesameDeployments may share a name in a namespace, so
+// the names of objects provisioned for Gateways are prefixed.
+func (i *Instance) baseName() string {
+	if i.Kind == KindGateway {
+		return "gateway-" + i.Name
+	}
+	return i.Name
+}
+
+// SesameName returns the name of the Sesame Deployment, Service,
+// ServiceAccount and SesameConfiguration.
+func (i *Instance) SesameName() string {
+	return "sesame-" + i.baseName()
+}
+
+// EnvoyName returns the name of the Envoy workload,
+// Service and ServiceAccount.
+func (i *Instance) EnvoyName() string {
+	return "envoy-" + i.baseName()
+}
+
+// CertgenName returns the name of the certgen Job and its RBAC objects.
+func (i *Instance) CertgenName() string {
+	return "sesame-certgen-" + i.baseName()
+}
+
+// ClusterRoleName returns the name of the ClusterRole and
+// ClusterRoleBinding for Sesame. Cluster-scoped names must be
+// unique across namespaces, so the namespace is included.
+func (i *Instance) ClusterRoleName() string {
+	return "sesame-" + i.Namespace + "-" + i.baseName()
+}
+
+// SecretNameSuffix returns the suffix certgen appends to the
+// names of the Secrets it generates for the instance.
+func (i *Instance) SecretNameSuffix() string {
+	return "-" + i.baseName()
+}
+
+// OwnerLabels returns the labels that record the owner of
+// the provisioned objects.
+func (i *Instance) OwnerLabels() map[string]string {
+	return map[string]string{
+		OwningKindLabel:      i.Kind,
+		OwningNameLabel:      i.Name,
+		OwningNamespaceLabel: i.Namespace,
+	}
+}
+
+// sesameSelector returns the labels that select the Sesame pods.
+func (i *Instance) sesameSelector() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":     "sesame",
+		"app.kubernetes.io/instance": i.baseName(),
+	}
+}
+
+// envoySelector returns the labels that select the Envoy pods.
+func (i *Instance) envoySelector() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":     "envoy",
+		"app.kubernetes.io/instance": i.baseName(),
+	}
+}
+
+// labels returns the owner labels merged with extra.
+func (i *Instance) labels(extra map[string]string) map[string]string {
+	labels := i.OwnerLabels()
+	for k, v := range extra {
+		labels[k] = v
+	}
+	return labels
+}
+
+// replicas returns the number of Sesame replicas.
+func (i *Instance) replicas() int32 {
+	if i.Replicas > 0 {
+		return i.Replicas
+	}
+	return defaultReplicas
+}
+
+// envoyReplicas returns the number of Envoy replicas
+// when Envoy runs as a Deployment.
+func (i *Instance) envoyReplicas() int32 {
+	if i.Envoy.Replicas > 0 {
+		return i.Envoy.Replicas
+	}
+	return defaultReplicas
+}
+
+// envoyServiceType returns the type of the Envoy Service.
+func (i *Instance) envoyServiceType() corev1.ServiceType {
+	if len(i.Envoy.ServiceType) > 0 {
+		return i.Envoy.ServiceType
+	}
+	return corev1.ServiceTypeLoadBalancer
+}
+
+// sesameImage returns the image for the Sesame containers.
+func (i *Instance) sesameImage() string {
+	if len(i.Images.Sesame) > 0 {
+		return i.Images.Sesame
+	}
+	return DefaultSesameImage
+}
+
+// envoyImage returns the image for the Envoy container.
+func (i *Instance) envoyImage() string {
+	if len(i.Images.Envoy) > 0 {
+		return i.Images.Envoy
+	}
+	return DefaultEnvoyImage
+}
+
+// Objects returns the namespaced objects that make up the instance.
+// Certificates must exist before the workloads can start, so the
+// certgen objects come first.
+func Objects(i *Instance) []client.Object {
+	objects := certgenObjects(i)
+	objects = append(objects,
+		sesameServiceAccount(i),
+		envoyServiceAccount(i),
+		sesameRole(i),
+		sesameRoleBinding(i),
+		sesameConfiguration(i),
+		sesameService(i),
+		envoyService(i),
+		sesameDeployment(i),
+		envoyWorkload(i),
+	)
+
+	return objects
+}
+
+// ClusterObjects returns the cluster-scoped objects that make up
+// the instance. These cannot be garbage collected through owner
+// references, so they must be deleted explicitly.
+func ClusterObjects(i *Instance) []client.Object {
+	return []client.Object{
+		sesameClusterRole(i),
+		sesameClusterRoleBinding(i),
+	}
+}
+
+// StaleObjects returns the objects that an earlier version of the
+// instance may have created but which it no longer needs, such as
+// the Envoy DaemonSet after switching Envoy to a Deployment.
+func StaleObjects(i *Instance) []client.Object {
+	meta := metav1.ObjectMeta{
+		Name:      i.EnvoyName(),
+		Namespace: i.Namespace,
+	}
+
+	if i.Envoy.WorkloadType == sesame_api_v1alpha1.WorkloadTypeDeployment {
+		return []client.Object{&appsv1.DaemonSet{ObjectMeta: meta}}
+	}
+	return []client.Object{&appsv1.Deployment{ObjectMeta: meta}}
+}
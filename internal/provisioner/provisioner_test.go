@@ -0,0 +1,295 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"context"
+	"io/ioutil"
+	"testing"
+
+	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
+	"github.com/projectsesame/sesame/internal/k8s"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+)
+
+func testInstance() *Instance {
+	return &Instance{
+		Name:      "example",
+		Namespace: "projectsesame",
+	}
+}
+
+func TestObjects(t *testing.T) {
+	instance := testInstance()
+	objects := Objects(instance)
+
+	names := map[string]bool{}
+	for _, obj := range objects {
+		assert.Equal(t, "projectsesame", obj.GetNamespace(), "%T %s", obj, obj.GetName())
+		assert.Equal(t, "example", obj.GetLabels()[OwningNameLabel], "%T %s", obj, obj.GetName())
+		assert.Equal(t, "projectsesame", obj.GetLabels()[OwningNamespaceLabel], "%T %s", obj, obj.GetName())
+		names[obj.GetName()] = true
+	}
+	assert.Equal(t, map[string]bool{
+		"sesame-example":         true,
+		"envoy-example":          true,
+		"sesame-certgen-example": true,
+	}, names)
+
+	// The certgen Job comes before the workloads that need its Secrets.
+	_, ok := objects[3].(*batchv1.Job)
+	assert.True(t, ok)
+
+	for _, obj := range ClusterObjects(instance) {
+		assert.Empty(t, obj.GetNamespace())
+		assert.Equal(t, "sesame-projectsesame-example", obj.GetName())
+		assert.Equal(t, instance.OwnerLabels(), obj.GetLabels())
+	}
+
+	// Objects provisioned for Gateways don't clash with
+	// those of a SesameDeployment of the same name.
+	instance.Kind = KindGateway
+	assert.Equal(t, "sesame-gateway-example", instance.SesameName())
+	assert.Equal(t, "envoy-gateway-example", instance.EnvoyName())
+	assert.Equal(t, "sesame-projectsesame-gateway-example", instance.ClusterRoleName())
+	assert.Equal(t, KindGateway, instance.OwnerLabels()[OwningKindLabel])
+}
+
+// TestRulesMatchExample checks that the provisioned Sesame is granted
+// every permission of the example ClusterRole, which is generated from
+// the kubebuilder RBAC tags.
+func TestRulesMatchExample(t *testing.T) {
+	data, err := ioutil.ReadFile("../../examples/sesame/02-role-sesame.yaml")
+	require.NoError(t, err)
+
+	var example rbacv1.ClusterRole
+	require.NoError(t, yaml.Unmarshal(data, &example))
+	require.NotEmpty(t, example.Rules)
+
+	allowed := func(group, resource, verb string) bool {
+		for _, rule := range append(append([]rbacv1.PolicyRule{}, sesameClusterRules...), sesameRules...) {
+			if contains(rule.APIGroups, group) && contains(rule.Resources, resource) && contains(rule.Verbs, verb) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, rule := range example.Rules {
+		for _, group := range rule.APIGroups {
+			for _, resource := range rule.Resources {
+				for _, verb := range rule.Verbs {
+					assert.Truef(t, allowed(group, resource, verb), "%s %s/%s is not allowed", verb, group, resource)
+				}
+			}
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func TestEnvoyWorkload(t *testing.T) {
+	instance := testInstance()
+
+	ds, ok := envoyWorkload(instance).(*appsv1.DaemonSet)
+	require.True(t, ok)
+	assert.Equal(t, "envoy-example", ds.Name)
+	assert.Equal(t, instance.envoySelector(), ds.Spec.Selector.MatchLabels)
+	assert.IsType(t, &appsv1.Deployment{}, StaleObjects(instance)[0])
+
+	instance.Envoy = sesame_api_v1alpha1.EnvoySettings{
+		WorkloadType: sesame_api_v1alpha1.WorkloadTypeDeployment,
+		Replicas:     3,
+	}
+	deploy, ok := envoyWorkload(instance).(*appsv1.Deployment)
+	require.True(t, ok)
+	assert.Equal(t, int32(3), *deploy.Spec.Replicas)
+	assert.IsType(t, &appsv1.DaemonSet{}, StaleObjects(instance)[0])
+}
+
+func TestEnvoyBootstrapOverloadManager(t *testing.T) {
+	instance := testInstance()
+	args := envoyPodTemplate(instance).Spec.InitContainers[0].Args
+	for _, arg := range args {
+		assert.NotContains(t, arg, "--overload-")
+	}
+
+	instance.Config.Envoy.OverloadManager = &sesame_api_v1alpha1.OverloadManagerParameters{
+		MaxHeapSizeBytes:                 1 << 30,
+		MaxActiveDownstreamConnections:   10000,
+		ShrinkHeapThresholdPercent:       95,
+		DisableKeepaliveThresholdPercent: 90,
+	}
+	args = envoyPodTemplate(instance).Spec.InitContainers[0].Args
+	assert.Equal(t, []string{
+		"--overload-max-heap-size-bytes=1073741824",
+		"--overload-max-active-downstream-connections=10000",
+		"--overload-shrink-heap-threshold=0.95",
+		"--overload-disable-keepalive-threshold=0.9",
+	}, args[len(args)-4:])
+}
+
+func TestEnvoyService(t *testing.T) {
+	instance := testInstance()
+
+	svc := envoyService(instance)
+	assert.Equal(t, corev1.ServiceTypeLoadBalancer, svc.Spec.Type)
+	assert.Equal(t, corev1.ServiceExternalTrafficPolicyTypeLocal, svc.Spec.ExternalTrafficPolicy)
+	assert.Equal(t, 8080, svc.Spec.Ports[0].TargetPort.IntValue())
+	assert.Equal(t, 8443, svc.Spec.Ports[1].TargetPort.IntValue())
+
+	instance.Envoy.ServiceType = corev1.ServiceTypeClusterIP
+	instance.Config.Envoy.HTTPListener.Port = 9080
+	svc = envoyService(instance)
+	assert.Equal(t, corev1.ServiceTypeClusterIP, svc.Spec.Type)
+	assert.Empty(t, svc.Spec.ExternalTrafficPolicy)
+	assert.Equal(t, 9080, svc.Spec.Ports[0].TargetPort.IntValue())
+
+	// Gateway listener ports are forwarded to the Envoy listeners that
+	// serve them. Port 80 has a listener of its own on port 8080, since
+	// Envoy's HTTP listener binds to port 9080, and port 9080 is skipped
+	// for HTTPS since Envoy's HTTP listener serves it.
+	instance.HTTPPorts = []int32{80, 8081}
+	instance.HTTPSPorts = []int32{9080, 443}
+	svc = envoyService(instance)
+	assert.Equal(t, []corev1.ServicePort{{
+		Name:       "http",
+		Protocol:   corev1.ProtocolTCP,
+		Port:       80,
+		TargetPort: intstr.FromInt(8080),
+	}, {
+		Name:       "http-8081",
+		Protocol:   corev1.ProtocolTCP,
+		Port:       8081,
+		TargetPort: intstr.FromInt(8081),
+	}, {
+		Name:       "https",
+		Protocol:   corev1.ProtocolTCP,
+		Port:       443,
+		TargetPort: intstr.FromInt(8443),
+	}}, svc.Spec.Ports)
+
+	// HTTP/3 is served over UDP on the HTTPS listener's port.
+	instance.Config.Envoy.HTTP3 = &sesame_api_v1alpha1.HTTP3Parameters{}
+	svc = envoyService(instance)
+	require.Len(t, svc.Spec.Ports, 4)
+	assert.Equal(t, corev1.ServicePort{
+		Name:       "http3",
+		Protocol:   corev1.ProtocolUDP,
+		Port:       443,
+		TargetPort: intstr.FromInt(8443),
+	}, svc.Spec.Ports[3])
+
+	// The Envoy container exposes each target port once per protocol.
+	var envoy corev1.Container
+	for _, c := range envoyPodTemplate(instance).Spec.Containers {
+		if c.Name == "envoy" {
+			envoy = c
+		}
+	}
+	assert.Equal(t, []corev1.ContainerPort{
+		{Name: "http", ContainerPort: 8080, Protocol: corev1.ProtocolTCP},
+		{Name: "http-8081", ContainerPort: 8081, Protocol: corev1.ProtocolTCP},
+		{Name: "https", ContainerPort: 8443, Protocol: corev1.ProtocolTCP},
+		{Name: "http3", ContainerPort: 8443, Protocol: corev1.ProtocolUDP},
+	}, envoy.Ports)
+}
+
+func TestSesameConfiguration(t *testing.T) {
+	instance := testInstance()
+	instance.Config.XDSServer.Port = 1234
+	instance.Config.CertificateRotation = &sesame_api_v1alpha1.CertificateRotationConfig{
+		CASecretName: "sesame-ca",
+		SecretNames:  []string{"sesamecert", "envoycert"},
+	}
+
+	config := sesameConfiguration(instance)
+	assert.Equal(t, "sesame-example", config.Name)
+	assert.Equal(t, xdsPort, config.Spec.XDSServer.Port)
+	assert.Equal(t, "/certs/tls.crt", config.Spec.XDSServer.TLS.CertFile)
+	assert.Equal(t, sesame_api_v1alpha1.NamespacedName{Name: "envoy-example", Namespace: "projectsesame"}, config.Spec.Envoy.Service)
+	assert.Equal(t, "projectsesame", config.Spec.CertificateRotation.Namespace)
+	assert.Equal(t, []string{"sesamecert-example", "envoycert-example"}, config.Spec.CertificateRotation.SecretNames)
+
+	// Required fields the configuration leaves unset are defaulted.
+	assert.Equal(t, sesame_api_v1alpha1.SesameServerType, config.Spec.XDSServer.Type)
+	assert.Equal(t, sesame_api_v1alpha1.AutoClusterDNSFamily, config.Spec.Envoy.Cluster.DNSLookupFamily)
+	assert.Equal(t, sesame_api_v1alpha1.EnvoyAccessLog, config.Spec.Envoy.Logging.AccessLogFormat)
+	assert.Equal(t, 8080, config.Spec.Envoy.HTTPListener.Port)
+	assert.Equal(t, "/dev/stdout", config.Spec.Envoy.HTTPSListener.AccessLog)
+	assert.Equal(t, 9001, config.Spec.Envoy.Network.EnvoyAdminPort)
+
+	// The instance's configuration is not modified.
+	assert.Equal(t, 1234, instance.Config.XDSServer.Port)
+
+	job := certgenJob(instance)
+	assert.Contains(t, job.Spec.Template.Spec.Containers[0].Command, "--secrets-name-suffix=-example")
+	assert.Contains(t, job.Spec.Template.Spec.Containers[0].Command, "--ca-secret-name=sesame-ca")
+}
+
+func TestEnsure(t *testing.T) {
+	scheme, err := k8s.NewSesameScheme()
+	require.NoError(t, err)
+
+	instance := testInstance()
+	cli := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	// Objects are created when they don't exist.
+	for _, obj := range Objects(instance) {
+		require.NoError(t, Ensure(context.Background(), cli, obj))
+	}
+
+	// The API server allocates the cluster IP and node ports.
+	svc := &corev1.Service{}
+	require.NoError(t, cli.Get(context.Background(), client.ObjectKeyFromObject(envoyService(instance)), svc))
+	svc.Spec.ClusterIP = "10.0.0.1"
+	svc.Spec.Ports[0].NodePort = 30080
+	svc.Labels["user"] = "label"
+	require.NoError(t, cli.Update(context.Background(), svc))
+
+	// Updating the Service keeps the allocated fields and unmanaged labels.
+	instance.Config.Envoy.HTTPSListener.Port = 9443
+	require.NoError(t, Ensure(context.Background(), cli, envoyService(instance)))
+	require.NoError(t, cli.Get(context.Background(), client.ObjectKeyFromObject(svc), svc))
+	assert.Equal(t, "10.0.0.1", svc.Spec.ClusterIP)
+	assert.Equal(t, int32(30080), svc.Spec.Ports[0].NodePort)
+	assert.Equal(t, 9443, svc.Spec.Ports[1].TargetPort.IntValue())
+	assert.Equal(t, "label", svc.Labels["user"])
+
+	// Updating the Deployment changes its replicas.
+	instance.Replicas = 5
+	require.NoError(t, Ensure(context.Background(), cli, sesameDeployment(instance)))
+	deploy := &appsv1.Deployment{}
+	require.NoError(t, cli.Get(context.Background(), client.ObjectKeyFromObject(sesameDeployment(instance)), deploy))
+	assert.Equal(t, int32(5), *deploy.Spec.Replicas)
+
+	// Deleting a missing object is not an error.
+	require.NoError(t, Delete(context.Background(), cli, StaleObjects(instance)[0]))
+}
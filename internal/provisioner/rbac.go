@@ -0,0 +1,167 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	readVerbs   = []string{"get", "list", "watch"}
+	statusVerbs = []string{"create", "get", "update"}
+)
+
+// sesameClusterRules are the cluster-wide permissions Sesame needs
+// to watch the resources it builds configuration from.
+var sesameClusterRules = []rbacv1.PolicyRule{{
+	APIGroups: []string{""},
+	Resources: []string{"configmaps", "endpoints", "namespaces", "secrets", "services"},
+	Verbs:     readVerbs,
+}, {
+	APIGroups: []string{"networking.k8s.io"},
+	Resources: []string{"ingresses", "ingressclasses"},
+	Verbs:     readVerbs,
+}, {
+	APIGroups: []string{"networking.k8s.io"},
+	Resources: []string{"ingresses/status"},
+	Verbs:     statusVerbs,
+}, {
+	APIGroups: []string{"projectsesame.io"},
+	Resources: []string{"extensionservices", "gatewayclassparameters", "httpproxies", "servicereferencegrants", "sesameconfigurations", "tlscertificatedelegations"},
+	Verbs:     readVerbs,
+}, {
+	APIGroups: []string{"projectsesame.io"},
+	Resources: []string{"extensionservices/status", "httpproxies/status", "sesameconfigurations/status", "tlscertificatedelegations/status"},
+	Verbs:     statusVerbs,
+}, {
+	APIGroups: []string{"gateway.networking.k8s.io"},
+	Resources: []string{"gatewayclasses", "gateways", "httproutes", "referencepolicies", "tlsroutes"},
+	Verbs:     readVerbs,
+}, {
+	APIGroups: []string{"gateway.networking.k8s.io"},
+	Resources: []string{"gatewayclasses/status", "gateways/status", "httproutes/status", "tlsroutes/status"},
+	Verbs:     []string{"update"},
+}}
+
+// sesameRules are the permissions Sesame needs in its own
+// namespace for leader election and certificate rotation.
+var sesameRules = []rbacv1.PolicyRule{{
+	APIGroups: []string{""},
+	Resources: []string{"configmaps", "events"},
+	Verbs:     []string{"create", "get", "update"},
+}, {
+	APIGroups: []string{"coordination.k8s.io"},
+	Resources: []string{"leases"},
+	Verbs:     []string{"create", "get", "update"},
+}, {
+	APIGroups: []string{""},
+	Resources: []string{"secrets"},
+	Verbs:     []string{"get", "update"},
+}}
+
+// certgenRules are the permissions certgen needs to
+// write the generated Secrets.
+var certgenRules = []rbacv1.PolicyRule{{
+	APIGroups: []string{""},
+	Resources: []string{"secrets"},
+	Verbs:     []string{"create", "update"},
+}}
+
+func serviceAccount(i *Instance, name string) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: i.Namespace,
+			Labels:    i.OwnerLabels(),
+		},
+	}
+}
+
+func role(i *Instance, name string, rules []rbacv1.PolicyRule) *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: i.Namespace,
+			Labels:    i.OwnerLabels(),
+		},
+		Rules: rules,
+	}
+}
+
+func roleBinding(i *Instance, name string) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: i.Namespace,
+			Labels:    i.OwnerLabels(),
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     name,
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      name,
+			Namespace: i.Namespace,
+		}},
+	}
+}
+
+func sesameServiceAccount(i *Instance) *corev1.ServiceAccount {
+	return serviceAccount(i, i.SesameName())
+}
+
+func envoyServiceAccount(i *Instance) *corev1.ServiceAccount {
+	return serviceAccount(i, i.EnvoyName())
+}
+
+func sesameRole(i *Instance) *rbacv1.Role {
+	return role(i, i.SesameName(), sesameRules)
+}
+
+func sesameRoleBinding(i *Instance) *rbacv1.RoleBinding {
+	return roleBinding(i, i.SesameName())
+}
+
+func sesameClusterRole(i *Instance) *rbacv1.ClusterRole {
+	return &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   i.ClusterRoleName(),
+			Labels: i.OwnerLabels(),
+		},
+		Rules: sesameClusterRules,
+	}
+}
+
+func sesameClusterRoleBinding(i *Instance) *rbacv1.ClusterRoleBinding {
+	return &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   i.ClusterRoleName(),
+			Labels: i.OwnerLabels(),
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     i.ClusterRoleName(),
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      i.SesameName(),
+			Namespace: i.Namespace,
+		}},
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"fmt"
+
+	"github.com/projectsesame/sesame/internal/gatewayapi"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	gatewayapi_v1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+// sesameService returns the Service Envoy connects to for xDS.
+func sesameService(i *Instance) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      i.SesameName(),
+			Namespace: i.Namespace,
+			Labels:    i.OwnerLabels(),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: i.sesameSelector(),
+			Ports: []corev1.ServicePort{{
+				Name:       "xds",
+				Protocol:   corev1.ProtocolTCP,
+				Port:       xdsPort,
+				TargetPort: intstr.FromInt(xdsPort),
+			}},
+		},
+	}
+}
+
+// envoyService returns the Service that exposes Envoy.
+func envoyService(i *Instance) *corev1.Service {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      i.EnvoyName(),
+			Namespace: i.Namespace,
+			Labels:    i.OwnerLabels(),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     i.envoyServiceType(),
+			Selector: i.envoySelector(),
+			Ports:    i.envoyServicePorts(),
+		},
+	}
+
+	// Preserve the client source address for Services
+	// that receive traffic from outside the cluster.
+	if svc.Spec.Type != corev1.ServiceTypeClusterIP {
+		svc.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyTypeLocal
+	}
+
+	return svc
+}
+
+// envoyServicePorts returns the ports of the Envoy Service.
+func (i *Instance) envoyServicePorts() []corev1.ServicePort {
+	ports := append(
+		servicePorts("http", i.HTTPPorts, 80, i.envoyHTTPPort(), i.envoyHTTPSPort()),
+		servicePorts("https", i.HTTPSPorts, 443, i.envoyHTTPSPort(), i.envoyHTTPPort())...,
+	)
+
+	// HTTP/3 clients connect to the advertised port over UDP.
+	if h := i.Config.Envoy.HTTP3; h != nil {
+		ports = append(ports, corev1.ServicePort{
+			Name:       "http3",
+			Protocol:   corev1.ProtocolUDP,
+			Port:       int32(intOrDefault(h.AdvertisedPort, 443)),
+			TargetPort: intstr.FromInt(intOrDefault(h.Port, i.envoyHTTPSPort())),
+		})
+	}
+
+	return ports
+}
+
+// servicePorts returns a Service port for each of ports, or for defaultPort
+// if there are none. Each port forwards to the Envoy listener that the
+// provisioned Sesame serves the Gateway listeners on the port from, which
+// is Envoy's listener on gatewayapi.EnvoyListenerPort, and defaultPort
+// forwards to targetPort. Ports whose Envoy listener is otherTargetPort
+// are skipped, since it serves another protocol and the provisioned Sesame
+// rejects their Gateway listeners. The first port is called name, and the
+// others are called name-<port>, so that the node port allocated to the
+// first port is kept when more ports are added.
+func servicePorts(name string, ports []int32, defaultPort int32, targetPort, otherTargetPort int) []corev1.ServicePort {
+	if len(ports) == 0 {
+		return []corev1.ServicePort{{
+			Name:       name,
+			Protocol:   corev1.ProtocolTCP,
+			Port:       defaultPort,
+			TargetPort: intstr.FromInt(targetPort),
+		}}
+	}
+
+	var servicePorts []corev1.ServicePort
+	for _, port := range ports {
+		envoyPort := gatewayapi.EnvoyListenerPort(gatewayapi_v1alpha2.PortNumber(port))
+		if envoyPort == otherTargetPort {
+			continue
+		}
+
+		portName := name
+		if len(servicePorts) > 0 {
+			portName = fmt.Sprintf("%s-%d", name, port)
+		}
+		servicePorts = append(servicePorts, corev1.ServicePort{
+			Name:       portName,
+			Protocol:   corev1.ProtocolTCP,
+			Port:       port,
+			TargetPort: intstr.FromInt(envoyPort),
+		})
+	}
+
+	return servicePorts
+}
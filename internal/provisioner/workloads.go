@@ -0,0 +1,438 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioner
+
+import (
+	"fmt"
+	"strconv"
+
+	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/pointer"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func intOrDefault(val, def int) int {
+	if val > 0 {
+		return val
+	}
+	return def
+}
+
+// envoyHTTPPort returns the port Envoy's HTTP listener binds to.
+func (i *Instance) envoyHTTPPort() int {
+	return intOrDefault(i.Config.Envoy.HTTPListener.Port, 8080)
+}
+
+// envoyHTTPSPort returns the port Envoy's HTTPS listener binds to.
+func (i *Instance) envoyHTTPSPort() int {
+	return intOrDefault(i.Config.Envoy.HTTPSListener.Port, 8443)
+}
+
+// envoyContainerPorts returns the ports of the Envoy container,
+// which are the target ports of the Envoy Service.
+func envoyContainerPorts(i *Instance) []corev1.ContainerPort {
+	type containerPort struct {
+		port     int32
+		protocol corev1.Protocol
+	}
+
+	var ports []corev1.ContainerPort
+	exposed := map[containerPort]bool{}
+	for _, sp := range i.envoyServicePorts() {
+		cp := containerPort{port: sp.TargetPort.IntVal, protocol: sp.Protocol}
+		if exposed[cp] {
+			continue
+		}
+		exposed[cp] = true
+
+		ports = append(ports, corev1.ContainerPort{
+			Name:          sp.Name,
+			ContainerPort: cp.port,
+			Protocol:      cp.protocol,
+		})
+	}
+
+	return ports
+}
+
+// overloadManagerArgs returns the arguments of "sesame bootstrap"
+// that configure the provided overload manager settings.
+func overloadManagerArgs(om *sesame_api_v1alpha1.OverloadManagerParameters) []string {
+	if om == nil {
+		return nil
+	}
+
+	var args []string
+	if om.MaxHeapSizeBytes > 0 {
+		args = append(args, fmt.Sprintf("--overload-max-heap-size-bytes=%d", om.MaxHeapSizeBytes))
+	}
+	if om.MaxActiveDownstreamConnections > 0 {
+		args = append(args, fmt.Sprintf("--overload-max-active-downstream-connections=%d", om.MaxActiveDownstreamConnections))
+	}
+
+	// The thresholds are fractions of the maximum heap size.
+	for _, t := range []struct {
+		flag    string
+		percent uint32
+	}{
+		{"overload-shrink-heap-threshold", om.ShrinkHeapThresholdPercent},
+		{"overload-disable-keepalive-threshold", om.DisableKeepaliveThresholdPercent},
+		{"overload-stop-accepting-requests-threshold", om.StopAcceptingRequestsThresholdPercent},
+		{"overload-stop-accepting-connections-threshold", om.StopAcceptingConnectionsThresholdPercent},
+	} {
+		if t.percent > 0 {
+			args = append(args, fmt.Sprintf("--%s=%s", t.flag, strconv.FormatFloat(float64(t.percent)/100, 'f', -1, 64)))
+		}
+	}
+
+	return args
+}
+
+// namespaceEnv returns an environment variable holding
+// the namespace of the pod.
+func namespaceEnv(name string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{
+				APIVersion: "v1",
+				FieldPath:  "metadata.namespace",
+			},
+		},
+	}
+}
+
+// podNameEnv returns an environment variable holding
+// the name of the pod.
+func podNameEnv(name string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{
+				APIVersion: "v1",
+				FieldPath:  "metadata.name",
+			},
+		},
+	}
+}
+
+// secretVolume returns a volume for the given Secret.
+func secretVolume(name, secretName string) corev1.Volume {
+	return corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: secretName,
+			},
+		},
+	}
+}
+
+// emptyDirVolume returns a volume backed by an empty directory.
+func emptyDirVolume(name string) corev1.Volume {
+	return corev1.Volume{
+		Name: name,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	}
+}
+
+// sesameDeployment returns the Deployment that runs Sesame.
+func sesameDeployment(i *Instance) *appsv1.Deployment {
+	selector := i.sesameSelector()
+	healthPort := intOrDefault(i.Config.Health.Port, metricsPort)
+	maxSurge := intstr.FromString("50%")
+
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      i.SesameName(),
+			Namespace: i.Namespace,
+			Labels:    i.labels(selector),
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: pointer.Int32(i.replicas()),
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RollingUpdateDeploymentStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateDeployment{
+					MaxSurge: &maxSurge,
+				},
+			},
+			Selector: &metav1.LabelSelector{
+				MatchLabels: selector,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{
+						"prometheus.io/scrape": "true",
+						"prometheus.io/port":   strconv.Itoa(intOrDefault(i.Config.Metrics.Port, metricsPort)),
+					},
+					Labels: selector,
+				},
+				Spec: corev1.PodSpec{
+					Affinity: &corev1.Affinity{
+						PodAntiAffinity: &corev1.PodAntiAffinity{
+							PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{{
+								Weight: 100,
+								PodAffinityTerm: corev1.PodAffinityTerm{
+									LabelSelector: &metav1.LabelSelector{
+										MatchLabels: selector,
+									},
+									TopologyKey: corev1.LabelHostname,
+								},
+							}},
+						},
+					},
+					Containers: []corev1.Container{{
+						Name:            "sesame",
+						Image:           i.sesameImage(),
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Command:         []string{"sesame"},
+						Args: []string{
+							"serve",
+							"--incluster",
+							"--sesame-config-name=" + i.SesameName(),
+							"--leader-election-resource-name=" + i.SesameName(),
+							"--leader-election-resource-namespace=$(SESAME_NAMESPACE)",
+						},
+						Env: []corev1.EnvVar{
+							namespaceEnv("SESAME_NAMESPACE"),
+							podNameEnv("POD_NAME"),
+						},
+						Ports: []corev1.ContainerPort{{
+							Name:          "xds",
+							ContainerPort: xdsPort,
+							Protocol:      corev1.ProtocolTCP,
+						}, {
+							Name:          "metrics",
+							ContainerPort: int32(intOrDefault(i.Config.Metrics.Port, metricsPort)),
+							Protocol:      corev1.ProtocolTCP,
+						}},
+						LivenessProbe: &corev1.Probe{
+							Handler: corev1.Handler{
+								HTTPGet: &corev1.HTTPGetAction{
+									Path: "/healthz",
+									Port: intstr.FromInt(healthPort),
+								},
+							},
+						},
+						ReadinessProbe: &corev1.Probe{
+							Handler: corev1.Handler{
+								TCPSocket: &corev1.TCPSocketAction{
+									Port: intstr.FromInt(xdsPort),
+								},
+							},
+							InitialDelaySeconds: 15,
+							PeriodSeconds:       10,
+						},
+						VolumeMounts: []corev1.VolumeMount{{
+							Name:      "sesamecert",
+							MountPath: certsDir,
+							ReadOnly:  true,
+						}},
+					}},
+					DNSPolicy:          corev1.DNSClusterFirst,
+					ServiceAccountName: i.SesameName(),
+					SecurityContext:    podSecurityContext(),
+					Volumes: []corev1.Volume{
+						secretVolume("sesamecert", sesameSecretName+i.SecretNameSuffix()),
+					},
+				},
+			},
+		},
+	}
+}
+
+// envoyWorkload returns the DaemonSet or Deployment that runs Envoy.
+func envoyWorkload(i *Instance) client.Object {
+	selector := i.envoySelector()
+	meta := metav1.ObjectMeta{
+		Name:      i.EnvoyName(),
+		Namespace: i.Namespace,
+		Labels:    i.labels(selector),
+	}
+
+	if i.Envoy.WorkloadType == sesame_api_v1alpha1.WorkloadTypeDeployment {
+		return &appsv1.Deployment{
+			ObjectMeta: meta,
+			Spec: appsv1.DeploymentSpec{
+				Replicas: pointer.Int32(i.envoyReplicas()),
+				Strategy: appsv1.DeploymentStrategy{
+					Type: appsv1.RollingUpdateDeploymentStrategyType,
+				},
+				Selector: &metav1.LabelSelector{
+					MatchLabels: selector,
+				},
+				Template: envoyPodTemplate(i),
+			},
+		}
+	}
+
+	maxUnavailable := intstr.FromString("10%")
+
+	return &appsv1.DaemonSet{
+		ObjectMeta: meta,
+		Spec: appsv1.DaemonSetSpec{
+			UpdateStrategy: appsv1.DaemonSetUpdateStrategy{
+				Type: appsv1.RollingUpdateDaemonSetStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateDaemonSet{
+					MaxUnavailable: &maxUnavailable,
+				},
+			},
+			Selector: &metav1.LabelSelector{
+				MatchLabels: selector,
+			},
+			Template: envoyPodTemplate(i),
+		},
+	}
+}
+
+// envoyPodTemplate returns the pod template for Envoy. An init container
+// writes the bootstrap configuration pointing Envoy at the Sesame Service,
+// and the shutdown manager drains Envoy before the pod is terminated.
+func envoyPodTemplate(i *Instance) corev1.PodTemplateSpec {
+	adminPort := intOrDefault(i.Config.Envoy.Health.Port, envoyAdminPort)
+
+	return corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				"prometheus.io/scrape": "true",
+				"prometheus.io/port":   strconv.Itoa(intOrDefault(i.Config.Envoy.Metrics.Port, envoyAdminPort)),
+				"prometheus.io/path":   "/stats/prometheus",
+			},
+			Labels: i.envoySelector(),
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:            "shutdown-manager",
+				Image:           i.sesameImage(),
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				Command:         []string{"/bin/sesame"},
+				Args:            []string{"envoy", "shutdown-manager"},
+				Lifecycle: &corev1.Lifecycle{
+					PreStop: &corev1.Handler{
+						Exec: &corev1.ExecAction{
+							Command: []string{"/bin/sesame", "envoy", "shutdown"},
+						},
+					},
+				},
+				LivenessProbe: &corev1.Probe{
+					Handler: corev1.Handler{
+						HTTPGet: &corev1.HTTPGetAction{
+							Path: "/healthz",
+							Port: intstr.FromInt(shutdownManagerPort),
+						},
+					},
+					InitialDelaySeconds: 3,
+					PeriodSeconds:       10,
+				},
+				VolumeMounts: []corev1.VolumeMount{{
+					Name:      "envoy-admin",
+					MountPath: "/admin",
+				}},
+			}, {
+				Name:            "envoy",
+				Image:           i.envoyImage(),
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				Command:         []string{"envoy"},
+				Args: []string{
+					"-c",
+					"/config/envoy.json",
+					"--service-cluster $(SESAME_NAMESPACE)",
+					"--service-node $(ENVOY_POD_NAME)",
+					"--log-level info",
+				},
+				Env: []corev1.EnvVar{
+					namespaceEnv("SESAME_NAMESPACE"),
+					podNameEnv("ENVOY_POD_NAME"),
+				},
+				Ports: envoyContainerPorts(i),
+				ReadinessProbe: &corev1.Probe{
+					Handler: corev1.Handler{
+						HTTPGet: &corev1.HTTPGetAction{
+							Path: "/ready",
+							Port: intstr.FromInt(adminPort),
+						},
+					},
+					InitialDelaySeconds: 3,
+					PeriodSeconds:       4,
+				},
+				Lifecycle: &corev1.Lifecycle{
+					PreStop: &corev1.Handler{
+						HTTPGet: &corev1.HTTPGetAction{
+							Path:   "/shutdown",
+							Port:   intstr.FromInt(shutdownManagerPort),
+							Scheme: corev1.URISchemeHTTP,
+						},
+					},
+				},
+				VolumeMounts: []corev1.VolumeMount{{
+					Name:      "envoy-config",
+					MountPath: "/config",
+					ReadOnly:  true,
+				}, {
+					Name:      "envoycert",
+					MountPath: certsDir,
+					ReadOnly:  true,
+				}, {
+					Name:      "envoy-admin",
+					MountPath: "/admin",
+				}},
+			}},
+			InitContainers: []corev1.Container{{
+				Name:            "envoy-initconfig",
+				Image:           i.sesameImage(),
+				ImagePullPolicy: corev1.PullIfNotPresent,
+				Command:         []string{"sesame"},
+				Args: append([]string{
+					"bootstrap",
+					"/config/envoy.json",
+					"--xds-address=" + i.SesameName(),
+					fmt.Sprintf("--xds-port=%d", xdsPort),
+					"--xds-resource-version=v3",
+					"--resources-dir=/config/resources",
+					"--envoy-cafile=" + certsDir + "/ca.crt",
+					"--envoy-cert-file=" + certsDir + "/tls.crt",
+					"--envoy-key-file=" + certsDir + "/tls.key",
+					"--namespace=$(SESAME_NAMESPACE)",
+				}, overloadManagerArgs(i.Config.Envoy.OverloadManager)...),
+				Env: []corev1.EnvVar{
+					namespaceEnv("SESAME_NAMESPACE"),
+				},
+				VolumeMounts: []corev1.VolumeMount{{
+					Name:      "envoy-config",
+					MountPath: "/config",
+				}, {
+					Name:      "envoycert",
+					MountPath: certsDir,
+					ReadOnly:  true,
+				}},
+			}},
+			AutomountServiceAccountToken:  pointer.Bool(false),
+			ServiceAccountName:            i.EnvoyName(),
+			TerminationGracePeriodSeconds: pointer.Int64(300),
+			RestartPolicy:                 corev1.RestartPolicyAlways,
+			SecurityContext:               podSecurityContext(),
+			Volumes: []corev1.Volume{
+				emptyDirVolume("envoy-admin"),
+				emptyDirVolume("envoy-config"),
+				secretVolume("envoycert", envoySecretName+i.SecretNameSuffix()),
+			},
+		},
+	}
+}
@@ -0,0 +1,192 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Config maps rate limit descriptors to limits for a single domain.
+type Config struct {
+	// Domain is the rate limit domain that this configuration
+	// applies to. It must match the domain configured on the
+	// Envoy rate limit filter.
+	Domain string `json:"domain"`
+
+	// Limits is the list of descriptors and their limits.
+	Limits []Limit `json:"limits"`
+}
+
+// Limit is the number of requests per unit of time that are allowed
+// for requests that generate a matching descriptor.
+type Limit struct {
+	// Descriptor is the descriptor that requests are matched against,
+	// using the same entries as an HTTPProxy RateLimitPolicy. An
+	// entry matches if the request descriptor has an entry with the
+	// same key and, for entries with a static value (genericKey,
+	// requestHeaderValueMatch and queryParameterValueMatch), the same value.
+	Descriptor sesame_api_v1.RateLimitDescriptor `json:"descriptor"`
+
+	// Requests defines how many requests per unit of time should
+	// be allowed before rate limiting occurs.
+	Requests uint32 `json:"requests"`
+
+	// Unit defines the period of time within which requests over
+	// the limit will be rate limited. Valid values are "second",
+	// "minute", "hour" and "day".
+	Unit string `json:"unit"`
+}
+
+// Parse reads a Config from the YAML document in r and validates it.
+func Parse(r io.Reader) (*Config, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg Config
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse rate limit configuration: %v", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// ParseFile reads a Config from the YAML file at path.
+func ParseFile(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Parse(f)
+}
+
+// Validate returns an error if the Config is not valid.
+func (c *Config) Validate() error {
+	if c.Domain == "" {
+		return fmt.Errorf("rate limit configuration must specify a domain")
+	}
+
+	for i, limit := range c.Limits {
+		if _, err := limit.matchers(); err != nil {
+			return fmt.Errorf("invalid limit %d: %v", i, err)
+		}
+		if limit.Requests == 0 {
+			return fmt.Errorf("invalid limit %d: requests must be greater than zero", i)
+		}
+		if _, err := unitDuration(limit.Unit); err != nil {
+			return fmt.Errorf("invalid limit %d: %v", i, err)
+		}
+	}
+
+	return nil
+}
+
+// unitDuration returns the length of the window for unit.
+func unitDuration(unit string) (time.Duration, error) {
+	switch unit {
+	case "second":
+		return time.Second, nil
+	case "minute":
+		return time.Minute, nil
+	case "hour":
+		return time.Hour, nil
+	case "day":
+		return 24 * time.Hour, nil
+	default:
+		return 0, fmt.Errorf("invalid unit %q", unit)
+	}
+}
+
+// entryMatcher matches a single entry of a request descriptor.
+type entryMatcher struct {
+	key string
+
+	// value is the value that the entry must have. If it is
+	// empty, any value matches, and each distinct value is
+	// counted separately.
+	value string
+}
+
+// matchers returns the entry matchers for the limit's descriptor, using
+// the descriptor keys that Envoy generates for each type of entry.
+func (l *Limit) matchers() ([]entryMatcher, error) {
+	if len(l.Descriptor.Entries) == 0 {
+		return nil, fmt.Errorf("descriptor must have at least one entry")
+	}
+
+	var matchers []entryMatcher
+	for _, entry := range l.Descriptor.Entries {
+		var (
+			m   entryMatcher
+			set int
+		)
+
+		if entry.GenericKey != nil {
+			set++
+			m = entryMatcher{key: entry.GenericKey.Key, value: entry.GenericKey.Value}
+			if m.key == "" {
+				m.key = "generic_key"
+			}
+		}
+		if entry.RequestHeader != nil {
+			set++
+			m = entryMatcher{key: entry.RequestHeader.DescriptorKey}
+		}
+		if entry.RequestHeaderValueMatch != nil {
+			set++
+			m = entryMatcher{key: "header_match", value: entry.RequestHeaderValueMatch.Value}
+		}
+		if entry.RemoteAddress != nil {
+			set++
+			m = entryMatcher{key: "remote_address"}
+		}
+		if entry.QueryParameterValueMatch != nil {
+			set++
+			m = entryMatcher{key: "header_match", value: entry.QueryParameterValueMatch.Value}
+		}
+		if entry.Metadata != nil {
+			set++
+			m = entryMatcher{key: entry.Metadata.DescriptorKey}
+		}
+		if entry.MaskedRemoteAddress != nil {
+			set++
+			m = entryMatcher{key: "masked_remote_address"}
+		}
+
+		if set != 1 {
+			return nil, fmt.Errorf("rate limit descriptor entry must have exactly one field set")
+		}
+		if m.key == "" {
+			return nil, fmt.Errorf("rate limit descriptor entry must have a descriptor key")
+		}
+
+		matchers = append(matchers, m)
+	}
+
+	return matchers, nil
+}
@@ -0,0 +1,71 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Counter counts hits against a key in fixed windows of time.
+type Counter interface {
+	// Increment adds hits to the count for key in the window of
+	// length window that contains now, and returns the new count
+	// and the time at which the window ends.
+	Increment(key string, hits uint32, window time.Duration, now time.Time) (uint32, time.Time)
+}
+
+// MemoryCounter is a Counter that keeps counts in memory. The zero
+// value is ready to use.
+type MemoryCounter struct {
+	mu        sync.Mutex
+	counts    map[string]*count
+	nextSweep time.Time
+}
+
+type count struct {
+	hits  uint32
+	reset time.Time
+}
+
+// sweepInterval is how often expired counts are removed.
+const sweepInterval = time.Minute
+
+// Increment implements Counter.
+func (m *MemoryCounter) Increment(key string, hits uint32, window time.Duration, now time.Time) (uint32, time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.counts == nil {
+		m.counts = map[string]*count{}
+	}
+
+	if now.After(m.nextSweep) {
+		for k, c := range m.counts {
+			if !now.Before(c.reset) {
+				delete(m.counts, k)
+			}
+		}
+		m.nextSweep = now.Add(sweepInterval)
+	}
+
+	c, ok := m.counts[key]
+	if !ok || !now.Before(c.reset) {
+		c = &count{reset: now.Truncate(window).Add(window)}
+		m.counts[key] = c
+	}
+
+	c.hits += hits
+	return c.hits, c.reset
+}
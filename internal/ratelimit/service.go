@@ -0,0 +1,199 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ratelimit provides a reference implementation of the Envoy
+// rate limit service, for use in development and tests.
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	envoy_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	envoy_service_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Service implements the Envoy RateLimitService gRPC API.
+type Service struct {
+	envoy_service_ratelimit_v3.UnimplementedRateLimitServiceServer
+
+	logrus.FieldLogger
+
+	domain  string
+	limits  []limit
+	counter Counter
+
+	// now returns the current time. It is replaced in tests.
+	now func() time.Time
+}
+
+type limit struct {
+	matchers []entryMatcher
+	requests uint32
+	unit     envoy_service_ratelimit_v3.RateLimitResponse_RateLimit_Unit
+	window   time.Duration
+}
+
+var units = map[string]envoy_service_ratelimit_v3.RateLimitResponse_RateLimit_Unit{
+	"second": envoy_service_ratelimit_v3.RateLimitResponse_RateLimit_SECOND,
+	"minute": envoy_service_ratelimit_v3.RateLimitResponse_RateLimit_MINUTE,
+	"hour":   envoy_service_ratelimit_v3.RateLimitResponse_RateLimit_HOUR,
+	"day":    envoy_service_ratelimit_v3.RateLimitResponse_RateLimit_DAY,
+}
+
+// NewService returns a Service that applies the limits in cfg using
+// counter. If counter is nil, counts are kept in memory.
+func NewService(log logrus.FieldLogger, cfg *Config, counter Counter) (*Service, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if counter == nil {
+		counter = &MemoryCounter{}
+	}
+
+	svc := &Service{
+		FieldLogger: log,
+		domain:      cfg.Domain,
+		counter:     counter,
+		now:         time.Now,
+	}
+
+	for _, l := range cfg.Limits {
+		// Errors are checked by Validate.
+		matchers, _ := l.matchers()
+		window, _ := unitDuration(l.Unit)
+
+		svc.limits = append(svc.limits, limit{
+			matchers: matchers,
+			requests: l.Requests,
+			unit:     units[l.Unit],
+			window:   window,
+		})
+	}
+
+	return svc, nil
+}
+
+// Register registers the Service with the gRPC server.
+func (s *Service) Register(g *grpc.Server) {
+	envoy_service_ratelimit_v3.RegisterRateLimitServiceServer(g, s)
+}
+
+// ShouldRateLimit implements RateLimitServiceServer. Each request
+// descriptor is matched against the configured limits in order, and the
+// first matching limit is applied. Descriptors that do not match any
+// limit, or requests for another domain, are always allowed.
+func (s *Service) ShouldRateLimit(ctx context.Context, req *envoy_service_ratelimit_v3.RateLimitRequest) (*envoy_service_ratelimit_v3.RateLimitResponse, error) {
+	resp := &envoy_service_ratelimit_v3.RateLimitResponse{
+		OverallCode: envoy_service_ratelimit_v3.RateLimitResponse_OK,
+	}
+
+	hits := req.GetHitsAddend()
+	if hits == 0 {
+		hits = 1
+	}
+
+	now := s.now()
+
+	for _, desc := range req.GetDescriptors() {
+		status := &envoy_service_ratelimit_v3.RateLimitResponse_DescriptorStatus{
+			Code: envoy_service_ratelimit_v3.RateLimitResponse_OK,
+		}
+		resp.Statuses = append(resp.Statuses, status)
+
+		if req.GetDomain() != s.domain {
+			continue
+		}
+
+		l := s.match(desc)
+		if l == nil {
+			continue
+		}
+
+		count, reset := s.counter.Increment(counterKey(s.domain, desc), hits, l.window, now)
+
+		status.CurrentLimit = &envoy_service_ratelimit_v3.RateLimitResponse_RateLimit{
+			RequestsPerUnit: l.requests,
+			Unit:            l.unit,
+		}
+		status.DurationUntilReset = durationpb.New(reset.Sub(now))
+
+		if count > l.requests {
+			status.Code = envoy_service_ratelimit_v3.RateLimitResponse_OVER_LIMIT
+			resp.OverallCode = envoy_service_ratelimit_v3.RateLimitResponse_OVER_LIMIT
+		} else {
+			status.LimitRemaining = l.requests - count
+		}
+
+		s.WithField("domain", s.domain).
+			WithField("descriptor", counterKey(s.domain, desc)).
+			WithField("count", count).
+			WithField("code", status.Code).
+			Debug("rate limit descriptor")
+	}
+
+	return resp, nil
+}
+
+// match returns the first limit that matches desc, or nil.
+func (s *Service) match(desc *envoy_ratelimit_v3.RateLimitDescriptor) *limit {
+	for i := range s.limits {
+		if s.limits[i].matches(desc) {
+			return &s.limits[i]
+		}
+	}
+	return nil
+}
+
+func (l *limit) matches(desc *envoy_ratelimit_v3.RateLimitDescriptor) bool {
+	entries := desc.GetEntries()
+	if len(entries) != len(l.matchers) {
+		return false
+	}
+
+	for i, m := range l.matchers {
+		if entries[i].GetKey() != m.key {
+			return false
+		}
+		if m.value != "" && entries[i].GetValue() != m.value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// counterKey returns the key that hits for desc are counted against.
+// Descriptor values come from requests, so each component is prefixed
+// with its length to keep distinct descriptors from sharing a key.
+func counterKey(domain string, desc *envoy_ratelimit_v3.RateLimitDescriptor) string {
+	var key strings.Builder
+	writeKeyComponent(&key, domain)
+	for _, e := range desc.GetEntries() {
+		writeKeyComponent(&key, e.GetKey())
+		writeKeyComponent(&key, e.GetValue())
+	}
+	return key.String()
+}
+
+func writeKeyComponent(key *strings.Builder, s string) {
+	key.WriteString(strconv.Itoa(len(s)))
+	key.WriteByte(':')
+	key.WriteString(s)
+}
@@ -0,0 +1,199 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimit
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	envoy_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/ratelimit/v3"
+	envoy_service_ratelimit_v3 "github.com/envoyproxy/go-control-plane/envoy/service/ratelimit/v3"
+	"github.com/projectsesame/sesame/internal/fixture"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `
+domain: sesame
+limits:
+- descriptor:
+    entries:
+    - genericKey:
+        value: foo
+  requests: 2
+  unit: minute
+- descriptor:
+    entries:
+    - remoteAddress: {}
+    - requestHeader:
+        headerName: X-Tenant
+        descriptorKey: tenant
+  requests: 1
+  unit: hour
+`
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse(strings.NewReader(testConfig))
+	require.NoError(t, err)
+	assert.Equal(t, "sesame", cfg.Domain)
+	assert.Len(t, cfg.Limits, 2)
+
+	tests := map[string]struct {
+		config string
+		want   string
+	}{
+		"missing domain": {
+			config: "limits: []",
+			want:   "rate limit configuration must specify a domain",
+		},
+		"unknown field": {
+			config: "domain: sesame\nfoo: bar",
+			want:   `failed to parse rate limit configuration: error unmarshaling JSON: while decoding JSON: json: unknown field "foo"`,
+		},
+		"empty descriptor": {
+			config: "domain: sesame\nlimits:\n- requests: 1\n  unit: second",
+			want:   "invalid limit 0: descriptor must have at least one entry",
+		},
+		"zero requests": {
+			config: "domain: sesame\nlimits:\n- descriptor:\n    entries:\n    - remoteAddress: {}\n  unit: second",
+			want:   "invalid limit 0: requests must be greater than zero",
+		},
+		"invalid unit": {
+			config: "domain: sesame\nlimits:\n- descriptor:\n    entries:\n    - remoteAddress: {}\n  requests: 1\n  unit: week",
+			want:   `invalid limit 0: invalid unit "week"`,
+		},
+		"multiple fields": {
+			config: "domain: sesame\nlimits:\n- descriptor:\n    entries:\n    - remoteAddress: {}\n      genericKey:\n        value: foo\n  requests: 1\n  unit: second",
+			want:   "invalid limit 0: rate limit descriptor entry must have exactly one field set",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tc.config))
+			require.Error(t, err)
+			assert.Equal(t, tc.want, err.Error())
+		})
+	}
+}
+
+func TestShouldRateLimit(t *testing.T) {
+	cfg, err := Parse(strings.NewReader(testConfig))
+	require.NoError(t, err)
+
+	svc, err := NewService(fixture.NewTestLogger(t), cfg, nil)
+	require.NoError(t, err)
+
+	now := time.Date(2021, 1, 1, 0, 0, 30, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	descriptor := func(kv ...string) *envoy_ratelimit_v3.RateLimitDescriptor {
+		d := &envoy_ratelimit_v3.RateLimitDescriptor{}
+		for i := 0; i < len(kv); i += 2 {
+			d.Entries = append(d.Entries, &envoy_ratelimit_v3.RateLimitDescriptor_Entry{Key: kv[i], Value: kv[i+1]})
+		}
+		return d
+	}
+
+	request := func(domain string, descriptors ...*envoy_ratelimit_v3.RateLimitDescriptor) *envoy_service_ratelimit_v3.RateLimitRequest {
+		return &envoy_service_ratelimit_v3.RateLimitRequest{Domain: domain, Descriptors: descriptors}
+	}
+
+	shouldRateLimit := func(req *envoy_service_ratelimit_v3.RateLimitRequest) *envoy_service_ratelimit_v3.RateLimitResponse {
+		resp, err := svc.ShouldRateLimit(context.Background(), req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	foo := descriptor("generic_key", "foo")
+
+	resp := shouldRateLimit(request("sesame", foo))
+	assert.Equal(t, envoy_service_ratelimit_v3.RateLimitResponse_OK, resp.OverallCode)
+	require.Len(t, resp.Statuses, 1)
+	assert.Equal(t, uint32(1), resp.Statuses[0].LimitRemaining)
+	assert.Equal(t, uint32(2), resp.Statuses[0].CurrentLimit.RequestsPerUnit)
+	assert.Equal(t, envoy_service_ratelimit_v3.RateLimitResponse_RateLimit_MINUTE, resp.Statuses[0].CurrentLimit.Unit)
+	assert.Equal(t, 30*time.Second, resp.Statuses[0].DurationUntilReset.AsDuration())
+
+	resp = shouldRateLimit(request("sesame", foo))
+	assert.Equal(t, envoy_service_ratelimit_v3.RateLimitResponse_OK, resp.OverallCode)
+	assert.Equal(t, uint32(0), resp.Statuses[0].LimitRemaining)
+
+	resp = shouldRateLimit(request("sesame", foo))
+	assert.Equal(t, envoy_service_ratelimit_v3.RateLimitResponse_OVER_LIMIT, resp.OverallCode)
+	assert.Equal(t, envoy_service_ratelimit_v3.RateLimitResponse_OVER_LIMIT, resp.Statuses[0].Code)
+
+	// Other domains and values are not limited.
+	resp = shouldRateLimit(request("other", foo))
+	assert.Equal(t, envoy_service_ratelimit_v3.RateLimitResponse_OK, resp.OverallCode)
+	assert.Nil(t, resp.Statuses[0].CurrentLimit)
+
+	resp = shouldRateLimit(request("sesame", descriptor("generic_key", "bar")))
+	assert.Equal(t, envoy_service_ratelimit_v3.RateLimitResponse_OK, resp.OverallCode)
+	assert.Nil(t, resp.Statuses[0].CurrentLimit)
+
+	// The count resets in the next window.
+	now = now.Add(time.Minute)
+	resp = shouldRateLimit(request("sesame", foo))
+	assert.Equal(t, envoy_service_ratelimit_v3.RateLimitResponse_OK, resp.OverallCode)
+	assert.Equal(t, uint32(1), resp.Statuses[0].LimitRemaining)
+
+	// Entries without a static value are counted per value.
+	tenant := func(addr, tenant string) *envoy_ratelimit_v3.RateLimitDescriptor {
+		return descriptor("remote_address", addr, "tenant", tenant)
+	}
+
+	resp = shouldRateLimit(request("sesame", tenant("10.0.0.1", "a")))
+	assert.Equal(t, envoy_service_ratelimit_v3.RateLimitResponse_OK, resp.OverallCode)
+
+	resp = shouldRateLimit(request("sesame", tenant("10.0.0.1", "b"), foo))
+	assert.Equal(t, envoy_service_ratelimit_v3.RateLimitResponse_OK, resp.OverallCode)
+	require.Len(t, resp.Statuses, 2)
+
+	resp = shouldRateLimit(request("sesame", descriptor("generic_key", "bar"), tenant("10.0.0.1", "a")))
+	assert.Equal(t, envoy_service_ratelimit_v3.RateLimitResponse_OVER_LIMIT, resp.OverallCode)
+	assert.Equal(t, envoy_service_ratelimit_v3.RateLimitResponse_OK, resp.Statuses[0].Code)
+	assert.Equal(t, envoy_service_ratelimit_v3.RateLimitResponse_OVER_LIMIT, resp.Statuses[1].Code)
+
+	// Hits addend is applied to every descriptor.
+	req := request("sesame", descriptor("generic_key", "foo"))
+	req.HitsAddend = 5
+	resp = shouldRateLimit(req)
+	assert.Equal(t, envoy_service_ratelimit_v3.RateLimitResponse_OVER_LIMIT, resp.OverallCode)
+}
+
+func TestCounterKey(t *testing.T) {
+	descriptor := func(kv ...string) *envoy_ratelimit_v3.RateLimitDescriptor {
+		d := &envoy_ratelimit_v3.RateLimitDescriptor{}
+		for i := 0; i < len(kv); i += 2 {
+			d.Entries = append(d.Entries, &envoy_ratelimit_v3.RateLimitDescriptor_Entry{Key: kv[i], Value: kv[i+1]})
+		}
+		return d
+	}
+
+	assert.Equal(t, "6:sesame11:generic_key3:foo", counterKey("sesame", descriptor("generic_key", "foo")))
+
+	// Separators in request values do not make descriptors share a key.
+	assert.NotEqual(t,
+		counterKey("sesame", descriptor("tenant", "a|b=c")),
+		counterKey("sesame", descriptor("tenant", "a", "b", "c")))
+	assert.NotEqual(t,
+		counterKey("sesame", descriptor("tenant", "a=b")),
+		counterKey("sesame", descriptor("tenant=a", "b")))
+	assert.NotEqual(t,
+		counterKey("sesame|x", descriptor("tenant", "a")),
+		counterKey("sesame", descriptor("x|tenant", "a")))
+}
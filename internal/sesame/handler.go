@@ -122,6 +122,13 @@ func (e *EventHandler) Start(ctx context.Context) error {
 		// pending is a reference to the current timer's channel.
 		pending <-chan time.Time
 
+		// recheck holds the timer which will expire when the
+		// expiry status of a serving certificate next changes.
+		recheck *time.Timer
+
+		// recheckC is a reference to the recheck timer's channel.
+		recheckC <-chan time.Time
+
 		// lastDAGRebuild holds the last time rebuildDAG was called.
 		// lastDAGRebuild is seeded to the current time on entry to
 		// run to allow the holdoff timer to batch the updates from
@@ -134,14 +141,34 @@ func (e *EventHandler) Start(ctx context.Context) error {
 		return
 	}
 
+	// rebuild rebuilds the DAG, and schedules another rebuild for
+	// when the expiry status of a certificate changes, since no
+	// event will be received then to refresh its status.
+	rebuild := func() {
+		next := e.rebuildDAG()
+		e.incSequence()
+		lastDAGRebuild = time.Now()
+
+		if recheck != nil {
+			recheck.Stop()
+		}
+		recheckC = nil
+		if !next.IsZero() {
+			recheck = time.NewTimer(time.Until(next))
+			recheckC = recheck.C
+		}
+	}
+
 	for {
-		// In the main loop one of four things can happen.
-		// 1. We're waiting for an event on op, stop, or pending, noting that
-		//    pending may be nil if there are no pending events.
+		// In the main loop one of five things can happen.
+		// 1. We're waiting for an event on op, stop, pending or recheck,
+		//    noting that pending and recheck may be nil.
 		// 2. We're processing an event.
 		// 3. The holdoff timer from a previous event has fired and we're
 		//    building a new DAG and sending to the Observer.
-		// 4. We're stopping.
+		// 4. The recheck timer has fired because the expiry status of a
+		//    certificate has changed, and we're rebuilding the DAG.
+		// 5. We're stopping.
 		//
 		// Only one of these things can happen at a time.
 		select {
@@ -168,9 +195,10 @@ func (e *EventHandler) Start(ctx context.Context) error {
 			}
 		case <-pending:
 			e.WithField("last_update", time.Since(lastDAGRebuild)).WithField("outstanding", reset()).Info("performing delayed update")
-			e.rebuildDAG()
-			e.incSequence()
-			lastDAGRebuild = time.Now()
+			rebuild()
+		case <-recheckC:
+			e.Info("rechecking certificate expiry")
+			rebuild()
 		case <-ctx.Done():
 			// shutdown
 			return nil
@@ -188,6 +216,7 @@ func (e *EventHandler) onUpdate(op interface{}) bool {
 	case opUpdate:
 		if cmp.Equal(op.oldObj, op.newObj,
 			cmpopts.IgnoreFields(sesame_api_v1.HTTPProxy{}, "Status"),
+			cmpopts.IgnoreFields(sesame_api_v1.TLSCertificateDelegation{}, "Status"),
 			cmpopts.IgnoreFields(metav1.ObjectMeta{}, "ResourceVersion"),
 			cmpopts.IgnoreFields(metav1.ObjectMeta{}, "ManagedFields"),
 		) {
@@ -228,11 +257,15 @@ func (e *EventHandler) incSequence() {
 
 // rebuildDAG builds a new DAG and sends it to the Observer,
 // the updates the status on objects, and updates the metrics.
-func (e *EventHandler) rebuildDAG() {
+// It returns the time at which the DAG must be rebuilt to refresh
+// the expiry status of the certificates, or the zero time.
+func (e *EventHandler) rebuildDAG() time.Time {
 	latestDAG := e.builder.Build()
 	e.observer.OnChange(latestDAG)
 
 	for _, upd := range latestDAG.StatusCache.GetStatusUpdates() {
 		e.statusUpdater.Send(upd)
 	}
+
+	return latestDAG.CertificateRecheck
 }
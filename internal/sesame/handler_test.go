@@ -16,7 +16,12 @@ package sesame
 import (
 	"testing"
 
+	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
+	"github.com/projectsesame/sesame/internal/dag"
+	"github.com/projectsesame/sesame/internal/fixture"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
@@ -24,3 +29,44 @@ func TestEventHandlerNotRequireLeaderElection(t *testing.T) {
 	var e manager.LeaderElectionRunnable = &EventHandler{}
 	require.False(t, e.NeedLeaderElection())
 }
+
+func TestEventHandlerSkipsStatusOnlyUpdates(t *testing.T) {
+	e := &EventHandler{
+		FieldLogger: fixture.NewTestLogger(t),
+		builder: &dag.Builder{
+			Source: dag.KubernetesCache{
+				FieldLogger: fixture.NewTestLogger(t),
+			},
+		},
+	}
+
+	delegation := &sesame_api_v1.TLSCertificateDelegation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "delegation",
+			Namespace:       "default",
+			ResourceVersion: "1",
+		},
+		Spec: sesame_api_v1.TLSCertificateDelegationSpec{
+			Delegations: []sesame_api_v1.CertificateDelegation{{
+				SecretName:       "wildcard",
+				TargetNamespaces: []string{"*"},
+			}},
+		},
+	}
+	require.True(t, e.onUpdate(opAdd{obj: delegation}))
+
+	// Sesame's own status update does not trigger a rebuild.
+	withStatus := delegation.DeepCopy()
+	withStatus.ResourceVersion = "2"
+	withStatus.Status.Delegations = []sesame_api_v1.CertificateDelegationStatus{{
+		SecretName: "wildcard",
+		Namespaces: []string{"default"},
+	}}
+	assert.False(t, e.onUpdate(opUpdate{oldObj: delegation, newObj: withStatus}))
+
+	// A change to the spec does.
+	withSpec := withStatus.DeepCopy()
+	withSpec.ResourceVersion = "3"
+	withSpec.Spec.Delegations[0].TargetNamespaces = []string{"default"}
+	assert.True(t, e.onUpdate(opUpdate{oldObj: withStatus, newObj: withSpec}))
+}
@@ -92,6 +92,8 @@ func (m *RebuildMetricsObserver) OnChange(d *dag.DAG) {
 	m.nextObserver.OnChange(d)
 	timer.ObserveDuration()
 
+	m.metrics.SetTLSCertificateExpiry(calculateTLSCertificateMetric(d))
+
 	select {
 	case <-m.httpProxyMetricsEnabled:
 		m.metrics.SetHTTPProxyMetric(calculateRouteMetric(d.StatusCache.GetProxyUpdates()))
@@ -99,6 +101,29 @@ func (m *RebuildMetricsObserver) OnChange(d *dag.DAG) {
 	}
 }
 
+// calculateTLSCertificateMetric returns the expiry time of the
+// serving certificate of each TLS virtual host in the DAG.
+func calculateTLSCertificateMetric(d *dag.DAG) map[metrics.TLSCertificateMeta]time.Time {
+	certificates := make(map[metrics.TLSCertificateMeta]time.Time)
+
+	for _, listener := range d.Listeners {
+		for _, svh := range listener.SecureVirtualHosts {
+			if svh.Secret == nil || svh.Secret.Certificate == nil {
+				continue
+			}
+
+			meta := metrics.TLSCertificateMeta{
+				Namespace: svh.Secret.Namespace(),
+				Name:      svh.Secret.Name(),
+				VHost:     svh.Name,
+			}
+			certificates[meta] = svh.Secret.Certificate.NotAfter
+		}
+	}
+
+	return certificates
+}
+
 func calculateRouteMetric(updates []*status.ProxyUpdate) metrics.RouteMetric {
 	proxyMetricTotal := make(map[metrics.Meta]int)
 	proxyMetricValid := make(map[metrics.Meta]int)
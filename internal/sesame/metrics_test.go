@@ -15,6 +15,7 @@ package sesame
 
 import (
 	"testing"
+	"time"
 
 	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
 	"github.com/projectsesame/sesame/internal/dag"
@@ -509,3 +510,54 @@ func TestHTTPProxyMetrics(t *testing.T) {
 		},
 	})
 }
+
+func TestTLSCertificateMetrics(t *testing.T) {
+	builder := dag.Builder{
+		Source: dag.KubernetesCache{
+			FieldLogger: fixture.NewTestLogger(t),
+		},
+		Processors: []dag.Processor{
+			&dag.HTTPProxyProcessor{},
+			&dag.ListenerProcessor{},
+		},
+	}
+
+	builder.Source.Insert(fixture.SecretRootsCert)
+	builder.Source.Insert(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "home",
+		},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{
+				Name:     "http",
+				Protocol: "TCP",
+				Port:     8080,
+			}},
+		},
+	})
+	builder.Source.Insert(&sesame_api_v1.HTTPProxy{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "roots",
+			Name:      "example",
+		},
+		Spec: sesame_api_v1.HTTPProxySpec{
+			VirtualHost: &sesame_api_v1.VirtualHost{
+				Fqdn: "example.com",
+				TLS: &sesame_api_v1.TLS{
+					SecretName: fixture.SecretRootsCert.Name,
+				},
+			},
+			Routes: []sesame_api_v1.Route{{
+				Services: []sesame_api_v1.Service{{
+					Name: "home",
+					Port: 8080,
+				}},
+			}},
+		},
+	})
+
+	assert.Equal(t, map[metrics.TLSCertificateMeta]time.Time{
+		{Namespace: "roots", Name: "ssl-cert", VHost: "example.com"}: time.Date(2029, 12, 2, 1, 34, 33, 0, time.UTC),
+	}, calculateTLSCertificateMetric(builder.Build()))
+}
@@ -16,6 +16,7 @@
 package status
 
 import (
+	"sort"
 	"time"
 
 	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
@@ -35,13 +36,12 @@ type ConditionType string
 const ValidCondition ConditionType = "Valid"
 
 // NewCache creates a new Cache for holding status updates.
-func NewCache(gateway types.NamespacedName, gatewayController gatewayapi_v1alpha2.GatewayController) Cache {
+func NewCache(gatewayController gatewayapi_v1alpha2.GatewayController) Cache {
 	return Cache{
-		gatewayRef:        gateway,
 		gatewayController: gatewayController,
 		proxyUpdates:      make(map[types.NamespacedName]*ProxyUpdate),
 		gatewayUpdates:    make(map[types.NamespacedName]*GatewayStatusUpdate),
-		routeUpdates:      make(map[types.NamespacedName]*RouteConditionsUpdate),
+		routeUpdates:      make(map[types.NamespacedName]map[types.NamespacedName]*RouteConditionsUpdate),
 		entries:           make(map[string]map[types.NamespacedName]CacheEntry),
 	}
 }
@@ -55,12 +55,14 @@ type CacheEntry interface {
 // It holds a per-Kind cache, and is intended to be accessed with a
 // KindAccessor.
 type Cache struct {
-	gatewayRef        types.NamespacedName
 	gatewayController gatewayapi_v1alpha2.GatewayController
 
 	proxyUpdates   map[types.NamespacedName]*ProxyUpdate
 	gatewayUpdates map[types.NamespacedName]*GatewayStatusUpdate
-	routeUpdates   map[types.NamespacedName]*RouteConditionsUpdate
+
+	// routeUpdates holds the route updates keyed
+	// by route and then by Gateway.
+	routeUpdates map[types.NamespacedName]map[types.NamespacedName]*RouteConditionsUpdate
 
 	// Map of cache entry maps, keyed on Kind.
 	entries map[string]map[types.NamespacedName]CacheEntry
@@ -106,11 +108,21 @@ func (c *Cache) GetStatusUpdates() []k8s.StatusUpdate {
 		flattened = append(flattened, update)
 	}
 
-	for fullname, routeUpdate := range c.routeUpdates {
+	for fullname, byGateway := range c.routeUpdates {
+		// Apply the updates for all of the route's Gateways
+		// in a single status update, in a stable order.
+		var routeUpdates routeStatusUpdate
+		for _, routeUpdate := range byGateway {
+			routeUpdates = append(routeUpdates, routeUpdate)
+		}
+		sort.Slice(routeUpdates, func(i, j int) bool {
+			return routeUpdates[i].GatewayRef.String() < routeUpdates[j].GatewayRef.String()
+		})
+
 		update := k8s.StatusUpdate{
 			NamespacedName: fullname,
-			Resource:       routeUpdate.Resource,
-			Mutator:        routeUpdate,
+			Resource:       routeUpdates[0].Resource,
+			Mutator:        routeUpdates,
 		}
 
 		flattened = append(flattened, update)
@@ -159,8 +171,10 @@ func (c *Cache) GetGatewayUpdates() []*GatewayStatusUpdate {
 // GetRouteUpdates gets the underlying RouteConditionsUpdate objects from the cache.
 func (c *Cache) GetRouteUpdates() []*RouteConditionsUpdate {
 	var allUpdates []*RouteConditionsUpdate
-	for _, conditionsUpdate := range c.routeUpdates {
-		allUpdates = append(allUpdates, conditionsUpdate)
+	for _, byGateway := range c.routeUpdates {
+		for _, conditionsUpdate := range byGateway {
+			allUpdates = append(allUpdates, conditionsUpdate)
+		}
 	}
 	return allUpdates
 }
@@ -222,15 +236,15 @@ func (c *Cache) ProxyAccessor(proxy *sesame_api_v1.HTTPProxy) (*ProxyUpdate, fun
 }
 
 // RouteConditionsAccessor returns a RouteConditionsUpdate that allows a client to build up a list of
-// metav1.Conditions as well as a function to commit the change back to the cache when everything
-// is done. The commit function pattern is used so that the RouteConditionsUpdate does not need
-// to know anything the cache internals.
-func (c *Cache) RouteConditionsAccessor(nsName types.NamespacedName, generation int64, resource client.Object, gateways []gatewayapi_v1alpha2.RouteParentStatus) (*RouteConditionsUpdate, func()) {
+// metav1.Conditions for the route's parent status for the given Gateway, as well as a function to
+// commit the change back to the cache when everything is done. The commit function pattern is used
+// so that the RouteConditionsUpdate does not need to know anything the cache internals.
+func (c *Cache) RouteConditionsAccessor(nsName types.NamespacedName, generation int64, resource client.Object, gateway types.NamespacedName, gateways []gatewayapi_v1alpha2.RouteParentStatus) (*RouteConditionsUpdate, func()) {
 	pu := &RouteConditionsUpdate{
 		FullName:           nsName,
 		Conditions:         make(map[gatewayapi_v1alpha2.RouteConditionType]metav1.Condition),
-		ExistingConditions: c.getRouteGatewayConditions(gateways),
-		GatewayRef:         c.gatewayRef,
+		ExistingConditions: getRouteGatewayConditions(gateway, gateways),
+		GatewayRef:         gateway,
 		GatewayController:  c.gatewayController,
 		Generation:         generation,
 		TransitionTime:     metav1.NewTime(clock.Now()),
@@ -241,6 +255,9 @@ func (c *Cache) RouteConditionsAccessor(nsName types.NamespacedName, generation
 		if len(pu.Conditions) == 0 {
 			return
 		}
-		c.routeUpdates[pu.FullName] = pu
+		if _, ok := c.routeUpdates[pu.FullName]; !ok {
+			c.routeUpdates[pu.FullName] = make(map[types.NamespacedName]*RouteConditionsUpdate)
+		}
+		c.routeUpdates[pu.FullName][pu.GatewayRef] = pu
 	}
 }
@@ -0,0 +1,140 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package status
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
+	"github.com/projectsesame/sesame/internal/k8s"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DelegationCacheEntry holds status updates for a particular TLSCertificateDelegation.
+type DelegationCacheEntry struct {
+	ConditionCache
+
+	Name           types.NamespacedName
+	Generation     int64
+	TransitionTime v1.Time
+
+	// Consumers holds the namespaces that reference
+	// each delegated secret, keyed by secret name.
+	Consumers map[string]map[string]struct{}
+}
+
+var _ CacheEntry = &DelegationCacheEntry{}
+
+// AddConsumer records that the named secret is referenced from namespace.
+func (e *DelegationCacheEntry) AddConsumer(secretName, namespace string) {
+	if e.Consumers == nil {
+		e.Consumers = make(map[string]map[string]struct{})
+	}
+	if e.Consumers[secretName] == nil {
+		e.Consumers[secretName] = make(map[string]struct{})
+	}
+
+	e.Consumers[secretName][namespace] = struct{}{}
+}
+
+// delegations returns the consumers of each secret, sorted by
+// secret name and namespace.
+func (e *DelegationCacheEntry) delegations() []sesame_api_v1.CertificateDelegationStatus {
+	var delegations []sesame_api_v1.CertificateDelegationStatus
+
+	for secretName, namespaces := range e.Consumers {
+		d := sesame_api_v1.CertificateDelegationStatus{
+			SecretName: secretName,
+		}
+		for ns := range namespaces {
+			d.Namespaces = append(d.Namespaces, ns)
+		}
+		sort.Strings(d.Namespaces)
+
+		delegations = append(delegations, d)
+	}
+
+	sort.Slice(delegations, func(i, j int) bool {
+		return delegations[i].SecretName < delegations[j].SecretName
+	})
+
+	return delegations
+}
+
+func (e *DelegationCacheEntry) AsStatusUpdate() k8s.StatusUpdate {
+	m := k8s.StatusMutatorFunc(func(obj client.Object) client.Object {
+		o, ok := obj.(*sesame_api_v1.TLSCertificateDelegation)
+		if !ok {
+			panic(fmt.Sprintf("unsupported %T object %q in status mutator", obj, e.Name))
+		}
+
+		delegation := o.DeepCopy()
+
+		for condType, cond := range e.Conditions {
+			cond.ObservedGeneration = e.Generation
+			cond.LastTransitionTime = e.TransitionTime
+
+			currCond := delegation.Status.GetConditionFor(string(condType))
+			if currCond == nil {
+				delegation.Status.Conditions = append(delegation.Status.Conditions, *cond)
+				continue
+			}
+
+			// Don't update the condition if our observation is stale.
+			if currCond.ObservedGeneration > cond.ObservedGeneration {
+				continue
+			}
+
+			cond.DeepCopyInto(currCond)
+		}
+
+		delegation.Status.Delegations = e.delegations()
+
+		return delegation
+	})
+
+	return k8s.StatusUpdate{
+		NamespacedName: e.Name,
+		Resource:       &sesame_api_v1.TLSCertificateDelegation{},
+		Mutator:        m,
+	}
+}
+
+// DelegationAccessor returns a pointer to a shared status cache entry
+// for the given TLSCertificateDelegation object. If no such entry exists,
+// a new entry is added. When the caller finishes with the cache entry,
+// it must call the returned function to release the entry back to the
+// cache.
+func DelegationAccessor(c *Cache, delegation *sesame_api_v1.TLSCertificateDelegation) (*DelegationCacheEntry, func()) {
+	entry := c.Get(delegation)
+	if entry == nil {
+		entry = &DelegationCacheEntry{
+			Name:           k8s.NamespacedNameOf(delegation),
+			Generation:     delegation.GetGeneration(),
+			TransitionTime: v1.NewTime(time.Now()),
+		}
+
+		// Populate the cache with the new entry
+		c.Put(delegation, entry)
+	}
+
+	entry = c.Get(delegation)
+	return entry.(*DelegationCacheEntry), func() {
+		c.Put(delegation, entry)
+	}
+}
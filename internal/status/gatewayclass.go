@@ -26,3 +26,11 @@ func SetGatewayClassAccepted(ctx context.Context, cli client.Client, gc *gateway
 	gc.Status.Conditions = mergeConditions(gc.Status.Conditions, computeGatewayClassAcceptedCondition(gc, accepted))
 	return gc
 }
+
+// SetGatewayClassInvalidParameters sets the Accepted condition of the
+// provided GatewayClass to false because the parameters it refers to
+// with its parametersRef are invalid, as described by msg.
+func SetGatewayClassInvalidParameters(ctx context.Context, cli client.Client, gc *gatewayapi_v1alpha2.GatewayClass, msg string) *gatewayapi_v1alpha2.GatewayClass {
+	gc.Status.Conditions = mergeConditions(gc.Status.Conditions, computeGatewayClassInvalidParametersCondition(gc, msg))
+	return gc
+}
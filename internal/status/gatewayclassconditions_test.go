@@ -66,6 +66,22 @@ func TestComputeGatewayClassAcceptedCondition(t *testing.T) {
 	}
 }
 
+func TestComputeGatewayClassInvalidParametersCondition(t *testing.T) {
+	gc := &gatewayapi_v1alpha2.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Generation: 7,
+		},
+	}
+
+	got := computeGatewayClassInvalidParametersCondition(gc, "GatewayClassParameters projectsesame/params not found")
+
+	assert.Equal(t, string(gatewayapi_v1alpha2.GatewayClassConditionStatusAccepted), got.Type)
+	assert.Equal(t, metav1.ConditionFalse, got.Status)
+	assert.Equal(t, string(gatewayapi_v1alpha2.GatewayClassReasonInvalidParameters), got.Reason)
+	assert.Equal(t, "Invalid GatewayClass parametersRef: GatewayClassParameters projectsesame/params not found", got.Message)
+	assert.Equal(t, gc.Generation, got.ObservedGeneration)
+}
+
 func TestConditionChanged(t *testing.T) {
 	testCases := []struct {
 		name     string
@@ -30,6 +30,19 @@ const ReasonInvalidGateway = "Invalid"
 
 const MessageValidGateway = "Valid Gateway"
 
+// ConditionCertificateExpiring is set on a Gateway when a certificate
+// referenced by one of its listeners is about to expire.
+const ConditionCertificateExpiring gatewayapi_v1alpha2.GatewayConditionType = "CertificateExpiring"
+
+const ReasonCertificateExpiring GatewayReasonType = "CertificateExpiring"
+
+// ConditionCertificateExpired is set on a Gateway when a certificate
+// referenced by one of its listeners has expired. The certificate is
+// still served.
+const ConditionCertificateExpired gatewayapi_v1alpha2.GatewayConditionType = "CertificateExpired"
+
+const ReasonCertificateExpired GatewayReasonType = "CertificateExpired"
+
 // GatewayStatusUpdate represents an atomic update to a
 // Gateway's status.
 type GatewayStatusUpdate struct {
@@ -137,6 +137,17 @@ func (routeUpdate *RouteConditionsUpdate) Mutate(obj client.Object) client.Objec
 	}
 }
 
+// routeStatusUpdate applies the RouteConditionsUpdates of a
+// route for each of the Gateways that it is attached to.
+type routeStatusUpdate []*RouteConditionsUpdate
+
+func (u routeStatusUpdate) Mutate(obj client.Object) client.Object {
+	for _, routeUpdate := range u {
+		obj = routeUpdate.Mutate(obj)
+	}
+	return obj
+}
+
 // combineConditions (due for a rename) returns all RouteParentStatuses
 // from gwStatus that are *not* for the routeUpdate's Gateway.
 func (routeUpdate *RouteConditionsUpdate) combineConditions(gwStatus []gatewayapi_v1alpha2.RouteParentStatus) []gatewayapi_v1alpha2.RouteParentStatus {
@@ -179,9 +190,9 @@ func parentRefForGateway(gateway types.NamespacedName) gatewayapi_v1alpha2.Paren
 	}
 }
 
-func (c *Cache) getRouteGatewayConditions(gatewayStatus []gatewayapi_v1alpha2.RouteParentStatus) map[gatewayapi_v1alpha2.RouteConditionType]metav1.Condition {
+func getRouteGatewayConditions(gateway types.NamespacedName, gatewayStatus []gatewayapi_v1alpha2.RouteParentStatus) map[gatewayapi_v1alpha2.RouteConditionType]metav1.Condition {
 	for _, gs := range gatewayStatus {
-		if isRefToGateway(gs.ParentRef, c.gatewayRef) {
+		if isRefToGateway(gs.ParentRef, gateway) {
 
 			conditions := make(map[gatewayapi_v1alpha2.RouteConditionType]metav1.Condition)
 			for _, gsCondition := range gs.Conditions {
@@ -52,6 +52,45 @@ func TestHTTPRouteAddCondition(t *testing.T) {
 	assert.Equal(t, simpleValidCondition.ObservedGeneration, got.ObservedGeneration)
 }
 
+func TestRouteStatusUpdatePerGateway(t *testing.T) {
+	cache := NewCache("projectsesame.io/projectsesame/sesame")
+
+	route := &gatewayapi_v1alpha2.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test",
+			Namespace:  "default",
+			Generation: 2,
+		},
+		Status: gatewayapi_v1alpha2.HTTPRouteStatus{
+			RouteStatus: gatewayapi_v1alpha2.RouteStatus{
+				Parents: []gatewayapi_v1alpha2.RouteParentStatus{{
+					ParentRef: parentRefForGateway(k8s.NamespacedNameFrom("other/gateway")),
+				}},
+			},
+		},
+	}
+
+	for _, gateway := range []string{"projectsesame/internal", "projectsesame/external"} {
+		routeUpdate, commit := cache.RouteConditionsAccessor(k8s.NamespacedNameOf(route), route.Generation, &gatewayapi_v1alpha2.HTTPRoute{}, k8s.NamespacedNameFrom(gateway), route.Status.Parents)
+		routeUpdate.AddCondition(gatewayapi_v1alpha2.ConditionRouteAccepted, metav1.ConditionTrue, ReasonValid, "Valid HTTPRoute")
+		commit()
+	}
+
+	assert.Len(t, cache.GetRouteUpdates(), 2)
+
+	updates := cache.GetStatusUpdates()
+	assert.Len(t, updates, 1)
+
+	got, ok := updates[0].Mutator.Mutate(route).(*gatewayapi_v1alpha2.HTTPRoute)
+	assert.True(t, ok)
+
+	var parents []string
+	for _, p := range got.Status.Parents {
+		parents = append(parents, string(*p.ParentRef.Namespace)+"/"+string(p.ParentRef.Name))
+	}
+	assert.ElementsMatch(t, []string{"projectsesame/internal", "projectsesame/external", "other/gateway"}, parents)
+}
+
 func newCondition(t string, status metav1.ConditionStatus, reason, msg string, lt time.Time) metav1.Condition {
 	return metav1.Condition{
 		Type:               t,
@@ -0,0 +1,197 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+
+	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
+	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
+	"github.com/projectsesame/sesame/internal/dag"
+	"github.com/projectsesame/sesame/internal/k8s"
+	"github.com/projectsesame/sesame/internal/status"
+	"github.com/sirupsen/logrus"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Validator is an admission.Handler that validates Sesame resources.
+//
+// HTTPProxy and ExtensionService objects are validated by building a
+// DAG from the current cluster state plus the candidate object, and
+// rejecting the object if it would be given an invalid status. This
+// means the checks and condition messages are exactly those of the
+// DAG processors.
+type Validator struct {
+	// Client is used to list the resources that the candidate
+	// object is validated against.
+	Client client.Reader
+
+	// NewBuilder returns a new DAG builder with an empty cache.
+	NewBuilder func() *dag.Builder
+
+	logrus.FieldLogger
+
+	decoder *admission.Decoder
+}
+
+// InjectDecoder implements admission.DecoderInjector.
+func (v *Validator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+// Handle implements admission.Handler.
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation == admissionv1.Delete {
+		return admission.Allowed("")
+	}
+
+	var obj client.Object
+	switch req.Kind.Kind {
+	case "HTTPProxy":
+		obj = &sesame_api_v1.HTTPProxy{}
+	case "ExtensionService":
+		obj = &sesame_api_v1alpha1.ExtensionService{}
+	case "SesameConfiguration":
+		obj = &sesame_api_v1alpha1.SesameConfiguration{}
+	default:
+		return admission.Allowed("")
+	}
+
+	if err := v.decoder.Decode(req, obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// The namespace is not set on objects that are being created
+	// through generateName, or in some cases at all, so take it from
+	// the request.
+	if obj.GetNamespace() == "" {
+		obj.SetNamespace(req.Namespace)
+	}
+
+	if cfg, ok := obj.(*sesame_api_v1alpha1.SesameConfiguration); ok {
+		if err := cfg.Spec.Validate(); err != nil {
+			return admission.Denied(err.Error())
+		}
+		return admission.Allowed("")
+	}
+
+	cond, err := v.validCondition(ctx, obj)
+	if err != nil {
+		v.WithError(err).WithField("kind", req.Kind.Kind).
+			WithField("namespace", obj.GetNamespace()).
+			WithField("name", obj.GetName()).
+			Error("failed to validate object")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	return responseFor(cond)
+}
+
+// validCondition builds a DAG containing obj and returns the
+// Valid condition that obj would be given, or nil if the DAG did
+// not generate a status for it.
+func (v *Validator) validCondition(ctx context.Context, obj client.Object) (*sesame_api_v1.DetailedCondition, error) {
+	builder := v.NewBuilder()
+
+	lists := []client.ObjectList{
+		&sesame_api_v1.HTTPProxyList{},
+		&sesame_api_v1.TLSCertificateDelegationList{},
+		&sesame_api_v1alpha1.ExtensionServiceList{},
+		&sesame_api_v1alpha1.ServiceReferenceGrantList{},
+		&corev1.ServiceList{},
+		&corev1.SecretList{},
+		&corev1.ConfigMapList{},
+		&corev1.NamespaceList{},
+	}
+
+	for _, list := range lists {
+		if err := v.Client.List(ctx, list); err != nil {
+			return nil, err
+		}
+
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range items {
+			builder.Source.Insert(item)
+		}
+	}
+
+	// If the cache doesn't accept the object, it isn't one that
+	// this Sesame is responsible for.
+	if !builder.Source.Insert(obj) {
+		return nil, nil
+	}
+
+	root := builder.Build()
+
+	key := k8s.NamespacedNameOf(obj)
+	for _, upd := range root.StatusCache.GetStatusUpdates() {
+		if upd.NamespacedName != key || reflect.TypeOf(upd.Resource) != reflect.TypeOf(obj) {
+			continue
+		}
+
+		switch o := upd.Mutator.Mutate(obj.DeepCopyObject().(client.Object)).(type) {
+		case *sesame_api_v1.HTTPProxy:
+			// Orphaned proxies are not invalid, they just haven't
+			// been included by a root yet.
+			if o.Status.CurrentStatus == string(status.ProxyStatusOrphaned) {
+				return nil, nil
+			}
+			return o.Status.GetConditionFor(sesame_api_v1.ValidConditionType), nil
+		case *sesame_api_v1alpha1.ExtensionService:
+			return o.Status.GetConditionFor(sesame_api_v1.ValidConditionType), nil
+		}
+	}
+
+	return nil, nil
+}
+
+// responseFor returns an admission response that denies the request if
+// cond is false, with the condition's errors as the reason. Warnings
+// are passed back to the client either way.
+func responseFor(cond *sesame_api_v1.DetailedCondition) admission.Response {
+	if cond == nil {
+		return admission.Allowed("")
+	}
+
+	var warnings []string
+	for _, w := range cond.Warnings {
+		warnings = append(warnings, w.Message)
+	}
+
+	if cond.Status != sesame_api_v1.ConditionFalse {
+		return admission.Allowed("").WithWarnings(warnings...)
+	}
+
+	var reasons []string
+	for _, e := range cond.Errors {
+		reasons = append(reasons, e.Message)
+	}
+	if len(reasons) == 0 {
+		reasons = append(reasons, cond.Message)
+	}
+
+	return admission.Denied(strings.Join(reasons, "; ")).WithWarnings(warnings...)
+}
@@ -0,0 +1,235 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
+	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
+	"github.com/projectsesame/sesame/internal/dag"
+	"github.com/projectsesame/sesame/internal/fixture"
+	"github.com/projectsesame/sesame/internal/k8s"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestValidatorHandle(t *testing.T) {
+	scheme, err := k8s.NewSesameScheme()
+	require.NoError(t, err)
+
+	svc := &corev1.Service{
+		ObjectMeta: fixture.ObjectMeta("default/kuard"),
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{
+				Protocol:   "TCP",
+				Port:       80,
+				TargetPort: intstr.FromInt(8080),
+			}},
+		},
+	}
+
+	// Services in the backend namespace may only be referred to
+	// from the default namespace if they are granted.
+	grantedSvc := svc.DeepCopy()
+	grantedSvc.ObjectMeta = fixture.ObjectMeta("backend/granted")
+	ungrantedSvc := svc.DeepCopy()
+	ungrantedSvc.ObjectMeta = fixture.ObjectMeta("backend/ungranted")
+
+	grant := &sesame_api_v1alpha1.ServiceReferenceGrant{
+		ObjectMeta: fixture.ObjectMeta("backend/grant"),
+		Spec: sesame_api_v1alpha1.ServiceReferenceGrantSpec{
+			From: []sesame_api_v1alpha1.ServiceReferenceGrantFrom{{Namespace: "default"}},
+			To:   []sesame_api_v1alpha1.ServiceReferenceGrantTo{{Kind: sesame_api_v1alpha1.KindService, Name: "granted"}},
+		},
+	}
+
+	errorPages := &corev1.ConfigMap{
+		ObjectMeta: fixture.ObjectMeta("default/error-pages"),
+		Data:       map[string]string{"503.html": "<h1>down</h1>"},
+	}
+
+	existing := &sesame_api_v1.HTTPProxy{
+		ObjectMeta: fixture.ObjectMeta("default/existing"),
+		Spec: sesame_api_v1.HTTPProxySpec{
+			VirtualHost: &sesame_api_v1.VirtualHost{Fqdn: "existing.example.com"},
+			Routes: []sesame_api_v1.Route{{
+				Services: []sesame_api_v1.Service{{Name: "kuard", Port: 80}},
+			}},
+		},
+	}
+
+	proxy := func(name, fqdn, service string) *sesame_api_v1.HTTPProxy {
+		return &sesame_api_v1.HTTPProxy{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "projectsesame.io/v1", Kind: "HTTPProxy"},
+			ObjectMeta: fixture.ObjectMeta(name),
+			Spec: sesame_api_v1.HTTPProxySpec{
+				VirtualHost: &sesame_api_v1.VirtualHost{Fqdn: fqdn},
+				Routes: []sesame_api_v1.Route{{
+					Services: []sesame_api_v1.Service{{Name: service, Port: 80}},
+				}},
+			},
+		}
+	}
+
+	crossNamespaceProxy := func(name, fqdn, service string) *sesame_api_v1.HTTPProxy {
+		p := proxy(name, fqdn, service)
+		p.Spec.Routes[0].Services[0].Namespace = "backend"
+		return p
+	}
+
+	localReplyProxy := func(name, fqdn, configMap string) *sesame_api_v1.HTTPProxy {
+		p := proxy(name, fqdn, "kuard")
+		p.Spec.VirtualHost.LocalReplyPolicy = &sesame_api_v1.LocalReplyPolicy{
+			ErrorPages: []sesame_api_v1.ErrorPage{{
+				StatusCode:  503,
+				ConfigMap:   configMap,
+				Key:         "503.html",
+				ContentType: "text/html",
+			}},
+		}
+		return p
+	}
+
+	v := &Validator{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(svc, grantedSvc, ungrantedSvc, grant, errorPages, existing).Build(),
+		NewBuilder: func() *dag.Builder {
+			return &dag.Builder{
+				Source: dag.KubernetesCache{
+					FieldLogger: fixture.NewTestLogger(t),
+				},
+				Processors: []dag.Processor{
+					&dag.ExtensionServiceProcessor{},
+					&dag.HTTPProxyProcessor{},
+					&dag.ListenerProcessor{},
+				},
+			}
+		},
+		FieldLogger: fixture.NewTestLogger(t),
+	}
+
+	decoder, err := admission.NewDecoder(scheme)
+	require.NoError(t, err)
+	require.NoError(t, v.InjectDecoder(decoder))
+
+	tests := map[string]struct {
+		obj       client.Object
+		operation admissionv1.Operation
+		allowed   bool
+		reason    string
+	}{
+		"valid proxy": {
+			obj:     proxy("default/valid", "valid.example.com", "kuard"),
+			allowed: true,
+		},
+		"updated proxy": {
+			obj:     proxy("default/existing", "existing.example.com", "kuard"),
+			allowed: true,
+		},
+		"missing service": {
+			obj:     proxy("default/missing", "missing.example.com", "missing"),
+			allowed: false,
+			reason:  `Spec.Routes unresolved service reference: service "default/missing" not found`,
+		},
+		"duplicate fqdn": {
+			obj:     proxy("default/duplicate", "existing.example.com", "kuard"),
+			allowed: false,
+			reason:  `fqdn "existing.example.com" is used in multiple HTTPProxies: default/duplicate, default/existing`,
+		},
+		"granted cross-namespace service": {
+			obj:     crossNamespaceProxy("default/granted", "granted.example.com", "granted"),
+			allowed: true,
+		},
+		"ungranted cross-namespace service": {
+			obj:     crossNamespaceProxy("default/ungranted", "ungranted.example.com", "ungranted"),
+			allowed: false,
+			reason:  `Spec.Routes service "ungranted" is not permitted by a ServiceReferenceGrant in namespace "backend"`,
+		},
+		"local reply policy": {
+			obj:     localReplyProxy("default/errorpages", "errorpages.example.com", "error-pages"),
+			allowed: true,
+		},
+		"local reply policy with missing configmap": {
+			obj:     localReplyProxy("default/missingpages", "missingpages.example.com", "missing-pages"),
+			allowed: false,
+			reason:  `Spec.VirtualHost.LocalReplyPolicy is invalid: error page for status code 503: ConfigMap "default/missing-pages": ConfigMap not found`,
+		},
+		"delete is always allowed": {
+			obj:       proxy("default/missing", "missing.example.com", "missing"),
+			operation: admissionv1.Delete,
+			allowed:   true,
+		},
+		"orphaned proxy": {
+			obj: &sesame_api_v1.HTTPProxy{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "projectsesame.io/v1", Kind: "HTTPProxy"},
+				ObjectMeta: fixture.ObjectMeta("default/child"),
+				Spec: sesame_api_v1.HTTPProxySpec{
+					Routes: []sesame_api_v1.Route{{
+						Services: []sesame_api_v1.Service{{Name: "kuard", Port: 80}},
+					}},
+				},
+			},
+			allowed: true,
+		},
+		"invalid configuration": {
+			obj: &sesame_api_v1alpha1.SesameConfiguration{
+				TypeMeta:   metav1.TypeMeta{APIVersion: "projectsesame.io/v1alpha1", Kind: "SesameConfiguration"},
+				ObjectMeta: fixture.ObjectMeta("projectsesame/sesame"),
+				Spec: sesame_api_v1alpha1.SesameConfigurationSpec{
+					Webhook: &sesame_api_v1alpha1.WebhookConfig{CertFile: "/certs/tls.crt"},
+				},
+			},
+			allowed: false,
+			reason:  "invalid sesame configuration: webhook requires both a certificate and key file",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			raw, err := json.Marshal(tc.obj)
+			require.NoError(t, err)
+
+			operation := tc.operation
+			if operation == "" {
+				operation = admissionv1.Create
+			}
+
+			req := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: operation,
+					Kind:      metav1.GroupVersionKind(tc.obj.GetObjectKind().GroupVersionKind()),
+					Namespace: tc.obj.GetNamespace(),
+					Name:      tc.obj.GetName(),
+					Object:    runtime.RawExtension{Raw: raw},
+				},
+			}
+
+			resp := v.Handle(context.Background(), req)
+			assert.Equal(t, tc.allowed, resp.Allowed)
+			if !tc.allowed {
+				assert.Equal(t, tc.reason, string(resp.Result.Reason))
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright Project Contour Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhook provides a validating admission webhook for
+// HTTPProxy and other Sesame resources.
+package webhook
+
+import (
+	"context"
+
+	"github.com/bombsimon/logrusr"
+	"github.com/projectsesame/sesame/internal/httpsvc"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// ValidatePath is the HTTP path the validating webhook is served on.
+const ValidatePath = "/validate"
+
+// Service serves the validating admission webhook over HTTPS.
+type Service struct {
+	httpsvc.Service
+
+	// Scheme is used to decode the objects in admission requests.
+	Scheme *runtime.Scheme
+
+	Validator *Validator
+}
+
+func (svc *Service) NeedLeaderElection() bool {
+	return false
+}
+
+// Implements controller-runtime Runnable interface.
+// When context is done, http server will shutdown.
+func (svc *Service) Start(ctx context.Context) error {
+	wh := &admission.Webhook{Handler: svc.Validator}
+	if err := wh.InjectLogger(logrusr.NewLogger(svc.FieldLogger)); err != nil {
+		return err
+	}
+	if err := wh.InjectScheme(svc.Scheme); err != nil {
+		return err
+	}
+
+	svc.ServeMux.Handle(ValidatePath, wh)
+	return svc.Service.Start(ctx)
+}
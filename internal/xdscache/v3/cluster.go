@@ -80,6 +80,12 @@ func (c *ClusterCache) OnChange(root *dag.DAG) {
 	clusters := map[string]*envoy_cluster_v3.Cluster{}
 
 	for _, cluster := range root.GetClusters() {
+		// Clusters that forward to an ExtensionService are
+		// added with the extension clusters below.
+		if cluster.ExtensionCluster != nil {
+			continue
+		}
+
 		name := envoy.Clustername(cluster)
 		if _, ok := clusters[name]; !ok {
 			clusters[name] = envoy_v3.Cluster(cluster)
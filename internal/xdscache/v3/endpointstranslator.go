@@ -79,6 +79,19 @@ func RecalculateEndpoints(port v1.ServicePort, ep *v1.Endpoints) []*LoadBalancin
 	return lb
 }
 
+// StaticEndpoints generates a slice of LoadBalancingEndpoint
+// resources for the given static endpoints, in the order given.
+func StaticEndpoints(endpoints []dag.StaticEndpoint) []*LoadBalancingEndpoint {
+	var lb []*LoadBalancingEndpoint
+	for _, e := range endpoints {
+		ep := envoy_v3.LBEndpoint(envoy_v3.SocketAddress(e.Address, int(e.Port)))
+		ep.LoadBalancingWeight = protobuf.UInt32OrNil(e.Weight)
+		lb = append(lb, ep)
+	}
+
+	return lb
+}
+
 // EndpointsCache is a cache of Endpoint and ServiceCluster objects.
 type EndpointsCache struct {
 	mu sync.Mutex // Protects all fields.
@@ -138,6 +151,14 @@ func (c *EndpointsCache) Recalculate() map[string]*envoy_endpoint_v3.ClusterLoad
 			}
 		}
 
+		// Static endpoints don't depend on any cached Endpoints,
+		// so they are always published as a single locality.
+		if lb := StaticEndpoints(cluster.StaticEndpoints); lb != nil {
+			cla.Endpoints = append(cla.Endpoints, &LocalityEndpoints{
+				LbEndpoints: lb,
+			})
+		}
+
 		assignments[cla.ClusterName] = &cla
 	}
 
@@ -862,6 +862,66 @@ func TestEndpointsTranslatorDefaultWeightedService(t *testing.T) {
 	protobuf.ExpectEqual(t, want, et.Contents())
 }
 
+// Test that static endpoints are published as their own locality
+// alongside the endpoints of the cluster's services.
+func TestEndpointsTranslatorStaticEndpoints(t *testing.T) {
+	et := NewEndpointsTranslator(fixture.NewTestLogger(t))
+	clusters := []*dag.ServiceCluster{
+		{
+			ClusterName: "default/static",
+			StaticEndpoints: []dag.StaticEndpoint{
+				{Address: "192.0.2.10", Port: 9000},
+				{Address: "192.0.2.11", Port: 9000, Weight: 3},
+			},
+		},
+		{
+			ClusterName: "default/mixed",
+			Services: []dag.WeightedService{
+				{
+					ServiceName:      "simple",
+					ServiceNamespace: "default",
+					ServicePort:      v1.ServicePort{},
+				},
+			},
+			StaticEndpoints: []dag.StaticEndpoint{
+				{Address: "192.0.2.12", Port: 9000},
+			},
+		},
+	}
+
+	require.NoError(t, et.cache.SetClusters(clusters))
+	et.Merge(et.cache.Recalculate())
+
+	et.OnAdd(endpoints("default", "simple", v1.EndpointSubset{
+		Addresses: addresses("192.168.183.24"),
+		Ports:     ports(port("", 8080)),
+	}))
+
+	weighted := envoy_v3.LBEndpoint(envoy_v3.SocketAddress("192.0.2.11", 9000))
+	weighted.LoadBalancingWeight = protobuf.UInt32(3)
+
+	want := []proto.Message{
+		&envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: "default/mixed",
+			Endpoints: []*envoy_endpoint_v3.LocalityLbEndpoints{
+				envoy_v3.WeightedEndpoints(1, envoy_v3.SocketAddress("192.168.183.24", 8080))[0],
+				envoy_v3.Endpoints(envoy_v3.SocketAddress("192.0.2.12", 9000))[0],
+			},
+		},
+		&envoy_endpoint_v3.ClusterLoadAssignment{
+			ClusterName: "default/static",
+			Endpoints: []*envoy_endpoint_v3.LocalityLbEndpoints{{
+				LbEndpoints: []*envoy_endpoint_v3.LbEndpoint{
+					envoy_v3.LBEndpoint(envoy_v3.SocketAddress("192.0.2.10", 9000)),
+					weighted,
+				},
+			}},
+		},
+	}
+
+	protobuf.ExpectEqual(t, want, et.Contents())
+}
+
 func TestEqual(t *testing.T) {
 	tests := map[string]struct {
 		a, b map[string]*envoy_endpoint_v3.ClusterLoadAssignment
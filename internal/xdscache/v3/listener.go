@@ -14,11 +14,12 @@
 package v3
 
 import (
-	"path"
+	"fmt"
 	"sort"
 	"sync"
 
 	envoy_accesslog_v3 "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
+	envoy_core_v3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoy_listener_v3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	http "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
 	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
@@ -41,6 +42,7 @@ const (
 	ENVOY_HTTP_LISTENER            = "ingress_http"
 	ENVOY_FALLBACK_ROUTECONFIG     = "ingress_fallbackcert"
 	ENVOY_HTTPS_LISTENER           = "ingress_https"
+	ENVOY_HTTP3_LISTENER           = "ingress_http3"
 	DEFAULT_HTTP_ACCESS_LOG        = "/dev/stdout"
 	DEFAULT_HTTP_LISTENER_ADDRESS  = "0.0.0.0"
 	DEFAULT_HTTP_LISTENER_PORT     = 8080
@@ -53,6 +55,24 @@ type Listener struct {
 	Name    string
 	Address string
 	Port    int
+
+	// MaxConnections is the maximum number of active connections
+	// on each filter chain of the listener. Envoy counts connections
+	// separately for each filter chain, so a listener with several
+	// filter chains accepts up to MaxConnections on each of them.
+	// Zero means unlimited.
+	MaxConnections uint32
+
+	// AccessLog is the access log path of the listener. If not
+	// set, the HTTP or HTTPS access log path is used.
+	AccessLog string
+}
+
+// GatewayListenerName returns the name of the Envoy listener that
+// a Gateway is mapped onto, based on the name of the default
+// listener of the same kind.
+func GatewayListenerName(listener string, gateway types.NamespacedName) string {
+	return fmt.Sprintf("%s_%s_%s", listener, gateway.Namespace, gateway.Name)
 }
 
 // ListenerConfig holds configuration parameters for building Envoy Listeners.
@@ -131,6 +151,79 @@ type ListenerConfig struct {
 	// RateLimitConfig optionally configures the global Rate Limit Service to be
 	// used.
 	RateLimitConfig *RateLimitConfig
+
+	// HTTP3 optionally configures a QUIC listener that serves HTTP/3
+	// for the TLS terminating virtual hosts of the HTTPS listeners.
+	HTTP3 *HTTP3Config
+}
+
+// HTTP3Config holds the configuration of the HTTP/3 listener.
+type HTTP3Config struct {
+	// Address and Port that the UDP listener binds to.
+	Address string
+	Port    int
+
+	// AdvertisedPort is the port that clients connect to for HTTP/3,
+	// which is advertised in the alt-svc header of HTTPS responses.
+	AdvertisedPort int
+
+	// MaxConcurrentStreams is the maximum number of concurrent
+	// streams per connection. Zero uses Envoy's default.
+	MaxConcurrentStreams uint32
+
+	// InitialStreamWindowSize and InitialConnectionWindowSize are the
+	// initial flow control windows, in bytes. Zero uses Envoy's default.
+	InitialStreamWindowSize     uint32
+	InitialConnectionWindowSize uint32
+}
+
+// AltSvc returns the alt-svc header value that advertises the HTTP/3
+// listener, or an empty string if HTTP/3 is not configured.
+func (lvc *ListenerConfig) AltSvc() string {
+	if lvc.HTTP3 == nil {
+		return ""
+	}
+	return fmt.Sprintf(`h3=":%d"; ma=86400`, lvc.HTTP3.AdvertisedPort)
+}
+
+func (lvc *ListenerConfig) http3Listener() *envoy_listener_v3.Listener {
+	return envoy_v3.QUICListener(
+		ENVOY_HTTP3_LISTENER,
+		lvc.HTTP3.Address,
+		lvc.HTTP3.Port,
+		&envoy_core_v3.QuicProtocolOptions{
+			MaxConcurrentStreams:        protobuf.UInt32OrNil(lvc.HTTP3.MaxConcurrentStreams),
+			InitialStreamWindowSize:     protobuf.UInt32OrNil(lvc.HTTP3.InitialStreamWindowSize),
+			InitialConnectionWindowSize: protobuf.UInt32OrNil(lvc.HTTP3.InitialConnectionWindowSize),
+		},
+	)
+}
+
+// http3VirtualHosts returns the names of the secure virtual hosts that are
+// served over HTTP/3, keyed by the name of their HTTPS listener. These are
+// the virtual hosts that terminate TLS and proxy HTTP. The HTTP/3 listener
+// selects filter chains by server name only, so a name served by more than
+// one HTTPS listener is only served for the first of them, which is the
+// default HTTPS listener if it serves the name.
+func http3VirtualHosts(listeners []*dag.Listener) map[string]map[string]bool {
+	served := map[string]bool{}
+	vhosts := map[string]map[string]bool{}
+
+	for _, listener := range listeners {
+		for _, vh := range listener.SecureVirtualHosts {
+			if vh.TCPProxy != nil || vh.Secret == nil || served[vh.VirtualHost.Name] {
+				continue
+			}
+			served[vh.VirtualHost.Name] = true
+
+			if vhosts[listener.Name] == nil {
+				vhosts[listener.Name] = map[string]bool{}
+			}
+			vhosts[listener.Name][vh.VirtualHost.Name] = true
+		}
+	}
+
+	return vhosts
 }
 
 type RateLimitConfig struct {
@@ -174,6 +267,122 @@ func (lvc *ListenerConfig) defaultListeners() *ListenerConfig {
 	return lvc
 }
 
+// withEnvoyConfig returns a copy of the ListenerConfig with the settings
+// of the GatewayClass's EnvoyConfig that are set applied to it. It is only
+// used for the listeners that Gateways are mapped onto, since the default
+// listeners also serve HTTPProxy and Ingress. Listener ports are never
+// overridden here: only the Gateway provisioner can change them, since it
+// also updates the target ports of the Envoy Service.
+func (lvc *ListenerConfig) withEnvoyConfig(envoyConfig *sesame_api_v1alpha1.GatewayEnvoyConfig) *ListenerConfig {
+	if envoyConfig == nil {
+		return lvc
+	}
+
+	cfg := *lvc
+
+	if l := envoyConfig.HTTPListener; l != nil && len(l.AccessLog) > 0 {
+		cfg.HTTPAccessLog = l.AccessLog
+		cfg.HTTPListeners = withListenerAccessLog(lvc.HTTPListeners, l.AccessLog)
+	}
+
+	if l := envoyConfig.HTTPSListener; l != nil && len(l.AccessLog) > 0 {
+		cfg.HTTPSAccessLog = l.AccessLog
+		cfg.HTTPSListeners = withListenerAccessLog(lvc.HTTPSListeners, l.AccessLog)
+	}
+
+	if len(envoyConfig.AccessLogFormat) > 0 {
+		cfg.AccessLogType = envoyConfig.AccessLogFormat
+	}
+
+	// The timeouts are validated before the GatewayClass
+	// is accepted, so a parse error is not expected here.
+	if timeouts, err := sesameconfig.ParseTimeoutPolicy(envoyConfig.Timeouts); err == nil {
+		cfg.Timeouts = overrideTimeouts(cfg.Timeouts, timeouts)
+	}
+
+	return &cfg
+}
+
+// withListenerPorts returns a copy of the ListenerConfig with a listener
+// for each of the DAG's Envoy listeners of Gateway listener ports. These
+// are configured like the default listener of their kind, but bind to
+// their own port.
+func (lvc *ListenerConfig) withListenerPorts(root *dag.DAG) *ListenerConfig {
+	if len(root.ListenerPorts) == 0 {
+		return lvc
+	}
+
+	cfg := *lvc
+	cfg.HTTPListeners = make(map[string]Listener, len(lvc.HTTPListeners))
+	for k, l := range lvc.HTTPListeners {
+		cfg.HTTPListeners[k] = l
+	}
+	cfg.HTTPSListeners = make(map[string]Listener, len(lvc.HTTPSListeners))
+	for k, l := range lvc.HTTPSListeners {
+		cfg.HTTPSListeners[k] = l
+	}
+
+	portListener := func(l Listener, name, defaultAddress string, port int) Listener {
+		l.Name = name
+		l.Port = port
+		if l.Address == "" {
+			l.Address = defaultAddress
+		}
+		return l
+	}
+
+	for _, listener := range root.Listeners {
+		port, ok := root.ListenerPorts[listener.Name]
+		if !ok {
+			continue
+		}
+		if len(listener.VirtualHosts) > 0 {
+			cfg.HTTPListeners[listener.Name] = portListener(lvc.HTTPListeners[ENVOY_HTTP_LISTENER], listener.Name, DEFAULT_HTTP_LISTENER_ADDRESS, port)
+		}
+		if len(listener.SecureVirtualHosts) > 0 {
+			cfg.HTTPSListeners[listener.Name] = portListener(lvc.HTTPSListeners[ENVOY_HTTPS_LISTENER], listener.Name, DEFAULT_HTTPS_LISTENER_ADDRESS, port)
+		}
+	}
+
+	return &cfg
+}
+
+// withListenerAccessLog returns a copy of listeners with
+// the access log path of each listener set to accessLog.
+func withListenerAccessLog(listeners map[string]Listener, accessLog string) map[string]Listener {
+	res := make(map[string]Listener, len(listeners))
+	for k, l := range listeners {
+		l.AccessLog = accessLog
+		res[k] = l
+	}
+	return res
+}
+
+// isGatewayListener reports whether the named listener is one that a
+// Gateway is mapped onto, rather than a default listener.
+func isGatewayListener(name string) bool {
+	return name != ENVOY_HTTP_LISTENER && name != ENVOY_HTTPS_LISTENER
+}
+
+// overrideTimeouts returns the timeouts with the
+// settings of overrides that are set applied.
+func overrideTimeouts(timeouts, overrides sesameconfig.Timeouts) sesameconfig.Timeouts {
+	override := func(setting *timeout.Setting, override timeout.Setting) {
+		if !override.UseDefault() {
+			*setting = override
+		}
+	}
+
+	override(&timeouts.Request, overrides.Request)
+	override(&timeouts.ConnectionIdle, overrides.ConnectionIdle)
+	override(&timeouts.StreamIdle, overrides.StreamIdle)
+	override(&timeouts.MaxConnectionDuration, overrides.MaxConnectionDuration)
+	override(&timeouts.DelayedClose, overrides.DelayedClose)
+	override(&timeouts.ConnectionShutdownGracePeriod, overrides.ConnectionShutdownGracePeriod)
+
+	return timeouts
+}
+
 func (lvc *ListenerConfig) secureListeners() map[string]*envoy_listener_v3.Listener {
 	listeners := make(map[string]*envoy_listener_v3.Listener)
 
@@ -198,6 +407,56 @@ func (lvc *ListenerConfig) secureListeners() map[string]*envoy_listener_v3.Liste
 	return listeners
 }
 
+// secureHTTPConnectionManager returns the HTTP connection manager for a
+// secure virtual host of the named HTTPS listener. The TLS and the HTTP/3
+// filter chains of the virtual host share it, with their own codec and
+// metrics prefix. It is uniquely named for the virtual host, so that the
+// SNI name the client requests only grants access to that host. See RFC
+// 6066 for security advice.
+func (lvc *ListenerConfig) secureHTTPConnectionManager(listener string, vh *dag.SecureVirtualHost, codec envoy_v3.HTTPVersionType, metricsPrefix string) *envoy_listener_v3.Filter {
+	var authFilter *http.HttpFilter
+	if vh.AuthorizationService != nil {
+		authFilter = envoy_v3.FilterExternalAuthz(
+			vh.AuthorizationService.Name,
+			vh.AuthorizationFailOpen,
+			vh.AuthorizationResponseTimeout,
+			vh.AuthorizationServerWithRequestBody,
+		)
+	}
+
+	return envoy_v3.HTTPConnectionManagerBuilder().
+		Codec(codec).
+		AddFilter(envoy_v3.FilterMisdirectedRequests(vh.VirtualHost.Name)).
+		DefaultFilters().
+		AddFilter(authFilter).
+		RouteConfigName(secureRouteConfigName(listener, vh.VirtualHost.Name)).
+		MetricsPrefix(metricsPrefix).
+		AccessLoggers(lvc.newSecureAccessLog(lvc.HTTPSListeners[listener])).
+		RequestTimeout(lvc.Timeouts.Request).
+		ConnectionIdleTimeout(lvc.Timeouts.ConnectionIdle).
+		StreamIdleTimeout(lvc.Timeouts.StreamIdle).
+		DelayedCloseTimeout(lvc.Timeouts.DelayedClose).
+		MaxConnectionDuration(lvc.Timeouts.MaxConnectionDuration).
+		ConnectionShutdownGracePeriod(lvc.Timeouts.ConnectionShutdownGracePeriod).
+		AllowChunkedLength(lvc.AllowChunkedLength).
+		LocalReplyConfig(envoy_v3.LocalReplyConfig(&vh.VirtualHost)).
+		AddFilter(envoy_v3.OriginalIPDetectionFilter(lvc.XffNumTrustedHops)).
+		AddFilter(envoy_v3.GlobalRateLimitFilter(envoyGlobalRateLimitConfig(lvc.RateLimitConfig))).
+		Get()
+}
+
+// listeners returns the HTTP and HTTPS listeners.
+func (lvc *ListenerConfig) listeners() []Listener {
+	var listeners []Listener
+	for _, l := range lvc.HTTPListeners {
+		listeners = append(listeners, l)
+	}
+	for _, l := range lvc.HTTPSListeners {
+		listeners = append(listeners, l)
+	}
+	return listeners
+}
+
 // httpAccessLog returns the access log for the HTTP (non TLS)
 // listener or DEFAULT_HTTP_ACCESS_LOG if not configured.
 func (lvc *ListenerConfig) httpAccessLog() string {
@@ -234,21 +493,31 @@ func (lvc *ListenerConfig) accesslogFields() sesame_api_v1alpha1.AccessLogFields
 	return sesame_api_v1alpha1.DefaultFields
 }
 
-func (lvc *ListenerConfig) newInsecureAccessLog() []*envoy_accesslog_v3.AccessLog {
+func (lvc *ListenerConfig) newInsecureAccessLog(l Listener) []*envoy_accesslog_v3.AccessLog {
+	path := lvc.httpAccessLog()
+	if l.AccessLog != "" {
+		path = l.AccessLog
+	}
+
 	switch lvc.accesslogType() {
 	case string(config.JSONAccessLog):
-		return envoy_v3.FileAccessLogJSON(lvc.httpAccessLog(), lvc.accesslogFields(), lvc.AccessLogFormatterExtensions)
+		return envoy_v3.FileAccessLogJSON(path, lvc.accesslogFields(), lvc.AccessLogFormatterExtensions)
 	default:
-		return envoy_v3.FileAccessLogEnvoy(lvc.httpAccessLog(), lvc.AccessLogFormatString, lvc.AccessLogFormatterExtensions)
+		return envoy_v3.FileAccessLogEnvoy(path, lvc.AccessLogFormatString, lvc.AccessLogFormatterExtensions)
 	}
 }
 
-func (lvc *ListenerConfig) newSecureAccessLog() []*envoy_accesslog_v3.AccessLog {
+func (lvc *ListenerConfig) newSecureAccessLog(l Listener) []*envoy_accesslog_v3.AccessLog {
+	path := lvc.httpsAccessLog()
+	if l.AccessLog != "" {
+		path = l.AccessLog
+	}
+
 	switch lvc.accesslogType() {
 	case "json":
-		return envoy_v3.FileAccessLogJSON(lvc.httpsAccessLog(), lvc.accesslogFields(), lvc.AccessLogFormatterExtensions)
+		return envoy_v3.FileAccessLogJSON(path, lvc.accesslogFields(), lvc.AccessLogFormatterExtensions)
 	default:
-		return envoy_v3.FileAccessLogEnvoy(lvc.httpsAccessLog(), lvc.AccessLogFormatString, lvc.AccessLogFormatterExtensions)
+		return envoy_v3.FileAccessLogEnvoy(path, lvc.AccessLogFormatString, lvc.AccessLogFormatterExtensions)
 	}
 }
 
@@ -345,8 +614,10 @@ func (c *ListenerCache) Query(names []string) []proto.Message {
 func (*ListenerCache) TypeURL() string { return resource.ListenerType }
 
 func (c *ListenerCache) OnChange(root *dag.DAG) {
-	cfg := c.Config.defaultListeners()
-	listeners := c.Config.secureListeners()
+	cfg := c.Config.defaultListeners().withListenerPorts(root)
+	gatewayCfg := cfg.withEnvoyConfig(root.EnvoyConfig)
+	listeners := cfg.secureListeners()
+	http3 := http3VirtualHosts(root.Listeners)
 
 	max := func(a, b envoy_tls_v3.TlsParameters_TlsProtocol) envoy_tls_v3.TlsParameters_TlsProtocol {
 		if a > b {
@@ -359,6 +630,13 @@ func (c *ListenerCache) OnChange(root *dag.DAG) {
 	// want the vhosts that have been attached to a listener
 	// by the listener processor.
 	for _, listener := range root.Listeners {
+		// The GatewayClass's Envoy settings only apply to the
+		// listeners that Gateways are mapped onto.
+		cfg := cfg
+		if isGatewayListener(listener.Name) {
+			cfg = gatewayCfg
+		}
+
 		if len(listener.VirtualHosts) > 0 {
 			if httpListener, ok := cfg.HTTPListeners[listener.Name]; ok {
 				// Add a listener if there are vhosts bound to http.
@@ -367,7 +645,7 @@ func (c *ListenerCache) OnChange(root *dag.DAG) {
 					DefaultFilters().
 					RouteConfigName(httpListener.Name).
 					MetricsPrefix(httpListener.Name).
-					AccessLoggers(cfg.newInsecureAccessLog()).
+					AccessLoggers(cfg.newInsecureAccessLog(httpListener)).
 					RequestTimeout(cfg.Timeouts.Request).
 					ConnectionIdleTimeout(cfg.Timeouts.ConnectionIdle).
 					StreamIdleTimeout(cfg.Timeouts.StreamIdle).
@@ -375,6 +653,7 @@ func (c *ListenerCache) OnChange(root *dag.DAG) {
 					MaxConnectionDuration(cfg.Timeouts.MaxConnectionDuration).
 					ConnectionShutdownGracePeriod(cfg.Timeouts.ConnectionShutdownGracePeriod).
 					AllowChunkedLength(cfg.AllowChunkedLength).
+					LocalReplyConfig(envoy_v3.LocalReplyConfig(listener.VirtualHosts...)).
 					AddFilter(envoy_v3.OriginalIPDetectionFilter(cfg.XffNumTrustedHops)).
 					AddFilter(envoy_v3.GlobalRateLimitFilter(envoyGlobalRateLimitConfig(cfg.RateLimitConfig))).
 					Get()
@@ -389,55 +668,33 @@ func (c *ListenerCache) OnChange(root *dag.DAG) {
 			}
 		}
 
+		// Skip the listener if it's not a configured HTTPS listener.
+		if len(listener.SecureVirtualHosts) > 0 {
+			if _, ok := listeners[listener.Name]; !ok {
+				continue
+			}
+		}
+		httpsListener := cfg.HTTPSListeners[listener.Name]
+
 		for _, vh := range listener.SecureVirtualHosts {
 			var alpnProtos []string
 			var filters []*envoy_listener_v3.Filter
 
 			if vh.TCPProxy == nil {
-				var authFilter *http.HttpFilter
-
-				if vh.AuthorizationService != nil {
-					authFilter = envoy_v3.FilterExternalAuthz(
-						vh.AuthorizationService.Name,
-						vh.AuthorizationFailOpen,
-						vh.AuthorizationResponseTimeout,
-						vh.AuthorizationServerWithRequestBody,
-					)
-				}
-				// Create a uniquely named HTTP connection manager for
-				// this vhost, so that the SNI name the client requests
-				// only grants access to that host. See RFC 6066 for
-				// security advice. Note that we still use the generic
-				// metrics prefix to keep compatibility with previous
-				// Sesame versions since the metrics prefix will be
-				// coded into monitoring dashboards.
-				cm := envoy_v3.HTTPConnectionManagerBuilder().
-					Codec(envoy_v3.CodecForVersions(cfg.DefaultHTTPVersions...)).
-					AddFilter(envoy_v3.FilterMisdirectedRequests(vh.VirtualHost.Name)).
-					DefaultFilters().
-					AddFilter(authFilter).
-					RouteConfigName(path.Join("https", vh.VirtualHost.Name)).
-					MetricsPrefix(listener.Name).
-					AccessLoggers(cfg.newSecureAccessLog()).
-					RequestTimeout(cfg.Timeouts.Request).
-					ConnectionIdleTimeout(cfg.Timeouts.ConnectionIdle).
-					StreamIdleTimeout(cfg.Timeouts.StreamIdle).
-					DelayedCloseTimeout(cfg.Timeouts.DelayedClose).
-					MaxConnectionDuration(cfg.Timeouts.MaxConnectionDuration).
-					ConnectionShutdownGracePeriod(cfg.Timeouts.ConnectionShutdownGracePeriod).
-					AllowChunkedLength(cfg.AllowChunkedLength).
-					AddFilter(envoy_v3.OriginalIPDetectionFilter(cfg.XffNumTrustedHops)).
-					AddFilter(envoy_v3.GlobalRateLimitFilter(envoyGlobalRateLimitConfig(cfg.RateLimitConfig))).
-					Get()
-
-				filters = envoy_v3.Filters(cm)
+				// Note that we still use the generic metrics prefix
+				// to keep compatibility with previous Sesame versions
+				// since the metrics prefix will be coded into
+				// monitoring dashboards.
+				filters = envoy_v3.Filters(
+					cfg.secureHTTPConnectionManager(listener.Name, vh, envoy_v3.CodecForVersions(cfg.DefaultHTTPVersions...), listener.Name),
+				)
 
 				alpnProtos = envoy_v3.ProtoNamesForVersions(cfg.DefaultHTTPVersions...)
 			} else {
 				filters = envoy_v3.Filters(
 					envoy_v3.TCPProxy(listener.Name,
 						vh.TCPProxy,
-						cfg.newSecureAccessLog()),
+						cfg.newSecureAccessLog(httpsListener)),
 				)
 
 				// Do not offer ALPN for TCP proxying, since
@@ -462,6 +719,25 @@ func (c *ListenerCache) OnChange(root *dag.DAG) {
 
 			listeners[listener.Name].FilterChains = append(listeners[listener.Name].FilterChains, envoy_v3.FilterChainTLS(vh.VirtualHost.Name, downstreamTLS, filters))
 
+			// Serve the same virtual host over HTTP/3 if it terminates
+			// TLS and proxies HTTP. QUIC always uses TLS 1.3.
+			if cfg.HTTP3 != nil && http3[listener.Name][vh.VirtualHost.Name] {
+				if _, ok := listeners[ENVOY_HTTP3_LISTENER]; !ok {
+					listeners[ENVOY_HTTP3_LISTENER] = cfg.http3Listener()
+				}
+
+				cm := cfg.secureHTTPConnectionManager(listener.Name, vh, envoy_v3.HTTPVersion3, ENVOY_HTTP3_LISTENER)
+
+				quicTLS := envoy_v3.DownstreamTLSContext(
+					vh.Secret,
+					envoy_tls_v3.TlsParameters_TLSv1_3,
+					nil,
+					vh.DownstreamValidation,
+					"h3")
+
+				listeners[ENVOY_HTTP3_LISTENER].FilterChains = append(listeners[ENVOY_HTTP3_LISTENER].FilterChains, envoy_v3.FilterChainQUIC(vh.VirtualHost.Name, quicTLS, envoy_v3.Filters(cm)))
+			}
+
 			// If this VirtualHost has enabled the fallback certificate then set a default
 			// FilterChain which will allow routes with this vhost to accept non-SNI TLS requests.
 			// Note that we don't add the misdirected requests filter on this chain because at this
@@ -482,7 +758,7 @@ func (c *ListenerCache) OnChange(root *dag.DAG) {
 					DefaultFilters().
 					RouteConfigName(ENVOY_FALLBACK_ROUTECONFIG).
 					MetricsPrefix(listener.Name).
-					AccessLoggers(cfg.newSecureAccessLog()).
+					AccessLoggers(cfg.newSecureAccessLog(httpsListener)).
 					RequestTimeout(cfg.Timeouts.Request).
 					ConnectionIdleTimeout(cfg.Timeouts.ConnectionIdle).
 					StreamIdleTimeout(cfg.Timeouts.StreamIdle).
@@ -502,13 +778,19 @@ func (c *ListenerCache) OnChange(root *dag.DAG) {
 		}
 	}
 
-	// Remove the https listener if there are no vhosts bound to it.
-	if len(listeners[ENVOY_HTTPS_LISTENER].FilterChains) == 0 {
-		delete(listeners, ENVOY_HTTPS_LISTENER)
-	} else {
-		// there's some https listeners, we need to sort the filter chains
-		// to ensure that the LDS entries are identical.
-		sort.Stable(sorter.For(listeners[ENVOY_HTTPS_LISTENER].FilterChains))
+	// Remove the https listeners if there are no vhosts bound to them.
+	for name := range cfg.HTTPSListeners {
+		if len(listeners[name].FilterChains) == 0 {
+			delete(listeners, name)
+		} else {
+			// there's some https listeners, we need to sort the filter chains
+			// to ensure that the LDS entries are identical.
+			sort.Stable(sorter.For(listeners[name].FilterChains))
+		}
+	}
+
+	if http3, ok := listeners[ENVOY_HTTP3_LISTENER]; ok {
+		sort.Stable(sorter.For(http3.FilterChains))
 	}
 
 	// support more params of envoy listener
@@ -524,6 +806,20 @@ func (c *ListenerCache) OnChange(root *dag.DAG) {
 		}
 	}
 
+	// 2. connection limits
+	// The connection limit filter counts the connections of the filter
+	// chain it is added to, so the limit applies to each filter chain
+	// (i.e. each TLS virtual host) rather than to the whole listener.
+	for _, l := range cfg.listeners() {
+		listener, ok := listeners[l.Name]
+		if !ok || l.MaxConnections == 0 {
+			continue
+		}
+		for _, fc := range listener.FilterChains {
+			fc.Filters = append([]*envoy_listener_v3.Filter{envoy_v3.ConnectionLimit(l.Name, l.MaxConnections)}, fc.Filters...)
+		}
+	}
+
 	c.Update(listeners)
 }
 
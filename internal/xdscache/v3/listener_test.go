@@ -27,16 +27,19 @@ import (
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
 	"github.com/golang/protobuf/proto"
 	sesame_api_v1 "github.com/projectsesame/sesame/apis/projectsesame/v1"
+	sesame_api_v1alpha1 "github.com/projectsesame/sesame/apis/projectsesame/v1alpha1"
 	"github.com/projectsesame/sesame/internal/dag"
 	envoy_v3 "github.com/projectsesame/sesame/internal/envoy/v3"
 	"github.com/projectsesame/sesame/internal/k8s"
 	"github.com/projectsesame/sesame/internal/protobuf"
 	"github.com/projectsesame/sesame/internal/sesameconfig"
 	"github.com/projectsesame/sesame/internal/timeout"
+	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	networking_v1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/pointer"
 )
 
 func TestListenerCacheContents(t *testing.T) {
@@ -149,6 +152,17 @@ func TestListenerVisit(t *testing.T) {
 			Get()
 	}
 
+	http3FilterFor := func(vhost string) *envoy_listener_v3.Filter {
+		return envoy_v3.HTTPConnectionManagerBuilder().
+			Codec(envoy_v3.HTTPVersion3).
+			AddFilter(envoy_v3.FilterMisdirectedRequests(vhost)).
+			DefaultFilters().
+			MetricsPrefix(ENVOY_HTTP3_LISTENER).
+			RouteConfigName(path.Join("https", vhost)).
+			AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil)).
+			Get()
+	}
+
 	fallbackCertFilter := envoy_v3.HTTPConnectionManagerBuilder().
 		DefaultFilters().
 		MetricsPrefix(ENVOY_HTTPS_LISTENER).
@@ -521,6 +535,297 @@ func TestListenerVisit(t *testing.T) {
 				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
 			}),
 		},
+		"httpproxy with secret and http3 configured": {
+			ListenerConfig: ListenerConfig{
+				HTTP3: &HTTP3Config{
+					Address:              "0.0.0.0",
+					Port:                 8443,
+					AdvertisedPort:       443,
+					MaxConcurrentStreams: 100,
+				},
+			},
+			objs: []interface{}{
+				&sesame_api_v1.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: sesame_api_v1.HTTPProxySpec{
+						VirtualHost: &sesame_api_v1.VirtualHost{
+							Fqdn: "www.example.com",
+							TLS: &sesame_api_v1.TLS{
+								SecretName: "secret",
+							},
+						},
+						Routes: []sesame_api_v1.Route{{
+							Services: []sesame_api_v1.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
+						}},
+					},
+				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret",
+						Namespace: "default",
+					},
+					Type: "kubernetes.io/tls",
+					Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backend",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     80,
+						}},
+					},
+				},
+			},
+			want: listenermap(&envoy_listener_v3.Listener{
+				Name:          ENVOY_HTTP_LISTENER,
+				Address:       envoy_v3.SocketAddress("0.0.0.0", 8080),
+				FilterChains:  envoy_v3.FilterChains(envoy_v3.HTTPConnectionManager(ENVOY_HTTP_LISTENER, envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil), 0)),
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			}, &envoy_listener_v3.Listener{
+				Name:    ENVOY_HTTPS_LISTENER,
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8443),
+				FilterChains: []*envoy_listener_v3.FilterChain{{
+					FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+						ServerNames: []string{"www.example.com"},
+					},
+					TransportSocket: transportSocket("secret", envoy_tls_v3.TlsParameters_TLSv1_2, nil, "h2", "http/1.1"),
+					Filters:         envoy_v3.Filters(httpsFilterFor("www.example.com")),
+				}},
+				ListenerFilters: envoy_v3.ListenerFilters(
+					envoy_v3.TLSInspector(),
+				),
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			}, &envoy_listener_v3.Listener{
+				Name:    ENVOY_HTTP3_LISTENER,
+				Address: envoy_v3.UDPSocketAddress("0.0.0.0", 8443),
+				UdpListenerConfig: &envoy_listener_v3.UdpListenerConfig{
+					QuicOptions: &envoy_listener_v3.QuicProtocolOptions{
+						QuicProtocolOptions: &envoy_core_v3.QuicProtocolOptions{
+							MaxConcurrentStreams: protobuf.UInt32(100),
+						},
+					},
+					DownstreamSocketConfig: &envoy_core_v3.UdpSocketConfig{
+						PreferGro: protobuf.Bool(true),
+					},
+				},
+				FilterChains: []*envoy_listener_v3.FilterChain{{
+					FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+						ServerNames: []string{"www.example.com"},
+					},
+					TransportSocket: quicTransportSocket("secret"),
+					Filters:         envoy_v3.Filters(http3FilterFor("www.example.com")),
+				}},
+			}),
+		},
+		"httpsproxy with secret with max connections set in listener config": {
+			ListenerConfig: ListenerConfig{
+				HTTPListeners: map[string]Listener{
+					ENVOY_HTTP_LISTENER: {
+						Name:           ENVOY_HTTP_LISTENER,
+						Address:        DEFAULT_HTTP_LISTENER_ADDRESS,
+						Port:           DEFAULT_HTTP_LISTENER_PORT,
+						MaxConnections: 1000,
+					},
+				},
+				HTTPSListeners: map[string]Listener{
+					ENVOY_HTTPS_LISTENER: {
+						Name:           ENVOY_HTTPS_LISTENER,
+						Address:        DEFAULT_HTTPS_LISTENER_ADDRESS,
+						Port:           DEFAULT_HTTPS_LISTENER_PORT,
+						MaxConnections: 2000,
+					},
+				},
+			},
+			objs: []interface{}{
+				&sesame_api_v1.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: sesame_api_v1.HTTPProxySpec{
+						VirtualHost: &sesame_api_v1.VirtualHost{
+							Fqdn: "www.example.com",
+							TLS: &sesame_api_v1.TLS{
+								SecretName: "secret",
+							},
+						},
+						Routes: []sesame_api_v1.Route{{
+							Services: []sesame_api_v1.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
+						}},
+					},
+				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret",
+						Namespace: "default",
+					},
+					Type: "kubernetes.io/tls",
+					Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backend",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     80,
+						}},
+					},
+				},
+			},
+			want: listenermap(&envoy_listener_v3.Listener{
+				Name:    ENVOY_HTTP_LISTENER,
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8080),
+				FilterChains: envoy_v3.FilterChains(
+					envoy_v3.ConnectionLimit(ENVOY_HTTP_LISTENER, 1000),
+					envoy_v3.HTTPConnectionManager(ENVOY_HTTP_LISTENER, envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil), 0),
+				),
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			}, &envoy_listener_v3.Listener{
+				Name:    ENVOY_HTTPS_LISTENER,
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8443),
+				FilterChains: []*envoy_listener_v3.FilterChain{{
+					FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+						ServerNames: []string{"www.example.com"},
+					},
+					TransportSocket: transportSocket("secret", envoy_tls_v3.TlsParameters_TLSv1_2, nil, "h2", "http/1.1"),
+					Filters: envoy_v3.Filters(
+						envoy_v3.ConnectionLimit(ENVOY_HTTPS_LISTENER, 2000),
+						httpsFilterFor("www.example.com"),
+					),
+				}},
+				ListenerFilters: envoy_v3.ListenerFilters(
+					envoy_v3.TLSInspector(),
+				),
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			}),
+		},
+		"httpsproxies with max connections set limit each filter chain": {
+			ListenerConfig: ListenerConfig{
+				HTTPSListeners: map[string]Listener{
+					ENVOY_HTTPS_LISTENER: {
+						Name:           ENVOY_HTTPS_LISTENER,
+						Address:        DEFAULT_HTTPS_LISTENER_ADDRESS,
+						Port:           DEFAULT_HTTPS_LISTENER_PORT,
+						MaxConnections: 2000,
+					},
+				},
+			},
+			objs: []interface{}{
+				&sesame_api_v1.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: sesame_api_v1.HTTPProxySpec{
+						VirtualHost: &sesame_api_v1.VirtualHost{
+							Fqdn: "www.example.com",
+							TLS: &sesame_api_v1.TLS{
+								SecretName: "secret",
+							},
+						},
+						Routes: []sesame_api_v1.Route{{
+							Services: []sesame_api_v1.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
+						}},
+					},
+				},
+				&sesame_api_v1.HTTPProxy{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "other",
+						Namespace: "default",
+					},
+					Spec: sesame_api_v1.HTTPProxySpec{
+						VirtualHost: &sesame_api_v1.VirtualHost{
+							Fqdn: "other.example.com",
+							TLS: &sesame_api_v1.TLS{
+								SecretName: "secret",
+							},
+						},
+						Routes: []sesame_api_v1.Route{{
+							Services: []sesame_api_v1.Service{{
+								Name: "backend",
+								Port: 80,
+							}},
+						}},
+					},
+				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret",
+						Namespace: "default",
+					},
+					Type: "kubernetes.io/tls",
+					Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "backend",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:     "http",
+							Protocol: "TCP",
+							Port:     80,
+						}},
+					},
+				},
+			},
+			want: listenermap(&envoy_listener_v3.Listener{
+				Name:    ENVOY_HTTP_LISTENER,
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8080),
+				FilterChains: envoy_v3.FilterChains(
+					envoy_v3.HTTPConnectionManager(ENVOY_HTTP_LISTENER, envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil), 0),
+				),
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			}, &envoy_listener_v3.Listener{
+				Name:    ENVOY_HTTPS_LISTENER,
+				Address: envoy_v3.SocketAddress("0.0.0.0", 8443),
+				FilterChains: []*envoy_listener_v3.FilterChain{{
+					FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+						ServerNames: []string{"other.example.com"},
+					},
+					TransportSocket: transportSocket("secret", envoy_tls_v3.TlsParameters_TLSv1_2, nil, "h2", "http/1.1"),
+					Filters: envoy_v3.Filters(
+						envoy_v3.ConnectionLimit(ENVOY_HTTPS_LISTENER, 2000),
+						httpsFilterFor("other.example.com"),
+					),
+				}, {
+					FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+						ServerNames: []string{"www.example.com"},
+					},
+					TransportSocket: transportSocket("secret", envoy_tls_v3.TlsParameters_TLSv1_2, nil, "h2", "http/1.1"),
+					Filters: envoy_v3.Filters(
+						envoy_v3.ConnectionLimit(ENVOY_HTTPS_LISTENER, 2000),
+						httpsFilterFor("www.example.com"),
+					),
+				}},
+				ListenerFilters: envoy_v3.ListenerFilters(
+					envoy_v3.TLSInspector(),
+				),
+				SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+			}),
+		},
 		"ingress with allow-http: false": {
 			objs: []interface{}{
 				&networking_v1.Ingress{
@@ -3363,6 +3668,256 @@ func TestListenerVisit(t *testing.T) {
 	}
 }
 
+func TestListenerVisitGatewayEnvoyConfig(t *testing.T) {
+	gateway := types.NamespacedName{Namespace: "projectsesame", Name: "internal"}
+	httpName := GatewayListenerName(ENVOY_HTTP_LISTENER, gateway)
+
+	root := &dag.DAG{
+		Listeners: []*dag.Listener{{
+			Name: ENVOY_HTTP_LISTENER,
+			VirtualHosts: []*dag.VirtualHost{{
+				Name: "www.example.com",
+			}},
+		}, {
+			Name: httpName,
+			VirtualHosts: []*dag.VirtualHost{{
+				Name: "gateway.example.com",
+			}},
+		}},
+		EnvoyConfig: &sesame_api_v1alpha1.GatewayEnvoyConfig{
+			HTTPListener: &sesame_api_v1alpha1.GatewayEnvoyListener{
+				Port:      9090,
+				AccessLog: "/dev/null",
+			},
+			AccessLogFormat: sesame_api_v1alpha1.JSONAccessLog,
+			Timeouts: &sesame_api_v1alpha1.TimeoutParameters{
+				ConnectionIdleTimeout: pointer.StringPtr("90s"),
+			},
+		},
+	}
+
+	lc := ListenerCache{
+		Config: ListenerConfig{
+			HTTPListeners: map[string]Listener{
+				ENVOY_HTTP_LISTENER: {Name: ENVOY_HTTP_LISTENER, Address: "0.0.0.0", Port: 8080},
+				httpName:            {Name: httpName, Address: "0.0.0.0", Port: 9080, AccessLog: "/dev/stdout"},
+			},
+			Timeouts: sesameconfig.Timeouts{
+				StreamIdle: timeout.DurationSetting(60 * time.Second),
+			},
+		},
+	}
+	lc.OnChange(root)
+
+	// The overrides only apply to the Gateway's own listener, and
+	// the listener ports are never changed by them since the
+	// Envoy Service's target ports would no longer match.
+	protobuf.ExpectEqual(t, listenermap(&envoy_listener_v3.Listener{
+		Name:    ENVOY_HTTP_LISTENER,
+		Address: envoy_v3.SocketAddress("0.0.0.0", 8080),
+		FilterChains: envoy_v3.FilterChains(
+			envoy_v3.HTTPConnectionManagerBuilder().
+				RouteConfigName(ENVOY_HTTP_LISTENER).
+				MetricsPrefix(ENVOY_HTTP_LISTENER).
+				AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTP_ACCESS_LOG, "", nil)).
+				DefaultFilters().
+				StreamIdleTimeout(timeout.DurationSetting(60 * time.Second)).
+				Get(),
+		),
+		SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+	}, &envoy_listener_v3.Listener{
+		Name:    httpName,
+		Address: envoy_v3.SocketAddress("0.0.0.0", 9080),
+		FilterChains: envoy_v3.FilterChains(
+			envoy_v3.HTTPConnectionManagerBuilder().
+				RouteConfigName(httpName).
+				MetricsPrefix(httpName).
+				AccessLoggers(envoy_v3.FileAccessLogJSON("/dev/null", sesame_api_v1alpha1.DefaultFields, nil)).
+				DefaultFilters().
+				ConnectionIdleTimeout(timeout.DurationSetting(90 * time.Second)).
+				StreamIdleTimeout(timeout.DurationSetting(60 * time.Second)).
+				Get(),
+		),
+		SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+	}), lc.values)
+
+	// The configured listeners are not changed by the overrides.
+	assert.Equal(t, "/dev/stdout", lc.Config.HTTPListeners[httpName].AccessLog)
+}
+
+func TestListenerVisitGatewayListeners(t *testing.T) {
+	gateway := types.NamespacedName{Namespace: "projectsesame", Name: "internal"}
+	httpName := GatewayListenerName(ENVOY_HTTP_LISTENER, gateway)
+	httpsName := GatewayListenerName(ENVOY_HTTPS_LISTENER, gateway)
+
+	secret := &dag.Secret{
+		Object: &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "secret",
+				Namespace: "default",
+			},
+			Type: v1.SecretTypeTLS,
+			Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+		},
+	}
+
+	root := &dag.DAG{
+		Listeners: []*dag.Listener{{
+			Name: httpName,
+			VirtualHosts: []*dag.VirtualHost{{
+				Name: "www.example.com",
+			}},
+		}, {
+			Name: httpsName,
+			SecureVirtualHosts: []*dag.SecureVirtualHost{{
+				VirtualHost: dag.VirtualHost{
+					Name: "www.example.com",
+				},
+				Secret: secret,
+			}},
+		}},
+	}
+
+	lc := ListenerCache{
+		Config: ListenerConfig{
+			HTTPListeners: map[string]Listener{
+				ENVOY_HTTP_LISTENER: {Name: ENVOY_HTTP_LISTENER, Address: "0.0.0.0", Port: 8080},
+				httpName:            {Name: httpName, Address: "0.0.0.0", Port: 9080, AccessLog: "/dev/null"},
+			},
+			HTTPSListeners: map[string]Listener{
+				ENVOY_HTTPS_LISTENER: {Name: ENVOY_HTTPS_LISTENER, Address: "0.0.0.0", Port: 8443},
+				httpsName:            {Name: httpsName, Address: "0.0.0.0", Port: 9443},
+			},
+		},
+	}
+	lc.OnChange(root)
+
+	// The Gateway's vhosts are only served by its own listeners,
+	// and the default HTTPS listener is removed since it has
+	// no vhosts.
+	protobuf.ExpectEqual(t, listenermap(&envoy_listener_v3.Listener{
+		Name:    httpName,
+		Address: envoy_v3.SocketAddress("0.0.0.0", 9080),
+		FilterChains: envoy_v3.FilterChains(
+			envoy_v3.HTTPConnectionManagerBuilder().
+				RouteConfigName(httpName).
+				MetricsPrefix(httpName).
+				AccessLoggers(envoy_v3.FileAccessLogEnvoy("/dev/null", "", nil)).
+				DefaultFilters().
+				Get(),
+		),
+		SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+	}, &envoy_listener_v3.Listener{
+		Name:    httpsName,
+		Address: envoy_v3.SocketAddress("0.0.0.0", 9443),
+		ListenerFilters: envoy_v3.ListenerFilters(
+			envoy_v3.TLSInspector(),
+		),
+		FilterChains: []*envoy_listener_v3.FilterChain{{
+			FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+				ServerNames: []string{"www.example.com"},
+			},
+			TransportSocket: transportSocket("secret", envoy_tls_v3.TlsParameters_TLSv1_2, nil, "h2", "http/1.1"),
+			Filters: envoy_v3.Filters(envoy_v3.HTTPConnectionManagerBuilder().
+				AddFilter(envoy_v3.FilterMisdirectedRequests("www.example.com")).
+				DefaultFilters().
+				MetricsPrefix(httpsName).
+				RouteConfigName(path.Join("https", httpsName, "www.example.com")).
+				AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTPS_ACCESS_LOG, "", nil)).
+				Get()),
+		}},
+		SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+	}), lc.values)
+}
+
+func TestListenerVisitGatewayListenerPorts(t *testing.T) {
+	secret := &dag.Secret{
+		Object: &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "secret",
+				Namespace: "default",
+			},
+			Type: v1.SecretTypeTLS,
+			Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+		},
+	}
+
+	root := &dag.DAG{
+		Listeners: []*dag.Listener{{
+			Name: "ingress_http_8081",
+			Port: 8081,
+			VirtualHosts: []*dag.VirtualHost{{
+				Name: "www.example.com",
+			}},
+		}, {
+			Name: "ingress_https_8444",
+			Port: 8444,
+			SecureVirtualHosts: []*dag.SecureVirtualHost{{
+				VirtualHost: dag.VirtualHost{
+					Name: "www.example.com",
+				},
+				Secret: secret,
+			}},
+		}},
+		ListenerPorts: map[string]int{
+			"ingress_http_8081":  8081,
+			"ingress_https_8444": 8444,
+		},
+	}
+
+	lc := ListenerCache{
+		Config: ListenerConfig{
+			HTTPListeners: map[string]Listener{
+				ENVOY_HTTP_LISTENER: {Name: ENVOY_HTTP_LISTENER, Address: "0.0.0.0", Port: 8080, AccessLog: "/dev/null"},
+			},
+			HTTPSListeners: map[string]Listener{
+				ENVOY_HTTPS_LISTENER: {Name: ENVOY_HTTPS_LISTENER, Address: "0.0.0.0", Port: 8443},
+			},
+		},
+	}
+	lc.OnChange(root)
+
+	// The listeners of Gateway listener ports are configured like
+	// the default listeners, but bind to their own ports.
+	protobuf.ExpectEqual(t, listenermap(&envoy_listener_v3.Listener{
+		Name:    "ingress_http_8081",
+		Address: envoy_v3.SocketAddress("0.0.0.0", 8081),
+		FilterChains: envoy_v3.FilterChains(
+			envoy_v3.HTTPConnectionManagerBuilder().
+				RouteConfigName("ingress_http_8081").
+				MetricsPrefix("ingress_http_8081").
+				AccessLoggers(envoy_v3.FileAccessLogEnvoy("/dev/null", "", nil)).
+				DefaultFilters().
+				Get(),
+		),
+		SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+	}, &envoy_listener_v3.Listener{
+		Name:    "ingress_https_8444",
+		Address: envoy_v3.SocketAddress("0.0.0.0", 8444),
+		ListenerFilters: envoy_v3.ListenerFilters(
+			envoy_v3.TLSInspector(),
+		),
+		FilterChains: []*envoy_listener_v3.FilterChain{{
+			FilterChainMatch: &envoy_listener_v3.FilterChainMatch{
+				ServerNames: []string{"www.example.com"},
+			},
+			TransportSocket: transportSocket("secret", envoy_tls_v3.TlsParameters_TLSv1_2, nil, "h2", "http/1.1"),
+			Filters: envoy_v3.Filters(envoy_v3.HTTPConnectionManagerBuilder().
+				AddFilter(envoy_v3.FilterMisdirectedRequests("www.example.com")).
+				DefaultFilters().
+				MetricsPrefix("ingress_https_8444").
+				RouteConfigName(path.Join("https", "ingress_https_8444", "www.example.com")).
+				AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTPS_ACCESS_LOG, "", nil)).
+				Get()),
+		}},
+		SocketOptions: envoy_v3.TCPKeepaliveSocketOptions(),
+	}), lc.values)
+
+	// The configuration is not modified.
+	assert.Len(t, lc.Config.HTTPListeners, 1)
+	assert.Len(t, lc.Config.HTTPSListeners, 1)
+}
+
 func transportSocket(secretname string, tlsMinProtoVersion envoy_tls_v3.TlsParameters_TlsProtocol, cipherSuites []string, alpnprotos ...string) *envoy_core_v3.TransportSocket {
 	secret := &dag.Secret{
 		Object: &v1.Secret{
@@ -3379,6 +3934,22 @@ func transportSocket(secretname string, tlsMinProtoVersion envoy_tls_v3.TlsParam
 	)
 }
 
+func quicTransportSocket(secretname string) *envoy_core_v3.TransportSocket {
+	secret := &dag.Secret{
+		Object: &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretname,
+				Namespace: "default",
+			},
+			Type: v1.SecretTypeTLS,
+			Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+		},
+	}
+	return envoy_v3.DownstreamQUICTransportSocket(
+		envoy_v3.DownstreamTLSContext(secret, envoy_tls_v3.TlsParameters_TLSv1_3, nil, nil, "h3"),
+	)
+}
+
 func listenermap(listeners ...*envoy_listener_v3.Listener) map[string]*envoy_listener_v3.Listener {
 	m := make(map[string]*envoy_listener_v3.Listener)
 	for _, l := range listeners {
@@ -3386,3 +3957,75 @@ func listenermap(listeners ...*envoy_listener_v3.Listener) map[string]*envoy_lis
 	}
 	return m
 }
+
+func TestListenerVisitGatewayListenersHTTP3(t *testing.T) {
+	gateway := types.NamespacedName{Namespace: "projectsesame", Name: "internal"}
+	httpsName := GatewayListenerName(ENVOY_HTTPS_LISTENER, gateway)
+
+	secret := &dag.Secret{
+		Object: &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "secret",
+				Namespace: "default",
+			},
+			Type: v1.SecretTypeTLS,
+			Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+		},
+	}
+
+	root := &dag.DAG{
+		Listeners: []*dag.Listener{{
+			Name: ENVOY_HTTPS_LISTENER,
+			SecureVirtualHosts: []*dag.SecureVirtualHost{{
+				VirtualHost: dag.VirtualHost{Name: "www.example.com"},
+				Secret:      secret,
+			}},
+		}, {
+			Name: httpsName,
+			SecureVirtualHosts: []*dag.SecureVirtualHost{{
+				VirtualHost: dag.VirtualHost{Name: "gateway.example.com"},
+				Secret:      secret,
+			}, {
+				VirtualHost: dag.VirtualHost{Name: "www.example.com"},
+				Secret:      secret,
+			}},
+		}},
+	}
+
+	lc := ListenerCache{
+		Config: ListenerConfig{
+			HTTPSListeners: map[string]Listener{
+				ENVOY_HTTPS_LISTENER: {Name: ENVOY_HTTPS_LISTENER, Address: "0.0.0.0", Port: 8443},
+				httpsName:            {Name: httpsName, Address: "0.0.0.0", Port: 9443},
+			},
+			HTTP3: &HTTP3Config{
+				Address:        "0.0.0.0",
+				Port:           8443,
+				AdvertisedPort: 443,
+			},
+		},
+	}
+	lc.OnChange(root)
+
+	http3FilterFor := func(listener, vhost string) []*envoy_listener_v3.Filter {
+		return envoy_v3.Filters(envoy_v3.HTTPConnectionManagerBuilder().
+			Codec(envoy_v3.HTTPVersion3).
+			AddFilter(envoy_v3.FilterMisdirectedRequests(vhost)).
+			DefaultFilters().
+			MetricsPrefix(ENVOY_HTTP3_LISTENER).
+			RouteConfigName(secureRouteConfigName(listener, vhost)).
+			AccessLoggers(envoy_v3.FileAccessLogEnvoy(DEFAULT_HTTPS_ACCESS_LOG, "", nil)).
+			Get())
+	}
+
+	// The Gateway's vhosts are also served over HTTP/3, except for
+	// the ones that the default HTTPS listener already serves.
+	http3 := lc.values[ENVOY_HTTP3_LISTENER]
+	if !assert.NotNil(t, http3) || !assert.Len(t, http3.FilterChains, 2) {
+		return
+	}
+	assert.Equal(t, []string{"gateway.example.com"}, http3.FilterChains[0].FilterChainMatch.ServerNames)
+	protobuf.ExpectEqual(t, http3FilterFor(httpsName, "gateway.example.com"), http3.FilterChains[0].Filters)
+	assert.Equal(t, []string{"www.example.com"}, http3.FilterChains[1].FilterChainMatch.ServerNames)
+	protobuf.ExpectEqual(t, http3FilterFor(ENVOY_HTTPS_LISTENER, "www.example.com"), http3.FilterChains[1].Filters)
+}
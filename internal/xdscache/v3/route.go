@@ -33,6 +33,10 @@ type RouteCache struct {
 	mu     sync.Mutex
 	values map[string]*envoy_route_v3.RouteConfiguration
 	sesame.Cond
+
+	// AltSvc, if not empty, is the value of an alt-svc header added
+	// to responses from HTTPS virtual hosts, advertising HTTP/3.
+	AltSvc string
 }
 
 // Update replaces the contents of the cache with the supplied map.
@@ -91,38 +95,55 @@ func (c *RouteCache) OnChange(root *dag.DAG) {
 	// 	- one for all the HTTP vhost routes -- "ingress_http"
 	//	- one per svhost -- "https/<vhost fqdn>"
 	//	- one for fallback cert (if configured) -- "ingress_fallbackcert"
+	//	- one per listener that Gateways are mapped onto for its HTTP
+	//	  vhost routes -- "<listener>", and one per svhost of such
+	//	  a listener -- "https/<listener>/<vhost fqdn>"
 	routeConfigs := map[string]*envoy_route_v3.RouteConfiguration{
 		ENVOY_HTTP_LISTENER: envoy_v3.RouteConfiguration(ENVOY_HTTP_LISTENER),
 	}
 
-	for vhost, routes := range root.GetVirtualHostRoutes() {
-		sortRoutes(routes)
-		routeConfigs[ENVOY_HTTP_LISTENER].VirtualHosts = append(routeConfigs[ENVOY_HTTP_LISTENER].VirtualHosts,
-			envoy_v3.VirtualHostAndRoutes(vhost, routes, false, nil))
-	}
+	// Advertise HTTP/3 for the secure vhosts that the HTTP/3
+	// listener serves.
+	http3 := http3VirtualHosts(root.Listeners)
 
-	for vhost, routes := range root.GetSecureVirtualHostRoutes() {
-		// Add secure vhost route config if not already present.
-		name := path.Join("https", vhost.VirtualHost.Name)
-		if _, ok := routeConfigs[name]; !ok {
-			routeConfigs[name] = envoy_v3.RouteConfiguration(name)
+	for _, listener := range root.Listeners {
+		for vhost, routes := range listener.GetVirtualHostRoutes() {
+			// Add the listener's route config if not already present.
+			if _, ok := routeConfigs[listener.Name]; !ok {
+				routeConfigs[listener.Name] = envoy_v3.RouteConfiguration(listener.Name)
+			}
+
+			sortRoutes(routes)
+			routeConfigs[listener.Name].VirtualHosts = append(routeConfigs[listener.Name].VirtualHosts,
+				envoy_v3.VirtualHostAndRoutes(vhost, routes, false, nil))
 		}
 
-		sortRoutes(routes)
-		routeConfigs[name].VirtualHosts = append(routeConfigs[name].VirtualHosts,
-			envoy_v3.VirtualHostAndRoutes(&vhost.VirtualHost, routes, true, vhost.AuthorizationService))
-
-		// A fallback route configuration contains routes for all the vhosts that have the fallback certificate enabled.
-		// When a request is received, the default TLS filterchain will accept the connection,
-		// and this routing table in RDS defines where the request proxies next.
-		if vhost.FallbackCertificate != nil {
-			// Add fallback route config if not already present.
-			if _, ok := routeConfigs[ENVOY_FALLBACK_ROUTECONFIG]; !ok {
-				routeConfigs[ENVOY_FALLBACK_ROUTECONFIG] = envoy_v3.RouteConfiguration(ENVOY_FALLBACK_ROUTECONFIG)
+		for vhost, routes := range listener.GetSecureVirtualHostRoutes() {
+			// Add secure vhost route config if not already present.
+			name := secureRouteConfigName(listener.Name, vhost.VirtualHost.Name)
+			if _, ok := routeConfigs[name]; !ok {
+				routeConfigs[name] = envoy_v3.RouteConfiguration(name)
+				if c.AltSvc != "" && http3[listener.Name][vhost.VirtualHost.Name] {
+					routeConfigs[name].ResponseHeadersToAdd = envoy_v3.AltSvcHeaders(c.AltSvc)
+				}
 			}
 
-			routeConfigs[ENVOY_FALLBACK_ROUTECONFIG].VirtualHosts = append(routeConfigs[ENVOY_FALLBACK_ROUTECONFIG].VirtualHosts,
+			sortRoutes(routes)
+			routeConfigs[name].VirtualHosts = append(routeConfigs[name].VirtualHosts,
 				envoy_v3.VirtualHostAndRoutes(&vhost.VirtualHost, routes, true, vhost.AuthorizationService))
+
+			// A fallback route configuration contains routes for all the vhosts that have the fallback certificate enabled.
+			// When a request is received, the default TLS filterchain will accept the connection,
+			// and this routing table in RDS defines where the request proxies next.
+			if vhost.FallbackCertificate != nil {
+				// Add fallback route config if not already present.
+				if _, ok := routeConfigs[ENVOY_FALLBACK_ROUTECONFIG]; !ok {
+					routeConfigs[ENVOY_FALLBACK_ROUTECONFIG] = envoy_v3.RouteConfiguration(ENVOY_FALLBACK_ROUTECONFIG)
+				}
+
+				routeConfigs[ENVOY_FALLBACK_ROUTECONFIG].VirtualHosts = append(routeConfigs[ENVOY_FALLBACK_ROUTECONFIG].VirtualHosts,
+					envoy_v3.VirtualHostAndRoutes(&vhost.VirtualHost, routes, true, vhost.AuthorizationService))
+			}
 		}
 	}
 
@@ -133,6 +154,18 @@ func (c *RouteCache) OnChange(root *dag.DAG) {
 	c.Update(routeConfigs)
 }
 
+// secureRouteConfigName returns the name of the route configuration
+// for a secure virtual host of the named HTTPS listener. The secure
+// virtual hosts of listeners that Gateways are mapped onto have their
+// own route configurations, since their hostnames may overlap with
+// those of the default HTTPS listener.
+func secureRouteConfigName(listener, vhost string) string {
+	if listener == ENVOY_HTTPS_LISTENER {
+		return path.Join("https", vhost)
+	}
+	return path.Join("https", listener, vhost)
+}
+
 // sortRoutes sorts the given Route slice in place. Routes are ordered
 // first by path match type, path match value via string comparison and
 // then by the length of the HeaderMatch slice (if any). The HeaderMatch
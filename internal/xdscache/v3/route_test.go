@@ -140,6 +140,7 @@ func TestRouteVisit(t *testing.T) {
 	tests := map[string]struct {
 		objs                []interface{}
 		fallbackCertificate *types.NamespacedName
+		altSvc              string
 		want                map[string]*envoy_route_v3.RouteConfiguration
 	}{
 		"nothing": {
@@ -392,6 +393,78 @@ func TestRouteVisit(t *testing.T) {
 				),
 			),
 		},
+		"vhost ingress with secret and http3 alt-svc": {
+			altSvc: `h3=":443"; ma=86400`,
+			objs: []interface{}{
+				&networking_v1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "simple",
+						Namespace: "default",
+					},
+					Spec: networking_v1.IngressSpec{
+						TLS: []networking_v1.IngressTLS{{
+							Hosts:      []string{"www.example.com"},
+							SecretName: "secret",
+						}},
+						Rules: []networking_v1.IngressRule{{
+							Host: "www.example.com",
+							IngressRuleValue: networking_v1.IngressRuleValue{
+								HTTP: &networking_v1.HTTPIngressRuleValue{
+									Paths: []networking_v1.HTTPIngressPath{{
+										Backend: networking_v1.IngressBackend{
+											Service: &networking_v1.IngressServiceBackend{
+												Name: "kuard",
+												Port: networking_v1.ServiceBackendPort{Name: "www"},
+											},
+										},
+									}},
+								},
+							},
+						}},
+					},
+				},
+				&v1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "secret",
+						Namespace: "default",
+					},
+					Type: "kubernetes.io/tls",
+					Data: secretdata(CERTIFICATE, RSA_PRIVATE_KEY),
+				},
+				&v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "kuard",
+						Namespace: "default",
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{{
+							Name:       "www",
+							Protocol:   "TCP",
+							Port:       8080,
+							TargetPort: intstr.FromInt(8080),
+						}},
+					},
+				},
+			},
+			want: routeConfigurations(
+				envoy_v3.RouteConfiguration("ingress_http",
+					envoy_v3.VirtualHost("www.example.com",
+						&envoy_route_v3.Route{
+							Match:  routePrefix("/"),
+							Action: routecluster("default/kuard/8080/da39a3ee5e"),
+						},
+					),
+				),
+				withAltSvc(envoy_v3.RouteConfiguration("https/www.example.com",
+					envoy_v3.VirtualHost("www.example.com",
+						&envoy_route_v3.Route{
+							Match:  routePrefix("/"),
+							Action: routecluster("default/kuard/8080/da39a3ee5e"),
+						},
+					),
+				), `h3=":443"; ma=86400`),
+			),
+		},
 		"simple httpproxy with secret": {
 			objs: []interface{}{
 				&sesame_api_v1.HTTPProxy{
@@ -3057,13 +3130,75 @@ func TestRouteVisit(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			var rc RouteCache
+			rc := RouteCache{AltSvc: tc.altSvc}
 			rc.OnChange(buildDAGFallback(t, tc.fallbackCertificate, tc.objs...))
 			protobuf.ExpectEqual(t, tc.want, rc.values)
 		})
 	}
 }
 
+func TestRouteVisitGatewayListeners(t *testing.T) {
+	route := &dag.Route{
+		PathMatchCondition: &dag.PrefixMatchCondition{Prefix: "/"},
+		DirectResponse:     &dag.DirectResponse{StatusCode: http.StatusServiceUnavailable},
+	}
+
+	vhost := func(name string) dag.VirtualHost {
+		return dag.VirtualHost{
+			Name:   name,
+			Routes: map[string]*dag.Route{route.PathMatchCondition.String(): route},
+		}
+	}
+
+	secret := &dag.Secret{Object: &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "default"},
+	}}
+
+	root := &dag.DAG{
+		Listeners: []*dag.Listener{{
+			Name:         ENVOY_HTTP_LISTENER,
+			VirtualHosts: []*dag.VirtualHost{{Name: "www.example.com", Routes: vhost("www.example.com").Routes}},
+		}, {
+			Name:               ENVOY_HTTPS_LISTENER,
+			SecureVirtualHosts: []*dag.SecureVirtualHost{{VirtualHost: vhost("www.example.com"), Secret: secret}},
+		}, {
+			Name:         "ingress_http_projectsesame_internal",
+			VirtualHosts: []*dag.VirtualHost{{Name: "www.example.com", Routes: vhost("www.example.com").Routes}},
+		}, {
+			Name: "ingress_https_projectsesame_internal",
+			SecureVirtualHosts: []*dag.SecureVirtualHost{
+				{VirtualHost: vhost("www.example.com"), Secret: secret},
+				{VirtualHost: vhost("gateway.example.com"), Secret: secret},
+			},
+		}},
+	}
+
+	rc := RouteCache{AltSvc: `h3=":443"; ma=86400`}
+	rc.OnChange(root)
+
+	// Virtual hosts with the same name on different
+	// listeners have separate route configurations.
+	var names []string
+	for name, cfg := range rc.values {
+		names = append(names, name)
+		assert.Len(t, cfg.VirtualHosts, 1)
+	}
+	assert.ElementsMatch(t, []string{
+		"ingress_http",
+		"https/www.example.com",
+		"ingress_http_projectsesame_internal",
+		"https/ingress_https_projectsesame_internal/www.example.com",
+		"https/ingress_https_projectsesame_internal/gateway.example.com",
+	}, names)
+
+	// HTTP/3 is advertised for the vhosts that the HTTP/3 listener
+	// serves. A name on more than one listener is only served for
+	// the default HTTPS listener.
+	assert.NotEmpty(t, rc.values["https/www.example.com"].ResponseHeadersToAdd)
+	assert.Empty(t, rc.values["https/ingress_https_projectsesame_internal/www.example.com"].ResponseHeadersToAdd)
+	assert.NotEmpty(t, rc.values["https/ingress_https_projectsesame_internal/gateway.example.com"].ResponseHeadersToAdd)
+}
+
 func TestSortLongestRouteFirst(t *testing.T) {
 	tests := map[string]struct {
 		routes []*dag.Route
@@ -3354,6 +3489,17 @@ func routeConfigurations(rcs ...*envoy_route_v3.RouteConfiguration) map[string]*
 	return m
 }
 
+func withAltSvc(rc *envoy_route_v3.RouteConfiguration, altSvc string) *envoy_route_v3.RouteConfiguration {
+	rc.ResponseHeadersToAdd = []*envoy_core_v3.HeaderValueOption{{
+		Header: &envoy_core_v3.HeaderValue{
+			Key:   "alt-svc",
+			Value: altSvc,
+		},
+		Append: protobuf.Bool(false),
+	}}
+	return rc
+}
+
 func withMirrorPolicy(route *envoy_route_v3.Route_Route, mirror string) *envoy_route_v3.Route_Route {
 	route.Route.RequestMirrorPolicies = []*envoy_route_v3.RouteAction_RequestMirrorPolicy{{
 		Cluster: mirror,
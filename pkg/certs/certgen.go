@@ -14,6 +14,10 @@
 package certs
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha1" // nolint:gosec
@@ -41,6 +45,11 @@ const (
 	// (in days).
 	DefaultCertificateLifetime = 365
 
+	// DefaultCALifetime holds the default lifetime of a generated
+	// CA certificate (in days). The CA outlives the certificates it
+	// issues, so that they can be renewed without replacing the CA.
+	DefaultCALifetime = 3650
+
 	// DefaultNamespace where Sesame is deployed. This value is added
 	// to the certificates Subject Alt Names.
 	DefaultNamespace = "projectsesame"
@@ -50,17 +59,67 @@ const (
 	// configuring Subject Alt Names on the certificates.
 	DefaultDNSName = "cluster.local"
 
-	// keySize sets the RSA key size to 2048 bits. This is minimum recommended size
-	// for RSA keys.
-	keySize = 2048
+	// DefaultKeyType is the type of key generated when no key type
+	// is configured.
+	DefaultKeyType = KeyTypeRSA2048
 )
 
+// KeyType is the type and size of a generated private key.
+type KeyType string
+
+const (
+	// KeyTypeRSA2048 generates 2048 bit RSA keys. This is the minimum
+	// recommended size for RSA keys.
+	KeyTypeRSA2048 KeyType = "rsa2048"
+
+	// KeyTypeRSA4096 generates 4096 bit RSA keys.
+	KeyTypeRSA4096 KeyType = "rsa4096"
+
+	// KeyTypeECDSAP256 generates ECDSA keys on the NIST P-256 curve.
+	KeyTypeECDSAP256 KeyType = "ecdsa-p256"
+
+	// KeyTypeECDSAP384 generates ECDSA keys on the NIST P-384 curve.
+	KeyTypeECDSAP384 KeyType = "ecdsa-p384"
+
+	// KeyTypeEd25519 generates Ed25519 keys.
+	KeyTypeEd25519 KeyType = "ed25519"
+)
+
+// KeyTypes lists the supported key types.
+var KeyTypes = []KeyType{
+	KeyTypeRSA2048,
+	KeyTypeRSA4096,
+	KeyTypeECDSAP256,
+	KeyTypeECDSAP384,
+	KeyTypeEd25519,
+}
+
+// validateEnvoyKeyType returns an error if Envoy cannot serve a
+// certificate with a key of keyType. Envoy only supports RSA and
+// ECDSA P-256 keys.
+func validateEnvoyKeyType(keyType KeyType) error {
+	switch keyType {
+	case KeyTypeRSA2048, KeyTypeRSA4096, KeyTypeECDSAP256:
+		return nil
+	case KeyTypeECDSAP384, KeyTypeEd25519:
+		return fmt.Errorf("key type %q is not supported by Envoy, use %q, %q or %q",
+			keyType, KeyTypeRSA2048, KeyTypeRSA4096, KeyTypeECDSAP256)
+	default:
+		return fmt.Errorf("unsupported key type %q", keyType)
+	}
+}
+
 // Configuration holds config parameters used for generating certificates.
 type Configuration struct {
 
 	// Lifetime is the number of days for which certificates will be valid.
+	// Certificates never outlive the CA that issues them.
 	Lifetime uint
 
+	// CALifetime is the number of days for which a generated CA
+	// certificate will be valid. Defaults to DefaultCALifetime.
+	CALifetime uint
+
 	// Namespace is the Kubernetes namespace name to add to the generated
 	// certificates Subject Alternate Name values.
 	Namespace string
@@ -75,16 +134,40 @@ type Configuration struct {
 
 	// EnvoyServiceName holds the name of the Envoy service name.
 	EnvoyServiceName string
+
+	// WebhookServiceName optionally holds the name of the admission
+	// webhook service. If set, a certificate for the webhook is also
+	// generated.
+	WebhookServiceName string
+
+	// KeyType is the type of the generated private keys. Defaults
+	// to DefaultKeyType. Since the same type is used for the Envoy
+	// key, it must be an RSA or ECDSA P-256 type.
+	KeyType KeyType
+
+	// CACertificate and CAPrivateKey optionally hold an existing
+	// PEM encoded CA keypair, such as an organization's intermediate
+	// CA, used to issue the certificates instead of generating a new
+	// self-signed CA. CACertificate may hold a chain, in which case
+	// the first certificate must be the issuing CA.
+	CACertificate []byte
+	CAPrivateKey  []byte
 }
 
 // Certificates contains a set of Certificates as []byte each holding
 // the CA Cert along with with Sesame & Envoy Certs.
 type Certificates struct {
 	CACertificate     []byte
+	CAPrivateKey      []byte
 	SesameCertificate []byte
 	SesamePrivateKey  []byte
 	EnvoyCertificate  []byte
 	EnvoyPrivateKey   []byte
+
+	// WebhookCertificate and WebhookPrivateKey are only
+	// populated when a webhook service name is configured.
+	WebhookCertificate []byte
+	WebhookPrivateKey  []byte
 }
 
 // GenerateCerts generates a CA Certificate along with certificates for
@@ -96,16 +179,39 @@ func GenerateCerts(config *Configuration) (*Certificates, error) {
 		config = &Configuration{}
 	}
 
+	keyType := config.KeyType
+	if keyType == "" {
+		keyType = DefaultKeyType
+	}
+	if err := validateEnvoyKeyType(keyType); err != nil {
+		return nil, err
+	}
+
 	now := time.Now()
 	expiry := now.Add(24 * time.Duration(uint32OrDefault(config.Lifetime, DefaultCertificateLifetime)) * time.Hour)
-	caCertPEM, caKeyPEM, err := newCA("Project Sesame", expiry)
-	if err != nil {
-		return nil, err
+	caExpiry := now.Add(24 * time.Duration(uint32OrDefault(config.CALifetime, DefaultCALifetime)) * time.Hour)
+
+	var caCertPEM, caKeyPEM []byte
+	switch {
+	case len(config.CACertificate) == 0 && len(config.CAPrivateKey) == 0:
+		var err error
+		caCertPEM, caKeyPEM, err = newCA("Project Sesame", caExpiry, keyType)
+		if err != nil {
+			return nil, err
+		}
+	case len(config.CACertificate) == 0 || len(config.CAPrivateKey) == 0:
+		return nil, fmt.Errorf("both a CA certificate and a CA private key must be provided")
+	default:
+		if _, _, err := parseCA(config.CACertificate, config.CAPrivateKey); err != nil {
+			return nil, err
+		}
+		caCertPEM, caKeyPEM = config.CACertificate, config.CAPrivateKey
 	}
 
-	sesameCert, sesameKey, err := newCert(caCertPEM,
+	sesameCert, sesameKey, err := newServiceCert(caCertPEM,
 		caKeyPEM,
 		expiry,
+		keyType,
 		stringOrDefault(config.SesameServiceName, DefaultSesameServiceName),
 		stringOrDefault(config.Namespace, DefaultNamespace),
 		stringOrDefault(config.DNSName, DefaultDNSName),
@@ -114,9 +220,10 @@ func GenerateCerts(config *Configuration) (*Certificates, error) {
 		return nil, err
 	}
 
-	envoyCert, envoyKey, err := newCert(caCertPEM,
+	envoyCert, envoyKey, err := newServiceCert(caCertPEM,
 		caKeyPEM,
 		expiry,
+		keyType,
 		stringOrDefault(config.EnvoyServiceName, DefaultEnvoyServiceName),
 		stringOrDefault(config.Namespace, DefaultNamespace),
 		stringOrDefault(config.DNSName, DefaultDNSName),
@@ -125,63 +232,135 @@ func GenerateCerts(config *Configuration) (*Certificates, error) {
 		return nil, err
 	}
 
-	return &Certificates{
+	certs := &Certificates{
 		CACertificate:     caCertPEM,
+		CAPrivateKey:      caKeyPEM,
 		SesameCertificate: sesameCert,
 		SesamePrivateKey:  sesameKey,
 		EnvoyCertificate:  envoyCert,
 		EnvoyPrivateKey:   envoyKey,
-	}, nil
+	}
+
+	if config.WebhookServiceName != "" {
+		certs.WebhookCertificate, certs.WebhookPrivateKey, err = newServiceCert(caCertPEM,
+			caKeyPEM,
+			expiry,
+			keyType,
+			config.WebhookServiceName,
+			stringOrDefault(config.Namespace, DefaultNamespace),
+			stringOrDefault(config.DNSName, DefaultDNSName),
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return certs, nil
 }
 
-// newCert generates a new keypair given the CA keypair, the expiry time, the service name
-// ("sesame" or "envoy"), and the Kubernetes namespace the service will run in (because
-// of the Kubernetes DNS schema.)
+// RenewCertificate issues a new keypair given the CA keypair and an
+// existing certificate, with the same common name, DNS names and key
+// type as the existing certificate and a lifetime in days (defaulting
+// to DefaultCertificateLifetime).
 // The return values are cert, key, err.
-func newCert(caCertPEM, caKeyPEM []byte, expiry time.Time, service, namespace, dnsname string) ([]byte, []byte, error) {
+func RenewCertificate(caCertPEM, caKeyPEM, certPEM []byte, lifetime uint) ([]byte, []byte, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, nil, fmt.Errorf("failed to decode certificate PEM")
+	}
 
-	caKeyPair, err := tls.X509KeyPair(caCertPEM, caKeyPEM)
+	cert, err := x509.ParseCertificate(block.Bytes)
 	if err != nil {
 		return nil, nil, err
 	}
-	caCert, err := x509.ParseCertificate(caKeyPair.Certificate[0])
+
+	keyType, err := keyTypeOf(cert.PublicKey)
 	if err != nil {
 		return nil, nil, err
 	}
-	caKey, ok := caKeyPair.PrivateKey.(*rsa.PrivateKey)
-	if !ok {
-		return nil, nil, fmt.Errorf("CA private key has unexpected type %T", caKeyPair.PrivateKey)
+
+	expiry := time.Now().Add(24 * time.Duration(uint32OrDefault(lifetime, DefaultCertificateLifetime)) * time.Hour)
+	return newCert(caCertPEM, caKeyPEM, expiry, keyType, cert.Subject.CommonName, cert.DNSNames)
+}
+
+// RenewCA issues a new self-signed CA keypair with the same common name
+// and key type as the existing CA and a lifetime in days (defaulting to
+// DefaultCALifetime). A CA that is not self-signed, such as an
+// organization's intermediate CA, must be renewed by its issuer.
+// The return values are cacert, cakey, err.
+func RenewCA(caCertPEM, caKeyPEM []byte, lifetime uint) ([]byte, []byte, error) {
+	caCert, _, err := parseCA(caCertPEM, caKeyPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := caCert.CheckSignatureFrom(caCert); err != nil {
+		return nil, nil, fmt.Errorf("CA certificate %q is not self-signed and must be renewed by its issuer", caCert.Subject.CommonName)
 	}
 
-	newKey, err := rsa.GenerateKey(rand.Reader, keySize)
+	keyType, err := keyTypeOf(caCert.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	expiry := time.Now().Add(24 * time.Duration(uint32OrDefault(lifetime, DefaultCALifetime)) * time.Hour)
+	return newCA(caCert.Subject.CommonName, expiry, keyType)
+}
+
+// newServiceCert generates a new keypair given the CA keypair, the expiry time, the key type,
+// the service name ("sesame" or "envoy"), and the Kubernetes namespace the service will run
+// in (because of the Kubernetes DNS schema.)
+// The return values are cert, key, err.
+func newServiceCert(caCertPEM, caKeyPEM []byte, expiry time.Time, keyType KeyType, service, namespace, dnsname string) ([]byte, []byte, error) {
+	return newCert(caCertPEM, caKeyPEM, expiry, keyType, service, serviceNames(service, namespace, dnsname))
+}
+
+// newCert generates a new keypair given the CA keypair, the expiry time,
+// the key type, and the common name and the DNS names of the certificate.
+// The expiry time is clamped to the expiry of the CA.
+// The return values are cert, key, err.
+func newCert(caCertPEM, caKeyPEM []byte, expiry time.Time, keyType KeyType, cn string, dnsNames []string) ([]byte, []byte, error) {
+
+	caCert, caKey, err := parseCA(caCertPEM, caKeyPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if expiry.After(caCert.NotAfter) {
+		expiry = caCert.NotAfter
+	}
+
+	newKey, err := generateKey(keyType)
 	if err != nil {
 		return nil, nil, fmt.Errorf("cannot generate key: %v", err)
 	}
 
+	keyUsage := x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment
+	if _, ok := newKey.(*rsa.PrivateKey); ok {
+		keyUsage |= x509.KeyUsageDataEncipherment | x509.KeyUsageKeyEncipherment
+	}
+
 	now := time.Now()
 	template := &x509.Certificate{
 		SerialNumber: newSerial(now),
 		Subject: pkix.Name{
-			CommonName: service,
+			CommonName: cn,
 		},
 		NotBefore:    now.UTC().AddDate(0, 0, -1),
 		NotAfter:     expiry.UTC(),
-		SubjectKeyId: bigIntHash(newKey.N),
-		KeyUsage: x509.KeyUsageDigitalSignature |
-			x509.KeyUsageDataEncipherment |
-			x509.KeyUsageKeyEncipherment |
-			x509.KeyUsageContentCommitment,
-		DNSNames: serviceNames(service, namespace, dnsname),
-	}
-	newCert, err := x509.CreateCertificate(rand.Reader, template, caCert, &newKey.PublicKey, caKey)
+		SubjectKeyId: subjectKeyID(newKey.Public()),
+		KeyUsage:     keyUsage,
+		DNSNames:     dnsNames,
+	}
+	newCert, err := x509.CreateCertificate(rand.Reader, template, caCert, newKey.Public(), caKey)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	newKeyPEM := pem.EncodeToMemory(&pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(newKey),
-	})
+	newKeyPEM, err := encodeKey(newKey)
+	if err != nil {
+		return nil, nil, err
+	}
 	newCertPEM := pem.EncodeToMemory(&pem.Block{
 		Type:  "CERTIFICATE",
 		Bytes: newCert,
@@ -190,15 +369,42 @@ func newCert(caCertPEM, caKeyPEM []byte, expiry time.Time, service, namespace, d
 
 }
 
-// newCA generates a new CA, given the CA's CN and an expiry time.
+// parseCA parses the CA keypair and checks that the certificate
+// can be used to issue certificates.
+func parseCA(caCertPEM, caKeyPEM []byte) (*x509.Certificate, crypto.Signer, error) {
+	caKeyPair, err := tls.X509KeyPair(caCertPEM, caKeyPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CA keypair: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(caKeyPair.Certificate[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	if !caCert.IsCA {
+		return nil, nil, fmt.Errorf("CA certificate %q is not a CA", caCert.Subject.CommonName)
+	}
+	caKey, ok := caKeyPair.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("CA private key has unexpected type %T", caKeyPair.PrivateKey)
+	}
+	return caCert, caKey, nil
+}
+
+// newCA generates a new CA, given the CA's CN, an expiry time and
+// the key type.
 // The return order is cacert, cakey, error.
-func newCA(cn string, expiry time.Time) ([]byte, []byte, error) {
+func newCA(cn string, expiry time.Time, keyType KeyType) ([]byte, []byte, error) {
 
-	key, err := rsa.GenerateKey(rand.Reader, keySize)
+	key, err := generateKey(keyType)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	keyUsage := x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		keyUsage |= x509.KeyUsageKeyEncipherment
+	}
+
 	now := time.Now()
 	serial := newSerial(now)
 	template := &x509.Certificate{
@@ -209,12 +415,12 @@ func newCA(cn string, expiry time.Time) ([]byte, []byte, error) {
 		},
 		NotBefore:             now.UTC().AddDate(0, 0, -1),
 		NotAfter:              expiry.UTC(),
-		SubjectKeyId:          bigIntHash(key.N),
-		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		SubjectKeyId:          subjectKeyID(key.Public()),
+		KeyUsage:              keyUsage,
 		IsCA:                  true,
 		BasicConstraintsValid: true,
 	}
-	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -222,13 +428,99 @@ func newCA(cn string, expiry time.Time) ([]byte, []byte, error) {
 		Type:  "CERTIFICATE",
 		Bytes: certDER,
 	})
-	keyPEMData := pem.EncodeToMemory(&pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(key),
-	})
+	keyPEMData, err := encodeKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
 	return certPEMData, keyPEMData, nil
 }
 
+// generateKey generates a new private key of the given type.
+func generateKey(keyType KeyType) (crypto.Signer, error) {
+	switch keyType {
+	case KeyTypeRSA2048:
+		return rsa.GenerateKey(rand.Reader, 2048)
+	case KeyTypeRSA4096:
+		return rsa.GenerateKey(rand.Reader, 4096)
+	case KeyTypeECDSAP256:
+		return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case KeyTypeECDSAP384:
+		return ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	case KeyTypeEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", keyType)
+	}
+}
+
+// keyTypeOf returns the KeyType of the public key.
+func keyTypeOf(pub crypto.PublicKey) (KeyType, error) {
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() > 2048 {
+			return KeyTypeRSA4096, nil
+		}
+		return KeyTypeRSA2048, nil
+	case *ecdsa.PublicKey:
+		if pub.Curve == elliptic.P384() {
+			return KeyTypeECDSAP384, nil
+		}
+		return KeyTypeECDSAP256, nil
+	case ed25519.PublicKey:
+		return KeyTypeEd25519, nil
+	default:
+		return "", fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+// encodeKey PEM encodes the private key. RSA keys are encoded in
+// PKCS #1 form and ECDSA keys in SEC 1 form, as OpenSSL does. Ed25519
+// keys have no such form and are encoded in PKCS #8 form.
+func encodeKey(key crypto.Signer) ([]byte, error) {
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		return pem.EncodeToMemory(&pem.Block{
+			Type:  "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(key),
+		}), nil
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{
+			Type:  "EC PRIVATE KEY",
+			Bytes: der,
+		}), nil
+	default:
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{
+			Type:  "PRIVATE KEY",
+			Bytes: der,
+		}), nil
+	}
+}
+
+// subjectKeyID generates a SubjectKeyId for the public key. RSA keys
+// use the hash of the modulus (see bigIntHash); other keys use the hash
+// of the DER encoded public key.
+func subjectKeyID(pub crypto.PublicKey) []byte {
+	if pub, ok := pub.(*rsa.PublicKey); ok {
+		return bigIntHash(pub.N)
+	}
+
+	// Only fails for unsupported key types, which generateKey
+	// never returns.
+	der, _ := x509.MarshalPKIXPublicKey(pub)
+	h := sha1.New() // nolint:gosec
+	h.Write(der)    // nolint:errcheck
+	return h.Sum(nil)
+}
+
 func newSerial(now time.Time) *big.Int {
 	return big.NewInt(int64(now.Nanosecond()))
 }
@@ -14,7 +14,13 @@
 package certs
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/pem"
 	"fmt"
 	"testing"
@@ -29,6 +35,7 @@ func TestGenerateCerts(t *testing.T) {
 		config            *Configuration
 		wantSesameDNSName string
 		wantEnvoyDNSName  string
+		wantWebhookDNS    string
 		wantError         error
 	}
 
@@ -63,6 +70,14 @@ func TestGenerateCerts(t *testing.T) {
 
 			err = verifyCert(got.EnvoyCertificate, roots, tc.wantEnvoyDNSName, currentTime)
 			assert.NoErrorf(t, err, "Validating %s failed", name)
+
+			if tc.wantWebhookDNS == "" {
+				assert.Empty(t, got.WebhookCertificate)
+				assert.Empty(t, got.WebhookPrivateKey)
+			} else {
+				err = verifyCert(got.WebhookCertificate, roots, tc.wantWebhookDNS, currentTime)
+				assert.NoErrorf(t, err, "Validating %s failed", name)
+			}
 		})
 	}
 
@@ -104,6 +119,16 @@ func TestGenerateCerts(t *testing.T) {
 		wantError:         nil,
 	})
 
+	run(t, "webhook service name", testcase{
+		config: &Configuration{
+			WebhookServiceName: "sesame-webhook",
+		},
+		wantSesameDNSName: "sesame",
+		wantEnvoyDNSName:  "envoy",
+		wantWebhookDNS:    "sesame-webhook",
+		wantError:         nil,
+	})
+
 	run(t, "custom dns name", testcase{
 		config: &Configuration{
 			DNSName: "project.sesame",
@@ -119,17 +144,17 @@ func TestGeneratedCertsValid(t *testing.T) {
 	now := time.Now()
 	expiry := now.Add(24 * 365 * time.Hour)
 
-	cacert, cakey, err := newCA("sesame", expiry)
+	cacert, cakey, err := newCA("sesame", expiry, KeyTypeRSA2048)
 	require.NoErrorf(t, err, "Failed to generate CA cert")
 
-	Sesamecert, _, err := newCert(cacert, cakey, expiry, "sesame", "projectsesame", "cluster.local")
+	Sesamecert, _, err := newServiceCert(cacert, cakey, expiry, KeyTypeRSA2048, "sesame", "projectsesame", "cluster.local")
 	require.NoErrorf(t, err, "Failed to generate Sesame cert")
 
 	roots := x509.NewCertPool()
 	ok := roots.AppendCertsFromPEM(cacert)
 	require.Truef(t, ok, "Failed to set up CA cert for testing, maybe it's an invalid PEM")
 
-	envoycert, _, err := newCert(cacert, cakey, expiry, "envoy", "projectsesame", "cluster.local")
+	envoycert, _, err := newServiceCert(cacert, cakey, expiry, KeyTypeRSA2048, "envoy", "projectsesame", "cluster.local")
 	require.NoErrorf(t, err, "Failed to generate Envoy cert")
 
 	tests := map[string]struct {
@@ -155,6 +180,226 @@ func TestGeneratedCertsValid(t *testing.T) {
 
 }
 
+func TestRenewCertificate(t *testing.T) {
+	now := time.Now()
+
+	// The existing certificate is about to expire.
+	cacert, cakey, err := newCA("sesame", now.Add(24*365*time.Hour), KeyTypeECDSAP256)
+	require.NoError(t, err)
+
+	oldcert, _, err := newServiceCert(cacert, cakey, now.Add(time.Hour), KeyTypeECDSAP256, "envoy", "projectsesame", "cluster.local")
+	require.NoError(t, err)
+
+	newcert, newkey, err := RenewCertificate(cacert, cakey, oldcert, 30)
+	require.NoError(t, err)
+	assert.NotEmpty(t, newkey)
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(cacert))
+
+	// The renewed certificate has the same names and the new lifetime.
+	for _, name := range []string{"envoy", "envoy.projectsesame.svc.cluster.local"} {
+		assert.NoError(t, verifyCert(newcert, roots, name, now.Add(29*24*time.Hour)))
+	}
+	assert.Error(t, verifyCert(newcert, roots, "envoy", now.Add(31*24*time.Hour)))
+
+	// The renewed certificate keeps the key type.
+	keyPair, err := tls.X509KeyPair(newcert, newkey)
+	require.NoError(t, err)
+	assert.IsType(t, &ecdsa.PrivateKey{}, keyPair.PrivateKey)
+
+	_, _, err = RenewCertificate(cacert, cakey, []byte("invalid"), 30)
+	assert.Error(t, err)
+}
+
+func TestGenerateCertsCAExpiresFirst(t *testing.T) {
+	got, err := GenerateCerts(&Configuration{
+		Lifetime:   30,
+		CALifetime: 10,
+	})
+	require.NoError(t, err)
+
+	caExpiry, err := certExpiry(got.CACertificate)
+	require.NoError(t, err)
+
+	// The certificates are clamped to the expiry of the CA.
+	for _, cert := range [][]byte{got.SesameCertificate, got.EnvoyCertificate} {
+		notAfter, err := certExpiry(cert)
+		require.NoError(t, err)
+		assert.True(t, notAfter.Equal(caExpiry), "certificate expires at %s, CA expires at %s", notAfter, caExpiry)
+	}
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(got.CACertificate))
+	assert.NoError(t, verifyCert(got.SesameCertificate, roots, "sesame", time.Now().Add(9*24*time.Hour)))
+	assert.Error(t, verifyCert(got.SesameCertificate, roots, "sesame", time.Now().Add(11*24*time.Hour)))
+
+	// By default, the CA outlives the certificates.
+	got, err = GenerateCerts(&Configuration{})
+	require.NoError(t, err)
+
+	caExpiry, err = certExpiry(got.CACertificate)
+	require.NoError(t, err)
+	notAfter, err := certExpiry(got.SesameCertificate)
+	require.NoError(t, err)
+	assert.True(t, caExpiry.After(notAfter.Add(24*time.Hour*(DefaultCALifetime-DefaultCertificateLifetime-1))))
+}
+
+func TestRenewCA(t *testing.T) {
+	now := time.Now()
+
+	cacert, cakey, err := newCA("Project Sesame", now.Add(time.Hour), KeyTypeECDSAP384)
+	require.NoError(t, err)
+
+	newcert, newkey, err := RenewCA(cacert, cakey, 0)
+	require.NoError(t, err)
+
+	keyPair, err := tls.X509KeyPair(newcert, newkey)
+	require.NoError(t, err)
+	require.IsType(t, &ecdsa.PrivateKey{}, keyPair.PrivateKey)
+	assert.Equal(t, elliptic.P384(), keyPair.PrivateKey.(*ecdsa.PrivateKey).Curve)
+
+	ca, err := x509.ParseCertificate(keyPair.Certificate[0])
+	require.NoError(t, err)
+	assert.True(t, ca.IsCA)
+	assert.Equal(t, "Project Sesame", ca.Subject.CommonName)
+	assert.True(t, ca.NotAfter.After(now.Add(24*time.Hour*(DefaultCALifetime-1))))
+
+	// Certificates issued by the new CA are not issued by the old one.
+	cert, _, err := newServiceCert(newcert, newkey, now.Add(24*time.Hour), KeyTypeECDSAP256, "envoy", "projectsesame", "cluster.local")
+	require.NoError(t, err)
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(newcert))
+	assert.NoError(t, verifyCert(cert, roots, "envoy", now))
+
+	roots = x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(cacert))
+	assert.Error(t, verifyCert(cert, roots, "envoy", now))
+}
+
+func certExpiry(certPEM []byte) (time.Time, error) {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return time.Time{}, fmt.Errorf("failed to decode certificate PEM")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return cert.NotAfter, nil
+}
+
+func TestGenerateCertsKeyTypes(t *testing.T) {
+	wantKeys := map[KeyType]interface{}{
+		KeyTypeRSA2048:   &rsa.PrivateKey{},
+		KeyTypeRSA4096:   &rsa.PrivateKey{},
+		KeyTypeECDSAP256: &ecdsa.PrivateKey{},
+	}
+
+	for keyType, wantKey := range wantKeys {
+		keyType, wantKey := keyType, wantKey
+		t.Run(string(keyType), func(t *testing.T) {
+			got, err := GenerateCerts(&Configuration{KeyType: keyType})
+			require.NoError(t, err)
+
+			roots := x509.NewCertPool()
+			require.True(t, roots.AppendCertsFromPEM(got.CACertificate))
+			assert.NoError(t, verifyCert(got.SesameCertificate, roots, "sesame", time.Now()))
+			assert.NoError(t, verifyCert(got.EnvoyCertificate, roots, "envoy", time.Now()))
+
+			for _, pair := range [][2][]byte{
+				{got.CACertificate, got.CAPrivateKey},
+				{got.SesameCertificate, got.SesamePrivateKey},
+				{got.EnvoyCertificate, got.EnvoyPrivateKey},
+			} {
+				keyPair, err := tls.X509KeyPair(pair[0], pair[1])
+				require.NoError(t, err)
+				assert.IsType(t, wantKey, keyPair.PrivateKey)
+			}
+		})
+	}
+
+	// Envoy only serves RSA and ECDSA P-256 keys.
+	_, err := GenerateCerts(&Configuration{KeyType: KeyTypeECDSAP384})
+	assert.EqualError(t, err, `key type "ecdsa-p384" is not supported by Envoy, use "rsa2048", "rsa4096" or "ecdsa-p256"`)
+	_, err = GenerateCerts(&Configuration{KeyType: KeyTypeEd25519})
+	assert.EqualError(t, err, `key type "ed25519" is not supported by Envoy, use "rsa2048", "rsa4096" or "ecdsa-p256"`)
+
+	_, err = GenerateCerts(&Configuration{KeyType: "dsa"})
+	assert.EqualError(t, err, `unsupported key type "dsa"`)
+}
+
+func TestGenerateCertsImportedCA(t *testing.T) {
+	now := time.Now()
+	expiry := now.Add(24 * 365 * time.Hour)
+
+	// An organization root CA and an intermediate CA issued by it.
+	rootCert, rootKey, err := newCA("root", expiry, KeyTypeECDSAP384)
+	require.NoError(t, err)
+	root, rootSigner, err := parseCA(rootCert, rootKey)
+	require.NoError(t, err)
+
+	intermediateKey, err := generateKey(KeyTypeECDSAP256)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          newSerial(now),
+		Subject:               pkix.Name{CommonName: "intermediate"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              expiry,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, root, intermediateKey.Public(), rootSigner)
+	require.NoError(t, err)
+
+	intermediateCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	intermediateKeyPEM, err := encodeKey(intermediateKey)
+	require.NoError(t, err)
+
+	// The chain is provided with the issuing CA first.
+	chain := append(append([]byte{}, intermediateCert...), rootCert...)
+
+	got, err := GenerateCerts(&Configuration{
+		KeyType:       KeyTypeECDSAP256,
+		CACertificate: chain,
+		CAPrivateKey:  intermediateKeyPEM,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, chain, got.CACertificate)
+	assert.Equal(t, intermediateKeyPEM, got.CAPrivateKey)
+
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(rootCert))
+	intermediates := x509.NewCertPool()
+	require.True(t, intermediates.AppendCertsFromPEM(intermediateCert))
+
+	block, _ := pem.Decode(got.EnvoyCertificate)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	_, err = cert.Verify(x509.VerifyOptions{
+		DNSName:       "envoy",
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	assert.NoError(t, err)
+
+	_, err = GenerateCerts(&Configuration{CACertificate: chain})
+	assert.EqualError(t, err, "both a CA certificate and a CA private key must be provided")
+
+	_, err = GenerateCerts(&Configuration{CACertificate: intermediateCert, CAPrivateKey: rootKey})
+	assert.Error(t, err)
+
+	// A leaf certificate cannot be used as the CA.
+	_, err = GenerateCerts(&Configuration{CACertificate: got.EnvoyCertificate, CAPrivateKey: got.EnvoyPrivateKey})
+	assert.EqualError(t, err, `CA certificate "envoy" is not a CA`)
+
+	// Only the issuer of the intermediate CA can renew it.
+	_, _, err = RenewCA(chain, intermediateKeyPEM, 0)
+	assert.EqualError(t, err, `CA certificate "intermediate" is not self-signed and must be renewed by its issuer`)
+}
+
 func verifyCert(certPEM []byte, roots *x509.CertPool, dnsname string, currentTime time.Time) error {
 	block, _ := pem.Decode(certPEM)
 	if block == nil {
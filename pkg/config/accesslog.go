@@ -103,6 +103,7 @@ var envoySimpleOperators = map[string]struct{}{
 	"UPSTREAM_CLUSTER":                              {},
 	"UPSTREAM_HOST":                                 {},
 	"UPSTREAM_LOCAL_ADDRESS":                        {},
+	"UPSTREAM_REMOTE_ADDRESS":                       {},
 	"UPSTREAM_TRANSPORT_FAILURE_REASON":             {},
 }
 
@@ -114,4 +115,6 @@ var envoyComplexOperators = map[string]struct{}{
 	"START_TIME":        {},
 	"TRAILER":           {},
 	"REQ_WITHOUT_QUERY": {},
+	"DYNAMIC_METADATA":  {},
+	"UPSTREAM_METADATA": {},
 }
@@ -17,6 +17,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"net"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -58,6 +60,42 @@ func (g *GatewayParameters) Validate() error {
 		errorString = strings.TrimSpace(fmt.Sprintf("%s controllerName required", errorString))
 	}
 
+	if g.GatewayRef != nil {
+		if len(strings.TrimSpace(g.GatewayRef.Name)) == 0 || len(strings.TrimSpace(g.GatewayRef.Namespace)) == 0 {
+			if len(errorString) > 0 {
+				errorString += ","
+			}
+			errorString = strings.TrimSpace(fmt.Sprintf("%s gatewayRef name and namespace required", errorString))
+		}
+	}
+
+	for i, m := range g.Gateways {
+		if len(strings.TrimSpace(m.Gateway.Name)) == 0 || len(strings.TrimSpace(m.Gateway.Namespace)) == 0 {
+			if len(errorString) > 0 {
+				errorString += ","
+			}
+			errorString = strings.TrimSpace(fmt.Sprintf("%s gateways[%d] gateway name and namespace required", errorString, i))
+		}
+
+		for _, l := range []*GatewayListenerParameters{m.HTTPListener, m.HTTPSListener} {
+			if l != nil && (l.Port < 1 || l.Port > 65535) {
+				if len(errorString) > 0 {
+					errorString += ","
+				}
+				errorString = strings.TrimSpace(fmt.Sprintf("%s gateways[%d] invalid listener port %d", errorString, i, l.Port))
+			}
+		}
+
+		if m.Service != nil {
+			if len(strings.TrimSpace(m.Service.Name)) == 0 || len(strings.TrimSpace(m.Service.Namespace)) == 0 {
+				if len(errorString) > 0 {
+					errorString += ","
+				}
+				errorString = strings.TrimSpace(fmt.Sprintf("%s gateways[%d] service name and namespace required", errorString, i))
+			}
+		}
+	}
+
 	if len(errorString) > 0 {
 		return fmt.Errorf("invalid Gateway parameters specified: %s", errorString)
 	}
@@ -211,7 +249,7 @@ func parseAccessLogFormat(format string) error {
 			return fmt.Errorf("invalid Envoy format: %s, invalid Envoy operator: %s", f, op)
 		}
 
-		if (op == "REQ" || op == "RESP" || op == "TRAILER" || op == "REQ_WITHOUT_QUERY") && f[3] == "" {
+		if (op == "REQ" || op == "RESP" || op == "TRAILER" || op == "REQ_WITHOUT_QUERY" || op == "DYNAMIC_METADATA" || op == "UPSTREAM_METADATA") && f[3] == "" {
 			return fmt.Errorf("invalid Envoy format: %s, arguments required for operator: %s", f, op)
 		}
 
@@ -322,9 +360,15 @@ type TLSParameters struct {
 	// by advanced users. Note that these will be ignored when TLS 1.3 is in
 	// use.
 	CipherSuites TLSCiphers `yaml:"cipher-suites,omitempty"`
+
+	// CertificateExpiryWarning is how long before expiry a serving
+	// certificate is reported as expiring in HTTPProxy and Gateway
+	// status. Set to "0s" to disable the warning. Defaults to "720h".
+	CertificateExpiryWarning string `yaml:"certificate-expiry-warning,omitempty"`
 }
 
-// Validate TLS fallback certificate, client certificate, and cipher suites
+// Validate TLS fallback certificate, client certificate, cipher suites
+// and certificate expiry warning.
 func (t TLSParameters) Validate() error {
 	// Check TLS secret names.
 	if err := t.FallbackCertificate.Validate(); err != nil {
@@ -339,6 +383,16 @@ func (t TLSParameters) Validate() error {
 		return fmt.Errorf("invalid TLS cipher suites: %w", err)
 	}
 
+	if t.CertificateExpiryWarning != "" {
+		window, err := time.ParseDuration(t.CertificateExpiryWarning)
+		if err != nil {
+			return fmt.Errorf("invalid TLS certificate expiry warning %q: %w", t.CertificateExpiryWarning, err)
+		}
+		if window < 0 {
+			return fmt.Errorf("invalid TLS certificate expiry warning %q: must not be negative", t.CertificateExpiryWarning)
+		}
+	}
+
 	return nil
 }
 
@@ -355,6 +409,52 @@ type GatewayParameters struct {
 	// GatewayClass. The string takes the form of "projectsesame.io/<namespace>/sesame".
 	// If unset, the gatewayclass controller will not be started.
 	ControllerName string `yaml:"controllerName,omitempty"`
+
+	// GatewayRef defines a specific Gateway that this Sesame
+	// instance corresponds to. If set, Sesame will reconcile
+	// only this gateway, and will not reconcile any gateway
+	// classes. Each port of the Gateway's listeners is served
+	// by an Envoy listener of its own.
+	GatewayRef *NamespacedName `yaml:"gatewayRef,omitempty"`
+
+	// Gateways maps Gateways onto their own Envoy listeners and
+	// services. Gateways that are not listed share Envoy's HTTP
+	// and HTTPS listeners, and have the addresses of Envoy's
+	// service written to their status.
+	Gateways []GatewayEnvoyMapping `yaml:"gateways,omitempty"`
+}
+
+// GatewayEnvoyMapping maps a Gateway onto the Envoy listeners that
+// serve its listeners and the Envoy service that exposes them.
+type GatewayEnvoyMapping struct {
+	// Gateway is the namespace and name of the Gateway.
+	Gateway NamespacedName `yaml:"gateway"`
+
+	// HTTPListener is the Envoy listener that serves the Gateway's
+	// HTTP listeners. If unset, Envoy's HTTP listener serves them.
+	HTTPListener *GatewayListenerParameters `yaml:"http,omitempty"`
+
+	// HTTPSListener is the Envoy listener that serves the Gateway's
+	// HTTPS and TLS listeners. If unset, Envoy's HTTPS listener
+	// serves them.
+	HTTPSListener *GatewayListenerParameters `yaml:"https,omitempty"`
+
+	// Service is the Envoy service that exposes the Gateway's
+	// listeners. If unset, the addresses of Envoy's service are
+	// written to the Gateway's status.
+	Service *NamespacedName `yaml:"service,omitempty"`
+}
+
+// GatewayListenerParameters holds the address and access log
+// of an Envoy listener that serves a Gateway.
+type GatewayListenerParameters struct {
+	// Address defaults to 0.0.0.0 if unset.
+	Address string `yaml:"address,omitempty"`
+	Port    int    `yaml:"port"`
+
+	// AccessLog defaults to the access log of Envoy's
+	// HTTP or HTTPS listener if unset.
+	AccessLog string `yaml:"access-log,omitempty"`
 }
 
 // LeaderElectionParameters holds the config bits for leader election
@@ -467,14 +567,18 @@ func (t TimeoutParameters) Validate() error {
 }
 
 type HeadersPolicy struct {
-	Set    map[string]string `yaml:"set,omitempty"`
-	Remove []string          `yaml:"remove,omitempty"`
+	Set         map[string]string `yaml:"set,omitempty"`
+	Add         map[string]string `yaml:"add,omitempty"`
+	AddIfAbsent map[string]string `yaml:"add-if-absent,omitempty"`
+	Remove      []string          `yaml:"remove,omitempty"`
 }
 
 func (h HeadersPolicy) Validate() error {
-	for key := range h.Set {
-		if msgs := validation.IsHTTPHeaderName(key); len(msgs) != 0 {
-			return fmt.Errorf("invalid header name %q: %v", key, msgs)
+	for _, headers := range []map[string]string{h.Set, h.Add, h.AddIfAbsent} {
+		for key := range headers {
+			if msgs := validation.IsHTTPHeaderName(key); len(msgs) != 0 {
+				return fmt.Errorf("invalid header name %q: %v", key, msgs)
+			}
 		}
 	}
 	for _, val := range h.Remove {
@@ -519,6 +623,96 @@ type ClusterParameters struct {
 	// See https://www.envoyproxy.io/docs/envoy/latest/api-v3/config/cluster/v3/cluster.proto.html#envoy-v3-api-enum-config-cluster-v3-cluster-dnslookupfamily
 	// for more information.
 	DNSLookupFamily ClusterDNSFamilyType `yaml:"dns-lookup-family"`
+
+	// ConnectionPolicy defines the default connection pool settings for
+	// clusters generated from HTTPProxy services.
+	ConnectionPolicy ConnectionPolicyParameters `yaml:"connection-policy,omitempty"`
+
+	// RetryBudget defines the default retry budget for clusters
+	// generated from HTTPProxy services.
+	RetryBudget *RetryBudgetParameters `yaml:"retry-budget,omitempty"`
+}
+
+// RetryBudgetParameters limits concurrent retries relative to the
+// number of active requests to an upstream cluster.
+type RetryBudgetParameters struct {
+	// BudgetPercent is the percentage of active requests that
+	// may be retries. Defaults to Envoy's 20%.
+	BudgetPercent *uint32 `yaml:"budget-percent,omitempty"`
+
+	// MinRetryConcurrency is the number of parallel retries that
+	// are always allowed. Defaults to Envoy's 3.
+	MinRetryConcurrency *uint32 `yaml:"min-retry-concurrency,omitempty"`
+}
+
+// Validate ensures that the retry budget parameters are valid.
+func (r *RetryBudgetParameters) Validate() error {
+	if r == nil {
+		return nil
+	}
+
+	if r.BudgetPercent != nil && *r.BudgetPercent > 100 {
+		return fmt.Errorf("invalid retry budget percent %d: must be at most 100", *r.BudgetPercent)
+	}
+
+	return nil
+}
+
+// ConnectionPolicyParameters holds the default connection pool settings
+// for upstream clusters. Zero values leave the Envoy defaults in place.
+type ConnectionPolicyParameters struct {
+	// MaxConnections is the maximum number of connections
+	// that Envoy will make to an upstream cluster.
+	MaxConnections uint32 `yaml:"max-connections,omitempty"`
+
+	// MaxPendingRequests is the maximum number of pending
+	// requests that Envoy will allow to an upstream cluster.
+	MaxPendingRequests uint32 `yaml:"max-pending-requests,omitempty"`
+
+	// MaxRequestsPerConnection is the maximum number of requests
+	// Envoy will send over a single upstream connection.
+	MaxRequestsPerConnection uint32 `yaml:"max-requests-per-connection,omitempty"`
+
+	// MaxConcurrentStreams is the maximum number of concurrent
+	// streams on a single HTTP/2 upstream connection.
+	MaxConcurrentStreams uint32 `yaml:"max-concurrent-streams,omitempty"`
+
+	// IdleTimeout is how long an upstream connection may remain
+	// without active requests before Envoy closes it.
+	IdleTimeout string `yaml:"idle-timeout,omitempty"`
+
+	// TCPKeepalive enables TCP keepalive on upstream connections.
+	TCPKeepalive *TCPKeepaliveParameters `yaml:"tcp-keepalive,omitempty"`
+}
+
+// TCPKeepaliveParameters holds the TCP keepalive settings for upstream connections.
+type TCPKeepaliveParameters struct {
+	// Probes is the maximum number of unanswered keepalive probes.
+	Probes uint32 `yaml:"probes,omitempty"`
+
+	// IdleTime is the number of seconds a connection must be
+	// idle before keepalive probes are sent.
+	IdleTime uint32 `yaml:"idle-time,omitempty"`
+
+	// Interval is the number of seconds between keepalive probes.
+	Interval uint32 `yaml:"interval,omitempty"`
+}
+
+// Validate ensures that the connection policy parameters are valid.
+func (c ConnectionPolicyParameters) Validate() error {
+	if c.MaxConcurrentStreams > math.MaxInt32 {
+		return fmt.Errorf("invalid max concurrent streams %d: must be at most %d", c.MaxConcurrentStreams, math.MaxInt32)
+	}
+
+	switch c.IdleTimeout {
+	case "", "infinity", "infinite":
+	default:
+		if _, err := time.ParseDuration(c.IdleTimeout); err != nil {
+			return fmt.Errorf("invalid connection policy idle timeout %q: %w", c.IdleTimeout, err)
+		}
+	}
+
+	return nil
 }
 
 // NetworkParameters hold various configurable network values.
@@ -542,6 +736,15 @@ type ListenerParameters struct {
 	// See https://www.envoyproxy.io/docs/envoy/latest/api-v2/api/v2/listener.proto#envoy-api-msg-listener-connectionbalanceconfig
 	// for more information.
 	ConnectionBalancer string `yaml:"connection-balancer"`
+
+	// HTTPMaxConnections is the maximum number of active connections
+	// to the HTTP listener. Zero means unlimited.
+	HTTPMaxConnections uint32 `yaml:"http-max-connections,omitempty"`
+
+	// HTTPSMaxConnections is the maximum number of active connections
+	// to each virtual host of the HTTPS listener, since each has its
+	// own filter chain. Zero means unlimited.
+	HTTPSMaxConnections uint32 `yaml:"https-max-connections,omitempty"`
 }
 
 func (p *ListenerParameters) Validate() error {
@@ -651,6 +854,20 @@ type Parameters struct {
 
 	// MetricsParameters holds configurable parameters for Sesame and Envoy metrics.
 	Metrics MetricsParameters `yaml:"metrics,omitempty"`
+
+	// Webhook holds configurable parameters for the validating admission webhook.
+	Webhook WebhookParameters `yaml:"webhook,omitempty"`
+
+	// CertificateRotation holds configurable parameters for the
+	// automatic rotation of the xDS certificates.
+	CertificateRotation CertificateRotationParameters `yaml:"certificate-rotation,omitempty"`
+
+	// Bootstrap holds configurable parameters for the Envoy bootstrap
+	// configuration written by `sesame bootstrap`.
+	Bootstrap BootstrapParameters `yaml:"bootstrap,omitempty"`
+
+	// HTTP3 holds configurable parameters for the HTTP/3 listener.
+	HTTP3 HTTP3Parameters `yaml:"http3,omitempty"`
 }
 
 // RateLimitService defines properties of a global Rate Limit Service.
@@ -734,12 +951,317 @@ func (p *MetricsServerParameters) HasTLS() bool {
 	return p.ServerCert != "" && p.ServerKey != ""
 }
 
+// WebhookParameters defines configuration for the validating admission webhook.
+// The webhook is only served when a server certificate and key are provided.
+type WebhookParameters struct {
+	// Address that the webhook server will bind to.
+	Address string `yaml:"address,omitempty"`
+
+	// Port that the webhook server will bind to.
+	Port int `yaml:"port,omitempty"`
+
+	// ServerCert is the file path for server certificate.
+	ServerCert string `yaml:"server-certificate-path,omitempty"`
+
+	// ServerKey is the file path for the private key which corresponds to the server certificate.
+	ServerKey string `yaml:"server-key-path,omitempty"`
+}
+
+func (p *WebhookParameters) Validate() error {
+	if (p.ServerCert != "") != (p.ServerKey != "") {
+		return fmt.Errorf("webhook: you must supply both server-certificate-path and server-key-path or none of them")
+	}
+
+	return nil
+}
+
+// Enabled returns true if the webhook should be served.
+func (p *WebhookParameters) Enabled() bool {
+	return p.ServerCert != "" && p.ServerKey != ""
+}
+
+// HTTP3Parameters defines configuration for the HTTP/3 (QUIC) listener.
+type HTTP3Parameters struct {
+	// Enabled enables the HTTP/3 listener.
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// Address that the UDP listener binds to. Defaults to the HTTPS
+	// listener address.
+	Address string `yaml:"address,omitempty"`
+
+	// Port that the UDP listener binds to. Defaults to the HTTPS
+	// listener port.
+	Port int `yaml:"port,omitempty"`
+
+	// AdvertisedPort is the port advertised to clients in the alt-svc
+	// header. Defaults to 443.
+	AdvertisedPort int `yaml:"advertised-port,omitempty"`
+
+	// MaxConcurrentStreams is the maximum number of concurrent streams
+	// per QUIC connection.
+	MaxConcurrentStreams uint32 `yaml:"max-concurrent-streams,omitempty"`
+
+	// InitialStreamWindowSize is the initial stream level flow control
+	// window, in bytes.
+	InitialStreamWindowSize uint32 `yaml:"initial-stream-window-size,omitempty"`
+
+	// InitialConnectionWindowSize is the initial connection level flow
+	// control window, in bytes.
+	InitialConnectionWindowSize uint32 `yaml:"initial-connection-window-size,omitempty"`
+}
+
+func (h *HTTP3Parameters) Validate() error {
+	if h.Port < 0 || h.Port > 65535 {
+		return fmt.Errorf("invalid http3 port %d", h.Port)
+	}
+	if h.AdvertisedPort < 0 || h.AdvertisedPort > 65535 {
+		return fmt.Errorf("invalid http3 advertised-port %d", h.AdvertisedPort)
+	}
+	if h.InitialStreamWindowSize > 16777216 {
+		return fmt.Errorf("http3 initial-stream-window-size %d must not exceed 16777216", h.InitialStreamWindowSize)
+	}
+	if h.InitialConnectionWindowSize > 25165824 {
+		return fmt.Errorf("http3 initial-connection-window-size %d must not exceed 25165824", h.InitialConnectionWindowSize)
+	}
+	return nil
+}
+
+// CertificateRotationParameters defines configuration for the automatic
+// rotation of the xDS certificates.
+type CertificateRotationParameters struct {
+	// Enabled turns on certificate rotation.
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// Namespace of the CA and certificate Secrets. Defaults to the
+	// namespace Sesame is running in.
+	Namespace string `yaml:"namespace,omitempty"`
+
+	// CASecretName is the name of the Secret holding the CA keypair.
+	CASecretName string `yaml:"ca-secret-name,omitempty"`
+
+	// SecretNames are the names of the Secrets holding the certificates to rotate.
+	SecretNames []string `yaml:"secret-names,omitempty"`
+
+	// RenewBefore is how long before expiry a certificate is re-issued.
+	RenewBefore string `yaml:"renew-before,omitempty"`
+
+	// Lifetime of re-issued certificates, in days.
+	Lifetime uint `yaml:"lifetime,omitempty"`
+}
+
+func (p *CertificateRotationParameters) Validate() error {
+	if p.RenewBefore != "" {
+		if _, err := time.ParseDuration(p.RenewBefore); err != nil {
+			return fmt.Errorf("certificate-rotation: invalid renew-before %q: %v", p.RenewBefore, err)
+		}
+	}
+
+	return nil
+}
+
+// BootstrapParameters defines additional Envoy bootstrap configuration.
+type BootstrapParameters struct {
+	// OverloadManager configures the Envoy overload manager.
+	OverloadManager OverloadManagerParameters `yaml:"overload-manager,omitempty"`
+
+	// StatsSinks are additional sinks that Envoy flushes stats to.
+	StatsSinks []StatsSinkParameters `yaml:"stats-sinks,omitempty"`
+
+	// StatsTags are additional rules for extracting tags from stat names.
+	StatsTags []StatsTagParameters `yaml:"stats-tags,omitempty"`
+
+	// Locality is the locality of the Envoy node.
+	Locality LocalityParameters `yaml:"locality,omitempty"`
+
+	// NodeMetadata is opaque metadata about the Envoy node.
+	NodeMetadata map[string]string `yaml:"node-metadata,omitempty"`
+
+	// Runtime holds Envoy runtime keys and values to set in the
+	// static runtime layer.
+	Runtime map[string]string `yaml:"runtime,omitempty"`
+}
+
+// OverloadManagerParameters defines the Envoy overload manager actions
+// that are triggered by heap usage.
+type OverloadManagerParameters struct {
+	// MaxHeapSizeBytes is the heap size that the thresholds are
+	// relative to. Setting it enables the overload manager.
+	MaxHeapSizeBytes uint64 `yaml:"max-heap-size-bytes,omitempty"`
+
+	// ShrinkHeapThreshold is the fraction of the maximum heap size at
+	// which Envoy releases free memory back to the system.
+	ShrinkHeapThreshold float64 `yaml:"shrink-heap-threshold,omitempty"`
+
+	// StopAcceptingRequestsThreshold is the fraction of the maximum
+	// heap size at which Envoy stops accepting new requests.
+	StopAcceptingRequestsThreshold float64 `yaml:"stop-accepting-requests-threshold,omitempty"`
+
+	// StopAcceptingConnectionsThreshold is the fraction of the maximum
+	// heap size at which Envoy stops accepting new connections.
+	StopAcceptingConnectionsThreshold float64 `yaml:"stop-accepting-connections-threshold,omitempty"`
+
+	// DisableKeepaliveThreshold is the fraction of the maximum heap
+	// size at which Envoy disables HTTP keepalive, so that clients
+	// reconnect and spread load to other Envoys.
+	DisableKeepaliveThreshold float64 `yaml:"disable-keepalive-threshold,omitempty"`
+
+	// MaxActiveDownstreamConnections is the maximum number of active
+	// downstream connections across all of Envoy's listeners. It does
+	// not require max-heap-size-bytes.
+	MaxActiveDownstreamConnections uint64 `yaml:"max-active-downstream-connections,omitempty"`
+}
+
+// Enabled returns true if the overload manager should be configured.
+func (o *OverloadManagerParameters) Enabled() bool {
+	return o.MaxHeapSizeBytes > 0
+}
+
+func (o *OverloadManagerParameters) Validate() error {
+	thresholds := []struct {
+		name  string
+		value float64
+	}{
+		{"shrink-heap-threshold", o.ShrinkHeapThreshold},
+		{"stop-accepting-requests-threshold", o.StopAcceptingRequestsThreshold},
+		{"stop-accepting-connections-threshold", o.StopAcceptingConnectionsThreshold},
+		{"disable-keepalive-threshold", o.DisableKeepaliveThreshold},
+	}
+
+	for _, t := range thresholds {
+		if t.value == 0 {
+			continue
+		}
+		if t.value < 0 || t.value > 1 {
+			return fmt.Errorf("invalid overload manager %s %v, must be between 0 and 1", t.name, t.value)
+		}
+		if !o.Enabled() {
+			return fmt.Errorf("overload manager %s requires max-heap-size-bytes", t.name)
+		}
+	}
+
+	return nil
+}
+
+// StatsSinkType is the type of an Envoy stats sink.
+type StatsSinkType string
+
+const (
+	StatsdSink         StatsSinkType = "statsd"
+	DogStatsdSink      StatsSinkType = "dogstatsd"
+	MetricsServiceSink StatsSinkType = "metrics-service"
+)
+
+// StatsSinkParameters defines an Envoy stats sink.
+type StatsSinkParameters struct {
+	// Type is the type of the sink: statsd, dogstatsd or metrics-service.
+	Type StatsSinkType `yaml:"type"`
+
+	// Address of the sink. For statsd and dogstatsd sinks, this must
+	// be an IP address. For metrics-service sinks, it may also be a
+	// DNS name.
+	Address string `yaml:"address"`
+
+	// Port of the sink.
+	Port int `yaml:"port"`
+
+	// Prefix is an optional prefix for stat names sent to statsd and
+	// dogstatsd sinks.
+	Prefix string `yaml:"prefix,omitempty"`
+}
+
+func (s *StatsSinkParameters) Validate() error {
+	switch s.Type {
+	case StatsdSink, DogStatsdSink:
+		if net.ParseIP(s.Address) == nil {
+			return fmt.Errorf("invalid %s stats sink address %q, must be an IP address", s.Type, s.Address)
+		}
+	case MetricsServiceSink:
+		if s.Address == "" {
+			return fmt.Errorf("metrics-service stats sink requires an address")
+		}
+		if s.Prefix != "" {
+			return fmt.Errorf("metrics-service stats sink does not support a prefix")
+		}
+	default:
+		return fmt.Errorf("invalid stats sink type %q", s.Type)
+	}
+
+	if s.Port <= 0 || s.Port > 65535 {
+		return fmt.Errorf("invalid %s stats sink port %d", s.Type, s.Port)
+	}
+
+	return nil
+}
+
+// StatsTagParameters defines a rule for extracting a tag from stat names.
+type StatsTagParameters struct {
+	// Name is the tag name.
+	Name string `yaml:"name"`
+
+	// Regex extracts the tag value from the stat name. The first
+	// capture group is the tag value, and is removed from the name.
+	Regex string `yaml:"regex,omitempty"`
+
+	// FixedValue is added as the tag value to all stats.
+	FixedValue string `yaml:"fixed-value,omitempty"`
+}
+
+func (t *StatsTagParameters) Validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("stats tag requires a name")
+	}
+	if (t.Regex == "") == (t.FixedValue == "") {
+		return fmt.Errorf("stats tag %q must have exactly one of regex or fixed-value", t.Name)
+	}
+	if t.Regex != "" {
+		if _, err := regexp.Compile(t.Regex); err != nil {
+			return fmt.Errorf("invalid stats tag %q regex: %v", t.Name, err)
+		}
+	}
+	return nil
+}
+
+// LocalityParameters defines the locality of the Envoy node.
+type LocalityParameters struct {
+	Region  string `yaml:"region,omitempty"`
+	Zone    string `yaml:"zone,omitempty"`
+	SubZone string `yaml:"sub-zone,omitempty"`
+}
+
+func (p *BootstrapParameters) Validate() error {
+	if err := p.OverloadManager.Validate(); err != nil {
+		return err
+	}
+
+	for i := range p.StatsSinks {
+		if err := p.StatsSinks[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	for i := range p.StatsTags {
+		if err := p.StatsTags[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // Validate verifies that the parameter values do not have any syntax errors.
 func (p *Parameters) Validate() error {
 	if err := p.Cluster.DNSLookupFamily.Validate(); err != nil {
 		return err
 	}
 
+	if err := p.Cluster.ConnectionPolicy.Validate(); err != nil {
+		return err
+	}
+
+	if err := p.Cluster.RetryBudget.Validate(); err != nil {
+		return err
+	}
+
 	if err := p.Server.XDSServerType.Validate(); err != nil {
 		return err
 	}
@@ -782,6 +1304,22 @@ func (p *Parameters) Validate() error {
 		return err
 	}
 
+	if err := p.Webhook.Validate(); err != nil {
+		return err
+	}
+
+	if err := p.CertificateRotation.Validate(); err != nil {
+		return err
+	}
+
+	if err := p.Bootstrap.Validate(); err != nil {
+		return err
+	}
+
+	if err := p.HTTP3.Validate(); err != nil {
+		return err
+	}
+
 	return p.Listener.Validate()
 }
 
@@ -140,6 +140,16 @@ func TestValidateHeadersPolicy(t *testing.T) {
 	assert.Error(t, HeadersPolicy{
 		Remove: []string{"inv@lid-header"},
 	}.Validate())
+	assert.Error(t, HeadersPolicy{
+		Add: map[string]string{
+			"inv@lid-header": "ook",
+		},
+	}.Validate())
+	assert.Error(t, HeadersPolicy{
+		AddIfAbsent: map[string]string{
+			"inv@lid-header": "ook",
+		},
+	}.Validate())
 	assert.NoError(t, HeadersPolicy{
 		Set:    map[string]string{},
 		Remove: []string{},
@@ -186,6 +196,34 @@ func TestValidateGatewayParameters(t *testing.T) {
 	// ControllerName is required.
 	gw = &GatewayParameters{ControllerName: "controller"}
 	assert.Equal(t, nil, gw.Validate())
+
+	// GatewayRef needs both a name and a namespace.
+	gw.GatewayRef = &NamespacedName{Name: "gateway"}
+	assert.Error(t, gw.Validate())
+
+	gw.GatewayRef.Namespace = "projectsesame"
+	assert.Equal(t, nil, gw.Validate())
+
+	// Gateway mappings need a Gateway name and namespace.
+	gw.Gateways = []GatewayEnvoyMapping{{Gateway: NamespacedName{Name: "internal"}}}
+	assert.Error(t, gw.Validate())
+
+	gw.Gateways[0].Gateway.Namespace = "projectsesame"
+	assert.Equal(t, nil, gw.Validate())
+
+	// Listener ports must be valid.
+	gw.Gateways[0].HTTPListener = &GatewayListenerParameters{Port: 0}
+	assert.Error(t, gw.Validate())
+
+	gw.Gateways[0].HTTPListener.Port = 9080
+	assert.Equal(t, nil, gw.Validate())
+
+	// Services need both a name and a namespace.
+	gw.Gateways[0].Service = &NamespacedName{Name: "envoy-internal"}
+	assert.Error(t, gw.Validate())
+
+	gw.Gateways[0].Service.Namespace = "projectsesame"
+	assert.Equal(t, nil, gw.Validate())
 }
 
 func TestValidateAccessLogType(t *testing.T) {
@@ -336,6 +374,12 @@ func TestTLSParametersValidation(t *testing.T) {
 			"AES128-GCM-SHA256",
 		},
 	}.Validate())
+
+	// Certificate expiry warning validation
+	assert.NoError(t, TLSParameters{CertificateExpiryWarning: "168h"}.Validate())
+	assert.NoError(t, TLSParameters{CertificateExpiryWarning: "0s"}.Validate())
+	assert.Error(t, TLSParameters{CertificateExpiryWarning: "a week"}.Validate())
+	assert.Error(t, TLSParameters{CertificateExpiryWarning: "-1h"}.Validate())
 }
 
 func TestSanitizeCipherSuites(t *testing.T) {
@@ -534,6 +578,161 @@ network:
   num-trusted-hops: 1
   admin-port: 9001
 `)
+
+	check(func(t *testing.T, conf *Parameters) {
+		assert.Equal(t, BootstrapParameters{
+			OverloadManager: OverloadManagerParameters{
+				MaxHeapSizeBytes:               1073741824,
+				ShrinkHeapThreshold:            0.95,
+				StopAcceptingRequestsThreshold: 0.98,
+			},
+			StatsSinks: []StatsSinkParameters{{
+				Type:    DogStatsdSink,
+				Address: "127.0.0.1",
+				Port:    8125,
+				Prefix:  "envoy",
+			}},
+			StatsTags: []StatsTagParameters{{
+				Name:       "cluster_name",
+				FixedValue: "production",
+			}},
+			Locality: LocalityParameters{
+				Region: "eu-west-1",
+				Zone:   "eu-west-1a",
+			},
+			NodeMetadata: map[string]string{"team": "platform"},
+			Runtime:      map[string]string{"overload.global_downstream_max_connections": "50000"},
+		}, conf.Bootstrap)
+	}, `
+bootstrap:
+  overload-manager:
+    max-heap-size-bytes: 1073741824
+    shrink-heap-threshold: 0.95
+    stop-accepting-requests-threshold: 0.98
+  stats-sinks:
+  - type: dogstatsd
+    address: 127.0.0.1
+    port: 8125
+    prefix: envoy
+  stats-tags:
+  - name: cluster_name
+    fixed-value: production
+  locality:
+    region: eu-west-1
+    zone: eu-west-1a
+  node-metadata:
+    team: platform
+  runtime:
+    overload.global_downstream_max_connections: "50000"
+`)
+}
+
+func TestBootstrapParametersValidation(t *testing.T) {
+	assert.NoError(t, (&BootstrapParameters{}).Validate())
+
+	tests := map[string]struct {
+		params BootstrapParameters
+		want   string
+	}{
+		"threshold without max heap": {
+			params: BootstrapParameters{
+				OverloadManager: OverloadManagerParameters{ShrinkHeapThreshold: 0.9},
+			},
+			want: "overload manager shrink-heap-threshold requires max-heap-size-bytes",
+		},
+		"threshold out of range": {
+			params: BootstrapParameters{
+				OverloadManager: OverloadManagerParameters{MaxHeapSizeBytes: 1024, StopAcceptingConnectionsThreshold: 1.5},
+			},
+			want: "invalid overload manager stop-accepting-connections-threshold 1.5, must be between 0 and 1",
+		},
+		"disable keepalive without max heap": {
+			params: BootstrapParameters{
+				OverloadManager: OverloadManagerParameters{MaxActiveDownstreamConnections: 10000, DisableKeepaliveThreshold: 0.9},
+			},
+			want: "overload manager disable-keepalive-threshold requires max-heap-size-bytes",
+		},
+		"statsd sink with DNS name": {
+			params: BootstrapParameters{
+				StatsSinks: []StatsSinkParameters{{Type: StatsdSink, Address: "statsd.monitoring", Port: 8125}},
+			},
+			want: `invalid statsd stats sink address "statsd.monitoring", must be an IP address`,
+		},
+		"metrics service sink without port": {
+			params: BootstrapParameters{
+				StatsSinks: []StatsSinkParameters{{Type: MetricsServiceSink, Address: "metrics.monitoring"}},
+			},
+			want: "invalid metrics-service stats sink port 0",
+		},
+		"unknown sink type": {
+			params: BootstrapParameters{
+				StatsSinks: []StatsSinkParameters{{Type: "hystrix"}},
+			},
+			want: `invalid stats sink type "hystrix"`,
+		},
+		"stats tag with regex and fixed value": {
+			params: BootstrapParameters{
+				StatsTags: []StatsTagParameters{{Name: "foo", Regex: "^foo\\.(.+?)\\.", FixedValue: "bar"}},
+			},
+			want: `stats tag "foo" must have exactly one of regex or fixed-value`,
+		},
+		"stats tag with invalid regex": {
+			params: BootstrapParameters{
+				StatsTags: []StatsTagParameters{{Name: "foo", Regex: "("}},
+			},
+			want: "invalid stats tag \"foo\" regex: error parsing regexp: missing closing ): `(`",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := tc.params.Validate()
+			require.Error(t, err)
+			assert.Equal(t, tc.want, err.Error())
+		})
+	}
+
+	valid := BootstrapParameters{
+		OverloadManager: OverloadManagerParameters{MaxHeapSizeBytes: 1024, ShrinkHeapThreshold: 0.95, DisableKeepaliveThreshold: 0.9},
+		StatsSinks: []StatsSinkParameters{
+			{Type: StatsdSink, Address: "10.0.0.1", Port: 8125},
+			{Type: MetricsServiceSink, Address: "metrics.monitoring", Port: 9000},
+		},
+		StatsTags: []StatsTagParameters{{Name: "foo", Regex: "^foo\\.(.+?)\\."}},
+	}
+	assert.NoError(t, valid.Validate())
+}
+
+func TestHTTP3ParametersValidation(t *testing.T) {
+	assert.NoError(t, (&HTTP3Parameters{}).Validate())
+	assert.NoError(t, (&HTTP3Parameters{Enabled: true, Port: 8443, AdvertisedPort: 443, InitialStreamWindowSize: 1048576}).Validate())
+
+	assert.EqualError(t, (&HTTP3Parameters{Port: 70000}).Validate(), "invalid http3 port 70000")
+	assert.EqualError(t, (&HTTP3Parameters{AdvertisedPort: -1}).Validate(), "invalid http3 advertised-port -1")
+	assert.EqualError(t, (&HTTP3Parameters{InitialConnectionWindowSize: 33554432}).Validate(),
+		"http3 initial-connection-window-size 33554432 must not exceed 25165824")
+}
+
+func TestConnectionPolicyParametersValidation(t *testing.T) {
+	assert.NoError(t, ConnectionPolicyParameters{}.Validate())
+	assert.NoError(t, ConnectionPolicyParameters{MaxConcurrentStreams: 100, IdleTimeout: "1h"}.Validate())
+	assert.NoError(t, ConnectionPolicyParameters{IdleTimeout: "infinity"}.Validate())
+
+	assert.EqualError(t, ConnectionPolicyParameters{MaxConcurrentStreams: 1 << 31}.Validate(),
+		"invalid max concurrent streams 2147483648: must be at most 2147483647")
+	assert.Error(t, ConnectionPolicyParameters{IdleTimeout: "90"}.Validate())
+}
+
+func TestRetryBudgetParametersValidation(t *testing.T) {
+	var nilBudget *RetryBudgetParameters
+	assert.NoError(t, nilBudget.Validate())
+
+	percent := uint32(20)
+	assert.NoError(t, (&RetryBudgetParameters{BudgetPercent: &percent}).Validate())
+
+	percent = 101
+	assert.EqualError(t, (&RetryBudgetParameters{BudgetPercent: &percent}).Validate(),
+		"invalid retry budget percent 101: must be at most 100")
 }
 
 func TestAccessLogFormatString(t *testing.T) {
@@ -648,6 +847,43 @@ func TestMetricsParametersValidation(t *testing.T) {
 
 }
 
+func TestWebhookParametersValidation(t *testing.T) {
+	disabled := WebhookParameters{}
+	assert.NoError(t, disabled.Validate())
+	assert.False(t, disabled.Enabled())
+
+	enabled := WebhookParameters{
+		Address:    "0.0.0.0",
+		Port:       9443,
+		ServerCert: "cert.pem",
+		ServerKey:  "key.pem",
+	}
+	assert.NoError(t, enabled.Validate())
+	assert.True(t, enabled.Enabled())
+
+	keyMissing := WebhookParameters{
+		ServerCert: "cert.pem",
+	}
+	assert.Error(t, keyMissing.Validate())
+}
+
+func TestCertificateRotationParametersValidation(t *testing.T) {
+	disabled := CertificateRotationParameters{}
+	assert.NoError(t, disabled.Validate())
+
+	enabled := CertificateRotationParameters{
+		Enabled:     true,
+		RenewBefore: "168h",
+	}
+	assert.NoError(t, enabled.Validate())
+
+	invalidRenewBefore := CertificateRotationParameters{
+		Enabled:     true,
+		RenewBefore: "7d",
+	}
+	assert.Error(t, invalidRenewBefore.Validate())
+}
+
 func TestListenerValidation(t *testing.T) {
 	var l *ListenerParameters
 	require.NoError(t, l.Validate())